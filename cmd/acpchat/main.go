@@ -79,6 +79,7 @@ func main() {
 	mode := flag.String("mode", "code", "session mode: ask, architect, code")
 	model := flag.String("model", "", "model override")
 	system := flag.String("system", "", "system prompt")
+	agents := flag.String("agents", "", "agent registry file (JSON or YAML) declaring additional subprocess agents")
 	flag.Parse()
 
 	// Initial prompt from positional args or stdin.
@@ -103,7 +104,16 @@ func main() {
 
 	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
 
-	config, err := agentConfig(*agent)
+	var registry []v2.AgentConfig
+	if *agents != "" {
+		registry, err = v2.LoadAgentRegistry(*agents)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	config, err := agentConfig(*agent, registry)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "error: %v\n", err)
 		os.Exit(1)
@@ -206,6 +216,10 @@ func main() {
 			if raw, ok := tc.RawInput.(map[string]any); ok {
 				if _, isPerm := raw["_permissionRequest"]; isPerm {
 					permCh <- id
+					if _, isBatch := raw["_permissionBatch"]; isBatch {
+						fmt.Fprintf(os.Stderr, "\033[33m[permission batch: %s → auto-approved]\033[0m\n", tc.Title)
+						return
+					}
 					fmt.Fprintf(os.Stderr, "\033[33m[permission: %s → auto-approved]\033[0m\n", tc.Title)
 					return
 				}
@@ -273,6 +287,12 @@ func main() {
 		}
 	}
 
+	mcpServers, err := v2.LoadMCPServersFromEnv()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+
 	statusCh := make(chan v2.SessionStatus, 8)
 
 	fmt.Fprintf(os.Stderr, "launching %s session (mode=%s, cwd=%s)...\n", *agent, *mode, absCwd)
@@ -283,7 +303,7 @@ func main() {
 		Model:        *model,
 		Cwd:          absCwd,
 		SessionMode:  *mode,
-		MCPServers:   []acp.McpServer{},
+		MCPServers:   v2.MergeMCPServers([]acp.McpServer{}, mcpServers),
 		StatusCh:     statusCh,
 	}, onEvent)
 	if err != nil {
@@ -311,6 +331,7 @@ func main() {
 
 	if ctx.Err() != nil {
 		_ = sess.Stop(context.Background())
+		_ = sess.Wait(context.Background())
 		return
 	}
 
@@ -332,7 +353,7 @@ func main() {
 		}
 
 		spin.start()
-		if cmdName, _, ok := parseSlashCommand(input); ok {
+		if cmdName, _, ok := v2.ParseSlashCommand(input); ok {
 			fmt.Fprintf(os.Stderr, "\033[2m[slash command sent via session/prompt: /%s]\033[0m\n", cmdName)
 			if _, known := availableCommands[cmdName]; !known {
 				fmt.Fprintf(os.Stderr, "\033[2m[slash command not in latest availableCommands: /%s]\033[0m\n", cmdName)
@@ -354,6 +375,7 @@ func main() {
 
 	fmt.Fprintln(os.Stderr, "\nstopping session...")
 	_ = sess.Stop(context.Background())
+	_ = sess.Wait(context.Background())
 }
 
 // waitForIdle waits for the session to reach idle, stopped, or errored status
@@ -483,7 +505,21 @@ func printContent(content []acp.ToolCallContent) {
 	}
 }
 
-func agentConfig(name string) (v2.AgentConfig, error) {
+// agentConfig returns the config for the named agent. Agents declared in
+// registry take precedence over the built-in subprocess agents; the
+// in-process agents cannot be redeclared.
+func agentConfig(name string, registry []v2.AgentConfig) (v2.AgentConfig, error) {
+	for _, cfg := range registry {
+		if cfg.AgentID == v2.ClaudeCodeConfig.AgentID || cfg.AgentID == v2.CodexConfig.AgentID {
+			return v2.AgentConfig{}, fmt.Errorf("agent registry: %s is an in-process agent and cannot be redeclared", cfg.AgentID)
+		}
+	}
+	for _, cfg := range registry {
+		if cfg.AgentID == name {
+			return cfg, nil
+		}
+	}
+
 	switch name {
 	case "claude-code":
 		cfg := v2.ClaudeCodeConfig
@@ -498,7 +534,7 @@ func agentConfig(name string) (v2.AgentConfig, error) {
 	case "gemini":
 		return v2.GeminiConfig, nil
 	default:
-		return v2.AgentConfig{}, fmt.Errorf("unknown agent: %s (use claude-code, codex, opencode, gemini)", name)
+		return v2.AgentConfig{}, fmt.Errorf("unknown agent: %s (use claude-code, codex, opencode, gemini or an agent from -agents)", name)
 	}
 }
 
@@ -510,25 +546,6 @@ func stdinHasData() bool {
 	return (fi.Mode() & os.ModeCharDevice) == 0
 }
 
-func parseSlashCommand(input string) (name, arg string, ok bool) {
-	if !strings.HasPrefix(input, "/") {
-		return "", "", false
-	}
-	trimmed := strings.TrimSpace(strings.TrimPrefix(input, "/"))
-	if trimmed == "" {
-		return "", "", false
-	}
-	parts := strings.SplitN(trimmed, " ", 2)
-	name = strings.TrimSpace(parts[0])
-	if name == "" {
-		return "", "", false
-	}
-	if len(parts) == 2 {
-		arg = strings.TrimSpace(parts[1])
-	}
-	return name, arg, true
-}
-
 func rawFallback(update acp.SessionUpdate, marshalErr error) map[string]any {
 	out := map[string]any{
 		"marshalError": marshalErr.Error(),
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"sync"
+)
+
+const mockAskQuestionAnswer = "Mock response: no live user input is available in this mode; proceed with sensible defaults."
+
+// pendingQuestions tracks ask_question calls waiting for an answer. A
+// question stays registered only while its caller waits: it is removed when
+// answered or when the caller's context is cancelled.
+type pendingQuestions struct {
+	mu      sync.Mutex
+	next    int
+	pending map[string]chan string
+
+	// deliver hands a newly registered question to whoever answers it.
+	deliver func(id, question string)
+}
+
+func newPendingQuestions(deliver func(id, question string)) *pendingQuestions {
+	return &pendingQuestions{
+		pending: make(map[string]chan string),
+		deliver: deliver,
+	}
+}
+
+// ask registers question and blocks until it is answered or ctx is done.
+func (p *pendingQuestions) ask(ctx context.Context, question string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	ch := make(chan string, 1)
+	p.mu.Lock()
+	p.next++
+	id := strconv.Itoa(p.next)
+	p.pending[id] = ch
+	p.mu.Unlock()
+	defer p.remove(id)
+
+	p.deliver(id, question)
+
+	select {
+	case answer := <-ch:
+		return answer, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// answer resolves the pending question id. It reports false if the question
+// is not pending, e.g. because its caller gave up.
+func (p *pendingQuestions) answer(id, answer string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	ch, ok := p.pending[id]
+	if !ok {
+		return false
+	}
+	delete(p.pending, id)
+	ch <- answer
+	return true
+}
+
+func (p *pendingQuestions) remove(id string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.pending, id)
+}
+
+func (p *pendingQuestions) len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.pending)
+}
+
+// newMockQuestions answers every question immediately with a fixed mock
+// response; no live user input is wired to agentctl yet.
+func newMockQuestions() *pendingQuestions {
+	var p *pendingQuestions
+	p = newPendingQuestions(func(id, _ string) {
+		p.answer(id, mockAskQuestionAnswer)
+	})
+	return p
+}
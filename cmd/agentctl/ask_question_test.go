@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPendingQuestions_Answer(t *testing.T) {
+	delivered := make(chan string, 1)
+	p := newPendingQuestions(func(id, question string) {
+		assert.Equal(t, "Which database?", question)
+		delivered <- id
+	})
+
+	done := make(chan string, 1)
+	go func() {
+		answer, err := p.ask(context.Background(), "Which database?")
+		assert.NoError(t, err)
+		done <- answer
+	}()
+
+	id := <-delivered
+	require.True(t, p.answer(id, "SQLite"))
+	assert.Equal(t, "SQLite", <-done)
+	assert.Zero(t, p.len())
+	assert.False(t, p.answer(id, "again"), "answered questions are no longer pending")
+}
+
+func TestPendingQuestions_CancelRemovesPending(t *testing.T) {
+	delivered := make(chan string, 1)
+	p := newPendingQuestions(func(id, _ string) { delivered <- id })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := p.ask(ctx, "Still there?")
+		errCh <- err
+	}()
+
+	id := <-delivered
+	assert.Equal(t, 1, p.len())
+	cancel()
+
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("ask did not return after cancel")
+	}
+	assert.Zero(t, p.len(), "cancelled question is not leaked")
+	assert.False(t, p.answer(id, "late"), "late answers are dropped")
+}
+
+func TestMockQuestions(t *testing.T) {
+	p := newMockQuestions()
+	answer, err := p.ask(context.Background(), "Anything?")
+	require.NoError(t, err)
+	assert.Equal(t, mockAskQuestionAnswer, answer)
+	assert.Zero(t, p.len())
+}
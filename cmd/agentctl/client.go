@@ -1,19 +1,32 @@
 package main
 
 import (
-	"net/http"
+	"fmt"
 	"os"
 
 	"connectrpc.com/connect"
+	"github.com/sebastianm/flowgentic/internal/connectutil"
 	workerv1connect "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1/workerv1connect"
+	"github.com/sebastianm/flowgentic/internal/tlsutil"
 	"github.com/sebastianm/flowgentic/internal/worker/interceptors"
 )
 
+// setClientTLS configures TLS for https worker URLs from the
+// AGENTCTL_TLS_CA_FILE, AGENTCTL_TLS_CERT_FILE and AGENTCTL_TLS_KEY_FILE env.
+func setClientTLS() error {
+	cfg, err := tlsutil.ClientConfig(tlsutil.FromEnv("AGENTCTL"))
+	if err != nil {
+		return fmt.Errorf("agentctl: %w", err)
+	}
+	connectutil.SetClientTLS(cfg)
+	return nil
+}
+
 func newAgentCtlClient() workerv1connect.AgentCtlServiceClient {
 	workerURL := os.Getenv("AGENTCTL_WORKER_URL")
 	var opts []connect.ClientOption
 	if secret := os.Getenv("AGENTCTL_WORKER_SECRET"); secret != "" {
 		opts = append(opts, connect.WithInterceptors(interceptors.NewAuth(secret)))
 	}
-	return workerv1connect.NewAgentCtlServiceClient(http.DefaultClient, workerURL, opts...)
+	return workerv1connect.NewAgentCtlServiceClient(connectutil.Client, workerURL, opts...)
 }
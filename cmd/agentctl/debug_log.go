@@ -0,0 +1,149 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+const (
+	// agentCtlLogDirEnv overrides the directory of the MCP server's debug
+	// log; it defaults to os.TempDir().
+	agentCtlLogDirEnv = "AGENTCTL_LOG_DIR"
+	// agentCtlLogMaxBytesEnv is the size at which the debug log is rotated;
+	// a negative value disables rotation.
+	agentCtlLogMaxBytesEnv = "AGENTCTL_LOG_MAX_BYTES"
+	// agentCtlLogMaxFilesEnv is how many rotated debug logs are kept.
+	agentCtlLogMaxFilesEnv = "AGENTCTL_LOG_MAX_FILES"
+
+	debugLogName            = "flowgentic-agentctl-mcp.log"
+	defaultDebugLogMaxBytes = 10 << 20
+	defaultDebugLogMaxFiles = 3
+)
+
+// openDebugLog opens the debug log configured by the AGENTCTL_LOG_* env.
+func openDebugLog() (*rotatingLog, error) {
+	dir := os.Getenv(agentCtlLogDirEnv)
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	maxBytes, err := envInt(agentCtlLogMaxBytesEnv, defaultDebugLogMaxBytes)
+	if err != nil {
+		return nil, err
+	}
+	maxFiles, err := envInt(agentCtlLogMaxFilesEnv, defaultDebugLogMaxFiles)
+	if err != nil {
+		return nil, err
+	}
+	return openRotatingLog(filepath.Join(dir, debugLogName), int64(maxBytes), max(maxFiles, 0))
+}
+
+// envInt returns the integer in the env var key, or def when it is unset or 0.
+func envInt(key string, def int) (int, error) {
+	v := os.Getenv(key)
+	if v == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	if n == 0 {
+		return def, nil
+	}
+	return n, nil
+}
+
+// rotatingLog appends to a log file and rotates it by size: a write that
+// would take the file past maxBytes first renames it to path.1, shifting
+// older files up to path.<maxFiles> and dropping the oldest. Every agentctl
+// process of a worker appends to the same file, so a write reopens the path
+// when another process has rotated it.
+type rotatingLog struct {
+	mu       sync.Mutex
+	path     string
+	maxBytes int64 // negative disables rotation
+	maxFiles int
+	f        *os.File
+}
+
+func openRotatingLog(path string, maxBytes int64, maxFiles int) (*rotatingLog, error) {
+	l := &rotatingLog{path: path, maxBytes: maxBytes, maxFiles: maxFiles}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+func (l *rotatingLog) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.reopenIfRotated(); err != nil {
+		return 0, err
+	}
+	if l.maxBytes >= 0 {
+		info, err := l.f.Stat()
+		if err != nil {
+			return 0, fmt.Errorf("stat debug log: %w", err)
+		}
+		if info.Size() > 0 && info.Size()+int64(len(p)) > l.maxBytes {
+			if err := l.rotate(); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return l.f.Write(p)
+}
+
+// Close closes the log file.
+func (l *rotatingLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.f.Close()
+}
+
+func (l *rotatingLog) open() error {
+	f, err := os.OpenFile(l.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open debug log: %w", err)
+	}
+	l.f = f
+	return nil
+}
+
+// reopenIfRotated reopens the path if it no longer names the open file.
+func (l *rotatingLog) reopenIfRotated() error {
+	cur, err := l.f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat debug log: %w", err)
+	}
+	if info, err := os.Stat(l.path); err == nil && os.SameFile(cur, info) {
+		return nil
+	}
+	_ = l.f.Close()
+	return l.open()
+}
+
+func (l *rotatingLog) rotate() error {
+	_ = l.f.Close()
+	for i := l.maxFiles - 1; i >= 1; i-- {
+		err := os.Rename(fmt.Sprintf("%s.%d", l.path, i), fmt.Sprintf("%s.%d", l.path, i+1))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("rotate debug log: %w", err)
+		}
+	}
+	var err error
+	if l.maxFiles > 0 {
+		err = os.Rename(l.path, l.path+".1")
+	} else {
+		err = os.Remove(l.path)
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("rotate debug log: %w", err)
+	}
+	return l.open()
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingLog_RotatesPastSizeCap(t *testing.T) {
+	path := filepath.Join(t.TempDir(), debugLogName)
+	l, err := openRotatingLog(path, 100, 2)
+	require.NoError(t, err)
+	defer l.Close()
+
+	for i := range 20 {
+		_, err := fmt.Fprintf(l, "line %02d %s\n", i, "0123456789012345678901234567890")
+		require.NoError(t, err)
+	}
+
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(p)
+		require.NoError(t, err, p)
+		assert.LessOrEqual(t, info.Size(), int64(100), p)
+	}
+	assert.NoFileExists(t, path+".3", "only maxFiles rotated logs are kept")
+
+	var all string
+	for _, p := range []string{path + ".2", path + ".1", path} {
+		data, err := os.ReadFile(p)
+		require.NoError(t, err)
+		all += string(data)
+	}
+	assert.NotContains(t, all, "line 00", "the oldest lines are trimmed")
+	assert.Contains(t, all, "line 19")
+}
+
+func TestRotatingLog_NoRotationWhenDisabled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), debugLogName)
+	l, err := openRotatingLog(path, -1, 2)
+	require.NoError(t, err)
+	defer l.Close()
+
+	for range 10 {
+		_, err := l.Write(make([]byte, 50))
+		require.NoError(t, err)
+	}
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, int64(500), info.Size())
+	assert.NoFileExists(t, path+".1")
+}
+
+func TestRotatingLog_ReopensAfterOtherProcessRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), debugLogName)
+	l, err := openRotatingLog(path, 1000, 1)
+	require.NoError(t, err)
+	defer l.Close()
+
+	_, err = l.Write([]byte("before\n"))
+	require.NoError(t, err)
+	require.NoError(t, os.Rename(path, path+".1"))
+	_, err = l.Write([]byte("after\n"))
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "after\n", string(data))
+}
+
+func TestOpenDebugLog_Env(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(agentCtlLogDirEnv, dir)
+	t.Setenv(agentCtlLogMaxBytesEnv, "4096")
+	t.Setenv(agentCtlLogMaxFilesEnv, "5")
+
+	l, err := openDebugLog()
+	require.NoError(t, err)
+	defer l.Close()
+	assert.Equal(t, filepath.Join(dir, debugLogName), l.path)
+	assert.Equal(t, int64(4096), l.maxBytes)
+	assert.Equal(t, 5, l.maxFiles)
+
+	t.Setenv(agentCtlLogMaxBytesEnv, "lots")
+	_, err = openDebugLog()
+	assert.ErrorContains(t, err, agentCtlLogMaxBytesEnv)
+}
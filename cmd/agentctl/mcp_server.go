@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -15,8 +16,9 @@ import (
 )
 
 type mcpServer struct {
-	server *mcp.Server
-	log    *log.Logger
+	server    *mcp.Server
+	log       *log.Logger
+	questions *pendingQuestions
 
 	setTopicFn          func(context.Context, string) error
 	askQuestionFn       func(context.Context, string) (string, error)
@@ -25,24 +27,29 @@ type mcpServer struct {
 	planRemoveThreadFn  func(string) error
 	planClearCurrentFn  func() error
 	planCommitFn        func(context.Context, string) (int, error)
+	progressFn          func(context.Context, string, *int) error
 }
 
 func newMCPServer() *mcpServer {
-	logFile, err := os.OpenFile("/tmp/flowgentic-agentctl-mcp.log", os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
-	if err != nil {
-		logFile = os.Stderr
+	var logFile io.Writer = os.Stderr
+	if debugLog, err := openDebugLog(); err == nil {
+		logFile = debugLog
+	} else {
+		fmt.Fprintf(os.Stderr, "agentctl: %v\n", err)
 	}
 
 	s := &mcpServer{
 		log:                 log.New(logFile, "", 0),
+		questions:           newMockQuestions(),
 		setTopicFn:          runSetTopic,
-		askQuestionFn:       mockAskQuestion,
 		planGetCurrentDirFn: planGetCurrentDir,
 		planRequestDirFn:    planRequestThreadDir,
 		planRemoveThreadFn:  planRemoveThread,
 		planClearCurrentFn:  planClearCurrent,
 		planCommitFn:        planCommit,
+		progressFn:          runReportProgress,
 	}
+	s.askQuestionFn = s.questions.ask
 	s.server = mcp.NewServer(&mcp.Implementation{
 		Name:    "agentctl",
 		Version: "1.0.0",
@@ -51,52 +58,90 @@ func newMCPServer() *mcpServer {
 	return s
 }
 
+// inputDrainTimeout bounds how long serve waits for the input adapter after
+// the server stopped for another reason than stdin closing. A read from an
+// open stdin cannot be interrupted.
+const inputDrainTimeout = time.Second
+
 func (s *mcpServer) Run(ctx context.Context) error {
+	return s.serve(ctx, os.Stdin, os.Stdout)
+}
+
+// serve runs the MCP server over stdin and stdout until ctx is cancelled or
+// stdin closes. Stdin closing means the parent agent is gone, so the server
+// is stopped even if a tool call is still in flight.
+func (s *mcpServer) serve(ctx context.Context, stdin io.Reader, stdout io.Writer) error {
 	s.logf("mcp serve start")
 
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
 	inR, inW := io.Pipe()
 	outR, outW := io.Pipe()
 
 	modeCh := make(chan bool, 1) // true = content-length framing, false = newline-delimited
-	errCh := make(chan error, 2)
+	inErrCh := make(chan error, 1)
+	outErrCh := make(chan error, 1)
 
 	go func() {
-		errCh <- adaptInput(os.Stdin, inW, modeCh)
+		inErrCh <- adaptInput(stdin, inW, modeCh, s.logf)
+		s.logf("stdin closed, stopping server")
+		cancel()
 	}()
 	go func() {
-		headerMode := <-modeCh
+		var headerMode bool
+		select {
+		case headerMode = <-modeCh:
+		case <-runCtx.Done():
+			// Stopped before any input arrived, so there is nothing to
+			// frame, unless the mode was detected just as it stopped.
+			select {
+			case headerMode = <-modeCh:
+			default:
+				outErrCh <- nil
+				return
+			}
+		}
 		if headerMode {
 			s.logf("framing detected: content-length")
 		} else {
 			s.logf("framing detected: newline")
 		}
-		errCh <- adaptOutput(outR, os.Stdout, headerMode)
+		outErrCh <- adaptOutput(outR, stdout, headerMode)
 	}()
 
-	runErr := s.server.Run(ctx, &mcp.IOTransport{
+	runErr := s.server.Run(runCtx, &mcp.IOTransport{
 		Reader: inR,
 		Writer: outW,
 	})
-	if runErr != nil {
-		s.logf("server run error: %v", runErr)
-	}
 	_ = inR.Close()
 	_ = outW.Close()
+	outErr := <-outErrCh // adaptOutput returns once outW is closed
 	_ = outR.Close()
-	_ = inW.Close()
 
+	var inErr error
 	select {
-	case adaptErr := <-errCh:
-		if runErr != nil {
-			return runErr
-		}
-		if adaptErr != nil && adaptErr != io.EOF {
-			s.logf("adapter error: %v", adaptErr)
-			return adaptErr
-		}
-	default:
+	case inErr = <-inErrCh:
+	case <-ctx.Done():
+	case <-time.After(inputDrainTimeout):
+		s.logf("stdin still open after server stopped, not waiting for it")
+	}
+	_ = inW.Close()
+
+	// Cancellation caused by stdin closing is a clean shutdown.
+	if runErr != nil && !(ctx.Err() == nil && errors.Is(runErr, context.Canceled)) {
+		s.logf("server run error: %v", runErr)
+		return runErr
+	}
+	if inErr != nil {
+		s.logf("input adapter error: %v", inErr)
+		return inErr
+	}
+	if outErr != nil {
+		s.logf("output adapter error: %v", outErr)
+		return outErr
 	}
-	return runErr
+	return nil
 }
 
 type setTopicArgs struct {
@@ -107,6 +152,16 @@ type setTopicResult struct {
 	Topic string `json:"topic"`
 }
 
+type reportProgressArgs struct {
+	Message string `json:"message" jsonschema:"What you are doing, e.g. 'analyzing 10/50 files' (max 200 characters)."`
+	Percent *int   `json:"percent,omitempty" jsonschema:"Optional completion percentage from 0 to 100."`
+}
+
+type reportProgressResult struct {
+	Message string `json:"message"`
+	Percent *int   `json:"percent,omitempty"`
+}
+
 type askQuestionArgs struct {
 	Question string `json:"question" jsonschema:"Short clarifying question for plan scope."`
 }
@@ -148,6 +203,11 @@ func (s *mcpServer) registerTools() {
 		Description: "Set a short topic for the current thread.",
 	}, s.handleSetTopic)
 
+	mcp.AddTool(s.server, &mcp.Tool{
+		Name:        "report_progress",
+		Description: "Report intermediate progress on a long task so the user sees what you are doing.",
+	}, s.handleReportProgress)
+
 	mcp.AddTool(s.server, &mcp.Tool{
 		Name:        "ask_question",
 		Description: "Ask a clarifying question and receive a mocked answer for planning.",
@@ -202,14 +262,40 @@ func (s *mcpServer) handleSetTopic(ctx context.Context, _ *mcp.CallToolRequest,
 	}, setTopicResult{Topic: topic}, nil
 }
 
+func (s *mcpServer) handleReportProgress(ctx context.Context, _ *mcp.CallToolRequest, args reportProgressArgs) (*mcp.CallToolResult, reportProgressResult, error) {
+	s.logf("tool call: report_progress")
+	message := strings.TrimSpace(args.Message)
+	if message == "" {
+		return nil, reportProgressResult{}, fmt.Errorf("message is required")
+	}
+	if len([]rune(message)) > 200 {
+		return nil, reportProgressResult{}, fmt.Errorf("message must be at most 200 characters")
+	}
+	if args.Percent != nil && (*args.Percent < 0 || *args.Percent > 100) {
+		return nil, reportProgressResult{}, fmt.Errorf("percent must be between 0 and 100")
+	}
+	if err := s.progressFn(ctx, message, args.Percent); err != nil {
+		s.logf("tool call: report_progress failed: %v", err)
+		return nil, reportProgressResult{}, err
+	}
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{
+			&mcp.TextContent{Text: "Progress reported"},
+		},
+	}, reportProgressResult{Message: message, Percent: args.Percent}, nil
+}
+
 func (s *mcpServer) handleAskQuestion(ctx context.Context, _ *mcp.CallToolRequest, args askQuestionArgs) (*mcp.CallToolResult, askQuestionResult, error) {
 	s.logf("tool call: ask_question")
 	question := strings.TrimSpace(args.Question)
 	if question == "" {
 		return nil, askQuestionResult{}, fmt.Errorf("question is required")
 	}
+	// Blocks until answered; ctx is cancelled when the MCP client abandons
+	// the call, which removes the pending question.
 	answer, err := s.askQuestionFn(ctx, question)
 	if err != nil {
+		s.logf("tool call: ask_question failed: %v", err)
 		return nil, askQuestionResult{}, err
 	}
 	return &mcp.CallToolResult{
@@ -223,10 +309,6 @@ func (s *mcpServer) handleAskQuestion(ctx context.Context, _ *mcp.CallToolReques
 	}, nil
 }
 
-func mockAskQuestion(_ context.Context, _ string) (string, error) {
-	return "Mock response: no live user input is available in this mode; proceed with sensible defaults.", nil
-}
-
 func (s *mcpServer) handlePlanGetCurrentDir(_ context.Context, _ *mcp.CallToolRequest, _ struct{}) (*mcp.CallToolResult, planGetCurrentDirResult, error) {
 	s.logf("tool call: plan_get_current_dir")
 	dir, err := s.planGetCurrentDirFn()
@@ -304,78 +386,82 @@ func (s *mcpServer) logf(format string, args ...any) {
 	s.log.Printf("%s %s", time.Now().Format(time.RFC3339Nano), fmt.Sprintf(format, args...))
 }
 
-func adaptInput(src io.Reader, dst *io.PipeWriter, modeCh chan<- bool) error {
+// adaptInput converts the client's input to newline-delimited JSON for the
+// server. The framing is detected from the first non-blank line and reported
+// on modeCh. A client that later switches framing is logged and followed.
+func adaptInput(src io.Reader, dst *io.PipeWriter, modeCh chan<- bool, logf func(format string, args ...any)) error {
 	defer close(modeCh)
 	defer func() { _ = dst.Close() }()
 
 	br := bufio.NewReader(src)
+	detected, headerMode := false, false
+	for {
+		line, err := br.ReadString('\n')
+		if strings.TrimSpace(line) != "" {
+			header := isHeaderLine(line)
+			if !detected {
+				detected, headerMode = true, header
+				modeCh <- headerMode
+			} else if header != headerMode {
+				logf("framing switched mid-session (content-length=%t), continuing", header)
+			}
 
-	line, err := br.ReadString('\n')
-	if err != nil {
-		return err
-	}
-
-	headerMode := strings.HasPrefix(strings.ToLower(strings.TrimSpace(line)), "content-length:")
-	modeCh <- headerMode
-
-	if headerMode {
-		if err := forwardHeaderFramedInput(line, br, dst); err != nil {
+			if header {
+				if ferr := forwardHeaderFramedMessage(line, br, dst); ferr != nil {
+					return ferr
+				}
+			} else if _, werr := io.WriteString(dst, line); werr != nil {
+				return werr
+			}
+		}
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
 			return err
 		}
-		return nil
 	}
+}
 
-	if _, err := io.WriteString(dst, line); err != nil {
-		return err
-	}
-	_, err = io.Copy(dst, br)
-	return err
+// isHeaderLine reports whether line looks like a "Name: value" framing
+// header rather than a JSON message.
+func isHeaderLine(line string) bool {
+	name, _, ok := strings.Cut(strings.TrimSpace(line), ":")
+	return ok && name != "" && !strings.ContainsAny(name, "{[\" \t")
 }
 
-func forwardHeaderFramedInput(firstLine string, br *bufio.Reader, dst *io.PipeWriter) error {
+// forwardHeaderFramedMessage reads the rest of the header block starting at
+// firstLine and forwards its payload as one line. Headers other than
+// Content-Length (e.g. Content-Type) may appear in any order and are ignored.
+func forwardHeaderFramedMessage(firstLine string, br *bufio.Reader, dst *io.PipeWriter) error {
+	contentLength := -1
 	line := firstLine
-	for {
-		contentLength, ok, err := parseContentLengthLine(line)
+	for strings.TrimSpace(line) != "" {
+		n, ok, err := parseContentLengthLine(line)
 		if err != nil {
 			return err
 		}
-		if !ok {
-			line, err = br.ReadString('\n')
-			if err != nil {
-				return err
-			}
-			continue
-		}
-
-		for {
-			h, err := br.ReadString('\n')
-			if err != nil {
-				return err
-			}
-			if strings.TrimSpace(h) == "" {
-				break
-			}
-		}
-
-		payload := make([]byte, contentLength)
-		if _, err := io.ReadFull(br, payload); err != nil {
-			return err
-		}
-		if _, err := dst.Write(payload); err != nil {
-			return err
+		if ok {
+			contentLength = n
 		}
-		if _, err := dst.Write([]byte{'\n'}); err != nil {
-			return err
-		}
-
 		line, err = br.ReadString('\n')
 		if err != nil {
-			if err == io.EOF {
-				return nil
-			}
 			return err
 		}
 	}
+	if contentLength < 0 {
+		return fmt.Errorf("header block without Content-Length")
+	}
+
+	payload := make([]byte, contentLength)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		return err
+	}
+	if _, err := dst.Write(payload); err != nil {
+		return err
+	}
+	_, err := dst.Write([]byte{'\n'})
+	return err
 }
 
 func parseContentLengthLine(line string) (int, bool, error) {
@@ -3,10 +3,12 @@ package main
 import (
 	"bytes"
 	"context"
+	"fmt"
 	"io"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/stretchr/testify/assert"
@@ -39,6 +41,7 @@ func TestMCPServerToolsList(t *testing.T) {
 
 	assert.ElementsMatch(t, []string{
 		"set_topic",
+		"report_progress",
 		"ask_question",
 		"plan_get_current_dir",
 		"plan_request_thread_dir",
@@ -68,6 +71,60 @@ func TestMCPServerToolCall_SetTopic(t *testing.T) {
 	assert.Contains(t, firstTextContent(res), "Topic set successfully")
 }
 
+func TestMCPServerToolCall_ReportProgress(t *testing.T) {
+	srv := newMCPServer()
+	var gotMessage string
+	var gotPercent *int
+	srv.progressFn = func(_ context.Context, message string, percent *int) error {
+		gotMessage, gotPercent = message, percent
+		return nil
+	}
+	session := newMCPTestSession(t, srv)
+
+	res, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "report_progress",
+		Arguments: map[string]any{"message": " analyzing 10/50 files ", "percent": 20},
+	})
+	require.NoError(t, err)
+	assert.False(t, res.IsError)
+	assert.Equal(t, "analyzing 10/50 files", gotMessage)
+	require.NotNil(t, gotPercent)
+	assert.Equal(t, 20, *gotPercent)
+
+	_, err = session.CallTool(context.Background(), &mcp.CallToolParams{
+		Name:      "report_progress",
+		Arguments: map[string]any{"message": "wrapping up"},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, gotPercent, "percent is optional")
+}
+
+func TestMCPServerToolCall_ReportProgressValidation(t *testing.T) {
+	srv := newMCPServer()
+	called := false
+	srv.progressFn = func(context.Context, string, *int) error {
+		called = true
+		return nil
+	}
+	session := newMCPTestSession(t, srv)
+
+	for name, args := range map[string]map[string]any{
+		"percent below 0":   {"message": "working", "percent": -1},
+		"percent above 100": {"message": "working", "percent": 101},
+		"empty message":     {"message": "  "},
+	} {
+		t.Run(name, func(t *testing.T) {
+			res, err := session.CallTool(context.Background(), &mcp.CallToolParams{
+				Name:      "report_progress",
+				Arguments: args,
+			})
+			require.NoError(t, err)
+			assert.True(t, res.IsError)
+		})
+	}
+	assert.False(t, called, "invalid reports are not forwarded")
+}
+
 func TestMCPServerToolCall_ValidationError(t *testing.T) {
 	srv := newMCPServer()
 	session := newMCPTestSession(t, srv)
@@ -98,6 +155,100 @@ func TestMCPServerToolCall_AskQuestion(t *testing.T) {
 	assert.Equal(t, "mocked: What genre should we target?", firstTextContent(res))
 }
 
+func TestMCPServerToolCall_AskQuestionCancelled(t *testing.T) {
+	srv := newMCPServer()
+	srv.questions = newPendingQuestions(func(string, string) {})
+	srv.askQuestionFn = srv.questions.ask
+	session := newMCPTestSession(t, srv)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := session.CallTool(ctx, &mcp.CallToolParams{
+			Name:      "ask_question",
+			Arguments: map[string]any{"question": "Which framework?"},
+		})
+		errCh <- err
+	}()
+
+	require.Eventually(t, func() bool { return srv.questions.len() == 1 }, time.Second, 5*time.Millisecond)
+	cancel()
+
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(time.Second):
+		t.Fatal("CallTool did not return after cancel")
+	}
+	// The server learns about the cancellation asynchronously.
+	require.Eventually(t, func() bool { return srv.questions.len() == 0 }, time.Second, 5*time.Millisecond,
+		"cancelled question is removed from the registry")
+}
+
+// serveAsync runs srv.serve in the background and returns its result.
+func serveAsync(srv *mcpServer, stdin io.Reader, stdout io.Writer) <-chan error {
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.serve(context.Background(), stdin, stdout) }()
+	return errCh
+}
+
+func TestMCPServerServe_ClosedStdinReturns(t *testing.T) {
+	srv := newMCPServer()
+
+	select {
+	case err := <-serveAsync(srv, strings.NewReader(""), io.Discard):
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("serve did not return after stdin closed")
+	}
+}
+
+func TestMCPServerServe_StdinClosedMidSession(t *testing.T) {
+	srv := newMCPServer()
+	srv.askQuestionFn = func(ctx context.Context, _ string) (string, error) {
+		<-ctx.Done() // never answered
+		return "", ctx.Err()
+	}
+	stdinR, stdinW := io.Pipe()
+	stdoutR, stdoutW := io.Pipe()
+	errCh := serveAsync(srv, stdinR, stdoutW)
+	go func() { _, _ = io.Copy(io.Discard, stdoutR) }()
+
+	send := func(msg string) {
+		_, err := io.WriteString(stdinW, msg+"\n")
+		require.NoError(t, err)
+	}
+	send(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-06-18","capabilities":{},"clientInfo":{"name":"t","version":"1"}}}`)
+	send(`{"jsonrpc":"2.0","method":"notifications/initialized","params":{}}`)
+	send(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"ask_question","arguments":{"question":"still there?"}}}`)
+	require.NoError(t, stdinW.Close())
+
+	select {
+	case err := <-errCh:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("serve did not return after stdin closed with a tool call in flight")
+	}
+}
+
+func TestMCPServerServe_CancelWithIdleStdin(t *testing.T) {
+	srv := newMCPServer()
+	stdinR, stdinW := io.Pipe()
+	defer stdinW.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.serve(ctx, stdinR, io.Discard) }()
+
+	// Stdin stays open and silent, so no framing is ever detected.
+	cancel()
+
+	select {
+	case <-errCh:
+	case <-time.After(2 * time.Second):
+		t.Fatal("serve did not return after ctx was cancelled with stdin idle")
+	}
+}
+
 func newMCPTestSession(t *testing.T, srv *mcpServer) *mcp.ClientSession {
 	t.Helper()
 
@@ -135,28 +286,91 @@ func firstTextContent(res *mcp.CallToolResult) string {
 	return ""
 }
 
-func TestAdaptInput_ContentLengthFraming(t *testing.T) {
-	payload := `{"jsonrpc":"2.0","id":1,"method":"ping","params":{}}`
-	in := "Content-Length: " + strconv.Itoa(len(payload)) + "\r\n\r\n" + payload + "\n"
-
+// runAdaptInput feeds in through adaptInput and returns the detected mode,
+// the forwarded output and any log lines.
+func runAdaptInput(t *testing.T, in string) (bool, string, []string) {
+	t.Helper()
 	pr, pw := io.Pipe()
 	modeCh := make(chan bool, 1)
 	errCh := make(chan error, 1)
+	var logs []string
+	logf := func(format string, args ...any) { logs = append(logs, fmt.Sprintf(format, args...)) }
 
 	go func() {
-		errCh <- adaptInput(strings.NewReader(in), pw, modeCh)
+		errCh <- adaptInput(strings.NewReader(in), pw, modeCh, logf)
 	}()
 
 	mode := <-modeCh
-	assert.True(t, mode)
-
 	out, err := io.ReadAll(pr)
 	require.NoError(t, err)
-	assert.Equal(t, payload+"\n", string(out))
-	err = <-errCh
-	if err != nil {
-		assert.ErrorIs(t, err, io.EOF)
-	}
+	require.NoError(t, <-errCh)
+	return mode, string(out), logs
+}
+
+func framed(headers, payload string) string {
+	return headers + "Content-Length: " + strconv.Itoa(len(payload)) + "\r\n\r\n" + payload
+}
+
+func TestAdaptInput_ContentLengthFraming(t *testing.T) {
+	payload := `{"jsonrpc":"2.0","id":1,"method":"ping","params":{}}`
+
+	mode, out, _ := runAdaptInput(t, framed("", payload)+"\n")
+	assert.True(t, mode)
+	assert.Equal(t, payload+"\n", out)
+}
+
+func TestAdaptInput_Detection(t *testing.T) {
+	first := `{"jsonrpc":"2.0","id":1,"method":"ping"}`
+	second := `{"jsonrpc":"2.0","id":2,"method":"ping"}`
+
+	t.Run("CRLF headers", func(t *testing.T) {
+		mode, out, _ := runAdaptInput(t, framed("", first)+framed("", second))
+		assert.True(t, mode)
+		assert.Equal(t, first+"\n"+second+"\n", out)
+	})
+
+	t.Run("leading blank lines before headers", func(t *testing.T) {
+		mode, out, _ := runAdaptInput(t, "\r\n\n"+framed("", first))
+		assert.True(t, mode)
+		assert.Equal(t, first+"\n", out)
+	})
+
+	t.Run("leading blank lines before newline-delimited JSON", func(t *testing.T) {
+		mode, out, _ := runAdaptInput(t, "\n\r\n"+first+"\n")
+		assert.False(t, mode)
+		assert.Equal(t, first+"\n", out)
+	})
+
+	t.Run("Content-Type before Content-Length", func(t *testing.T) {
+		in := framed("Content-Type: application/vscode-jsonrpc; charset=utf-8\r\n", first)
+		mode, out, _ := runAdaptInput(t, in)
+		assert.True(t, mode)
+		assert.Equal(t, first+"\n", out)
+	})
+
+	t.Run("Content-Type after Content-Length", func(t *testing.T) {
+		in := "Content-Length: " + strconv.Itoa(len(first)) + "\r\nContent-Type: application/json\r\n\r\n" + first
+		mode, out, _ := runAdaptInput(t, in)
+		assert.True(t, mode)
+		assert.Equal(t, first+"\n", out)
+	})
+
+	t.Run("framing switch is logged and followed", func(t *testing.T) {
+		mode, out, logs := runAdaptInput(t, framed("", first)+second+"\n")
+		assert.True(t, mode)
+		assert.Equal(t, first+"\n"+second+"\n", out)
+		require.Len(t, logs, 1)
+		assert.Contains(t, logs[0], "framing switched")
+	})
+}
+
+func TestAdaptInput_HeaderBlockWithoutContentLength(t *testing.T) {
+	pr, pw := io.Pipe()
+	modeCh := make(chan bool, 1)
+	go func() { _, _ = io.Copy(io.Discard, pr) }()
+
+	err := adaptInput(strings.NewReader("Content-Type: application/json\r\n\r\n{}"), pw, modeCh, func(string, ...any) {})
+	assert.ErrorContains(t, err, "without Content-Length")
 }
 
 func TestAdaptOutput_ContentLengthFraming(t *testing.T) {
@@ -89,7 +89,7 @@ func planRemoveThread(threadID string) error {
 	for i, a := range st.Additional {
 		if a.ThreadID == threadID {
 			idx = i
-			if err := os.RemoveAll(a.PlanDir); err != nil {
+			if err := removePlanDir(st, a.PlanDir); err != nil {
 				return fmt.Errorf("remove plan dir %q: %w", a.PlanDir, err)
 			}
 			break
@@ -108,7 +108,7 @@ func planClearCurrent() error {
 	if err != nil {
 		return err
 	}
-	if err := os.RemoveAll(st.Current.PlanDir); err != nil {
+	if err := removePlanDir(st, st.Current.PlanDir); err != nil {
 		return fmt.Errorf("clear current plan dir: %w", err)
 	}
 	return ensurePlanDir(st.Current.PlanDir)
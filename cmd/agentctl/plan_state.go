@@ -5,14 +5,28 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/google/uuid"
 )
 
 const (
 	agentCtlSessionIDEnv = "AGENTCTL_SESSION_ID"
-	planRootSuffix       = ".agentflow/plans"
-	stateDirName         = ".agentctl"
+	// agentCtlPlanRootEnv overrides the directory plan dirs are allocated
+	// under; it defaults to ~/.agentflow/plans.
+	agentCtlPlanRootEnv = "AGENTCTL_PLAN_ROOT"
+	planRootSuffix      = ".agentflow/plans"
+
+	// Each session gets a namespace dir under the plan root holding its
+	// state, its current plan dir and the dirs of its additional threads.
+	planStateFile     = "state.json"
+	currentPlanDir    = "current"
+	threadPlanDirsDir = "threads"
+
+	// Before namespacing, the state lived in <root>/.agentctl/<session
+	// id>.json, the current plan dir was <root>/<session id> (now the
+	// namespace) and each additional thread had a dir <root>/<uuid>.
+	legacyStateDirName = ".agentctl"
 )
 
 type planAllocation struct {
@@ -23,42 +37,47 @@ type planAllocation struct {
 type planState struct {
 	Current    planAllocation   `json:"current"`
 	Additional []planAllocation `json:"additional"`
+
+	namespace string // the session's namespace dir
 }
 
 func loadOrInitPlanState() (*planState, error) {
-	sessionID := os.Getenv(agentCtlSessionIDEnv)
-	if sessionID == "" {
-		return nil, fmt.Errorf("%s env not set", agentCtlSessionIDEnv)
-	}
-
-	root, err := planRootDir()
+	ns, err := sessionPlanNamespace()
 	if err != nil {
 		return nil, err
 	}
-	statePath := filepath.Join(root, stateDirName, sessionID+".json")
 
-	b, err := os.ReadFile(statePath)
+	b, err := os.ReadFile(filepath.Join(ns, planStateFile))
 	if err == nil {
-		var st planState
+		st := planState{namespace: ns}
 		if err := json.Unmarshal(b, &st); err != nil {
 			return nil, fmt.Errorf("parse plan state: %w", err)
 		}
 		if st.Current.ThreadID == "" || st.Current.PlanDir == "" {
 			return nil, fmt.Errorf("invalid plan state: missing current allocation")
 		}
+		for _, a := range append([]planAllocation{st.Current}, st.Additional...) {
+			if err := checkInNamespace(ns, a.PlanDir); err != nil {
+				return nil, fmt.Errorf("invalid plan state: %w", err)
+			}
+		}
 		return &st, nil
 	}
 	if !os.IsNotExist(err) {
 		return nil, fmt.Errorf("read plan state: %w", err)
 	}
+	if st, err := migrateLegacyPlanState(ns); st != nil || err != nil {
+		return st, err
+	}
 
 	// Current thread is anchored to the internal session id.
-	currentDir := filepath.Join(root, sessionID)
+	currentDir := filepath.Join(ns, currentPlanDir)
 	st := &planState{
 		Current: planAllocation{
-			ThreadID: sessionID,
+			ThreadID: os.Getenv(agentCtlSessionIDEnv),
 			PlanDir:  currentDir,
 		},
+		namespace: ns,
 	}
 	if err := ensurePlanDir(currentDir); err != nil {
 		return nil, err
@@ -69,20 +88,109 @@ func loadOrInitPlanState() (*planState, error) {
 	return st, nil
 }
 
-func savePlanState(st *planState) error {
-	sessionID := os.Getenv(agentCtlSessionIDEnv)
-	if sessionID == "" {
-		return fmt.Errorf("%s env not set", agentCtlSessionIDEnv)
+// migrateLegacyPlanState moves the session's plan dirs from the layout used
+// before namespacing into ns and returns the resulting state, or nil if the
+// session has no legacy state. The legacy current dir is ns itself, so its
+// files move into ns/current.
+func migrateLegacyPlanState(ns string) (*planState, error) {
+	root := filepath.Dir(ns)
+	legacyPath := filepath.Join(root, legacyStateDirName, filepath.Base(ns)+".json")
+	b, err := os.ReadFile(legacyPath)
+	if os.IsNotExist(err) {
+		return nil, nil
 	}
-	root, err := planRootDir()
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("read legacy plan state: %w", err)
+	}
+	var legacy planState
+	if err := json.Unmarshal(b, &legacy); err != nil {
+		return nil, fmt.Errorf("parse legacy plan state: %w", err)
+	}
+	if legacy.Current.ThreadID == "" {
+		return nil, fmt.Errorf("invalid legacy plan state: missing current allocation")
+	}
+
+	st := &planState{
+		Current:   planAllocation{ThreadID: legacy.Current.ThreadID, PlanDir: filepath.Join(ns, currentPlanDir)},
+		namespace: ns,
+	}
+	if err := ensurePlanDir(st.Current.PlanDir); err != nil {
+		return nil, err
+	}
+	if filepath.Clean(legacy.Current.PlanDir) == ns {
+		entries, err := os.ReadDir(ns)
+		if err != nil {
+			return nil, fmt.Errorf("read legacy plan dir: %w", err)
+		}
+		for _, e := range entries {
+			switch e.Name() {
+			case planStateFile, currentPlanDir, threadPlanDirsDir:
+				continue
+			}
+			if err := movePlanEntry(filepath.Join(ns, e.Name()), filepath.Join(st.Current.PlanDir, e.Name())); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for _, a := range legacy.Additional {
+		if a.ThreadID == "" || a.ThreadID != filepath.Base(a.ThreadID) {
+			return nil, fmt.Errorf("invalid legacy plan state: thread id %q", a.ThreadID)
+		}
+		moved := planAllocation{ThreadID: a.ThreadID, PlanDir: filepath.Join(ns, threadPlanDirsDir, a.ThreadID)}
+		// Only dirs the old layout allocated, directly under the root, are
+		// moved; anything else the state names is left where it is.
+		if filepath.Dir(filepath.Clean(a.PlanDir)) == root && checkInNamespace(root, a.PlanDir) == nil {
+			if err := os.MkdirAll(filepath.Dir(moved.PlanDir), 0o755); err != nil {
+				return nil, fmt.Errorf("create thread plan dirs: %w", err)
+			}
+			if err := os.Rename(a.PlanDir, moved.PlanDir); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("move legacy plan dir %q: %w", a.PlanDir, err)
+			}
+		}
+		if err := ensurePlanDir(moved.PlanDir); err != nil {
+			return nil, err
+		}
+		st.Additional = append(st.Additional, moved)
 	}
-	stateDir := filepath.Join(root, stateDirName)
-	if err := os.MkdirAll(stateDir, 0o755); err != nil {
-		return fmt.Errorf("create state dir: %w", err)
+
+	if err := savePlanState(st); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(legacyPath); err != nil {
+		return nil, fmt.Errorf("remove legacy plan state: %w", err)
+	}
+	return st, nil
+}
+
+// movePlanEntry moves a file or dir of the legacy current plan dir into the
+// new one, merging into a dir that already exists there (tasks/).
+func movePlanEntry(from, to string) error {
+	info, err := os.Lstat(from)
+	if err != nil {
+		return fmt.Errorf("move legacy plan entry: %w", err)
 	}
-	statePath := filepath.Join(stateDir, sessionID+".json")
+	if info.IsDir() {
+		if _, err := os.Stat(to); err == nil {
+			entries, err := os.ReadDir(from)
+			if err != nil {
+				return fmt.Errorf("move legacy plan entry: %w", err)
+			}
+			for _, e := range entries {
+				if err := movePlanEntry(filepath.Join(from, e.Name()), filepath.Join(to, e.Name())); err != nil {
+					return err
+				}
+			}
+			return os.Remove(from)
+		}
+	}
+	if err := os.Rename(from, to); err != nil {
+		return fmt.Errorf("move legacy plan entry: %w", err)
+	}
+	return nil
+}
+
+func savePlanState(st *planState) error {
+	statePath := filepath.Join(st.namespace, planStateFile)
 	tmpPath := statePath + ".tmp"
 
 	b, err := json.MarshalIndent(st, "", "  ")
@@ -99,17 +207,73 @@ func savePlanState(st *planState) error {
 }
 
 func planRootDir() (string, error) {
-	home, err := os.UserHomeDir()
+	root := os.Getenv(agentCtlPlanRootEnv)
+	if root == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("resolve home dir: %w", err)
+		}
+		root = filepath.Join(home, planRootSuffix)
+	}
+	root, err := filepath.Abs(root)
 	if err != nil {
-		return "", fmt.Errorf("resolve home dir: %w", err)
+		return "", fmt.Errorf("resolve plan root: %w", err)
 	}
-	root := filepath.Join(home, planRootSuffix)
 	if err := os.MkdirAll(root, 0o755); err != nil {
 		return "", fmt.Errorf("create plan root: %w", err)
 	}
 	return root, nil
 }
 
+// sessionPlanNamespace returns the namespace dir of the session named by
+// AGENTCTL_SESSION_ID, creating it if needed.
+func sessionPlanNamespace() (string, error) {
+	sessionID := os.Getenv(agentCtlSessionIDEnv)
+	if sessionID == "" {
+		return "", fmt.Errorf("%s env not set", agentCtlSessionIDEnv)
+	}
+	if sessionID != filepath.Base(sessionID) || sessionID == "." || sessionID == ".." {
+		return "", fmt.Errorf("invalid %s %q", agentCtlSessionIDEnv, sessionID)
+	}
+	root, err := planRootDir()
+	if err != nil {
+		return "", err
+	}
+	ns := filepath.Join(root, sessionID)
+	if err := os.MkdirAll(ns, 0o755); err != nil {
+		return "", fmt.Errorf("create plan namespace: %w", err)
+	}
+	return ns, nil
+}
+
+// checkInNamespace returns an error unless dir lies inside ns, also after
+// resolving symlinks.
+func checkInNamespace(ns, dir string) error {
+	inside := func(ns, dir string) bool {
+		rel, err := filepath.Rel(ns, dir)
+		return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+	}
+	if !filepath.IsAbs(dir) || !inside(ns, filepath.Clean(dir)) {
+		return fmt.Errorf("plan dir %q is outside the session's plan namespace", dir)
+	}
+	resolvedNS, err := filepath.EvalSymlinks(ns)
+	if err != nil {
+		return fmt.Errorf("resolve plan namespace: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(dir); err == nil && !inside(resolvedNS, resolved) {
+		return fmt.Errorf("plan dir %q resolves outside the session's plan namespace", dir)
+	}
+	return nil
+}
+
+// removePlanDir deletes dir, which must lie inside the session's namespace.
+func removePlanDir(st *planState, dir string) error {
+	if err := checkInNamespace(st.namespace, dir); err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
 func ensurePlanDir(path string) error {
 	if err := os.MkdirAll(filepath.Join(path, "tasks"), 0o755); err != nil {
 		return fmt.Errorf("create plan dir %q: %w", path, err)
@@ -118,20 +282,12 @@ func ensurePlanDir(path string) error {
 }
 
 func allocateAdditionalPlanDir(st *planState) (planAllocation, error) {
-	root, err := planRootDir()
-	if err != nil {
-		return planAllocation{}, err
-	}
-
 	threadID := uuid.Must(uuid.NewV7()).String()
-	internalSessionID := uuid.Must(uuid.NewV7()).String()
-	path := filepath.Join(root, internalSessionID)
-
 	a := planAllocation{
 		ThreadID: threadID,
-		PlanDir:  path,
+		PlanDir:  filepath.Join(st.namespace, threadPlanDirsDir, threadID),
 	}
-	if err := ensurePlanDir(path); err != nil {
+	if err := ensurePlanDir(a.PlanDir); err != nil {
 		return planAllocation{}, err
 	}
 	st.Additional = append(st.Additional, a)
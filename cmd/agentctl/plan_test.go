@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -90,3 +91,158 @@ func mustWriteFile(t *testing.T, path, content string) {
 	}
 }
 
+func TestPlanDirs_NamespacedBySession(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(agentCtlPlanRootEnv, root)
+
+	t.Setenv(agentCtlSessionIDEnv, "sess-a")
+	dirA, err := planGetCurrentDir()
+	if err != nil {
+		t.Fatalf("current dir: %v", err)
+	}
+	if want := filepath.Join(root, "sess-a", currentPlanDir); dirA != want {
+		t.Fatalf("current dir: got %q want %q", dirA, want)
+	}
+	threadA, err := planRequestThreadDir()
+	if err != nil {
+		t.Fatalf("request thread dir: %v", err)
+	}
+	if want := filepath.Join(root, "sess-a", threadPlanDirsDir, threadA.ThreadID); threadA.PlanDir != want {
+		t.Fatalf("thread dir: got %q want %q", threadA.PlanDir, want)
+	}
+	mustWriteFile(t, filepath.Join(dirA, "plan.md"), "a")
+
+	t.Setenv(agentCtlSessionIDEnv, "sess-b")
+	dirB, err := planGetCurrentDir()
+	if err != nil {
+		t.Fatalf("current dir: %v", err)
+	}
+	if dirB == dirA {
+		t.Fatalf("sessions share current dir %q", dirA)
+	}
+	if err := planClearCurrent(); err != nil {
+		t.Fatalf("clear current: %v", err)
+	}
+	if err := planRemoveThread(threadA.ThreadID); err == nil {
+		t.Fatalf("session b removed a thread of session a")
+	}
+	if _, err := os.Stat(filepath.Join(dirA, "plan.md")); err != nil {
+		t.Fatalf("session a's plan was touched: %v", err)
+	}
+	if _, err := os.Stat(threadA.PlanDir); err != nil {
+		t.Fatalf("session a's thread dir was touched: %v", err)
+	}
+
+	t.Setenv(agentCtlSessionIDEnv, "../sess-a")
+	if _, err := planGetCurrentDir(); err == nil {
+		t.Fatalf("expected a session id escaping the root to be rejected")
+	}
+}
+
+func TestPlanDirs_MigratesLegacyLayout(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv(agentCtlPlanRootEnv, root)
+	t.Setenv(agentCtlSessionIDEnv, "sess-a")
+
+	legacyCurrent := filepath.Join(root, "sess-a")
+	legacyThread := filepath.Join(root, "thread-1")
+	mustWriteFile(t, filepath.Join(legacyCurrent, "plan.md"), "current")
+	mustWriteFile(t, filepath.Join(legacyCurrent, "tasks", "01-a.md"), "task")
+	mustWriteFile(t, filepath.Join(legacyThread, "plan.md"), "thread")
+	b, err := json.Marshal(planState{
+		Current:    planAllocation{ThreadID: "sess-a", PlanDir: legacyCurrent},
+		Additional: []planAllocation{{ThreadID: "thread-1", PlanDir: legacyThread}},
+	})
+	if err != nil {
+		t.Fatalf("marshal state: %v", err)
+	}
+	legacyState := filepath.Join(root, legacyStateDirName, "sess-a.json")
+	mustWriteFile(t, legacyState, string(b))
+
+	dir, err := planGetCurrentDir()
+	if err != nil {
+		t.Fatalf("current dir: %v", err)
+	}
+	if want := filepath.Join(root, "sess-a", currentPlanDir); dir != want {
+		t.Fatalf("current dir: got %q want %q", dir, want)
+	}
+	for path, want := range map[string]string{
+		filepath.Join(dir, "plan.md"):                                           "current",
+		filepath.Join(dir, "tasks", "01-a.md"):                                  "task",
+		filepath.Join(root, "sess-a", threadPlanDirsDir, "thread-1", "plan.md"): "thread",
+	} {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("read migrated file: %v", err)
+		}
+		if string(got) != want {
+			t.Fatalf("%s: got %q want %q", path, got, want)
+		}
+	}
+	if _, err := os.Stat(legacyThread); !os.IsNotExist(err) {
+		t.Fatalf("legacy thread dir left behind: %v", err)
+	}
+	if _, err := os.Stat(legacyState); !os.IsNotExist(err) {
+		t.Fatalf("legacy state left behind: %v", err)
+	}
+
+	st, err := loadOrInitPlanState()
+	if err != nil {
+		t.Fatalf("load state: %v", err)
+	}
+	if len(st.Additional) != 1 || st.Additional[0].ThreadID != "thread-1" {
+		t.Fatalf("threads not kept: %+v", st.Additional)
+	}
+}
+
+func TestPlanDirs_RejectEscapingPaths(t *testing.T) {
+	root := t.TempDir()
+	outside := t.TempDir()
+	mustWriteFile(t, filepath.Join(outside, "keep.md"), "keep")
+	t.Setenv(agentCtlPlanRootEnv, root)
+	t.Setenv(agentCtlSessionIDEnv, "sess-1")
+
+	ns := filepath.Join(root, "sess-1")
+	if err := os.MkdirAll(ns, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(ns, "link")); err != nil {
+		t.Fatal(err)
+	}
+	writeState := func(st planState) {
+		t.Helper()
+		b, err := json.Marshal(st)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mustWriteFile(t, filepath.Join(ns, planStateFile), string(b))
+	}
+	current := planAllocation{ThreadID: "sess-1", PlanDir: filepath.Join(ns, currentPlanDir)}
+
+	cases := map[string]string{
+		"outside dir":      outside,
+		"dot-dot":          filepath.Join(ns, "..", "..", filepath.Base(outside)),
+		"namespace itself": ns,
+		"relative":         "current",
+		"via symlink":      filepath.Join(ns, "link"),
+	}
+	for name, dir := range cases {
+		writeState(planState{Current: current, Additional: []planAllocation{{ThreadID: "t-1", PlanDir: dir}}})
+		if err := planRemoveThread("t-1"); err == nil {
+			t.Errorf("%s: remove thread accepted %q", name, dir)
+		}
+		writeState(planState{Current: planAllocation{ThreadID: "sess-1", PlanDir: dir}})
+		if err := planClearCurrent(); err == nil {
+			t.Errorf("%s: clear current accepted %q", name, dir)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(outside, "keep.md")); err != nil {
+		t.Fatalf("file outside the namespace was removed: %v", err)
+	}
+
+	// A valid state still works.
+	writeState(planState{Current: current})
+	if err := planClearCurrent(); err != nil {
+		t.Fatalf("clear current: %v", err)
+	}
+}
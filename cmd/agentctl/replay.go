@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sebastianm/flowgentic/internal/controlplane/session"
+	"github.com/sebastianm/flowgentic/internal/controlplane/session/store"
+	"github.com/sebastianm/flowgentic/internal/database"
+)
+
+// runReplay implements `agentctl replay`: it prints the transcript of a
+// persisted session straight from the control plane's SQLite database.
+func runReplay(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("agentctl replay", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	sessionID := fs.String("session-id", "", "Session to replay")
+	raw := fs.Bool("raw", false, "Print the raw JSON event records instead of the transcript")
+	dbPath := fs.String("db", "", "Path to the SQLite database (default ~/.flowgentic/flowgentic.db)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if strings.TrimSpace(*sessionID) == "" {
+		return fmt.Errorf("--session-id is required")
+	}
+
+	path := *dbPath
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("determining home directory: %w", err)
+		}
+		path = filepath.Join(home, ".flowgentic", "flowgentic.db")
+	}
+
+	db, err := database.OpenReadOnly(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	events, err := store.NewSQLiteStore(db).ListSessionEventsBySession(ctx, *sessionID)
+	if err != nil {
+		return err
+	}
+	if len(events) == 0 {
+		return fmt.Errorf("no events found for session %q", *sessionID)
+	}
+
+	if *raw {
+		for _, e := range events {
+			payload, err := session.DecompressPayload(e.Payload)
+			if err != nil {
+				return fmt.Errorf("event %d: %w", e.Sequence, err)
+			}
+			if _, err := fmt.Fprintln(out, string(payload)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	t := &session.Transcript{}
+	for _, e := range events {
+		r, err := session.UnmarshalRecord(e.Payload)
+		if err != nil {
+			return fmt.Errorf("event %d: %w", e.Sequence, err)
+		}
+		t.Add(session.RecordToCPEvent(r))
+	}
+	return t.WriteText(out)
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/sebastianm/flowgentic/internal/controlplane/session"
+	"github.com/sebastianm/flowgentic/internal/controlplane/session/store"
+	"github.com/sebastianm/flowgentic/internal/database"
+	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
+)
+
+// writeReplayDB persists events for sess-1 to a fresh database and returns its path.
+func writeReplayDB(t *testing.T, events ...*workerv1.SessionEvent) string {
+	t.Helper()
+	dbPath := filepath.Join(t.TempDir(), "flowgentic.db")
+	db, err := database.Open(context.Background(), dbPath)
+	require.NoError(t, err)
+	defer db.Close()
+
+	// Events only; skip the sessions/threads/projects rows they reference.
+	_, err = db.Exec("PRAGMA foreign_keys=OFF")
+	require.NoError(t, err)
+
+	st := store.NewSQLiteStore(db)
+	for i, e := range events {
+		e.SessionId = "sess-1"
+		e.Sequence = int64(i + 1)
+		e.Timestamp = time.Date(2026, 1, 2, 3, 4, i, 0, time.UTC).Format(time.RFC3339)
+		r := session.WorkerEventToRecord(e)
+		payload, err := session.MarshalRecord(r)
+		require.NoError(t, err)
+		require.NoError(t, st.InsertSessionEvent(context.Background(), session.SessionEvent{
+			SessionID: r.SessionID,
+			Sequence:  r.Sequence,
+			EventType: r.Type,
+			Payload:   payload,
+			CreatedAt: time.Now(),
+		}))
+	}
+	return dbPath
+}
+
+func replayFixture(t *testing.T) string {
+	return writeReplayDB(t,
+		&workerv1.SessionEvent{Payload: &workerv1.SessionEvent_UserMessage{
+			UserMessage: &workerv1.UserMessage{Text: "list the files"},
+		}},
+		&workerv1.SessionEvent{Payload: &workerv1.SessionEvent_AgentThoughtChunk{
+			AgentThoughtChunk: &workerv1.AgentThoughtChunk{Text: "I should run ls"},
+		}},
+		&workerv1.SessionEvent{Payload: &workerv1.SessionEvent_ToolCall{
+			ToolCall: &workerv1.ToolCall{
+				ToolCallId: "tc-1",
+				Title:      "ls",
+				Kind:       workerv1.ToolCallKind_TOOL_CALL_KIND_EXECUTE,
+				Status:     workerv1.ToolCallStatus_TOOL_CALL_STATUS_IN_PROGRESS,
+			},
+		}},
+		&workerv1.SessionEvent{Payload: &workerv1.SessionEvent_PermissionRequest{
+			PermissionRequest: &workerv1.PermissionRequest{RequestId: "tc-1", Title: "ls"},
+		}},
+		&workerv1.SessionEvent{Payload: &workerv1.SessionEvent_PermissionResolved{
+			PermissionResolved: &workerv1.PermissionResolved{RequestId: "tc-1", OptionId: "allow", Outcome: "allowed"},
+		}},
+		&workerv1.SessionEvent{Payload: &workerv1.SessionEvent_ToolCallUpdate{
+			ToolCallUpdate: &workerv1.ToolCallUpdate{
+				ToolCallId: "tc-1",
+				Status:     workerv1.ToolCallStatus_TOOL_CALL_STATUS_COMPLETED,
+				RawOutput:  "go.mod\nmain.go",
+			},
+		}},
+		&workerv1.SessionEvent{Payload: &workerv1.SessionEvent_AgentMessageChunk{
+			AgentMessageChunk: &workerv1.AgentMessageChunk{Text: "There are "},
+		}},
+		&workerv1.SessionEvent{Payload: &workerv1.SessionEvent_AgentMessageChunk{
+			AgentMessageChunk: &workerv1.AgentMessageChunk{Text: "two files."},
+		}},
+		&workerv1.SessionEvent{Payload: &workerv1.SessionEvent_StatusChange{
+			StatusChange: &workerv1.StatusChange{Status: workerv1.SessionStatus_SESSION_STATUS_IDLE},
+		}},
+	)
+}
+
+func TestRunReplay_PrintsTranscript(t *testing.T) {
+	dbPath := replayFixture(t)
+
+	var out bytes.Buffer
+	require.NoError(t, runReplay(context.Background(), []string{"--db", dbPath, "--session-id", "sess-1"}, &out))
+
+	assert.Equal(t, strings.Join([]string{
+		"[2026-01-02T03:04:00Z] user: list the files",
+		"[2026-01-02T03:04:01Z] thinking: I should run ls",
+		"[2026-01-02T03:04:02Z] tool: ls (completed)",
+		"    go.mod",
+		"    main.go",
+		"[2026-01-02T03:04:03Z] permission: requested for ls",
+		"[2026-01-02T03:04:04Z] permission: allowed",
+		"[2026-01-02T03:04:06Z] agent: There are two files.",
+		"[2026-01-02T03:04:08Z] status: SESSION_STATUS_IDLE",
+		"",
+	}, "\n"), out.String())
+}
+
+func TestRunReplay_Raw(t *testing.T) {
+	dbPath := replayFixture(t)
+
+	var out bytes.Buffer
+	require.NoError(t, runReplay(context.Background(), []string{"--db", dbPath, "--session-id", "sess-1", "--raw"}, &out))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 9)
+	for i, line := range lines {
+		r, err := session.UnmarshalRecord([]byte(line))
+		require.NoError(t, err)
+		assert.Equal(t, int64(i+1), r.Sequence)
+	}
+	first, _ := session.UnmarshalRecord([]byte(lines[0]))
+	assert.Equal(t, "user_message", first.Type)
+}
+
+func TestRunReplay_RawDecompressesLargePayloads(t *testing.T) {
+	long := strings.Repeat("lorem ipsum ", session.CompressPayloadThreshold/10)
+	dbPath := writeReplayDB(t, &workerv1.SessionEvent{Payload: &workerv1.SessionEvent_AgentMessageChunk{
+		AgentMessageChunk: &workerv1.AgentMessageChunk{Text: long},
+	}})
+
+	var out bytes.Buffer
+	require.NoError(t, runReplay(context.Background(), []string{"--db", dbPath, "--session-id", "sess-1", "--raw"}, &out))
+
+	line := strings.TrimSuffix(out.String(), "\n")
+	assert.False(t, session.PayloadCompressed([]byte(line)))
+	r, err := session.UnmarshalRecord([]byte(line))
+	require.NoError(t, err)
+	assert.Equal(t, "agent_message_chunk", r.Type)
+	assert.Contains(t, line, long)
+}
+
+func TestRunReplay_Errors(t *testing.T) {
+	dbPath := replayFixture(t)
+	ctx := context.Background()
+
+	err := runReplay(ctx, []string{"--db", dbPath}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, "--session-id is required")
+
+	err = runReplay(ctx, []string{"--db", dbPath, "--session-id", "nope"}, &bytes.Buffer{})
+	assert.ErrorContains(t, err, `no events found for session "nope"`)
+
+	err = runReplay(ctx, []string{"--db", filepath.Join(t.TempDir(), "missing.db"), "--session-id", "sess-1"}, &bytes.Buffer{})
+	assert.Error(t, err)
+}
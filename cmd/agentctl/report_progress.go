@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"connectrpc.com/connect"
+	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
+)
+
+func runReportProgress(ctx context.Context, message string, percent *int) error {
+	sessionID := os.Getenv(agentCtlSessionIDEnv)
+	if sessionID == "" {
+		return fmt.Errorf("%s env not set", agentCtlSessionIDEnv)
+	}
+
+	req := &workerv1.ReportProgressRequest{SessionId: sessionID, Message: message}
+	if percent != nil {
+		p := int32(*percent)
+		req.Percent = &p
+	}
+	client := newAgentCtlClient()
+	if _, err := client.ReportProgress(ctx, connect.NewRequest(req)); err != nil {
+		return fmt.Errorf("failed to report progress: %w", err)
+	}
+	return nil
+}
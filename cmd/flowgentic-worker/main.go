@@ -9,10 +9,12 @@ import (
 
 func main() {
 	listenAddr := flag.String("listen-addr", "", "Address to listen on (e.g. :8081)")
+	plaintext := flag.Bool("plaintext", false, "Serve without TLS even if worker.tls is configured")
 	flag.Parse()
 
 	srv := server.New(server.Opts{
 		ListenAddr: *listenAddr,
+		Plaintext:  *plaintext,
 	})
 	if err := srv.Start(); err != nil {
 		os.Exit(1)
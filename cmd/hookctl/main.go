@@ -5,12 +5,13 @@ import (
 	"flag"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 
 	"connectrpc.com/connect"
+	"github.com/sebastianm/flowgentic/internal/connectutil"
 	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
 	workerv1connect "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1/workerv1connect"
+	"github.com/sebastianm/flowgentic/internal/tlsutil"
 	"github.com/sebastianm/flowgentic/internal/worker/driver"
 	"github.com/sebastianm/flowgentic/internal/worker/interceptors"
 )
@@ -49,12 +50,19 @@ func main() {
 		os.Exit(1)
 	}
 
+	tlsCfg, err := tlsutil.ClientConfig(tlsutil.FromEnv("AGENTCTL"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "hookctl: %v\n", err)
+		os.Exit(1)
+	}
+	connectutil.SetClientTLS(tlsCfg)
+
 	var opts []connect.ClientOption
 	if secret := os.Getenv("AGENTCTL_WROKER_SECRET"); secret != "" {
 		opts = append(opts, connect.WithInterceptors(interceptors.NewAuth(secret)))
 	}
 
-	client := workerv1connect.NewHookCtlServiceClient(http.DefaultClient, workerURL, opts...)
+	client := workerv1connect.NewHookCtlServiceClient(connectutil.Client, workerURL, opts...)
 	_, err = client.ReportHook(context.Background(), connect.NewRequest(&workerv1.ReportHookRequest{
 		SessionId: agentRunID,
 		Agent:     protoAgent,
@@ -18,6 +18,9 @@ type Client interface {
 	Disconnect() error
 	Query(ctx context.Context, prompt string) error
 	QueryWithSession(ctx context.Context, prompt string, sessionID string) error
+	// QueryContentWithSession sends a user message made of content blocks,
+	// such as text and base64 images, in the Anthropic Messages API format.
+	QueryContentWithSession(ctx context.Context, content []map[string]any, sessionID string) error
 	QueryStream(ctx context.Context, messages <-chan StreamMessage) error
 	ReceiveMessages(ctx context.Context) <-chan Message
 	ReceiveResponse(ctx context.Context) MessageIterator
@@ -40,6 +43,9 @@ type Client interface {
 	SupportedCommands(ctx context.Context) ([]SlashCommand, error)
 	// SupportedModels returns the list of available models.
 	SupportedModels(ctx context.Context) ([]ModelInfo, error)
+	// Ping checks that the CLI still answers control requests.
+	// Only works in streaming mode (after Connect()).
+	Ping(ctx context.Context) error
 	GetStreamIssues() []StreamIssue
 	GetStreamStats() StreamStats
 	GetServerInfo(ctx context.Context) (map[string]interface{}, error)
@@ -316,8 +322,22 @@ func (c *ClientImpl) QueryWithSession(ctx context.Context, prompt string, sessio
 	return c.queryWithSession(ctx, prompt, sessionID)
 }
 
+// QueryContentWithSession sends a user message made of content blocks using
+// the specified session ID. Each block is a Messages API content block, e.g.
+//
+//	{"type": "image", "source": {"type": "base64", "media_type": "image/png", "data": "..."}}
+//
+// If sessionID is empty, it defaults to "default".
+func (c *ClientImpl) QueryContentWithSession(ctx context.Context, content []map[string]any, sessionID string) error {
+	if sessionID == "" {
+		sessionID = defaultSessionID
+	}
+	return c.queryWithSession(ctx, content, sessionID)
+}
+
 // queryWithSession is the internal implementation for sending queries with session management.
-func (c *ClientImpl) queryWithSession(ctx context.Context, prompt string, sessionID string) error {
+// content is the message content: a string or a list of content blocks.
+func (c *ClientImpl) queryWithSession(ctx context.Context, content any, sessionID string) error {
 	// Check context before proceeding
 	if ctx.Err() != nil {
 		return ctx.Err()
@@ -343,7 +363,7 @@ func (c *ClientImpl) queryWithSession(ctx context.Context, prompt string, sessio
 		Type: "user",
 		Message: map[string]interface{}{
 			"role":    "user",
-			"content": prompt,
+			"content": content,
 		},
 		ParentToolUseID: nil,
 		SessionID:       sessionID,
@@ -576,6 +596,26 @@ func (c *ClientImpl) SupportedModels(ctx context.Context) ([]ModelInfo, error) {
 	return transport.SupportedModels(ctx)
 }
 
+// Ping checks that the CLI still answers control requests. It returns an
+// error if the CLI does not respond in time, for example because it hangs.
+// Only works in streaming mode (after Connect()).
+func (c *ClientImpl) Ping(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	c.mu.RLock()
+	connected := c.connected
+	transport := c.transport
+	c.mu.RUnlock()
+
+	if !connected || transport == nil {
+		return fmt.Errorf("client not connected")
+	}
+
+	return transport.Ping(ctx)
+}
+
 // clientIterator implements MessageIterator for client message reception
 type clientIterator struct {
 	msgChan <-chan Message
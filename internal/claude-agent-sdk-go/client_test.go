@@ -1222,6 +1222,10 @@ func (c *clientMockTransport) SupportedModels(_ context.Context) ([]ModelInfo, e
 	return nil, nil
 }
 
+func (c *clientMockTransport) Ping(_ context.Context) error {
+	return nil
+}
+
 // Streamlined Mock Transport Options - reduced from 11 to 6 essential functions
 type ClientMockTransportOption func(*clientMockTransport)
 
@@ -2220,6 +2224,40 @@ func TestClientQueryWithCustomSession(t *testing.T) {
 	}
 }
 
+func TestClientQueryContentWithSession(t *testing.T) {
+	ctx, cancel := setupClientTestContext(t, 5*time.Second)
+	defer cancel()
+
+	transport := newClientMockTransport()
+	client := setupClientForTest(t, transport)
+	defer disconnectClientSafely(t, client)
+
+	connectClientSafely(ctx, t, client)
+
+	content := []map[string]any{
+		{"type": "text", "text": "What is this?"},
+		{"type": "image", "source": map[string]any{"type": "base64", "media_type": "image/png", "data": "iVBORw0K"}},
+	}
+	if err := client.QueryContentWithSession(ctx, content, ""); err != nil {
+		t.Fatalf("QueryContentWithSession failed: %v", err)
+	}
+
+	sentMsg, ok := transport.getSentMessage(0)
+	if !ok {
+		t.Fatal("Expected a message to be sent")
+	}
+	if sentMsg.SessionID != defaultSessionID {
+		t.Errorf("Expected session ID %q, got %q", defaultSessionID, sentMsg.SessionID)
+	}
+	message, ok := sentMsg.Message.(map[string]interface{})
+	if !ok {
+		t.Fatal("Expected message to be a map")
+	}
+	if got, ok := message["content"].([]map[string]any); !ok || len(got) != 2 || got[1]["type"] != "image" {
+		t.Errorf("Expected the content blocks to be sent as is, got %v", message["content"])
+	}
+}
+
 func TestClientQueryWithSessionValidation(t *testing.T) {
 	ctx, cancel := setupClientTestContext(t, 5*time.Second)
 	defer cancel()
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -12,6 +13,10 @@ import (
 // DefaultInitTimeout is the default timeout for the Initialize handshake.
 const DefaultInitTimeout = 60 * time.Second
 
+// ErrControlRequestFailed is wrapped by the error SendControlRequest returns
+// when the CLI answered a request with an error response.
+var ErrControlRequestFailed = errors.New("control request error")
+
 // Transport abstracts the I/O operations for the control protocol.
 // This allows testing with mock transports.
 type Transport interface {
@@ -233,7 +238,7 @@ func (p *Protocol) SendControlRequest(ctx context.Context, request any, timeout
 	select {
 	case response := <-responseChan:
 		if response.Subtype == ResponseSubtypeError {
-			return nil, fmt.Errorf("control request error: %s", response.Error)
+			return nil, fmt.Errorf("%w: %s", ErrControlRequestFailed, response.Error)
 		}
 		return response.Response, nil
 
@@ -553,6 +558,18 @@ func (p *Protocol) SetPermissionMode(ctx context.Context, mode string) error {
 	return err
 }
 
+// Ping checks that the CLI still reads and answers control requests. Any
+// response counts, including an error response from a CLI that does not know
+// the request; only a failed write or a missing response before timeout is an
+// error.
+func (p *Protocol) Ping(ctx context.Context, timeout time.Duration) error {
+	_, err := p.SendControlRequest(ctx, McpStatusRequest{Subtype: SubtypeMcpStatus}, timeout)
+	if errors.Is(err, ErrControlRequestFailed) {
+		return nil
+	}
+	return err
+}
+
 // RewindFiles reverts tracked files to their state at a specific user message.
 // The userMessageID should be the UUID from a UserMessage received during the session.
 // Requires EnableFileCheckpointing to be set when creating the client.
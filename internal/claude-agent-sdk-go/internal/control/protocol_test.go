@@ -4,6 +4,7 @@ package control
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
@@ -1956,3 +1957,78 @@ func testMarshalRewindFilesRequest(t *testing.T) {
 	assertControlEqual(t, "rewind_files", request["subtype"])
 	assertControlEqual(t, "msg-uuid-12345", request["user_message_id"])
 }
+
+// =============================================================================
+// Ping Tests
+// =============================================================================
+
+func TestPing(t *testing.T) {
+	t.Run("error_response_counts_as_alive", testPingErrorResponse)
+	t.Run("timeout", testPingTimeout)
+}
+
+func testPingErrorResponse(t *testing.T) {
+	t.Helper()
+
+	ctx, cancel := setupControlTestContext(t, 5*time.Second)
+	defer cancel()
+
+	transport := newControlMockTransport()
+	protocol := NewProtocol(transport)
+
+	err := protocol.Start(ctx)
+	assertControlNoError(t, err)
+	defer func() { _ = protocol.Close() }()
+
+	// Respond with error, as a CLI that does not know the request would
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		transport.mu.Lock()
+		if len(transport.writtenData) > 0 {
+			var req SDKControlRequest
+			if err := json.Unmarshal(transport.writtenData[0], &req); err == nil {
+				transport.mu.Unlock()
+				transport.injectErrorResponse(req.RequestID, "unsupported control request")
+				return
+			}
+		}
+		transport.mu.Unlock()
+	}()
+
+	err = protocol.Ping(ctx, 2*time.Second)
+	assertControlNoError(t, err)
+
+	var sent map[string]any
+	transport.mu.Lock()
+	err = json.Unmarshal(transport.writtenData[0], &sent)
+	transport.mu.Unlock()
+	assertControlNoError(t, err)
+	request, ok := sent["request"].(map[string]any)
+	if !ok {
+		t.Fatal("request field should be an object")
+	}
+	assertControlEqual(t, SubtypeMcpStatus, request["subtype"])
+}
+
+func testPingTimeout(t *testing.T) {
+	t.Helper()
+
+	ctx, cancel := setupControlTestContext(t, 5*time.Second)
+	defer cancel()
+
+	transport := newControlMockTransport()
+	protocol := NewProtocol(transport)
+
+	err := protocol.Start(ctx)
+	assertControlNoError(t, err)
+	defer func() { _ = protocol.Close() }()
+
+	// Don't send any response - should timeout
+	err = protocol.Ping(ctx, 100*time.Millisecond)
+	if err == nil {
+		t.Fatal("expected timeout error, got nil")
+	}
+	if errors.Is(err, ErrControlRequestFailed) {
+		t.Errorf("a missing response is not an error response: %v", err)
+	}
+}
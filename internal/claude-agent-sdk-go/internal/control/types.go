@@ -34,6 +34,8 @@ const (
 	SubtypeMcpMessage = "mcp_message"
 	// SubtypeRewindFiles requests file rewind to a specific user message state.
 	SubtypeRewindFiles = "rewind_files"
+	// SubtypeMcpStatus requests the status of the session's MCP servers.
+	SubtypeMcpStatus = "mcp_status"
 )
 
 // Response subtype constants for control responses.
@@ -145,6 +147,13 @@ type RewindFilesRequest struct {
 	UserMessageID string `json:"user_message_id"`
 }
 
+// McpStatusRequest asks the CLI for the status of its MCP servers. It has no
+// side effects, which makes it usable as a liveness check.
+type McpStatusRequest struct {
+	// Subtype is always SubtypeMcpStatus ("mcp_status").
+	Subtype string `json:"subtype"`
+}
+
 // =============================================================================
 // Permission Callback Types (Issue #8)
 // =============================================================================
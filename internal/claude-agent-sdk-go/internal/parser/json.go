@@ -244,10 +244,13 @@ func (p *Parser) parseAssistantMessage(data map[string]any) (*shared.AssistantMe
 		errorPtr = &errType
 	}
 
+	stopReason, _ := messageData["stop_reason"].(string)
+
 	return &shared.AssistantMessage{
-		Content: blocks,
-		Model:   model,
-		Error:   errorPtr,
+		Content:    blocks,
+		Model:      model,
+		Error:      errorPtr,
+		StopReason: stopReason,
 	}, nil
 }
 
@@ -199,6 +199,25 @@ func TestParseValidMessages(t *testing.T) {
 				}
 			},
 		},
+		{
+			name: "assistant_message_with_refusal_stop_reason",
+			data: map[string]any{
+				"type": "assistant",
+				"message": map[string]any{
+					"content":     []any{map[string]any{"type": "text", "text": "I can't help with that."}},
+					"model":       "claude-3-sonnet",
+					"stop_reason": "refusal",
+				},
+			},
+			expectedType: shared.MessageTypeAssistant,
+			validate: func(t *testing.T, msg shared.Message) {
+				t.Helper()
+				am := msg.(*shared.AssistantMessage)
+				if am.StopReason != "refusal" {
+					t.Errorf("expected StopReason 'refusal', got %q", am.StopReason)
+				}
+			},
+		},
 		{
 			name:         "system_message",
 			data:         map[string]any{"type": "system", "subtype": "status"},
@@ -109,6 +109,9 @@ type AssistantMessage struct {
 	Content     []ContentBlock         `json:"content"`
 	Model       string                 `json:"model"`
 	Error       *AssistantMessageError `json:"error,omitempty"`
+	// StopReason is the API stop reason of the message, e.g. "end_turn"
+	// or "refusal". CLI versions that do not report it leave it empty.
+	StopReason string `json:"stop_reason,omitempty"`
 }
 
 // Type returns the message type for AssistantMessage.
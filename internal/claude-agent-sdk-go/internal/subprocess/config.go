@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/sebastianm/flowgentic/internal/claude-agent-sdk-go/internal/cli"
 	"github.com/sebastianm/flowgentic/internal/claude-agent-sdk-go/internal/control"
@@ -192,6 +193,34 @@ func (t *Transport) SupportedModels(ctx context.Context) ([]control.ModelInfo, e
 	return append([]control.ModelInfo(nil), initResp.Models...), nil
 }
 
+// defaultPingTimeout bounds a Ping whose context has no deadline.
+const defaultPingTimeout = 5 * time.Second
+
+// Ping checks that the CLI still answers control requests, waiting until
+// ctx's deadline, or five seconds if it has none. This requires streaming
+// mode and control protocol initialization.
+func (t *Transport) Ping(ctx context.Context) error {
+	t.mu.RLock()
+	connected, oneShot, protocol := t.connected, t.closeStdin, t.protocol
+	t.mu.RUnlock()
+
+	if !connected {
+		return fmt.Errorf("transport not connected")
+	}
+	if oneShot {
+		return fmt.Errorf("Ping not available in one-shot mode")
+	}
+	if protocol == nil {
+		return fmt.Errorf("control protocol not initialized")
+	}
+
+	timeout := defaultPingTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	return protocol.Ping(ctx, timeout)
+}
+
 // buildProtocolOptions constructs control protocol options from transport configuration.
 // This extracts callback wiring logic from Connect to reduce cyclomatic complexity.
 func (t *Transport) buildProtocolOptions() []control.ProtocolOption {
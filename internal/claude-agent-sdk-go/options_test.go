@@ -895,6 +895,9 @@ func (m *mockTransportForOptions) SupportedCommands(_ context.Context) ([]SlashC
 func (m *mockTransportForOptions) SupportedModels(_ context.Context) ([]ModelInfo, error) {
 	return nil, nil
 }
+func (m *mockTransportForOptions) Ping(_ context.Context) error {
+	return nil
+}
 func (m *mockTransportForOptions) Close() error                   { return nil }
 func (m *mockTransportForOptions) GetValidator() *StreamValidator { return &StreamValidator{} }
 
@@ -938,6 +938,10 @@ func (q *queryMockTransport) SupportedModels(_ context.Context) ([]ModelInfo, er
 	return nil, nil
 }
 
+func (q *queryMockTransport) Ping(_ context.Context) error {
+	return nil
+}
+
 func (q *queryMockTransport) Close() error {
 	q.mu.Lock()
 	defer q.mu.Unlock()
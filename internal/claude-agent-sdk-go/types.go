@@ -130,6 +130,8 @@ type Transport interface {
 	SupportedCommands(ctx context.Context) ([]SlashCommand, error)
 	// SupportedModels returns the list of available models.
 	SupportedModels(ctx context.Context) ([]ModelInfo, error)
+	// Ping checks that the CLI still answers control requests.
+	Ping(ctx context.Context) error
 	Close() error
 	GetValidator() *StreamValidator
 }
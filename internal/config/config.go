@@ -37,6 +37,18 @@ type TailscaleConfig struct {
 	ServiceName string `json:"serviceName"`
 }
 
+// TLSConfig names the PEM files for TLS between flowgentic services. A
+// server serves TLS once CertFile and KeyFile are set and, with CAFile,
+// requires client certificates signed by one of its CAs. A client trusts
+// the CAs of CAFile instead of the system roots and presents CertFile and
+// KeyFile, if set, to servers that ask for one. Empty files keep plaintext
+// HTTP, the default for local use.
+type TLSConfig struct {
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+	CAFile   string `json:"caFile"`
+}
+
 // WorkerEndpoint describes a remote worker that the control plane can relay
 // requests to.
 type WorkerEndpoint struct {
@@ -62,17 +74,164 @@ type EmbeddedWorkerConfig struct {
 
 // ControlPlaneConfig holds configuration for the flowgentic control plane.
 type ControlPlaneConfig struct {
-	Port           int                  `json:"port"`
-	Tailscale      TailscaleConfig      `json:"tailscale"`
+	Port      int             `json:"port"`
+	Tailscale TailscaleConfig `json:"tailscale"`
+	// TLS serves the control plane API over TLS.
+	TLS TLSConfig `json:"tls"`
+	// WorkerTLS is the TLS material for calls to workers with https URLs.
+	WorkerTLS      TLSConfig            `json:"workerTLS"`
 	Workers        []WorkerEndpoint     `json:"workers"`
 	DatabasePath   string               `json:"databasePath"`
 	EmbeddedWorker EmbeddedWorkerConfig `json:"embeddedWorker"`
+	// EventHeartbeatSeconds is the keepalive interval for idle session event
+	// streams. Zero uses the control plane default.
+	EventHeartbeatSeconds int `json:"eventHeartbeatSeconds"`
+	// MaxStoredRawOutputBytes bounds the raw tool output stored per event.
+	// Zero uses the control plane default; a negative value disables the
+	// limit.
+	MaxStoredRawOutputBytes int `json:"maxStoredRawOutputBytes"`
+	// RawOutputBlobDir, if set, keeps the full raw output of truncated tool
+	// calls as files in this directory.
+	RawOutputBlobDir string `json:"rawOutputBlobDir"`
+	// MaxPromptBytes bounds the text of a prompt or user message. Zero uses
+	// the default of 1 MiB; a negative value disables the limit.
+	MaxPromptBytes int `json:"maxPromptBytes"`
+	// MaxAttachmentBytes bounds the base64 attachment data of a prompt or
+	// user message. Zero uses the default of 20 MiB; a negative value
+	// disables the limit.
+	MaxAttachmentBytes int `json:"maxAttachmentBytes"`
+}
+
+// PromptWrapConfig holds standing instructions the worker wraps around every
+// user prompt. Prompts starting with a slash command are left unchanged.
+type PromptWrapConfig struct {
+	Prefix string `json:"prefix"`
+	Suffix string `json:"suffix"`
+}
+
+// ToolPolicyConfig restricts which tools an agent may use. Currently honored
+// by the Claude Code adapter.
+type ToolPolicyConfig struct {
+	// DisallowedTools are always denied (e.g. "WebFetch", "Bash").
+	DisallowedTools []string `json:"disallowedTools"`
+	// PlanModeAllowedTools are allowed in Flowgentic plan mode in addition
+	// to the read/write file tools and Flowgentic MCP tools.
+	PlanModeAllowedTools []string `json:"planModeAllowedTools"`
+	// KindPermissions decides permission requests by ACP tool kind
+	// ("read", "edit", "execute", ...) as "allow", "ask" or "deny". A tool
+	// that is disallowed or allowed by name, or plan-mode allowed while in
+	// Flowgentic plan mode, is decided by name instead. Only agents with
+	// the tool_kind_permissions capability accept it.
+	KindPermissions map[string]string `json:"kindPermissions"`
+}
+
+// EventRetentionConfig bounds the un-acknowledged session events a worker
+// keeps when the control plane stops acknowledging them. Zero uses the
+// worker default; a negative value disables the limit.
+type EventRetentionConfig struct {
+	MaxEvents     int `json:"maxEvents"`
+	MaxAgeSeconds int `json:"maxAgeSeconds"`
+}
+
+// AgentStderrConfig controls how the worker handles agent stderr. TailLines
+// is how many of the last lines each session keeps (zero uses the worker
+// default); LogLevel is the level they are logged at ("debug" by default).
+type AgentStderrConfig struct {
+	TailLines int    `json:"tailLines"`
+	LogLevel  string `json:"logLevel"`
+}
+
+// AgentctlLogConfig controls the debug log the agentctl MCP servers of all
+// sessions share. Zero fields keep agentctl's defaults: the temp dir,
+// rotation at 10 MiB and 3 old files. A negative MaxBytes disables rotation.
+type AgentctlLogConfig struct {
+	Dir      string `json:"dir"`
+	MaxBytes int64  `json:"maxBytes"`
+	MaxFiles int    `json:"maxFiles"`
+}
+
+// AgentLivenessConfig makes the worker check every IntervalSeconds that the
+// in-process agents (claude-code) still answer, failing a session after
+// Failures checks in a row (zero uses 3) that each got no answer within
+// TimeoutSeconds (zero uses the interval). A zero interval disables the
+// checks.
+type AgentLivenessConfig struct {
+	IntervalSeconds int `json:"intervalSeconds"`
+	TimeoutSeconds  int `json:"timeoutSeconds"`
+	Failures        int `json:"failures"`
+}
+
+// ResourceLimitsConfig caps an agent subprocess on Linux. Zero fields are
+// unlimited. CPUQuota is in CPUs (e.g. 1.5). Both need a writable cgroup v2:
+// CgroupParent, if the worker's own cgroup does not already enable the
+// memory and cpu controllers for its children.
+type ResourceLimitsConfig struct {
+	MemoryBytes  int64   `json:"memoryBytes"`
+	CPUQuota     float64 `json:"cpuQuota"`
+	CgroupParent string  `json:"cgroupParent"`
 }
 
 // WorkerConfig holds configuration for the flowgentic worker.
 type WorkerConfig struct {
 	Port      int             `json:"port"`
 	Tailscale TailscaleConfig `json:"tailscale"`
+	// TLS serves the worker's public API over TLS. The embedded worker
+	// ignores it; the control plane reaches it over loopback.
+	TLS TLSConfig `json:"tls"`
+
+	// PromptWrap applies to every agent without an entry in AgentPromptWrap.
+	PromptWrap PromptWrapConfig `json:"promptWrap"`
+	// AgentPromptWrap overrides PromptWrap per agent ID (e.g. "claude-code").
+	// An entry replaces the default entirely; an empty entry disables wrapping.
+	AgentPromptWrap map[string]PromptWrapConfig `json:"agentPromptWrap"`
+
+	// AgentToolPolicy adds tool restrictions per agent ID on top of the
+	// adapter's built-in lists.
+	AgentToolPolicy map[string]ToolPolicyConfig `json:"agentToolPolicy"`
+
+	// EventRetention limits the events queued per session for the control plane.
+	EventRetention EventRetentionConfig `json:"eventRetention"`
+
+	// AgentModelAliases adds short model names per agent ID (e.g.
+	// "claude-code": {"sonnet": "claude-sonnet-4-5-20250929"}). Entries
+	// override the agent's built-in aliases.
+	AgentModelAliases map[string]map[string]string `json:"agentModelAliases"`
+
+	// CodexSharedAppServer runs all Codex sessions on one app-server process
+	// instead of starting one per session.
+	CodexSharedAppServer bool `json:"codexSharedAppServer"`
+
+	// AgentStderr applies to the stderr of every agent subprocess.
+	AgentStderr AgentStderrConfig `json:"agentStderr"`
+
+	// AgentctlLog applies to the debug log of the agentctl MCP server.
+	AgentctlLog AgentctlLogConfig `json:"agentctlLog"`
+
+	// AgentLiveness detects in-process agents that hang.
+	AgentLiveness AgentLivenessConfig `json:"agentLiveness"`
+
+	// AgentResourceLimits caps the subprocess of each agent ID. In-process
+	// agents (claude-code, codex) are not limited.
+	AgentResourceLimits map[string]ResourceLimitsConfig `json:"agentResourceLimits"`
+
+	// MaxPromptBytes bounds the text of a prompt sent to an agent. Zero uses
+	// the default of 1 MiB; a negative value disables the limit.
+	MaxPromptBytes int `json:"maxPromptBytes"`
+	// MaxAttachmentBytes bounds the base64 attachment data of a prompt. Zero
+	// uses the default of 20 MiB; a negative value disables the limit.
+	MaxAttachmentBytes int `json:"maxAttachmentBytes"`
+
+	// MaxRawOutputBytes bounds the raw output of each tool call update the
+	// worker emits, before it is broadcast or queued for the control plane.
+	// Zero uses the default of 1 MiB; a negative value disables the limit.
+	MaxRawOutputBytes int `json:"maxRawOutputBytes"`
+
+	// LogLevel is the level the worker logs at ("info" by default).
+	// ComponentLogLevels overrides it per component: "driver", "adapter",
+	// "bridge" or "session-manager". FLOWGENTIC_LOG_LEVELS, e.g.
+	// "adapter=debug,bridge=warn", overrides both.
+	LogLevel           string            `json:"logLevel"`
+	ComponentLogLevels map[string]string `json:"componentLogLevels"`
 }
 
 // Config is the top-level configuration for the flowgentic system.
@@ -1,26 +1,58 @@
 package connectutil
 
-import "net/http"
+import (
+	"crypto/tls"
+	"net/http"
+)
+
+// Client is a shared HTTP client for calls between flowgentic services. It
+// speaks HTTP/1 over plaintext and negotiates HTTP/2 over TLS.
+var Client = &http.Client{
+	Transport: clientTransport(),
+}
 
 // H2CClient is a shared HTTP client configured for unencrypted HTTP/2 (h2c),
-// suitable for Connect RPC clients communicating over plaintext.
+// suitable for Connect RPC clients communicating over plaintext. It uses
+// HTTP/2 over TLS for https URLs.
 var H2CClient = &http.Client{
 	Transport: &http.Transport{
 		Protocols: h2cProtocols(),
 	},
 }
 
-// H2CServerProtocols returns an *http.Protocols configured for both HTTP/1
-// and unencrypted HTTP/2, suitable for Connect RPC servers.
+// SetClientTLS sets the TLS configuration Client and H2CClient use for
+// https URLs. Call it once at startup, before either client is used; a nil
+// cfg keeps Go's defaults.
+func SetClientTLS(cfg *tls.Config) {
+	for _, c := range []*http.Client{Client, H2CClient} {
+		c.Transport.(*http.Transport).TLSClientConfig = cfg
+	}
+}
+
+// H2CServerProtocols returns an *http.Protocols configured for HTTP/1 and
+// for HTTP/2 both unencrypted and over TLS, suitable for Connect RPC servers.
 func H2CServerProtocols() *http.Protocols {
 	p := new(http.Protocols)
 	p.SetHTTP1(true)
+	p.SetHTTP2(true)
 	p.SetUnencryptedHTTP2(true)
 	return p
 }
 
+// clientTransport returns a copy of http.DefaultTransport, which Client
+// replaces for inter-service calls, with its own TLS configuration.
+func clientTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	p := new(http.Protocols)
+	p.SetHTTP1(true)
+	p.SetHTTP2(true)
+	t.Protocols = p
+	return t
+}
+
 func h2cProtocols() *http.Protocols {
 	p := new(http.Protocols)
+	p.SetHTTP2(true)
 	p.SetUnencryptedHTTP2(true)
 	return p
 }
@@ -1,7 +1,10 @@
 package connectutil
 
 import (
+	"crypto/tls"
+	"crypto/x509"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -17,6 +20,8 @@ func TestH2CServerProtocols(t *testing.T) {
 		assert.True(t, p.HTTP1())
 		// Verify unencrypted HTTP/2 is enabled
 		assert.True(t, p.UnencryptedHTTP2())
+		// Verify HTTP/2 over TLS is enabled
+		assert.True(t, p.HTTP2())
 	})
 
 	t.Run("returns fresh instance each call", func(t *testing.T) {
@@ -41,5 +46,25 @@ func TestH2CClient(t *testing.T) {
 		// The Transport should have protocols configured
 		assert.NotNil(t, transport.Protocols)
 		assert.True(t, transport.Protocols.UnencryptedHTTP2())
+		assert.True(t, transport.Protocols.HTTP2())
 	})
 }
+
+func TestSetClientTLS(t *testing.T) {
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	defer srv.Close()
+	t.Cleanup(func() { SetClientTLS(nil) })
+
+	pool := x509.NewCertPool()
+	pool.AddCert(srv.Certificate())
+	SetClientTLS(&tls.Config{RootCAs: pool})
+
+	for name, c := range map[string]*http.Client{"Client": Client, "H2CClient": H2CClient} {
+		resp, err := c.Get(srv.URL)
+		require.NoError(t, err, name)
+		resp.Body.Close()
+		assert.Equal(t, 2, resp.ProtoMajor, name)
+	}
+}
@@ -2,9 +2,10 @@ package embeddedworker
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
-	"net/http"
 	"os"
 	"os/exec"
 	"sync"
@@ -15,6 +16,7 @@ import (
 	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
 	"github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1/workerv1connect"
 
+	"github.com/sebastianm/flowgentic/internal/connectutil"
 	"github.com/sebastianm/flowgentic/internal/controlplane/worker"
 	"github.com/sebastianm/flowgentic/internal/portutil"
 
@@ -94,6 +96,13 @@ func (s *EmbeddedWorkerService) Start(ctx context.Context) error {
 	s.setStatusLocked(controlplanev1.EmbeddedWorkerStatus_EMBEDDED_WORKER_STATUS_STARTING, "")
 	s.mu.Unlock()
 
+	if err := checkBinary(s.binaryPath); err != nil {
+		s.mu.Lock()
+		s.setStatusLocked(controlplanev1.EmbeddedWorkerStatus_EMBEDDED_WORKER_STATUS_ERRORED, err.Error())
+		s.mu.Unlock()
+		return err
+	}
+
 	port, err := portutil.FindFreePortFrom(s.preferredPort, 10)
 	if err != nil {
 		s.mu.Lock()
@@ -106,7 +115,7 @@ func (s *EmbeddedWorkerService) Start(ctx context.Context) error {
 	workerURL := fmt.Sprintf("http://127.0.0.1:%d", port)
 
 	childCtx, cancel := context.WithCancel(context.Background())
-	cmd := exec.CommandContext(childCtx, s.binaryPath, "--listen-addr="+listenAddr)
+	cmd := exec.CommandContext(childCtx, s.binaryPath, "--listen-addr="+listenAddr, "--plaintext")
 	cmd.Env = append(os.Environ(),
 		"FLOWGENTIC_WORKER_SECRET="+s.secret,
 		"FLOWGENTIC_CONFIG="+s.configPath,
@@ -160,6 +169,22 @@ func (s *EmbeddedWorkerService) Start(ctx context.Context) error {
 	return nil
 }
 
+// checkBinary reports why binaryPath, a path or a name looked up in PATH,
+// cannot be run as the worker, so a missing binary fails Start clearly
+// instead of with an exec error.
+func checkBinary(binaryPath string) error {
+	_, err := exec.LookPath(binaryPath)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, exec.ErrNotFound), errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("worker binary not found at %s", binaryPath)
+	case errors.Is(err, fs.ErrPermission):
+		return fmt.Errorf("worker binary at %s is not executable", binaryPath)
+	}
+	return fmt.Errorf("checking worker binary %s: %w", binaryPath, err)
+}
+
 // Stop gracefully shuts down the worker process.
 func (s *EmbeddedWorkerService) Stop(ctx context.Context) error {
 	s.mu.Lock()
@@ -281,7 +306,7 @@ func (s *EmbeddedWorkerService) setStatusLocked(status controlplanev1.EmbeddedWo
 // elapses.
 func (s *EmbeddedWorkerService) waitForHealthy(ctx context.Context, workerURL string) error {
 	client := workerv1connect.NewSystemServiceClient(
-		http.DefaultClient,
+		connectutil.Client,
 		workerURL,
 		connect.WithInterceptors(secretInterceptor(s.secret)),
 	)
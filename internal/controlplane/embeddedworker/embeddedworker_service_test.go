@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -125,6 +126,29 @@ func TestEmbeddedWorkerService(t *testing.T) {
 		})
 	})
 
+	t.Run("Start with a missing binary", func(t *testing.T) {
+		svc := newTestService(newFakeRegistry(), newMemStore())
+
+		err := svc.Start(context.Background())
+		require.EqualError(t, err, "worker binary not found at /nonexistent/binary")
+		status, lastErr, pid, _ := svc.GetStatus()
+		assert.Equal(t, controlplanev1.EmbeddedWorkerStatus_EMBEDDED_WORKER_STATUS_ERRORED, status)
+		assert.Equal(t, "worker binary not found at /nonexistent/binary", lastErr)
+		assert.Zero(t, pid)
+	})
+
+	t.Run("Start with a binary that is not executable", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "flowgentic-worker")
+		require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"), 0o644))
+		svc := newTestService(newFakeRegistry(), newMemStore())
+		svc.binaryPath = path
+
+		err := svc.Start(context.Background())
+		require.EqualError(t, err, "worker binary at "+path+" is not executable")
+		status, _, _, _ := svc.GetStatus()
+		assert.Equal(t, controlplanev1.EmbeddedWorkerStatus_EMBEDDED_WORKER_STATUS_ERRORED, status)
+	})
+
 	t.Run("Stop", func(t *testing.T) {
 		t.Run("returns error when not running", func(t *testing.T) {
 			reg := newFakeRegistry()
@@ -180,7 +204,7 @@ func TestEmbeddedWorkerService(t *testing.T) {
 
 			err := svc.Restart(context.Background())
 			require.Error(t, err)
-			assert.Contains(t, err.Error(), "starting worker process")
+			assert.Contains(t, err.Error(), "worker binary not found")
 		})
 
 		t.Run("attempts start from stopped state", func(t *testing.T) {
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/sebastianm/flowgentic/internal/connectutil"
 )
 
 // allowedPrefixes is the set of service path prefixes that the relay will
@@ -123,6 +124,7 @@ func (h *relayHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 	targetURL, _ := url.Parse(workerURL)
 	proxy := &httputil.ReverseProxy{
+		Transport: connectutil.Client.Transport,
 		Rewrite: func(pr *httputil.ProxyRequest) {
 			pr.SetURL(targetURL)
 			pr.Out.Header.Set("Authorization", "Bearer "+secret)
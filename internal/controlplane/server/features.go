@@ -7,6 +7,7 @@ import (
 	"log/slog"
 	"net/http"
 	"os"
+	"time"
 
 	"connectrpc.com/grpcreflect"
 	"github.com/sebastianm/flowgentic/internal/config"
@@ -71,6 +72,9 @@ func (s *Server) startFeatures(mux *http.ServeMux, db *sql.DB, cp config.Control
 		DB:                 db,
 		Registry:           registry,
 		ThreadTopicUpdater: threadSvc,
+		HeartbeatInterval:  time.Duration(cp.EventHeartbeatSeconds) * time.Second,
+		MaxPromptBytes:     cp.MaxPromptBytes,
+		MaxAttachmentBytes: cp.MaxAttachmentBytes,
 	})
 
 	// Wire up task feature.
@@ -81,7 +85,16 @@ func (s *Server) startFeatures(mux *http.ServeMux, db *sql.DB, cp config.Control
 	})
 
 	// Start state sync watchers for all configured workers.
-	stateSyncHandler := session.NewStateSyncHandler(s.log, sessionFeature.Store, threadSvc, sessionFeature.Service)
+	rawOutput := session.RawOutputLimit{MaxBytes: cp.MaxStoredRawOutputBytes}
+	if cp.RawOutputBlobDir != "" {
+		blobs, err := session.NewFileBlobStore(cp.RawOutputBlobDir)
+		if err != nil {
+			s.log.Error("raw output blob store disabled", "dir", cp.RawOutputBlobDir, "error", err)
+		} else {
+			rawOutput.Blobs = blobs
+		}
+	}
+	stateSyncHandler := session.NewStateSyncHandler(s.log, sessionFeature.Store, threadSvc, sessionFeature.Service, sessionFeature.Service, rawOutput)
 	for _, w := range cp.Workers {
 		watcher := session.NewStateSyncWatcher(s.log, w.ID, w.URL, w.Secret, stateSyncHandler)
 		go watcher.Run(serverCtx)
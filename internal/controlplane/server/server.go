@@ -2,6 +2,7 @@ package server
 
 import (
 	"context"
+	"crypto/tls"
 	"flag"
 	"fmt"
 	"html"
@@ -17,6 +18,7 @@ import (
 	"github.com/sebastianm/flowgentic/internal/config"
 	"github.com/sebastianm/flowgentic/internal/connectutil"
 	"github.com/sebastianm/flowgentic/internal/database"
+	"github.com/sebastianm/flowgentic/internal/tlsutil"
 	"github.com/sebastianm/flowgentic/internal/tsnetutil"
 )
 
@@ -61,11 +63,29 @@ func (s *Server) Start() error {
 		listenAddr = fmt.Sprintf(":%d", cp.Port)
 	}
 
+	tlsCfg, err := tlsutil.ServerConfig(cp.TLS)
+	if err != nil {
+		s.log.Error("config error", "error", err)
+		return fmt.Errorf("control plane tls: %w", err)
+	}
+	if tlsCfg != nil && cp.Tailscale.HTTPS {
+		return fmt.Errorf("control plane tls cannot be combined with tailscale https")
+	}
+	workerTLS, err := tlsutil.ClientConfig(cp.WorkerTLS)
+	if err != nil {
+		s.log.Error("config error", "error", err)
+		return fmt.Errorf("worker client tls: %w", err)
+	}
+	connectutil.SetClientTLS(workerTLS)
+
 	ln, err := tsnetutil.ListenAddr(listenAddr, cp.Tailscale)
 	if err != nil {
 		s.log.Error("listen failed", "addr", listenAddr, "error", err)
 		return err
 	}
+	if tlsCfg != nil {
+		ln.Listener = tls.NewListener(ln.Listener, tlsCfg)
+	}
 	s.ln = ln
 	defer s.ln.Close()
 
@@ -103,6 +123,7 @@ func (s *Server) Start() error {
 		"addr", s.ln.Addr().String(),
 		"tailscale_enabled", cp.Tailscale.Enabled,
 		"https", cp.Tailscale.HTTPS,
+		"tls", tlsCfg != nil,
 	)
 
 	// Auto-start embedded worker after server is listening.
@@ -0,0 +1,98 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	controlplanev1 "github.com/sebastianm/flowgentic/internal/proto/gen/controlplane/v1"
+	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
+)
+
+// LifecycleKind names a change in a session's lifecycle.
+type LifecycleKind string
+
+const (
+	LifecycleCreated  LifecycleKind = "created"  // the session record was created
+	LifecycleLaunched LifecycleKind = "launched" // a worker started the session
+	LifecycleStopped  LifecycleKind = "stopped"  // the session ended
+	LifecycleErrored  LifecycleKind = "errored"  // dispatching or running the session failed
+)
+
+// LifecycleEvent reports a lifecycle change of a session. Unlike
+// SessionEventUpdate it comes from the control plane, not the agent.
+type LifecycleEvent struct {
+	SessionID string
+	ThreadID  string
+	Kind      LifecycleKind
+	Status    string // the session's status after the change
+	CreatedAt time.Time
+	Timestamp time.Time
+}
+
+// LifecyclePublisher delivers lifecycle events to live subscribers.
+type LifecyclePublisher interface {
+	PublishLifecycle(evt LifecycleEvent)
+}
+
+func newLifecycleEvent(sess Session, kind LifecycleKind, status string) LifecycleEvent {
+	return LifecycleEvent{
+		SessionID: sess.ID,
+		ThreadID:  sess.ThreadID,
+		Kind:      kind,
+		Status:    status,
+		CreatedAt: sess.CreatedAt,
+		Timestamp: time.Now().UTC(),
+	}
+}
+
+// setStatus stores status for sess and publishes kind to p, if set.
+func setStatus(ctx context.Context, store Store, p LifecyclePublisher, sess Session, status, agentSessionID string, kind LifecycleKind) error {
+	if err := store.UpdateSessionStatus(ctx, sess.ID, status, agentSessionID); err != nil {
+		return err
+	}
+	if p != nil {
+		p.PublishLifecycle(newLifecycleEvent(sess, kind, status))
+	}
+	return nil
+}
+
+// workerTerminalStatus maps a worker session status that ends the session to
+// the lifecycle kind and stored status; ok is false for other statuses.
+func workerTerminalStatus(s workerv1.SessionStatus) (kind LifecycleKind, status string, ok bool) {
+	switch s {
+	case workerv1.SessionStatus_SESSION_STATUS_STOPPED:
+		return LifecycleStopped, "stopped", true
+	case workerv1.SessionStatus_SESSION_STATUS_ERRORED:
+		return LifecycleErrored, "failed", true
+	default:
+		return "", "", false
+	}
+}
+
+// removedStatus maps the final status a worker reports for a removed session
+// to the lifecycle event and session status. Any status but "errored",
+// including none from older workers, means the session stopped.
+func removedStatus(final string) (kind LifecycleKind, status string) {
+	if final == "errored" {
+		return LifecycleErrored, "failed"
+	}
+	return LifecycleStopped, "stopped"
+}
+
+func lifecycleEventToProto(evt LifecycleEvent) *controlplanev1.SessionLifecycleEvent {
+	return &controlplanev1.SessionLifecycleEvent{
+		SessionId: evt.SessionID,
+		ThreadId:  evt.ThreadID,
+		Kind:      lifecycleKindToProto[evt.Kind],
+		Status:    evt.Status,
+		CreatedAt: evt.CreatedAt.UTC().Format("2006-01-02T15:04:05.000Z"),
+		Timestamp: evt.Timestamp.UTC().Format(time.RFC3339Nano),
+	}
+}
+
+var lifecycleKindToProto = map[LifecycleKind]controlplanev1.SessionLifecycleKind{
+	LifecycleCreated:  controlplanev1.SessionLifecycleKind_SESSION_LIFECYCLE_KIND_CREATED,
+	LifecycleLaunched: controlplanev1.SessionLifecycleKind_SESSION_LIFECYCLE_KIND_LAUNCHED,
+	LifecycleStopped:  controlplanev1.SessionLifecycleKind_SESSION_LIFECYCLE_KIND_STOPPED,
+	LifecycleErrored:  controlplanev1.SessionLifecycleKind_SESSION_LIFECYCLE_KIND_ERRORED,
+}
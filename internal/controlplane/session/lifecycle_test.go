@@ -0,0 +1,128 @@
+package session
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	controlplanev1 "github.com/sebastianm/flowgentic/internal/proto/gen/controlplane/v1"
+	"github.com/sebastianm/flowgentic/internal/proto/gen/controlplane/v1/controlplanev1connect"
+	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
+)
+
+func TestWatchSessionLifecycle_CreateAndStop(t *testing.T) {
+	store := &memSessionStore{}
+	svc := NewSessionService(store, NewReconciler(slog.Default(), store, fakeRegistry{}), fakeRegistry{})
+	h := &sessionServiceHandler{log: slog.Default(), svc: svc, threadTopicUpdater: &topicRecorder{}, heartbeatInterval: time.Hour}
+	mux := http.NewServeMux()
+	mux.Handle(controlplanev1connect.NewSessionServiceHandler(h))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// Response headers arrive with the first message, so receive in the
+	// background.
+	client := controlplanev1connect.NewSessionServiceClient(srv.Client(), srv.URL)
+	events := make(chan *controlplanev1.SessionLifecycleEvent, 8)
+	go func() {
+		stream, err := client.WatchSessionLifecycle(ctx, connect.NewRequest(&controlplanev1.WatchSessionLifecycleRequest{ThreadId: "thread-1"}))
+		if err != nil {
+			return
+		}
+		defer stream.Close()
+		for stream.Receive() {
+			if e := stream.Msg().Event; e != nil {
+				events <- e
+			}
+		}
+	}()
+	require.Eventually(t, func() bool {
+		svc.mu.Lock()
+		defer svc.mu.Unlock()
+		return len(svc.lifecycleSubscribers) == 1
+	}, time.Second, time.Millisecond)
+
+	next := func() *controlplanev1.SessionLifecycleEvent {
+		t.Helper()
+		select {
+		case e := <-events:
+			return e
+		case <-time.After(time.Second):
+			t.Fatal("no lifecycle event")
+			return nil
+		}
+	}
+
+	_, _, err := svc.CreateSessionForThread(ctx, "thread-2", "w1", "other thread", "claude-code", "", "", "", "")
+	require.NoError(t, err)
+	id, _, err := svc.CreateSessionForThread(ctx, "thread-1", "w1", "Fix the flaky test", "claude-code", "", "", "", "")
+	require.NoError(t, err)
+
+	created := next()
+	assert.Equal(t, id, created.SessionId, "events of other threads are filtered out")
+	assert.Equal(t, "thread-1", created.ThreadId)
+	assert.Equal(t, controlplanev1.SessionLifecycleKind_SESSION_LIFECYCLE_KIND_CREATED, created.Kind)
+	assert.Equal(t, "pending", created.Status)
+	assert.NotEmpty(t, created.CreatedAt)
+	assert.NotEmpty(t, created.Timestamp)
+
+	stateSync := NewStateSyncHandler(slog.Default(), store, &topicRecorder{}, svc, svc, RawOutputLimit{})
+	stateSync.HandleSessionUpdate("w1", &workerv1.SessionState{SessionId: id, Status: workerv1.SessionStatus_SESSION_STATUS_STOPPED})
+	stateSync.HandleSessionRemoved("w1", &workerv1.SessionRemoved{SessionId: id, FinalStatus: "stopped"})
+
+	stopped := next()
+	assert.Equal(t, id, stopped.SessionId)
+	assert.Equal(t, controlplanev1.SessionLifecycleKind_SESSION_LIFECYCLE_KIND_STOPPED, stopped.Kind)
+	assert.Equal(t, "stopped", stopped.Status)
+	sess, err := svc.GetSession(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, "stopped", sess.Status)
+
+	// The removal of the already stopped session publishes nothing.
+	again, _, err := svc.CreateSessionForThread(ctx, "thread-1", "w1", "Again", "claude-code", "", "", "", "")
+	require.NoError(t, err)
+	assert.Equal(t, again, next().SessionId)
+
+	// A session removed after failing ends as failed.
+	stateSync.HandleSessionRemoved("w1", &workerv1.SessionRemoved{SessionId: again, FinalStatus: "errored"})
+	failed := next()
+	assert.Equal(t, again, failed.SessionId)
+	assert.Equal(t, controlplanev1.SessionLifecycleKind_SESSION_LIFECYCLE_KIND_ERRORED, failed.Kind)
+	assert.Equal(t, "failed", failed.Status)
+}
+
+type recordingLifecycle struct {
+	mu     sync.Mutex
+	events []LifecycleEvent
+}
+
+func (r *recordingLifecycle) PublishLifecycle(evt LifecycleEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, evt)
+}
+
+func TestReconciler_PublishesDispatchFailure(t *testing.T) {
+	store := &memSessionStore{}
+	sess := Session{ID: "sess-1", ThreadID: "thread-1", WorkerID: "gone", Status: "pending"}
+	require.NoError(t, store.CreateSession(context.Background(), sess))
+	lifecycle := &recordingLifecycle{}
+	r := NewReconciler(slog.Default(), store, fakeRegistry{})
+	r.lifecycle = lifecycle
+
+	r.dispatchSession(context.Background(), sess)
+
+	require.Len(t, lifecycle.events, 1)
+	assert.Equal(t, LifecycleErrored, lifecycle.events[0].Kind)
+	assert.Equal(t, "failed", lifecycle.events[0].Status)
+	assert.Equal(t, "thread-1", lifecycle.events[0].ThreadID)
+}
@@ -0,0 +1,58 @@
+package session
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// CompressPayloadThreshold is the encoded size above which MarshalRecord
+// gzips an event record. Smaller records stay plain JSON, which is cheaper
+// to read back.
+const CompressPayloadThreshold = 4 << 10
+
+// gzipMagic starts every gzip stream. JSON never starts with it, so stored
+// payloads need no flag saying whether they are compressed.
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// PayloadCompressed reports whether a stored event payload is gzipped.
+func PayloadCompressed(payload []byte) bool {
+	return bytes.HasPrefix(payload, gzipMagic)
+}
+
+// CompressPayload gzips a plain JSON payload larger than
+// CompressPayloadThreshold. Other payloads are returned unchanged with ok
+// false.
+func CompressPayload(payload []byte) (out []byte, ok bool, err error) {
+	if len(payload) <= CompressPayloadThreshold || PayloadCompressed(payload) {
+		return payload, false, nil
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(payload); err != nil {
+		return nil, false, fmt.Errorf("compress event payload: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, false, fmt.Errorf("compress event payload: %w", err)
+	}
+	return buf.Bytes(), true, nil
+}
+
+// DecompressPayload returns the JSON of a stored payload, gunzipping it if
+// CompressPayload compressed it.
+func DecompressPayload(payload []byte) ([]byte, error) {
+	if !PayloadCompressed(payload) {
+		return payload, nil
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("decompress event payload: %w", err)
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("decompress event payload: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,79 @@
+package session
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshalRecord_SmallRecordStaysPlain(t *testing.T) {
+	r := SessionEventRecord{V: eventRecordVersion, SessionID: "sess-1", Sequence: 1, Type: "agent_message_chunk", Text: "hello"}
+	data, err := MarshalRecord(r)
+	require.NoError(t, err)
+	assert.False(t, PayloadCompressed(data))
+	assert.True(t, json.Valid(data))
+
+	restored, err := UnmarshalRecord(data)
+	require.NoError(t, err)
+	assert.Equal(t, r, restored)
+}
+
+func TestMarshalRecord_LargeRecordIsCompressed(t *testing.T) {
+	r := SessionEventRecord{
+		V:          eventRecordVersion,
+		SessionID:  "sess-1",
+		Sequence:   2,
+		Type:       "tool_call_update",
+		ToolCallID: "call-1",
+		RawOutput:  strings.Repeat("line of output\n", 1000),
+	}
+	data, err := MarshalRecord(r)
+	require.NoError(t, err)
+	assert.True(t, PayloadCompressed(data))
+	assert.Less(t, len(data), len(r.RawOutput)/10)
+
+	restored, err := UnmarshalRecord(data)
+	require.NoError(t, err)
+	assert.Equal(t, r, restored)
+}
+
+func TestUnmarshalRecord_ReadsPlainLargeRows(t *testing.T) {
+	// Rows stored before compression are plain JSON of any size.
+	r := SessionEventRecord{V: eventRecordVersion, SessionID: "sess-1", Type: "agent_message_chunk", Text: strings.Repeat("x", 2*CompressPayloadThreshold)}
+	data, err := json.Marshal(r)
+	require.NoError(t, err)
+
+	restored, err := UnmarshalRecord(data)
+	require.NoError(t, err)
+	assert.Equal(t, r, restored)
+}
+
+func TestCompressPayload(t *testing.T) {
+	small := []byte(`{"v":1}`)
+	out, ok, err := CompressPayload(small)
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.Equal(t, small, out)
+
+	large := []byte(`{"text":"` + strings.Repeat("x", CompressPayloadThreshold) + `"}`)
+	compressed, ok, err := CompressPayload(large)
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	again, ok, err := CompressPayload(compressed)
+	require.NoError(t, err)
+	assert.False(t, ok, "compressed payloads are left alone")
+	assert.Equal(t, compressed, again)
+
+	plain, err := DecompressPayload(compressed)
+	require.NoError(t, err)
+	assert.Equal(t, large, plain)
+}
+
+func TestUnmarshalRecord_CorruptCompressedPayload(t *testing.T) {
+	_, err := UnmarshalRecord(append([]byte{0x1f, 0x8b}, "not gzip"...))
+	assert.ErrorContains(t, err, "decompress event payload")
+}
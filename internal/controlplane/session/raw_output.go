@@ -0,0 +1,122 @@
+package session
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+// DefaultMaxStoredRawOutput is the raw tool output stored per event when no
+// limit is configured.
+const DefaultMaxStoredRawOutput = 64 << 10
+
+// BlobStore keeps the full raw output of tool calls that exceed the stored
+// size. The returned reference is recorded on the event.
+type BlobStore interface {
+	PutBlob(ctx context.Context, data []byte) (ref string, err error)
+}
+
+// RawOutputLimit bounds the raw tool output stored with each event. Longer
+// outputs are cut and marked with their full length and SHA-256, and spilled
+// to Blobs when one is set.
+type RawOutputLimit struct {
+	// MaxBytes is the stored size. Zero uses DefaultMaxStoredRawOutput; a
+	// negative value stores outputs in full.
+	MaxBytes int
+	Blobs    BlobStore
+}
+
+// apply truncates r.RawOutput if it exceeds the limit. The record is marked
+// even if spilling the full output fails; that error is returned. Output the
+// worker already cut keeps the worker's size and SHA-256 and is not spilled,
+// since the full output never reached the control plane.
+func (l RawOutputLimit) apply(ctx context.Context, r *SessionEventRecord) error {
+	limit := l.MaxBytes
+	if limit == 0 {
+		limit = DefaultMaxStoredRawOutput
+	}
+	if limit < 0 || len(r.RawOutput) <= limit {
+		return nil
+	}
+	if r.RawOutputTruncated {
+		r.RawOutput = truncateUTF8(r.RawOutput, limit)
+		return nil
+	}
+
+	full := r.RawOutput
+	sum := sha256.Sum256([]byte(full))
+	r.RawOutput = truncateUTF8(full, limit)
+	r.RawOutputTruncated = true
+	r.RawOutputSize = int64(len(full))
+	r.RawOutputSHA256 = hex.EncodeToString(sum[:])
+
+	if l.Blobs == nil {
+		return nil
+	}
+	ref, err := l.Blobs.PutBlob(ctx, []byte(full))
+	if err != nil {
+		return fmt.Errorf("spill raw output: %w", err)
+	}
+	r.RawOutputBlob = ref
+	return nil
+}
+
+// truncateUTF8 returns at most n bytes of s without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// FileBlobStore is a BlobStore writing each blob to a file in a directory,
+// named after the SHA-256 of its content. References are those file names.
+type FileBlobStore struct {
+	dir string
+}
+
+// NewFileBlobStore returns a FileBlobStore in dir, creating it if needed.
+func NewFileBlobStore(dir string) (*FileBlobStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create blob dir: %w", err)
+	}
+	return &FileBlobStore{dir: dir}, nil
+}
+
+func (s *FileBlobStore) PutBlob(_ context.Context, data []byte) (string, error) {
+	sum := sha256.Sum256(data)
+	ref := hex.EncodeToString(sum[:])
+	path := filepath.Join(s.dir, ref)
+	if _, err := os.Stat(path); err == nil {
+		return ref, nil // content-addressed: already stored
+	}
+
+	tmp, err := os.CreateTemp(s.dir, ref+".tmp-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+	return ref, nil
+}
+
+// GetBlob returns the content stored under ref.
+func (s *FileBlobStore) GetBlob(_ context.Context, ref string) ([]byte, error) {
+	if ref == "" || filepath.Base(ref) != ref {
+		return nil, fmt.Errorf("invalid blob ref %q", ref)
+	}
+	return os.ReadFile(filepath.Join(s.dir, ref))
+}
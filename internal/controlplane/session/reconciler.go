@@ -3,11 +3,11 @@ package session
 import (
 	"context"
 	"log/slog"
-	"net/http"
 	"time"
 
 	"connectrpc.com/connect"
 
+	"github.com/sebastianm/flowgentic/internal/connectutil"
 	"github.com/sebastianm/flowgentic/internal/controlplane/systemprompts"
 	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
 	"github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1/workerv1connect"
@@ -35,6 +35,9 @@ type Reconciler struct {
 	store    Store
 	registry WorkerRegistry
 	notify   chan struct{}
+
+	// lifecycle, if set, is told when a session is launched or fails.
+	lifecycle LifecyclePublisher
 }
 
 // NewReconciler creates a reconciler that dispatches pending sessions to
@@ -88,7 +91,7 @@ func (r *Reconciler) dispatchSession(ctx context.Context, sess Session) {
 	workerURL, secret, ok := r.registry.Lookup(sess.WorkerID)
 	if !ok {
 		r.log.Error("reconciler: unknown worker", "worker_id", sess.WorkerID, "session_id", sess.ID)
-		if err := r.store.UpdateSessionStatus(ctx, sess.ID, "failed", ""); err != nil {
+		if err := setStatus(ctx, r.store, r.lifecycle, sess, "failed", "", LifecycleErrored); err != nil {
 			r.log.Error("reconciler: failed to mark session as failed", "session_id", sess.ID, "error", err)
 		}
 		return
@@ -105,7 +108,7 @@ func (r *Reconciler) dispatchSession(ctx context.Context, sess Session) {
 	}
 
 	client := workerv1connect.NewWorkerServiceClient(
-		http.DefaultClient,
+		connectutil.Client,
 		workerURL,
 		connect.WithInterceptors(secretInterceptor(secret)),
 	)
@@ -123,7 +126,7 @@ func (r *Reconciler) dispatchSession(ctx context.Context, sess Session) {
 	}))
 	if err != nil {
 		r.log.Error("reconciler: NewSession RPC failed", "session_id", sess.ID, "error", err)
-		if err := r.store.UpdateSessionStatus(ctx, sess.ID, "failed", ""); err != nil {
+		if err := setStatus(ctx, r.store, r.lifecycle, sess, "failed", "", LifecycleErrored); err != nil {
 			r.log.Error("reconciler: failed to mark session as failed", "session_id", sess.ID, "error", err)
 		}
 		return
@@ -131,14 +134,14 @@ func (r *Reconciler) dispatchSession(ctx context.Context, sess Session) {
 
 	if !resp.Msg.Accepted {
 		r.log.Warn("reconciler: worker rejected session", "session_id", sess.ID, "message", resp.Msg.Message)
-		if err := r.store.UpdateSessionStatus(ctx, sess.ID, "failed", ""); err != nil {
+		if err := setStatus(ctx, r.store, r.lifecycle, sess, "failed", "", LifecycleErrored); err != nil {
 			r.log.Error("reconciler: failed to mark session as failed", "session_id", sess.ID, "error", err)
 		}
 		return
 	}
 
 	agentSessionID := resp.Msg.AgentSessionId
-	if err := r.store.UpdateSessionStatus(ctx, sess.ID, "running", agentSessionID); err != nil {
+	if err := setStatus(ctx, r.store, r.lifecycle, sess, "running", agentSessionID, LifecycleLaunched); err != nil {
 		r.log.Error("reconciler: failed to mark session as running", "session_id", sess.ID, "error", err)
 		return
 	}
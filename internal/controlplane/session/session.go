@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"log/slog"
 	"net/http"
+	"time"
 
 	"github.com/sebastianm/flowgentic/internal/proto/gen/controlplane/v1/controlplanev1connect"
 )
@@ -21,6 +22,20 @@ type StartDeps struct {
 	DB                 *sql.DB
 	Registry           WorkerRegistry
 	ThreadTopicUpdater ThreadTopicUpdater
+	// HeartbeatInterval is how often idle WatchSessionEvents streams get a
+	// keepalive. Zero uses the default.
+	HeartbeatInterval time.Duration
+	// MaxPromptBytes bounds prompt text; see promptutil.Validate.
+	MaxPromptBytes int
+	// MaxAttachmentBytes bounds prompt attachment data; see
+	// promptutil.ValidateAttachments.
+	MaxAttachmentBytes int
+}
+
+// PayloadCompactor is implemented by stores that can compress event
+// payloads stored before MarshalRecord compressed large records.
+type PayloadCompactor interface {
+	CompressEventPayloads(ctx context.Context) (int, error)
 }
 
 type Feature struct {
@@ -32,10 +47,35 @@ func Start(ctx context.Context, d StartDeps) *Feature {
 	st := storeFactory(d.DB)
 	reconciler := NewReconciler(d.Log, st, d.Registry)
 	svc := NewSessionService(st, reconciler, d.Registry)
-	h := &sessionServiceHandler{log: d.Log, svc: svc, store: st, threadTopicUpdater: d.ThreadTopicUpdater}
+	reconciler.lifecycle = svc
+	h := &sessionServiceHandler{
+		log:                d.Log,
+		svc:                svc,
+		store:              st,
+		threadTopicUpdater: d.ThreadTopicUpdater,
+		heartbeatInterval:  d.HeartbeatInterval,
+		maxPromptBytes:     d.MaxPromptBytes,
+		maxAttachmentBytes: d.MaxAttachmentBytes,
+	}
 	d.Mux.Handle(controlplanev1connect.NewSessionServiceHandler(h))
 
 	go reconciler.Run(ctx)
+	if c, ok := st.(PayloadCompactor); ok {
+		go compactPayloads(ctx, d.Log, c)
+	}
 
 	return &Feature{Service: svc, Store: st}
 }
+
+// compactPayloads compresses large event payloads left from before
+// compression. Reading works either way, so failures are only logged.
+func compactPayloads(ctx context.Context, log *slog.Logger, c PayloadCompactor) {
+	n, err := c.CompressEventPayloads(ctx)
+	if err != nil {
+		log.Warn("compressing stored event payloads", "error", err, "compressed", n)
+		return
+	}
+	if n > 0 {
+		log.Info("compressed stored event payloads", "count", n)
+	}
+}
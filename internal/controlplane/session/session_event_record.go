@@ -26,9 +26,72 @@ type SessionEventRecord struct {
 	RawOutput  string `json:"raw_output,omitempty"`
 	Status     string `json:"status,omitempty"` // ACP: "in_progress", "completed", "failed"
 	ModeID     string `json:"mode_id,omitempty"`
+	ModelID    string `json:"model_id,omitempty"`
+	Reason     string `json:"reason,omitempty"` // session_error, errored status_change: "auth" or empty; turn_ended: cancel reason
+
+	StderrTail []string `json:"stderr_tail,omitempty"` // session_error, errored status_change: the agent's last stderr lines
+
+	// permission_request and permission_resolved only.
+	RequestID string                   `json:"request_id,omitempty"`
+	Options   []PermissionOptionRecord `json:"options,omitempty"`
+	OptionID  string                   `json:"option_id,omitempty"`
+	Outcome   string                   `json:"outcome,omitempty"` // "allowed", "auto_approved", "denied" or "cancelled"
+
+	Count int64 `json:"count,omitempty"` // events_pruned: number of events the worker dropped
+
+	AcceptingInput bool `json:"accepting_input,omitempty"` // status_change only
+
+	// Set when RawOutput was cut to the stored limit; see RawOutputLimit.
+	RawOutputTruncated bool   `json:"raw_output_truncated,omitempty"`
+	RawOutputSize      int64  `json:"raw_output_size,omitempty"`   // full length in bytes
+	RawOutputSHA256    string `json:"raw_output_sha256,omitempty"` // hex digest of the full output
+	RawOutputBlob      string `json:"raw_output_blob,omitempty"`   // BlobStore reference, if spilled
+
+	Plans []PlanRecord `json:"plans,omitempty"` // plan_submitted only
+
+	PlanEntries []PlanEntryRecord `json:"plan_entries,omitempty"` // agent_plan only: the agent's whole current plan
+
+	MCPStartup *MCPStartupRecord `json:"mcp_startup,omitempty"` // mcp_server_startup only; Text holds the summary
+
+	SessionInit *SessionInitRecord `json:"session_init,omitempty"` // session_init only; Text holds the summary
+
+	Percent *int32 `json:"percent,omitempty"` // progress only, if reported; Text holds the message
+
+	StopReason string           `json:"stop_reason,omitempty"` // turn_ended only; Reason says what cancelled it
+	TurnStats  *TurnStatsRecord `json:"turn_stats,omitempty"`  // turn_ended only, if the agent answered
+
+	AutoContinueIteration int32 `json:"auto_continue_iteration,omitempty"` // user_message only: set if the worker sent it
 
 	Locations []LocationRecord     `json:"locations,omitempty"`
 	Content   []ContentBlockRecord `json:"content,omitempty"`
+	Input     *ToolInputRecord     `json:"input,omitempty"` // well-known tools only
+	Metadata  map[string]string    `json:"metadata,omitempty"` // tool_call, tool_call_update: rendering hints from the agent
+}
+
+// MCPStartupRecord is a JSON-serializable MCP server startup report.
+type MCPStartupRecord struct {
+	Server  string `json:"server,omitempty"`
+	Status  string `json:"status,omitempty"`
+	Message string `json:"message,omitempty"`
+	Ready   bool   `json:"ready,omitempty"`
+}
+
+// SessionInitRecord is a JSON-serializable session configuration reported
+// by the agent.
+type SessionInitRecord struct {
+	Model          string   `json:"model,omitempty"`
+	PermissionMode string   `json:"permission_mode,omitempty"`
+	Tools          []string `json:"tools,omitempty"`
+}
+
+// TurnStatsRecord is a JSON-serializable timing and usage report of a
+// prompt turn.
+type TurnStatsRecord struct {
+	DurationMs         int64 `json:"duration_ms"`
+	TimeToFirstTokenMs int64 `json:"time_to_first_token_ms,omitempty"`
+	ToolCalls          int32 `json:"tool_calls,omitempty"`
+	InputTokens        int64 `json:"input_tokens,omitempty"`
+	OutputTokens       int64 `json:"output_tokens,omitempty"`
 }
 
 // LocationRecord is a JSON-serializable tool call location.
@@ -39,11 +102,72 @@ type LocationRecord struct {
 
 // ContentBlockRecord is a JSON-serializable tool call content block.
 type ContentBlockRecord struct {
-	Type    string `json:"type"`              // "diff" or "text"
-	Path    string `json:"path,omitempty"`    // diff only
-	NewText string `json:"new_text,omitempty"` // diff only
-	OldText string `json:"old_text,omitempty"` // diff only
-	Text    string `json:"text,omitempty"`     // text only
+	Type     string `json:"type"`                // "diff", "text" or "command_output"
+	Path     string `json:"path,omitempty"`      // diff only
+	NewText  string `json:"new_text,omitempty"`  // diff only
+	OldText  string `json:"old_text,omitempty"`  // diff only
+	Text     string `json:"text,omitempty"`      // text only
+	Stdout   string `json:"stdout,omitempty"`    // command_output only
+	Stderr   string `json:"stderr,omitempty"`    // command_output only
+	ExitCode *int32 `json:"exit_code,omitempty"` // command_output only; nil if unknown
+}
+
+// ToolInputRecord is a JSON-serializable structured tool input. Tool selects
+// which of the other fields apply.
+type ToolInputRecord struct {
+	Tool string `json:"tool"` // "read", "write", "edit", "bash", "grep" or "glob"
+
+	FilePath   string `json:"file_path,omitempty"`
+	Offset     *int64 `json:"offset,omitempty"`
+	Limit      *int64 `json:"limit,omitempty"`
+	Content    string `json:"content,omitempty"`
+	OldString  string `json:"old_string,omitempty"`
+	NewString  string `json:"new_string,omitempty"`
+	ReplaceAll bool   `json:"replace_all,omitempty"`
+
+	Command         string `json:"command,omitempty"`
+	Description     string `json:"description,omitempty"`
+	TimeoutMs       *int64 `json:"timeout_ms,omitempty"`
+	RunInBackground bool   `json:"run_in_background,omitempty"`
+
+	Pattern         string `json:"pattern,omitempty"`
+	Path            string `json:"path,omitempty"`
+	Glob            string `json:"glob,omitempty"`
+	FileType        string `json:"file_type,omitempty"`
+	OutputMode      string `json:"output_mode,omitempty"`
+	CaseInsensitive bool   `json:"case_insensitive,omitempty"`
+}
+
+// PlanRecord is a JSON-serializable submitted plan.
+type PlanRecord struct {
+	ThreadID string           `json:"thread_id,omitempty"`
+	Title    string           `json:"title"`
+	Body     string           `json:"body,omitempty"`
+	Steps    []PlanStepRecord `json:"steps,omitempty"`
+}
+
+// PlanStepRecord is a JSON-serializable plan step.
+type PlanStepRecord struct {
+	ID          string   `json:"id"`
+	File        string   `json:"file,omitempty"`
+	Description string   `json:"description,omitempty"`
+	DependsOn   []string `json:"depends_on,omitempty"`
+	Agent       string   `json:"agent,omitempty"`
+	Subtasks    []string `json:"subtasks,omitempty"`
+}
+
+// PlanEntryRecord is a JSON-serializable entry of the agent's execution plan.
+type PlanEntryRecord struct {
+	Content  string `json:"content"`
+	Priority string `json:"priority,omitempty"` // ACP: "high", "medium" or "low"
+	Status   string `json:"status,omitempty"`   // ACP: "pending", "in_progress" or "completed"
+}
+
+// PermissionOptionRecord is a JSON-serializable permission option.
+type PermissionOptionRecord struct {
+	OptionID string `json:"option_id"`
+	Name     string `json:"name,omitempty"`
+	Kind     string `json:"kind,omitempty"` // ACP: "allow_once", "reject_once", etc.
 }
 
 const eventRecordVersion = 1
@@ -76,6 +200,8 @@ func WorkerEventToRecord(e *workerv1.SessionEvent) SessionEventRecord {
 		r.Status = toolCallStatusToString(tc.GetStatus())
 		r.Locations = locationsToRecord(tc.GetLocations())
 		r.Content = contentBlocksToRecord(tc.GetContent())
+		r.Input = toolInputToRecord(tc.GetInput())
+		r.Metadata = tc.GetMetadata()
 	case *workerv1.SessionEvent_ToolCallUpdate:
 		r.Type = "tool_call_update"
 		tc := p.ToolCallUpdate
@@ -83,17 +209,103 @@ func WorkerEventToRecord(e *workerv1.SessionEvent) SessionEventRecord {
 		r.Title = tc.GetTitle()
 		r.Status = toolCallStatusToString(tc.GetStatus())
 		r.RawOutput = tc.GetRawOutput()
+		if size := tc.GetRawOutputSize(); size > int64(len(r.RawOutput)) {
+			// The worker already cut the output to its own limit.
+			r.RawOutputTruncated = true
+			r.RawOutputSize = size
+			r.RawOutputSHA256 = tc.GetRawOutputSha256()
+		}
 		r.Locations = locationsToRecord(tc.GetLocations())
 		r.Content = contentBlocksToRecord(tc.GetContent())
+		r.Input = toolInputToRecord(tc.GetInput())
+		r.Metadata = tc.GetMetadata()
 	case *workerv1.SessionEvent_StatusChange:
 		r.Type = "status_change"
 		r.Status = p.StatusChange.GetStatus().String()
+		r.AcceptingInput = p.StatusChange.GetAcceptingInput()
+		if se := p.StatusChange.GetError(); se != nil {
+			r.Reason = sessionErrorReasonToString(se.GetReason())
+			r.Text = se.GetMessage()
+			r.StderrTail = se.GetStderrTail()
+		}
 	case *workerv1.SessionEvent_CurrentModeUpdate:
 		r.Type = "current_mode_update"
 		r.ModeID = p.CurrentModeUpdate.GetModeId()
 	case *workerv1.SessionEvent_UserMessage:
 		r.Type = "user_message"
 		r.Text = p.UserMessage.GetText()
+		r.AutoContinueIteration = p.UserMessage.GetAutoContinueIteration()
+	case *workerv1.SessionEvent_CurrentModelUpdate:
+		r.Type = "current_model_update"
+		r.ModelID = p.CurrentModelUpdate.GetModelId()
+	case *workerv1.SessionEvent_SessionError:
+		r.Type = "session_error"
+		r.Reason = sessionErrorReasonToString(p.SessionError.GetReason())
+		r.Text = p.SessionError.GetMessage()
+		r.StderrTail = p.SessionError.GetStderrTail()
+	case *workerv1.SessionEvent_PermissionRequest:
+		r.Type = "permission_request"
+		pr := p.PermissionRequest
+		r.RequestID = pr.GetRequestId()
+		r.Title = pr.GetTitle()
+		r.Kind = toolCallKindToString(pr.GetKind())
+		for _, o := range pr.GetOptions() {
+			r.Options = append(r.Options, PermissionOptionRecord{
+				OptionID: o.GetOptionId(),
+				Name:     o.GetName(),
+				Kind:     o.GetKind(),
+			})
+		}
+	case *workerv1.SessionEvent_PermissionResolved:
+		r.Type = "permission_resolved"
+		r.RequestID = p.PermissionResolved.GetRequestId()
+		r.OptionID = p.PermissionResolved.GetOptionId()
+		r.Outcome = p.PermissionResolved.GetOutcome()
+	case *workerv1.SessionEvent_EventsPruned:
+		r.Type = "events_pruned"
+		r.Count = p.EventsPruned.GetCount()
+	case *workerv1.SessionEvent_McpServerStartup:
+		r.Type = "mcp_server_startup"
+		ms := p.McpServerStartup
+		r.Text = ms.GetText()
+		r.MCPStartup = &MCPStartupRecord{
+			Server:  ms.GetServer(),
+			Status:  ms.GetStatus(),
+			Message: ms.GetMessage(),
+			Ready:   ms.GetReady(),
+		}
+	case *workerv1.SessionEvent_SessionInit:
+		r.Type = "session_init"
+		si := p.SessionInit
+		r.Text = si.GetText()
+		r.SessionInit = &SessionInitRecord{
+			Model:          si.GetModel(),
+			PermissionMode: si.GetPermissionMode(),
+			Tools:          si.GetTools(),
+		}
+	case *workerv1.SessionEvent_Progress:
+		r.Type = "progress"
+		r.Text = p.Progress.GetMessage()
+		r.Percent = p.Progress.Percent
+	case *workerv1.SessionEvent_TurnEnded:
+		r.Type = "turn_ended"
+		r.StopReason = stopReasonToString(p.TurnEnded.GetStopReason())
+		r.Reason = cancelReasonToString(p.TurnEnded.GetCancelReason())
+		if s := p.TurnEnded.GetStats(); s != nil {
+			r.TurnStats = &TurnStatsRecord{
+				DurationMs:         s.GetDurationMs(),
+				TimeToFirstTokenMs: s.GetTimeToFirstTokenMs(),
+				ToolCalls:          s.GetToolCalls(),
+				InputTokens:        s.GetInputTokens(),
+				OutputTokens:       s.GetOutputTokens(),
+			}
+		}
+	case *workerv1.SessionEvent_AgentPlan:
+		r.Type = "agent_plan"
+		r.PlanEntries = agentPlanToRecord(p.AgentPlan)
+	case *workerv1.SessionEvent_PlanSubmitted:
+		r.Type = "plan_submitted"
+		r.Plans = plansToRecord(p.PlanSubmitted.GetPlans())
 	default:
 		r.Type = "unknown"
 	}
@@ -135,6 +347,8 @@ func RecordToCPEvent(r SessionEventRecord) *controlplanev1.SessionEvent {
 			})
 		}
 		tc.Content = recordContentBlocksToCP(r.Content)
+		tc.Input = recordToolInputToCP(r.Input)
+		tc.Metadata = r.Metadata
 		e.Payload = &controlplanev1.SessionEvent_ToolCall{ToolCall: tc}
 	case "tool_call_update":
 		tc := &controlplanev1.ToolCallUpdate{
@@ -150,32 +364,122 @@ func RecordToCPEvent(r SessionEventRecord) *controlplanev1.SessionEvent {
 			})
 		}
 		tc.Content = recordContentBlocksToCP(r.Content)
+		tc.Input = recordToolInputToCP(r.Input)
+		tc.Metadata = r.Metadata
 		e.Payload = &controlplanev1.SessionEvent_ToolCallUpdate{ToolCallUpdate: tc}
 	case "status_change":
-		e.Payload = &controlplanev1.SessionEvent_StatusChange{
-			StatusChange: &controlplanev1.StatusChange{Status: r.Status},
+		sc := &controlplanev1.StatusChange{Status: r.Status, AcceptingInput: r.AcceptingInput}
+		if r.Text != "" || r.Reason != "" {
+			sc.Error = &controlplanev1.SessionError{Reason: r.Reason, Message: r.Text, StderrTail: r.StderrTail}
 		}
+		e.Payload = &controlplanev1.SessionEvent_StatusChange{StatusChange: sc}
 	case "current_mode_update":
 		e.Payload = &controlplanev1.SessionEvent_CurrentModeUpdate{
 			CurrentModeUpdate: &controlplanev1.CurrentModeUpdate{ModeId: r.ModeID},
 		}
 	case "user_message":
 		e.Payload = &controlplanev1.SessionEvent_UserMessage{
-			UserMessage: &controlplanev1.UserMessage{Text: r.Text},
+			UserMessage: &controlplanev1.UserMessage{Text: r.Text, AutoContinueIteration: r.AutoContinueIteration},
+		}
+	case "current_model_update":
+		e.Payload = &controlplanev1.SessionEvent_CurrentModelUpdate{
+			CurrentModelUpdate: &controlplanev1.CurrentModelUpdate{ModelId: r.ModelID},
+		}
+	case "session_error":
+		e.Payload = &controlplanev1.SessionEvent_SessionError{
+			SessionError: &controlplanev1.SessionError{Reason: r.Reason, Message: r.Text, StderrTail: r.StderrTail},
+		}
+	case "permission_request":
+		pr := &controlplanev1.PermissionRequest{
+			RequestId: r.RequestID,
+			Title:     r.Title,
+			Kind:      stringToToolCallKind(r.Kind),
+		}
+		for _, o := range r.Options {
+			pr.Options = append(pr.Options, &controlplanev1.PermissionOption{
+				OptionId: o.OptionID,
+				Name:     o.Name,
+				Kind:     o.Kind,
+			})
+		}
+		e.Payload = &controlplanev1.SessionEvent_PermissionRequest{PermissionRequest: pr}
+	case "permission_resolved":
+		e.Payload = &controlplanev1.SessionEvent_PermissionResolved{
+			PermissionResolved: &controlplanev1.PermissionResolved{
+				RequestId: r.RequestID,
+				OptionId:  r.OptionID,
+				Outcome:   r.Outcome,
+			},
+		}
+	case "events_pruned":
+		e.Payload = &controlplanev1.SessionEvent_EventsPruned{
+			EventsPruned: &controlplanev1.EventsPruned{Count: r.Count},
+		}
+	case "mcp_server_startup":
+		ms := &controlplanev1.McpServerStartup{Text: r.Text}
+		if r.MCPStartup != nil {
+			ms.Server = r.MCPStartup.Server
+			ms.Status = r.MCPStartup.Status
+			ms.Message = r.MCPStartup.Message
+			ms.Ready = r.MCPStartup.Ready
+		}
+		e.Payload = &controlplanev1.SessionEvent_McpServerStartup{McpServerStartup: ms}
+	case "session_init":
+		si := &controlplanev1.SessionInit{Text: r.Text}
+		if r.SessionInit != nil {
+			si.Model = r.SessionInit.Model
+			si.PermissionMode = r.SessionInit.PermissionMode
+			si.Tools = r.SessionInit.Tools
+		}
+		e.Payload = &controlplanev1.SessionEvent_SessionInit{SessionInit: si}
+	case "progress":
+		e.Payload = &controlplanev1.SessionEvent_Progress{
+			Progress: &controlplanev1.Progress{Message: r.Text, Percent: r.Percent},
+		}
+	case "turn_ended":
+		te := &controlplanev1.TurnEnded{StopReason: stringToStopReason(r.StopReason), CancelReason: stringToCancelReason(r.Reason)}
+		if s := r.TurnStats; s != nil {
+			te.Stats = &controlplanev1.TurnStats{
+				DurationMs:         s.DurationMs,
+				TimeToFirstTokenMs: s.TimeToFirstTokenMs,
+				ToolCalls:          s.ToolCalls,
+				InputTokens:        s.InputTokens,
+				OutputTokens:       s.OutputTokens,
+			}
+		}
+		e.Payload = &controlplanev1.SessionEvent_TurnEnded{TurnEnded: te}
+	case "agent_plan":
+		e.Payload = &controlplanev1.SessionEvent_AgentPlan{
+			AgentPlan: recordPlanEntriesToCP(r.PlanEntries),
+		}
+	case "plan_submitted":
+		e.Payload = &controlplanev1.SessionEvent_PlanSubmitted{
+			PlanSubmitted: &controlplanev1.PlanSubmitted{Plans: recordPlansToCP(r.Plans)},
 		}
 	}
 
 	return e
 }
 
-// MarshalRecord serializes a SessionEventRecord to JSON bytes.
+// MarshalRecord serializes a SessionEventRecord to JSON bytes, gzipped if
+// they exceed CompressPayloadThreshold.
 func MarshalRecord(r SessionEventRecord) ([]byte, error) {
-	return json.Marshal(r)
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	data, _, err = CompressPayload(data)
+	return data, err
 }
 
-// UnmarshalRecord deserializes JSON bytes into a SessionEventRecord.
+// UnmarshalRecord deserializes bytes written by MarshalRecord, compressed
+// or not, into a SessionEventRecord.
 func UnmarshalRecord(data []byte) (SessionEventRecord, error) {
 	var r SessionEventRecord
+	data, err := DecompressPayload(data)
+	if err != nil {
+		return r, err
+	}
 	if err := json.Unmarshal(data, &r); err != nil {
 		return r, fmt.Errorf("unmarshal event record: %w", err)
 	}
@@ -209,6 +513,47 @@ func toolCallKindToString(k workerv1.ToolCallKind) string {
 	}
 }
 
+func sessionErrorReasonToString(r workerv1.SessionErrorReason) string {
+	switch r {
+	case workerv1.SessionErrorReason_SESSION_ERROR_REASON_AUTH:
+		return "auth"
+	default:
+		return ""
+	}
+}
+
+func cancelReasonToString(r workerv1.CancelReason) string {
+	switch r {
+	case workerv1.CancelReason_CANCEL_REASON_USER:
+		return "user"
+	case workerv1.CancelReason_CANCEL_REASON_TIMEOUT:
+		return "timeout"
+	case workerv1.CancelReason_CANCEL_REASON_LIMIT:
+		return "limit"
+	default:
+		return ""
+	}
+}
+
+// stopReasonToString returns the ACP name of a stop reason, as stored in
+// records.
+func stopReasonToString(r workerv1.StopReason) string {
+	switch r {
+	case workerv1.StopReason_STOP_REASON_END_TURN:
+		return "end_turn"
+	case workerv1.StopReason_STOP_REASON_MAX_TOKENS:
+		return "max_tokens"
+	case workerv1.StopReason_STOP_REASON_MAX_TURN_REQUESTS:
+		return "max_turn_requests"
+	case workerv1.StopReason_STOP_REASON_REFUSAL:
+		return "refusal"
+	case workerv1.StopReason_STOP_REASON_CANCELLED:
+		return "cancelled"
+	default:
+		return ""
+	}
+}
+
 func toolCallStatusToString(s workerv1.ToolCallStatus) string {
 	switch s {
 	case workerv1.ToolCallStatus_TOOL_CALL_STATUS_IN_PROGRESS:
@@ -260,6 +605,36 @@ func stringToToolCallStatus(s string) controlplanev1.ToolCallStatus {
 	}
 }
 
+func stringToCancelReason(s string) controlplanev1.CancelReason {
+	switch s {
+	case "user":
+		return controlplanev1.CancelReason_CANCEL_REASON_USER
+	case "timeout":
+		return controlplanev1.CancelReason_CANCEL_REASON_TIMEOUT
+	case "limit":
+		return controlplanev1.CancelReason_CANCEL_REASON_LIMIT
+	default:
+		return controlplanev1.CancelReason_CANCEL_REASON_UNSPECIFIED
+	}
+}
+
+func stringToStopReason(s string) controlplanev1.StopReason {
+	switch s {
+	case "end_turn":
+		return controlplanev1.StopReason_STOP_REASON_END_TURN
+	case "max_tokens":
+		return controlplanev1.StopReason_STOP_REASON_MAX_TOKENS
+	case "max_turn_requests":
+		return controlplanev1.StopReason_STOP_REASON_MAX_TURN_REQUESTS
+	case "refusal":
+		return controlplanev1.StopReason_STOP_REASON_REFUSAL
+	case "cancelled":
+		return controlplanev1.StopReason_STOP_REASON_CANCELLED
+	default:
+		return controlplanev1.StopReason_STOP_REASON_UNSPECIFIED
+	}
+}
+
 // --- Helper converters ---
 
 func locationsToRecord(locs []*workerv1.ToolCallLocation) []LocationRecord {
@@ -292,6 +667,13 @@ func contentBlocksToRecord(blocks []*workerv1.ToolCallContentBlock) []ContentBlo
 				Type: "text",
 				Text: cb.Text.GetText(),
 			})
+		case *workerv1.ToolCallContentBlock_CommandOutput:
+			out = append(out, ContentBlockRecord{
+				Type:     "command_output",
+				Stdout:   cb.CommandOutput.GetStdout(),
+				Stderr:   cb.CommandOutput.GetStderr(),
+				ExitCode: cb.CommandOutput.ExitCode,
+			})
 		}
 	}
 	return out
@@ -322,7 +704,159 @@ func recordContentBlocksToCP(blocks []ContentBlockRecord) []*controlplanev1.Tool
 					},
 				},
 			})
+		case "command_output":
+			out = append(out, &controlplanev1.ToolCallContentBlock{
+				Block: &controlplanev1.ToolCallContentBlock_CommandOutput{
+					CommandOutput: &controlplanev1.ToolCallCommandOutput{
+						Stdout:   b.Stdout,
+						Stderr:   b.Stderr,
+						ExitCode: b.ExitCode,
+					},
+				},
+			})
+		}
+	}
+	return out
+}
+
+func plansToRecord(plans []*workerv1.Plan) []PlanRecord {
+	out := make([]PlanRecord, 0, len(plans))
+	for _, p := range plans {
+		pr := PlanRecord{ThreadID: p.GetThreadId(), Title: p.GetTitle(), Body: p.GetBody()}
+		for _, s := range p.GetSteps() {
+			pr.Steps = append(pr.Steps, PlanStepRecord{
+				ID:          s.GetId(),
+				File:        s.GetFile(),
+				Description: s.GetDescription(),
+				DependsOn:   s.GetDependsOn(),
+				Agent:       s.GetAgent(),
+				Subtasks:    s.GetSubtasks(),
+			})
+		}
+		out = append(out, pr)
+	}
+	return out
+}
+
+func recordPlansToCP(plans []PlanRecord) []*controlplanev1.Plan {
+	out := make([]*controlplanev1.Plan, 0, len(plans))
+	for _, p := range plans {
+		cp := &controlplanev1.Plan{ThreadId: p.ThreadID, Title: p.Title, Body: p.Body}
+		for _, s := range p.Steps {
+			cp.Steps = append(cp.Steps, &controlplanev1.PlanStep{
+				Id:          s.ID,
+				File:        s.File,
+				Description: s.Description,
+				DependsOn:   s.DependsOn,
+				Agent:       s.Agent,
+				Subtasks:    s.Subtasks,
+			})
 		}
+		out = append(out, cp)
 	}
 	return out
 }
+
+func agentPlanToRecord(p *workerv1.AgentPlan) []PlanEntryRecord {
+	var out []PlanEntryRecord
+	for _, e := range p.GetEntries() {
+		out = append(out, PlanEntryRecord{Content: e.GetContent(), Priority: e.GetPriority(), Status: e.GetStatus()})
+	}
+	return out
+}
+
+func recordPlanEntriesToCP(entries []PlanEntryRecord) *controlplanev1.AgentPlan {
+	p := &controlplanev1.AgentPlan{}
+	for _, e := range entries {
+		p.Entries = append(p.Entries, &controlplanev1.AgentPlanEntry{Content: e.Content, Priority: e.Priority, Status: e.Status})
+	}
+	return p
+}
+
+func toolInputToRecord(in *workerv1.ToolInput) *ToolInputRecord {
+	switch t := in.GetTool().(type) {
+	case *workerv1.ToolInput_Read:
+		return &ToolInputRecord{Tool: "read", FilePath: t.Read.GetFilePath(), Offset: t.Read.Offset, Limit: t.Read.Limit}
+	case *workerv1.ToolInput_Write:
+		return &ToolInputRecord{Tool: "write", FilePath: t.Write.GetFilePath(), Content: t.Write.GetContent()}
+	case *workerv1.ToolInput_Edit:
+		return &ToolInputRecord{
+			Tool:       "edit",
+			FilePath:   t.Edit.GetFilePath(),
+			OldString:  t.Edit.GetOldString(),
+			NewString:  t.Edit.GetNewString(),
+			ReplaceAll: t.Edit.GetReplaceAll(),
+		}
+	case *workerv1.ToolInput_Bash:
+		return &ToolInputRecord{
+			Tool:            "bash",
+			Command:         t.Bash.GetCommand(),
+			Description:     t.Bash.GetDescription(),
+			TimeoutMs:       t.Bash.TimeoutMs,
+			RunInBackground: t.Bash.GetRunInBackground(),
+		}
+	case *workerv1.ToolInput_Grep:
+		return &ToolInputRecord{
+			Tool:            "grep",
+			Pattern:         t.Grep.GetPattern(),
+			Path:            t.Grep.GetPath(),
+			Glob:            t.Grep.GetGlob(),
+			FileType:        t.Grep.GetType(),
+			OutputMode:      t.Grep.GetOutputMode(),
+			CaseInsensitive: t.Grep.GetCaseInsensitive(),
+		}
+	case *workerv1.ToolInput_Glob:
+		return &ToolInputRecord{Tool: "glob", Pattern: t.Glob.GetPattern(), Path: t.Glob.GetPath()}
+	default:
+		return nil
+	}
+}
+
+func recordToolInputToCP(r *ToolInputRecord) *controlplanev1.ToolInput {
+	if r == nil {
+		return nil
+	}
+	switch r.Tool {
+	case "read":
+		return &controlplanev1.ToolInput{Tool: &controlplanev1.ToolInput_Read{Read: &controlplanev1.ToolInputRead{
+			FilePath: r.FilePath,
+			Offset:   r.Offset,
+			Limit:    r.Limit,
+		}}}
+	case "write":
+		return &controlplanev1.ToolInput{Tool: &controlplanev1.ToolInput_Write{Write: &controlplanev1.ToolInputWrite{
+			FilePath: r.FilePath,
+			Content:  r.Content,
+		}}}
+	case "edit":
+		return &controlplanev1.ToolInput{Tool: &controlplanev1.ToolInput_Edit{Edit: &controlplanev1.ToolInputEdit{
+			FilePath:   r.FilePath,
+			OldString:  r.OldString,
+			NewString:  r.NewString,
+			ReplaceAll: r.ReplaceAll,
+		}}}
+	case "bash":
+		return &controlplanev1.ToolInput{Tool: &controlplanev1.ToolInput_Bash{Bash: &controlplanev1.ToolInputBash{
+			Command:         r.Command,
+			Description:     r.Description,
+			TimeoutMs:       r.TimeoutMs,
+			RunInBackground: r.RunInBackground,
+		}}}
+	case "grep":
+		return &controlplanev1.ToolInput{Tool: &controlplanev1.ToolInput_Grep{Grep: &controlplanev1.ToolInputGrep{
+			Pattern:         r.Pattern,
+			Path:            r.Path,
+			Glob:            r.Glob,
+			Type:            r.FileType,
+			OutputMode:      r.OutputMode,
+			CaseInsensitive: r.CaseInsensitive,
+		}}}
+	case "glob":
+		return &controlplanev1.ToolInput{Tool: &controlplanev1.ToolInput_Glob{Glob: &controlplanev1.ToolInputGlob{
+			Pattern: r.Pattern,
+			Path:    r.Path,
+		}}}
+	default:
+		return nil
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	controlplanev1 "github.com/sebastianm/flowgentic/internal/proto/gen/controlplane/v1"
 	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
 )
 
@@ -132,6 +133,75 @@ func TestRoundTrip_CurrentModeUpdate(t *testing.T) {
 	assert.Equal(t, "architect", cpEvent.GetCurrentModeUpdate().ModeId)
 }
 
+func TestRoundTrip_CurrentModelUpdate(t *testing.T) {
+	event := &workerv1.SessionEvent{
+		SessionId: "sess-1",
+		Sequence:  4,
+		Timestamp: "2024-01-01T00:00:03Z",
+		Payload: &workerv1.SessionEvent_CurrentModelUpdate{
+			CurrentModelUpdate: &workerv1.CurrentModelUpdate{ModelId: "claude-sonnet"},
+		},
+	}
+
+	record := WorkerEventToRecord(event)
+	assert.Equal(t, "current_model_update", record.Type)
+	assert.Equal(t, "claude-sonnet", record.ModelID)
+
+	data, err := MarshalRecord(record)
+	require.NoError(t, err)
+
+	restored, err := UnmarshalRecord(data)
+	require.NoError(t, err)
+
+	cpEvent := RecordToCPEvent(restored)
+	assert.Equal(t, "claude-sonnet", cpEvent.GetCurrentModelUpdate().ModelId)
+}
+
+func TestRoundTrip_CommandOutputNonzeroExit(t *testing.T) {
+	exitCode := int32(2)
+	event := &workerv1.SessionEvent{
+		SessionId: "sess-1",
+		Sequence:  6,
+		Timestamp: "2024-01-01T00:00:05Z",
+		Payload: &workerv1.SessionEvent_ToolCallUpdate{
+			ToolCallUpdate: &workerv1.ToolCallUpdate{
+				ToolCallId: "tc-1",
+				Status:     workerv1.ToolCallStatus_TOOL_CALL_STATUS_FAILED,
+				Content: []*workerv1.ToolCallContentBlock{
+					{Block: &workerv1.ToolCallContentBlock_CommandOutput{
+						CommandOutput: &workerv1.ToolCallCommandOutput{
+							Stdout:   "compiling\n",
+							Stderr:   "error: boom\n",
+							ExitCode: &exitCode,
+						},
+					}},
+				},
+			},
+		},
+	}
+
+	record := WorkerEventToRecord(event)
+	require.Len(t, record.Content, 1)
+	assert.Equal(t, "command_output", record.Content[0].Type)
+	require.NotNil(t, record.Content[0].ExitCode)
+	assert.Equal(t, int32(2), *record.Content[0].ExitCode)
+
+	data, err := MarshalRecord(record)
+	require.NoError(t, err)
+	restored, err := UnmarshalRecord(data)
+	require.NoError(t, err)
+
+	cpEvent := RecordToCPEvent(restored)
+	blocks := cpEvent.GetToolCallUpdate().GetContent()
+	require.Len(t, blocks, 1)
+	co := blocks[0].GetCommandOutput()
+	require.NotNil(t, co)
+	assert.Equal(t, "compiling\n", co.Stdout)
+	assert.Equal(t, "error: boom\n", co.Stderr)
+	require.NotNil(t, co.ExitCode)
+	assert.Equal(t, int32(2), co.GetExitCode())
+}
+
 func TestRoundTrip_ToolCallUpdate(t *testing.T) {
 	event := &workerv1.SessionEvent{
 		SessionId: "sess-1",
@@ -166,3 +236,479 @@ func TestRoundTrip_ToolCallUpdate(t *testing.T) {
 	assert.Equal(t, "tc-1", cpEvent.GetToolCallUpdate().ToolCallId)
 	assert.Len(t, cpEvent.GetToolCallUpdate().Content, 1)
 }
+
+func TestRoundTrip_SessionError(t *testing.T) {
+	event := &workerv1.SessionEvent{
+		SessionId: "sess-1",
+		Sequence:  5,
+		Timestamp: "2024-01-01T00:00:04Z",
+		Payload: &workerv1.SessionEvent_SessionError{
+			SessionError: &workerv1.SessionError{
+				Reason:  workerv1.SessionErrorReason_SESSION_ERROR_REASON_AUTH,
+				Message: "Invalid API key · Please run /login",
+			},
+		},
+	}
+
+	record := WorkerEventToRecord(event)
+	assert.Equal(t, "session_error", record.Type)
+	assert.Equal(t, "auth", record.Reason)
+
+	data, err := MarshalRecord(record)
+	require.NoError(t, err)
+
+	restored, err := UnmarshalRecord(data)
+	require.NoError(t, err)
+
+	cpEvent := RecordToCPEvent(restored)
+	require.NotNil(t, cpEvent.GetSessionError())
+	assert.Equal(t, "auth", cpEvent.GetSessionError().Reason)
+	assert.Equal(t, "Invalid API key · Please run /login", cpEvent.GetSessionError().Message)
+}
+
+func TestRoundTrip_ErroredStatusChange(t *testing.T) {
+	event := &workerv1.SessionEvent{
+		SessionId: "sess-1",
+		Sequence:  6,
+		Timestamp: "2024-01-01T00:00:05Z",
+		Payload: &workerv1.SessionEvent_StatusChange{
+			StatusChange: &workerv1.StatusChange{
+				Status: workerv1.SessionStatus_SESSION_STATUS_ERRORED,
+				Error: &workerv1.SessionError{
+					Message:    "agent codex exited unexpectedly",
+					StderrTail: []string{"thread 'main' panicked", "note: run with RUST_BACKTRACE=1"},
+				},
+			},
+		},
+	}
+
+	data, err := MarshalRecord(WorkerEventToRecord(event))
+	require.NoError(t, err)
+	restored, err := UnmarshalRecord(data)
+	require.NoError(t, err)
+
+	sc := RecordToCPEvent(restored).GetStatusChange()
+	require.NotNil(t, sc)
+	assert.Equal(t, "SESSION_STATUS_ERRORED", sc.Status)
+	require.NotNil(t, sc.Error)
+	assert.Equal(t, "agent codex exited unexpectedly", sc.Error.Message)
+	assert.Empty(t, sc.Error.Reason)
+	assert.Equal(t, []string{"thread 'main' panicked", "note: run with RUST_BACKTRACE=1"}, sc.Error.StderrTail)
+}
+
+func TestRoundTrip_StatusChangeAcceptingInput(t *testing.T) {
+	event := &workerv1.SessionEvent{
+		SessionId: "sess-1",
+		Sequence:  7,
+		Timestamp: "2024-01-01T00:00:06Z",
+		Payload: &workerv1.SessionEvent_StatusChange{
+			StatusChange: &workerv1.StatusChange{Status: workerv1.SessionStatus_SESSION_STATUS_IDLE, AcceptingInput: true},
+		},
+	}
+
+	data, err := MarshalRecord(WorkerEventToRecord(event))
+	require.NoError(t, err)
+	restored, err := UnmarshalRecord(data)
+	require.NoError(t, err)
+
+	sc := RecordToCPEvent(restored).GetStatusChange()
+	require.NotNil(t, sc)
+	assert.Equal(t, "SESSION_STATUS_IDLE", sc.Status)
+	assert.True(t, sc.AcceptingInput)
+}
+
+func TestRoundTrip_MCPServerStartup(t *testing.T) {
+	event := &workerv1.SessionEvent{
+		SessionId: "sess-1",
+		Sequence:  2,
+		Timestamp: "2024-01-01T00:00:01Z",
+		Payload: &workerv1.SessionEvent_McpServerStartup{
+			McpServerStartup: &workerv1.McpServerStartup{
+				Server: "flowgentic",
+				Status: "failed",
+				Text:   "[mcp startup] flowgentic - failed",
+			},
+		},
+	}
+
+	record := WorkerEventToRecord(event)
+	assert.Equal(t, "mcp_server_startup", record.Type)
+	data, err := MarshalRecord(record)
+	require.NoError(t, err)
+	restored, err := UnmarshalRecord(data)
+	require.NoError(t, err)
+
+	ms := RecordToCPEvent(restored).GetMcpServerStartup()
+	require.NotNil(t, ms)
+	assert.Equal(t, "flowgentic", ms.Server)
+	assert.Equal(t, "failed", ms.Status)
+	assert.False(t, ms.Ready)
+	assert.Equal(t, "[mcp startup] flowgentic - failed", ms.Text)
+}
+
+func TestRoundTrip_SessionInit(t *testing.T) {
+	event := &workerv1.SessionEvent{
+		SessionId: "sess-1",
+		Sequence:  2,
+		Timestamp: "2024-01-01T00:00:01Z",
+		Payload: &workerv1.SessionEvent_SessionInit{
+			SessionInit: &workerv1.SessionInit{
+				Model:          "claude-sonnet-4-5",
+				PermissionMode: "acceptEdits",
+				Tools:          []string{"Bash", "Edit"},
+				Text:           "Session started: model claude-sonnet-4-5, permission mode acceptEdits, 2 tools",
+			},
+		},
+	}
+
+	record := WorkerEventToRecord(event)
+	assert.Equal(t, "session_init", record.Type)
+	data, err := MarshalRecord(record)
+	require.NoError(t, err)
+	restored, err := UnmarshalRecord(data)
+	require.NoError(t, err)
+
+	si := RecordToCPEvent(restored).GetSessionInit()
+	require.NotNil(t, si)
+	assert.Equal(t, "claude-sonnet-4-5", si.Model)
+	assert.Equal(t, "acceptEdits", si.PermissionMode)
+	assert.Equal(t, []string{"Bash", "Edit"}, si.Tools)
+	assert.Equal(t, "Session started: model claude-sonnet-4-5, permission mode acceptEdits, 2 tools", si.Text)
+}
+
+func TestRoundTrip_Progress(t *testing.T) {
+	roundTrip := func(p *workerv1.Progress) *controlplanev1.Progress {
+		t.Helper()
+		record := WorkerEventToRecord(&workerv1.SessionEvent{
+			SessionId: "sess-1",
+			Sequence:  3,
+			Timestamp: "2024-01-01T00:00:02Z",
+			Payload:   &workerv1.SessionEvent_Progress{Progress: p},
+		})
+		assert.Equal(t, "progress", record.Type)
+		data, err := MarshalRecord(record)
+		require.NoError(t, err)
+		restored, err := UnmarshalRecord(data)
+		require.NoError(t, err)
+		out := RecordToCPEvent(restored).GetProgress()
+		require.NotNil(t, out)
+		return out
+	}
+
+	zero := int32(0)
+	got := roundTrip(&workerv1.Progress{Message: "starting", Percent: &zero})
+	assert.Equal(t, "starting", got.Message)
+	require.NotNil(t, got.Percent, "0% survives the round trip")
+	assert.Equal(t, int32(0), *got.Percent)
+
+	got = roundTrip(&workerv1.Progress{Message: "analyzing files"})
+	assert.Equal(t, "analyzing files", got.Message)
+	assert.Nil(t, got.Percent)
+}
+
+func TestRoundTrip_TurnEnded(t *testing.T) {
+	for _, reason := range []workerv1.CancelReason{
+		workerv1.CancelReason_CANCEL_REASON_USER,
+		workerv1.CancelReason_CANCEL_REASON_TIMEOUT,
+		workerv1.CancelReason_CANCEL_REASON_LIMIT,
+	} {
+		evt := &workerv1.SessionEvent{
+			SessionId: "sess-1",
+			Sequence:  4,
+			Timestamp: "2024-01-01T00:00:03Z",
+			Payload: &workerv1.SessionEvent_TurnEnded{
+				TurnEnded: &workerv1.TurnEnded{StopReason: workerv1.StopReason_STOP_REASON_CANCELLED, CancelReason: reason},
+			},
+		}
+		record := WorkerEventToRecord(evt)
+		assert.Equal(t, "turn_ended", record.Type)
+		data, err := MarshalRecord(record)
+		require.NoError(t, err)
+		restored, err := UnmarshalRecord(data)
+		require.NoError(t, err)
+
+		got := RecordToCPEvent(restored).GetTurnEnded()
+		require.NotNil(t, got)
+		assert.Equal(t, controlplanev1.StopReason_STOP_REASON_CANCELLED, got.StopReason)
+		assert.Equal(t, reason.String(), got.CancelReason.String())
+		assert.Equal(t, got.CancelReason, workerEventToCPEvent(evt).GetTurnEnded().GetCancelReason(), "live events match stored ones")
+	}
+}
+
+func TestRoundTrip_TurnEndedStopReasons(t *testing.T) {
+	for _, reason := range []workerv1.StopReason{
+		workerv1.StopReason_STOP_REASON_END_TURN,
+		workerv1.StopReason_STOP_REASON_MAX_TOKENS,
+		workerv1.StopReason_STOP_REASON_MAX_TURN_REQUESTS,
+		workerv1.StopReason_STOP_REASON_REFUSAL,
+	} {
+		evt := &workerv1.SessionEvent{
+			SessionId: "sess-1",
+			Sequence:  4,
+			Timestamp: "2024-01-01T00:00:03Z",
+			Payload: &workerv1.SessionEvent_TurnEnded{
+				TurnEnded: &workerv1.TurnEnded{StopReason: reason},
+			},
+		}
+		data, err := MarshalRecord(WorkerEventToRecord(evt))
+		require.NoError(t, err)
+		restored, err := UnmarshalRecord(data)
+		require.NoError(t, err)
+
+		got := RecordToCPEvent(restored).GetTurnEnded()
+		require.NotNil(t, got)
+		assert.Equal(t, reason.String(), got.StopReason.String())
+		assert.Equal(t, controlplanev1.CancelReason_CANCEL_REASON_UNSPECIFIED, got.CancelReason)
+		assert.Equal(t, got.StopReason, workerEventToCPEvent(evt).GetTurnEnded().GetStopReason(), "live events match stored ones")
+	}
+}
+
+func TestRoundTrip_TurnEndedStats(t *testing.T) {
+	evt := &workerv1.SessionEvent{
+		SessionId: "sess-1",
+		Sequence:  4,
+		Timestamp: "2024-01-01T00:00:03Z",
+		Payload: &workerv1.SessionEvent_TurnEnded{
+			TurnEnded: &workerv1.TurnEnded{
+				StopReason: workerv1.StopReason_STOP_REASON_END_TURN,
+				Stats:      &workerv1.TurnStats{DurationMs: 4200, TimeToFirstTokenMs: 850, ToolCalls: 3, InputTokens: 1200, OutputTokens: 345},
+			},
+		},
+	}
+	data, err := MarshalRecord(WorkerEventToRecord(evt))
+	require.NoError(t, err)
+	restored, err := UnmarshalRecord(data)
+	require.NoError(t, err)
+
+	got := RecordToCPEvent(restored).GetTurnEnded().GetStats()
+	require.NotNil(t, got)
+	assert.Equal(t, int64(4200), got.DurationMs)
+	assert.Equal(t, int64(850), got.TimeToFirstTokenMs)
+	assert.Equal(t, int32(3), got.ToolCalls)
+	assert.Equal(t, int64(1200), got.InputTokens)
+	assert.Equal(t, int64(345), got.OutputTokens)
+	assert.Equal(t, got.String(), workerEventToCPEvent(evt).GetTurnEnded().GetStats().String(), "live events match stored ones")
+}
+
+func TestRoundTrip_AgentPlan(t *testing.T) {
+	evt := &workerv1.SessionEvent{
+		SessionId: "sess-1",
+		Sequence:  5,
+		Timestamp: "2024-01-01T00:00:04Z",
+		Payload: &workerv1.SessionEvent_AgentPlan{
+			AgentPlan: &workerv1.AgentPlan{Entries: []*workerv1.AgentPlanEntry{
+				{Content: "read", Priority: "high", Status: "completed"},
+				{Content: "write", Priority: "low", Status: "pending"},
+			}},
+		},
+	}
+	data, err := MarshalRecord(WorkerEventToRecord(evt))
+	require.NoError(t, err)
+	restored, err := UnmarshalRecord(data)
+	require.NoError(t, err)
+	assert.Equal(t, "agent_plan", restored.Type)
+
+	got := RecordToCPEvent(restored).GetAgentPlan()
+	require.NotNil(t, got)
+	require.Len(t, got.Entries, 2)
+	assert.Equal(t, "read", got.Entries[0].Content)
+	assert.Equal(t, "completed", got.Entries[0].Status)
+	assert.Equal(t, "low", got.Entries[1].Priority)
+	assert.Len(t, workerEventToCPEvent(evt).GetAgentPlan().GetEntries(), 2, "live events match stored ones")
+}
+
+func TestRoundTrip_PermissionEvents(t *testing.T) {
+	request := &workerv1.SessionEvent{
+		SessionId: "sess-1",
+		Sequence:  6,
+		Timestamp: "2024-01-01T00:00:05Z",
+		Payload: &workerv1.SessionEvent_PermissionRequest{
+			PermissionRequest: &workerv1.PermissionRequest{
+				RequestId: "call-1",
+				Title:     "rm -rf build",
+				Kind:      workerv1.ToolCallKind_TOOL_CALL_KIND_EXECUTE,
+				Options: []*workerv1.PermissionOption{
+					{OptionId: "allow", Name: "Allow", Kind: "allow_once"},
+					{OptionId: "reject", Name: "Reject", Kind: "reject_once"},
+				},
+			},
+		},
+	}
+	resolved := &workerv1.SessionEvent{
+		SessionId: "sess-1",
+		Sequence:  7,
+		Timestamp: "2024-01-01T00:00:06Z",
+		Payload: &workerv1.SessionEvent_PermissionResolved{
+			PermissionResolved: &workerv1.PermissionResolved{RequestId: "call-1", OptionId: "allow", Outcome: "allowed"},
+		},
+	}
+
+	roundTrip := func(e *workerv1.SessionEvent) (SessionEventRecord, *controlplanev1.SessionEvent) {
+		record := WorkerEventToRecord(e)
+		data, err := MarshalRecord(record)
+		require.NoError(t, err)
+		restored, err := UnmarshalRecord(data)
+		require.NoError(t, err)
+		return restored, RecordToCPEvent(restored)
+	}
+
+	record, cpEvent := roundTrip(request)
+	assert.Equal(t, "permission_request", record.Type)
+	assert.Equal(t, "execute", record.Kind)
+	pr := cpEvent.GetPermissionRequest()
+	require.NotNil(t, pr)
+	assert.Equal(t, "call-1", pr.RequestId)
+	assert.Equal(t, "rm -rf build", pr.Title)
+	assert.Equal(t, controlplanev1.ToolCallKind_TOOL_CALL_KIND_EXECUTE, pr.Kind)
+	require.Len(t, pr.Options, 2)
+	assert.Equal(t, "reject", pr.Options[1].OptionId)
+	assert.Equal(t, "Reject", pr.Options[1].Name)
+	assert.Equal(t, "reject_once", pr.Options[1].Kind)
+
+	record, cpEvent = roundTrip(resolved)
+	assert.Equal(t, "permission_resolved", record.Type)
+	res := cpEvent.GetPermissionResolved()
+	require.NotNil(t, res)
+	assert.Equal(t, "call-1", res.RequestId)
+	assert.Equal(t, "allow", res.OptionId)
+	assert.Equal(t, "allowed", res.Outcome)
+}
+
+func TestRoundTrip_EventsPruned(t *testing.T) {
+	event := &workerv1.SessionEvent{
+		SessionId: "sess-1",
+		Sequence:  42,
+		Payload: &workerv1.SessionEvent_EventsPruned{
+			EventsPruned: &workerv1.EventsPruned{Count: 12},
+		},
+	}
+
+	record := WorkerEventToRecord(event)
+	assert.Equal(t, "events_pruned", record.Type)
+
+	data, err := MarshalRecord(record)
+	require.NoError(t, err)
+	restored, err := UnmarshalRecord(data)
+	require.NoError(t, err)
+
+	cpEvent := RecordToCPEvent(restored)
+	require.NotNil(t, cpEvent.GetEventsPruned())
+	assert.Equal(t, int64(12), cpEvent.GetEventsPruned().Count)
+}
+
+func TestRoundTrip_PlanSubmitted(t *testing.T) {
+	event := &workerv1.SessionEvent{
+		SessionId: "sess-1",
+		Sequence:  7,
+		Payload: &workerv1.SessionEvent_PlanSubmitted{
+			PlanSubmitted: &workerv1.PlanSubmitted{Plans: []*workerv1.Plan{{
+				ThreadId: "th-1",
+				Title:    "Add caching",
+				Body:     "Cache lookups.",
+				Steps: []*workerv1.PlanStep{
+					{Id: "store", File: "01-store.md", Description: "Add the store."},
+					{Id: "wire", File: "02-wire.md", DependsOn: []string{"store"}, Agent: "codex"},
+				},
+			}}},
+		},
+	}
+
+	record := WorkerEventToRecord(event)
+	assert.Equal(t, "plan_submitted", record.Type)
+
+	data, err := MarshalRecord(record)
+	require.NoError(t, err)
+	restored, err := UnmarshalRecord(data)
+	require.NoError(t, err)
+
+	cpEvent := RecordToCPEvent(restored)
+	require.NotNil(t, cpEvent.GetPlanSubmitted())
+	plans := cpEvent.GetPlanSubmitted().Plans
+	require.Len(t, plans, 1)
+	assert.Equal(t, "th-1", plans[0].ThreadId)
+	assert.Equal(t, "Add caching", plans[0].Title)
+	require.Len(t, plans[0].Steps, 2)
+	assert.Equal(t, "01-store.md", plans[0].Steps[0].File)
+	assert.Equal(t, []string{"store"}, plans[0].Steps[1].DependsOn)
+	assert.Equal(t, "codex", plans[0].Steps[1].Agent)
+}
+
+func TestRoundTrip_ToolCallMetadata(t *testing.T) {
+	metadata := map[string]string{"claudeCode.toolName": "Bash", "background": "true"}
+	events := []*workerv1.SessionEvent{
+		{SessionId: "sess-1", Sequence: 9, Payload: &workerv1.SessionEvent_ToolCall{
+			ToolCall: &workerv1.ToolCall{ToolCallId: "tc-1", Metadata: metadata},
+		}},
+		{SessionId: "sess-1", Sequence: 10, Payload: &workerv1.SessionEvent_ToolCallUpdate{
+			ToolCallUpdate: &workerv1.ToolCallUpdate{ToolCallId: "tc-1", Metadata: metadata},
+		}},
+	}
+
+	var restored []SessionEventRecord
+	for _, event := range events {
+		data, err := MarshalRecord(WorkerEventToRecord(event))
+		require.NoError(t, err)
+		r, err := UnmarshalRecord(data)
+		require.NoError(t, err)
+		assert.Equal(t, "Bash", r.Metadata["claudeCode.toolName"], "%s keeps the tool name", r.Type)
+		restored = append(restored, r)
+	}
+	assert.Equal(t, metadata, RecordToCPEvent(restored[0]).GetToolCall().GetMetadata())
+	assert.Equal(t, metadata, RecordToCPEvent(restored[1]).GetToolCallUpdate().GetMetadata())
+
+	plain, err := MarshalRecord(WorkerEventToRecord(&workerv1.SessionEvent{Payload: &workerv1.SessionEvent_ToolCall{
+		ToolCall: &workerv1.ToolCall{ToolCallId: "tc-2"},
+	}}))
+	require.NoError(t, err)
+	assert.NotContains(t, string(plain), "metadata")
+}
+
+func TestRoundTrip_AutoContinueUserMessage(t *testing.T) {
+	event := &workerv1.SessionEvent{SessionId: "sess-1", Sequence: 4, Payload: &workerv1.SessionEvent_UserMessage{
+		UserMessage: &workerv1.UserMessage{Text: "Continue.", AutoContinueIteration: 2},
+	}}
+
+	data, err := MarshalRecord(WorkerEventToRecord(event))
+	require.NoError(t, err)
+	r, err := UnmarshalRecord(data)
+	require.NoError(t, err)
+	msg := RecordToCPEvent(r).GetUserMessage()
+	assert.Equal(t, "Continue.", msg.GetText())
+	assert.Equal(t, int32(2), msg.GetAutoContinueIteration())
+}
+
+func TestRoundTrip_ToolInput(t *testing.T) {
+	timeout := int64(60000)
+	event := &workerv1.SessionEvent{
+		SessionId: "sess-1",
+		Sequence:  8,
+		Payload: &workerv1.SessionEvent_ToolCall{
+			ToolCall: &workerv1.ToolCall{
+				ToolCallId: "tc-1",
+				RawInput:   `{"command":"go test ./..."}`,
+				Input: &workerv1.ToolInput{Tool: &workerv1.ToolInput_Bash{Bash: &workerv1.ToolInputBash{
+					Command:   "go test ./...",
+					TimeoutMs: &timeout,
+				}}},
+			},
+		},
+	}
+
+	record := WorkerEventToRecord(event)
+	require.NotNil(t, record.Input)
+	assert.Equal(t, "bash", record.Input.Tool)
+
+	data, err := MarshalRecord(record)
+	require.NoError(t, err)
+	restored, err := UnmarshalRecord(data)
+	require.NoError(t, err)
+
+	tc := RecordToCPEvent(restored).GetToolCall()
+	require.NotNil(t, tc)
+	assert.Equal(t, `{"command":"go test ./..."}`, tc.RawInput)
+	bash := tc.GetInput().GetBash()
+	require.NotNil(t, bash)
+	assert.Equal(t, "go test ./...", bash.Command)
+	require.NotNil(t, bash.TimeoutMs)
+	assert.Equal(t, int64(60000), *bash.TimeoutMs)
+}
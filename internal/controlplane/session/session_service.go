@@ -3,6 +3,7 @@ package session
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -22,10 +23,16 @@ type Session struct {
 	SessionMode string
 	SessionID   string
 	TaskID      string
-	CreatedAt   time.Time
-	UpdatedAt   time.Time
+	// IdempotencyKey is the key the session was created with, if any.
+	IdempotencyKey string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
 }
 
+// ErrIdempotencyKeyReused is returned when an idempotency key is used again
+// for a different thread.
+var ErrIdempotencyKeyReused = errors.New("idempotency key already used for another thread")
+
 // SessionEvent is the domain type for a raw persisted event.
 type SessionEvent struct {
 	SessionID string
@@ -38,14 +45,21 @@ type SessionEvent struct {
 type Store interface {
 	CreateSession(ctx context.Context, s Session) error
 	GetSession(ctx context.Context, id string) (Session, error)
+	// GetSessionByIdempotencyKey returns an error wrapping sql.ErrNoRows if
+	// no session was created with key.
+	GetSessionByIdempotencyKey(ctx context.Context, key string) (Session, error)
 	ListSessionsByThread(ctx context.Context, threadID string) ([]Session, error)
 	ListPendingSessions(ctx context.Context, limit int64) ([]Session, error)
 	UpdateSessionStatus(ctx context.Context, id, status, sessionID string) error
+	UpdateSessionMode(ctx context.Context, id, sessionMode string) error
 	GetCwdForSession(ctx context.Context, sessionID string) (string, error)
 	InsertSessionEvent(ctx context.Context, evt SessionEvent) error
 	ListSessionEventsBySession(ctx context.Context, sessionID string) ([]SessionEvent, error)
 	ListSessionEventsByThread(ctx context.Context, threadID string) ([]SessionEvent, error)
 	ListSessionEventsByTask(ctx context.Context, taskID sql.NullString) ([]SessionEvent, error)
+	// ListThreadSummaries returns a page of the project's threads with their
+	// most recently created session, ordered by UpdatedAt and then ThreadID.
+	ListThreadSummaries(ctx context.Context, projectID string, page ThreadPage) ([]ThreadSummary, error)
 }
 
 type SessionService struct {
@@ -53,43 +67,79 @@ type SessionService struct {
 	reconciler *Reconciler
 	registry   WorkerRegistry
 
-	mu               sync.Mutex
-	eventSubscribers map[chan SessionEventUpdate]struct{}
+	mu                   sync.Mutex
+	eventSubscribers     map[chan SessionEventUpdate]struct{}
+	lifecycleSubscribers map[chan LifecycleEvent]struct{}
+	// latestSequence maps a session ID to the highest event sequence
+	// broadcast or loaded from history; see LatestEventSequence.
+	latestSequence map[string]int64
 }
 
 func NewSessionService(store Store, reconciler *Reconciler, registry WorkerRegistry) *SessionService {
 	return &SessionService{
-		store:            store,
-		reconciler:       reconciler,
-		registry:         registry,
-		eventSubscribers: make(map[chan SessionEventUpdate]struct{}),
+		store:                store,
+		reconciler:           reconciler,
+		registry:             registry,
+		eventSubscribers:     make(map[chan SessionEventUpdate]struct{}),
+		lifecycleSubscribers: make(map[chan LifecycleEvent]struct{}),
+		latestSequence:       make(map[string]int64),
 	}
 }
 
-func (s *SessionService) CreateSessionForThread(ctx context.Context, threadID, workerID, prompt, agent, model, mode, sessionMode string) (string, error) {
-	id := uuid.Must(uuid.NewV7()).String()
+// CreateSessionForThread creates a pending session and returns its ID. With
+// a non-empty idempotencyKey, a session already created with that key is
+// returned instead and created is false.
+func (s *SessionService) CreateSessionForThread(ctx context.Context, threadID, workerID, prompt, agent, model, mode, sessionMode, idempotencyKey string) (id string, created bool, err error) {
+	if idempotencyKey != "" {
+		if id, err := s.sessionForKey(ctx, threadID, idempotencyKey); err == nil || !errors.Is(err, sql.ErrNoRows) {
+			return id, false, err
+		}
+	}
+
+	id = uuid.Must(uuid.NewV7()).String()
 	now := time.Now().UTC()
 
 	sess := Session{
-		ID:          id,
-		ThreadID:    threadID,
-		WorkerID:    workerID,
-		Prompt:      prompt,
-		Status:      "pending",
-		Agent:       agent,
-		Model:       model,
-		Mode:        mode,
-		SessionMode: sessionMode,
-		CreatedAt:   now,
-		UpdatedAt:   now,
+		ID:             id,
+		ThreadID:       threadID,
+		WorkerID:       workerID,
+		Prompt:         prompt,
+		Status:         "pending",
+		Agent:          agent,
+		Model:          model,
+		Mode:           mode,
+		SessionMode:    sessionMode,
+		IdempotencyKey: idempotencyKey,
+		CreatedAt:      now,
+		UpdatedAt:      now,
 	}
 
 	if err := s.store.CreateSession(ctx, sess); err != nil {
-		return "", fmt.Errorf("creating session: %w", err)
+		if idempotencyKey != "" {
+			// A concurrent create with the same key may have won the insert.
+			if existing, lookupErr := s.sessionForKey(ctx, threadID, idempotencyKey); lookupErr == nil || errors.Is(lookupErr, ErrIdempotencyKeyReused) {
+				return existing, false, lookupErr
+			}
+		}
+		return "", false, fmt.Errorf("creating session: %w", err)
 	}
 
+	s.PublishLifecycle(newLifecycleEvent(sess, LifecycleCreated, sess.Status))
 	s.reconciler.Notify()
-	return id, nil
+	return id, true, nil
+}
+
+// sessionForKey returns the ID of the session created with key, which must
+// belong to threadID.
+func (s *SessionService) sessionForKey(ctx context.Context, threadID, key string) (string, error) {
+	sess, err := s.store.GetSessionByIdempotencyKey(ctx, key)
+	if err != nil {
+		return "", err
+	}
+	if sess.ThreadID != threadID {
+		return "", ErrIdempotencyKeyReused
+	}
+	return sess.ID, nil
 }
 
 func (s *SessionService) GetSession(ctx context.Context, id string) (Session, error) {
@@ -143,6 +193,7 @@ func (s *SessionService) UnsubscribeEvents(ch chan SessionEventUpdate) {
 func (s *SessionService) BroadcastEvent(evt SessionEventUpdate) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
+	s.latestSequence[evt.SessionID] = max(s.latestSequence[evt.SessionID], evt.Event.GetSequence())
 	for ch := range s.eventSubscribers {
 		select {
 		case ch <- evt:
@@ -151,19 +202,76 @@ func (s *SessionService) BroadcastEvent(evt SessionEventUpdate) {
 	}
 }
 
+// SubscribeLifecycle returns a channel that receives session lifecycle
+// events.
+func (s *SessionService) SubscribeLifecycle() chan LifecycleEvent {
+	ch := make(chan LifecycleEvent, 64)
+	s.mu.Lock()
+	s.lifecycleSubscribers[ch] = struct{}{}
+	s.mu.Unlock()
+	return ch
+}
+
+// UnsubscribeLifecycle removes a lifecycle subscriber channel.
+func (s *SessionService) UnsubscribeLifecycle(ch chan LifecycleEvent) {
+	s.mu.Lock()
+	delete(s.lifecycleSubscribers, ch)
+	s.mu.Unlock()
+}
+
+// PublishLifecycle implements LifecyclePublisher. A session that ended
+// broadcasts no more events, so its latest sequence is forgotten.
+func (s *SessionService) PublishLifecycle(evt LifecycleEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if evt.Kind == LifecycleStopped || evt.Kind == LifecycleErrored {
+		delete(s.latestSequence, evt.SessionID)
+	}
+	for ch := range s.lifecycleSubscribers {
+		select {
+		case ch <- evt:
+		default: // drop if slow
+		}
+	}
+}
+
 // --- Event History ---
 
 func (s *SessionService) LoadEventHistory(ctx context.Context, sessionID, threadID, taskID string) ([]SessionEvent, error) {
+	var events []SessionEvent
+	var err error
 	switch {
 	case sessionID != "":
-		return s.store.ListSessionEventsBySession(ctx, sessionID)
+		events, err = s.store.ListSessionEventsBySession(ctx, sessionID)
 	case threadID != "":
-		return s.store.ListSessionEventsByThread(ctx, threadID)
+		events, err = s.store.ListSessionEventsByThread(ctx, threadID)
 	case taskID != "":
-		return s.store.ListSessionEventsByTask(ctx, sql.NullString{String: taskID, Valid: true})
+		events, err = s.store.ListSessionEventsByTask(ctx, sql.NullString{String: taskID, Valid: true})
 	default:
 		return nil, fmt.Errorf("one of session_id, thread_id, or task_id must be set")
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	for _, e := range events {
+		s.latestSequence[e.SessionID] = max(s.latestSequence[e.SessionID], e.Sequence)
+	}
+	s.mu.Unlock()
+	return events, nil
+}
+
+// LatestEventSequence returns the highest sequence among the session's events
+// broadcast since the service started or loaded by LoadEventHistory, and
+// false if there were none. Every stored event of the session has a sequence
+// no higher than it: events are stored before they are broadcast, and
+// merged chunks take the sequence of their last chunk.
+func (s *SessionService) LatestEventSequence(sessionID string) (int64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seq, ok := s.latestSequence[sessionID]
+	return seq, ok
 }
 
 // ListSessionIDsForThread returns the IDs of all sessions belonging to a thread.
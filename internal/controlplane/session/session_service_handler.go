@@ -2,12 +2,17 @@ package session
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"net/http"
+	"slices"
+	"strings"
+	"time"
 
 	"connectrpc.com/connect"
 
+	"github.com/sebastianm/flowgentic/internal/connectutil"
+	"github.com/sebastianm/flowgentic/internal/promptutil"
 	controlplanev1 "github.com/sebastianm/flowgentic/internal/proto/gen/controlplane/v1"
 	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
 	"github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1/workerv1connect"
@@ -18,11 +23,18 @@ type ThreadTopicUpdater interface {
 	UpdateTopic(ctx context.Context, id, topic string) error
 }
 
+// defaultHeartbeatInterval is how often WatchSessionEvents sends a keepalive
+// on an otherwise idle stream when no interval is configured.
+const defaultHeartbeatInterval = 15 * time.Second
+
 type sessionServiceHandler struct {
 	log                *slog.Logger
 	svc                *SessionService
 	store              Store
 	threadTopicUpdater ThreadTopicUpdater
+	heartbeatInterval  time.Duration
+	maxPromptBytes     int
+	maxAttachmentBytes int
 }
 
 func (h *sessionServiceHandler) CreateSession(
@@ -39,17 +51,23 @@ func (h *sessionServiceHandler) CreateSession(
 	if msg.Prompt == "" {
 		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("prompt is required"))
 	}
+	if err := promptutil.Validate(h.maxPromptBytes, msg.Prompt); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
 	if msg.Agent == "" {
 		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("agent is required"))
 	}
 
-	sessionID, err := h.svc.CreateSessionForThread(ctx, msg.ThreadId, msg.WorkerId, msg.Prompt, msg.Agent, msg.Model, msg.Mode, msg.SessionMode)
+	sessionID, created, err := h.svc.CreateSessionForThread(ctx, msg.ThreadId, msg.WorkerId, msg.Prompt, msg.Agent, msg.Model, msg.Mode, msg.SessionMode, msg.IdempotencyKey)
+	if errors.Is(err, ErrIdempotencyKeyReused) {
+		return nil, connect.NewError(connect.CodeFailedPrecondition, err)
+	}
 	if err != nil {
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("creating session: %w", err))
 	}
 
 	// Derive topic from prompt and update thread.
-	if topic := deriveInitialTopic(msg.Prompt); topic != "" {
+	if topic := deriveInitialTopic(msg.Prompt); created && topic != "" {
 		if err := h.threadTopicUpdater.UpdateTopic(ctx, msg.ThreadId, topic); err != nil {
 			h.log.Error("failed to update thread topic", "thread_id", msg.ThreadId, "error", err)
 		}
@@ -106,6 +124,46 @@ func (h *sessionServiceHandler) ListSessions(
 	}), nil
 }
 
+func (h *sessionServiceHandler) ListThreads(
+	ctx context.Context,
+	req *connect.Request[controlplanev1.SessionServiceListThreadsRequest],
+) (*connect.Response[controlplanev1.SessionServiceListThreadsResponse], error) {
+	if req.Msg.ProjectId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("project_id is required"))
+	}
+	if req.Msg.PageSize < 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("page_size must not be negative"))
+	}
+
+	threads, next, err := h.svc.ListThreads(ctx, req.Msg.ProjectId, ThreadListOptions{
+		OldestFirst: req.Msg.OldestFirst,
+		PageSize:    int(req.Msg.PageSize),
+		PageToken:   req.Msg.PageToken,
+	})
+	if errors.Is(err, ErrInvalidPageToken) {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	pbThreads := make([]*controlplanev1.ThreadSummary, len(threads))
+	for i, t := range threads {
+		pbThreads[i] = &controlplanev1.ThreadSummary{
+			ThreadId:            t.ThreadID,
+			Topic:               t.Topic,
+			LatestSessionId:     t.LatestSessionID,
+			LatestSessionStatus: t.LatestSessionStatus,
+			UpdatedAt:           t.UpdatedAt.UTC().Format("2006-01-02T15:04:05.000Z"),
+		}
+	}
+
+	return connect.NewResponse(&controlplanev1.SessionServiceListThreadsResponse{
+		Threads:       pbThreads,
+		NextPageToken: next,
+	}), nil
+}
+
 func (h *sessionServiceHandler) SetSessionMode(
 	ctx context.Context,
 	req *connect.Request[controlplanev1.SetSessionModeRequest],
@@ -130,7 +188,7 @@ func (h *sessionServiceHandler) SetSessionMode(
 	}
 
 	client := workerv1connect.NewWorkerServiceClient(
-		http.DefaultClient,
+		connectutil.Client,
 		workerURL,
 		connect.WithInterceptors(secretInterceptor(secret)),
 	)
@@ -139,6 +197,10 @@ func (h *sessionServiceHandler) SetSessionMode(
 		ModeId:    modeID,
 	}))
 	if err != nil {
+		// The worker rejects modes the agent does not offer.
+		if connectErr := new(connect.Error); errors.As(err, &connectErr) && connectErr.Code() == connect.CodeInvalidArgument {
+			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New(connectErr.Message()))
+		}
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("forward to worker: %w", err))
 	}
 
@@ -153,9 +215,16 @@ func (h *sessionServiceHandler) SendUserMessage(
 	if threadID == "" {
 		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("thread_id is required"))
 	}
-	text := req.Msg.Text
-	if text == "" {
-		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("text is required"))
+	var blocks []*controlplanev1.PromptContentBlock
+	if text := req.Msg.Text; text != "" {
+		blocks = append(blocks, &controlplanev1.PromptContentBlock{Type: "text", Text: text})
+	}
+	blocks = append(blocks, req.Msg.ContentBlocks...)
+	if len(blocks) == 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("text or content_blocks is required"))
+	}
+	if err := h.validatePrompt(blocks); err != nil {
+		return nil, err
 	}
 
 	sess, err := h.svc.FindActiveSessionForThread(ctx, threadID)
@@ -164,7 +233,7 @@ func (h *sessionServiceHandler) SendUserMessage(
 		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("no active session: %w", err))
 	}
 
-	h.log.Info("SendUserMessage: forwarding to worker", "thread_id", threadID, "session_id", sess.ID, "worker_id", sess.WorkerID, "session_status", sess.Status)
+	h.log.Info("SendUserMessage: forwarding to worker", "thread_id", threadID, "session_id", sess.ID, "worker_id", sess.WorkerID, "session_status", sess.Status, "blocks", len(blocks))
 
 	// Forward to the worker.
 	workerURL, secret, ok := h.svc.LookupWorker(sess.WorkerID)
@@ -174,18 +243,20 @@ func (h *sessionServiceHandler) SendUserMessage(
 	}
 
 	client := workerv1connect.NewWorkerServiceClient(
-		http.DefaultClient,
+		connectutil.Client,
 		workerURL,
 		connect.WithInterceptors(secretInterceptor(secret)),
 	)
 	_, err = client.SendUserMessage(ctx, connect.NewRequest(&workerv1.SendUserMessageRequest{
-		SessionId: sess.ID,
-		ContentBlocks: []*workerv1.ContentBlock{
-			{Type: "text", Text: text},
-		},
+		SessionId:     sess.ID,
+		ContentBlocks: promptBlocksToWorker(blocks),
 	}))
 	if err != nil {
 		h.log.Error("SendUserMessage: forward to worker failed", "session_id", sess.ID, "error", err)
+		// The worker rejects blocks it cannot build an ACP prompt from.
+		if connectErr := new(connect.Error); errors.As(err, &connectErr) && connectErr.Code() == connect.CodeInvalidArgument {
+			return nil, connect.NewError(connect.CodeInvalidArgument, errors.New(connectErr.Message()))
+		}
 		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("forward to worker: %w", err))
 	}
 	h.log.Info("SendUserMessage: completed", "session_id", sess.ID)
@@ -198,8 +269,16 @@ func (h *sessionServiceHandler) WatchSessionEvents(
 	req *connect.Request[controlplanev1.WatchSessionEventsRequest],
 	stream *connect.ServerStream[controlplanev1.WatchSessionEventsResponse],
 ) error {
-	msg := req.Msg
+	return h.watchSessionEvents(ctx, req.Msg, stream.Send)
+}
 
+// watchSessionEvents sends the stored events of msg's scope after
+// msg.AfterSequence, then live events, to send until ctx is done.
+func (h *sessionServiceHandler) watchSessionEvents(
+	ctx context.Context,
+	msg *controlplanev1.WatchSessionEventsRequest,
+	send func(*controlplanev1.WatchSessionEventsResponse) error,
+) error {
 	// Build dynamic scope matcher for live events.
 	matchesScope, err := h.buildScopeMatcher(msg)
 	if err != nil {
@@ -210,6 +289,15 @@ func (h *sessionServiceHandler) WatchSessionEvents(
 	ch := h.svc.SubscribeEvents()
 	defer h.svc.UnsubscribeEvents(ch)
 
+	// A client that already has every stored event of the session skips
+	// the history scan. Subscribing first means an event broadcast after
+	// the check still arrives live.
+	if msg.SessionId != "" && msg.AfterSequence > 0 {
+		if latest, ok := h.svc.LatestEventSequence(msg.SessionId); ok && msg.AfterSequence >= latest {
+			return h.streamLiveEvents(ctx, ch, matchesScope, send)
+		}
+	}
+
 	// 1. Replay raw events from SQLite (history catch-up).
 	events, err := h.svc.LoadEventHistory(ctx, msg.SessionId, msg.ThreadId, msg.TaskId)
 	if err != nil {
@@ -228,7 +316,7 @@ func (h *sessionServiceHandler) WatchSessionEvents(
 			continue
 		}
 
-		if err := stream.Send(&controlplanev1.WatchSessionEventsResponse{
+		if err := send(&controlplanev1.WatchSessionEventsResponse{
 			Event:     cpEvent,
 			IsHistory: true,
 		}); err != nil {
@@ -237,10 +325,36 @@ func (h *sessionServiceHandler) WatchSessionEvents(
 	}
 
 	// 2. Live events via pub-sub.
+	return h.streamLiveEvents(ctx, ch, matchesScope, send)
+}
+
+// streamLiveEvents forwards matching live events to send until ctx is done.
+// Whenever no event has been sent for the heartbeat interval, it sends a
+// heartbeat so proxies do not drop the idle stream.
+func (h *sessionServiceHandler) streamLiveEvents(
+	ctx context.Context,
+	ch <-chan SessionEventUpdate,
+	matchesScope func(context.Context, string) (bool, error),
+	send func(*controlplanev1.WatchSessionEventsResponse) error,
+) error {
+	interval := h.heartbeatInterval
+	if interval <= 0 {
+		interval = defaultHeartbeatInterval
+	}
+	heartbeat := time.NewTimer(interval)
+	defer heartbeat.Stop()
+
 	for {
 		select {
 		case <-ctx.Done():
 			return nil
+		case <-heartbeat.C:
+			if err := send(&controlplanev1.WatchSessionEventsResponse{
+				Heartbeat: &controlplanev1.Heartbeat{Timestamp: time.Now().UTC().Format(time.RFC3339Nano)},
+			}); err != nil {
+				return err
+			}
+			heartbeat.Reset(interval)
 		case evt := <-ch:
 			match, err := matchesScope(ctx, evt.SessionID)
 			if err != nil {
@@ -250,16 +364,219 @@ func (h *sessionServiceHandler) WatchSessionEvents(
 			if !match {
 				continue
 			}
-			if err := stream.Send(&controlplanev1.WatchSessionEventsResponse{
+			if err := send(&controlplanev1.WatchSessionEventsResponse{
 				Event:     workerEventToCPEvent(evt.Event),
 				IsHistory: false,
 			}); err != nil {
 				return err
 			}
+			heartbeat.Reset(interval)
 		}
 	}
 }
 
+// WatchSessionLifecycle streams lifecycle events of sessions, optionally
+// limited to one thread, with heartbeats while idle. There is no history
+// replay; ListSessions gives the current state.
+func (h *sessionServiceHandler) WatchSessionLifecycle(
+	ctx context.Context,
+	req *connect.Request[controlplanev1.WatchSessionLifecycleRequest],
+	stream *connect.ServerStream[controlplanev1.WatchSessionLifecycleResponse],
+) error {
+	threadID := req.Msg.ThreadId
+	ch := h.svc.SubscribeLifecycle()
+	defer h.svc.UnsubscribeLifecycle(ch)
+
+	interval := h.heartbeatInterval
+	if interval <= 0 {
+		interval = defaultHeartbeatInterval
+	}
+	heartbeat := time.NewTimer(interval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-heartbeat.C:
+			if err := stream.Send(&controlplanev1.WatchSessionLifecycleResponse{
+				Heartbeat: &controlplanev1.Heartbeat{Timestamp: time.Now().UTC().Format(time.RFC3339Nano)},
+			}); err != nil {
+				return err
+			}
+			heartbeat.Reset(interval)
+		case evt := <-ch:
+			if threadID != "" && evt.ThreadID != threadID {
+				continue
+			}
+			if err := stream.Send(&controlplanev1.WatchSessionLifecycleResponse{
+				Event: lifecycleEventToProto(evt),
+			}); err != nil {
+				return err
+			}
+			heartbeat.Reset(interval)
+		}
+	}
+}
+
+func (h *sessionServiceHandler) SendPrompt(
+	ctx context.Context,
+	req *connect.Request[controlplanev1.SendPromptRequest],
+) (*connect.Response[controlplanev1.SendPromptResponse], error) {
+	msg := req.Msg
+	if msg.ThreadId == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("thread_id is required"))
+	}
+	if len(msg.ContentBlocks) == 0 {
+		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("content_blocks is required"))
+	}
+	if err := h.validatePrompt(msg.ContentBlocks); err != nil {
+		return nil, err
+	}
+
+	sess, err := h.svc.FindActiveSessionForThread(ctx, msg.ThreadId)
+	if err != nil {
+		h.log.Error("SendPrompt: no active session", "thread_id", msg.ThreadId, "error", err)
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("no active session: %w", err))
+	}
+
+	workerURL, secret, ok := h.svc.LookupWorker(sess.WorkerID)
+	if !ok {
+		h.log.Error("SendPrompt: worker not reachable", "worker_id", sess.WorkerID)
+		return nil, connect.NewError(connect.CodeFailedPrecondition, fmt.Errorf("worker %s not reachable", sess.WorkerID))
+	}
+
+	blocks := promptBlocksToWorker(msg.ContentBlocks)
+
+	h.log.Info("SendPrompt: forwarding to worker", "thread_id", msg.ThreadId, "session_id", sess.ID, "worker_id", sess.WorkerID, "blocks", len(blocks))
+
+	client := workerv1connect.NewWorkerServiceClient(
+		connectutil.Client,
+		workerURL,
+		connect.WithInterceptors(secretInterceptor(secret)),
+	)
+	resp, err := client.Prompt(ctx, connect.NewRequest(&workerv1.PromptRequest{
+		SessionId:     sess.ID,
+		ContentBlocks: blocks,
+		Model:         msg.Model,
+		SessionMode:   msg.SessionMode,
+	}))
+	if err != nil {
+		h.log.Error("SendPrompt: forward to worker failed", "session_id", sess.ID, "error", err)
+		return nil, connect.NewError(connect.CodeInternal, fmt.Errorf("forward to worker: %w", err))
+	}
+
+	return connect.NewResponse(&controlplanev1.SendPromptResponse{
+		StopReason: resp.Msg.StopReason,
+	}), nil
+}
+
+// validatePrompt checks the text of prompt content blocks against the
+// prompt size limit and for characters that would break the agent's
+// JSON-RPC framing, and their attachment data against its own limit.
+func (h *sessionServiceHandler) validatePrompt(blocks []*controlplanev1.PromptContentBlock) error {
+	texts := make([]string, 0, len(blocks))
+	data := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		texts = append(texts, b.Text)
+		data = append(data, b.Data)
+	}
+	if err := promptutil.Validate(h.maxPromptBytes, texts...); err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if err := promptutil.ValidateAttachments(h.maxAttachmentBytes, data...); err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	return nil
+}
+
+// promptBlocksToWorker converts prompt content blocks for the worker, which
+// checks that each block is complete for its type.
+func promptBlocksToWorker(blocks []*controlplanev1.PromptContentBlock) []*workerv1.ContentBlock {
+	out := make([]*workerv1.ContentBlock, 0, len(blocks))
+	for _, b := range blocks {
+		out = append(out, &workerv1.ContentBlock{
+			Type:     b.Type,
+			Text:     b.Text,
+			MimeType: b.MimeType,
+			Data:     b.Data,
+			Uri:      b.Uri,
+		})
+	}
+	return out
+}
+
+// ExportSession renders the stored events of a session as a transcript in
+// the requested format, Markdown by default.
+func (h *sessionServiceHandler) ExportSession(
+	ctx context.Context,
+	req *connect.Request[controlplanev1.ExportSessionRequest],
+) (*connect.Response[controlplanev1.ExportSessionResponse], error) {
+	msg := req.Msg
+
+	events, err := h.svc.LoadEventHistory(ctx, msg.SessionId, "", "")
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	if len(events) == 0 {
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("no events found for session %q", msg.SessionId))
+	}
+
+	t := &Transcript{}
+	for _, e := range events {
+		cpEvent, err := deserializeAndConvertEvent(e)
+		if err != nil {
+			h.log.Warn("export session: failed to deserialize event",
+				"session_id", e.SessionID, "sequence", e.Sequence, "error", err)
+			continue
+		}
+		t.Add(cpEvent)
+	}
+
+	var b strings.Builder
+	resp := &controlplanev1.ExportSessionResponse{}
+	switch msg.Format {
+	case controlplanev1.ExportFormat_EXPORT_FORMAT_JSON:
+		err = t.WriteJSON(&b, msg.SessionId)
+		resp.ContentType = "application/json"
+	default:
+		err = t.WriteMarkdown(&b, msg.SessionId)
+		resp.ContentType = "text/markdown"
+	}
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	resp.Content = b.String()
+	return connect.NewResponse(resp), nil
+}
+
+func (h *sessionServiceHandler) GetPlan(
+	ctx context.Context,
+	req *connect.Request[controlplanev1.GetPlanRequest],
+) (*connect.Response[controlplanev1.GetPlanResponse], error) {
+	events, err := h.svc.LoadEventHistory(ctx, req.Msg.SessionId, "", "")
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+
+	// The latest submission replaces earlier ones.
+	for _, e := range slices.Backward(events) {
+		cpEvent, err := deserializeAndConvertEvent(e)
+		if err != nil {
+			h.log.Warn("get plan: failed to deserialize event",
+				"session_id", e.SessionID, "sequence", e.Sequence, "error", err)
+			continue
+		}
+		if p := cpEvent.GetPlanSubmitted(); p != nil {
+			return connect.NewResponse(&controlplanev1.GetPlanResponse{
+				Plans:       p.GetPlans(),
+				SubmittedAt: cpEvent.GetTimestamp(),
+			}), nil
+		}
+	}
+	return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("no plan submitted in session %q", req.Msg.SessionId))
+}
+
 // deserializeAndConvertEvent deserializes a stored JSON event payload and converts it to a CP-side SessionEvent.
 func deserializeAndConvertEvent(e SessionEvent) (*controlplanev1.SessionEvent, error) {
 	record, err := UnmarshalRecord(e.Payload)
@@ -358,6 +675,8 @@ func workerEventToCPEvent(w *workerv1.SessionEvent) *controlplanev1.SessionEvent
 			Kind:       controlplanev1.ToolCallKind(tc.GetKind()),
 			RawInput:   tc.GetRawInput(),
 			Status:     controlplanev1.ToolCallStatus(tc.GetStatus()),
+			Input:      recordToolInputToCP(toolInputToRecord(tc.GetInput())),
+			Metadata:   tc.GetMetadata(),
 		}
 		for _, loc := range tc.GetLocations() {
 			cpTc.Locations = append(cpTc.Locations, &controlplanev1.ToolCallLocation{
@@ -376,6 +695,8 @@ func workerEventToCPEvent(w *workerv1.SessionEvent) *controlplanev1.SessionEvent
 			Title:      tc.GetTitle(),
 			Status:     controlplanev1.ToolCallStatus(tc.GetStatus()),
 			RawOutput:  tc.GetRawOutput(),
+			Input:      recordToolInputToCP(toolInputToRecord(tc.GetInput())),
+			Metadata:   tc.GetMetadata(),
 		}
 		for _, loc := range tc.GetLocations() {
 			cpTc.Locations = append(cpTc.Locations, &controlplanev1.ToolCallLocation{
@@ -388,16 +709,120 @@ func workerEventToCPEvent(w *workerv1.SessionEvent) *controlplanev1.SessionEvent
 		}
 		e.Payload = &controlplanev1.SessionEvent_ToolCallUpdate{ToolCallUpdate: cpTc}
 	case *workerv1.SessionEvent_StatusChange:
-		e.Payload = &controlplanev1.SessionEvent_StatusChange{
-			StatusChange: &controlplanev1.StatusChange{Status: p.StatusChange.GetStatus().String()},
+		sc := &controlplanev1.StatusChange{
+			Status:         p.StatusChange.GetStatus().String(),
+			AcceptingInput: p.StatusChange.GetAcceptingInput(),
 		}
+		if se := p.StatusChange.GetError(); se != nil {
+			sc.Error = &controlplanev1.SessionError{
+				Reason:     sessionErrorReasonToString(se.GetReason()),
+				Message:    se.GetMessage(),
+				StderrTail: se.GetStderrTail(),
+			}
+		}
+		e.Payload = &controlplanev1.SessionEvent_StatusChange{StatusChange: sc}
 	case *workerv1.SessionEvent_CurrentModeUpdate:
 		e.Payload = &controlplanev1.SessionEvent_CurrentModeUpdate{
 			CurrentModeUpdate: &controlplanev1.CurrentModeUpdate{ModeId: p.CurrentModeUpdate.GetModeId()},
 		}
 	case *workerv1.SessionEvent_UserMessage:
 		e.Payload = &controlplanev1.SessionEvent_UserMessage{
-			UserMessage: &controlplanev1.UserMessage{Text: p.UserMessage.GetText()},
+			UserMessage: &controlplanev1.UserMessage{
+				Text:                  p.UserMessage.GetText(),
+				AutoContinueIteration: p.UserMessage.GetAutoContinueIteration(),
+			},
+		}
+	case *workerv1.SessionEvent_CurrentModelUpdate:
+		e.Payload = &controlplanev1.SessionEvent_CurrentModelUpdate{
+			CurrentModelUpdate: &controlplanev1.CurrentModelUpdate{ModelId: p.CurrentModelUpdate.GetModelId()},
+		}
+	case *workerv1.SessionEvent_SessionError:
+		e.Payload = &controlplanev1.SessionEvent_SessionError{
+			SessionError: &controlplanev1.SessionError{
+				Reason:     sessionErrorReasonToString(p.SessionError.GetReason()),
+				Message:    p.SessionError.GetMessage(),
+				StderrTail: p.SessionError.GetStderrTail(),
+			},
+		}
+	case *workerv1.SessionEvent_PermissionRequest:
+		pr := p.PermissionRequest
+		cpPr := &controlplanev1.PermissionRequest{
+			RequestId: pr.GetRequestId(),
+			Title:     pr.GetTitle(),
+			Kind:      controlplanev1.ToolCallKind(pr.GetKind()),
+		}
+		for _, o := range pr.GetOptions() {
+			cpPr.Options = append(cpPr.Options, &controlplanev1.PermissionOption{
+				OptionId: o.GetOptionId(),
+				Name:     o.GetName(),
+				Kind:     o.GetKind(),
+			})
+		}
+		e.Payload = &controlplanev1.SessionEvent_PermissionRequest{PermissionRequest: cpPr}
+	case *workerv1.SessionEvent_PermissionResolved:
+		e.Payload = &controlplanev1.SessionEvent_PermissionResolved{
+			PermissionResolved: &controlplanev1.PermissionResolved{
+				RequestId: p.PermissionResolved.GetRequestId(),
+				OptionId:  p.PermissionResolved.GetOptionId(),
+				Outcome:   p.PermissionResolved.GetOutcome(),
+			},
+		}
+	case *workerv1.SessionEvent_EventsPruned:
+		e.Payload = &controlplanev1.SessionEvent_EventsPruned{
+			EventsPruned: &controlplanev1.EventsPruned{Count: p.EventsPruned.GetCount()},
+		}
+	case *workerv1.SessionEvent_McpServerStartup:
+		ms := p.McpServerStartup
+		e.Payload = &controlplanev1.SessionEvent_McpServerStartup{
+			McpServerStartup: &controlplanev1.McpServerStartup{
+				Server:  ms.GetServer(),
+				Status:  ms.GetStatus(),
+				Message: ms.GetMessage(),
+				Ready:   ms.GetReady(),
+				Text:    ms.GetText(),
+			},
+		}
+	case *workerv1.SessionEvent_SessionInit:
+		si := p.SessionInit
+		e.Payload = &controlplanev1.SessionEvent_SessionInit{
+			SessionInit: &controlplanev1.SessionInit{
+				Model:          si.GetModel(),
+				PermissionMode: si.GetPermissionMode(),
+				Tools:          si.GetTools(),
+				Text:           si.GetText(),
+			},
+		}
+	case *workerv1.SessionEvent_Progress:
+		e.Payload = &controlplanev1.SessionEvent_Progress{
+			Progress: &controlplanev1.Progress{
+				Message: p.Progress.GetMessage(),
+				Percent: p.Progress.Percent,
+			},
+		}
+	case *workerv1.SessionEvent_TurnEnded:
+		te := &controlplanev1.TurnEnded{
+			StopReason:   stringToStopReason(stopReasonToString(p.TurnEnded.GetStopReason())),
+			CancelReason: stringToCancelReason(cancelReasonToString(p.TurnEnded.GetCancelReason())),
+		}
+		if s := p.TurnEnded.GetStats(); s != nil {
+			te.Stats = &controlplanev1.TurnStats{
+				DurationMs:         s.GetDurationMs(),
+				TimeToFirstTokenMs: s.GetTimeToFirstTokenMs(),
+				ToolCalls:          s.GetToolCalls(),
+				InputTokens:        s.GetInputTokens(),
+				OutputTokens:       s.GetOutputTokens(),
+			}
+		}
+		e.Payload = &controlplanev1.SessionEvent_TurnEnded{TurnEnded: te}
+	case *workerv1.SessionEvent_AgentPlan:
+		e.Payload = &controlplanev1.SessionEvent_AgentPlan{
+			AgentPlan: recordPlanEntriesToCP(agentPlanToRecord(p.AgentPlan)),
+		}
+	case *workerv1.SessionEvent_PlanSubmitted:
+		e.Payload = &controlplanev1.SessionEvent_PlanSubmitted{
+			PlanSubmitted: &controlplanev1.PlanSubmitted{
+				Plans: recordPlansToCP(plansToRecord(p.PlanSubmitted.GetPlans())),
+			},
 		}
 	}
 
@@ -425,6 +850,16 @@ func workerContentBlockToCP(cb *workerv1.ToolCallContentBlock) *controlplanev1.T
 				},
 			},
 		}
+	case *workerv1.ToolCallContentBlock_CommandOutput:
+		return &controlplanev1.ToolCallContentBlock{
+			Block: &controlplanev1.ToolCallContentBlock_CommandOutput{
+				CommandOutput: &controlplanev1.ToolCallCommandOutput{
+					Stdout:   b.CommandOutput.GetStdout(),
+					Stderr:   b.CommandOutput.GetStderr(),
+					ExitCode: b.CommandOutput.ExitCode,
+				},
+			},
+		}
 	default:
 		return &controlplanev1.ToolCallContentBlock{}
 	}
@@ -0,0 +1,673 @@
+package session
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	controlplanev1 "github.com/sebastianm/flowgentic/internal/proto/gen/controlplane/v1"
+	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
+	"github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1/workerv1connect"
+)
+
+// recordingStream collects responses sent by streamLiveEvents.
+type recordingStream struct {
+	mu   sync.Mutex
+	sent []*controlplanev1.WatchSessionEventsResponse
+}
+
+func (r *recordingStream) send(resp *controlplanev1.WatchSessionEventsResponse) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, resp)
+	return nil
+}
+
+func (r *recordingStream) snapshot() []*controlplanev1.WatchSessionEventsResponse {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]*controlplanev1.WatchSessionEventsResponse(nil), r.sent...)
+}
+
+func (r *recordingStream) heartbeats() int {
+	n := 0
+	for _, resp := range r.snapshot() {
+		if resp.Heartbeat != nil {
+			n++
+		}
+	}
+	return n
+}
+
+func matchAll(context.Context, string) (bool, error) { return true, nil }
+
+func TestStreamLiveEvents_HeartbeatsDuringSilence(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	h := &sessionServiceHandler{log: slog.Default(), heartbeatInterval: interval}
+	ch := make(chan SessionEventUpdate)
+	stream := &recordingStream{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- h.streamLiveEvents(ctx, ch, matchAll, stream.send) }()
+
+	require.Eventually(t, func() bool { return stream.heartbeats() >= 2 }, time.Second, 5*time.Millisecond)
+	for _, resp := range stream.snapshot() {
+		assert.Nil(t, resp.Event, "heartbeats carry no event")
+		assert.False(t, resp.IsHistory)
+		assert.NotEmpty(t, resp.Heartbeat.Timestamp)
+	}
+
+	// While events keep flowing faster than the interval, no heartbeat is sent.
+	before := stream.heartbeats()
+	for i := range 10 {
+		ch <- SessionEventUpdate{SessionID: "sess-1", Event: makeMessageChunk("sess-1", "hi", int64(i+1))}
+		time.Sleep(interval / 5)
+	}
+	assert.Equal(t, before, stream.heartbeats(), "no heartbeats while events flow")
+
+	var events int
+	for _, resp := range stream.snapshot() {
+		if resp.Event != nil {
+			assert.Nil(t, resp.Heartbeat)
+			events++
+		}
+	}
+	assert.Equal(t, 10, events)
+
+	// Silence again resumes heartbeats.
+	require.Eventually(t, func() bool { return stream.heartbeats() > before }, time.Second, 5*time.Millisecond)
+
+	cancel()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("streamLiveEvents did not return after cancel")
+	}
+}
+
+// fakeSessionStore serves ListSessionsByThread from a fixed list; other Store
+// methods are not used by these tests.
+type fakeSessionStore struct {
+	Store
+	sessions []Session
+}
+
+func (f *fakeSessionStore) ListSessionsByThread(_ context.Context, threadID string) ([]Session, error) {
+	var out []Session
+	for _, s := range f.sessions {
+		if s.ThreadID == threadID {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+type fakeRegistry map[string]string
+
+func (r fakeRegistry) Lookup(workerID string) (string, string, bool) {
+	url, ok := r[workerID]
+	return url, "worker-secret", ok
+}
+
+// fakeWorker records Prompt and SendUserMessage calls and answers with a
+// fixed stop reason.
+type fakeWorker struct {
+	workerv1connect.UnimplementedWorkerServiceHandler
+	mu       sync.Mutex
+	prompts  []*workerv1.PromptRequest
+	messages []*workerv1.SendUserMessageRequest
+	auth     string
+}
+
+func (w *fakeWorker) SendUserMessage(_ context.Context, req *connect.Request[workerv1.SendUserMessageRequest]) (*connect.Response[workerv1.SendUserMessageResponse], error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.messages = append(w.messages, req.Msg)
+	w.auth = req.Header().Get("Authorization")
+	return connect.NewResponse(&workerv1.SendUserMessageResponse{StopReason: "end_turn"}), nil
+}
+
+func (w *fakeWorker) Prompt(_ context.Context, req *connect.Request[workerv1.PromptRequest]) (*connect.Response[workerv1.PromptResponse], error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.prompts = append(w.prompts, req.Msg)
+	w.auth = req.Header().Get("Authorization")
+	return connect.NewResponse(&workerv1.PromptResponse{StopReason: "end_turn"}), nil
+}
+
+func TestSendPrompt_ForwardsToWorker(t *testing.T) {
+	worker := &fakeWorker{}
+	mux := http.NewServeMux()
+	mux.Handle(workerv1connect.NewWorkerServiceHandler(worker))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	store := &fakeSessionStore{sessions: []Session{
+		{ID: "sess-old", ThreadID: "thread-1", WorkerID: "w1", Status: "stopped"},
+		{ID: "sess-1", ThreadID: "thread-1", WorkerID: "w1", Status: "running"},
+	}}
+	h := &sessionServiceHandler{
+		log: slog.Default(),
+		svc: NewSessionService(store, nil, fakeRegistry{"w1": srv.URL}),
+	}
+
+	resp, err := h.SendPrompt(context.Background(), connect.NewRequest(&controlplanev1.SendPromptRequest{
+		ThreadId: "thread-1",
+		ContentBlocks: []*controlplanev1.PromptContentBlock{
+			{Type: "text", Text: "Look at this diff"},
+			{Type: "text", Text: "and summarize it"},
+		},
+		Model:       "opus",
+		SessionMode: "ask",
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "end_turn", resp.Msg.StopReason)
+
+	worker.mu.Lock()
+	defer worker.mu.Unlock()
+	require.Len(t, worker.prompts, 1)
+	got := worker.prompts[0]
+	assert.Equal(t, "sess-1", got.SessionId)
+	assert.Equal(t, "opus", got.Model)
+	assert.Equal(t, "ask", got.SessionMode)
+	require.Len(t, got.ContentBlocks, 2)
+	assert.Equal(t, "Look at this diff", got.ContentBlocks[0].Text)
+	assert.Equal(t, "and summarize it", got.ContentBlocks[1].Text)
+	assert.Equal(t, "Bearer worker-secret", worker.auth)
+}
+
+func TestSendUserMessage_ForwardsContentBlocks(t *testing.T) {
+	worker := &fakeWorker{}
+	mux := http.NewServeMux()
+	mux.Handle(workerv1connect.NewWorkerServiceHandler(worker))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	store := &fakeSessionStore{sessions: []Session{
+		{ID: "sess-1", ThreadID: "thread-1", WorkerID: "w1", Status: "running"},
+	}}
+	h := &sessionServiceHandler{
+		log: slog.Default(),
+		svc: NewSessionService(store, nil, fakeRegistry{"w1": srv.URL}),
+	}
+
+	_, err := h.SendUserMessage(context.Background(), connect.NewRequest(&controlplanev1.SendUserMessageRequest{
+		ThreadId: "thread-1",
+		Text:     "Why does this render wrong?",
+		ContentBlocks: []*controlplanev1.PromptContentBlock{
+			{Type: "image", MimeType: "image/png", Data: "iVBORw0K"},
+			{Type: "resource", Uri: "file:///repo/styles.css", MimeType: "text/css", Text: "body { margin: 0 }"},
+		},
+	}))
+	require.NoError(t, err)
+
+	// Blocks without text are accepted on their own.
+	_, err = h.SendUserMessage(context.Background(), connect.NewRequest(&controlplanev1.SendUserMessageRequest{
+		ThreadId:      "thread-1",
+		ContentBlocks: []*controlplanev1.PromptContentBlock{{Type: "image", MimeType: "image/jpeg", Data: "/9j/"}},
+	}))
+	require.NoError(t, err)
+
+	_, err = h.SendUserMessage(context.Background(), connect.NewRequest(&controlplanev1.SendUserMessageRequest{ThreadId: "thread-1"}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+	worker.mu.Lock()
+	defer worker.mu.Unlock()
+	require.Len(t, worker.messages, 2)
+	got := worker.messages[0]
+	assert.Equal(t, "sess-1", got.SessionId)
+	require.Len(t, got.ContentBlocks, 3)
+	assert.Equal(t, &workerv1.ContentBlock{Type: "text", Text: "Why does this render wrong?"}, got.ContentBlocks[0], "text goes first")
+	assert.Equal(t, "image", got.ContentBlocks[1].Type)
+	assert.Equal(t, "image/png", got.ContentBlocks[1].MimeType)
+	assert.Equal(t, "iVBORw0K", got.ContentBlocks[1].Data)
+	assert.Equal(t, "resource", got.ContentBlocks[2].Type)
+	assert.Equal(t, "file:///repo/styles.css", got.ContentBlocks[2].Uri)
+	assert.Equal(t, "text/css", got.ContentBlocks[2].MimeType)
+	assert.Equal(t, "body { margin: 0 }", got.ContentBlocks[2].Text)
+	require.Len(t, worker.messages[1].ContentBlocks, 1)
+	assert.Equal(t, "/9j/", worker.messages[1].ContentBlocks[0].Data)
+	assert.Equal(t, "Bearer worker-secret", worker.auth)
+}
+
+func TestSendPrompt_Errors(t *testing.T) {
+	store := &fakeSessionStore{sessions: []Session{
+		{ID: "sess-1", ThreadID: "thread-1", WorkerID: "gone", Status: "running"},
+	}}
+	h := &sessionServiceHandler{
+		log: slog.Default(),
+		svc: NewSessionService(store, nil, fakeRegistry{}),
+	}
+	blocks := []*controlplanev1.PromptContentBlock{{Type: "text", Text: "hi"}}
+
+	_, err := h.SendPrompt(context.Background(), connect.NewRequest(&controlplanev1.SendPromptRequest{ThreadId: "thread-1"}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+	_, err = h.SendPrompt(context.Background(), connect.NewRequest(&controlplanev1.SendPromptRequest{ThreadId: "thread-2", ContentBlocks: blocks}))
+	assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err), "no active session")
+
+	_, err = h.SendPrompt(context.Background(), connect.NewRequest(&controlplanev1.SendPromptRequest{ThreadId: "thread-1", ContentBlocks: blocks}))
+	assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err), "worker not registered")
+}
+
+func TestPromptValidation(t *testing.T) {
+	h := &sessionServiceHandler{log: slog.Default(), maxPromptBytes: 16, maxAttachmentBytes: 32}
+	ctx := context.Background()
+	oversized := strings.Repeat("x", 17)
+
+	_, err := h.SendUserMessage(ctx, connect.NewRequest(&controlplanev1.SendUserMessageRequest{ThreadId: "thread-1", Text: oversized}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	assert.ErrorContains(t, err, "over the limit of 16")
+
+	_, err = h.SendUserMessage(ctx, connect.NewRequest(&controlplanev1.SendUserMessageRequest{ThreadId: "thread-1", Text: "a\x00b"}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	assert.ErrorContains(t, err, "control character")
+
+	// The limit applies to all blocks together.
+	_, err = h.SendPrompt(ctx, connect.NewRequest(&controlplanev1.SendPromptRequest{
+		ThreadId: "thread-1",
+		ContentBlocks: []*controlplanev1.PromptContentBlock{
+			{Type: "text", Text: strings.Repeat("x", 10)},
+			{Type: "text", Text: strings.Repeat("y", 10)},
+		},
+	}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+
+	// Attachment data has its own limit.
+	_, err = h.SendUserMessage(ctx, connect.NewRequest(&controlplanev1.SendUserMessageRequest{
+		ThreadId: "thread-1",
+		ContentBlocks: []*controlplanev1.PromptContentBlock{
+			{Type: "text", Text: "look"},
+			{Type: "image", MimeType: "image/png", Data: strings.Repeat("A", 33)},
+		},
+	}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	assert.ErrorContains(t, err, "attachments are 33 bytes, over the limit of 32")
+	require.NoError(t, h.validatePrompt([]*controlplanev1.PromptContentBlock{
+		{Type: "text", Text: "look"},
+		{Type: "image", MimeType: "image/png", Data: strings.Repeat("A", 32)},
+	}), "attachments do not count toward the prompt limit")
+
+	_, err = h.CreateSession(ctx, connect.NewRequest(&controlplanev1.CreateSessionRequest{
+		ThreadId: "thread-1",
+		WorkerId: "w1",
+		Agent:    "claude-code",
+		Prompt:   "\x1b[2J",
+	}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+}
+
+// threadSummaryStore serves ListThreadSummaries from a fixed list, paging
+// it like the SQLite store.
+type threadSummaryStore struct {
+	Store
+	threads map[string][]ThreadSummary
+}
+
+func (s *threadSummaryStore) ListThreadSummaries(_ context.Context, projectID string, page ThreadPage) ([]ThreadSummary, error) {
+	order := func(a, b ThreadSummary) int {
+		c := cmp.Or(a.UpdatedAt.Compare(b.UpdatedAt), strings.Compare(a.ThreadID, b.ThreadID))
+		if !page.OldestFirst {
+			c = -c
+		}
+		return c
+	}
+	threads := slices.SortedFunc(slices.Values(s.threads[projectID]), order)
+	if page.After != nil {
+		after := ThreadSummary{ThreadID: page.After.ThreadID, UpdatedAt: page.After.UpdatedAt}
+		threads = slices.DeleteFunc(threads, func(t ThreadSummary) bool { return order(t, after) <= 0 })
+	}
+	return threads[:min(page.Limit, len(threads))], nil
+}
+
+func TestListThreads(t *testing.T) {
+	at := func(minute int) time.Time { return time.Date(2026, 10, 1, 12, minute, 0, 0, time.UTC) }
+	store := &threadSummaryStore{threads: map[string][]ThreadSummary{
+		"p1": {
+			{ThreadID: "thread-a", Topic: "Fix login", LatestSessionID: "sess-a2", LatestSessionStatus: "running", UpdatedAt: at(5)},
+			{ThreadID: "thread-b", Topic: "Add retries", UpdatedAt: at(1)},
+			{ThreadID: "thread-c", Topic: "Bump deps", LatestSessionID: "sess-c1", LatestSessionStatus: "errored", UpdatedAt: at(3)},
+		},
+		"p2": {{ThreadID: "thread-x", UpdatedAt: at(9)}},
+	}}
+	h := &sessionServiceHandler{log: slog.Default(), svc: NewSessionService(store, nil, nil)}
+	ctx := context.Background()
+	ids := func(threads []*controlplanev1.ThreadSummary) []string {
+		var out []string
+		for _, t := range threads {
+			out = append(out, t.ThreadId)
+		}
+		return out
+	}
+
+	resp, err := h.ListThreads(ctx, connect.NewRequest(&controlplanev1.SessionServiceListThreadsRequest{ProjectId: "p1"}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"thread-a", "thread-c", "thread-b"}, ids(resp.Msg.Threads), "newest first")
+	assert.Empty(t, resp.Msg.NextPageToken)
+	first := resp.Msg.Threads[0]
+	assert.Equal(t, "Fix login", first.Topic)
+	assert.Equal(t, "sess-a2", first.LatestSessionId)
+	assert.Equal(t, "running", first.LatestSessionStatus)
+	assert.Equal(t, "2026-10-01T12:05:00.000Z", first.UpdatedAt)
+	assert.Empty(t, resp.Msg.Threads[2].LatestSessionId, "a thread without sessions")
+
+	// Page through oldest first.
+	var pages [][]string
+	token := ""
+	for {
+		resp, err := h.ListThreads(ctx, connect.NewRequest(&controlplanev1.SessionServiceListThreadsRequest{
+			ProjectId: "p1", OldestFirst: true, PageSize: 2, PageToken: token,
+		}))
+		require.NoError(t, err)
+		pages = append(pages, ids(resp.Msg.Threads))
+		if token = resp.Msg.NextPageToken; token == "" {
+			break
+		}
+	}
+	assert.Equal(t, [][]string{{"thread-b", "thread-c"}, {"thread-a"}}, pages)
+
+	// A thread updated while paging moves to the front without shifting the
+	// threads on later pages.
+	resp, err = h.ListThreads(ctx, connect.NewRequest(&controlplanev1.SessionServiceListThreadsRequest{ProjectId: "p1", PageSize: 1}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"thread-a"}, ids(resp.Msg.Threads))
+	store.threads["p1"][1].UpdatedAt = at(7)
+	resp, err = h.ListThreads(ctx, connect.NewRequest(&controlplanev1.SessionServiceListThreadsRequest{ProjectId: "p1", PageSize: 1, PageToken: resp.Msg.NextPageToken}))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"thread-c"}, ids(resp.Msg.Threads))
+
+	_, err = h.ListThreads(ctx, connect.NewRequest(&controlplanev1.SessionServiceListThreadsRequest{}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err), "project_id is required")
+	_, err = h.ListThreads(ctx, connect.NewRequest(&controlplanev1.SessionServiceListThreadsRequest{ProjectId: "p1", PageToken: "bogus"}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+}
+
+// memSessionStore keeps created sessions in memory, rejecting a second
+// session with the same idempotency key like the unique index does.
+type memSessionStore struct {
+	Store
+	mu       sync.Mutex
+	sessions map[string]Session
+}
+
+func (s *memSessionStore) CreateSession(_ context.Context, sess Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, existing := range s.sessions {
+		if sess.IdempotencyKey != "" && existing.IdempotencyKey == sess.IdempotencyKey {
+			return errors.New("UNIQUE constraint failed: sessions.idempotency_key")
+		}
+	}
+	if s.sessions == nil {
+		s.sessions = make(map[string]Session)
+	}
+	s.sessions[sess.ID] = sess
+	return nil
+}
+
+func (s *memSessionStore) GetSession(_ context.Context, id string) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if !ok {
+		return Session{}, sql.ErrNoRows
+	}
+	return sess, nil
+}
+
+func (s *memSessionStore) GetSessionByIdempotencyKey(_ context.Context, key string) (Session, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sess := range s.sessions {
+		if sess.IdempotencyKey == key {
+			return sess, nil
+		}
+	}
+	return Session{}, sql.ErrNoRows
+}
+
+func (s *memSessionStore) UpdateSessionStatus(_ context.Context, id, status, sessionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sess, ok := s.sessions[id]
+	if !ok {
+		return sql.ErrNoRows
+	}
+	sess.Status = status
+	sess.SessionID = sessionID
+	s.sessions[id] = sess
+	return nil
+}
+
+type topicRecorder struct{ topics []string }
+
+func (r *topicRecorder) UpdateTopic(_ context.Context, _, topic string) error {
+	r.topics = append(r.topics, topic)
+	return nil
+}
+
+func TestCreateSession_IdempotencyKey(t *testing.T) {
+	store := &memSessionStore{}
+	topics := &topicRecorder{}
+	h := &sessionServiceHandler{
+		log:                slog.Default(),
+		svc:                NewSessionService(store, NewReconciler(slog.Default(), store, fakeRegistry{}), fakeRegistry{}),
+		threadTopicUpdater: topics,
+	}
+	create := func(threadID, key string) (*controlplanev1.CreateSessionResponse, error) {
+		resp, err := h.CreateSession(context.Background(), connect.NewRequest(&controlplanev1.CreateSessionRequest{
+			ThreadId:       threadID,
+			WorkerId:       "w1",
+			Prompt:         "Fix the flaky test",
+			Agent:          "claude-code",
+			IdempotencyKey: key,
+		}))
+		if err != nil {
+			return nil, err
+		}
+		return resp.Msg, nil
+	}
+
+	first, err := create("thread-1", "key-1")
+	require.NoError(t, err)
+	retry, err := create("thread-1", "key-1")
+	require.NoError(t, err)
+	assert.Equal(t, first.Session.Id, retry.Session.Id, "a retry returns the session already created")
+	assert.Len(t, store.sessions, 1)
+	assert.Len(t, topics.topics, 1, "a retry does not touch the topic again")
+
+	other, err := create("thread-1", "key-2")
+	require.NoError(t, err)
+	assert.NotEqual(t, first.Session.Id, other.Session.Id)
+
+	unkeyed1, err := create("thread-1", "")
+	require.NoError(t, err)
+	unkeyed2, err := create("thread-1", "")
+	require.NoError(t, err)
+	assert.NotEqual(t, unkeyed1.Session.Id, unkeyed2.Session.Id, "creates without a key are never merged")
+
+	_, err = create("thread-2", "key-1")
+	assert.Equal(t, connect.CodeFailedPrecondition, connect.CodeOf(err), "key reused for another thread")
+}
+
+// eventStore serves ListSessionEventsBySession from persisted records and
+// counts the calls.
+type eventStore struct {
+	Store
+	events []SessionEvent
+	loads  atomic.Int32
+}
+
+func (s *eventStore) ListSessionEventsBySession(_ context.Context, sessionID string) ([]SessionEvent, error) {
+	s.loads.Add(1)
+	var out []SessionEvent
+	for _, e := range s.events {
+		if e.SessionID == sessionID {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+// add persists worker events the way the event ingester does.
+func (s *eventStore) add(t *testing.T, events ...*workerv1.SessionEvent) {
+	t.Helper()
+	for _, e := range events {
+		r := WorkerEventToRecord(e)
+		payload, err := MarshalRecord(r)
+		require.NoError(t, err)
+		s.events = append(s.events, SessionEvent{SessionID: r.SessionID, Sequence: r.Sequence, EventType: r.Type, Payload: payload})
+	}
+}
+
+func TestWatchSessionEvents_CaughtUpClientSkipsHistory(t *testing.T) {
+	store := &eventStore{}
+	store.add(t, makeMessageChunk("sess-1", "a", 1), makeMessageChunk("sess-1", "b", 2), makeMessageChunk("sess-1", "c", 3))
+	svc := NewSessionService(store, nil, nil)
+	h := &sessionServiceHandler{log: slog.Default(), svc: svc}
+
+	watch := func(after int64) (*recordingStream, func()) {
+		t.Helper()
+		stream := &recordingStream{}
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			done <- h.watchSessionEvents(ctx, &controlplanev1.WatchSessionEventsRequest{SessionId: "sess-1", AfterSequence: after}, stream.send)
+		}()
+		require.Eventually(t, func() bool {
+			svc.mu.Lock()
+			defer svc.mu.Unlock()
+			return len(svc.eventSubscribers) == 1
+		}, time.Second, time.Millisecond)
+		return stream, func() {
+			cancel()
+			require.NoError(t, <-done)
+		}
+	}
+	sequences := func(stream *recordingStream) []int64 {
+		var out []int64
+		for _, resp := range stream.snapshot() {
+			if resp.Event != nil {
+				out = append(out, resp.Event.Sequence)
+			}
+		}
+		return out
+	}
+
+	// The latest sequence is unknown at first, so history is loaded.
+	stream, stop := watch(3)
+	require.Eventually(t, func() bool { return store.loads.Load() == 1 }, time.Second, time.Millisecond)
+	stop()
+	assert.Empty(t, sequences(stream))
+
+	stream, stop = watch(3)
+	svc.BroadcastEvent(SessionEventUpdate{SessionID: "sess-1", Event: makeMessageChunk("sess-1", "d", 4)})
+	require.Eventually(t, func() bool { return len(sequences(stream)) == 1 }, time.Second, time.Millisecond)
+	stop()
+	assert.Equal(t, int32(1), store.loads.Load(), "a caught-up client goes straight to live events")
+	assert.Equal(t, []int64{4}, sequences(stream))
+
+	// Sequence 4 was broadcast, so a client at 3 is behind.
+	stream, stop = watch(3)
+	require.Eventually(t, func() bool { return store.loads.Load() == 2 }, time.Second, time.Millisecond)
+	stop()
+	assert.Empty(t, sequences(stream), "sequence 4 was not stored yet")
+
+	stream, stop = watch(2)
+	require.Eventually(t, func() bool { return len(sequences(stream)) == 1 }, time.Second, time.Millisecond)
+	stop()
+	assert.Equal(t, []int64{3}, sequences(stream))
+}
+
+func TestSessionService_ForgetsLatestSequenceOfEndedSession(t *testing.T) {
+	svc := NewSessionService(&eventStore{}, nil, nil)
+	svc.BroadcastEvent(SessionEventUpdate{SessionID: "sess-1", Event: makeMessageChunk("sess-1", "a", 1)})
+	svc.BroadcastEvent(SessionEventUpdate{SessionID: "sess-2", Event: makeMessageChunk("sess-2", "a", 1)})
+
+	svc.PublishLifecycle(LifecycleEvent{SessionID: "sess-1", Kind: LifecycleLaunched})
+	_, ok := svc.LatestEventSequence("sess-1")
+	assert.True(t, ok, "a running session keeps its sequence")
+
+	svc.PublishLifecycle(LifecycleEvent{SessionID: "sess-1", Kind: LifecycleStopped})
+	svc.PublishLifecycle(LifecycleEvent{SessionID: "sess-2", Kind: LifecycleErrored})
+	_, ok = svc.LatestEventSequence("sess-1")
+	assert.False(t, ok)
+	_, ok = svc.LatestEventSequence("sess-2")
+	assert.False(t, ok)
+}
+
+func TestExportSession(t *testing.T) {
+	store := &eventStore{}
+	store.add(t, scriptedSession()...)
+	h := &sessionServiceHandler{log: slog.Default(), svc: NewSessionService(store, nil, nil)}
+	ctx := context.Background()
+
+	resp, err := h.ExportSession(ctx, connect.NewRequest(&controlplanev1.ExportSessionRequest{SessionId: "sess-1"}))
+	require.NoError(t, err)
+	assert.Equal(t, "text/markdown", resp.Msg.ContentType)
+	assert.Contains(t, resp.Msg.Content, "## Turn 2")
+	assert.Contains(t, resp.Msg.Content, "```diff\n")
+
+	resp, err = h.ExportSession(ctx, connect.NewRequest(&controlplanev1.ExportSessionRequest{
+		SessionId: "sess-1",
+		Format:    controlplanev1.ExportFormat_EXPORT_FORMAT_JSON,
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, "application/json", resp.Msg.ContentType)
+	assert.Contains(t, resp.Msg.Content, `"session_id": "sess-1"`)
+
+	_, err = h.ExportSession(ctx, connect.NewRequest(&controlplanev1.ExportSessionRequest{SessionId: "nope"}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+}
+
+func TestGetPlan(t *testing.T) {
+	planEvent := func(seq int64, ts, title string) *workerv1.SessionEvent {
+		return &workerv1.SessionEvent{
+			SessionId: "sess-1",
+			Sequence:  seq,
+			Timestamp: ts,
+			Payload: &workerv1.SessionEvent_PlanSubmitted{PlanSubmitted: &workerv1.PlanSubmitted{
+				Plans: []*workerv1.Plan{{ThreadId: "th-1", Title: title, Steps: []*workerv1.PlanStep{{Id: "step-1"}}}},
+			}},
+		}
+	}
+	store := &eventStore{}
+	store.add(t,
+		planEvent(1, "2026-01-01T00:00:00Z", "First draft"),
+		&workerv1.SessionEvent{SessionId: "sess-1", Sequence: 2, Payload: &workerv1.SessionEvent_UserMessage{
+			UserMessage: &workerv1.UserMessage{Text: "split step one"},
+		}},
+		planEvent(3, "2026-01-01T00:05:00Z", "Second draft"),
+		&workerv1.SessionEvent{SessionId: "sess-2", Sequence: 1, Payload: &workerv1.SessionEvent_UserMessage{
+			UserMessage: &workerv1.UserMessage{Text: "no plan here"},
+		}},
+	)
+	h := &sessionServiceHandler{log: slog.Default(), svc: NewSessionService(store, nil, nil)}
+	ctx := context.Background()
+
+	resp, err := h.GetPlan(ctx, connect.NewRequest(&controlplanev1.GetPlanRequest{SessionId: "sess-1"}))
+	require.NoError(t, err)
+	assert.Equal(t, "2026-01-01T00:05:00Z", resp.Msg.SubmittedAt)
+	require.Len(t, resp.Msg.Plans, 1)
+	assert.Equal(t, "Second draft", resp.Msg.Plans[0].Title, "the latest submission wins")
+	assert.Equal(t, "th-1", resp.Msg.Plans[0].ThreadId)
+
+	_, err = h.GetPlan(ctx, connect.NewRequest(&controlplanev1.GetPlanRequest{SessionId: "sess-2"}))
+	assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
+}
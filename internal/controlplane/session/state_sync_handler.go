@@ -46,18 +46,22 @@ type stateSyncHandler struct {
 	topicUpdater TopicUpdater
 	persister    EventPersister
 	broadcaster  EventBroadcaster
+	lifecycle    LifecyclePublisher
+	rawOutput    RawOutputLimit
 
 	mu            sync.Mutex
 	pendingChunks map[string]*chunkAccumulator // sessionID → accumulator
 }
 
-func NewStateSyncHandler(log *slog.Logger, store Store, topicUpdater TopicUpdater, broadcaster EventBroadcaster) StateSyncHandler {
+func NewStateSyncHandler(log *slog.Logger, store Store, topicUpdater TopicUpdater, broadcaster EventBroadcaster, lifecycle LifecyclePublisher, rawOutput RawOutputLimit) StateSyncHandler {
 	return &stateSyncHandler{
 		log:           log,
 		store:         store,
 		topicUpdater:  topicUpdater,
 		persister:     store,
 		broadcaster:   broadcaster,
+		lifecycle:     lifecycle,
+		rawOutput:     rawOutput,
 		pendingChunks: make(map[string]*chunkAccumulator),
 	}
 }
@@ -72,15 +76,25 @@ func (h *stateSyncHandler) HandleSessionUpdate(workerID string, s *workerv1.Sess
 	h.processSessionUpdate(workerID, s)
 }
 
-func (h *stateSyncHandler) HandleSessionRemoved(_ string, _ *workerv1.SessionRemoved) {
-	// no-op: topic stays as the last known value
+func (h *stateSyncHandler) HandleSessionRemoved(_ string, r *workerv1.SessionRemoved) {
+	// The topic stays as the last known value.
+	kind, status := removedStatus(r.GetFinalStatus())
+	h.endSession(r.GetSessionId(), kind, status)
 }
 
 func (h *stateSyncHandler) HandleSessionEvent(_ string, event *workerv1.SessionEvent) {
 	// 1. Persist with chunk merging — consecutive chunks are buffered and flushed as one row.
-	h.persistEventMerging(event)
+	// Events of ephemeral sessions are only streamed.
+	if !event.GetEphemeral() {
+		h.persistEventMerging(event)
+	}
+
+	// 2. Keep the session's current mode in step with agent-initiated changes.
+	if u := event.GetCurrentModeUpdate(); u != nil && u.GetModeId() != "" {
+		h.updateSessionMode(event.GetSessionId(), u.GetModeId())
+	}
 
-	// 2. Forward every event as-is to pub-sub for live frontend streaming.
+	// 3. Forward every event as-is to pub-sub for live frontend streaming.
 	h.broadcaster.BroadcastEvent(SessionEventUpdate{
 		SessionID: event.GetSessionId(),
 		Event:     event,
@@ -101,6 +115,13 @@ func (h *stateSyncHandler) FlushAll() {
 func (h *stateSyncHandler) persistEventMerging(event *workerv1.SessionEvent) {
 	record := WorkerEventToRecord(event)
 	sessionID := event.GetSessionId()
+	if err := h.rawOutput.apply(context.Background(), &record); err != nil {
+		h.log.Warn("state sync: storing truncated raw output only",
+			"session_id", sessionID,
+			"sequence", record.Sequence,
+			"error", err,
+		)
+	}
 
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -179,11 +200,46 @@ func (h *stateSyncHandler) persistRecordLocked(sessionID string, record SessionE
 	}
 }
 
+func (h *stateSyncHandler) updateSessionMode(sessionID, modeID string) {
+	if err := h.store.UpdateSessionMode(context.Background(), sessionID, modeID); err != nil {
+		h.log.Error("state sync: failed to update session mode",
+			"session_id", sessionID,
+			"mode", modeID,
+			"error", err,
+		)
+	}
+}
+
 func isChunkType(eventType string) bool {
 	return eventType == "agent_message_chunk" || eventType == "agent_thought_chunk"
 }
 
+// endSession marks a session the worker ended as status and publishes kind.
+// Sessions already ended are left alone, so the repeated updates a worker
+// sends for a stopped session publish one event.
+func (h *stateSyncHandler) endSession(sessionID string, kind LifecycleKind, status string) {
+	ctx := context.Background()
+	sess, err := h.store.GetSession(ctx, sessionID)
+	if err != nil {
+		h.log.Warn("state sync: session not found", "session_id", sessionID, "error", err)
+		return
+	}
+	if sess.Status == "stopped" || sess.Status == "failed" {
+		return
+	}
+	if err := setStatus(ctx, h.store, h.lifecycle, sess, status, sess.SessionID, kind); err != nil {
+		h.log.Error("state sync: failed to update session status",
+			"session_id", sessionID,
+			"status", status,
+			"error", err,
+		)
+	}
+}
+
 func (h *stateSyncHandler) processSessionUpdate(_ string, state *workerv1.SessionState) {
+	if kind, status, ok := workerTerminalStatus(state.Status); ok {
+		h.endSession(state.SessionId, kind, status)
+	}
 	if state.Topic == "" {
 		return
 	}
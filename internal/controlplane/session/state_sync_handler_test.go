@@ -2,8 +2,10 @@ package session
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/sha256"
+	"encoding/hex"
 	"log/slog"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -31,6 +33,24 @@ func (r *recordingBroadcaster) BroadcastEvent(evt SessionEventUpdate) {
 	r.events = append(r.events, evt)
 }
 
+// modeStore keeps sessions in memory for the mode-update path; other Store
+// methods are not used by these tests.
+type modeStore struct {
+	Store
+	sessions map[string]Session
+}
+
+func (m *modeStore) GetSession(_ context.Context, id string) (Session, error) {
+	return m.sessions[id], nil
+}
+
+func (m *modeStore) UpdateSessionMode(_ context.Context, id, sessionMode string) error {
+	s := m.sessions[id]
+	s.SessionMode = sessionMode
+	m.sessions[id] = s
+	return nil
+}
+
 func newTestHandler(persister *recordingPersister, broadcaster *recordingBroadcaster) *stateSyncHandler {
 	return &stateSyncHandler{
 		log:           slog.Default(),
@@ -78,8 +98,8 @@ func makeToolCall(sessionID string, seq int64) *workerv1.SessionEvent {
 
 func decodePayload(t *testing.T, payload []byte) SessionEventRecord {
 	t.Helper()
-	var r SessionEventRecord
-	require.NoError(t, json.Unmarshal(payload, &r))
+	r, err := UnmarshalRecord(payload)
+	require.NoError(t, err)
 	return r
 }
 
@@ -254,3 +274,120 @@ func TestChunkMerging(t *testing.T) {
 		assert.Equal(t, "2024-01-01T00:00:01Z", rec.Timestamp)
 	})
 }
+
+func TestStateSyncHandler_CurrentModeUpdatePersistsSessionMode(t *testing.T) {
+	store := &modeStore{sessions: map[string]Session{"s1": {ID: "s1", SessionMode: "code"}}}
+	h := newTestHandler(&recordingPersister{}, &recordingBroadcaster{})
+	h.store = store
+
+	h.HandleSessionEvent("w1", &workerv1.SessionEvent{
+		SessionId: "s1",
+		Sequence:  1,
+		Timestamp: "2024-01-01T00:00:00Z",
+		Payload: &workerv1.SessionEvent_CurrentModeUpdate{
+			CurrentModeUpdate: &workerv1.CurrentModeUpdate{ModeId: "architect"},
+		},
+	})
+
+	sess, err := store.GetSession(context.Background(), "s1")
+	require.NoError(t, err)
+	assert.Equal(t, "architect", sessionToProto(sess).GetSessionMode())
+}
+
+func TestStateSyncHandler_EphemeralEventsAreOnlyBroadcast(t *testing.T) {
+	persister := &recordingPersister{}
+	broadcaster := &recordingBroadcaster{}
+	h := newTestHandler(persister, broadcaster)
+
+	for _, e := range []*workerv1.SessionEvent{makeMessageChunk("s1", "hi", 1), makeToolCall("s1", 2)} {
+		e.Ephemeral = true
+		h.HandleSessionEvent("w1", e)
+	}
+	h.FlushAll()
+
+	assert.Empty(t, persister.events)
+	assert.Len(t, broadcaster.events, 2)
+}
+
+func makeToolCallUpdate(sessionID string, seq int64, rawOutput string) *workerv1.SessionEvent {
+	return &workerv1.SessionEvent{
+		SessionId: sessionID,
+		Sequence:  seq,
+		Timestamp: "2024-01-01T00:00:00Z",
+		Payload: &workerv1.SessionEvent_ToolCallUpdate{
+			ToolCallUpdate: &workerv1.ToolCallUpdate{
+				ToolCallId: "tc-1",
+				Status:     workerv1.ToolCallStatus_TOOL_CALL_STATUS_COMPLETED,
+				RawOutput:  rawOutput,
+			},
+		},
+	}
+}
+
+func TestStateSyncHandler_TruncatesOversizedRawOutput(t *testing.T) {
+	output := strings.Repeat("a", 99) + "é" + strings.Repeat("b", 100) // "é" straddles the limit
+	sum := sha256.Sum256([]byte(output))
+
+	t.Run("stores prefix and marker", func(t *testing.T) {
+		persister := &recordingPersister{}
+		h := newTestHandler(persister, &recordingBroadcaster{})
+		h.rawOutput = RawOutputLimit{MaxBytes: 100}
+
+		h.HandleSessionEvent("w1", makeToolCallUpdate("s1", 1, output))
+
+		require.Len(t, persister.events, 1)
+		r := decodePayload(t, persister.events[0].Payload)
+		assert.Equal(t, strings.Repeat("a", 99), r.RawOutput, "cut before the split rune")
+		assert.True(t, r.RawOutputTruncated)
+		assert.Equal(t, int64(len(output)), r.RawOutputSize)
+		assert.Equal(t, hex.EncodeToString(sum[:]), r.RawOutputSHA256)
+		assert.Empty(t, r.RawOutputBlob)
+	})
+
+	t.Run("spills full output to blob store", func(t *testing.T) {
+		blobs, err := NewFileBlobStore(t.TempDir())
+		require.NoError(t, err)
+		persister := &recordingPersister{}
+		h := newTestHandler(persister, &recordingBroadcaster{})
+		h.rawOutput = RawOutputLimit{MaxBytes: 100, Blobs: blobs}
+
+		h.HandleSessionEvent("w1", makeToolCallUpdate("s1", 1, output))
+
+		r := decodePayload(t, persister.events[0].Payload)
+		require.True(t, r.RawOutputTruncated)
+		require.NotEmpty(t, r.RawOutputBlob)
+		full, err := blobs.GetBlob(context.Background(), r.RawOutputBlob)
+		require.NoError(t, err)
+		assert.Equal(t, output, string(full))
+	})
+
+	t.Run("keeps the worker's marker", func(t *testing.T) {
+		persister := &recordingPersister{}
+		h := newTestHandler(persister, &recordingBroadcaster{})
+		h.rawOutput = RawOutputLimit{MaxBytes: 50}
+		event := makeToolCallUpdate("s1", 1, output[:150])
+		update := event.GetToolCallUpdate()
+		update.RawOutputSize = 4096
+		update.RawOutputSha256 = "feed"
+
+		h.HandleSessionEvent("w1", event)
+
+		r := decodePayload(t, persister.events[0].Payload)
+		assert.Equal(t, strings.Repeat("a", 50), r.RawOutput)
+		assert.True(t, r.RawOutputTruncated)
+		assert.Equal(t, int64(4096), r.RawOutputSize, "the full size from the worker")
+		assert.Equal(t, "feed", r.RawOutputSHA256)
+	})
+
+	t.Run("small output is stored as is", func(t *testing.T) {
+		persister := &recordingPersister{}
+		h := newTestHandler(persister, &recordingBroadcaster{})
+
+		h.HandleSessionEvent("w1", makeToolCallUpdate("s1", 1, output))
+
+		r := decodePayload(t, persister.events[0].Payload)
+		assert.Equal(t, output, r.RawOutput)
+		assert.False(t, r.RawOutputTruncated)
+		assert.Zero(t, r.RawOutputSize)
+	})
+}
@@ -25,21 +25,38 @@ type StateSyncWatcher struct {
 	url      string
 	secret   string
 	handler  StateSyncHandler
+
+	// retryDelay is the first reconnect backoff.
+	retryDelay time.Duration
+
+	// lastSeq is the highest event sequence handled per session. It
+	// survives reconnects to the same worker instance, so events the worker
+	// sends again are dropped and missed ones are fetched. Only the Run
+	// goroutine uses it.
+	lastSeq map[string]int64
+	// instanceID is the worker instance lastSeq counts for; a snapshot from
+	// another one means the worker restarted and lastSeq is reset.
+	instanceID string
+	// connected is set once a stream delivered a snapshot; a later snapshot
+	// means the watcher reconnected.
+	connected bool
 }
 
 func NewStateSyncWatcher(log *slog.Logger, workerID, url, secret string, handler StateSyncHandler) *StateSyncWatcher {
 	return &StateSyncWatcher{
-		log:      log.With("worker_id", workerID),
-		workerID: workerID,
-		url:      url,
-		secret:   secret,
-		handler:  handler,
+		log:        log.With("worker_id", workerID),
+		workerID:   workerID,
+		url:        url,
+		secret:     secret,
+		handler:    handler,
+		retryDelay: time.Second,
+		lastSeq:    make(map[string]int64),
 	}
 }
 
 func (w *StateSyncWatcher) Run(ctx context.Context) {
 	w.log.Info("state sync watcher Run() starting", "url", w.url)
-	backoff := time.Second
+	backoff := w.retryDelay
 	const maxBackoff = 10 * time.Second
 
 	for {
@@ -72,6 +89,9 @@ func (w *StateSyncWatcher) watch(ctx context.Context) error {
 	defer stream.CloseResponse()
 	defer stream.CloseRequest()
 	defer w.handler.FlushAll()
+	// Cancelling ctx does not abort a pending Receive on its own.
+	stop := context.AfterFunc(ctx, func() { stream.CloseResponse() })
+	defer stop()
 
 	if err := stream.Send(&workerv1.StateSyncRequest{}); err != nil {
 		w.log.Error("state sync send initial request failed", "error", err)
@@ -90,24 +110,86 @@ func (w *StateSyncWatcher) watch(ctx context.Context) error {
 		case *workerv1.StateSyncResponse_Snapshot:
 			w.log.Info("received snapshot", "sessions", len(u.Snapshot.Sessions))
 			w.handler.HandleSnapshot(w.workerID, u.Snapshot.Sessions)
+			if id := u.Snapshot.GetInstanceId(); id != w.instanceID {
+				if w.connected {
+					w.log.Info("worker restarted, resetting event sequences", "instance_id", id, "previous_instance_id", w.instanceID)
+				}
+				clear(w.lastSeq)
+				w.instanceID = id
+			}
+			if w.connected {
+				if err := w.resync(ctx, client, stream, u.Snapshot.Sessions); err != nil {
+					return err
+				}
+			}
+			w.connected = true
 		case *workerv1.StateSyncResponse_SessionUpdate:
 			w.log.Info("received session update", "session_id", u.SessionUpdate.SessionId, "topic", u.SessionUpdate.Topic)
 			w.handler.HandleSessionUpdate(w.workerID, u.SessionUpdate)
 		case *workerv1.StateSyncResponse_SessionRemoved:
 			w.log.Info("received session removed", "session_id", u.SessionRemoved.SessionId)
 			w.handler.HandleSessionRemoved(w.workerID, u.SessionRemoved)
+			delete(w.lastSeq, u.SessionRemoved.GetSessionId())
 		case *workerv1.StateSyncResponse_SessionEvent:
-			w.handler.HandleSessionEvent(w.workerID, u.SessionEvent)
-			// Send ACK back to worker.
-			if err := stream.Send(&workerv1.StateSyncRequest{
-				AckSessionId: u.SessionEvent.GetSessionId(),
-				AckSequence:  u.SessionEvent.GetSequence(),
-			}); err != nil {
-				w.log.Error("state sync ACK send failed", "error", err)
+			if err := w.deliver(stream, u.SessionEvent); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// resync runs after a reconnect: it forgets sessions the worker no longer
+// has, then fetches the events of the others it has not handled yet, which
+// the stream may never send again. A restarted worker's sequences were
+// already reset by the snapshot's instance ID.
+func (w *StateSyncWatcher) resync(ctx context.Context, client workerv1connect.WorkerServiceClient, stream *connect.BidiStreamForClient[workerv1.StateSyncRequest, workerv1.StateSyncResponse], sessions []*workerv1.SessionState) error {
+	w.log.Info("worker reconnected, re-syncing sessions", "sessions", len(sessions))
+	live := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		live[s.GetSessionId()] = true
+	}
+	for id := range w.lastSeq {
+		if !live[id] {
+			delete(w.lastSeq, id)
+		}
+	}
+
+	for _, s := range sessions {
+		id := s.GetSessionId()
+		resp, err := client.GetPendingEvents(ctx, connect.NewRequest(&workerv1.GetPendingEventsRequest{
+			SessionId:     id,
+			AfterSequence: w.lastSeq[id],
+		}))
+		if err != nil {
+			// The stream still replays what the worker has queued.
+			w.log.Warn("fetching missed events failed", "session_id", id, "error", err)
+			continue
+		}
+		for _, e := range resp.Msg.GetEvents() {
+			if err := w.deliver(stream, e); err != nil {
 				return err
 			}
 		}
 	}
+	return nil
+}
+
+// deliver hands an event to the handler unless it was handled before, then
+// acknowledges it so the worker can drop it.
+func (w *StateSyncWatcher) deliver(stream *connect.BidiStreamForClient[workerv1.StateSyncRequest, workerv1.StateSyncResponse], e *workerv1.SessionEvent) error {
+	id, seq := e.GetSessionId(), e.GetSequence()
+	if seq > w.lastSeq[id] {
+		w.handler.HandleSessionEvent(w.workerID, e)
+		w.lastSeq[id] = seq
+	}
+	if err := stream.Send(&workerv1.StateSyncRequest{
+		AckSessionId: id,
+		AckSequence:  seq,
+	}); err != nil {
+		w.log.Error("state sync ACK send failed", "error", err)
+		return err
+	}
+	return nil
 }
 
 type streamSecretInterceptor struct {
@@ -0,0 +1,264 @@
+package session
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"connectrpc.com/connect"
+	"github.com/sebastianm/flowgentic/internal/connectutil"
+	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
+	"github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1/workerv1connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bouncingWorker is a worker whose first StateSync stream sends events 1 and
+// 2 of sess-1 and ends, as if the worker restarted. Later streams replay
+// event 2, whose ACK may have been lost, but not event 3, which the worker
+// queued meanwhile; GetPendingEvents returns both.
+type bouncingWorker struct {
+	workerv1connect.UnimplementedWorkerServiceHandler
+
+	mu           sync.Mutex
+	streams      int
+	acks         []int64
+	fetchedAfter []int64
+}
+
+func workerEvent(seq int64) *workerv1.SessionEvent {
+	return &workerv1.SessionEvent{
+		SessionId: "sess-1",
+		Sequence:  seq,
+		Payload:   &workerv1.SessionEvent_AgentMessageChunk{AgentMessageChunk: &workerv1.AgentMessageChunk{Text: "chunk"}},
+	}
+}
+
+func (w *bouncingWorker) StateSync(_ context.Context, stream *connect.BidiStream[workerv1.StateSyncRequest, workerv1.StateSyncResponse]) error {
+	if _, err := stream.Receive(); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.streams++
+	first := w.streams == 1
+	w.mu.Unlock()
+
+	send := func(u *workerv1.StateSyncResponse) error { return stream.Send(u) }
+	lastSeq := int64(3)
+	if first {
+		lastSeq = 2
+	}
+	if err := send(&workerv1.StateSyncResponse{Update: &workerv1.StateSyncResponse_Snapshot{
+		Snapshot: &workerv1.SessionStateSnapshot{Sessions: []*workerv1.SessionState{{SessionId: "sess-1", LastSequence: lastSeq}}},
+	}}); err != nil {
+		return err
+	}
+	replay := []int64{2}
+	if first {
+		replay = []int64{1, 2}
+	}
+	for _, seq := range replay {
+		if err := send(&workerv1.StateSyncResponse{Update: &workerv1.StateSyncResponse_SessionEvent{SessionEvent: workerEvent(seq)}}); err != nil {
+			return err
+		}
+	}
+	if first {
+		return nil
+	}
+	for {
+		req, err := stream.Receive()
+		if err != nil {
+			return nil
+		}
+		w.mu.Lock()
+		w.acks = append(w.acks, req.GetAckSequence())
+		w.mu.Unlock()
+	}
+}
+
+func (w *bouncingWorker) GetPendingEvents(_ context.Context, req *connect.Request[workerv1.GetPendingEventsRequest]) (*connect.Response[workerv1.GetPendingEventsResponse], error) {
+	w.mu.Lock()
+	w.fetchedAfter = append(w.fetchedAfter, req.Msg.AfterSequence)
+	w.mu.Unlock()
+	var events []*workerv1.SessionEvent
+	for _, seq := range []int64{2, 3} {
+		if seq > req.Msg.AfterSequence {
+			events = append(events, workerEvent(seq))
+		}
+	}
+	return connect.NewResponse(&workerv1.GetPendingEventsResponse{Events: events}), nil
+}
+
+// maxAck returns the highest acknowledged sequence; dropped duplicates are
+// acknowledged again, so acks need not grow.
+func (w *bouncingWorker) maxAck() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return slices.Max(append([]int64{0}, w.acks...))
+}
+
+// eventRecorder is a StateSyncHandler recording the sequences of the events
+// and the number of snapshots it is given.
+type eventRecorder struct {
+	mu        sync.Mutex
+	seqs      []int64
+	snapshots int
+}
+
+func (r *eventRecorder) HandleSnapshot(string, []*workerv1.SessionState) {
+	r.mu.Lock()
+	r.snapshots++
+	r.mu.Unlock()
+}
+func (r *eventRecorder) HandleSessionUpdate(string, *workerv1.SessionState)    {}
+func (r *eventRecorder) HandleSessionRemoved(string, *workerv1.SessionRemoved) {}
+func (r *eventRecorder) FlushAll()                                             {}
+
+func (r *eventRecorder) HandleSessionEvent(_ string, e *workerv1.SessionEvent) {
+	r.mu.Lock()
+	r.seqs = append(r.seqs, e.GetSequence())
+	r.mu.Unlock()
+}
+
+func (r *eventRecorder) handled() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]int64(nil), r.seqs...)
+}
+
+func TestStateSyncWatcher_ResyncsAfterWorkerRestart(t *testing.T) {
+	worker := &bouncingWorker{}
+	mux := http.NewServeMux()
+	mux.Handle(workerv1connect.NewWorkerServiceHandler(worker))
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Config.Protocols = connectutil.H2CServerProtocols()
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	rec := &eventRecorder{}
+	w := NewStateSyncWatcher(slog.Default(), "w1", srv.URL, "secret", rec)
+	w.retryDelay = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	require.Eventually(t, func() bool { return worker.maxAck() == 3 }, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, []int64{1, 2, 3}, rec.handled(), "every event is handled once, in order")
+
+	worker.mu.Lock()
+	defer worker.mu.Unlock()
+	require.Len(t, worker.fetchedAfter, 1, "missed events are fetched on reconnect only")
+	assert.Contains(t, []int64{1, 2}, worker.fetchedAfter[0])
+}
+
+// restartingWorker is a worker whose first StateSync stream sends events 1
+// to 3 of sess-1 and ends. It then restarts without its saved state, so
+// later streams, with a new instance ID, number the session's events from 1
+// again and have already got past 3.
+type restartingWorker struct {
+	workerv1connect.UnimplementedWorkerServiceHandler
+
+	mu           sync.Mutex
+	streams      int
+	acks         []int64
+	fetchedAfter []int64
+}
+
+func (w *restartingWorker) StateSync(_ context.Context, stream *connect.BidiStream[workerv1.StateSyncRequest, workerv1.StateSyncResponse]) error {
+	if _, err := stream.Receive(); err != nil {
+		return err
+	}
+	w.mu.Lock()
+	w.streams++
+	first := w.streams == 1
+	w.mu.Unlock()
+
+	events, instance := restartedEvents, "boot-2"
+	if first {
+		events, instance = []int64{1, 2, 3}, "boot-1"
+	}
+	if err := stream.Send(&workerv1.StateSyncResponse{Update: &workerv1.StateSyncResponse_Snapshot{
+		Snapshot: &workerv1.SessionStateSnapshot{
+			Sessions:   []*workerv1.SessionState{{SessionId: "sess-1", LastSequence: int64(len(events))}},
+			InstanceId: instance,
+		},
+	}}); err != nil {
+		return err
+	}
+	for _, seq := range events {
+		if err := stream.Send(&workerv1.StateSyncResponse{Update: &workerv1.StateSyncResponse_SessionEvent{SessionEvent: workerEvent(seq)}}); err != nil {
+			return err
+		}
+	}
+	for {
+		req, err := stream.Receive()
+		if err != nil {
+			return nil
+		}
+		w.mu.Lock()
+		w.acks = append(w.acks, req.GetAckSequence())
+		w.mu.Unlock()
+		if first && req.GetAckSequence() == 3 {
+			return nil
+		}
+	}
+}
+
+func (w *restartingWorker) GetPendingEvents(_ context.Context, req *connect.Request[workerv1.GetPendingEventsRequest]) (*connect.Response[workerv1.GetPendingEventsResponse], error) {
+	w.mu.Lock()
+	w.fetchedAfter = append(w.fetchedAfter, req.Msg.AfterSequence)
+	w.mu.Unlock()
+	var events []*workerv1.SessionEvent
+	for _, seq := range restartedEvents {
+		if seq > req.Msg.AfterSequence {
+			events = append(events, workerEvent(seq))
+		}
+	}
+	return connect.NewResponse(&workerv1.GetPendingEventsResponse{Events: events}), nil
+}
+
+// restartedEvents are the events of sess-1 after restartingWorker restarted.
+var restartedEvents = []int64{1, 2, 3, 4, 5}
+
+func TestStateSyncWatcher_WorkerRestartResetsSequence(t *testing.T) {
+	worker := &restartingWorker{}
+	mux := http.NewServeMux()
+	mux.Handle(workerv1connect.NewWorkerServiceHandler(worker))
+	srv := httptest.NewUnstartedServer(mux)
+	srv.Config.Protocols = connectutil.H2CServerProtocols()
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	rec := &eventRecorder{}
+	w := NewStateSyncWatcher(slog.Default(), "w1", srv.URL, "secret", rec)
+	w.retryDelay = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+
+	require.Eventually(t, func() bool { return len(rec.handled()) == 8 }, 5*time.Second, 5*time.Millisecond)
+	assert.Equal(t, []int64{1, 2, 3, 1, 2, 3, 4, 5}, rec.handled(), "the restarted worker's events are not dropped")
+
+	worker.mu.Lock()
+	defer worker.mu.Unlock()
+	assert.Equal(t, []int64{0}, worker.fetchedAfter, "missed events are fetched from the start")
+}
@@ -1,11 +1,15 @@
 -- name: CreateSession :exec
-INSERT INTO sessions (id, thread_id, worker_id, prompt, status, agent, model, mode, session_mode, created_at, updated_at)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
+INSERT INTO sessions (id, thread_id, worker_id, prompt, status, agent, model, mode, session_mode, idempotency_key, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?);
 
 -- name: GetSession :one
 SELECT * FROM sessions
 WHERE id = ?;
 
+-- name: GetSessionByIdempotencyKey :one
+SELECT * FROM sessions
+WHERE idempotency_key = ?;
+
 -- name: ListSessionsByThread :many
 SELECT * FROM sessions
 WHERE thread_id = ?
@@ -22,6 +26,11 @@ UPDATE sessions
 SET session_id = ?, status = ?, updated_at = ?
 WHERE id = ?;
 
+-- name: UpdateSessionMode :execresult
+UPDATE sessions
+SET session_mode = ?, updated_at = ?
+WHERE id = ?;
+
 -- name: GetEmbeddedWorkerPathForSession :one
 SELECT p.embedded_worker_path
 FROM sessions s
@@ -53,3 +62,41 @@ SELECT se.* FROM session_events se
 JOIN sessions s ON s.id = se.session_id
 WHERE s.task_id = ?
 ORDER BY se.sequence ASC;
+
+-- name: ListThreadSummariesNewestFirst :many
+SELECT * FROM (
+    SELECT t.id, t.topic,
+        CAST(MAX(t.updated_at, COALESCE(s.updated_at, '')) AS TEXT) AS updated_at,
+        CAST(COALESCE(s.id, '') AS TEXT) AS latest_session_id,
+        CAST(COALESCE(s.status, '') AS TEXT) AS latest_session_status
+    FROM threads t
+    LEFT JOIN sessions s ON s.id = (
+        SELECT id FROM sessions
+        WHERE thread_id = t.id
+        ORDER BY created_at DESC, rowid DESC
+        LIMIT 1
+    )
+    WHERE t.project_id = sqlc.arg(project_id)
+)
+WHERE (updated_at, id) < (sqlc.arg(after_updated_at), sqlc.arg(after_id))
+ORDER BY updated_at DESC, id DESC
+LIMIT sqlc.arg(page_limit);
+
+-- name: ListThreadSummariesOldestFirst :many
+SELECT * FROM (
+    SELECT t.id, t.topic,
+        CAST(MAX(t.updated_at, COALESCE(s.updated_at, '')) AS TEXT) AS updated_at,
+        CAST(COALESCE(s.id, '') AS TEXT) AS latest_session_id,
+        CAST(COALESCE(s.status, '') AS TEXT) AS latest_session_status
+    FROM threads t
+    LEFT JOIN sessions s ON s.id = (
+        SELECT id FROM sessions
+        WHERE thread_id = t.id
+        ORDER BY created_at DESC, rowid DESC
+        LIMIT 1
+    )
+    WHERE t.project_id = sqlc.arg(project_id)
+)
+WHERE (updated_at, id) > (sqlc.arg(after_updated_at), sqlc.arg(after_id))
+ORDER BY updated_at ASC, id ASC
+LIMIT sqlc.arg(page_limit);
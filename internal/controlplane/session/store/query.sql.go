@@ -11,22 +11,23 @@ import (
 )
 
 const createSession = `-- name: CreateSession :exec
-INSERT INTO sessions (id, thread_id, worker_id, prompt, status, agent, model, mode, session_mode, created_at, updated_at)
-VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+INSERT INTO sessions (id, thread_id, worker_id, prompt, status, agent, model, mode, session_mode, idempotency_key, created_at, updated_at)
+VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 `
 
 type CreateSessionParams struct {
-	ID          string
-	ThreadID    string
-	WorkerID    string
-	Prompt      string
-	Status      string
-	Agent       string
-	Model       string
-	Mode        string
-	SessionMode string
-	CreatedAt   string
-	UpdatedAt   string
+	ID             string
+	ThreadID       string
+	WorkerID       string
+	Prompt         string
+	Status         string
+	Agent          string
+	Model          string
+	Mode           string
+	SessionMode    string
+	IdempotencyKey sql.NullString
+	CreatedAt      string
+	UpdatedAt      string
 }
 
 func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) error {
@@ -40,6 +41,7 @@ func (q *Queries) CreateSession(ctx context.Context, arg CreateSessionParams) er
 		arg.Model,
 		arg.Mode,
 		arg.SessionMode,
+		arg.IdempotencyKey,
 		arg.CreatedAt,
 		arg.UpdatedAt,
 	)
@@ -62,7 +64,7 @@ func (q *Queries) GetEmbeddedWorkerPathForSession(ctx context.Context, id string
 }
 
 const getSession = `-- name: GetSession :one
-SELECT id, thread_id, worker_id, prompt, status, agent, model, mode, yolo, session_id, created_at, updated_at, session_mode, task_id FROM sessions
+SELECT id, thread_id, worker_id, prompt, status, agent, model, mode, yolo, session_id, created_at, updated_at, session_mode, task_id, idempotency_key FROM sessions
 WHERE id = ?
 `
 
@@ -84,6 +86,35 @@ func (q *Queries) GetSession(ctx context.Context, id string) (Session, error) {
 		&i.UpdatedAt,
 		&i.SessionMode,
 		&i.TaskID,
+		&i.IdempotencyKey,
+	)
+	return i, err
+}
+
+const getSessionByIdempotencyKey = `-- name: GetSessionByIdempotencyKey :one
+SELECT id, thread_id, worker_id, prompt, status, agent, model, mode, yolo, session_id, created_at, updated_at, session_mode, task_id, idempotency_key FROM sessions
+WHERE idempotency_key = ?
+`
+
+func (q *Queries) GetSessionByIdempotencyKey(ctx context.Context, idempotencyKey sql.NullString) (Session, error) {
+	row := q.db.QueryRowContext(ctx, getSessionByIdempotencyKey, idempotencyKey)
+	var i Session
+	err := row.Scan(
+		&i.ID,
+		&i.ThreadID,
+		&i.WorkerID,
+		&i.Prompt,
+		&i.Status,
+		&i.Agent,
+		&i.Model,
+		&i.Mode,
+		&i.Yolo,
+		&i.SessionID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.SessionMode,
+		&i.TaskID,
+		&i.IdempotencyKey,
 	)
 	return i, err
 }
@@ -130,7 +161,7 @@ func (q *Queries) InsertSessionEvent(ctx context.Context, arg InsertSessionEvent
 }
 
 const listPendingSessions = `-- name: ListPendingSessions :many
-SELECT id, thread_id, worker_id, prompt, status, agent, model, mode, yolo, session_id, created_at, updated_at, session_mode, task_id FROM sessions
+SELECT id, thread_id, worker_id, prompt, status, agent, model, mode, yolo, session_id, created_at, updated_at, session_mode, task_id, idempotency_key FROM sessions
 WHERE status = 'pending'
 ORDER BY created_at
 LIMIT ?
@@ -160,6 +191,7 @@ func (q *Queries) ListPendingSessions(ctx context.Context, limit int64) ([]Sessi
 			&i.UpdatedAt,
 			&i.SessionMode,
 			&i.TaskID,
+			&i.IdempotencyKey,
 		); err != nil {
 			return nil, err
 		}
@@ -285,7 +317,7 @@ func (q *Queries) ListSessionEventsByThread(ctx context.Context, threadID string
 }
 
 const listSessionsByThread = `-- name: ListSessionsByThread :many
-SELECT id, thread_id, worker_id, prompt, status, agent, model, mode, yolo, session_id, created_at, updated_at, session_mode, task_id FROM sessions
+SELECT id, thread_id, worker_id, prompt, status, agent, model, mode, yolo, session_id, created_at, updated_at, session_mode, task_id, idempotency_key FROM sessions
 WHERE thread_id = ?
 ORDER BY created_at
 `
@@ -314,6 +346,76 @@ func (q *Queries) ListSessionsByThread(ctx context.Context, threadID string) ([]
 			&i.UpdatedAt,
 			&i.SessionMode,
 			&i.TaskID,
+			&i.IdempotencyKey,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const listThreadSummariesNewestFirst = `-- name: ListThreadSummariesNewestFirst :many
+SELECT * FROM (
+    SELECT t.id, t.topic,
+        CAST(MAX(t.updated_at, COALESCE(s.updated_at, '')) AS TEXT) AS updated_at,
+        CAST(COALESCE(s.id, '') AS TEXT) AS latest_session_id,
+        CAST(COALESCE(s.status, '') AS TEXT) AS latest_session_status
+    FROM threads t
+    LEFT JOIN sessions s ON s.id = (
+        SELECT id FROM sessions
+        WHERE thread_id = t.id
+        ORDER BY created_at DESC, rowid DESC
+        LIMIT 1
+    )
+    WHERE t.project_id = ?
+)
+WHERE (updated_at, id) < (?, ?)
+ORDER BY updated_at DESC, id DESC
+LIMIT ?
+`
+
+type ListThreadSummariesNewestFirstParams struct {
+	ProjectID      string
+	AfterUpdatedAt string
+	AfterID        string
+	PageLimit      int64
+}
+
+type ListThreadSummariesNewestFirstRow struct {
+	ID                  string
+	Topic               string
+	UpdatedAt           string
+	LatestSessionID     string
+	LatestSessionStatus string
+}
+
+func (q *Queries) ListThreadSummariesNewestFirst(ctx context.Context, arg ListThreadSummariesNewestFirstParams) ([]ListThreadSummariesNewestFirstRow, error) {
+	rows, err := q.db.QueryContext(ctx, listThreadSummariesNewestFirst,
+		arg.ProjectID,
+		arg.AfterUpdatedAt,
+		arg.AfterID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListThreadSummariesNewestFirstRow
+	for rows.Next() {
+		var i ListThreadSummariesNewestFirstRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Topic,
+			&i.UpdatedAt,
+			&i.LatestSessionID,
+			&i.LatestSessionStatus,
 		); err != nil {
 			return nil, err
 		}
@@ -328,6 +430,91 @@ func (q *Queries) ListSessionsByThread(ctx context.Context, threadID string) ([]
 	return items, nil
 }
 
+const listThreadSummariesOldestFirst = `-- name: ListThreadSummariesOldestFirst :many
+SELECT * FROM (
+    SELECT t.id, t.topic,
+        CAST(MAX(t.updated_at, COALESCE(s.updated_at, '')) AS TEXT) AS updated_at,
+        CAST(COALESCE(s.id, '') AS TEXT) AS latest_session_id,
+        CAST(COALESCE(s.status, '') AS TEXT) AS latest_session_status
+    FROM threads t
+    LEFT JOIN sessions s ON s.id = (
+        SELECT id FROM sessions
+        WHERE thread_id = t.id
+        ORDER BY created_at DESC, rowid DESC
+        LIMIT 1
+    )
+    WHERE t.project_id = ?
+)
+WHERE (updated_at, id) > (?, ?)
+ORDER BY updated_at ASC, id ASC
+LIMIT ?
+`
+
+type ListThreadSummariesOldestFirstParams struct {
+	ProjectID      string
+	AfterUpdatedAt string
+	AfterID        string
+	PageLimit      int64
+}
+
+type ListThreadSummariesOldestFirstRow struct {
+	ID                  string
+	Topic               string
+	UpdatedAt           string
+	LatestSessionID     string
+	LatestSessionStatus string
+}
+
+func (q *Queries) ListThreadSummariesOldestFirst(ctx context.Context, arg ListThreadSummariesOldestFirstParams) ([]ListThreadSummariesOldestFirstRow, error) {
+	rows, err := q.db.QueryContext(ctx, listThreadSummariesOldestFirst,
+		arg.ProjectID,
+		arg.AfterUpdatedAt,
+		arg.AfterID,
+		arg.PageLimit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ListThreadSummariesOldestFirstRow
+	for rows.Next() {
+		var i ListThreadSummariesOldestFirstRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.Topic,
+			&i.UpdatedAt,
+			&i.LatestSessionID,
+			&i.LatestSessionStatus,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const updateSessionMode = `-- name: UpdateSessionMode :execresult
+UPDATE sessions
+SET session_mode = ?, updated_at = ?
+WHERE id = ?
+`
+
+type UpdateSessionModeParams struct {
+	SessionMode string
+	UpdatedAt   string
+	ID          string
+}
+
+func (q *Queries) UpdateSessionMode(ctx context.Context, arg UpdateSessionModeParams) (sql.Result, error) {
+	return q.db.ExecContext(ctx, updateSessionMode, arg.SessionMode, arg.UpdatedAt, arg.ID)
+}
+
 const updateSessionStatus = `-- name: UpdateSessionStatus :execresult
 UPDATE sessions
 SET session_id = ?, status = ?, updated_at = ?
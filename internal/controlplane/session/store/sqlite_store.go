@@ -22,17 +22,18 @@ func NewSQLiteStore(db *sql.DB) *SQLiteStore {
 
 func (s *SQLiteStore) CreateSession(ctx context.Context, sess session.Session) error {
 	return s.q.CreateSession(ctx, CreateSessionParams{
-		ID:          sess.ID,
-		ThreadID:    sess.ThreadID,
-		WorkerID:    sess.WorkerID,
-		Prompt:      sess.Prompt,
-		Status:      sess.Status,
-		Agent:       sess.Agent,
-		Model:       sess.Model,
-		Mode:        sess.Mode,
-		SessionMode: sess.SessionMode,
-		CreatedAt:   sess.CreatedAt.Format(timeFormat),
-		UpdatedAt:   sess.UpdatedAt.Format(timeFormat),
+		ID:             sess.ID,
+		ThreadID:       sess.ThreadID,
+		WorkerID:       sess.WorkerID,
+		Prompt:         sess.Prompt,
+		Status:         sess.Status,
+		Agent:          sess.Agent,
+		Model:          sess.Model,
+		Mode:           sess.Mode,
+		SessionMode:    sess.SessionMode,
+		IdempotencyKey: sql.NullString{String: sess.IdempotencyKey, Valid: sess.IdempotencyKey != ""},
+		CreatedAt:      sess.CreatedAt.Format(timeFormat),
+		UpdatedAt:      sess.UpdatedAt.Format(timeFormat),
 	})
 }
 
@@ -44,6 +45,14 @@ func (s *SQLiteStore) GetSession(ctx context.Context, id string) (session.Sessio
 	return sessionFromRow(row), nil
 }
 
+func (s *SQLiteStore) GetSessionByIdempotencyKey(ctx context.Context, key string) (session.Session, error) {
+	row, err := s.q.GetSessionByIdempotencyKey(ctx, sql.NullString{String: key, Valid: true})
+	if err != nil {
+		return session.Session{}, fmt.Errorf("getting session for idempotency key: %w", err)
+	}
+	return sessionFromRow(row), nil
+}
+
 func (s *SQLiteStore) ListSessionsByThread(ctx context.Context, threadID string) ([]session.Session, error) {
 	rows, err := s.q.ListSessionsByThread(ctx, threadID)
 	if err != nil {
@@ -92,6 +101,27 @@ func (s *SQLiteStore) UpdateSessionStatus(ctx context.Context, id, status, sessi
 	return nil
 }
 
+func (s *SQLiteStore) UpdateSessionMode(ctx context.Context, id, sessionMode string) error {
+	now := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	res, err := s.q.UpdateSessionMode(ctx, UpdateSessionModeParams{
+		SessionMode: sessionMode,
+		UpdatedAt:   now,
+		ID:          id,
+	})
+	if err != nil {
+		return fmt.Errorf("updating session mode: %w", err)
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("checking rows affected: %w", err)
+	}
+	if n == 0 {
+		return fmt.Errorf("session %q not found", id)
+	}
+	return nil
+}
+
 func (s *SQLiteStore) GetCwdForSession(ctx context.Context, sessionID string) (string, error) {
 	sess, err := s.q.GetSession(ctx, sessionID)
 	if err != nil {
@@ -151,6 +181,108 @@ func (s *SQLiteStore) ListSessionEventsByTask(ctx context.Context, taskID sql.Nu
 	return sessionEventsFromRows(rows), nil
 }
 
+// threadCursorMax orders after every stored updated_at, so a newest-first
+// listing without a cursor starts at the top.
+const threadCursorMax = "9999-12-31T23:59:59.999Z"
+
+func (s *SQLiteStore) ListThreadSummaries(ctx context.Context, projectID string, page session.ThreadPage) ([]session.ThreadSummary, error) {
+	var afterUpdatedAt, afterID string
+	if page.After != nil {
+		afterUpdatedAt, afterID = page.After.UpdatedAt.UTC().Format(timeFormat), page.After.ThreadID
+	} else if !page.OldestFirst {
+		afterUpdatedAt = threadCursorMax
+	}
+
+	// Both queries return the same columns, so oldest-first rows convert.
+	var rows []ListThreadSummariesNewestFirstRow
+	var err error
+	if page.OldestFirst {
+		var oldest []ListThreadSummariesOldestFirstRow
+		oldest, err = s.q.ListThreadSummariesOldestFirst(ctx, ListThreadSummariesOldestFirstParams{
+			ProjectID: projectID, AfterUpdatedAt: afterUpdatedAt, AfterID: afterID, PageLimit: int64(page.Limit),
+		})
+		for _, r := range oldest {
+			rows = append(rows, ListThreadSummariesNewestFirstRow(r))
+		}
+	} else {
+		rows, err = s.q.ListThreadSummariesNewestFirst(ctx, ListThreadSummariesNewestFirstParams{
+			ProjectID: projectID, AfterUpdatedAt: afterUpdatedAt, AfterID: afterID, PageLimit: int64(page.Limit),
+		})
+	}
+	if err != nil {
+		return nil, fmt.Errorf("listing thread summaries for project %q: %w", projectID, err)
+	}
+
+	threads := make([]session.ThreadSummary, len(rows))
+	for i, r := range rows {
+		updatedAt, _ := time.Parse(timeFormat, r.UpdatedAt)
+		threads[i] = session.ThreadSummary{
+			ThreadID:            r.ID,
+			Topic:               r.Topic,
+			LatestSessionID:     r.LatestSessionID,
+			LatestSessionStatus: r.LatestSessionStatus,
+			UpdatedAt:           updatedAt,
+		}
+	}
+	return threads, nil
+}
+
+// compressBatchSize is the number of rows CompressEventPayloads rewrites per
+// query.
+const compressBatchSize = 100
+
+// CompressEventPayloads gzips stored event payloads that were written before
+// MarshalRecord compressed large records. It returns the number of rows
+// rewritten; rows already compressed or small enough are left alone.
+func (s *SQLiteStore) CompressEventPayloads(ctx context.Context) (int, error) {
+	var n int
+	var afterID int64
+	for {
+		type row struct {
+			id      int64
+			payload []byte
+		}
+		rows, err := s.db.QueryContext(ctx,
+			"SELECT id, payload FROM session_events WHERE id > ? AND length(payload) > ? AND substr(payload, 1, 1) <> x'1f' ORDER BY id LIMIT ?",
+			afterID, session.CompressPayloadThreshold, compressBatchSize,
+		)
+		if err != nil {
+			return n, fmt.Errorf("listing event payloads to compress: %w", err)
+		}
+		var batch []row
+		for rows.Next() {
+			var r row
+			if err := rows.Scan(&r.id, &r.payload); err != nil {
+				rows.Close()
+				return n, fmt.Errorf("scanning event payload: %w", err)
+			}
+			batch = append(batch, r)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return n, fmt.Errorf("listing event payloads to compress: %w", err)
+		}
+		if len(batch) == 0 {
+			return n, nil
+		}
+
+		for _, r := range batch {
+			afterID = r.id
+			compressed, ok, err := session.CompressPayload(r.payload)
+			if err != nil {
+				return n, err
+			}
+			if !ok {
+				continue
+			}
+			if _, err := s.db.ExecContext(ctx, "UPDATE session_events SET payload = ? WHERE id = ?", compressed, r.id); err != nil {
+				return n, fmt.Errorf("compressing event payload %d: %w", r.id, err)
+			}
+			n++
+		}
+	}
+}
+
 func sessionEventsFromRows(rows []SessionEvent) []session.SessionEvent {
 	evts := make([]session.SessionEvent, len(rows))
 	for i, r := range rows {
@@ -170,18 +302,19 @@ func sessionFromRow(r Session) session.Session {
 	createdAt, _ := time.Parse(timeFormat, r.CreatedAt)
 	updatedAt, _ := time.Parse(timeFormat, r.UpdatedAt)
 	return session.Session{
-		ID:          r.ID,
-		ThreadID:    r.ThreadID,
-		WorkerID:    r.WorkerID,
-		Prompt:      r.Prompt,
-		Status:      r.Status,
-		Agent:       r.Agent,
-		Model:       r.Model,
-		Mode:        r.Mode,
-		SessionMode: r.SessionMode,
-		SessionID:   r.SessionID,
-		TaskID:      r.TaskID.String,
-		CreatedAt:   createdAt,
-		UpdatedAt:   updatedAt,
+		ID:             r.ID,
+		ThreadID:       r.ThreadID,
+		WorkerID:       r.WorkerID,
+		Prompt:         r.Prompt,
+		Status:         r.Status,
+		Agent:          r.Agent,
+		Model:          r.Model,
+		Mode:           r.Mode,
+		SessionMode:    r.SessionMode,
+		SessionID:      r.SessionID,
+		TaskID:         r.TaskID.String,
+		IdempotencyKey: r.IdempotencyKey.String,
+		CreatedAt:      createdAt,
+		UpdatedAt:      updatedAt,
 	}
 }
@@ -0,0 +1,136 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sebastianm/flowgentic/internal/controlplane/project"
+	projectstore "github.com/sebastianm/flowgentic/internal/controlplane/project/store"
+	"github.com/sebastianm/flowgentic/internal/controlplane/session"
+	"github.com/sebastianm/flowgentic/internal/controlplane/thread"
+	threadstore "github.com/sebastianm/flowgentic/internal/controlplane/thread/store"
+	"github.com/sebastianm/flowgentic/internal/database"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T) *SQLiteStore {
+	t.Helper()
+	ctx := context.Background()
+	db, err := database.Open(ctx, filepath.Join(t.TempDir(), "test.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	// Parent rows for the FK constraints.
+	_, err = projectstore.NewSQLiteStore(db).CreateProject(ctx, project.Project{ID: "p1", Name: "Project"})
+	require.NoError(t, err)
+	_, err = threadstore.NewSQLiteStore(db).CreateThread(ctx, thread.Thread{ID: "t1", ProjectID: "p1"})
+	require.NoError(t, err)
+
+	s := NewSQLiteStore(db)
+	now := time.Now().UTC()
+	require.NoError(t, s.CreateSession(ctx, session.Session{ID: "s1", ThreadID: "t1", WorkerID: "w1", Status: "running", CreatedAt: now, UpdatedAt: now}))
+	return s
+}
+
+func TestSQLiteStore_CompressEventPayloads(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+
+	// Rows written before compression: plain JSON, however large.
+	large := session.SessionEventRecord{V: 1, SessionID: "s1", Sequence: 1, Type: "agent_message_chunk", Text: strings.Repeat("x", 2*session.CompressPayloadThreshold)}
+	small := session.SessionEventRecord{V: 1, SessionID: "s1", Sequence: 2, Type: "agent_message_chunk", Text: "hi"}
+	for _, r := range []session.SessionEventRecord{large, small} {
+		payload, err := json.Marshal(r)
+		require.NoError(t, err)
+		require.NoError(t, s.InsertSessionEvent(ctx, session.SessionEvent{SessionID: "s1", Sequence: r.Sequence, EventType: r.Type, Payload: payload, CreatedAt: time.Now()}))
+	}
+
+	n, err := s.CompressEventPayloads(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	events, err := s.ListSessionEventsBySession(ctx, "s1")
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	assert.True(t, session.PayloadCompressed(events[0].Payload))
+	assert.False(t, session.PayloadCompressed(events[1].Payload))
+	for i, want := range []session.SessionEventRecord{large, small} {
+		got, err := session.UnmarshalRecord(events[i].Payload)
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	n, err = s.CompressEventPayloads(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, n, "a second run finds nothing to compress")
+}
+
+func TestSQLiteStore_ListThreadSummaries(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	threads := threadstore.NewSQLiteStore(s.db)
+	_, err := threads.CreateThread(ctx, thread.Thread{ID: "t2", ProjectID: "p1"})
+	require.NoError(t, err)
+	require.NoError(t, threads.UpdateThreadTopic(ctx, "t1", "Fix login"))
+
+	// t1 gets a second, newer session; s1 is the one newTestStore created.
+	later := time.Now().UTC().Add(time.Minute)
+	require.NoError(t, s.CreateSession(ctx, session.Session{ID: "s2", ThreadID: "t1", WorkerID: "w1", Status: "pending", CreatedAt: later, UpdatedAt: later}))
+
+	got, err := s.ListThreadSummaries(ctx, "p1", session.ThreadPage{Limit: 10})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	byID := map[string]session.ThreadSummary{}
+	for _, ts := range got {
+		byID[ts.ThreadID] = ts
+	}
+	assert.Equal(t, "Fix login", byID["t1"].Topic)
+	assert.Equal(t, "s2", byID["t1"].LatestSessionID)
+	assert.Equal(t, "pending", byID["t1"].LatestSessionStatus)
+	assert.Equal(t, later.Truncate(time.Millisecond), byID["t1"].UpdatedAt, "the latest session is newer than the thread")
+	assert.Empty(t, byID["t2"].LatestSessionID)
+	assert.Empty(t, byID["t2"].LatestSessionStatus)
+	assert.False(t, byID["t2"].UpdatedAt.IsZero())
+
+	got, err = s.ListThreadSummaries(ctx, "other", session.ThreadPage{Limit: 10})
+	require.NoError(t, err)
+	assert.Empty(t, got)
+}
+
+func TestSQLiteStore_ListThreadSummariesPages(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	threads := threadstore.NewSQLiteStore(s.db)
+	for _, id := range []string{"t2", "t3", "t4"} {
+		_, err := threads.CreateThread(ctx, thread.Thread{ID: id, ProjectID: "p1"})
+		require.NoError(t, err)
+	}
+	// t1 to t4 differ in updated_at only by the order they were written in,
+	// which may all fall in the same millisecond; ties go by ID.
+	list := func(page session.ThreadPage) []string {
+		t.Helper()
+		got, err := s.ListThreadSummaries(ctx, "p1", page)
+		require.NoError(t, err)
+		ids := make([]string, len(got))
+		for i, ts := range got {
+			ids[i] = ts.ThreadID
+		}
+		return ids
+	}
+	all := list(session.ThreadPage{Limit: 10})
+	require.Len(t, all, 4)
+	oldest := list(session.ThreadPage{OldestFirst: true, Limit: 10})
+	assert.Equal(t, []string{all[3], all[2], all[1], all[0]}, oldest)
+
+	got, err := s.ListThreadSummaries(ctx, "p1", session.ThreadPage{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, got, 2)
+	after := &session.ThreadCursor{UpdatedAt: got[1].UpdatedAt, ThreadID: got[1].ThreadID}
+	assert.Equal(t, all[2:], list(session.ThreadPage{After: after, Limit: 10}))
+	assert.Equal(t, []string{all[0]}, list(session.ThreadPage{OldestFirst: true, After: after, Limit: 10}))
+}
@@ -0,0 +1,115 @@
+package session
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultThreadPageSize is the page size of ListThreads when none is given.
+	defaultThreadPageSize = 50
+	// maxThreadPageSize caps the page size of ListThreads.
+	maxThreadPageSize = 200
+)
+
+// ErrInvalidPageToken is returned for a page token ListThreads did not issue.
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// ThreadSummary describes a thread by its most recent session.
+// LatestSessionID and LatestSessionStatus are empty for a thread without
+// sessions; UpdatedAt is the later of the thread's and that session's.
+type ThreadSummary struct {
+	ThreadID            string
+	Topic               string
+	LatestSessionID     string
+	LatestSessionStatus string
+	UpdatedAt           time.Time
+}
+
+// ThreadListOptions selects a page of ListThreads.
+type ThreadListOptions struct {
+	// OldestFirst orders by UpdatedAt ascending instead of newest first.
+	OldestFirst bool
+	// PageSize is the maximum number of threads returned; zero uses 50 and
+	// it is capped at 200.
+	PageSize int
+	// PageToken is the token returned with the previous page, or empty.
+	PageToken string
+}
+
+// ThreadPage selects the threads Store.ListThreadSummaries returns.
+type ThreadPage struct {
+	// OldestFirst orders by UpdatedAt and ThreadID ascending instead of
+	// descending.
+	OldestFirst bool
+	// After, if set, is the last thread of the previous page; only threads
+	// ordered after it are returned.
+	After *ThreadCursor
+	// Limit is the maximum number of threads returned.
+	Limit int
+}
+
+// ThreadCursor is the position of a thread in the ListThreads order.
+type ThreadCursor struct {
+	UpdatedAt time.Time
+	ThreadID  string
+}
+
+// ListThreads returns a page of the project's thread summaries ordered by
+// UpdatedAt, and the token of the next page, which is empty on the last one.
+// The token holds the position of the page's last thread rather than an
+// offset, so threads updated while paging move between pages without
+// shifting the others.
+func (s *SessionService) ListThreads(ctx context.Context, projectID string, opts ThreadListOptions) ([]ThreadSummary, string, error) {
+	page := ThreadPage{OldestFirst: opts.OldestFirst}
+	if opts.PageToken != "" {
+		after, err := decodeThreadCursor(opts.PageToken)
+		if err != nil {
+			return nil, "", fmt.Errorf("%w: %q", ErrInvalidPageToken, opts.PageToken)
+		}
+		page.After = &after
+	}
+	size := opts.PageSize
+	if size <= 0 {
+		size = defaultThreadPageSize
+	}
+	size = min(size, maxThreadPageSize)
+	// One more than the page holds tells whether another page follows.
+	page.Limit = size + 1
+
+	threads, err := s.store.ListThreadSummaries(ctx, projectID, page)
+	if err != nil {
+		return nil, "", fmt.Errorf("listing thread summaries: %w", err)
+	}
+	if len(threads) <= size {
+		return threads, "", nil
+	}
+	threads = threads[:size]
+	last := threads[size-1]
+	return threads, encodeThreadCursor(ThreadCursor{UpdatedAt: last.UpdatedAt, ThreadID: last.ThreadID}), nil
+}
+
+func encodeThreadCursor(c ThreadCursor) string {
+	return base64.RawURLEncoding.EncodeToString(fmt.Appendf(nil, "%d:%s", c.UpdatedAt.UnixMilli(), c.ThreadID))
+}
+
+func decodeThreadCursor(token string) (ThreadCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return ThreadCursor{}, err
+	}
+	ms, id, ok := strings.Cut(string(raw), ":")
+	if !ok || id == "" {
+		return ThreadCursor{}, errors.New("malformed cursor")
+	}
+	n, err := strconv.ParseInt(ms, 10, 64)
+	if err != nil {
+		return ThreadCursor{}, err
+	}
+	return ThreadCursor{UpdatedAt: time.UnixMilli(n).UTC(), ThreadID: id}, nil
+}
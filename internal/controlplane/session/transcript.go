@@ -0,0 +1,324 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	controlplanev1 "github.com/sebastianm/flowgentic/internal/proto/gen/controlplane/v1"
+)
+
+// TranscriptEntry is one entry of an assembled transcript.
+type TranscriptEntry struct {
+	Timestamp string `json:"ts"`
+	Role      string `json:"role"` // "user", "agent", "thinking", "tool", "plan", "status", "mode", "model", "permission" or "error"
+	Text      string `json:"text,omitempty"`
+
+	// Tool entries only.
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+	Kind       string           `json:"kind,omitempty"` // ACP: "read", "edit", "execute", etc.
+	Status     string           `json:"status,omitempty"`
+	Output     string           `json:"output,omitempty"`
+	Diffs      []TranscriptDiff `json:"diffs,omitempty"`
+
+	// Plan entries only: the plan's step IDs in order.
+	Steps []string `json:"steps,omitempty"`
+}
+
+// TranscriptDiff is a file change made by a tool call.
+type TranscriptDiff struct {
+	Path    string `json:"path"`
+	OldText string `json:"old_text,omitempty"`
+	NewText string `json:"new_text"`
+}
+
+// Transcript assembles session events the way the chat view does: streamed
+// message and thought chunks are joined into one entry, and tool call
+// updates are folded into the tool call they belong to.
+type Transcript struct {
+	entries []*TranscriptEntry
+	tools   map[string]*TranscriptEntry
+}
+
+// Add folds one session event into the transcript. Events must be added in
+// sequence order.
+func (t *Transcript) Add(e *controlplanev1.SessionEvent) {
+	ts := e.GetTimestamp()
+	switch p := e.Payload.(type) {
+	case *controlplanev1.SessionEvent_AgentMessageChunk:
+		t.appendChunk(ts, "agent", p.AgentMessageChunk.GetText())
+	case *controlplanev1.SessionEvent_AgentThoughtChunk:
+		t.appendChunk(ts, "thinking", p.AgentThoughtChunk.GetText())
+	case *controlplanev1.SessionEvent_UserMessage:
+		t.push(&TranscriptEntry{Timestamp: ts, Role: "user", Text: p.UserMessage.GetText()})
+	case *controlplanev1.SessionEvent_ToolCall:
+		tc := p.ToolCall
+		t.pushTool(&TranscriptEntry{
+			Timestamp:  ts,
+			Role:       "tool",
+			Text:       tc.GetTitle(),
+			ToolCallID: tc.GetToolCallId(),
+			Kind:       toolKindName(tc.GetKind()),
+			Status:     toolStatusName(tc.GetStatus()),
+			Diffs:      transcriptDiffs(tc.GetContent()),
+		})
+	case *controlplanev1.SessionEvent_ToolCallUpdate:
+		tc := p.ToolCallUpdate
+		entry, ok := t.tools[tc.GetToolCallId()]
+		if !ok {
+			// Update without a preceding tool call; show it on its own.
+			entry = &TranscriptEntry{Timestamp: ts, Role: "tool", ToolCallID: tc.GetToolCallId()}
+			t.pushTool(entry)
+		}
+		if tc.GetTitle() != "" {
+			entry.Text = tc.GetTitle()
+		}
+		entry.Status = toolStatusName(tc.GetStatus())
+		if tc.GetRawOutput() != "" {
+			entry.Output = tc.GetRawOutput()
+		}
+		if diffs := transcriptDiffs(tc.GetContent()); len(diffs) > 0 {
+			entry.Diffs = diffs
+		}
+	case *controlplanev1.SessionEvent_StatusChange:
+		t.push(&TranscriptEntry{Timestamp: ts, Role: "status", Text: p.StatusChange.GetStatus()})
+	case *controlplanev1.SessionEvent_CurrentModeUpdate:
+		t.push(&TranscriptEntry{Timestamp: ts, Role: "mode", Text: p.CurrentModeUpdate.GetModeId()})
+	case *controlplanev1.SessionEvent_CurrentModelUpdate:
+		t.push(&TranscriptEntry{Timestamp: ts, Role: "model", Text: p.CurrentModelUpdate.GetModelId()})
+	case *controlplanev1.SessionEvent_SessionError:
+		text := p.SessionError.GetMessage()
+		if reason := p.SessionError.GetReason(); reason != "" {
+			text = reason + ": " + text
+		}
+		t.push(&TranscriptEntry{Timestamp: ts, Role: "error", Text: text})
+	case *controlplanev1.SessionEvent_PermissionRequest:
+		t.push(&TranscriptEntry{Timestamp: ts, Role: "permission", Text: "requested for " + p.PermissionRequest.GetTitle()})
+	case *controlplanev1.SessionEvent_PermissionResolved:
+		t.push(&TranscriptEntry{Timestamp: ts, Role: "permission", Text: p.PermissionResolved.GetOutcome()})
+	case *controlplanev1.SessionEvent_EventsPruned:
+		t.push(&TranscriptEntry{Timestamp: ts, Role: "error", Text: fmt.Sprintf("%d events were dropped by the worker", p.EventsPruned.GetCount())})
+	case *controlplanev1.SessionEvent_PlanSubmitted:
+		for _, plan := range p.PlanSubmitted.GetPlans() {
+			entry := &TranscriptEntry{Timestamp: ts, Role: "plan", Text: plan.GetTitle()}
+			for _, s := range plan.GetSteps() {
+				entry.Steps = append(entry.Steps, s.GetId())
+			}
+			t.push(entry)
+		}
+	}
+}
+
+// Entries returns the assembled entries in order.
+func (t *Transcript) Entries() []*TranscriptEntry {
+	return t.entries
+}
+
+// appendChunk extends the previous entry if it has the same role, otherwise
+// it starts a new one.
+func (t *Transcript) appendChunk(ts, role, text string) {
+	if n := len(t.entries); n > 0 && t.entries[n-1].Role == role {
+		t.entries[n-1].Text += text
+		return
+	}
+	t.push(&TranscriptEntry{Timestamp: ts, Role: role, Text: text})
+}
+
+func (t *Transcript) push(e *TranscriptEntry) {
+	t.entries = append(t.entries, e)
+}
+
+func (t *Transcript) pushTool(e *TranscriptEntry) {
+	t.push(e)
+	if t.tools == nil {
+		t.tools = make(map[string]*TranscriptEntry)
+	}
+	t.tools[e.ToolCallID] = e
+}
+
+// WriteText writes one timestamped line per entry, with tool output indented
+// below its tool call.
+func (t *Transcript) WriteText(w io.Writer) error {
+	for _, e := range t.entries {
+		var line string
+		switch e.Role {
+		case "tool":
+			line = fmt.Sprintf("[%s] tool: %s (%s)", e.Timestamp, e.Text, e.Status)
+			if e.Output != "" {
+				line += "\n" + indent(e.Output)
+			}
+		default:
+			line = fmt.Sprintf("[%s] %s: %s", e.Timestamp, e.Role, e.Text)
+		}
+		if _, err := fmt.Fprintln(w, line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the session's entries as an indented JSON document.
+func (t *Transcript) WriteJSON(w io.Writer, sessionID string) error {
+	entries := t.entries
+	if entries == nil {
+		entries = []*TranscriptEntry{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		SessionID string             `json:"session_id"`
+		Entries   []*TranscriptEntry `json:"entries"`
+	}{sessionID, entries})
+}
+
+// WriteMarkdown renders the transcript as Markdown: a heading per turn, each
+// tool call under its title with its kind and status, edits as fenced diffs
+// and tool output and thinking in collapsible sections. A turn starts with
+// each user message. Status changes are left out.
+func (t *Transcript) WriteMarkdown(w io.Writer, sessionID string) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Session %s\n", sessionID)
+
+	turn := 0
+	for _, e := range t.entries {
+		if e.Role == "status" {
+			continue
+		}
+		if e.Role == "user" || turn == 0 {
+			turn++
+			fmt.Fprintf(&b, "\n## Turn %d\n", turn)
+		}
+		b.WriteString("\n")
+		switch e.Role {
+		case "user":
+			fmt.Fprintf(&b, "**User:** %s\n", e.Text)
+		case "agent":
+			fmt.Fprintf(&b, "%s\n", strings.TrimRight(e.Text, "\n"))
+		case "thinking":
+			writeDetails(&b, "Thinking", strings.TrimRight(e.Text, "\n")+"\n")
+		case "tool":
+			writeMarkdownTool(&b, e)
+		case "plan":
+			fmt.Fprintf(&b, "**Plan submitted:** %s\n", e.Text)
+			for i, step := range e.Steps {
+				fmt.Fprintf(&b, "%d. %s\n", i+1, step)
+			}
+		case "permission":
+			fmt.Fprintf(&b, "> Permission %s\n", e.Text)
+		case "mode":
+			fmt.Fprintf(&b, "_Mode changed to %s._\n", e.Text)
+		case "model":
+			fmt.Fprintf(&b, "_Model changed to %s._\n", e.Text)
+		case "error":
+			fmt.Fprintf(&b, "> **Error:** %s\n", e.Text)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeMarkdownTool(b *strings.Builder, e *TranscriptEntry) {
+	title := e.Text
+	if title == "" {
+		title = e.ToolCallID
+	}
+	fmt.Fprintf(b, "### %s\n\n", title)
+
+	meta := e.Status
+	if e.Kind != "" {
+		meta = e.Kind + " · " + meta
+	}
+	fmt.Fprintf(b, "_%s_\n", meta)
+
+	for _, d := range e.Diffs {
+		b.WriteString("\n")
+		b.WriteString(fenced("diff", unifiedDiff(d)))
+	}
+	if e.Output != "" {
+		b.WriteString("\n")
+		writeDetails(b, "Output", fenced("", e.Output))
+	}
+}
+
+// writeDetails writes body in a collapsible HTML details block.
+func writeDetails(b *strings.Builder, summary, body string) {
+	fmt.Fprintf(b, "<details>\n<summary>%s</summary>\n\n%s\n</details>\n", summary, body)
+}
+
+// fenced wraps s in a code fence longer than any backtick run inside it.
+func fenced(lang, s string) string {
+	run, longest := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	fence := strings.Repeat("`", max(3, longest+1))
+	return fence + lang + "\n" + strings.TrimRight(s, "\n") + "\n" + fence + "\n"
+}
+
+// unifiedDiff renders a diff as removed old lines followed by added new lines.
+func unifiedDiff(d TranscriptDiff) string {
+	var b strings.Builder
+	if d.OldText == "" {
+		b.WriteString("--- /dev/null\n")
+	} else {
+		fmt.Fprintf(&b, "--- a/%s\n", strings.TrimPrefix(d.Path, "/"))
+	}
+	fmt.Fprintf(&b, "+++ b/%s\n", strings.TrimPrefix(d.Path, "/"))
+	for _, l := range diffLines(d.OldText) {
+		b.WriteString("-" + l + "\n")
+	}
+	for _, l := range diffLines(d.NewText) {
+		b.WriteString("+" + l + "\n")
+	}
+	return b.String()
+}
+
+func diffLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimRight(s, "\n"), "\n")
+}
+
+func transcriptDiffs(blocks []*controlplanev1.ToolCallContentBlock) []TranscriptDiff {
+	var out []TranscriptDiff
+	for _, b := range blocks {
+		if d := b.GetDiff(); d != nil {
+			out = append(out, TranscriptDiff{Path: d.GetPath(), OldText: d.GetOldText(), NewText: d.GetNewText()})
+		}
+	}
+	return out
+}
+
+func toolKindName(k controlplanev1.ToolCallKind) string {
+	if k == controlplanev1.ToolCallKind_TOOL_CALL_KIND_UNSPECIFIED {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(k.String(), "TOOL_CALL_KIND_"))
+}
+
+func toolStatusName(s controlplanev1.ToolCallStatus) string {
+	switch s {
+	case controlplanev1.ToolCallStatus_TOOL_CALL_STATUS_COMPLETED:
+		return "completed"
+	case controlplanev1.ToolCallStatus_TOOL_CALL_STATUS_FAILED:
+		return "failed"
+	default:
+		return "in_progress"
+	}
+}
+
+func indent(s string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = "    " + l
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,130 @@
+package session
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
+)
+
+// scriptedSession returns the worker events of a two-turn session: a listing
+// with command output, then an edit with a diff.
+func scriptedSession() []*workerv1.SessionEvent {
+	events := []*workerv1.SessionEvent{
+		{Payload: &workerv1.SessionEvent_UserMessage{UserMessage: &workerv1.UserMessage{Text: "list the files"}}},
+		{Payload: &workerv1.SessionEvent_AgentThoughtChunk{AgentThoughtChunk: &workerv1.AgentThoughtChunk{Text: "I should run ls"}}},
+		{Payload: &workerv1.SessionEvent_ToolCall{ToolCall: &workerv1.ToolCall{
+			ToolCallId: "tc-1",
+			Title:      "ls",
+			Kind:       workerv1.ToolCallKind_TOOL_CALL_KIND_EXECUTE,
+			Status:     workerv1.ToolCallStatus_TOOL_CALL_STATUS_IN_PROGRESS,
+		}}},
+		{Payload: &workerv1.SessionEvent_ToolCallUpdate{ToolCallUpdate: &workerv1.ToolCallUpdate{
+			ToolCallId: "tc-1",
+			Status:     workerv1.ToolCallStatus_TOOL_CALL_STATUS_COMPLETED,
+			RawOutput:  "go.mod\nmain.go",
+		}}},
+		{Payload: &workerv1.SessionEvent_AgentMessageChunk{AgentMessageChunk: &workerv1.AgentMessageChunk{Text: "There are "}}},
+		{Payload: &workerv1.SessionEvent_AgentMessageChunk{AgentMessageChunk: &workerv1.AgentMessageChunk{Text: "two files."}}},
+		{Payload: &workerv1.SessionEvent_StatusChange{StatusChange: &workerv1.StatusChange{Status: workerv1.SessionStatus_SESSION_STATUS_IDLE}}},
+		{Payload: &workerv1.SessionEvent_UserMessage{UserMessage: &workerv1.UserMessage{Text: "rename main"}}},
+		{Payload: &workerv1.SessionEvent_ToolCall{ToolCall: &workerv1.ToolCall{
+			ToolCallId: "tc-2",
+			Title:      "Edit main.go",
+			Kind:       workerv1.ToolCallKind_TOOL_CALL_KIND_EDIT,
+			Status:     workerv1.ToolCallStatus_TOOL_CALL_STATUS_IN_PROGRESS,
+		}}},
+		{Payload: &workerv1.SessionEvent_PermissionRequest{PermissionRequest: &workerv1.PermissionRequest{RequestId: "tc-2", Title: "Edit main.go"}}},
+		{Payload: &workerv1.SessionEvent_PermissionResolved{PermissionResolved: &workerv1.PermissionResolved{RequestId: "tc-2", Outcome: "allowed"}}},
+		{Payload: &workerv1.SessionEvent_ToolCallUpdate{ToolCallUpdate: &workerv1.ToolCallUpdate{
+			ToolCallId: "tc-2",
+			Status:     workerv1.ToolCallStatus_TOOL_CALL_STATUS_COMPLETED,
+			Content: []*workerv1.ToolCallContentBlock{{Block: &workerv1.ToolCallContentBlock_Diff{
+				Diff: &workerv1.ToolCallDiff{Path: "/work/main.go", OldText: "func main() {}", NewText: "func run() {}"},
+			}}},
+		}}},
+	}
+	for i, e := range events {
+		e.SessionId = "sess-1"
+		e.Sequence = int64(i + 1)
+		e.Timestamp = fmt.Sprintf("2026-01-02T03:04:%02dZ", i)
+	}
+	return events
+}
+
+// scriptedTranscript assembles scriptedSession through the persisted record form.
+func scriptedTranscript() *Transcript {
+	t := &Transcript{}
+	for _, e := range scriptedSession() {
+		t.Add(RecordToCPEvent(WorkerEventToRecord(e)))
+	}
+	return t
+}
+
+func TestTranscript_Assembles(t *testing.T) {
+	entries := scriptedTranscript().Entries()
+
+	roles := make([]string, len(entries))
+	for i, e := range entries {
+		roles[i] = e.Role
+	}
+	assert.Equal(t, []string{"user", "thinking", "tool", "agent", "status", "user", "tool", "permission", "permission"}, roles)
+
+	assert.Equal(t, "There are two files.", entries[3].Text)
+	ls := entries[2]
+	assert.Equal(t, "execute", ls.Kind)
+	assert.Equal(t, "completed", ls.Status)
+	assert.Equal(t, "go.mod\nmain.go", ls.Output)
+	edit := entries[6]
+	assert.Equal(t, "edit", edit.Kind)
+	assert.Equal(t, []TranscriptDiff{{Path: "/work/main.go", OldText: "func main() {}", NewText: "func run() {}"}}, edit.Diffs)
+}
+
+func TestTranscript_WriteMarkdown(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, scriptedTranscript().WriteMarkdown(&b, "sess-1"))
+	md := b.String()
+
+	for _, want := range []string{
+		"# Session sess-1\n",
+		"\n## Turn 1\n\n**User:** list the files\n",
+		"<details>\n<summary>Thinking</summary>\n\nI should run ls\n\n</details>\n",
+		"### ls\n\n_execute · completed_\n",
+		"<details>\n<summary>Output</summary>\n\n```\ngo.mod\nmain.go\n```\n\n</details>\n",
+		"\nThere are two files.\n",
+		"\n## Turn 2\n\n**User:** rename main\n",
+		"### Edit main.go\n\n_edit · completed_\n",
+		"```diff\n--- a/work/main.go\n+++ b/work/main.go\n-func main() {}\n+func run() {}\n```\n",
+		"> Permission requested for Edit main.go\n",
+		"> Permission allowed\n",
+	} {
+		assert.Contains(t, md, want)
+	}
+	assert.NotContains(t, md, "## Turn 3")
+	assert.NotContains(t, md, "SESSION_STATUS_IDLE", "status changes are left out")
+}
+
+func TestTranscript_WriteMarkdownFencesBackticks(t *testing.T) {
+	assert.Equal(t, "````\nuse ``` here\n````\n", fenced("", "use ``` here\n"))
+	assert.Equal(t, "```diff\n+x\n```\n", fenced("diff", "+x\n"))
+}
+
+func TestTranscript_WriteJSON(t *testing.T) {
+	var b bytes.Buffer
+	require.NoError(t, scriptedTranscript().WriteJSON(&b, "sess-1"))
+
+	var doc struct {
+		SessionID string            `json:"session_id"`
+		Entries   []TranscriptEntry `json:"entries"`
+	}
+	require.NoError(t, json.Unmarshal(b.Bytes(), &doc))
+	assert.Equal(t, "sess-1", doc.SessionID)
+	require.Len(t, doc.Entries, 9)
+	assert.Equal(t, "tc-2", doc.Entries[6].ToolCallID)
+	assert.Equal(t, "func run() {}", doc.Entries[6].Diffs[0].NewText)
+}
@@ -3,10 +3,10 @@ package worker
 import (
 	"context"
 	"fmt"
-	"net/http"
 	"time"
 
 	"connectrpc.com/connect"
+	"github.com/sebastianm/flowgentic/internal/connectutil"
 	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
 	"github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1/workerv1connect"
 )
@@ -29,7 +29,7 @@ func (s *PingService) PingWorker(ctx context.Context, workerID string) (string,
 	}
 
 	client := workerv1connect.NewSystemServiceClient(
-		http.DefaultClient,
+		connectutil.Client,
 		workerURL,
 		connect.WithInterceptors(secretInterceptor(secret)),
 	)
@@ -27,20 +27,21 @@ type Project struct {
 }
 
 type Session struct {
-	ID          string
-	ThreadID    string
-	WorkerID    string
-	Prompt      string
-	Status      string
-	Agent       string
-	Model       string
-	Mode        string
-	Yolo        int64
-	SessionID   string
-	CreatedAt   string
-	UpdatedAt   string
-	SessionMode string
-	TaskID      sql.NullString
+	ID             string
+	ThreadID       string
+	WorkerID       string
+	Prompt         string
+	Status         string
+	Agent          string
+	Model          string
+	Mode           string
+	Yolo           int64
+	SessionID      string
+	CreatedAt      string
+	UpdatedAt      string
+	SessionMode    string
+	TaskID         sql.NullString
+	IdempotencyKey sql.NullString
 }
 
 type SessionEvent struct {
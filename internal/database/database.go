@@ -43,6 +43,25 @@ func Open(ctx context.Context, dbPath string) (*sql.DB, error) {
 	return db, nil
 }
 
+// OpenReadOnly opens an existing SQLite database at dbPath without running
+// migrations. Writes through the returned handle fail, so it is safe to use
+// while the control plane holds the database open.
+func OpenReadOnly(ctx context.Context, dbPath string) (*sql.DB, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", "file:"+dbPath+"?mode=ro&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("opening database: %w", err)
+	}
+	return db, nil
+}
+
 func pragmas(db *sql.DB) error {
 	for _, p := range []string{
 		"PRAGMA journal_mode=WAL",
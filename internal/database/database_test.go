@@ -56,3 +56,31 @@ func TestOpen(t *testing.T) {
 		db2.Close()
 	})
 }
+
+func TestOpenReadOnly(t *testing.T) {
+	t.Run("reads existing database", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "test.db")
+		db, err := Open(context.Background(), dbPath)
+		require.NoError(t, err)
+		db.Close()
+
+		ro, err := OpenReadOnly(context.Background(), dbPath)
+		require.NoError(t, err)
+		defer ro.Close()
+
+		var count int
+		require.NoError(t, ro.QueryRow("SELECT COUNT(*) FROM workers").Scan(&count))
+		assert.Equal(t, 0, count)
+
+		_, err = ro.Exec("DELETE FROM workers")
+		assert.Error(t, err, "writes are rejected")
+	})
+
+	t.Run("missing database", func(t *testing.T) {
+		dbPath := filepath.Join(t.TempDir(), "missing.db")
+
+		_, err := OpenReadOnly(context.Background(), dbPath)
+		require.Error(t, err)
+		assert.NoFileExists(t, dbPath, "does not create the file")
+	})
+}
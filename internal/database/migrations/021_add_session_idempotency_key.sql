@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE sessions ADD COLUMN idempotency_key TEXT DEFAULT NULL;
+CREATE UNIQUE INDEX idx_sessions_idempotency_key ON sessions(idempotency_key);
+
+-- +goose Down
+DROP INDEX idx_sessions_idempotency_key;
+ALTER TABLE sessions DROP COLUMN idempotency_key;
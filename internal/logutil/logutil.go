@@ -0,0 +1,156 @@
+package logutil
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+)
+
+// Components that can be given their own log level. A logger belongs to the
+// component named by the last of these attributes attached to it with
+// With: "driver", "adapter", "side" (the bridge to an agent or app-server)
+// and "component", whose value is the component name.
+const (
+	ComponentDriver         = "driver"
+	ComponentAdapter        = "adapter"
+	ComponentBridge         = "bridge"
+	ComponentSessionManager = "session-manager"
+)
+
+// componentOf returns the component an attribute marks a logger as.
+func componentOf(a slog.Attr) (string, bool) {
+	switch a.Key {
+	case "driver":
+		return ComponentDriver, true
+	case "adapter":
+		return ComponentAdapter, true
+	case "side":
+		return ComponentBridge, true
+	case "component":
+		return a.Value.String(), true
+	}
+	return "", false
+}
+
+// Levels holds the minimum level logged per component and the level of
+// everything else. It is safe for concurrent use, so levels can be set
+// after the loggers using them were created.
+type Levels struct {
+	set atomic.Pointer[levelSet]
+}
+
+type levelSet struct {
+	def        slog.Level
+	components map[string]slog.Level
+}
+
+// NewLevels returns Levels logging everything at def and above.
+func NewLevels(def slog.Level) *Levels {
+	l := &Levels{}
+	l.Set(def, nil)
+	return l
+}
+
+// Set replaces the levels: components logs at the given levels, all others
+// at def.
+func (l *Levels) Set(def slog.Level, components map[string]slog.Level) {
+	l.set.Store(&levelSet{def: def, components: components})
+}
+
+// Level returns the minimum level logged for component.
+func (l *Levels) Level(component string) slog.Level {
+	s := l.set.Load()
+	if level, ok := s.components[component]; ok {
+		return level
+	}
+	return s.def
+}
+
+// ParseLevels parses per-component levels written as
+// "adapter=debug,bridge=warn".
+func ParseLevels(spec string) (map[string]slog.Level, error) {
+	levels := make(map[string]slog.Level)
+	for entry := range strings.SplitSeq(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		component, text, ok := strings.Cut(entry, "=")
+		if !ok || component == "" {
+			return nil, fmt.Errorf("log level %q: want component=level", entry)
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(text)); err != nil {
+			return nil, fmt.Errorf("log level of %s: %w", component, err)
+		}
+		levels[component] = level
+	}
+	return levels, nil
+}
+
+// ComponentHandler is a slog.Handler dropping records below the level of
+// the logger's component. The handler it wraps should accept every level.
+//
+// A "component" attribute is held back from the wrapped handler and added to
+// each record instead, so a child logger naming its own component replaces
+// its parent's rather than logging the key twice.
+type ComponentHandler struct {
+	next      slog.Handler
+	levels    *Levels
+	component string
+	attr      *slog.Attr // the "component" attribute not yet passed to next
+}
+
+// NewComponentHandler returns a handler filtering records for next by
+// levels.
+func NewComponentHandler(next slog.Handler, levels *Levels) *ComponentHandler {
+	return &ComponentHandler{next: next, levels: levels}
+}
+
+func (h *ComponentHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return level >= h.levels.Level(h.component) && h.next.Enabled(ctx, level)
+}
+
+func (h *ComponentHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.attr != nil {
+		withComponent := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+		withComponent.AddAttrs(*h.attr)
+		r.Attrs(func(a slog.Attr) bool {
+			withComponent.AddAttrs(a)
+			return true
+		})
+		r = withComponent
+	}
+	return h.next.Handle(ctx, r)
+}
+
+func (h *ComponentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	child := *h
+	rest := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		if c, ok := componentOf(a); ok {
+			child.component = c
+		}
+		if a.Key == "component" {
+			child.attr = &a
+			continue
+		}
+		rest = append(rest, a)
+	}
+	if len(rest) > 0 {
+		child.next = h.next.WithAttrs(rest)
+	}
+	return &child
+}
+
+func (h *ComponentHandler) WithGroup(name string) slog.Handler {
+	// Attributes after the group are qualified by it, so the component
+	// attribute is passed on now to stay outside the group.
+	next := h.next
+	if h.attr != nil {
+		next = next.WithAttrs([]slog.Attr{*h.attr})
+	}
+	return &ComponentHandler{next: next.WithGroup(name), levels: h.levels, component: h.component}
+}
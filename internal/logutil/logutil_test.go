@@ -0,0 +1,78 @@
+package logutil
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponentHandler(t *testing.T) {
+	var buf bytes.Buffer
+	levels := NewLevels(slog.LevelInfo)
+	root := slog.New(NewComponentHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), levels)).
+		With("component", "flowgentic-worker")
+	drv := root.With("driver", "codex")
+	adapter := drv.With("adapter", "codex")
+	bridge := adapter.With("side", "app-server")
+
+	levels.Set(slog.LevelInfo, map[string]slog.Level{ComponentAdapter: slog.LevelWarn, ComponentBridge: slog.LevelDebug})
+
+	root.Info("root info")
+	drv.Debug("driver debug")
+	drv.Info("driver info")
+	adapter.Info("adapter info")
+	adapter.Warn("adapter warn")
+	bridge.Debug("bridge debug")
+
+	out := buf.String()
+	assert.Contains(t, out, "root info")
+	assert.NotContains(t, out, "driver debug")
+	assert.Contains(t, out, "driver info")
+	assert.NotContains(t, out, "adapter info", "the adapter override suppresses info")
+	assert.Contains(t, out, "adapter warn")
+	assert.Contains(t, out, "bridge debug", "the bridge override lowers the level below the default")
+}
+
+func TestComponentHandler_ComponentAttr(t *testing.T) {
+	var buf bytes.Buffer
+	levels := NewLevels(slog.LevelInfo)
+	levels.Set(slog.LevelInfo, map[string]slog.Level{ComponentSessionManager: slog.LevelError})
+	root := slog.New(NewComponentHandler(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}), levels))
+
+	root.With("component", ComponentSessionManager).WithGroup("g").Warn("manager warn")
+	root.With("component", "flowgentic-worker").Warn("worker warn")
+
+	assert.NotContains(t, buf.String(), "manager warn")
+	assert.Contains(t, buf.String(), "worker warn")
+}
+
+func TestComponentHandler_ComponentAttrReplaced(t *testing.T) {
+	var buf bytes.Buffer
+	root := slog.New(NewComponentHandler(slog.NewTextHandler(&buf, nil), NewLevels(slog.LevelInfo))).
+		With("component", "flowgentic-worker")
+
+	root.With("component", ComponentSessionManager).Info("manager info", "session", "s1")
+	root.With("driver", "codex").WithGroup("g").Info("driver info", "k", "v")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, 1, strings.Count(lines[0], "component="))
+	assert.Contains(t, lines[0], "component=session-manager session=s1")
+	assert.Contains(t, lines[1], "component=flowgentic-worker")
+	assert.Contains(t, lines[1], "g.k=v", "the component stays outside the group")
+}
+
+func TestParseLevels(t *testing.T) {
+	levels, err := ParseLevels(" adapter=debug, bridge=WARN ,")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]slog.Level{"adapter": slog.LevelDebug, "bridge": slog.LevelWarn}, levels)
+
+	_, err = ParseLevels("adapter")
+	assert.ErrorContains(t, err, "want component=level")
+	_, err = ParseLevels("adapter=loud")
+	assert.ErrorContains(t, err, "log level of adapter")
+}
@@ -0,0 +1,61 @@
+package promptutil
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// DefaultMaxBytes is the prompt size accepted when no limit is configured.
+const DefaultMaxBytes = 1 << 20
+
+// DefaultMaxAttachmentBytes is the attachment data accepted per prompt when
+// no limit is configured; a few base64 screenshots fit.
+const DefaultMaxAttachmentBytes = 20 << 20
+
+// Validate checks the text of a prompt before it is sent to an agent. The
+// texts together must fit in maxBytes; zero uses DefaultMaxBytes and a
+// negative value disables the limit. Each text must be valid UTF-8 without
+// control characters other than tab, newline and carriage return, which
+// would break the agent's JSON-RPC framing.
+func Validate(maxBytes int, texts ...string) error {
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxBytes
+	}
+	size := 0
+	for _, text := range texts {
+		size += len(text)
+	}
+	if maxBytes > 0 && size > maxBytes {
+		return fmt.Errorf("prompt is %d bytes, over the limit of %d", size, maxBytes)
+	}
+
+	for _, text := range texts {
+		if !utf8.ValidString(text) {
+			return fmt.Errorf("prompt is not valid UTF-8")
+		}
+		for i, r := range text {
+			if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+				return fmt.Errorf("prompt contains control character %U at byte %d", r, i)
+			}
+		}
+	}
+	return nil
+}
+
+// ValidateAttachments checks the base64 data of a prompt's attachments,
+// which together must fit in maxBytes; zero uses DefaultMaxAttachmentBytes
+// and a negative value disables the limit. Attachments have their own limit
+// so a screenshot does not use up the prompt text's.
+func ValidateAttachments(maxBytes int, data ...string) error {
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxAttachmentBytes
+	}
+	size := 0
+	for _, d := range data {
+		size += len(d)
+	}
+	if maxBytes > 0 && size > maxBytes {
+		return fmt.Errorf("attachments are %d bytes, over the limit of %d", size, maxBytes)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package promptutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateAttachments(t *testing.T) {
+	assert.NoError(t, ValidateAttachments(10, "12345", "67890"))
+	assert.ErrorContains(t, ValidateAttachments(10, "12345", "678901"), "attachments are 11 bytes, over the limit of 10")
+	assert.NoError(t, ValidateAttachments(0, strings.Repeat("A", DefaultMaxBytes+1)), "the default is above the prompt's")
+	assert.ErrorContains(t, ValidateAttachments(0, strings.Repeat("A", DefaultMaxAttachmentBytes+1)), "over the limit of 20971520")
+	assert.NoError(t, ValidateAttachments(-1, strings.Repeat("A", DefaultMaxAttachmentBytes+1)))
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		maxBytes int
+		texts    []string
+		wantErr  string
+	}{
+		{name: "plain text", texts: []string{"Fix the bug\n\tin main.go\r\n"}},
+		{name: "unicode", texts: []string{"Grüße 👋"}},
+		{name: "at the limit", maxBytes: 10, texts: []string{"12345", "67890"}},
+		{name: "over the limit", maxBytes: 10, texts: []string{"12345", "678901"}, wantErr: "prompt is 11 bytes, over the limit of 10"},
+		{name: "over the default limit", texts: []string{strings.Repeat("x", DefaultMaxBytes+1)}, wantErr: "over the limit of 1048576"},
+		{name: "limit disabled", maxBytes: -1, texts: []string{strings.Repeat("x", DefaultMaxBytes+1)}},
+		{name: "null byte", texts: []string{"ok", "a\x00b"}, wantErr: "control character U+0000 at byte 1"},
+		{name: "escape", texts: []string{"\x1b[31mred"}, wantErr: "control character U+001B at byte 0"},
+		{name: "invalid UTF-8", texts: []string{"a\xffb"}, wantErr: "not valid UTF-8"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(tt.maxBytes, tt.texts...)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -51,6 +51,20 @@ const (
 	// SessionServiceSendUserMessageProcedure is the fully-qualified name of the SessionService's
 	// SendUserMessage RPC.
 	SessionServiceSendUserMessageProcedure = "/controlplane.v1.SessionService/SendUserMessage"
+	// SessionServiceSendPromptProcedure is the fully-qualified name of the SessionService's SendPrompt
+	// RPC.
+	SessionServiceSendPromptProcedure = "/controlplane.v1.SessionService/SendPrompt"
+	// SessionServiceExportSessionProcedure is the fully-qualified name of the SessionService's
+	// ExportSession RPC.
+	SessionServiceExportSessionProcedure = "/controlplane.v1.SessionService/ExportSession"
+	// SessionServiceGetPlanProcedure is the fully-qualified name of the SessionService's GetPlan RPC.
+	SessionServiceGetPlanProcedure = "/controlplane.v1.SessionService/GetPlan"
+	// SessionServiceWatchSessionLifecycleProcedure is the fully-qualified name of the SessionService's
+	// WatchSessionLifecycle RPC.
+	SessionServiceWatchSessionLifecycleProcedure = "/controlplane.v1.SessionService/WatchSessionLifecycle"
+	// SessionServiceListThreadsProcedure is the fully-qualified name of the SessionService's
+	// ListThreads RPC.
+	SessionServiceListThreadsProcedure = "/controlplane.v1.SessionService/ListThreads"
 )
 
 // SessionServiceClient is a client for the controlplane.v1.SessionService service.
@@ -67,6 +81,19 @@ type SessionServiceClient interface {
 	WatchSessionEvents(context.Context, *connect.Request[v1.WatchSessionEventsRequest]) (*connect.ServerStreamForClient[v1.WatchSessionEventsResponse], error)
 	// SendUserMessage sends a follow-up message to the active session for a thread.
 	SendUserMessage(context.Context, *connect.Request[v1.SendUserMessageRequest]) (*connect.Response[v1.SendUserMessageResponse], error)
+	// SendPrompt sends a multi-block prompt, with optional model and session mode
+	// overrides, to the active session for a thread and waits for the turn to end.
+	SendPrompt(context.Context, *connect.Request[v1.SendPromptRequest]) (*connect.Response[v1.SendPromptResponse], error)
+	// ExportSession returns the assembled transcript of a session as Markdown or JSON.
+	ExportSession(context.Context, *connect.Request[v1.ExportSessionRequest]) (*connect.Response[v1.ExportSessionResponse], error)
+	// GetPlan returns the plans most recently submitted in a session.
+	GetPlan(context.Context, *connect.Request[v1.GetPlanRequest]) (*connect.Response[v1.GetPlanResponse], error)
+	// WatchSessionLifecycle streams live lifecycle changes of sessions
+	// (created, launched, stopped, errored), apart from agent events.
+	WatchSessionLifecycle(context.Context, *connect.Request[v1.WatchSessionLifecycleRequest]) (*connect.ServerStreamForClient[v1.WatchSessionLifecycleResponse], error)
+	// ListThreads returns a project's threads with the status of their most
+	// recent session, most recently updated first.
+	ListThreads(context.Context, *connect.Request[v1.SessionServiceListThreadsRequest]) (*connect.Response[v1.SessionServiceListThreadsResponse], error)
 }
 
 // NewSessionServiceClient constructs a client for the controlplane.v1.SessionService service. By
@@ -116,17 +143,52 @@ func NewSessionServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(sessionServiceMethods.ByName("SendUserMessage")),
 			connect.WithClientOptions(opts...),
 		),
+		sendPrompt: connect.NewClient[v1.SendPromptRequest, v1.SendPromptResponse](
+			httpClient,
+			baseURL+SessionServiceSendPromptProcedure,
+			connect.WithSchema(sessionServiceMethods.ByName("SendPrompt")),
+			connect.WithClientOptions(opts...),
+		),
+		exportSession: connect.NewClient[v1.ExportSessionRequest, v1.ExportSessionResponse](
+			httpClient,
+			baseURL+SessionServiceExportSessionProcedure,
+			connect.WithSchema(sessionServiceMethods.ByName("ExportSession")),
+			connect.WithClientOptions(opts...),
+		),
+		getPlan: connect.NewClient[v1.GetPlanRequest, v1.GetPlanResponse](
+			httpClient,
+			baseURL+SessionServiceGetPlanProcedure,
+			connect.WithSchema(sessionServiceMethods.ByName("GetPlan")),
+			connect.WithClientOptions(opts...),
+		),
+		watchSessionLifecycle: connect.NewClient[v1.WatchSessionLifecycleRequest, v1.WatchSessionLifecycleResponse](
+			httpClient,
+			baseURL+SessionServiceWatchSessionLifecycleProcedure,
+			connect.WithSchema(sessionServiceMethods.ByName("WatchSessionLifecycle")),
+			connect.WithClientOptions(opts...),
+		),
+		listThreads: connect.NewClient[v1.SessionServiceListThreadsRequest, v1.SessionServiceListThreadsResponse](
+			httpClient,
+			baseURL+SessionServiceListThreadsProcedure,
+			connect.WithSchema(sessionServiceMethods.ByName("ListThreads")),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // sessionServiceClient implements SessionServiceClient.
 type sessionServiceClient struct {
-	createSession      *connect.Client[v1.CreateSessionRequest, v1.CreateSessionResponse]
-	getSession         *connect.Client[v1.GetSessionRequest, v1.GetSessionResponse]
-	listSessions       *connect.Client[v1.ListSessionsRequest, v1.ListSessionsResponse]
-	setSessionMode     *connect.Client[v1.SetSessionModeRequest, v1.SetSessionModeResponse]
-	watchSessionEvents *connect.Client[v1.WatchSessionEventsRequest, v1.WatchSessionEventsResponse]
-	sendUserMessage    *connect.Client[v1.SendUserMessageRequest, v1.SendUserMessageResponse]
+	createSession         *connect.Client[v1.CreateSessionRequest, v1.CreateSessionResponse]
+	getSession            *connect.Client[v1.GetSessionRequest, v1.GetSessionResponse]
+	listSessions          *connect.Client[v1.ListSessionsRequest, v1.ListSessionsResponse]
+	setSessionMode        *connect.Client[v1.SetSessionModeRequest, v1.SetSessionModeResponse]
+	watchSessionEvents    *connect.Client[v1.WatchSessionEventsRequest, v1.WatchSessionEventsResponse]
+	sendUserMessage       *connect.Client[v1.SendUserMessageRequest, v1.SendUserMessageResponse]
+	sendPrompt            *connect.Client[v1.SendPromptRequest, v1.SendPromptResponse]
+	exportSession         *connect.Client[v1.ExportSessionRequest, v1.ExportSessionResponse]
+	getPlan               *connect.Client[v1.GetPlanRequest, v1.GetPlanResponse]
+	watchSessionLifecycle *connect.Client[v1.WatchSessionLifecycleRequest, v1.WatchSessionLifecycleResponse]
+	listThreads           *connect.Client[v1.SessionServiceListThreadsRequest, v1.SessionServiceListThreadsResponse]
 }
 
 // CreateSession calls controlplane.v1.SessionService.CreateSession.
@@ -159,6 +221,31 @@ func (c *sessionServiceClient) SendUserMessage(ctx context.Context, req *connect
 	return c.sendUserMessage.CallUnary(ctx, req)
 }
 
+// SendPrompt calls controlplane.v1.SessionService.SendPrompt.
+func (c *sessionServiceClient) SendPrompt(ctx context.Context, req *connect.Request[v1.SendPromptRequest]) (*connect.Response[v1.SendPromptResponse], error) {
+	return c.sendPrompt.CallUnary(ctx, req)
+}
+
+// ExportSession calls controlplane.v1.SessionService.ExportSession.
+func (c *sessionServiceClient) ExportSession(ctx context.Context, req *connect.Request[v1.ExportSessionRequest]) (*connect.Response[v1.ExportSessionResponse], error) {
+	return c.exportSession.CallUnary(ctx, req)
+}
+
+// GetPlan calls controlplane.v1.SessionService.GetPlan.
+func (c *sessionServiceClient) GetPlan(ctx context.Context, req *connect.Request[v1.GetPlanRequest]) (*connect.Response[v1.GetPlanResponse], error) {
+	return c.getPlan.CallUnary(ctx, req)
+}
+
+// WatchSessionLifecycle calls controlplane.v1.SessionService.WatchSessionLifecycle.
+func (c *sessionServiceClient) WatchSessionLifecycle(ctx context.Context, req *connect.Request[v1.WatchSessionLifecycleRequest]) (*connect.ServerStreamForClient[v1.WatchSessionLifecycleResponse], error) {
+	return c.watchSessionLifecycle.CallServerStream(ctx, req)
+}
+
+// ListThreads calls controlplane.v1.SessionService.ListThreads.
+func (c *sessionServiceClient) ListThreads(ctx context.Context, req *connect.Request[v1.SessionServiceListThreadsRequest]) (*connect.Response[v1.SessionServiceListThreadsResponse], error) {
+	return c.listThreads.CallUnary(ctx, req)
+}
+
 // SessionServiceHandler is an implementation of the controlplane.v1.SessionService service.
 type SessionServiceHandler interface {
 	// CreateSession creates a new agent session for a thread.
@@ -173,6 +260,19 @@ type SessionServiceHandler interface {
 	WatchSessionEvents(context.Context, *connect.Request[v1.WatchSessionEventsRequest], *connect.ServerStream[v1.WatchSessionEventsResponse]) error
 	// SendUserMessage sends a follow-up message to the active session for a thread.
 	SendUserMessage(context.Context, *connect.Request[v1.SendUserMessageRequest]) (*connect.Response[v1.SendUserMessageResponse], error)
+	// SendPrompt sends a multi-block prompt, with optional model and session mode
+	// overrides, to the active session for a thread and waits for the turn to end.
+	SendPrompt(context.Context, *connect.Request[v1.SendPromptRequest]) (*connect.Response[v1.SendPromptResponse], error)
+	// ExportSession returns the assembled transcript of a session as Markdown or JSON.
+	ExportSession(context.Context, *connect.Request[v1.ExportSessionRequest]) (*connect.Response[v1.ExportSessionResponse], error)
+	// GetPlan returns the plans most recently submitted in a session.
+	GetPlan(context.Context, *connect.Request[v1.GetPlanRequest]) (*connect.Response[v1.GetPlanResponse], error)
+	// WatchSessionLifecycle streams live lifecycle changes of sessions
+	// (created, launched, stopped, errored), apart from agent events.
+	WatchSessionLifecycle(context.Context, *connect.Request[v1.WatchSessionLifecycleRequest], *connect.ServerStream[v1.WatchSessionLifecycleResponse]) error
+	// ListThreads returns a project's threads with the status of their most
+	// recent session, most recently updated first.
+	ListThreads(context.Context, *connect.Request[v1.SessionServiceListThreadsRequest]) (*connect.Response[v1.SessionServiceListThreadsResponse], error)
 }
 
 // NewSessionServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -218,6 +318,36 @@ func NewSessionServiceHandler(svc SessionServiceHandler, opts ...connect.Handler
 		connect.WithSchema(sessionServiceMethods.ByName("SendUserMessage")),
 		connect.WithHandlerOptions(opts...),
 	)
+	sessionServiceSendPromptHandler := connect.NewUnaryHandler(
+		SessionServiceSendPromptProcedure,
+		svc.SendPrompt,
+		connect.WithSchema(sessionServiceMethods.ByName("SendPrompt")),
+		connect.WithHandlerOptions(opts...),
+	)
+	sessionServiceExportSessionHandler := connect.NewUnaryHandler(
+		SessionServiceExportSessionProcedure,
+		svc.ExportSession,
+		connect.WithSchema(sessionServiceMethods.ByName("ExportSession")),
+		connect.WithHandlerOptions(opts...),
+	)
+	sessionServiceGetPlanHandler := connect.NewUnaryHandler(
+		SessionServiceGetPlanProcedure,
+		svc.GetPlan,
+		connect.WithSchema(sessionServiceMethods.ByName("GetPlan")),
+		connect.WithHandlerOptions(opts...),
+	)
+	sessionServiceWatchSessionLifecycleHandler := connect.NewServerStreamHandler(
+		SessionServiceWatchSessionLifecycleProcedure,
+		svc.WatchSessionLifecycle,
+		connect.WithSchema(sessionServiceMethods.ByName("WatchSessionLifecycle")),
+		connect.WithHandlerOptions(opts...),
+	)
+	sessionServiceListThreadsHandler := connect.NewUnaryHandler(
+		SessionServiceListThreadsProcedure,
+		svc.ListThreads,
+		connect.WithSchema(sessionServiceMethods.ByName("ListThreads")),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/controlplane.v1.SessionService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case SessionServiceCreateSessionProcedure:
@@ -232,6 +362,16 @@ func NewSessionServiceHandler(svc SessionServiceHandler, opts ...connect.Handler
 			sessionServiceWatchSessionEventsHandler.ServeHTTP(w, r)
 		case SessionServiceSendUserMessageProcedure:
 			sessionServiceSendUserMessageHandler.ServeHTTP(w, r)
+		case SessionServiceSendPromptProcedure:
+			sessionServiceSendPromptHandler.ServeHTTP(w, r)
+		case SessionServiceExportSessionProcedure:
+			sessionServiceExportSessionHandler.ServeHTTP(w, r)
+		case SessionServiceGetPlanProcedure:
+			sessionServiceGetPlanHandler.ServeHTTP(w, r)
+		case SessionServiceWatchSessionLifecycleProcedure:
+			sessionServiceWatchSessionLifecycleHandler.ServeHTTP(w, r)
+		case SessionServiceListThreadsProcedure:
+			sessionServiceListThreadsHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -264,3 +404,23 @@ func (UnimplementedSessionServiceHandler) WatchSessionEvents(context.Context, *c
 func (UnimplementedSessionServiceHandler) SendUserMessage(context.Context, *connect.Request[v1.SendUserMessageRequest]) (*connect.Response[v1.SendUserMessageResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlplane.v1.SessionService.SendUserMessage is not implemented"))
 }
+
+func (UnimplementedSessionServiceHandler) SendPrompt(context.Context, *connect.Request[v1.SendPromptRequest]) (*connect.Response[v1.SendPromptResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlplane.v1.SessionService.SendPrompt is not implemented"))
+}
+
+func (UnimplementedSessionServiceHandler) ExportSession(context.Context, *connect.Request[v1.ExportSessionRequest]) (*connect.Response[v1.ExportSessionResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlplane.v1.SessionService.ExportSession is not implemented"))
+}
+
+func (UnimplementedSessionServiceHandler) GetPlan(context.Context, *connect.Request[v1.GetPlanRequest]) (*connect.Response[v1.GetPlanResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlplane.v1.SessionService.GetPlan is not implemented"))
+}
+
+func (UnimplementedSessionServiceHandler) WatchSessionLifecycle(context.Context, *connect.Request[v1.WatchSessionLifecycleRequest], *connect.ServerStream[v1.WatchSessionLifecycleResponse]) error {
+	return connect.NewError(connect.CodeUnimplemented, errors.New("controlplane.v1.SessionService.WatchSessionLifecycle is not implemented"))
+}
+
+func (UnimplementedSessionServiceHandler) ListThreads(context.Context, *connect.Request[v1.SessionServiceListThreadsRequest]) (*connect.Response[v1.SessionServiceListThreadsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("controlplane.v1.SessionService.ListThreads is not implemented"))
+}
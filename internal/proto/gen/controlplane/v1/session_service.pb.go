@@ -144,6 +144,226 @@ func (ToolCallKind) EnumDescriptor() ([]byte, []int) {
 	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{1}
 }
 
+// StopReason says why the agent ended a prompt turn.
+type StopReason int32
+
+const (
+	StopReason_STOP_REASON_UNSPECIFIED       StopReason = 0
+	StopReason_STOP_REASON_END_TURN          StopReason = 1
+	StopReason_STOP_REASON_MAX_TOKENS        StopReason = 2
+	StopReason_STOP_REASON_MAX_TURN_REQUESTS StopReason = 3
+	StopReason_STOP_REASON_REFUSAL           StopReason = 4
+	StopReason_STOP_REASON_CANCELLED         StopReason = 5
+)
+
+// Enum value maps for StopReason.
+var (
+	StopReason_name = map[int32]string{
+		0: "STOP_REASON_UNSPECIFIED",
+		1: "STOP_REASON_END_TURN",
+		2: "STOP_REASON_MAX_TOKENS",
+		3: "STOP_REASON_MAX_TURN_REQUESTS",
+		4: "STOP_REASON_REFUSAL",
+		5: "STOP_REASON_CANCELLED",
+	}
+	StopReason_value = map[string]int32{
+		"STOP_REASON_UNSPECIFIED":       0,
+		"STOP_REASON_END_TURN":          1,
+		"STOP_REASON_MAX_TOKENS":        2,
+		"STOP_REASON_MAX_TURN_REQUESTS": 3,
+		"STOP_REASON_REFUSAL":           4,
+		"STOP_REASON_CANCELLED":         5,
+	}
+)
+
+func (x StopReason) Enum() *StopReason {
+	p := new(StopReason)
+	*p = x
+	return p
+}
+
+func (x StopReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StopReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_controlplane_v1_session_service_proto_enumTypes[2].Descriptor()
+}
+
+func (StopReason) Type() protoreflect.EnumType {
+	return &file_controlplane_v1_session_service_proto_enumTypes[2]
+}
+
+func (x StopReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StopReason.Descriptor instead.
+func (StopReason) EnumDescriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{2}
+}
+
+// CancelReason says what cancelled a turn.
+type CancelReason int32
+
+const (
+	CancelReason_CANCEL_REASON_UNSPECIFIED CancelReason = 0
+	CancelReason_CANCEL_REASON_USER        CancelReason = 1
+	CancelReason_CANCEL_REASON_TIMEOUT     CancelReason = 2
+	CancelReason_CANCEL_REASON_LIMIT       CancelReason = 3
+)
+
+// Enum value maps for CancelReason.
+var (
+	CancelReason_name = map[int32]string{
+		0: "CANCEL_REASON_UNSPECIFIED",
+		1: "CANCEL_REASON_USER",
+		2: "CANCEL_REASON_TIMEOUT",
+		3: "CANCEL_REASON_LIMIT",
+	}
+	CancelReason_value = map[string]int32{
+		"CANCEL_REASON_UNSPECIFIED": 0,
+		"CANCEL_REASON_USER":        1,
+		"CANCEL_REASON_TIMEOUT":     2,
+		"CANCEL_REASON_LIMIT":       3,
+	}
+)
+
+func (x CancelReason) Enum() *CancelReason {
+	p := new(CancelReason)
+	*p = x
+	return p
+}
+
+func (x CancelReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CancelReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_controlplane_v1_session_service_proto_enumTypes[3].Descriptor()
+}
+
+func (CancelReason) Type() protoreflect.EnumType {
+	return &file_controlplane_v1_session_service_proto_enumTypes[3]
+}
+
+func (x CancelReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CancelReason.Descriptor instead.
+func (CancelReason) EnumDescriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{3}
+}
+
+type SessionLifecycleKind int32
+
+const (
+	SessionLifecycleKind_SESSION_LIFECYCLE_KIND_UNSPECIFIED SessionLifecycleKind = 0
+	// The session record was created; it waits to be dispatched.
+	SessionLifecycleKind_SESSION_LIFECYCLE_KIND_CREATED SessionLifecycleKind = 1
+	// A worker started the session.
+	SessionLifecycleKind_SESSION_LIFECYCLE_KIND_LAUNCHED SessionLifecycleKind = 2
+	// The session ended.
+	SessionLifecycleKind_SESSION_LIFECYCLE_KIND_STOPPED SessionLifecycleKind = 3
+	// Dispatching or running the session failed.
+	SessionLifecycleKind_SESSION_LIFECYCLE_KIND_ERRORED SessionLifecycleKind = 4
+)
+
+// Enum value maps for SessionLifecycleKind.
+var (
+	SessionLifecycleKind_name = map[int32]string{
+		0: "SESSION_LIFECYCLE_KIND_UNSPECIFIED",
+		1: "SESSION_LIFECYCLE_KIND_CREATED",
+		2: "SESSION_LIFECYCLE_KIND_LAUNCHED",
+		3: "SESSION_LIFECYCLE_KIND_STOPPED",
+		4: "SESSION_LIFECYCLE_KIND_ERRORED",
+	}
+	SessionLifecycleKind_value = map[string]int32{
+		"SESSION_LIFECYCLE_KIND_UNSPECIFIED": 0,
+		"SESSION_LIFECYCLE_KIND_CREATED":     1,
+		"SESSION_LIFECYCLE_KIND_LAUNCHED":    2,
+		"SESSION_LIFECYCLE_KIND_STOPPED":     3,
+		"SESSION_LIFECYCLE_KIND_ERRORED":     4,
+	}
+)
+
+func (x SessionLifecycleKind) Enum() *SessionLifecycleKind {
+	p := new(SessionLifecycleKind)
+	*p = x
+	return p
+}
+
+func (x SessionLifecycleKind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SessionLifecycleKind) Descriptor() protoreflect.EnumDescriptor {
+	return file_controlplane_v1_session_service_proto_enumTypes[4].Descriptor()
+}
+
+func (SessionLifecycleKind) Type() protoreflect.EnumType {
+	return &file_controlplane_v1_session_service_proto_enumTypes[4]
+}
+
+func (x SessionLifecycleKind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SessionLifecycleKind.Descriptor instead.
+func (SessionLifecycleKind) EnumDescriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{4}
+}
+
+type ExportFormat int32
+
+const (
+	ExportFormat_EXPORT_FORMAT_UNSPECIFIED ExportFormat = 0 // treated as Markdown
+	ExportFormat_EXPORT_FORMAT_MARKDOWN    ExportFormat = 1
+	ExportFormat_EXPORT_FORMAT_JSON        ExportFormat = 2
+)
+
+// Enum value maps for ExportFormat.
+var (
+	ExportFormat_name = map[int32]string{
+		0: "EXPORT_FORMAT_UNSPECIFIED",
+		1: "EXPORT_FORMAT_MARKDOWN",
+		2: "EXPORT_FORMAT_JSON",
+	}
+	ExportFormat_value = map[string]int32{
+		"EXPORT_FORMAT_UNSPECIFIED": 0,
+		"EXPORT_FORMAT_MARKDOWN":    1,
+		"EXPORT_FORMAT_JSON":        2,
+	}
+)
+
+func (x ExportFormat) Enum() *ExportFormat {
+	p := new(ExportFormat)
+	*p = x
+	return p
+}
+
+func (x ExportFormat) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ExportFormat) Descriptor() protoreflect.EnumDescriptor {
+	return file_controlplane_v1_session_service_proto_enumTypes[5].Descriptor()
+}
+
+func (ExportFormat) Type() protoreflect.EnumType {
+	return &file_controlplane_v1_session_service_proto_enumTypes[5]
+}
+
+func (x ExportFormat) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ExportFormat.Descriptor instead.
+func (ExportFormat) EnumDescriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{5}
+}
+
 // SessionConfig describes a session record.
 type SessionConfig struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
@@ -567,6 +787,17 @@ type SessionEvent struct {
 	//	*SessionEvent_StatusChange
 	//	*SessionEvent_CurrentModeUpdate
 	//	*SessionEvent_UserMessage
+	//	*SessionEvent_CurrentModelUpdate
+	//	*SessionEvent_SessionError
+	//	*SessionEvent_PermissionRequest
+	//	*SessionEvent_PermissionResolved
+	//	*SessionEvent_EventsPruned
+	//	*SessionEvent_PlanSubmitted
+	//	*SessionEvent_McpServerStartup
+	//	*SessionEvent_Progress
+	//	*SessionEvent_TurnEnded
+	//	*SessionEvent_AgentPlan
+	//	*SessionEvent_SessionInit
 	Payload       isSessionEvent_Payload `protobuf_oneof:"payload"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -693,6 +924,105 @@ func (x *SessionEvent) GetUserMessage() *UserMessage {
 	return nil
 }
 
+func (x *SessionEvent) GetCurrentModelUpdate() *CurrentModelUpdate {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_CurrentModelUpdate); ok {
+			return x.CurrentModelUpdate
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetSessionError() *SessionError {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_SessionError); ok {
+			return x.SessionError
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetPermissionRequest() *PermissionRequest {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_PermissionRequest); ok {
+			return x.PermissionRequest
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetPermissionResolved() *PermissionResolved {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_PermissionResolved); ok {
+			return x.PermissionResolved
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetEventsPruned() *EventsPruned {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_EventsPruned); ok {
+			return x.EventsPruned
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetPlanSubmitted() *PlanSubmitted {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_PlanSubmitted); ok {
+			return x.PlanSubmitted
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetMcpServerStartup() *McpServerStartup {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_McpServerStartup); ok {
+			return x.McpServerStartup
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetProgress() *Progress {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetTurnEnded() *TurnEnded {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_TurnEnded); ok {
+			return x.TurnEnded
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetAgentPlan() *AgentPlan {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_AgentPlan); ok {
+			return x.AgentPlan
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetSessionInit() *SessionInit {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_SessionInit); ok {
+			return x.SessionInit
+		}
+	}
+	return nil
+}
+
 type isSessionEvent_Payload interface {
 	isSessionEvent_Payload()
 }
@@ -725,6 +1055,50 @@ type SessionEvent_UserMessage struct {
 	UserMessage *UserMessage `protobuf:"bytes,16,opt,name=user_message,json=userMessage,proto3,oneof"`
 }
 
+type SessionEvent_CurrentModelUpdate struct {
+	CurrentModelUpdate *CurrentModelUpdate `protobuf:"bytes,17,opt,name=current_model_update,json=currentModelUpdate,proto3,oneof"`
+}
+
+type SessionEvent_SessionError struct {
+	SessionError *SessionError `protobuf:"bytes,18,opt,name=session_error,json=sessionError,proto3,oneof"`
+}
+
+type SessionEvent_PermissionRequest struct {
+	PermissionRequest *PermissionRequest `protobuf:"bytes,19,opt,name=permission_request,json=permissionRequest,proto3,oneof"`
+}
+
+type SessionEvent_PermissionResolved struct {
+	PermissionResolved *PermissionResolved `protobuf:"bytes,20,opt,name=permission_resolved,json=permissionResolved,proto3,oneof"`
+}
+
+type SessionEvent_EventsPruned struct {
+	EventsPruned *EventsPruned `protobuf:"bytes,21,opt,name=events_pruned,json=eventsPruned,proto3,oneof"`
+}
+
+type SessionEvent_PlanSubmitted struct {
+	PlanSubmitted *PlanSubmitted `protobuf:"bytes,22,opt,name=plan_submitted,json=planSubmitted,proto3,oneof"`
+}
+
+type SessionEvent_McpServerStartup struct {
+	McpServerStartup *McpServerStartup `protobuf:"bytes,23,opt,name=mcp_server_startup,json=mcpServerStartup,proto3,oneof"`
+}
+
+type SessionEvent_Progress struct {
+	Progress *Progress `protobuf:"bytes,24,opt,name=progress,proto3,oneof"`
+}
+
+type SessionEvent_TurnEnded struct {
+	TurnEnded *TurnEnded `protobuf:"bytes,25,opt,name=turn_ended,json=turnEnded,proto3,oneof"`
+}
+
+type SessionEvent_AgentPlan struct {
+	AgentPlan *AgentPlan `protobuf:"bytes,26,opt,name=agent_plan,json=agentPlan,proto3,oneof"`
+}
+
+type SessionEvent_SessionInit struct {
+	SessionInit *SessionInit `protobuf:"bytes,27,opt,name=session_init,json=sessionInit,proto3,oneof"`
+}
+
 func (*SessionEvent_AgentMessageChunk) isSessionEvent_Payload() {}
 
 func (*SessionEvent_AgentThoughtChunk) isSessionEvent_Payload() {}
@@ -739,6 +1113,28 @@ func (*SessionEvent_CurrentModeUpdate) isSessionEvent_Payload() {}
 
 func (*SessionEvent_UserMessage) isSessionEvent_Payload() {}
 
+func (*SessionEvent_CurrentModelUpdate) isSessionEvent_Payload() {}
+
+func (*SessionEvent_SessionError) isSessionEvent_Payload() {}
+
+func (*SessionEvent_PermissionRequest) isSessionEvent_Payload() {}
+
+func (*SessionEvent_PermissionResolved) isSessionEvent_Payload() {}
+
+func (*SessionEvent_EventsPruned) isSessionEvent_Payload() {}
+
+func (*SessionEvent_PlanSubmitted) isSessionEvent_Payload() {}
+
+func (*SessionEvent_McpServerStartup) isSessionEvent_Payload() {}
+
+func (*SessionEvent_Progress) isSessionEvent_Payload() {}
+
+func (*SessionEvent_TurnEnded) isSessionEvent_Payload() {}
+
+func (*SessionEvent_AgentPlan) isSessionEvent_Payload() {}
+
+func (*SessionEvent_SessionInit) isSessionEvent_Payload() {}
+
 // Sub-messages (duplicated from worker proto to keep packages independent).
 type AgentMessageChunk struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
@@ -829,10 +1225,13 @@ func (x *AgentThoughtChunk) GetText() string {
 }
 
 type UserMessage struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Text  string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	// Set on prompts the worker sent by itself to let the agent continue,
+	// numbering them from 1 since the last prompt from the user.
+	AutoContinueIteration int32 `protobuf:"varint,2,opt,name=auto_continue_iteration,json=autoContinueIteration,proto3" json:"auto_continue_iteration,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
 }
 
 func (x *UserMessage) Reset() {
@@ -872,15 +1271,26 @@ func (x *UserMessage) GetText() string {
 	return ""
 }
 
+func (x *UserMessage) GetAutoContinueIteration() int32 {
+	if x != nil {
+		return x.AutoContinueIteration
+	}
+	return 0
+}
+
 type ToolCall struct {
-	state         protoimpl.MessageState  `protogen:"open.v1"`
-	ToolCallId    string                  `protobuf:"bytes,1,opt,name=tool_call_id,json=toolCallId,proto3" json:"tool_call_id,omitempty"`
-	Title         string                  `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	Kind          ToolCallKind            `protobuf:"varint,3,opt,name=kind,proto3,enum=controlplane.v1.ToolCallKind" json:"kind,omitempty"`
-	RawInput      string                  `protobuf:"bytes,4,opt,name=raw_input,json=rawInput,proto3" json:"raw_input,omitempty"`
-	Locations     []*ToolCallLocation     `protobuf:"bytes,5,rep,name=locations,proto3" json:"locations,omitempty"`
-	Status        ToolCallStatus          `protobuf:"varint,6,opt,name=status,proto3,enum=controlplane.v1.ToolCallStatus" json:"status,omitempty"`
-	Content       []*ToolCallContentBlock `protobuf:"bytes,7,rep,name=content,proto3" json:"content,omitempty"`
+	state      protoimpl.MessageState  `protogen:"open.v1"`
+	ToolCallId string                  `protobuf:"bytes,1,opt,name=tool_call_id,json=toolCallId,proto3" json:"tool_call_id,omitempty"`
+	Title      string                  `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Kind       ToolCallKind            `protobuf:"varint,3,opt,name=kind,proto3,enum=controlplane.v1.ToolCallKind" json:"kind,omitempty"`
+	RawInput   string                  `protobuf:"bytes,4,opt,name=raw_input,json=rawInput,proto3" json:"raw_input,omitempty"`
+	Locations  []*ToolCallLocation     `protobuf:"bytes,5,rep,name=locations,proto3" json:"locations,omitempty"`
+	Status     ToolCallStatus          `protobuf:"varint,6,opt,name=status,proto3,enum=controlplane.v1.ToolCallStatus" json:"status,omitempty"`
+	Content    []*ToolCallContentBlock `protobuf:"bytes,7,rep,name=content,proto3" json:"content,omitempty"`
+	Input      *ToolInput              `protobuf:"bytes,8,opt,name=input,proto3" json:"input,omitempty"`
+	// Free-form rendering hints from the agent's ACP _meta, flattened to
+	// dotted keys such as "claudeCode.toolName".
+	Metadata      map[string]string `protobuf:"bytes,9,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -964,14 +1374,32 @@ func (x *ToolCall) GetContent() []*ToolCallContentBlock {
 	return nil
 }
 
+func (x *ToolCall) GetInput() *ToolInput {
+	if x != nil {
+		return x.Input
+	}
+	return nil
+}
+
+func (x *ToolCall) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
 type ToolCallUpdate struct {
-	state         protoimpl.MessageState  `protogen:"open.v1"`
-	ToolCallId    string                  `protobuf:"bytes,1,opt,name=tool_call_id,json=toolCallId,proto3" json:"tool_call_id,omitempty"`
-	Title         string                  `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	Status        ToolCallStatus          `protobuf:"varint,3,opt,name=status,proto3,enum=controlplane.v1.ToolCallStatus" json:"status,omitempty"`
-	RawOutput     string                  `protobuf:"bytes,4,opt,name=raw_output,json=rawOutput,proto3" json:"raw_output,omitempty"`
-	Locations     []*ToolCallLocation     `protobuf:"bytes,5,rep,name=locations,proto3" json:"locations,omitempty"`
-	Content       []*ToolCallContentBlock `protobuf:"bytes,6,rep,name=content,proto3" json:"content,omitempty"`
+	state      protoimpl.MessageState  `protogen:"open.v1"`
+	ToolCallId string                  `protobuf:"bytes,1,opt,name=tool_call_id,json=toolCallId,proto3" json:"tool_call_id,omitempty"`
+	Title      string                  `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Status     ToolCallStatus          `protobuf:"varint,3,opt,name=status,proto3,enum=controlplane.v1.ToolCallStatus" json:"status,omitempty"`
+	RawOutput  string                  `protobuf:"bytes,4,opt,name=raw_output,json=rawOutput,proto3" json:"raw_output,omitempty"`
+	Locations  []*ToolCallLocation     `protobuf:"bytes,5,rep,name=locations,proto3" json:"locations,omitempty"`
+	Content    []*ToolCallContentBlock `protobuf:"bytes,6,rep,name=content,proto3" json:"content,omitempty"`
+	Input      *ToolInput              `protobuf:"bytes,7,opt,name=input,proto3" json:"input,omitempty"`
+	// Free-form rendering hints from the agent's ACP _meta, flattened to
+	// dotted keys such as "claudeCode.toolName".
+	Metadata      map[string]string `protobuf:"bytes,8,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -1048,12 +1476,27 @@ func (x *ToolCallUpdate) GetContent() []*ToolCallContentBlock {
 	return nil
 }
 
+func (x *ToolCallUpdate) GetInput() *ToolInput {
+	if x != nil {
+		return x.Input
+	}
+	return nil
+}
+
+func (x *ToolCallUpdate) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
 type ToolCallContentBlock struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Types that are valid to be assigned to Block:
 	//
 	//	*ToolCallContentBlock_Diff
 	//	*ToolCallContentBlock_Text
+	//	*ToolCallContentBlock_CommandOutput
 	Block         isToolCallContentBlock_Block `protobuf_oneof:"block"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
@@ -1114,6 +1557,15 @@ func (x *ToolCallContentBlock) GetText() *ToolCallText {
 	return nil
 }
 
+func (x *ToolCallContentBlock) GetCommandOutput() *ToolCallCommandOutput {
+	if x != nil {
+		if x, ok := x.Block.(*ToolCallContentBlock_CommandOutput); ok {
+			return x.CommandOutput
+		}
+	}
+	return nil
+}
+
 type isToolCallContentBlock_Block interface {
 	isToolCallContentBlock_Block()
 }
@@ -1126,10 +1578,16 @@ type ToolCallContentBlock_Text struct {
 	Text *ToolCallText `protobuf:"bytes,2,opt,name=text,proto3,oneof"`
 }
 
+type ToolCallContentBlock_CommandOutput struct {
+	CommandOutput *ToolCallCommandOutput `protobuf:"bytes,3,opt,name=command_output,json=commandOutput,proto3,oneof"`
+}
+
 func (*ToolCallContentBlock_Diff) isToolCallContentBlock_Block() {}
 
 func (*ToolCallContentBlock_Text) isToolCallContentBlock_Block() {}
 
+func (*ToolCallContentBlock_CommandOutput) isToolCallContentBlock_Block() {}
+
 type ToolCallDiff struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
@@ -1234,28 +1692,29 @@ func (x *ToolCallText) GetText() string {
 	return ""
 }
 
-type ToolCallLocation struct {
+type ToolCallCommandOutput struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
-	Line          int64                  `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`
+	Stdout        string                 `protobuf:"bytes,1,opt,name=stdout,proto3" json:"stdout,omitempty"`
+	Stderr        string                 `protobuf:"bytes,2,opt,name=stderr,proto3" json:"stderr,omitempty"`
+	ExitCode      *int32                 `protobuf:"varint,3,opt,name=exit_code,json=exitCode,proto3,oneof" json:"exit_code,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ToolCallLocation) Reset() {
-	*x = ToolCallLocation{}
+func (x *ToolCallCommandOutput) Reset() {
+	*x = ToolCallCommandOutput{}
 	mi := &file_controlplane_v1_session_service_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ToolCallLocation) String() string {
+func (x *ToolCallCommandOutput) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ToolCallLocation) ProtoMessage() {}
+func (*ToolCallCommandOutput) ProtoMessage() {}
 
-func (x *ToolCallLocation) ProtoReflect() protoreflect.Message {
+func (x *ToolCallCommandOutput) ProtoReflect() protoreflect.Message {
 	mi := &file_controlplane_v1_session_service_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
@@ -1267,47 +1726,2500 @@ func (x *ToolCallLocation) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ToolCallLocation.ProtoReflect.Descriptor instead.
-func (*ToolCallLocation) Descriptor() ([]byte, []int) {
+// Deprecated: Use ToolCallCommandOutput.ProtoReflect.Descriptor instead.
+func (*ToolCallCommandOutput) Descriptor() ([]byte, []int) {
 	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{16}
 }
 
-func (x *ToolCallLocation) GetPath() string {
+func (x *ToolCallCommandOutput) GetStdout() string {
 	if x != nil {
-		return x.Path
+		return x.Stdout
 	}
 	return ""
 }
 
-func (x *ToolCallLocation) GetLine() int64 {
+func (x *ToolCallCommandOutput) GetStderr() string {
 	if x != nil {
-		return x.Line
+		return x.Stderr
+	}
+	return ""
+}
+
+func (x *ToolCallCommandOutput) GetExitCode() int32 {
+	if x != nil && x.ExitCode != nil {
+		return *x.ExitCode
+	}
+	return 0
+}
+
+// Structured input of a well-known tool, parsed from raw_input so clients
+// need not re-parse it. Unset for other tools.
+type ToolInput struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Tool:
+	//
+	//	*ToolInput_Read
+	//	*ToolInput_Write
+	//	*ToolInput_Edit
+	//	*ToolInput_Bash
+	//	*ToolInput_Grep
+	//	*ToolInput_Glob
+	Tool          isToolInput_Tool `protobuf_oneof:"tool"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolInput) Reset() {
+	*x = ToolInput{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolInput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolInput) ProtoMessage() {}
+
+func (x *ToolInput) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolInput.ProtoReflect.Descriptor instead.
+func (*ToolInput) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ToolInput) GetTool() isToolInput_Tool {
+	if x != nil {
+		return x.Tool
+	}
+	return nil
+}
+
+func (x *ToolInput) GetRead() *ToolInputRead {
+	if x != nil {
+		if x, ok := x.Tool.(*ToolInput_Read); ok {
+			return x.Read
+		}
+	}
+	return nil
+}
+
+func (x *ToolInput) GetWrite() *ToolInputWrite {
+	if x != nil {
+		if x, ok := x.Tool.(*ToolInput_Write); ok {
+			return x.Write
+		}
+	}
+	return nil
+}
+
+func (x *ToolInput) GetEdit() *ToolInputEdit {
+	if x != nil {
+		if x, ok := x.Tool.(*ToolInput_Edit); ok {
+			return x.Edit
+		}
+	}
+	return nil
+}
+
+func (x *ToolInput) GetBash() *ToolInputBash {
+	if x != nil {
+		if x, ok := x.Tool.(*ToolInput_Bash); ok {
+			return x.Bash
+		}
+	}
+	return nil
+}
+
+func (x *ToolInput) GetGrep() *ToolInputGrep {
+	if x != nil {
+		if x, ok := x.Tool.(*ToolInput_Grep); ok {
+			return x.Grep
+		}
+	}
+	return nil
+}
+
+func (x *ToolInput) GetGlob() *ToolInputGlob {
+	if x != nil {
+		if x, ok := x.Tool.(*ToolInput_Glob); ok {
+			return x.Glob
+		}
+	}
+	return nil
+}
+
+type isToolInput_Tool interface {
+	isToolInput_Tool()
+}
+
+type ToolInput_Read struct {
+	Read *ToolInputRead `protobuf:"bytes,1,opt,name=read,proto3,oneof"`
+}
+
+type ToolInput_Write struct {
+	Write *ToolInputWrite `protobuf:"bytes,2,opt,name=write,proto3,oneof"`
+}
+
+type ToolInput_Edit struct {
+	Edit *ToolInputEdit `protobuf:"bytes,3,opt,name=edit,proto3,oneof"`
+}
+
+type ToolInput_Bash struct {
+	Bash *ToolInputBash `protobuf:"bytes,4,opt,name=bash,proto3,oneof"`
+}
+
+type ToolInput_Grep struct {
+	Grep *ToolInputGrep `protobuf:"bytes,5,opt,name=grep,proto3,oneof"`
+}
+
+type ToolInput_Glob struct {
+	Glob *ToolInputGlob `protobuf:"bytes,6,opt,name=glob,proto3,oneof"`
+}
+
+func (*ToolInput_Read) isToolInput_Tool() {}
+
+func (*ToolInput_Write) isToolInput_Tool() {}
+
+func (*ToolInput_Edit) isToolInput_Tool() {}
+
+func (*ToolInput_Bash) isToolInput_Tool() {}
+
+func (*ToolInput_Grep) isToolInput_Tool() {}
+
+func (*ToolInput_Glob) isToolInput_Tool() {}
+
+type ToolInputRead struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FilePath      string                 `protobuf:"bytes,1,opt,name=file_path,json=filePath,proto3" json:"file_path,omitempty"`
+	Offset        *int64                 `protobuf:"varint,2,opt,name=offset,proto3,oneof" json:"offset,omitempty"`
+	Limit         *int64                 `protobuf:"varint,3,opt,name=limit,proto3,oneof" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolInputRead) Reset() {
+	*x = ToolInputRead{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolInputRead) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolInputRead) ProtoMessage() {}
+
+func (x *ToolInputRead) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolInputRead.ProtoReflect.Descriptor instead.
+func (*ToolInputRead) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ToolInputRead) GetFilePath() string {
+	if x != nil {
+		return x.FilePath
+	}
+	return ""
+}
+
+func (x *ToolInputRead) GetOffset() int64 {
+	if x != nil && x.Offset != nil {
+		return *x.Offset
+	}
+	return 0
+}
+
+func (x *ToolInputRead) GetLimit() int64 {
+	if x != nil && x.Limit != nil {
+		return *x.Limit
 	}
 	return 0
 }
 
-type StatusChange struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+type ToolInputWrite struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FilePath      string                 `protobuf:"bytes,1,opt,name=file_path,json=filePath,proto3" json:"file_path,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolInputWrite) Reset() {
+	*x = ToolInputWrite{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolInputWrite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolInputWrite) ProtoMessage() {}
+
+func (x *ToolInputWrite) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolInputWrite.ProtoReflect.Descriptor instead.
+func (*ToolInputWrite) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ToolInputWrite) GetFilePath() string {
+	if x != nil {
+		return x.FilePath
+	}
+	return ""
+}
+
+func (x *ToolInputWrite) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+type ToolInputEdit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FilePath      string                 `protobuf:"bytes,1,opt,name=file_path,json=filePath,proto3" json:"file_path,omitempty"`
+	OldString     string                 `protobuf:"bytes,2,opt,name=old_string,json=oldString,proto3" json:"old_string,omitempty"`
+	NewString     string                 `protobuf:"bytes,3,opt,name=new_string,json=newString,proto3" json:"new_string,omitempty"`
+	ReplaceAll    bool                   `protobuf:"varint,4,opt,name=replace_all,json=replaceAll,proto3" json:"replace_all,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolInputEdit) Reset() {
+	*x = ToolInputEdit{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolInputEdit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolInputEdit) ProtoMessage() {}
+
+func (x *ToolInputEdit) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolInputEdit.ProtoReflect.Descriptor instead.
+func (*ToolInputEdit) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ToolInputEdit) GetFilePath() string {
+	if x != nil {
+		return x.FilePath
+	}
+	return ""
+}
+
+func (x *ToolInputEdit) GetOldString() string {
+	if x != nil {
+		return x.OldString
+	}
+	return ""
+}
+
+func (x *ToolInputEdit) GetNewString() string {
+	if x != nil {
+		return x.NewString
+	}
+	return ""
+}
+
+func (x *ToolInputEdit) GetReplaceAll() bool {
+	if x != nil {
+		return x.ReplaceAll
+	}
+	return false
+}
+
+type ToolInputBash struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Command         string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	Description     string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	TimeoutMs       *int64                 `protobuf:"varint,3,opt,name=timeout_ms,json=timeoutMs,proto3,oneof" json:"timeout_ms,omitempty"`
+	RunInBackground bool                   `protobuf:"varint,4,opt,name=run_in_background,json=runInBackground,proto3" json:"run_in_background,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ToolInputBash) Reset() {
+	*x = ToolInputBash{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolInputBash) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolInputBash) ProtoMessage() {}
+
+func (x *ToolInputBash) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolInputBash.ProtoReflect.Descriptor instead.
+func (*ToolInputBash) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ToolInputBash) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *ToolInputBash) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *ToolInputBash) GetTimeoutMs() int64 {
+	if x != nil && x.TimeoutMs != nil {
+		return *x.TimeoutMs
+	}
+	return 0
+}
+
+func (x *ToolInputBash) GetRunInBackground() bool {
+	if x != nil {
+		return x.RunInBackground
+	}
+	return false
+}
+
+type ToolInputGrep struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Pattern         string                 `protobuf:"bytes,1,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	Path            string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Glob            string                 `protobuf:"bytes,3,opt,name=glob,proto3" json:"glob,omitempty"`
+	Type            string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	OutputMode      string                 `protobuf:"bytes,5,opt,name=output_mode,json=outputMode,proto3" json:"output_mode,omitempty"`
+	CaseInsensitive bool                   `protobuf:"varint,6,opt,name=case_insensitive,json=caseInsensitive,proto3" json:"case_insensitive,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ToolInputGrep) Reset() {
+	*x = ToolInputGrep{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolInputGrep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolInputGrep) ProtoMessage() {}
+
+func (x *ToolInputGrep) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolInputGrep.ProtoReflect.Descriptor instead.
+func (*ToolInputGrep) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ToolInputGrep) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *ToolInputGrep) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ToolInputGrep) GetGlob() string {
+	if x != nil {
+		return x.Glob
+	}
+	return ""
+}
+
+func (x *ToolInputGrep) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ToolInputGrep) GetOutputMode() string {
+	if x != nil {
+		return x.OutputMode
+	}
+	return ""
+}
+
+func (x *ToolInputGrep) GetCaseInsensitive() bool {
+	if x != nil {
+		return x.CaseInsensitive
+	}
+	return false
+}
+
+type ToolInputGlob struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pattern       string                 `protobuf:"bytes,1,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolInputGlob) Reset() {
+	*x = ToolInputGlob{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolInputGlob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolInputGlob) ProtoMessage() {}
+
+func (x *ToolInputGlob) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolInputGlob.ProtoReflect.Descriptor instead.
+func (*ToolInputGlob) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ToolInputGlob) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *ToolInputGlob) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type ToolCallLocation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Line          int64                  `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolCallLocation) Reset() {
+	*x = ToolCallLocation{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolCallLocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolCallLocation) ProtoMessage() {}
+
+func (x *ToolCallLocation) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolCallLocation.ProtoReflect.Descriptor instead.
+func (*ToolCallLocation) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ToolCallLocation) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ToolCallLocation) GetLine() int64 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+type StatusChange struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Status string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	// Why the session failed; set when status is errored.
+	Error *SessionError `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	// Whether the session takes a prompt now: true while idle and ready,
+	// false while it processes a prompt, is starting, stopping or has ended.
+	// Status alternates between running and idle with every turn while the
+	// session stays alive; clients enable their composer on this instead.
+	AcceptingInput bool `protobuf:"varint,3,opt,name=accepting_input,json=acceptingInput,proto3" json:"accepting_input,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *StatusChange) Reset() {
+	*x = StatusChange{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusChange) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusChange) ProtoMessage() {}
+
+func (x *StatusChange) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusChange.ProtoReflect.Descriptor instead.
+func (*StatusChange) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *StatusChange) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *StatusChange) GetError() *SessionError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *StatusChange) GetAcceptingInput() bool {
+	if x != nil {
+		return x.AcceptingInput
+	}
+	return false
+}
+
+type CurrentModeUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ModeId        string                 `protobuf:"bytes,1,opt,name=mode_id,json=modeId,proto3" json:"mode_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CurrentModeUpdate) Reset() {
+	*x = CurrentModeUpdate{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CurrentModeUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CurrentModeUpdate) ProtoMessage() {}
+
+func (x *CurrentModeUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CurrentModeUpdate.ProtoReflect.Descriptor instead.
+func (*CurrentModeUpdate) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *CurrentModeUpdate) GetModeId() string {
+	if x != nil {
+		return x.ModeId
+	}
+	return ""
+}
+
+type CurrentModelUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ModelId       string                 `protobuf:"bytes,1,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CurrentModelUpdate) Reset() {
+	*x = CurrentModelUpdate{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CurrentModelUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CurrentModelUpdate) ProtoMessage() {}
+
+func (x *CurrentModelUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CurrentModelUpdate.ProtoReflect.Descriptor instead.
+func (*CurrentModelUpdate) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *CurrentModelUpdate) GetModelId() string {
+	if x != nil {
+		return x.ModelId
+	}
+	return ""
+}
+
+// Why a session failed. reason is "auth" when the agent needs to be
+// re-authenticated, empty otherwise.
+type SessionError struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Reason  string                 `protobuf:"bytes,1,opt,name=reason,proto3" json:"reason,omitempty"`
+	Message string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// The agent's last stderr lines before the failure, oldest first.
+	StderrTail    []string `protobuf:"bytes,3,rep,name=stderr_tail,json=stderrTail,proto3" json:"stderr_tail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionError) Reset() {
+	*x = SessionError{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionError) ProtoMessage() {}
+
+func (x *SessionError) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionError.ProtoReflect.Descriptor instead.
+func (*SessionError) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *SessionError) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+func (x *SessionError) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *SessionError) GetStderrTail() []string {
+	if x != nil {
+		return x.StderrTail
+	}
+	return nil
+}
+
+// An agent asked for permission to run a tool. request_id is the ID of the
+// tool call awaiting approval.
+type PermissionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Kind          ToolCallKind           `protobuf:"varint,3,opt,name=kind,proto3,enum=controlplane.v1.ToolCallKind" json:"kind,omitempty"`
+	Options       []*PermissionOption    `protobuf:"bytes,4,rep,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PermissionRequest) Reset() {
+	*x = PermissionRequest{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PermissionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PermissionRequest) ProtoMessage() {}
+
+func (x *PermissionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PermissionRequest.ProtoReflect.Descriptor instead.
+func (*PermissionRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *PermissionRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *PermissionRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *PermissionRequest) GetKind() ToolCallKind {
+	if x != nil {
+		return x.Kind
+	}
+	return ToolCallKind_TOOL_CALL_KIND_UNSPECIFIED
+}
+
+func (x *PermissionRequest) GetOptions() []*PermissionOption {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+// kind is the ACP option kind: "allow_once", "allow_always", "reject_once"
+// or "reject_always".
+type PermissionOption struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	OptionId      string                 `protobuf:"bytes,1,opt,name=option_id,json=optionId,proto3" json:"option_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Kind          string                 `protobuf:"bytes,3,opt,name=kind,proto3" json:"kind,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PermissionOption) Reset() {
+	*x = PermissionOption{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PermissionOption) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PermissionOption) ProtoMessage() {}
+
+func (x *PermissionOption) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PermissionOption.ProtoReflect.Descriptor instead.
+func (*PermissionOption) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *PermissionOption) GetOptionId() string {
+	if x != nil {
+		return x.OptionId
+	}
+	return ""
+}
+
+func (x *PermissionOption) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PermissionOption) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+// How a permission request ended. outcome is "allowed", "auto_approved",
+// "denied" or "cancelled"; option_id is the selected option, empty unless
+// the tool was allowed.
+type PermissionResolved struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	OptionId      string                 `protobuf:"bytes,2,opt,name=option_id,json=optionId,proto3" json:"option_id,omitempty"`
+	Outcome       string                 `protobuf:"bytes,3,opt,name=outcome,proto3" json:"outcome,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PermissionResolved) Reset() {
+	*x = PermissionResolved{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PermissionResolved) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PermissionResolved) ProtoMessage() {}
+
+func (x *PermissionResolved) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PermissionResolved.ProtoReflect.Descriptor instead.
+func (*PermissionResolved) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *PermissionResolved) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *PermissionResolved) GetOptionId() string {
+	if x != nil {
+		return x.OptionId
+	}
+	return ""
+}
+
+func (x *PermissionResolved) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+// The worker dropped count events before this point because its retention
+// limit was reached.
+type EventsPruned struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Count         int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EventsPruned) Reset() {
+	*x = EventsPruned{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EventsPruned) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EventsPruned) ProtoMessage() {}
+
+func (x *EventsPruned) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EventsPruned.ProtoReflect.Descriptor instead.
+func (*EventsPruned) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *EventsPruned) GetCount() int64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+// Progress of an MCP server the agent starts; text summarizes it for
+// clients that don't render it separately.
+type McpServerStartup struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Ready         bool                   `protobuf:"varint,4,opt,name=ready,proto3" json:"ready,omitempty"`
+	Text          string                 `protobuf:"bytes,5,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *McpServerStartup) Reset() {
+	*x = McpServerStartup{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *McpServerStartup) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*McpServerStartup) ProtoMessage() {}
+
+func (x *McpServerStartup) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use McpServerStartup.ProtoReflect.Descriptor instead.
+func (*McpServerStartup) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *McpServerStartup) GetServer() string {
+	if x != nil {
+		return x.Server
+	}
+	return ""
+}
+
+func (x *McpServerStartup) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *McpServerStartup) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *McpServerStartup) GetReady() bool {
+	if x != nil {
+		return x.Ready
+	}
+	return false
+}
+
+func (x *McpServerStartup) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+// The configuration the agent reported it started the session with; text
+// summarizes it for clients that don't render it separately.
+type SessionInit struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Model          string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	PermissionMode string                 `protobuf:"bytes,2,opt,name=permission_mode,json=permissionMode,proto3" json:"permission_mode,omitempty"`
+	Tools          []string               `protobuf:"bytes,3,rep,name=tools,proto3" json:"tools,omitempty"`
+	Text           string                 `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SessionInit) Reset() {
+	*x = SessionInit{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionInit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionInit) ProtoMessage() {}
+
+func (x *SessionInit) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionInit.ProtoReflect.Descriptor instead.
+func (*SessionInit) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *SessionInit) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *SessionInit) GetPermissionMode() string {
+	if x != nil {
+		return x.PermissionMode
+	}
+	return ""
+}
+
+func (x *SessionInit) GetTools() []string {
+	if x != nil {
+		return x.Tools
+	}
+	return nil
+}
+
+func (x *SessionInit) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+// The agent reported progress on a long task; percent (0-100) is optional.
+type Progress struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Percent       *int32                 `protobuf:"varint,2,opt,name=percent,proto3,oneof" json:"percent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Progress) Reset() {
+	*x = Progress{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Progress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Progress) ProtoMessage() {}
+
+func (x *Progress) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Progress.ProtoReflect.Descriptor instead.
+func (*Progress) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *Progress) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *Progress) GetPercent() int32 {
+	if x != nil && x.Percent != nil {
+		return *x.Percent
+	}
+	return 0
+}
+
+// A prompt turn ended. cancel_reason is set if stop_reason is
+// STOP_REASON_CANCELLED. stats is set if the agent answered the prompt.
+type TurnEnded struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StopReason    StopReason             `protobuf:"varint,1,opt,name=stop_reason,json=stopReason,proto3,enum=controlplane.v1.StopReason" json:"stop_reason,omitempty"`
+	CancelReason  CancelReason           `protobuf:"varint,2,opt,name=cancel_reason,json=cancelReason,proto3,enum=controlplane.v1.CancelReason" json:"cancel_reason,omitempty"`
+	Stats         *TurnStats             `protobuf:"bytes,3,opt,name=stats,proto3" json:"stats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TurnEnded) Reset() {
+	*x = TurnEnded{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[36]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TurnEnded) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TurnEnded) ProtoMessage() {}
+
+func (x *TurnEnded) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[36]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TurnEnded.ProtoReflect.Descriptor instead.
+func (*TurnEnded) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{36}
+}
+
+func (x *TurnEnded) GetStopReason() StopReason {
+	if x != nil {
+		return x.StopReason
+	}
+	return StopReason_STOP_REASON_UNSPECIFIED
+}
+
+func (x *TurnEnded) GetCancelReason() CancelReason {
+	if x != nil {
+		return x.CancelReason
+	}
+	return CancelReason_CANCEL_REASON_UNSPECIFIED
+}
+
+func (x *TurnEnded) GetStats() *TurnStats {
+	if x != nil {
+		return x.Stats
+	}
+	return nil
+}
+
+// Timing and usage of a prompt turn. time_to_first_token_ms is zero if the
+// agent sent no output; the token counts are zero if it doesn't report them.
+type TurnStats struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	DurationMs         int64                  `protobuf:"varint,1,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	TimeToFirstTokenMs int64                  `protobuf:"varint,2,opt,name=time_to_first_token_ms,json=timeToFirstTokenMs,proto3" json:"time_to_first_token_ms,omitempty"`
+	ToolCalls          int32                  `protobuf:"varint,3,opt,name=tool_calls,json=toolCalls,proto3" json:"tool_calls,omitempty"`
+	InputTokens        int64                  `protobuf:"varint,4,opt,name=input_tokens,json=inputTokens,proto3" json:"input_tokens,omitempty"`
+	OutputTokens       int64                  `protobuf:"varint,5,opt,name=output_tokens,json=outputTokens,proto3" json:"output_tokens,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *TurnStats) Reset() {
+	*x = TurnStats{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TurnStats) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TurnStats) ProtoMessage() {}
+
+func (x *TurnStats) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TurnStats.ProtoReflect.Descriptor instead.
+func (*TurnStats) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *TurnStats) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+func (x *TurnStats) GetTimeToFirstTokenMs() int64 {
+	if x != nil {
+		return x.TimeToFirstTokenMs
+	}
+	return 0
+}
+
+func (x *TurnStats) GetToolCalls() int32 {
+	if x != nil {
+		return x.ToolCalls
+	}
+	return 0
+}
+
+func (x *TurnStats) GetInputTokens() int64 {
+	if x != nil {
+		return x.InputTokens
+	}
+	return 0
+}
+
+func (x *TurnStats) GetOutputTokens() int64 {
+	if x != nil {
+		return x.OutputTokens
+	}
+	return 0
+}
+
+// The agent's current execution plan; each AgentPlan replaces the previous one.
+type AgentPlan struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*AgentPlanEntry      `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentPlan) Reset() {
+	*x = AgentPlan{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentPlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentPlan) ProtoMessage() {}
+
+func (x *AgentPlan) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentPlan.ProtoReflect.Descriptor instead.
+func (*AgentPlan) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *AgentPlan) GetEntries() []*AgentPlanEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
+}
+
+type AgentPlanEntry struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Content       string                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	Priority      string                 `protobuf:"bytes,2,opt,name=priority,proto3" json:"priority,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentPlanEntry) Reset() {
+	*x = AgentPlanEntry{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentPlanEntry) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentPlanEntry) ProtoMessage() {}
+
+func (x *AgentPlanEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentPlanEntry.ProtoReflect.Descriptor instead.
+func (*AgentPlanEntry) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *AgentPlanEntry) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *AgentPlanEntry) GetPriority() string {
+	if x != nil {
+		return x.Priority
+	}
+	return ""
+}
+
+func (x *AgentPlanEntry) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+// The agent submitted plans via `agentctl plan commit`, one per thread.
+type PlanSubmitted struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Plans         []*Plan                `protobuf:"bytes,1,rep,name=plans,proto3" json:"plans,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlanSubmitted) Reset() {
+	*x = PlanSubmitted{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlanSubmitted) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanSubmitted) ProtoMessage() {}
+
+func (x *PlanSubmitted) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanSubmitted.ProtoReflect.Descriptor instead.
+func (*PlanSubmitted) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *PlanSubmitted) GetPlans() []*Plan {
+	if x != nil {
+		return x.Plans
+	}
+	return nil
+}
+
+type Plan struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ThreadId      string                 `protobuf:"bytes,1,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Body          string                 `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
+	Steps         []*PlanStep            `protobuf:"bytes,4,rep,name=steps,proto3" json:"steps,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Plan) Reset() {
+	*x = Plan{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Plan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Plan) ProtoMessage() {}
+
+func (x *Plan) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Plan.ProtoReflect.Descriptor instead.
+func (*Plan) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *Plan) GetThreadId() string {
+	if x != nil {
+		return x.ThreadId
+	}
+	return ""
+}
+
+func (x *Plan) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Plan) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+func (x *Plan) GetSteps() []*PlanStep {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+type PlanStep struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	File          string                 `protobuf:"bytes,2,opt,name=file,proto3" json:"file,omitempty"` // task file name, e.g. "01-setup.md"
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	DependsOn     []string               `protobuf:"bytes,4,rep,name=depends_on,json=dependsOn,proto3" json:"depends_on,omitempty"`
+	Agent         string                 `protobuf:"bytes,5,opt,name=agent,proto3" json:"agent,omitempty"`
+	Subtasks      []string               `protobuf:"bytes,6,rep,name=subtasks,proto3" json:"subtasks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PlanStep) Reset() {
+	*x = PlanStep{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[42]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PlanStep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PlanStep) ProtoMessage() {}
+
+func (x *PlanStep) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[42]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PlanStep.ProtoReflect.Descriptor instead.
+func (*PlanStep) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{42}
+}
+
+func (x *PlanStep) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *PlanStep) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
+}
+
+func (x *PlanStep) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *PlanStep) GetDependsOn() []string {
+	if x != nil {
+		return x.DependsOn
+	}
+	return nil
+}
+
+func (x *PlanStep) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+func (x *PlanStep) GetSubtasks() []string {
+	if x != nil {
+		return x.Subtasks
+	}
+	return nil
+}
+
+type WatchSessionEventsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Identify what to watch — one of these must be set.
+	SessionId     string `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`              // watch a specific session
+	ThreadId      string `protobuf:"bytes,2,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`                 // watch all sessions for a thread
+	TaskId        string `protobuf:"bytes,3,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`                       // watch sessions for a specific task
+	AfterSequence int64  `protobuf:"varint,4,opt,name=after_sequence,json=afterSequence,proto3" json:"after_sequence,omitempty"` // 0 = include all history
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchSessionEventsRequest) Reset() {
+	*x = WatchSessionEventsRequest{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchSessionEventsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchSessionEventsRequest) ProtoMessage() {}
+
+func (x *WatchSessionEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchSessionEventsRequest.ProtoReflect.Descriptor instead.
+func (*WatchSessionEventsRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{43}
+}
+
+func (x *WatchSessionEventsRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *WatchSessionEventsRequest) GetThreadId() string {
+	if x != nil {
+		return x.ThreadId
+	}
+	return ""
+}
+
+func (x *WatchSessionEventsRequest) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *WatchSessionEventsRequest) GetAfterSequence() int64 {
+	if x != nil {
+		return x.AfterSequence
+	}
+	return 0
+}
+
+type WatchSessionEventsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Unified: both history replay and live events are sent as SessionEvent.
+	Event *SessionEvent `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	// True for DB history replay, false for live — lets frontend know when catch-up is done.
+	IsHistory bool `protobuf:"varint,2,opt,name=is_history,json=isHistory,proto3" json:"is_history,omitempty"`
+	// Set (with event unset) on keepalive messages sent while the stream is idle.
+	// Heartbeats carry no sequence and are never persisted.
+	Heartbeat     *Heartbeat `protobuf:"bytes,3,opt,name=heartbeat,proto3" json:"heartbeat,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchSessionEventsResponse) Reset() {
+	*x = WatchSessionEventsResponse{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[44]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchSessionEventsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchSessionEventsResponse) ProtoMessage() {}
+
+func (x *WatchSessionEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[44]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchSessionEventsResponse.ProtoReflect.Descriptor instead.
+func (*WatchSessionEventsResponse) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{44}
+}
+
+func (x *WatchSessionEventsResponse) GetEvent() *SessionEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *WatchSessionEventsResponse) GetIsHistory() bool {
+	if x != nil {
+		return x.IsHistory
+	}
+	return false
+}
+
+func (x *WatchSessionEventsResponse) GetHeartbeat() *Heartbeat {
+	if x != nil {
+		return x.Heartbeat
+	}
+	return nil
+}
+
+type Heartbeat struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Timestamp     string                 `protobuf:"bytes,1,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Heartbeat) Reset() {
+	*x = Heartbeat{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Heartbeat) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Heartbeat) ProtoMessage() {}
+
+func (x *Heartbeat) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[45]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Heartbeat.ProtoReflect.Descriptor instead.
+func (*Heartbeat) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{45}
+}
+
+func (x *Heartbeat) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+type WatchSessionLifecycleRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only sessions of this thread; empty watches every session.
+	ThreadId      string `protobuf:"bytes,1,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchSessionLifecycleRequest) Reset() {
+	*x = WatchSessionLifecycleRequest{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchSessionLifecycleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchSessionLifecycleRequest) ProtoMessage() {}
+
+func (x *WatchSessionLifecycleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchSessionLifecycleRequest.ProtoReflect.Descriptor instead.
+func (*WatchSessionLifecycleRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{46}
+}
+
+func (x *WatchSessionLifecycleRequest) GetThreadId() string {
+	if x != nil {
+		return x.ThreadId
+	}
+	return ""
+}
+
+type WatchSessionLifecycleResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Event *SessionLifecycleEvent `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
+	// Set (with event unset) on keepalive messages sent while the stream is idle.
+	Heartbeat     *Heartbeat `protobuf:"bytes,2,opt,name=heartbeat,proto3" json:"heartbeat,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchSessionLifecycleResponse) Reset() {
+	*x = WatchSessionLifecycleResponse{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[47]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchSessionLifecycleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchSessionLifecycleResponse) ProtoMessage() {}
+
+func (x *WatchSessionLifecycleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[47]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchSessionLifecycleResponse.ProtoReflect.Descriptor instead.
+func (*WatchSessionLifecycleResponse) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{47}
+}
+
+func (x *WatchSessionLifecycleResponse) GetEvent() *SessionLifecycleEvent {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *WatchSessionLifecycleResponse) GetHeartbeat() *Heartbeat {
+	if x != nil {
+		return x.Heartbeat
+	}
+	return nil
+}
+
+type SessionLifecycleEvent struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	ThreadId  string                 `protobuf:"bytes,2,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`
+	Kind      SessionLifecycleKind   `protobuf:"varint,3,opt,name=kind,proto3,enum=controlplane.v1.SessionLifecycleKind" json:"kind,omitempty"`
+	// The session's status after the change, as in SessionConfig.status.
+	Status    string `protobuf:"bytes,4,opt,name=status,proto3" json:"status,omitempty"`
+	CreatedAt string `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// When the change happened.
+	Timestamp     string `protobuf:"bytes,6,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionLifecycleEvent) Reset() {
+	*x = SessionLifecycleEvent{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[48]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionLifecycleEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionLifecycleEvent) ProtoMessage() {}
+
+func (x *SessionLifecycleEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[48]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionLifecycleEvent.ProtoReflect.Descriptor instead.
+func (*SessionLifecycleEvent) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{48}
+}
+
+func (x *SessionLifecycleEvent) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *SessionLifecycleEvent) GetThreadId() string {
+	if x != nil {
+		return x.ThreadId
+	}
+	return ""
+}
+
+func (x *SessionLifecycleEvent) GetKind() SessionLifecycleKind {
+	if x != nil {
+		return x.Kind
+	}
+	return SessionLifecycleKind_SESSION_LIFECYCLE_KIND_UNSPECIFIED
+}
+
+func (x *SessionLifecycleEvent) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *SessionLifecycleEvent) GetCreatedAt() string {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return ""
+}
+
+func (x *SessionLifecycleEvent) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+type CreateSessionRequest struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	ThreadId    string                 `protobuf:"bytes,1,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`
+	WorkerId    string                 `protobuf:"bytes,2,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
+	Prompt      string                 `protobuf:"bytes,3,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	Agent       string                 `protobuf:"bytes,4,opt,name=agent,proto3" json:"agent,omitempty"`
+	Model       string                 `protobuf:"bytes,5,opt,name=model,proto3" json:"model,omitempty"`
+	Mode        string                 `protobuf:"bytes,6,opt,name=mode,proto3" json:"mode,omitempty"`
+	SessionMode string                 `protobuf:"bytes,7,opt,name=session_mode,json=sessionMode,proto3" json:"session_mode,omitempty"`
+	// When set, a retried create with the same key returns the session the
+	// first one created instead of starting another.
+	IdempotencyKey string `protobuf:"bytes,8,opt,name=idempotency_key,json=idempotencyKey,proto3" json:"idempotency_key,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *CreateSessionRequest) Reset() {
+	*x = CreateSessionRequest{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[49]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSessionRequest) ProtoMessage() {}
+
+func (x *CreateSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[49]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSessionRequest.ProtoReflect.Descriptor instead.
+func (*CreateSessionRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{49}
+}
+
+func (x *CreateSessionRequest) GetThreadId() string {
+	if x != nil {
+		return x.ThreadId
+	}
+	return ""
+}
+
+func (x *CreateSessionRequest) GetWorkerId() string {
+	if x != nil {
+		return x.WorkerId
+	}
+	return ""
+}
+
+func (x *CreateSessionRequest) GetPrompt() string {
+	if x != nil {
+		return x.Prompt
+	}
+	return ""
+}
+
+func (x *CreateSessionRequest) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
+}
+
+func (x *CreateSessionRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *CreateSessionRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *CreateSessionRequest) GetSessionMode() string {
+	if x != nil {
+		return x.SessionMode
+	}
+	return ""
+}
+
+func (x *CreateSessionRequest) GetIdempotencyKey() string {
+	if x != nil {
+		return x.IdempotencyKey
+	}
+	return ""
+}
+
+type CreateSessionResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Session       *SessionConfig         `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateSessionResponse) Reset() {
+	*x = CreateSessionResponse{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[50]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateSessionResponse) ProtoMessage() {}
+
+func (x *CreateSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[50]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateSessionResponse.ProtoReflect.Descriptor instead.
+func (*CreateSessionResponse) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{50}
+}
+
+func (x *CreateSessionResponse) GetSession() *SessionConfig {
+	if x != nil {
+		return x.Session
+	}
+	return nil
+}
+
+type SendUserMessageRequest struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	ThreadId string                 `protobuf:"bytes,1,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`
+	// The message text. It is sent ahead of content_blocks; one of the two
+	// must be set.
+	Text string `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	// Content blocks, such as images or files, to send with or instead of
+	// text.
+	ContentBlocks []*PromptContentBlock `protobuf:"bytes,3,rep,name=content_blocks,json=contentBlocks,proto3" json:"content_blocks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendUserMessageRequest) Reset() {
+	*x = SendUserMessageRequest{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendUserMessageRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendUserMessageRequest) ProtoMessage() {}
+
+func (x *SendUserMessageRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[51]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendUserMessageRequest.ProtoReflect.Descriptor instead.
+func (*SendUserMessageRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *SendUserMessageRequest) GetThreadId() string {
+	if x != nil {
+		return x.ThreadId
+	}
+	return ""
+}
+
+func (x *SendUserMessageRequest) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *SendUserMessageRequest) GetContentBlocks() []*PromptContentBlock {
+	if x != nil {
+		return x.ContentBlocks
+	}
+	return nil
+}
+
+type SendUserMessageResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendUserMessageResponse) Reset() {
+	*x = SendUserMessageResponse{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[52]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendUserMessageResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendUserMessageResponse) ProtoMessage() {}
+
+func (x *SendUserMessageResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[52]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendUserMessageResponse.ProtoReflect.Descriptor instead.
+func (*SendUserMessageResponse) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{52}
+}
+
+// PromptContentBlock is one block of a prompt. An empty type is "text".
+type PromptContentBlock struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // "text", "image" or "resource"
+	// The text of a "text" block, or the contents of a text "resource".
+	Text string `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	// The MIME type of an "image" or "resource" block.
+	MimeType string `protobuf:"bytes,3,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	// Base64-encoded data: the image of an "image" block, or the contents of
+	// a binary "resource".
+	Data string `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	// The URI of a "resource" block.
+	Uri           string `protobuf:"bytes,5,opt,name=uri,proto3" json:"uri,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PromptContentBlock) Reset() {
+	*x = PromptContentBlock{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PromptContentBlock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PromptContentBlock) ProtoMessage() {}
+
+func (x *PromptContentBlock) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[53]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PromptContentBlock.ProtoReflect.Descriptor instead.
+func (*PromptContentBlock) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *PromptContentBlock) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *PromptContentBlock) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *PromptContentBlock) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *PromptContentBlock) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *PromptContentBlock) GetUri() string {
+	if x != nil {
+		return x.Uri
+	}
+	return ""
+}
+
+type SendPromptRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ThreadId      string                 `protobuf:"bytes,1,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`
+	ContentBlocks []*PromptContentBlock  `protobuf:"bytes,2,rep,name=content_blocks,json=contentBlocks,proto3" json:"content_blocks,omitempty"`
+	// Optional model to use for this prompt only; the session's model is
+	// restored when the turn ends.
+	Model string `protobuf:"bytes,3,opt,name=model,proto3" json:"model,omitempty"`
+	// Optional session mode to use for this prompt only; the session's mode is
+	// restored when the turn ends.
+	SessionMode   string `protobuf:"bytes,4,opt,name=session_mode,json=sessionMode,proto3" json:"session_mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SendPromptRequest) Reset() {
+	*x = SendPromptRequest{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[54]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SendPromptRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SendPromptRequest) ProtoMessage() {}
+
+func (x *SendPromptRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[54]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SendPromptRequest.ProtoReflect.Descriptor instead.
+func (*SendPromptRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{54}
+}
+
+func (x *SendPromptRequest) GetThreadId() string {
+	if x != nil {
+		return x.ThreadId
+	}
+	return ""
+}
+
+func (x *SendPromptRequest) GetContentBlocks() []*PromptContentBlock {
+	if x != nil {
+		return x.ContentBlocks
+	}
+	return nil
+}
+
+func (x *SendPromptRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *SendPromptRequest) GetSessionMode() string {
+	if x != nil {
+		return x.SessionMode
+	}
+	return ""
+}
+
+type SendPromptResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Why the agent ended the turn (e.g. "end_turn", "cancelled").
+	StopReason    string `protobuf:"bytes,1,opt,name=stop_reason,json=stopReason,proto3" json:"stop_reason,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StatusChange) Reset() {
-	*x = StatusChange{}
-	mi := &file_controlplane_v1_session_service_proto_msgTypes[17]
+func (x *SendPromptResponse) Reset() {
+	*x = SendPromptResponse{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[55]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StatusChange) String() string {
+func (x *SendPromptResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StatusChange) ProtoMessage() {}
+func (*SendPromptResponse) ProtoMessage() {}
 
-func (x *StatusChange) ProtoReflect() protoreflect.Message {
-	mi := &file_controlplane_v1_session_service_proto_msgTypes[17]
+func (x *SendPromptResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[55]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1318,40 +4230,41 @@ func (x *StatusChange) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StatusChange.ProtoReflect.Descriptor instead.
-func (*StatusChange) Descriptor() ([]byte, []int) {
-	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use SendPromptResponse.ProtoReflect.Descriptor instead.
+func (*SendPromptResponse) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{55}
 }
 
-func (x *StatusChange) GetStatus() string {
+func (x *SendPromptResponse) GetStopReason() string {
 	if x != nil {
-		return x.Status
+		return x.StopReason
 	}
 	return ""
 }
 
-type CurrentModeUpdate struct {
+type ExportSessionRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	ModeId        string                 `protobuf:"bytes,1,opt,name=mode_id,json=modeId,proto3" json:"mode_id,omitempty"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Format        ExportFormat           `protobuf:"varint,2,opt,name=format,proto3,enum=controlplane.v1.ExportFormat" json:"format,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CurrentModeUpdate) Reset() {
-	*x = CurrentModeUpdate{}
-	mi := &file_controlplane_v1_session_service_proto_msgTypes[18]
+func (x *ExportSessionRequest) Reset() {
+	*x = ExportSessionRequest{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[56]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CurrentModeUpdate) String() string {
+func (x *ExportSessionRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CurrentModeUpdate) ProtoMessage() {}
+func (*ExportSessionRequest) ProtoMessage() {}
 
-func (x *CurrentModeUpdate) ProtoReflect() protoreflect.Message {
-	mi := &file_controlplane_v1_session_service_proto_msgTypes[18]
+func (x *ExportSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[56]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1362,44 +4275,50 @@ func (x *CurrentModeUpdate) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CurrentModeUpdate.ProtoReflect.Descriptor instead.
-func (*CurrentModeUpdate) Descriptor() ([]byte, []int) {
-	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{18}
+// Deprecated: Use ExportSessionRequest.ProtoReflect.Descriptor instead.
+func (*ExportSessionRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{56}
 }
 
-func (x *CurrentModeUpdate) GetModeId() string {
+func (x *ExportSessionRequest) GetSessionId() string {
 	if x != nil {
-		return x.ModeId
+		return x.SessionId
 	}
 	return ""
 }
 
-type WatchSessionEventsRequest struct {
+func (x *ExportSessionRequest) GetFormat() ExportFormat {
+	if x != nil {
+		return x.Format
+	}
+	return ExportFormat_EXPORT_FORMAT_UNSPECIFIED
+}
+
+type ExportSessionResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// Identify what to watch — one of these must be set.
-	SessionId     string `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`              // watch a specific session
-	ThreadId      string `protobuf:"bytes,2,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`                 // watch all sessions for a thread
-	TaskId        string `protobuf:"bytes,3,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`                       // watch sessions for a specific task
-	AfterSequence int64  `protobuf:"varint,4,opt,name=after_sequence,json=afterSequence,proto3" json:"after_sequence,omitempty"` // 0 = include all history
+	// The rendered transcript.
+	Content string `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	// "text/markdown" or "application/json".
+	ContentType   string `protobuf:"bytes,2,opt,name=content_type,json=contentType,proto3" json:"content_type,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *WatchSessionEventsRequest) Reset() {
-	*x = WatchSessionEventsRequest{}
-	mi := &file_controlplane_v1_session_service_proto_msgTypes[19]
+func (x *ExportSessionResponse) Reset() {
+	*x = ExportSessionResponse{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[57]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *WatchSessionEventsRequest) String() string {
+func (x *ExportSessionResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WatchSessionEventsRequest) ProtoMessage() {}
+func (*ExportSessionResponse) ProtoMessage() {}
 
-func (x *WatchSessionEventsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_controlplane_v1_session_service_proto_msgTypes[19]
+func (x *ExportSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[57]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1410,64 +4329,93 @@ func (x *WatchSessionEventsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WatchSessionEventsRequest.ProtoReflect.Descriptor instead.
-func (*WatchSessionEventsRequest) Descriptor() ([]byte, []int) {
-	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{19}
+// Deprecated: Use ExportSessionResponse.ProtoReflect.Descriptor instead.
+func (*ExportSessionResponse) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{57}
 }
 
-func (x *WatchSessionEventsRequest) GetSessionId() string {
+func (x *ExportSessionResponse) GetContent() string {
 	if x != nil {
-		return x.SessionId
+		return x.Content
 	}
 	return ""
 }
 
-func (x *WatchSessionEventsRequest) GetThreadId() string {
+func (x *ExportSessionResponse) GetContentType() string {
 	if x != nil {
-		return x.ThreadId
+		return x.ContentType
 	}
 	return ""
 }
 
-func (x *WatchSessionEventsRequest) GetTaskId() string {
+type GetPlanRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPlanRequest) Reset() {
+	*x = GetPlanRequest{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[58]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPlanRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPlanRequest) ProtoMessage() {}
+
+func (x *GetPlanRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[58]
 	if x != nil {
-		return x.TaskId
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *WatchSessionEventsRequest) GetAfterSequence() int64 {
+// Deprecated: Use GetPlanRequest.ProtoReflect.Descriptor instead.
+func (*GetPlanRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{58}
+}
+
+func (x *GetPlanRequest) GetSessionId() string {
 	if x != nil {
-		return x.AfterSequence
+		return x.SessionId
 	}
-	return 0
+	return ""
 }
 
-type WatchSessionEventsResponse struct {
+type GetPlanResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// Unified: both history replay and live events are sent as SessionEvent.
-	Event *SessionEvent `protobuf:"bytes,1,opt,name=event,proto3" json:"event,omitempty"`
-	// True for DB history replay, false for live — lets frontend know when catch-up is done.
-	IsHistory     bool `protobuf:"varint,2,opt,name=is_history,json=isHistory,proto3" json:"is_history,omitempty"`
+	Plans []*Plan                `protobuf:"bytes,1,rep,name=plans,proto3" json:"plans,omitempty"`
+	// RFC 3339 time of the submission.
+	SubmittedAt   string `protobuf:"bytes,2,opt,name=submitted_at,json=submittedAt,proto3" json:"submitted_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *WatchSessionEventsResponse) Reset() {
-	*x = WatchSessionEventsResponse{}
-	mi := &file_controlplane_v1_session_service_proto_msgTypes[20]
+func (x *GetPlanResponse) Reset() {
+	*x = GetPlanResponse{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[59]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *WatchSessionEventsResponse) String() string {
+func (x *GetPlanResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*WatchSessionEventsResponse) ProtoMessage() {}
+func (*GetPlanResponse) ProtoMessage() {}
 
-func (x *WatchSessionEventsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_controlplane_v1_session_service_proto_msgTypes[20]
+func (x *GetPlanResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[59]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1478,53 +4426,53 @@ func (x *WatchSessionEventsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use WatchSessionEventsResponse.ProtoReflect.Descriptor instead.
-func (*WatchSessionEventsResponse) Descriptor() ([]byte, []int) {
-	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{20}
+// Deprecated: Use GetPlanResponse.ProtoReflect.Descriptor instead.
+func (*GetPlanResponse) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{59}
 }
 
-func (x *WatchSessionEventsResponse) GetEvent() *SessionEvent {
+func (x *GetPlanResponse) GetPlans() []*Plan {
 	if x != nil {
-		return x.Event
+		return x.Plans
 	}
 	return nil
 }
 
-func (x *WatchSessionEventsResponse) GetIsHistory() bool {
+func (x *GetPlanResponse) GetSubmittedAt() string {
 	if x != nil {
-		return x.IsHistory
+		return x.SubmittedAt
 	}
-	return false
+	return ""
 }
 
-type CreateSessionRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ThreadId      string                 `protobuf:"bytes,1,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`
-	WorkerId      string                 `protobuf:"bytes,2,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
-	Prompt        string                 `protobuf:"bytes,3,opt,name=prompt,proto3" json:"prompt,omitempty"`
-	Agent         string                 `protobuf:"bytes,4,opt,name=agent,proto3" json:"agent,omitempty"`
-	Model         string                 `protobuf:"bytes,5,opt,name=model,proto3" json:"model,omitempty"`
-	Mode          string                 `protobuf:"bytes,6,opt,name=mode,proto3" json:"mode,omitempty"`
-	SessionMode   string                 `protobuf:"bytes,7,opt,name=session_mode,json=sessionMode,proto3" json:"session_mode,omitempty"`
+type SessionServiceListThreadsRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	ProjectId string                 `protobuf:"bytes,1,opt,name=project_id,json=projectId,proto3" json:"project_id,omitempty"`
+	// List the least recently updated threads first.
+	OldestFirst bool `protobuf:"varint,2,opt,name=oldest_first,json=oldestFirst,proto3" json:"oldest_first,omitempty"`
+	// Maximum number of threads returned; zero uses 50, at most 200.
+	PageSize int32 `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	// next_page_token of the previous response; empty starts at the first page.
+	PageToken     string `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreateSessionRequest) Reset() {
-	*x = CreateSessionRequest{}
-	mi := &file_controlplane_v1_session_service_proto_msgTypes[21]
+func (x *SessionServiceListThreadsRequest) Reset() {
+	*x = SessionServiceListThreadsRequest{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[60]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateSessionRequest) String() string {
+func (x *SessionServiceListThreadsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateSessionRequest) ProtoMessage() {}
+func (*SessionServiceListThreadsRequest) ProtoMessage() {}
 
-func (x *CreateSessionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_controlplane_v1_session_service_proto_msgTypes[21]
+func (x *SessionServiceListThreadsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[60]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1535,82 +4483,63 @@ func (x *CreateSessionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateSessionRequest.ProtoReflect.Descriptor instead.
-func (*CreateSessionRequest) Descriptor() ([]byte, []int) {
-	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{21}
-}
-
-func (x *CreateSessionRequest) GetThreadId() string {
-	if x != nil {
-		return x.ThreadId
-	}
-	return ""
-}
-
-func (x *CreateSessionRequest) GetWorkerId() string {
-	if x != nil {
-		return x.WorkerId
-	}
-	return ""
-}
-
-func (x *CreateSessionRequest) GetPrompt() string {
-	if x != nil {
-		return x.Prompt
-	}
-	return ""
+// Deprecated: Use SessionServiceListThreadsRequest.ProtoReflect.Descriptor instead.
+func (*SessionServiceListThreadsRequest) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{60}
 }
 
-func (x *CreateSessionRequest) GetAgent() string {
+func (x *SessionServiceListThreadsRequest) GetProjectId() string {
 	if x != nil {
-		return x.Agent
+		return x.ProjectId
 	}
 	return ""
 }
 
-func (x *CreateSessionRequest) GetModel() string {
+func (x *SessionServiceListThreadsRequest) GetOldestFirst() bool {
 	if x != nil {
-		return x.Model
+		return x.OldestFirst
 	}
-	return ""
+	return false
 }
 
-func (x *CreateSessionRequest) GetMode() string {
+func (x *SessionServiceListThreadsRequest) GetPageSize() int32 {
 	if x != nil {
-		return x.Mode
+		return x.PageSize
 	}
-	return ""
+	return 0
 }
 
-func (x *CreateSessionRequest) GetSessionMode() string {
+func (x *SessionServiceListThreadsRequest) GetPageToken() string {
 	if x != nil {
-		return x.SessionMode
+		return x.PageToken
 	}
 	return ""
 }
 
-type CreateSessionResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Session       *SessionConfig         `protobuf:"bytes,1,opt,name=session,proto3" json:"session,omitempty"`
+type SessionServiceListThreadsResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Threads []*ThreadSummary       `protobuf:"bytes,1,rep,name=threads,proto3" json:"threads,omitempty"`
+	// Passed as page_token to get the next page; empty on the last page.
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *CreateSessionResponse) Reset() {
-	*x = CreateSessionResponse{}
-	mi := &file_controlplane_v1_session_service_proto_msgTypes[22]
+func (x *SessionServiceListThreadsResponse) Reset() {
+	*x = SessionServiceListThreadsResponse{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[61]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *CreateSessionResponse) String() string {
+func (x *SessionServiceListThreadsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*CreateSessionResponse) ProtoMessage() {}
+func (*SessionServiceListThreadsResponse) ProtoMessage() {}
 
-func (x *CreateSessionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_controlplane_v1_session_service_proto_msgTypes[22]
+func (x *SessionServiceListThreadsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[61]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1621,41 +4550,54 @@ func (x *CreateSessionResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use CreateSessionResponse.ProtoReflect.Descriptor instead.
-func (*CreateSessionResponse) Descriptor() ([]byte, []int) {
-	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{22}
+// Deprecated: Use SessionServiceListThreadsResponse.ProtoReflect.Descriptor instead.
+func (*SessionServiceListThreadsResponse) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{61}
 }
 
-func (x *CreateSessionResponse) GetSession() *SessionConfig {
+func (x *SessionServiceListThreadsResponse) GetThreads() []*ThreadSummary {
 	if x != nil {
-		return x.Session
+		return x.Threads
 	}
 	return nil
 }
 
-type SendUserMessageRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ThreadId      string                 `protobuf:"bytes,1,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`
-	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+func (x *SessionServiceListThreadsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// ThreadSummary describes a thread by its most recent session.
+type ThreadSummary struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	ThreadId string                 `protobuf:"bytes,1,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`
+	Topic    string                 `protobuf:"bytes,2,opt,name=topic,proto3" json:"topic,omitempty"`
+	// Empty if no session was created in the thread yet.
+	LatestSessionId     string `protobuf:"bytes,3,opt,name=latest_session_id,json=latestSessionId,proto3" json:"latest_session_id,omitempty"`
+	LatestSessionStatus string `protobuf:"bytes,4,opt,name=latest_session_status,json=latestSessionStatus,proto3" json:"latest_session_status,omitempty"`
+	// The later of the thread's and its latest session's updated_at.
+	UpdatedAt     string `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SendUserMessageRequest) Reset() {
-	*x = SendUserMessageRequest{}
-	mi := &file_controlplane_v1_session_service_proto_msgTypes[23]
+func (x *ThreadSummary) Reset() {
+	*x = ThreadSummary{}
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[62]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SendUserMessageRequest) String() string {
+func (x *ThreadSummary) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SendUserMessageRequest) ProtoMessage() {}
+func (*ThreadSummary) ProtoMessage() {}
 
-func (x *SendUserMessageRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_controlplane_v1_session_service_proto_msgTypes[23]
+func (x *ThreadSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_controlplane_v1_session_service_proto_msgTypes[62]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1666,59 +4608,44 @@ func (x *SendUserMessageRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SendUserMessageRequest.ProtoReflect.Descriptor instead.
-func (*SendUserMessageRequest) Descriptor() ([]byte, []int) {
-	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{23}
+// Deprecated: Use ThreadSummary.ProtoReflect.Descriptor instead.
+func (*ThreadSummary) Descriptor() ([]byte, []int) {
+	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{62}
 }
 
-func (x *SendUserMessageRequest) GetThreadId() string {
+func (x *ThreadSummary) GetThreadId() string {
 	if x != nil {
 		return x.ThreadId
 	}
 	return ""
 }
 
-func (x *SendUserMessageRequest) GetText() string {
+func (x *ThreadSummary) GetTopic() string {
 	if x != nil {
-		return x.Text
+		return x.Topic
 	}
 	return ""
 }
 
-type SendUserMessageResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
-
-func (x *SendUserMessageResponse) Reset() {
-	*x = SendUserMessageResponse{}
-	mi := &file_controlplane_v1_session_service_proto_msgTypes[24]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
-}
-
-func (x *SendUserMessageResponse) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *ThreadSummary) GetLatestSessionId() string {
+	if x != nil {
+		return x.LatestSessionId
+	}
+	return ""
 }
 
-func (*SendUserMessageResponse) ProtoMessage() {}
-
-func (x *SendUserMessageResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_controlplane_v1_session_service_proto_msgTypes[24]
+func (x *ThreadSummary) GetLatestSessionStatus() string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.LatestSessionStatus
 	}
-	return mi.MessageOf(x)
+	return ""
 }
 
-// Deprecated: Use SendUserMessageResponse.ProtoReflect.Descriptor instead.
-func (*SendUserMessageResponse) Descriptor() ([]byte, []int) {
-	return file_controlplane_v1_session_service_proto_rawDescGZIP(), []int{24}
+func (x *ThreadSummary) GetUpdatedAt() string {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return ""
 }
 
 var File_controlplane_v1_session_service_proto protoreflect.FileDescriptor
@@ -1755,7 +4682,7 @@ const file_controlplane_v1_session_service_proto_rawDesc = "" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\tsessionId\x12 \n" +
 	"\amode_id\x18\x02 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\x06modeId\"\x18\n" +
-	"\x16SetSessionModeResponse\"\x84\x05\n" +
+	"\x16SetSessionModeResponse\"\xa8\v\n" +
 	"\fSessionEvent\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1a\n" +
@@ -1768,14 +4695,28 @@ const file_controlplane_v1_session_service_proto_rawDesc = "" +
 	"\x10tool_call_update\x18\r \x01(\v2\x1f.controlplane.v1.ToolCallUpdateH\x00R\x0etoolCallUpdate\x12D\n" +
 	"\rstatus_change\x18\x0e \x01(\v2\x1d.controlplane.v1.StatusChangeH\x00R\fstatusChange\x12T\n" +
 	"\x13current_mode_update\x18\x0f \x01(\v2\".controlplane.v1.CurrentModeUpdateH\x00R\x11currentModeUpdate\x12A\n" +
-	"\fuser_message\x18\x10 \x01(\v2\x1c.controlplane.v1.UserMessageH\x00R\vuserMessageB\t\n" +
+	"\fuser_message\x18\x10 \x01(\v2\x1c.controlplane.v1.UserMessageH\x00R\vuserMessage\x12W\n" +
+	"\x14current_model_update\x18\x11 \x01(\v2#.controlplane.v1.CurrentModelUpdateH\x00R\x12currentModelUpdate\x12D\n" +
+	"\rsession_error\x18\x12 \x01(\v2\x1d.controlplane.v1.SessionErrorH\x00R\fsessionError\x12S\n" +
+	"\x12permission_request\x18\x13 \x01(\v2\".controlplane.v1.PermissionRequestH\x00R\x11permissionRequest\x12V\n" +
+	"\x13permission_resolved\x18\x14 \x01(\v2#.controlplane.v1.PermissionResolvedH\x00R\x12permissionResolved\x12D\n" +
+	"\revents_pruned\x18\x15 \x01(\v2\x1d.controlplane.v1.EventsPrunedH\x00R\feventsPruned\x12G\n" +
+	"\x0eplan_submitted\x18\x16 \x01(\v2\x1e.controlplane.v1.PlanSubmittedH\x00R\rplanSubmitted\x12Q\n" +
+	"\x12mcp_server_startup\x18\x17 \x01(\v2!.controlplane.v1.McpServerStartupH\x00R\x10mcpServerStartup\x127\n" +
+	"\bprogress\x18\x18 \x01(\v2\x19.controlplane.v1.ProgressH\x00R\bprogress\x12;\n" +
+	"\n" +
+	"turn_ended\x18\x19 \x01(\v2\x1a.controlplane.v1.TurnEndedH\x00R\tturnEnded\x12;\n" +
+	"\n" +
+	"agent_plan\x18\x1a \x01(\v2\x1a.controlplane.v1.AgentPlanH\x00R\tagentPlan\x12A\n" +
+	"\fsession_init\x18\x1b \x01(\v2\x1c.controlplane.v1.SessionInitH\x00R\vsessionInitB\t\n" +
 	"\apayload\"'\n" +
 	"\x11AgentMessageChunk\x12\x12\n" +
 	"\x04text\x18\x01 \x01(\tR\x04text\"'\n" +
 	"\x11AgentThoughtChunk\x12\x12\n" +
-	"\x04text\x18\x01 \x01(\tR\x04text\"!\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\"Y\n" +
 	"\vUserMessage\x12\x12\n" +
-	"\x04text\x18\x01 \x01(\tR\x04text\"\xcd\x02\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x126\n" +
+	"\x17auto_continue_iteration\x18\x02 \x01(\x05R\x15autoContinueIteration\"\x81\x04\n" +
 	"\bToolCall\x12 \n" +
 	"\ftool_call_id\x18\x01 \x01(\tR\n" +
 	"toolCallId\x12\x14\n" +
@@ -1784,7 +4725,12 @@ const file_controlplane_v1_session_service_proto_rawDesc = "" +
 	"\traw_input\x18\x04 \x01(\tR\brawInput\x12?\n" +
 	"\tlocations\x18\x05 \x03(\v2!.controlplane.v1.ToolCallLocationR\tlocations\x127\n" +
 	"\x06status\x18\x06 \x01(\x0e2\x1f.controlplane.v1.ToolCallStatusR\x06status\x12?\n" +
-	"\acontent\x18\a \x03(\v2%.controlplane.v1.ToolCallContentBlockR\acontent\"\xa2\x02\n" +
+	"\acontent\x18\a \x03(\v2%.controlplane.v1.ToolCallContentBlockR\acontent\x120\n" +
+	"\x05input\x18\b \x01(\v2\x1a.controlplane.v1.ToolInputR\x05input\x12C\n" +
+	"\bmetadata\x18\t \x03(\v2'.controlplane.v1.ToolCall.MetadataEntryR\bmetadata\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xdc\x03\n" +
 	"\x0eToolCallUpdate\x12 \n" +
 	"\ftool_call_id\x18\x01 \x01(\tR\n" +
 	"toolCallId\x12\x14\n" +
@@ -1793,34 +4739,182 @@ const file_controlplane_v1_session_service_proto_rawDesc = "" +
 	"\n" +
 	"raw_output\x18\x04 \x01(\tR\trawOutput\x12?\n" +
 	"\tlocations\x18\x05 \x03(\v2!.controlplane.v1.ToolCallLocationR\tlocations\x12?\n" +
-	"\acontent\x18\x06 \x03(\v2%.controlplane.v1.ToolCallContentBlockR\acontent\"\x89\x01\n" +
+	"\acontent\x18\x06 \x03(\v2%.controlplane.v1.ToolCallContentBlockR\acontent\x120\n" +
+	"\x05input\x18\a \x01(\v2\x1a.controlplane.v1.ToolInputR\x05input\x12I\n" +
+	"\bmetadata\x18\b \x03(\v2-.controlplane.v1.ToolCallUpdate.MetadataEntryR\bmetadata\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xda\x01\n" +
 	"\x14ToolCallContentBlock\x123\n" +
 	"\x04diff\x18\x01 \x01(\v2\x1d.controlplane.v1.ToolCallDiffH\x00R\x04diff\x123\n" +
-	"\x04text\x18\x02 \x01(\v2\x1d.controlplane.v1.ToolCallTextH\x00R\x04textB\a\n" +
+	"\x04text\x18\x02 \x01(\v2\x1d.controlplane.v1.ToolCallTextH\x00R\x04text\x12O\n" +
+	"\x0ecommand_output\x18\x03 \x01(\v2&.controlplane.v1.ToolCallCommandOutputH\x00R\rcommandOutputB\a\n" +
 	"\x05block\"X\n" +
 	"\fToolCallDiff\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12\x19\n" +
 	"\bnew_text\x18\x02 \x01(\tR\anewText\x12\x19\n" +
 	"\bold_text\x18\x03 \x01(\tR\aoldText\"\"\n" +
 	"\fToolCallText\x12\x12\n" +
-	"\x04text\x18\x01 \x01(\tR\x04text\":\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\"w\n" +
+	"\x15ToolCallCommandOutput\x12\x16\n" +
+	"\x06stdout\x18\x01 \x01(\tR\x06stdout\x12\x16\n" +
+	"\x06stderr\x18\x02 \x01(\tR\x06stderr\x12 \n" +
+	"\texit_code\x18\x03 \x01(\x05H\x00R\bexitCode\x88\x01\x01B\f\n" +
+	"\n" +
+	"_exit_code\"\xda\x02\n" +
+	"\tToolInput\x124\n" +
+	"\x04read\x18\x01 \x01(\v2\x1e.controlplane.v1.ToolInputReadH\x00R\x04read\x127\n" +
+	"\x05write\x18\x02 \x01(\v2\x1f.controlplane.v1.ToolInputWriteH\x00R\x05write\x124\n" +
+	"\x04edit\x18\x03 \x01(\v2\x1e.controlplane.v1.ToolInputEditH\x00R\x04edit\x124\n" +
+	"\x04bash\x18\x04 \x01(\v2\x1e.controlplane.v1.ToolInputBashH\x00R\x04bash\x124\n" +
+	"\x04grep\x18\x05 \x01(\v2\x1e.controlplane.v1.ToolInputGrepH\x00R\x04grep\x124\n" +
+	"\x04glob\x18\x06 \x01(\v2\x1e.controlplane.v1.ToolInputGlobH\x00R\x04globB\x06\n" +
+	"\x04tool\"y\n" +
+	"\rToolInputRead\x12\x1b\n" +
+	"\tfile_path\x18\x01 \x01(\tR\bfilePath\x12\x1b\n" +
+	"\x06offset\x18\x02 \x01(\x03H\x00R\x06offset\x88\x01\x01\x12\x19\n" +
+	"\x05limit\x18\x03 \x01(\x03H\x01R\x05limit\x88\x01\x01B\t\n" +
+	"\a_offsetB\b\n" +
+	"\x06_limit\"G\n" +
+	"\x0eToolInputWrite\x12\x1b\n" +
+	"\tfile_path\x18\x01 \x01(\tR\bfilePath\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\"\x8b\x01\n" +
+	"\rToolInputEdit\x12\x1b\n" +
+	"\tfile_path\x18\x01 \x01(\tR\bfilePath\x12\x1d\n" +
+	"\n" +
+	"old_string\x18\x02 \x01(\tR\toldString\x12\x1d\n" +
+	"\n" +
+	"new_string\x18\x03 \x01(\tR\tnewString\x12\x1f\n" +
+	"\vreplace_all\x18\x04 \x01(\bR\n" +
+	"replaceAll\"\xaa\x01\n" +
+	"\rToolInputBash\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\"\n" +
+	"\n" +
+	"timeout_ms\x18\x03 \x01(\x03H\x00R\ttimeoutMs\x88\x01\x01\x12*\n" +
+	"\x11run_in_background\x18\x04 \x01(\bR\x0frunInBackgroundB\r\n" +
+	"\v_timeout_ms\"\xb1\x01\n" +
+	"\rToolInputGrep\x12\x18\n" +
+	"\apattern\x18\x01 \x01(\tR\apattern\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x12\n" +
+	"\x04glob\x18\x03 \x01(\tR\x04glob\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x1f\n" +
+	"\voutput_mode\x18\x05 \x01(\tR\n" +
+	"outputMode\x12)\n" +
+	"\x10case_insensitive\x18\x06 \x01(\bR\x0fcaseInsensitive\"=\n" +
+	"\rToolInputGlob\x12\x18\n" +
+	"\apattern\x18\x01 \x01(\tR\apattern\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\":\n" +
 	"\x10ToolCallLocation\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
-	"\x04line\x18\x02 \x01(\x03R\x04line\"&\n" +
+	"\x04line\x18\x02 \x01(\x03R\x04line\"\x84\x01\n" +
 	"\fStatusChange\x12\x16\n" +
-	"\x06status\x18\x01 \x01(\tR\x06status\",\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x123\n" +
+	"\x05error\x18\x02 \x01(\v2\x1d.controlplane.v1.SessionErrorR\x05error\x12'\n" +
+	"\x0faccepting_input\x18\x03 \x01(\bR\x0eacceptingInput\",\n" +
 	"\x11CurrentModeUpdate\x12\x17\n" +
-	"\amode_id\x18\x01 \x01(\tR\x06modeId\"\x97\x01\n" +
+	"\amode_id\x18\x01 \x01(\tR\x06modeId\"/\n" +
+	"\x12CurrentModelUpdate\x12\x19\n" +
+	"\bmodel_id\x18\x01 \x01(\tR\amodelId\"a\n" +
+	"\fSessionError\x12\x16\n" +
+	"\x06reason\x18\x01 \x01(\tR\x06reason\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1f\n" +
+	"\vstderr_tail\x18\x03 \x03(\tR\n" +
+	"stderrTail\"\xb8\x01\n" +
+	"\x11PermissionRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x121\n" +
+	"\x04kind\x18\x03 \x01(\x0e2\x1d.controlplane.v1.ToolCallKindR\x04kind\x12;\n" +
+	"\aoptions\x18\x04 \x03(\v2!.controlplane.v1.PermissionOptionR\aoptions\"W\n" +
+	"\x10PermissionOption\x12\x1b\n" +
+	"\toption_id\x18\x01 \x01(\tR\boptionId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04kind\x18\x03 \x01(\tR\x04kind\"j\n" +
+	"\x12PermissionResolved\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x1b\n" +
+	"\toption_id\x18\x02 \x01(\tR\boptionId\x12\x18\n" +
+	"\aoutcome\x18\x03 \x01(\tR\aoutcome\"$\n" +
+	"\fEventsPruned\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\"\x86\x01\n" +
+	"\x10McpServerStartup\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x14\n" +
+	"\x05ready\x18\x04 \x01(\bR\x05ready\x12\x12\n" +
+	"\x04text\x18\x05 \x01(\tR\x04text\"v\n" +
+	"\vSessionInit\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12'\n" +
+	"\x0fpermission_mode\x18\x02 \x01(\tR\x0epermissionMode\x12\x14\n" +
+	"\x05tools\x18\x03 \x03(\tR\x05tools\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\"O\n" +
+	"\bProgress\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1d\n" +
+	"\apercent\x18\x02 \x01(\x05H\x00R\apercent\x88\x01\x01B\n" +
+	"\n" +
+	"\b_percent\"\xbf\x01\n" +
+	"\tTurnEnded\x12<\n" +
+	"\vstop_reason\x18\x01 \x01(\x0e2\x1b.controlplane.v1.StopReasonR\n" +
+	"stopReason\x12B\n" +
+	"\rcancel_reason\x18\x02 \x01(\x0e2\x1d.controlplane.v1.CancelReasonR\fcancelReason\x120\n" +
+	"\x05stats\x18\x03 \x01(\v2\x1a.controlplane.v1.TurnStatsR\x05stats\"\xc7\x01\n" +
+	"\tTurnStats\x12\x1f\n" +
+	"\vduration_ms\x18\x01 \x01(\x03R\n" +
+	"durationMs\x122\n" +
+	"\x16time_to_first_token_ms\x18\x02 \x01(\x03R\x12timeToFirstTokenMs\x12\x1d\n" +
+	"\n" +
+	"tool_calls\x18\x03 \x01(\x05R\ttoolCalls\x12!\n" +
+	"\finput_tokens\x18\x04 \x01(\x03R\vinputTokens\x12#\n" +
+	"\routput_tokens\x18\x05 \x01(\x03R\foutputTokens\"F\n" +
+	"\tAgentPlan\x129\n" +
+	"\aentries\x18\x01 \x03(\v2\x1f.controlplane.v1.AgentPlanEntryR\aentries\"^\n" +
+	"\x0eAgentPlanEntry\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\tR\acontent\x12\x1a\n" +
+	"\bpriority\x18\x02 \x01(\tR\bpriority\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\"<\n" +
+	"\rPlanSubmitted\x12+\n" +
+	"\x05plans\x18\x01 \x03(\v2\x15.controlplane.v1.PlanR\x05plans\"~\n" +
+	"\x04Plan\x12\x1b\n" +
+	"\tthread_id\x18\x01 \x01(\tR\bthreadId\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x12\n" +
+	"\x04body\x18\x03 \x01(\tR\x04body\x12/\n" +
+	"\x05steps\x18\x04 \x03(\v2\x19.controlplane.v1.PlanStepR\x05steps\"\xa1\x01\n" +
+	"\bPlanStep\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04file\x18\x02 \x01(\tR\x04file\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1d\n" +
+	"\n" +
+	"depends_on\x18\x04 \x03(\tR\tdependsOn\x12\x14\n" +
+	"\x05agent\x18\x05 \x01(\tR\x05agent\x12\x1a\n" +
+	"\bsubtasks\x18\x06 \x03(\tR\bsubtasks\"\x97\x01\n" +
 	"\x19WatchSessionEventsRequest\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1b\n" +
 	"\tthread_id\x18\x02 \x01(\tR\bthreadId\x12\x17\n" +
 	"\atask_id\x18\x03 \x01(\tR\x06taskId\x12%\n" +
-	"\x0eafter_sequence\x18\x04 \x01(\x03R\rafterSequence\"p\n" +
+	"\x0eafter_sequence\x18\x04 \x01(\x03R\rafterSequence\"\xaa\x01\n" +
 	"\x1aWatchSessionEventsResponse\x123\n" +
 	"\x05event\x18\x01 \x01(\v2\x1d.controlplane.v1.SessionEventR\x05event\x12\x1d\n" +
 	"\n" +
-	"is_history\x18\x02 \x01(\bR\tisHistory\"\xcb\x01\n" +
+	"is_history\x18\x02 \x01(\bR\tisHistory\x128\n" +
+	"\theartbeat\x18\x03 \x01(\v2\x1a.controlplane.v1.HeartbeatR\theartbeat\")\n" +
+	"\tHeartbeat\x12\x1c\n" +
+	"\ttimestamp\x18\x01 \x01(\tR\ttimestamp\";\n" +
+	"\x1cWatchSessionLifecycleRequest\x12\x1b\n" +
+	"\tthread_id\x18\x01 \x01(\tR\bthreadId\"\x97\x01\n" +
+	"\x1dWatchSessionLifecycleResponse\x12<\n" +
+	"\x05event\x18\x01 \x01(\v2&.controlplane.v1.SessionLifecycleEventR\x05event\x128\n" +
+	"\theartbeat\x18\x02 \x01(\v2\x1a.controlplane.v1.HeartbeatR\theartbeat\"\xe3\x01\n" +
+	"\x15SessionLifecycleEvent\x12\x1d\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1b\n" +
+	"\tthread_id\x18\x02 \x01(\tR\bthreadId\x129\n" +
+	"\x04kind\x18\x03 \x01(\x0e2%.controlplane.v1.SessionLifecycleKindR\x04kind\x12\x16\n" +
+	"\x06status\x18\x04 \x01(\tR\x06status\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\tR\tcreatedAt\x12\x1c\n" +
+	"\ttimestamp\x18\x06 \x01(\tR\ttimestamp\"\xf4\x01\n" +
 	"\x14CreateSessionRequest\x12\x1b\n" +
 	"\tthread_id\x18\x01 \x01(\tR\bthreadId\x12\x1b\n" +
 	"\tworker_id\x18\x02 \x01(\tR\bworkerId\x12\x16\n" +
@@ -1828,13 +4922,59 @@ const file_controlplane_v1_session_service_proto_rawDesc = "" +
 	"\x05agent\x18\x04 \x01(\tR\x05agent\x12\x14\n" +
 	"\x05model\x18\x05 \x01(\tR\x05model\x12\x12\n" +
 	"\x04mode\x18\x06 \x01(\tR\x04mode\x12!\n" +
-	"\fsession_mode\x18\a \x01(\tR\vsessionMode\"Q\n" +
+	"\fsession_mode\x18\a \x01(\tR\vsessionMode\x12'\n" +
+	"\x0fidempotency_key\x18\b \x01(\tR\x0eidempotencyKey\"Q\n" +
 	"\x15CreateSessionResponse\x128\n" +
-	"\asession\x18\x01 \x01(\v2\x1e.controlplane.v1.SessionConfigR\asession\"[\n" +
+	"\asession\x18\x01 \x01(\v2\x1e.controlplane.v1.SessionConfigR\asession\"\x9e\x01\n" +
 	"\x16SendUserMessageRequest\x12$\n" +
-	"\tthread_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\bthreadId\x12\x1b\n" +
-	"\x04text\x18\x02 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\x04text\"\x19\n" +
-	"\x17SendUserMessageResponse*\x91\x01\n" +
+	"\tthread_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\bthreadId\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12J\n" +
+	"\x0econtent_blocks\x18\x03 \x03(\v2#.controlplane.v1.PromptContentBlockR\rcontentBlocks\"\x19\n" +
+	"\x17SendUserMessageResponse\"\x7f\n" +
+	"\x12PromptContentBlock\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x1b\n" +
+	"\tmime_type\x18\x03 \x01(\tR\bmimeType\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\tR\x04data\x12\x10\n" +
+	"\x03uri\x18\x05 \x01(\tR\x03uri\"\xc8\x01\n" +
+	"\x11SendPromptRequest\x12$\n" +
+	"\tthread_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\bthreadId\x12T\n" +
+	"\x0econtent_blocks\x18\x02 \x03(\v2#.controlplane.v1.PromptContentBlockB\b\xbaH\x05\x92\x01\x02\b\x01R\rcontentBlocks\x12\x14\n" +
+	"\x05model\x18\x03 \x01(\tR\x05model\x12!\n" +
+	"\fsession_mode\x18\x04 \x01(\tR\vsessionMode\"5\n" +
+	"\x12SendPromptResponse\x12\x1f\n" +
+	"\vstop_reason\x18\x01 \x01(\tR\n" +
+	"stopReason\"\x7f\n" +
+	"\x14ExportSessionRequest\x12&\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\tsessionId\x12?\n" +
+	"\x06format\x18\x02 \x01(\x0e2\x1d.controlplane.v1.ExportFormatB\b\xbaH\x05\x82\x01\x02\x10\x01R\x06format\"T\n" +
+	"\x15ExportSessionResponse\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\tR\acontent\x12!\n" +
+	"\fcontent_type\x18\x02 \x01(\tR\vcontentType\"8\n" +
+	"\x0eGetPlanRequest\x12&\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\tsessionId\"a\n" +
+	"\x0fGetPlanResponse\x12+\n" +
+	"\x05plans\x18\x01 \x03(\v2\x15.controlplane.v1.PlanR\x05plans\x12!\n" +
+	"\fsubmitted_at\x18\x02 \x01(\tR\vsubmittedAt\"\xa0\x01\n" +
+	" SessionServiceListThreadsRequest\x12\x1d\n" +
+	"\n" +
+	"project_id\x18\x01 \x01(\tR\tprojectId\x12!\n" +
+	"\foldest_first\x18\x02 \x01(\bR\voldestFirst\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\"\x85\x01\n" +
+	"!SessionServiceListThreadsResponse\x128\n" +
+	"\athreads\x18\x01 \x03(\v2\x1e.controlplane.v1.ThreadSummaryR\athreads\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"\xc1\x01\n" +
+	"\rThreadSummary\x12\x1b\n" +
+	"\tthread_id\x18\x01 \x01(\tR\bthreadId\x12\x14\n" +
+	"\x05topic\x18\x02 \x01(\tR\x05topic\x12*\n" +
+	"\x11latest_session_id\x18\x03 \x01(\tR\x0flatestSessionId\x122\n" +
+	"\x15latest_session_status\x18\x04 \x01(\tR\x13latestSessionStatus\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\tR\tupdatedAt*\x91\x01\n" +
 	"\x0eToolCallStatus\x12 \n" +
 	"\x1cTOOL_CALL_STATUS_UNSPECIFIED\x10\x00\x12 \n" +
 	"\x1cTOOL_CALL_STATUS_IN_PROGRESS\x10\x01\x12\x1e\n" +
@@ -1850,7 +4990,30 @@ const file_controlplane_v1_session_service_proto_rawDesc = "" +
 	"\x16TOOL_CALL_KIND_EXECUTE\x10\x06\x12\x18\n" +
 	"\x14TOOL_CALL_KIND_THINK\x10\a\x12\x18\n" +
 	"\x14TOOL_CALL_KIND_FETCH\x10\b\x12\x18\n" +
-	"\x14TOOL_CALL_KIND_OTHER\x10\t2\xea\x04\n" +
+	"\x14TOOL_CALL_KIND_OTHER\x10\t*\xb6\x01\n" +
+	"\n" +
+	"StopReason\x12\x1b\n" +
+	"\x17STOP_REASON_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14STOP_REASON_END_TURN\x10\x01\x12\x1a\n" +
+	"\x16STOP_REASON_MAX_TOKENS\x10\x02\x12!\n" +
+	"\x1dSTOP_REASON_MAX_TURN_REQUESTS\x10\x03\x12\x17\n" +
+	"\x13STOP_REASON_REFUSAL\x10\x04\x12\x19\n" +
+	"\x15STOP_REASON_CANCELLED\x10\x05*y\n" +
+	"\fCancelReason\x12\x1d\n" +
+	"\x19CANCEL_REASON_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12CANCEL_REASON_USER\x10\x01\x12\x19\n" +
+	"\x15CANCEL_REASON_TIMEOUT\x10\x02\x12\x17\n" +
+	"\x13CANCEL_REASON_LIMIT\x10\x03*\xcf\x01\n" +
+	"\x14SessionLifecycleKind\x12&\n" +
+	"\"SESSION_LIFECYCLE_KIND_UNSPECIFIED\x10\x00\x12\"\n" +
+	"\x1eSESSION_LIFECYCLE_KIND_CREATED\x10\x01\x12#\n" +
+	"\x1fSESSION_LIFECYCLE_KIND_LAUNCHED\x10\x02\x12\"\n" +
+	"\x1eSESSION_LIFECYCLE_KIND_STOPPED\x10\x03\x12\"\n" +
+	"\x1eSESSION_LIFECYCLE_KIND_ERRORED\x10\x04*a\n" +
+	"\fExportFormat\x12\x1d\n" +
+	"\x19EXPORT_FORMAT_UNSPECIFIED\x10\x00\x12\x1a\n" +
+	"\x16EXPORT_FORMAT_MARKDOWN\x10\x01\x12\x16\n" +
+	"\x12EXPORT_FORMAT_JSON\x10\x022\xe9\b\n" +
 	"\x0eSessionService\x12`\n" +
 	"\rCreateSession\x12%.controlplane.v1.CreateSessionRequest\x1a&.controlplane.v1.CreateSessionResponse\"\x00\x12W\n" +
 	"\n" +
@@ -1858,7 +5021,13 @@ const file_controlplane_v1_session_service_proto_rawDesc = "" +
 	"\fListSessions\x12$.controlplane.v1.ListSessionsRequest\x1a%.controlplane.v1.ListSessionsResponse\"\x00\x12c\n" +
 	"\x0eSetSessionMode\x12&.controlplane.v1.SetSessionModeRequest\x1a'.controlplane.v1.SetSessionModeResponse\"\x00\x12q\n" +
 	"\x12WatchSessionEvents\x12*.controlplane.v1.WatchSessionEventsRequest\x1a+.controlplane.v1.WatchSessionEventsResponse\"\x000\x01\x12f\n" +
-	"\x0fSendUserMessage\x12'.controlplane.v1.SendUserMessageRequest\x1a(.controlplane.v1.SendUserMessageResponse\"\x00B\xdb\x01\n" +
+	"\x0fSendUserMessage\x12'.controlplane.v1.SendUserMessageRequest\x1a(.controlplane.v1.SendUserMessageResponse\"\x00\x12W\n" +
+	"\n" +
+	"SendPrompt\x12\".controlplane.v1.SendPromptRequest\x1a#.controlplane.v1.SendPromptResponse\"\x00\x12`\n" +
+	"\rExportSession\x12%.controlplane.v1.ExportSessionRequest\x1a&.controlplane.v1.ExportSessionResponse\"\x00\x12N\n" +
+	"\aGetPlan\x12\x1f.controlplane.v1.GetPlanRequest\x1a .controlplane.v1.GetPlanResponse\"\x00\x12z\n" +
+	"\x15WatchSessionLifecycle\x12-.controlplane.v1.WatchSessionLifecycleRequest\x1a..controlplane.v1.WatchSessionLifecycleResponse\"\x000\x01\x12v\n" +
+	"\vListThreads\x121.controlplane.v1.SessionServiceListThreadsRequest\x1a2.controlplane.v1.SessionServiceListThreadsResponse\"\x00B\xdb\x01\n" +
 	"\x13com.controlplane.v1B\x13SessionServiceProtoP\x01ZRgithub.com/sebastianm/flowgentic/internal/proto/gen/controlplane/v1;controlplanev1\xa2\x02\x03CXX\xaa\x02\x0fControlplane.V1\xca\x02\x0fControlplane\\V1\xe2\x02\x1bControlplane\\V1\\GPBMetadata\xea\x02\x10Controlplane::V1b\x06proto3"
 
 var (
@@ -1873,75 +5042,169 @@ func file_controlplane_v1_session_service_proto_rawDescGZIP() []byte {
 	return file_controlplane_v1_session_service_proto_rawDescData
 }
 
-var file_controlplane_v1_session_service_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_controlplane_v1_session_service_proto_msgTypes = make([]protoimpl.MessageInfo, 25)
+var file_controlplane_v1_session_service_proto_enumTypes = make([]protoimpl.EnumInfo, 6)
+var file_controlplane_v1_session_service_proto_msgTypes = make([]protoimpl.MessageInfo, 65)
 var file_controlplane_v1_session_service_proto_goTypes = []any{
-	(ToolCallStatus)(0),                // 0: controlplane.v1.ToolCallStatus
-	(ToolCallKind)(0),                  // 1: controlplane.v1.ToolCallKind
-	(*SessionConfig)(nil),              // 2: controlplane.v1.SessionConfig
-	(*GetSessionRequest)(nil),          // 3: controlplane.v1.GetSessionRequest
-	(*GetSessionResponse)(nil),         // 4: controlplane.v1.GetSessionResponse
-	(*ListSessionsRequest)(nil),        // 5: controlplane.v1.ListSessionsRequest
-	(*ListSessionsResponse)(nil),       // 6: controlplane.v1.ListSessionsResponse
-	(*SetSessionModeRequest)(nil),      // 7: controlplane.v1.SetSessionModeRequest
-	(*SetSessionModeResponse)(nil),     // 8: controlplane.v1.SetSessionModeResponse
-	(*SessionEvent)(nil),               // 9: controlplane.v1.SessionEvent
-	(*AgentMessageChunk)(nil),          // 10: controlplane.v1.AgentMessageChunk
-	(*AgentThoughtChunk)(nil),          // 11: controlplane.v1.AgentThoughtChunk
-	(*UserMessage)(nil),                // 12: controlplane.v1.UserMessage
-	(*ToolCall)(nil),                   // 13: controlplane.v1.ToolCall
-	(*ToolCallUpdate)(nil),             // 14: controlplane.v1.ToolCallUpdate
-	(*ToolCallContentBlock)(nil),       // 15: controlplane.v1.ToolCallContentBlock
-	(*ToolCallDiff)(nil),               // 16: controlplane.v1.ToolCallDiff
-	(*ToolCallText)(nil),               // 17: controlplane.v1.ToolCallText
-	(*ToolCallLocation)(nil),           // 18: controlplane.v1.ToolCallLocation
-	(*StatusChange)(nil),               // 19: controlplane.v1.StatusChange
-	(*CurrentModeUpdate)(nil),          // 20: controlplane.v1.CurrentModeUpdate
-	(*WatchSessionEventsRequest)(nil),  // 21: controlplane.v1.WatchSessionEventsRequest
-	(*WatchSessionEventsResponse)(nil), // 22: controlplane.v1.WatchSessionEventsResponse
-	(*CreateSessionRequest)(nil),       // 23: controlplane.v1.CreateSessionRequest
-	(*CreateSessionResponse)(nil),      // 24: controlplane.v1.CreateSessionResponse
-	(*SendUserMessageRequest)(nil),     // 25: controlplane.v1.SendUserMessageRequest
-	(*SendUserMessageResponse)(nil),    // 26: controlplane.v1.SendUserMessageResponse
+	(ToolCallStatus)(0),                       // 0: controlplane.v1.ToolCallStatus
+	(ToolCallKind)(0),                         // 1: controlplane.v1.ToolCallKind
+	(StopReason)(0),                           // 2: controlplane.v1.StopReason
+	(CancelReason)(0),                         // 3: controlplane.v1.CancelReason
+	(SessionLifecycleKind)(0),                 // 4: controlplane.v1.SessionLifecycleKind
+	(ExportFormat)(0),                         // 5: controlplane.v1.ExportFormat
+	(*SessionConfig)(nil),                     // 6: controlplane.v1.SessionConfig
+	(*GetSessionRequest)(nil),                 // 7: controlplane.v1.GetSessionRequest
+	(*GetSessionResponse)(nil),                // 8: controlplane.v1.GetSessionResponse
+	(*ListSessionsRequest)(nil),               // 9: controlplane.v1.ListSessionsRequest
+	(*ListSessionsResponse)(nil),              // 10: controlplane.v1.ListSessionsResponse
+	(*SetSessionModeRequest)(nil),             // 11: controlplane.v1.SetSessionModeRequest
+	(*SetSessionModeResponse)(nil),            // 12: controlplane.v1.SetSessionModeResponse
+	(*SessionEvent)(nil),                      // 13: controlplane.v1.SessionEvent
+	(*AgentMessageChunk)(nil),                 // 14: controlplane.v1.AgentMessageChunk
+	(*AgentThoughtChunk)(nil),                 // 15: controlplane.v1.AgentThoughtChunk
+	(*UserMessage)(nil),                       // 16: controlplane.v1.UserMessage
+	(*ToolCall)(nil),                          // 17: controlplane.v1.ToolCall
+	(*ToolCallUpdate)(nil),                    // 18: controlplane.v1.ToolCallUpdate
+	(*ToolCallContentBlock)(nil),              // 19: controlplane.v1.ToolCallContentBlock
+	(*ToolCallDiff)(nil),                      // 20: controlplane.v1.ToolCallDiff
+	(*ToolCallText)(nil),                      // 21: controlplane.v1.ToolCallText
+	(*ToolCallCommandOutput)(nil),             // 22: controlplane.v1.ToolCallCommandOutput
+	(*ToolInput)(nil),                         // 23: controlplane.v1.ToolInput
+	(*ToolInputRead)(nil),                     // 24: controlplane.v1.ToolInputRead
+	(*ToolInputWrite)(nil),                    // 25: controlplane.v1.ToolInputWrite
+	(*ToolInputEdit)(nil),                     // 26: controlplane.v1.ToolInputEdit
+	(*ToolInputBash)(nil),                     // 27: controlplane.v1.ToolInputBash
+	(*ToolInputGrep)(nil),                     // 28: controlplane.v1.ToolInputGrep
+	(*ToolInputGlob)(nil),                     // 29: controlplane.v1.ToolInputGlob
+	(*ToolCallLocation)(nil),                  // 30: controlplane.v1.ToolCallLocation
+	(*StatusChange)(nil),                      // 31: controlplane.v1.StatusChange
+	(*CurrentModeUpdate)(nil),                 // 32: controlplane.v1.CurrentModeUpdate
+	(*CurrentModelUpdate)(nil),                // 33: controlplane.v1.CurrentModelUpdate
+	(*SessionError)(nil),                      // 34: controlplane.v1.SessionError
+	(*PermissionRequest)(nil),                 // 35: controlplane.v1.PermissionRequest
+	(*PermissionOption)(nil),                  // 36: controlplane.v1.PermissionOption
+	(*PermissionResolved)(nil),                // 37: controlplane.v1.PermissionResolved
+	(*EventsPruned)(nil),                      // 38: controlplane.v1.EventsPruned
+	(*McpServerStartup)(nil),                  // 39: controlplane.v1.McpServerStartup
+	(*SessionInit)(nil),                       // 40: controlplane.v1.SessionInit
+	(*Progress)(nil),                          // 41: controlplane.v1.Progress
+	(*TurnEnded)(nil),                         // 42: controlplane.v1.TurnEnded
+	(*TurnStats)(nil),                         // 43: controlplane.v1.TurnStats
+	(*AgentPlan)(nil),                         // 44: controlplane.v1.AgentPlan
+	(*AgentPlanEntry)(nil),                    // 45: controlplane.v1.AgentPlanEntry
+	(*PlanSubmitted)(nil),                     // 46: controlplane.v1.PlanSubmitted
+	(*Plan)(nil),                              // 47: controlplane.v1.Plan
+	(*PlanStep)(nil),                          // 48: controlplane.v1.PlanStep
+	(*WatchSessionEventsRequest)(nil),         // 49: controlplane.v1.WatchSessionEventsRequest
+	(*WatchSessionEventsResponse)(nil),        // 50: controlplane.v1.WatchSessionEventsResponse
+	(*Heartbeat)(nil),                         // 51: controlplane.v1.Heartbeat
+	(*WatchSessionLifecycleRequest)(nil),      // 52: controlplane.v1.WatchSessionLifecycleRequest
+	(*WatchSessionLifecycleResponse)(nil),     // 53: controlplane.v1.WatchSessionLifecycleResponse
+	(*SessionLifecycleEvent)(nil),             // 54: controlplane.v1.SessionLifecycleEvent
+	(*CreateSessionRequest)(nil),              // 55: controlplane.v1.CreateSessionRequest
+	(*CreateSessionResponse)(nil),             // 56: controlplane.v1.CreateSessionResponse
+	(*SendUserMessageRequest)(nil),            // 57: controlplane.v1.SendUserMessageRequest
+	(*SendUserMessageResponse)(nil),           // 58: controlplane.v1.SendUserMessageResponse
+	(*PromptContentBlock)(nil),                // 59: controlplane.v1.PromptContentBlock
+	(*SendPromptRequest)(nil),                 // 60: controlplane.v1.SendPromptRequest
+	(*SendPromptResponse)(nil),                // 61: controlplane.v1.SendPromptResponse
+	(*ExportSessionRequest)(nil),              // 62: controlplane.v1.ExportSessionRequest
+	(*ExportSessionResponse)(nil),             // 63: controlplane.v1.ExportSessionResponse
+	(*GetPlanRequest)(nil),                    // 64: controlplane.v1.GetPlanRequest
+	(*GetPlanResponse)(nil),                   // 65: controlplane.v1.GetPlanResponse
+	(*SessionServiceListThreadsRequest)(nil),  // 66: controlplane.v1.SessionServiceListThreadsRequest
+	(*SessionServiceListThreadsResponse)(nil), // 67: controlplane.v1.SessionServiceListThreadsResponse
+	(*ThreadSummary)(nil),                     // 68: controlplane.v1.ThreadSummary
+	nil,                                       // 69: controlplane.v1.ToolCall.MetadataEntry
+	nil,                                       // 70: controlplane.v1.ToolCallUpdate.MetadataEntry
 }
 var file_controlplane_v1_session_service_proto_depIdxs = []int32{
-	2,  // 0: controlplane.v1.GetSessionResponse.session:type_name -> controlplane.v1.SessionConfig
-	2,  // 1: controlplane.v1.ListSessionsResponse.sessions:type_name -> controlplane.v1.SessionConfig
-	10, // 2: controlplane.v1.SessionEvent.agent_message_chunk:type_name -> controlplane.v1.AgentMessageChunk
-	11, // 3: controlplane.v1.SessionEvent.agent_thought_chunk:type_name -> controlplane.v1.AgentThoughtChunk
-	13, // 4: controlplane.v1.SessionEvent.tool_call:type_name -> controlplane.v1.ToolCall
-	14, // 5: controlplane.v1.SessionEvent.tool_call_update:type_name -> controlplane.v1.ToolCallUpdate
-	19, // 6: controlplane.v1.SessionEvent.status_change:type_name -> controlplane.v1.StatusChange
-	20, // 7: controlplane.v1.SessionEvent.current_mode_update:type_name -> controlplane.v1.CurrentModeUpdate
-	12, // 8: controlplane.v1.SessionEvent.user_message:type_name -> controlplane.v1.UserMessage
-	1,  // 9: controlplane.v1.ToolCall.kind:type_name -> controlplane.v1.ToolCallKind
-	18, // 10: controlplane.v1.ToolCall.locations:type_name -> controlplane.v1.ToolCallLocation
-	0,  // 11: controlplane.v1.ToolCall.status:type_name -> controlplane.v1.ToolCallStatus
-	15, // 12: controlplane.v1.ToolCall.content:type_name -> controlplane.v1.ToolCallContentBlock
-	0,  // 13: controlplane.v1.ToolCallUpdate.status:type_name -> controlplane.v1.ToolCallStatus
-	18, // 14: controlplane.v1.ToolCallUpdate.locations:type_name -> controlplane.v1.ToolCallLocation
-	15, // 15: controlplane.v1.ToolCallUpdate.content:type_name -> controlplane.v1.ToolCallContentBlock
-	16, // 16: controlplane.v1.ToolCallContentBlock.diff:type_name -> controlplane.v1.ToolCallDiff
-	17, // 17: controlplane.v1.ToolCallContentBlock.text:type_name -> controlplane.v1.ToolCallText
-	9,  // 18: controlplane.v1.WatchSessionEventsResponse.event:type_name -> controlplane.v1.SessionEvent
-	2,  // 19: controlplane.v1.CreateSessionResponse.session:type_name -> controlplane.v1.SessionConfig
-	23, // 20: controlplane.v1.SessionService.CreateSession:input_type -> controlplane.v1.CreateSessionRequest
-	3,  // 21: controlplane.v1.SessionService.GetSession:input_type -> controlplane.v1.GetSessionRequest
-	5,  // 22: controlplane.v1.SessionService.ListSessions:input_type -> controlplane.v1.ListSessionsRequest
-	7,  // 23: controlplane.v1.SessionService.SetSessionMode:input_type -> controlplane.v1.SetSessionModeRequest
-	21, // 24: controlplane.v1.SessionService.WatchSessionEvents:input_type -> controlplane.v1.WatchSessionEventsRequest
-	25, // 25: controlplane.v1.SessionService.SendUserMessage:input_type -> controlplane.v1.SendUserMessageRequest
-	24, // 26: controlplane.v1.SessionService.CreateSession:output_type -> controlplane.v1.CreateSessionResponse
-	4,  // 27: controlplane.v1.SessionService.GetSession:output_type -> controlplane.v1.GetSessionResponse
-	6,  // 28: controlplane.v1.SessionService.ListSessions:output_type -> controlplane.v1.ListSessionsResponse
-	8,  // 29: controlplane.v1.SessionService.SetSessionMode:output_type -> controlplane.v1.SetSessionModeResponse
-	22, // 30: controlplane.v1.SessionService.WatchSessionEvents:output_type -> controlplane.v1.WatchSessionEventsResponse
-	26, // 31: controlplane.v1.SessionService.SendUserMessage:output_type -> controlplane.v1.SendUserMessageResponse
-	26, // [26:32] is the sub-list for method output_type
-	20, // [20:26] is the sub-list for method input_type
-	20, // [20:20] is the sub-list for extension type_name
-	20, // [20:20] is the sub-list for extension extendee
-	0,  // [0:20] is the sub-list for field type_name
+	6,  // 0: controlplane.v1.GetSessionResponse.session:type_name -> controlplane.v1.SessionConfig
+	6,  // 1: controlplane.v1.ListSessionsResponse.sessions:type_name -> controlplane.v1.SessionConfig
+	14, // 2: controlplane.v1.SessionEvent.agent_message_chunk:type_name -> controlplane.v1.AgentMessageChunk
+	15, // 3: controlplane.v1.SessionEvent.agent_thought_chunk:type_name -> controlplane.v1.AgentThoughtChunk
+	17, // 4: controlplane.v1.SessionEvent.tool_call:type_name -> controlplane.v1.ToolCall
+	18, // 5: controlplane.v1.SessionEvent.tool_call_update:type_name -> controlplane.v1.ToolCallUpdate
+	31, // 6: controlplane.v1.SessionEvent.status_change:type_name -> controlplane.v1.StatusChange
+	32, // 7: controlplane.v1.SessionEvent.current_mode_update:type_name -> controlplane.v1.CurrentModeUpdate
+	16, // 8: controlplane.v1.SessionEvent.user_message:type_name -> controlplane.v1.UserMessage
+	33, // 9: controlplane.v1.SessionEvent.current_model_update:type_name -> controlplane.v1.CurrentModelUpdate
+	34, // 10: controlplane.v1.SessionEvent.session_error:type_name -> controlplane.v1.SessionError
+	35, // 11: controlplane.v1.SessionEvent.permission_request:type_name -> controlplane.v1.PermissionRequest
+	37, // 12: controlplane.v1.SessionEvent.permission_resolved:type_name -> controlplane.v1.PermissionResolved
+	38, // 13: controlplane.v1.SessionEvent.events_pruned:type_name -> controlplane.v1.EventsPruned
+	46, // 14: controlplane.v1.SessionEvent.plan_submitted:type_name -> controlplane.v1.PlanSubmitted
+	39, // 15: controlplane.v1.SessionEvent.mcp_server_startup:type_name -> controlplane.v1.McpServerStartup
+	41, // 16: controlplane.v1.SessionEvent.progress:type_name -> controlplane.v1.Progress
+	42, // 17: controlplane.v1.SessionEvent.turn_ended:type_name -> controlplane.v1.TurnEnded
+	44, // 18: controlplane.v1.SessionEvent.agent_plan:type_name -> controlplane.v1.AgentPlan
+	40, // 19: controlplane.v1.SessionEvent.session_init:type_name -> controlplane.v1.SessionInit
+	1,  // 20: controlplane.v1.ToolCall.kind:type_name -> controlplane.v1.ToolCallKind
+	30, // 21: controlplane.v1.ToolCall.locations:type_name -> controlplane.v1.ToolCallLocation
+	0,  // 22: controlplane.v1.ToolCall.status:type_name -> controlplane.v1.ToolCallStatus
+	19, // 23: controlplane.v1.ToolCall.content:type_name -> controlplane.v1.ToolCallContentBlock
+	23, // 24: controlplane.v1.ToolCall.input:type_name -> controlplane.v1.ToolInput
+	69, // 25: controlplane.v1.ToolCall.metadata:type_name -> controlplane.v1.ToolCall.MetadataEntry
+	0,  // 26: controlplane.v1.ToolCallUpdate.status:type_name -> controlplane.v1.ToolCallStatus
+	30, // 27: controlplane.v1.ToolCallUpdate.locations:type_name -> controlplane.v1.ToolCallLocation
+	19, // 28: controlplane.v1.ToolCallUpdate.content:type_name -> controlplane.v1.ToolCallContentBlock
+	23, // 29: controlplane.v1.ToolCallUpdate.input:type_name -> controlplane.v1.ToolInput
+	70, // 30: controlplane.v1.ToolCallUpdate.metadata:type_name -> controlplane.v1.ToolCallUpdate.MetadataEntry
+	20, // 31: controlplane.v1.ToolCallContentBlock.diff:type_name -> controlplane.v1.ToolCallDiff
+	21, // 32: controlplane.v1.ToolCallContentBlock.text:type_name -> controlplane.v1.ToolCallText
+	22, // 33: controlplane.v1.ToolCallContentBlock.command_output:type_name -> controlplane.v1.ToolCallCommandOutput
+	24, // 34: controlplane.v1.ToolInput.read:type_name -> controlplane.v1.ToolInputRead
+	25, // 35: controlplane.v1.ToolInput.write:type_name -> controlplane.v1.ToolInputWrite
+	26, // 36: controlplane.v1.ToolInput.edit:type_name -> controlplane.v1.ToolInputEdit
+	27, // 37: controlplane.v1.ToolInput.bash:type_name -> controlplane.v1.ToolInputBash
+	28, // 38: controlplane.v1.ToolInput.grep:type_name -> controlplane.v1.ToolInputGrep
+	29, // 39: controlplane.v1.ToolInput.glob:type_name -> controlplane.v1.ToolInputGlob
+	34, // 40: controlplane.v1.StatusChange.error:type_name -> controlplane.v1.SessionError
+	1,  // 41: controlplane.v1.PermissionRequest.kind:type_name -> controlplane.v1.ToolCallKind
+	36, // 42: controlplane.v1.PermissionRequest.options:type_name -> controlplane.v1.PermissionOption
+	2,  // 43: controlplane.v1.TurnEnded.stop_reason:type_name -> controlplane.v1.StopReason
+	3,  // 44: controlplane.v1.TurnEnded.cancel_reason:type_name -> controlplane.v1.CancelReason
+	43, // 45: controlplane.v1.TurnEnded.stats:type_name -> controlplane.v1.TurnStats
+	45, // 46: controlplane.v1.AgentPlan.entries:type_name -> controlplane.v1.AgentPlanEntry
+	47, // 47: controlplane.v1.PlanSubmitted.plans:type_name -> controlplane.v1.Plan
+	48, // 48: controlplane.v1.Plan.steps:type_name -> controlplane.v1.PlanStep
+	13, // 49: controlplane.v1.WatchSessionEventsResponse.event:type_name -> controlplane.v1.SessionEvent
+	51, // 50: controlplane.v1.WatchSessionEventsResponse.heartbeat:type_name -> controlplane.v1.Heartbeat
+	54, // 51: controlplane.v1.WatchSessionLifecycleResponse.event:type_name -> controlplane.v1.SessionLifecycleEvent
+	51, // 52: controlplane.v1.WatchSessionLifecycleResponse.heartbeat:type_name -> controlplane.v1.Heartbeat
+	4,  // 53: controlplane.v1.SessionLifecycleEvent.kind:type_name -> controlplane.v1.SessionLifecycleKind
+	6,  // 54: controlplane.v1.CreateSessionResponse.session:type_name -> controlplane.v1.SessionConfig
+	59, // 55: controlplane.v1.SendUserMessageRequest.content_blocks:type_name -> controlplane.v1.PromptContentBlock
+	59, // 56: controlplane.v1.SendPromptRequest.content_blocks:type_name -> controlplane.v1.PromptContentBlock
+	5,  // 57: controlplane.v1.ExportSessionRequest.format:type_name -> controlplane.v1.ExportFormat
+	47, // 58: controlplane.v1.GetPlanResponse.plans:type_name -> controlplane.v1.Plan
+	68, // 59: controlplane.v1.SessionServiceListThreadsResponse.threads:type_name -> controlplane.v1.ThreadSummary
+	55, // 60: controlplane.v1.SessionService.CreateSession:input_type -> controlplane.v1.CreateSessionRequest
+	7,  // 61: controlplane.v1.SessionService.GetSession:input_type -> controlplane.v1.GetSessionRequest
+	9,  // 62: controlplane.v1.SessionService.ListSessions:input_type -> controlplane.v1.ListSessionsRequest
+	11, // 63: controlplane.v1.SessionService.SetSessionMode:input_type -> controlplane.v1.SetSessionModeRequest
+	49, // 64: controlplane.v1.SessionService.WatchSessionEvents:input_type -> controlplane.v1.WatchSessionEventsRequest
+	57, // 65: controlplane.v1.SessionService.SendUserMessage:input_type -> controlplane.v1.SendUserMessageRequest
+	60, // 66: controlplane.v1.SessionService.SendPrompt:input_type -> controlplane.v1.SendPromptRequest
+	62, // 67: controlplane.v1.SessionService.ExportSession:input_type -> controlplane.v1.ExportSessionRequest
+	64, // 68: controlplane.v1.SessionService.GetPlan:input_type -> controlplane.v1.GetPlanRequest
+	52, // 69: controlplane.v1.SessionService.WatchSessionLifecycle:input_type -> controlplane.v1.WatchSessionLifecycleRequest
+	66, // 70: controlplane.v1.SessionService.ListThreads:input_type -> controlplane.v1.SessionServiceListThreadsRequest
+	56, // 71: controlplane.v1.SessionService.CreateSession:output_type -> controlplane.v1.CreateSessionResponse
+	8,  // 72: controlplane.v1.SessionService.GetSession:output_type -> controlplane.v1.GetSessionResponse
+	10, // 73: controlplane.v1.SessionService.ListSessions:output_type -> controlplane.v1.ListSessionsResponse
+	12, // 74: controlplane.v1.SessionService.SetSessionMode:output_type -> controlplane.v1.SetSessionModeResponse
+	50, // 75: controlplane.v1.SessionService.WatchSessionEvents:output_type -> controlplane.v1.WatchSessionEventsResponse
+	58, // 76: controlplane.v1.SessionService.SendUserMessage:output_type -> controlplane.v1.SendUserMessageResponse
+	61, // 77: controlplane.v1.SessionService.SendPrompt:output_type -> controlplane.v1.SendPromptResponse
+	63, // 78: controlplane.v1.SessionService.ExportSession:output_type -> controlplane.v1.ExportSessionResponse
+	65, // 79: controlplane.v1.SessionService.GetPlan:output_type -> controlplane.v1.GetPlanResponse
+	53, // 80: controlplane.v1.SessionService.WatchSessionLifecycle:output_type -> controlplane.v1.WatchSessionLifecycleResponse
+	67, // 81: controlplane.v1.SessionService.ListThreads:output_type -> controlplane.v1.SessionServiceListThreadsResponse
+	71, // [71:82] is the sub-list for method output_type
+	60, // [60:71] is the sub-list for method input_type
+	60, // [60:60] is the sub-list for extension type_name
+	60, // [60:60] is the sub-list for extension extendee
+	0,  // [0:60] is the sub-list for field type_name
 }
 
 func init() { file_controlplane_v1_session_service_proto_init() }
@@ -1957,18 +5220,42 @@ func file_controlplane_v1_session_service_proto_init() {
 		(*SessionEvent_StatusChange)(nil),
 		(*SessionEvent_CurrentModeUpdate)(nil),
 		(*SessionEvent_UserMessage)(nil),
+		(*SessionEvent_CurrentModelUpdate)(nil),
+		(*SessionEvent_SessionError)(nil),
+		(*SessionEvent_PermissionRequest)(nil),
+		(*SessionEvent_PermissionResolved)(nil),
+		(*SessionEvent_EventsPruned)(nil),
+		(*SessionEvent_PlanSubmitted)(nil),
+		(*SessionEvent_McpServerStartup)(nil),
+		(*SessionEvent_Progress)(nil),
+		(*SessionEvent_TurnEnded)(nil),
+		(*SessionEvent_AgentPlan)(nil),
+		(*SessionEvent_SessionInit)(nil),
 	}
 	file_controlplane_v1_session_service_proto_msgTypes[13].OneofWrappers = []any{
 		(*ToolCallContentBlock_Diff)(nil),
 		(*ToolCallContentBlock_Text)(nil),
+		(*ToolCallContentBlock_CommandOutput)(nil),
+	}
+	file_controlplane_v1_session_service_proto_msgTypes[16].OneofWrappers = []any{}
+	file_controlplane_v1_session_service_proto_msgTypes[17].OneofWrappers = []any{
+		(*ToolInput_Read)(nil),
+		(*ToolInput_Write)(nil),
+		(*ToolInput_Edit)(nil),
+		(*ToolInput_Bash)(nil),
+		(*ToolInput_Grep)(nil),
+		(*ToolInput_Glob)(nil),
 	}
+	file_controlplane_v1_session_service_proto_msgTypes[18].OneofWrappers = []any{}
+	file_controlplane_v1_session_service_proto_msgTypes[21].OneofWrappers = []any{}
+	file_controlplane_v1_session_service_proto_msgTypes[35].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_controlplane_v1_session_service_proto_rawDesc), len(file_controlplane_v1_session_service_proto_rawDesc)),
-			NumEnums:      2,
-			NumMessages:   25,
+			NumEnums:      6,
+			NumMessages:   65,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -302,6 +302,102 @@ func (*SubmitPlanResponse) Descriptor() ([]byte, []int) {
 	return file_worker_v1_agentctl_service_proto_rawDescGZIP(), []int{5}
 }
 
+type ReportProgressRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	Percent       *int32                 `protobuf:"varint,3,opt,name=percent,proto3,oneof" json:"percent,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportProgressRequest) Reset() {
+	*x = ReportProgressRequest{}
+	mi := &file_worker_v1_agentctl_service_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportProgressRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportProgressRequest) ProtoMessage() {}
+
+func (x *ReportProgressRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_agentctl_service_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportProgressRequest.ProtoReflect.Descriptor instead.
+func (*ReportProgressRequest) Descriptor() ([]byte, []int) {
+	return file_worker_v1_agentctl_service_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *ReportProgressRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *ReportProgressRequest) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *ReportProgressRequest) GetPercent() int32 {
+	if x != nil && x.Percent != nil {
+		return *x.Percent
+	}
+	return 0
+}
+
+type ReportProgressResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReportProgressResponse) Reset() {
+	*x = ReportProgressResponse{}
+	mi := &file_worker_v1_agentctl_service_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReportProgressResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReportProgressResponse) ProtoMessage() {}
+
+func (x *ReportProgressResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_agentctl_service_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReportProgressResponse.ProtoReflect.Descriptor instead.
+func (*ReportProgressResponse) Descriptor() ([]byte, []int) {
+	return file_worker_v1_agentctl_service_proto_rawDescGZIP(), []int{7}
+}
+
 var File_worker_v1_agentctl_service_proto protoreflect.FileDescriptor
 
 const file_worker_v1_agentctl_service_proto_rawDesc = "" +
@@ -323,12 +419,21 @@ const file_worker_v1_agentctl_service_proto_rawDesc = "" +
 	"session_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\tsessionId\x12&\n" +
 	"\x05agent\x18\x02 \x01(\x0e2\x10.worker.v1.AgentR\x05agent\x12\x12\n" +
 	"\x04plan\x18\x03 \x01(\fR\x04plan\"\x14\n" +
-	"\x12SubmitPlanResponse2\xf8\x01\n" +
+	"\x12SubmitPlanResponse\"\x98\x01\n" +
+	"\x15ReportProgressRequest\x12&\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\tsessionId\x12!\n" +
+	"\amessage\x18\x02 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\amessage\x12(\n" +
+	"\apercent\x18\x03 \x01(\x05B\t\xbaH\x06\x1a\x04\x18d(\x00H\x00R\apercent\x88\x01\x01B\n" +
+	"\n" +
+	"\b_percent\"\x18\n" +
+	"\x16ReportProgressResponse2\xd1\x02\n" +
 	"\x0fAgentCtlService\x12E\n" +
 	"\bSetTopic\x12\x1a.worker.v1.SetTopicRequest\x1a\x1b.worker.v1.SetTopicResponse\"\x00\x12Q\n" +
 	"\fReportStatus\x12\x1e.worker.v1.ReportStatusRequest\x1a\x1f.worker.v1.ReportStatusResponse\"\x00\x12K\n" +
 	"\n" +
-	"SubmitPlan\x12\x1c.worker.v1.SubmitPlanRequest\x1a\x1d.worker.v1.SubmitPlanResponse\"\x00B\xb2\x01\n" +
+	"SubmitPlan\x12\x1c.worker.v1.SubmitPlanRequest\x1a\x1d.worker.v1.SubmitPlanResponse\"\x00\x12W\n" +
+	"\x0eReportProgress\x12 .worker.v1.ReportProgressRequest\x1a!.worker.v1.ReportProgressResponse\"\x00B\xb2\x01\n" +
 	"\rcom.worker.v1B\x14AgentctlServiceProtoP\x01ZFgithub.com/sebastianm/flowgentic/internal/proto/gen/worker/v1;workerv1\xa2\x02\x03WXX\xaa\x02\tWorker.V1\xca\x02\tWorker\\V1\xe2\x02\x15Worker\\V1\\GPBMetadata\xea\x02\n" +
 	"Worker::V1b\x06proto3"
 
@@ -344,27 +449,31 @@ func file_worker_v1_agentctl_service_proto_rawDescGZIP() []byte {
 	return file_worker_v1_agentctl_service_proto_rawDescData
 }
 
-var file_worker_v1_agentctl_service_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_worker_v1_agentctl_service_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
 var file_worker_v1_agentctl_service_proto_goTypes = []any{
-	(*SetTopicRequest)(nil),      // 0: worker.v1.SetTopicRequest
-	(*SetTopicResponse)(nil),     // 1: worker.v1.SetTopicResponse
-	(*ReportStatusRequest)(nil),  // 2: worker.v1.ReportStatusRequest
-	(*ReportStatusResponse)(nil), // 3: worker.v1.ReportStatusResponse
-	(*SubmitPlanRequest)(nil),    // 4: worker.v1.SubmitPlanRequest
-	(*SubmitPlanResponse)(nil),   // 5: worker.v1.SubmitPlanResponse
-	(Agent)(0),                   // 6: worker.v1.Agent
+	(*SetTopicRequest)(nil),        // 0: worker.v1.SetTopicRequest
+	(*SetTopicResponse)(nil),       // 1: worker.v1.SetTopicResponse
+	(*ReportStatusRequest)(nil),    // 2: worker.v1.ReportStatusRequest
+	(*ReportStatusResponse)(nil),   // 3: worker.v1.ReportStatusResponse
+	(*SubmitPlanRequest)(nil),      // 4: worker.v1.SubmitPlanRequest
+	(*SubmitPlanResponse)(nil),     // 5: worker.v1.SubmitPlanResponse
+	(*ReportProgressRequest)(nil),  // 6: worker.v1.ReportProgressRequest
+	(*ReportProgressResponse)(nil), // 7: worker.v1.ReportProgressResponse
+	(Agent)(0),                     // 8: worker.v1.Agent
 }
 var file_worker_v1_agentctl_service_proto_depIdxs = []int32{
-	6, // 0: worker.v1.ReportStatusRequest.agent:type_name -> worker.v1.Agent
-	6, // 1: worker.v1.SubmitPlanRequest.agent:type_name -> worker.v1.Agent
+	8, // 0: worker.v1.ReportStatusRequest.agent:type_name -> worker.v1.Agent
+	8, // 1: worker.v1.SubmitPlanRequest.agent:type_name -> worker.v1.Agent
 	0, // 2: worker.v1.AgentCtlService.SetTopic:input_type -> worker.v1.SetTopicRequest
 	2, // 3: worker.v1.AgentCtlService.ReportStatus:input_type -> worker.v1.ReportStatusRequest
 	4, // 4: worker.v1.AgentCtlService.SubmitPlan:input_type -> worker.v1.SubmitPlanRequest
-	1, // 5: worker.v1.AgentCtlService.SetTopic:output_type -> worker.v1.SetTopicResponse
-	3, // 6: worker.v1.AgentCtlService.ReportStatus:output_type -> worker.v1.ReportStatusResponse
-	5, // 7: worker.v1.AgentCtlService.SubmitPlan:output_type -> worker.v1.SubmitPlanResponse
-	5, // [5:8] is the sub-list for method output_type
-	2, // [2:5] is the sub-list for method input_type
+	6, // 5: worker.v1.AgentCtlService.ReportProgress:input_type -> worker.v1.ReportProgressRequest
+	1, // 6: worker.v1.AgentCtlService.SetTopic:output_type -> worker.v1.SetTopicResponse
+	3, // 7: worker.v1.AgentCtlService.ReportStatus:output_type -> worker.v1.ReportStatusResponse
+	5, // 8: worker.v1.AgentCtlService.SubmitPlan:output_type -> worker.v1.SubmitPlanResponse
+	7, // 9: worker.v1.AgentCtlService.ReportProgress:output_type -> worker.v1.ReportProgressResponse
+	6, // [6:10] is the sub-list for method output_type
+	2, // [2:6] is the sub-list for method input_type
 	2, // [2:2] is the sub-list for extension type_name
 	2, // [2:2] is the sub-list for extension extendee
 	0, // [0:2] is the sub-list for field type_name
@@ -376,13 +485,14 @@ func file_worker_v1_agentctl_service_proto_init() {
 		return
 	}
 	file_worker_v1_agent_proto_init()
+	file_worker_v1_agentctl_service_proto_msgTypes[6].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_worker_v1_agentctl_service_proto_rawDesc), len(file_worker_v1_agentctl_service_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   6,
+			NumMessages:   8,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -148,7 +148,9 @@ func (*PingRequest) Descriptor() ([]byte, []int) {
 }
 
 type PingResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Un-acknowledged session events buffered across all sessions.
+	QueuedEvents  int64 `protobuf:"varint,1,opt,name=queued_events,json=queuedEvents,proto3" json:"queued_events,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -183,6 +185,13 @@ func (*PingResponse) Descriptor() ([]byte, []int) {
 	return file_worker_v1_system_service_proto_rawDescGZIP(), []int{3}
 }
 
+func (x *PingResponse) GetQueuedEvents() int64 {
+	if x != nil {
+		return x.QueuedEvents
+	}
+	return 0
+}
+
 type AgentInfo struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Machine-readable identifier (e.g. "claude-code", "aider", "codex").
@@ -438,8 +447,9 @@ const file_worker_v1_system_service_proto_rawDesc = "" +
 	"\rdisable_cache\x18\x01 \x01(\bR\fdisableCache\"B\n" +
 	"\x12ListAgentsResponse\x12,\n" +
 	"\x06agents\x18\x01 \x03(\v2\x14.worker.v1.AgentInfoR\x06agents\"\r\n" +
-	"\vPingRequest\"\x0e\n" +
-	"\fPingResponse\"c\n" +
+	"\vPingRequest\"3\n" +
+	"\fPingResponse\x12#\n" +
+	"\rqueued_events\x18\x01 \x01(\x03R\fqueuedEvents\"c\n" +
 	"\tAgentInfo\x12\x0e\n" +
 	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
 	"\x04name\x18\x02 \x01(\tR\x04name\x12\x18\n" +
@@ -129,6 +129,62 @@ func (SessionMode) EnumDescriptor() ([]byte, []int) {
 	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{1}
 }
 
+// CancelReason says what cancelled a turn.
+type CancelReason int32
+
+const (
+	CancelReason_CANCEL_REASON_UNSPECIFIED CancelReason = 0
+	// A client cancelled the turn.
+	CancelReason_CANCEL_REASON_USER CancelReason = 1
+	// The prompt's deadline passed before the agent finished.
+	CancelReason_CANCEL_REASON_TIMEOUT CancelReason = 2
+	// A limit on the turn, such as a maximum number of agent turns, was hit.
+	CancelReason_CANCEL_REASON_LIMIT CancelReason = 3
+)
+
+// Enum value maps for CancelReason.
+var (
+	CancelReason_name = map[int32]string{
+		0: "CANCEL_REASON_UNSPECIFIED",
+		1: "CANCEL_REASON_USER",
+		2: "CANCEL_REASON_TIMEOUT",
+		3: "CANCEL_REASON_LIMIT",
+	}
+	CancelReason_value = map[string]int32{
+		"CANCEL_REASON_UNSPECIFIED": 0,
+		"CANCEL_REASON_USER":        1,
+		"CANCEL_REASON_TIMEOUT":     2,
+		"CANCEL_REASON_LIMIT":       3,
+	}
+)
+
+func (x CancelReason) Enum() *CancelReason {
+	p := new(CancelReason)
+	*p = x
+	return p
+}
+
+func (x CancelReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (CancelReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_worker_v1_worker_service_proto_enumTypes[2].Descriptor()
+}
+
+func (CancelReason) Type() protoreflect.EnumType {
+	return &file_worker_v1_worker_service_proto_enumTypes[2]
+}
+
+func (x CancelReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use CancelReason.Descriptor instead.
+func (CancelReason) EnumDescriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{2}
+}
+
 type ToolCallStatus int32
 
 const (
@@ -165,11 +221,11 @@ func (x ToolCallStatus) String() string {
 }
 
 func (ToolCallStatus) Descriptor() protoreflect.EnumDescriptor {
-	return file_worker_v1_worker_service_proto_enumTypes[2].Descriptor()
+	return file_worker_v1_worker_service_proto_enumTypes[3].Descriptor()
 }
 
 func (ToolCallStatus) Type() protoreflect.EnumType {
-	return &file_worker_v1_worker_service_proto_enumTypes[2]
+	return &file_worker_v1_worker_service_proto_enumTypes[3]
 }
 
 func (x ToolCallStatus) Number() protoreflect.EnumNumber {
@@ -178,7 +234,7 @@ func (x ToolCallStatus) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ToolCallStatus.Descriptor instead.
 func (ToolCallStatus) EnumDescriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{2}
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{3}
 }
 
 type ToolCallKind int32
@@ -235,11 +291,11 @@ func (x ToolCallKind) String() string {
 }
 
 func (ToolCallKind) Descriptor() protoreflect.EnumDescriptor {
-	return file_worker_v1_worker_service_proto_enumTypes[3].Descriptor()
+	return file_worker_v1_worker_service_proto_enumTypes[4].Descriptor()
 }
 
 func (ToolCallKind) Type() protoreflect.EnumType {
-	return &file_worker_v1_worker_service_proto_enumTypes[3]
+	return &file_worker_v1_worker_service_proto_enumTypes[4]
 }
 
 func (x ToolCallKind) Number() protoreflect.EnumNumber {
@@ -248,7 +304,118 @@ func (x ToolCallKind) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ToolCallKind.Descriptor instead.
 func (ToolCallKind) EnumDescriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{3}
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{4}
+}
+
+type SessionErrorReason int32
+
+const (
+	SessionErrorReason_SESSION_ERROR_REASON_UNSPECIFIED SessionErrorReason = 0
+	// The agent rejected its credentials; the user has to re-authenticate.
+	SessionErrorReason_SESSION_ERROR_REASON_AUTH SessionErrorReason = 1
+)
+
+// Enum value maps for SessionErrorReason.
+var (
+	SessionErrorReason_name = map[int32]string{
+		0: "SESSION_ERROR_REASON_UNSPECIFIED",
+		1: "SESSION_ERROR_REASON_AUTH",
+	}
+	SessionErrorReason_value = map[string]int32{
+		"SESSION_ERROR_REASON_UNSPECIFIED": 0,
+		"SESSION_ERROR_REASON_AUTH":        1,
+	}
+)
+
+func (x SessionErrorReason) Enum() *SessionErrorReason {
+	p := new(SessionErrorReason)
+	*p = x
+	return p
+}
+
+func (x SessionErrorReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SessionErrorReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_worker_v1_worker_service_proto_enumTypes[5].Descriptor()
+}
+
+func (SessionErrorReason) Type() protoreflect.EnumType {
+	return &file_worker_v1_worker_service_proto_enumTypes[5]
+}
+
+func (x SessionErrorReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SessionErrorReason.Descriptor instead.
+func (SessionErrorReason) EnumDescriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{5}
+}
+
+// StopReason says why the agent ended a prompt turn.
+type StopReason int32
+
+const (
+	StopReason_STOP_REASON_UNSPECIFIED StopReason = 0
+	// The agent finished its response.
+	StopReason_STOP_REASON_END_TURN StopReason = 1
+	// The agent hit the maximum number of tokens it may generate.
+	StopReason_STOP_REASON_MAX_TOKENS StopReason = 2
+	// The agent hit the maximum number of model requests in one turn.
+	StopReason_STOP_REASON_MAX_TURN_REQUESTS StopReason = 3
+	// The agent refused to continue.
+	StopReason_STOP_REASON_REFUSAL StopReason = 4
+	// The turn was cancelled.
+	StopReason_STOP_REASON_CANCELLED StopReason = 5
+)
+
+// Enum value maps for StopReason.
+var (
+	StopReason_name = map[int32]string{
+		0: "STOP_REASON_UNSPECIFIED",
+		1: "STOP_REASON_END_TURN",
+		2: "STOP_REASON_MAX_TOKENS",
+		3: "STOP_REASON_MAX_TURN_REQUESTS",
+		4: "STOP_REASON_REFUSAL",
+		5: "STOP_REASON_CANCELLED",
+	}
+	StopReason_value = map[string]int32{
+		"STOP_REASON_UNSPECIFIED":       0,
+		"STOP_REASON_END_TURN":          1,
+		"STOP_REASON_MAX_TOKENS":        2,
+		"STOP_REASON_MAX_TURN_REQUESTS": 3,
+		"STOP_REASON_REFUSAL":           4,
+		"STOP_REASON_CANCELLED":         5,
+	}
+)
+
+func (x StopReason) Enum() *StopReason {
+	p := new(StopReason)
+	*p = x
+	return p
+}
+
+func (x StopReason) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StopReason) Descriptor() protoreflect.EnumDescriptor {
+	return file_worker_v1_worker_service_proto_enumTypes[6].Descriptor()
+}
+
+func (StopReason) Type() protoreflect.EnumType {
+	return &file_worker_v1_worker_service_proto_enumTypes[6]
+}
+
+func (x StopReason) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StopReason.Descriptor instead.
+func (StopReason) EnumDescriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{6}
 }
 
 type SendUserMessageRequest struct {
@@ -304,10 +471,19 @@ func (x *SendUserMessageRequest) GetContentBlocks() []*ContentBlock {
 	return nil
 }
 
+// ContentBlock is one block of a prompt. An empty type is "text".
 type ContentBlock struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // "text"
-	Text          string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Type  string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"` // "text", "image" or "resource"
+	// The text of a "text" block, or the contents of a text "resource".
+	Text string `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	// The MIME type of an "image" or "resource" block.
+	MimeType string `protobuf:"bytes,3,opt,name=mime_type,json=mimeType,proto3" json:"mime_type,omitempty"`
+	// Base64-encoded data: the image of an "image" block, or the contents of
+	// a binary "resource".
+	Data string `protobuf:"bytes,4,opt,name=data,proto3" json:"data,omitempty"`
+	// The URI of a "resource" block.
+	Uri           string `protobuf:"bytes,5,opt,name=uri,proto3" json:"uri,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -356,6 +532,27 @@ func (x *ContentBlock) GetText() string {
 	return ""
 }
 
+func (x *ContentBlock) GetMimeType() string {
+	if x != nil {
+		return x.MimeType
+	}
+	return ""
+}
+
+func (x *ContentBlock) GetData() string {
+	if x != nil {
+		return x.Data
+	}
+	return ""
+}
+
+func (x *ContentBlock) GetUri() string {
+	if x != nil {
+		return x.Uri
+	}
+	return ""
+}
+
 type SendUserMessageResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	StopReason    string                 `protobuf:"bytes,1,opt,name=stop_reason,json=stopReason,proto3" json:"stop_reason,omitempty"`
@@ -400,16 +597,135 @@ func (x *SendUserMessageResponse) GetStopReason() string {
 	return ""
 }
 
-type CancelSessionRequest struct {
+type PromptRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// The prompt content blocks to send.
+	ContentBlocks []*ContentBlock `protobuf:"bytes,2,rep,name=content_blocks,json=contentBlocks,proto3" json:"content_blocks,omitempty"`
+	// Optional model to use for this prompt only; the session's model is
+	// restored when the turn ends.
+	Model string `protobuf:"bytes,3,opt,name=model,proto3" json:"model,omitempty"`
+	// Optional session mode ("ask", "architect", "code") to use for this prompt
+	// only; the session's mode is restored when the turn ends.
+	SessionMode   string `protobuf:"bytes,4,opt,name=session_mode,json=sessionMode,proto3" json:"session_mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PromptRequest) Reset() {
+	*x = PromptRequest{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PromptRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PromptRequest) ProtoMessage() {}
+
+func (x *PromptRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PromptRequest.ProtoReflect.Descriptor instead.
+func (*PromptRequest) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *PromptRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *PromptRequest) GetContentBlocks() []*ContentBlock {
+	if x != nil {
+		return x.ContentBlocks
+	}
+	return nil
+}
+
+func (x *PromptRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *PromptRequest) GetSessionMode() string {
+	if x != nil {
+		return x.SessionMode
+	}
+	return ""
+}
+
+type PromptResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	StopReason    string                 `protobuf:"bytes,1,opt,name=stop_reason,json=stopReason,proto3" json:"stop_reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PromptResponse) Reset() {
+	*x = PromptResponse{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PromptResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PromptResponse) ProtoMessage() {}
+
+func (x *PromptResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PromptResponse.ProtoReflect.Descriptor instead.
+func (*PromptResponse) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *PromptResponse) GetStopReason() string {
+	if x != nil {
+		return x.StopReason
+	}
+	return ""
+}
+
+type CancelSessionRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// Why the turn is cancelled; unspecified counts as a user cancellation.
+	Reason        CancelReason `protobuf:"varint,2,opt,name=reason,proto3,enum=worker.v1.CancelReason" json:"reason,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *CancelSessionRequest) Reset() {
 	*x = CancelSessionRequest{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[3]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -421,7 +737,7 @@ func (x *CancelSessionRequest) String() string {
 func (*CancelSessionRequest) ProtoMessage() {}
 
 func (x *CancelSessionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[3]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -434,7 +750,7 @@ func (x *CancelSessionRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CancelSessionRequest.ProtoReflect.Descriptor instead.
 func (*CancelSessionRequest) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{3}
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{5}
 }
 
 func (x *CancelSessionRequest) GetSessionId() string {
@@ -444,6 +760,13 @@ func (x *CancelSessionRequest) GetSessionId() string {
 	return ""
 }
 
+func (x *CancelSessionRequest) GetReason() CancelReason {
+	if x != nil {
+		return x.Reason
+	}
+	return CancelReason_CANCEL_REASON_UNSPECIFIED
+}
+
 type CancelSessionResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	unknownFields protoimpl.UnknownFields
@@ -452,7 +775,7 @@ type CancelSessionResponse struct {
 
 func (x *CancelSessionResponse) Reset() {
 	*x = CancelSessionResponse{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[4]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[6]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -464,7 +787,7 @@ func (x *CancelSessionResponse) String() string {
 func (*CancelSessionResponse) ProtoMessage() {}
 
 func (x *CancelSessionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[4]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[6]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -477,7 +800,7 @@ func (x *CancelSessionResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CancelSessionResponse.ProtoReflect.Descriptor instead.
 func (*CancelSessionResponse) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{4}
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{6}
 }
 
 type SetSessionModeRequest struct {
@@ -492,7 +815,7 @@ type SetSessionModeRequest struct {
 
 func (x *SetSessionModeRequest) Reset() {
 	*x = SetSessionModeRequest{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[5]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[7]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -504,7 +827,7 @@ func (x *SetSessionModeRequest) String() string {
 func (*SetSessionModeRequest) ProtoMessage() {}
 
 func (x *SetSessionModeRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[5]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[7]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -517,7 +840,7 @@ func (x *SetSessionModeRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetSessionModeRequest.ProtoReflect.Descriptor instead.
 func (*SetSessionModeRequest) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{5}
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{7}
 }
 
 func (x *SetSessionModeRequest) GetSessionId() string {
@@ -542,7 +865,7 @@ type SetSessionModeResponse struct {
 
 func (x *SetSessionModeResponse) Reset() {
 	*x = SetSessionModeResponse{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[6]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[8]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -554,7 +877,7 @@ func (x *SetSessionModeResponse) String() string {
 func (*SetSessionModeResponse) ProtoMessage() {}
 
 func (x *SetSessionModeResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[6]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[8]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -567,50 +890,34 @@ func (x *SetSessionModeResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SetSessionModeResponse.ProtoReflect.Descriptor instead.
 func (*SetSessionModeResponse) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{6}
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{8}
 }
 
-type NewSessionRequest struct {
+type SetAllowedToolsRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// Unique identifier for this session, assigned by the control plane.
+	// The session whose allowed tools should change.
 	SessionId string `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	// Agent driver to use.
-	Agent Agent `protobuf:"varint,2,opt,name=agent,proto3,enum=worker.v1.Agent" json:"agent,omitempty"`
-	// Session mode: "headless" or "interactive".
-	Mode string `protobuf:"bytes,3,opt,name=mode,proto3" json:"mode,omitempty"`
-	// The prompt to send to the agent.
-	Prompt string `protobuf:"bytes,4,opt,name=prompt,proto3" json:"prompt,omitempty"`
-	// Optional system prompt override.
-	SystemPrompt string `protobuf:"bytes,5,opt,name=system_prompt,json=systemPrompt,proto3" json:"system_prompt,omitempty"`
-	// Optional model override (e.g. "opus", "sonnet").
-	Model string `protobuf:"bytes,6,opt,name=model,proto3" json:"model,omitempty"`
-	// Working directory for the agent session.
-	Cwd string `protobuf:"bytes,7,opt,name=cwd,proto3" json:"cwd,omitempty"`
-	// Optional: resume an existing session by agent session ID.
-	AgentSessionId string `protobuf:"bytes,8,opt,name=agent_session_id,json=agentSessionId,proto3" json:"agent_session_id,omitempty"`
-	// Session mode (e.g. "ask", "architect", "code").
-	SessionMode string `protobuf:"bytes,9,opt,name=session_mode,json=sessionMode,proto3" json:"session_mode,omitempty"`
-	// Optional list of allowed tools.
-	AllowedTools  []string `protobuf:"bytes,10,rep,name=allowed_tools,json=allowedTools,proto3" json:"allowed_tools,omitempty"`
+	// The new allowlist; it replaces the current one. Empty revokes all.
+	Tools         []string `protobuf:"bytes,2,rep,name=tools,proto3" json:"tools,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NewSessionRequest) Reset() {
-	*x = NewSessionRequest{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[7]
+func (x *SetAllowedToolsRequest) Reset() {
+	*x = SetAllowedToolsRequest{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[9]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NewSessionRequest) String() string {
+func (x *SetAllowedToolsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NewSessionRequest) ProtoMessage() {}
+func (*SetAllowedToolsRequest) ProtoMessage() {}
 
-func (x *NewSessionRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[7]
+func (x *SetAllowedToolsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[9]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -621,116 +928,87 @@ func (x *NewSessionRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NewSessionRequest.ProtoReflect.Descriptor instead.
-func (*NewSessionRequest) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{7}
+// Deprecated: Use SetAllowedToolsRequest.ProtoReflect.Descriptor instead.
+func (*SetAllowedToolsRequest) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{9}
 }
 
-func (x *NewSessionRequest) GetSessionId() string {
+func (x *SetAllowedToolsRequest) GetSessionId() string {
 	if x != nil {
 		return x.SessionId
 	}
 	return ""
 }
 
-func (x *NewSessionRequest) GetAgent() Agent {
-	if x != nil {
-		return x.Agent
-	}
-	return Agent_AGENT_UNSPECIFIED
-}
-
-func (x *NewSessionRequest) GetMode() string {
-	if x != nil {
-		return x.Mode
-	}
-	return ""
-}
-
-func (x *NewSessionRequest) GetPrompt() string {
+func (x *SetAllowedToolsRequest) GetTools() []string {
 	if x != nil {
-		return x.Prompt
+		return x.Tools
 	}
-	return ""
+	return nil
 }
 
-func (x *NewSessionRequest) GetSystemPrompt() string {
-	if x != nil {
-		return x.SystemPrompt
-	}
-	return ""
+type SetAllowedToolsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NewSessionRequest) GetModel() string {
-	if x != nil {
-		return x.Model
-	}
-	return ""
+func (x *SetAllowedToolsResponse) Reset() {
+	*x = SetAllowedToolsResponse{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *NewSessionRequest) GetCwd() string {
-	if x != nil {
-		return x.Cwd
-	}
-	return ""
+func (x *SetAllowedToolsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *NewSessionRequest) GetAgentSessionId() string {
-	if x != nil {
-		return x.AgentSessionId
-	}
-	return ""
-}
+func (*SetAllowedToolsResponse) ProtoMessage() {}
 
-func (x *NewSessionRequest) GetSessionMode() string {
+func (x *SetAllowedToolsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[10]
 	if x != nil {
-		return x.SessionMode
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *NewSessionRequest) GetAllowedTools() []string {
-	if x != nil {
-		return x.AllowedTools
-	}
-	return nil
+// Deprecated: Use SetAllowedToolsResponse.ProtoReflect.Descriptor instead.
+func (*SetAllowedToolsResponse) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{10}
 }
 
-type NewSessionResponse struct {
+type SetHostCommandsRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// Whether the worker accepted the session.
-	Accepted bool `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
-	// Human-readable message (e.g. reason for rejection).
-	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
-	// The session ID for the launched session.
-	SessionId string `protobuf:"bytes,3,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	// The agent driver used.
-	Agent Agent `protobuf:"varint,4,opt,name=agent,proto3,enum=worker.v1.Agent" json:"agent,omitempty"`
-	// Current status of the session.
-	Status string `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
-	// Session mode (headless or interactive).
-	Mode string `protobuf:"bytes,6,opt,name=mode,proto3" json:"mode,omitempty"`
-	// The agent-side session ID (assigned by or passed to the CLI).
-	AgentSessionId string `protobuf:"bytes,11,opt,name=agent_session_id,json=agentSessionId,proto3" json:"agent_session_id,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	// The session whose host commands should change.
+	SessionId string `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// The new commands; they replace the ones set before. Empty removes all.
+	// A command named like one of the agent's is left out.
+	Commands      []*HostCommand `protobuf:"bytes,2,rep,name=commands,proto3" json:"commands,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NewSessionResponse) Reset() {
-	*x = NewSessionResponse{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[8]
+func (x *SetHostCommandsRequest) Reset() {
+	*x = SetHostCommandsRequest{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *NewSessionResponse) String() string {
+func (x *SetHostCommandsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*NewSessionResponse) ProtoMessage() {}
+func (*SetHostCommandsRequest) ProtoMessage() {}
 
-func (x *NewSessionResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[8]
+func (x *SetHostCommandsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -741,87 +1019,87 @@ func (x *NewSessionResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use NewSessionResponse.ProtoReflect.Descriptor instead.
-func (*NewSessionResponse) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{8}
+// Deprecated: Use SetHostCommandsRequest.ProtoReflect.Descriptor instead.
+func (*SetHostCommandsRequest) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{11}
 }
 
-func (x *NewSessionResponse) GetAccepted() bool {
+func (x *SetHostCommandsRequest) GetSessionId() string {
 	if x != nil {
-		return x.Accepted
+		return x.SessionId
 	}
-	return false
+	return ""
 }
 
-func (x *NewSessionResponse) GetMessage() string {
+func (x *SetHostCommandsRequest) GetCommands() []*HostCommand {
 	if x != nil {
-		return x.Message
+		return x.Commands
 	}
-	return ""
+	return nil
 }
 
-func (x *NewSessionResponse) GetSessionId() string {
-	if x != nil {
-		return x.SessionId
-	}
-	return ""
+type SetHostCommandsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *NewSessionResponse) GetAgent() Agent {
-	if x != nil {
-		return x.Agent
-	}
-	return Agent_AGENT_UNSPECIFIED
+func (x *SetHostCommandsResponse) Reset() {
+	*x = SetHostCommandsResponse{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *NewSessionResponse) GetStatus() string {
-	if x != nil {
-		return x.Status
-	}
-	return ""
+func (x *SetHostCommandsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *NewSessionResponse) GetMode() string {
+func (*SetHostCommandsResponse) ProtoMessage() {}
+
+func (x *SetHostCommandsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[12]
 	if x != nil {
-		return x.Mode
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ""
+	return mi.MessageOf(x)
 }
 
-func (x *NewSessionResponse) GetAgentSessionId() string {
-	if x != nil {
-		return x.AgentSessionId
-	}
-	return ""
+// Deprecated: Use SetHostCommandsResponse.ProtoReflect.Descriptor instead.
+func (*SetHostCommandsResponse) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{12}
 }
 
-type SessionInfo struct {
-	state          protoimpl.MessageState `protogen:"open.v1"`
-	SessionId      string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	Agent          Agent                  `protobuf:"varint,2,opt,name=agent,proto3,enum=worker.v1.Agent" json:"agent,omitempty"`
-	Status         SessionStatus          `protobuf:"varint,3,opt,name=status,proto3,enum=worker.v1.SessionStatus" json:"status,omitempty"`
-	Mode           SessionMode            `protobuf:"varint,4,opt,name=mode,proto3,enum=worker.v1.SessionMode" json:"mode,omitempty"`
-	AgentSessionId string                 `protobuf:"bytes,5,opt,name=agent_session_id,json=agentSessionId,proto3" json:"agent_session_id,omitempty"`
-	Model          string                 `protobuf:"bytes,6,opt,name=model,proto3" json:"model,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+// A slash command provided by the host rather than the agent.
+type HostCommand struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Name        string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	// Shown while the command's input is still missing; empty if it takes none.
+	InputHint     string `protobuf:"bytes,3,opt,name=input_hint,json=inputHint,proto3" json:"input_hint,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SessionInfo) Reset() {
-	*x = SessionInfo{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[9]
+func (x *HostCommand) Reset() {
+	*x = HostCommand{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SessionInfo) String() string {
+func (x *HostCommand) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SessionInfo) ProtoMessage() {}
+func (*HostCommand) ProtoMessage() {}
 
-func (x *SessionInfo) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[9]
+func (x *HostCommand) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -832,74 +1110,56 @@ func (x *SessionInfo) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SessionInfo.ProtoReflect.Descriptor instead.
-func (*SessionInfo) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{9}
+// Deprecated: Use HostCommand.ProtoReflect.Descriptor instead.
+func (*HostCommand) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{13}
 }
 
-func (x *SessionInfo) GetSessionId() string {
+func (x *HostCommand) GetName() string {
 	if x != nil {
-		return x.SessionId
+		return x.Name
 	}
 	return ""
 }
 
-func (x *SessionInfo) GetAgent() Agent {
-	if x != nil {
-		return x.Agent
-	}
-	return Agent_AGENT_UNSPECIFIED
-}
-
-func (x *SessionInfo) GetStatus() SessionStatus {
-	if x != nil {
-		return x.Status
-	}
-	return SessionStatus_SESSION_STATUS_UNSPECIFIED
-}
-
-func (x *SessionInfo) GetMode() SessionMode {
-	if x != nil {
-		return x.Mode
-	}
-	return SessionMode_SESSION_MODE_UNSPECIFIED
-}
-
-func (x *SessionInfo) GetAgentSessionId() string {
+func (x *HostCommand) GetDescription() string {
 	if x != nil {
-		return x.AgentSessionId
+		return x.Description
 	}
 	return ""
 }
 
-func (x *SessionInfo) GetModel() string {
+func (x *HostCommand) GetInputHint() string {
 	if x != nil {
-		return x.Model
+		return x.InputHint
 	}
 	return ""
 }
 
-type ListSessionsRequest struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
+type GetPendingEventsRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// Only events with a higher sequence are returned.
+	AfterSequence int64 `protobuf:"varint,2,opt,name=after_sequence,json=afterSequence,proto3" json:"after_sequence,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListSessionsRequest) Reset() {
-	*x = ListSessionsRequest{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[10]
+func (x *GetPendingEventsRequest) Reset() {
+	*x = GetPendingEventsRequest{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListSessionsRequest) String() string {
+func (x *GetPendingEventsRequest) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListSessionsRequest) ProtoMessage() {}
+func (*GetPendingEventsRequest) ProtoMessage() {}
 
-func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[10]
+func (x *GetPendingEventsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -910,33 +1170,48 @@ func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
-func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{10}
+// Deprecated: Use GetPendingEventsRequest.ProtoReflect.Descriptor instead.
+func (*GetPendingEventsRequest) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{14}
 }
 
-type ListSessionsResponse struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Sessions      []*SessionInfo         `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+func (x *GetPendingEventsRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *GetPendingEventsRequest) GetAfterSequence() int64 {
+	if x != nil {
+		return x.AfterSequence
+	}
+	return 0
+}
+
+type GetPendingEventsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Un-acknowledged events, oldest first.
+	Events        []*SessionEvent `protobuf:"bytes,1,rep,name=events,proto3" json:"events,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ListSessionsResponse) Reset() {
-	*x = ListSessionsResponse{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[11]
+func (x *GetPendingEventsResponse) Reset() {
+	*x = GetPendingEventsResponse{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ListSessionsResponse) String() string {
+func (x *GetPendingEventsResponse) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ListSessionsResponse) ProtoMessage() {}
+func (*GetPendingEventsResponse) ProtoMessage() {}
 
-func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[11]
+func (x *GetPendingEventsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -947,44 +1222,1974 @@ func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
-func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{11}
+// Deprecated: Use GetPendingEventsResponse.ProtoReflect.Descriptor instead.
+func (*GetPendingEventsResponse) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{15}
 }
 
-func (x *ListSessionsResponse) GetSessions() []*SessionInfo {
+func (x *GetPendingEventsResponse) GetEvents() []*SessionEvent {
 	if x != nil {
-		return x.Sessions
+		return x.Events
 	}
 	return nil
 }
 
-// CP → Worker (bidi stream).
-type StateSyncRequest struct {
+type NewSessionRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// ACK: CP has processed all events up to and including this sequence for this session.
-	// Worker can drop ACKed events from its queue.
-	AckSessionId  string `protobuf:"bytes,1,opt,name=ack_session_id,json=ackSessionId,proto3" json:"ack_session_id,omitempty"`
-	AckSequence   int64  `protobuf:"varint,2,opt,name=ack_sequence,json=ackSequence,proto3" json:"ack_sequence,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
-}
+	// Unique identifier for this session, assigned by the control plane.
+	SessionId string `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// Agent driver to use.
+	Agent Agent `protobuf:"varint,2,opt,name=agent,proto3,enum=worker.v1.Agent" json:"agent,omitempty"`
+	// Session mode: "headless" or "interactive".
+	Mode string `protobuf:"bytes,3,opt,name=mode,proto3" json:"mode,omitempty"`
+	// The prompt to send to the agent.
+	Prompt string `protobuf:"bytes,4,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	// Optional system prompt override.
+	SystemPrompt string `protobuf:"bytes,5,opt,name=system_prompt,json=systemPrompt,proto3" json:"system_prompt,omitempty"`
+	// Optional model override (e.g. "opus", "sonnet").
+	Model string `protobuf:"bytes,6,opt,name=model,proto3" json:"model,omitempty"`
+	// Working directory for the agent session.
+	Cwd string `protobuf:"bytes,7,opt,name=cwd,proto3" json:"cwd,omitempty"`
+	// Optional: resume an existing session by agent session ID.
+	AgentSessionId string `protobuf:"bytes,8,opt,name=agent_session_id,json=agentSessionId,proto3" json:"agent_session_id,omitempty"`
+	// Session mode (e.g. "ask", "architect", "code").
+	SessionMode string `protobuf:"bytes,9,opt,name=session_mode,json=sessionMode,proto3" json:"session_mode,omitempty"`
+	// Optional list of allowed tools.
+	AllowedTools []string `protobuf:"bytes,10,rep,name=allowed_tools,json=allowedTools,proto3" json:"allowed_tools,omitempty"`
+	// Optional reasoning effort ("low", "medium", "high"); empty = agent default.
+	ReasoningEffort string `protobuf:"bytes,11,opt,name=reasoning_effort,json=reasoningEffort,proto3" json:"reasoning_effort,omitempty"`
+	// Optional user-assigned labels (e.g. project, branch, user) for filtering.
+	Labels map[string]string `protobuf:"bytes,12,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Derive a topic from the first exchange if the agent never calls set_topic.
+	AutoTopic bool `protobuf:"varint,13,opt,name=auto_topic,json=autoTopic,proto3" json:"auto_topic,omitempty"`
+	// Stream the session's events live only: they are not queued for
+	// redelivery and the control plane does not persist them.
+	Ephemeral bool `protobuf:"varint,14,opt,name=ephemeral,proto3" json:"ephemeral,omitempty"`
+	// Prompt the agent again by itself after each turn.
+	AutoContinue *AutoContinue `protobuf:"bytes,15,opt,name=auto_continue,json=autoContinue,proto3" json:"auto_continue,omitempty"`
+	// Built-in or extension capability names the agent must have, such as
+	// "image_generation"; the launch fails otherwise.
+	RequiredCapabilities []string `protobuf:"bytes,16,rep,name=required_capabilities,json=requiredCapabilities,proto3" json:"required_capabilities,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *NewSessionRequest) Reset() {
+	*x = NewSessionRequest{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NewSessionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NewSessionRequest) ProtoMessage() {}
+
+func (x *NewSessionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NewSessionRequest.ProtoReflect.Descriptor instead.
+func (*NewSessionRequest) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *NewSessionRequest) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *NewSessionRequest) GetAgent() Agent {
+	if x != nil {
+		return x.Agent
+	}
+	return Agent_AGENT_UNSPECIFIED
+}
+
+func (x *NewSessionRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *NewSessionRequest) GetPrompt() string {
+	if x != nil {
+		return x.Prompt
+	}
+	return ""
+}
+
+func (x *NewSessionRequest) GetSystemPrompt() string {
+	if x != nil {
+		return x.SystemPrompt
+	}
+	return ""
+}
+
+func (x *NewSessionRequest) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *NewSessionRequest) GetCwd() string {
+	if x != nil {
+		return x.Cwd
+	}
+	return ""
+}
+
+func (x *NewSessionRequest) GetAgentSessionId() string {
+	if x != nil {
+		return x.AgentSessionId
+	}
+	return ""
+}
+
+func (x *NewSessionRequest) GetSessionMode() string {
+	if x != nil {
+		return x.SessionMode
+	}
+	return ""
+}
+
+func (x *NewSessionRequest) GetAllowedTools() []string {
+	if x != nil {
+		return x.AllowedTools
+	}
+	return nil
+}
+
+func (x *NewSessionRequest) GetReasoningEffort() string {
+	if x != nil {
+		return x.ReasoningEffort
+	}
+	return ""
+}
+
+func (x *NewSessionRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *NewSessionRequest) GetAutoTopic() bool {
+	if x != nil {
+		return x.AutoTopic
+	}
+	return false
+}
+
+func (x *NewSessionRequest) GetEphemeral() bool {
+	if x != nil {
+		return x.Ephemeral
+	}
+	return false
+}
+
+func (x *NewSessionRequest) GetAutoContinue() *AutoContinue {
+	if x != nil {
+		return x.AutoContinue
+	}
+	return nil
+}
+
+func (x *NewSessionRequest) GetRequiredCapabilities() []string {
+	if x != nil {
+		return x.RequiredCapabilities
+	}
+	return nil
+}
+
+// AutoContinue sends prompt after a turn ends normally, at most
+// max_iterations times per prompt from the user, until the agent's reply
+// contains stop_phrase.
+type AutoContinue struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Empty uses "Continue.".
+	Prompt string `protobuf:"bytes,1,opt,name=prompt,proto3" json:"prompt,omitempty"`
+	// Zero disables auto-continue.
+	MaxIterations int32 `protobuf:"varint,2,opt,name=max_iterations,json=maxIterations,proto3" json:"max_iterations,omitempty"`
+	// Empty continues until max_iterations.
+	StopPhrase    string `protobuf:"bytes,3,opt,name=stop_phrase,json=stopPhrase,proto3" json:"stop_phrase,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AutoContinue) Reset() {
+	*x = AutoContinue{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AutoContinue) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AutoContinue) ProtoMessage() {}
+
+func (x *AutoContinue) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AutoContinue.ProtoReflect.Descriptor instead.
+func (*AutoContinue) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *AutoContinue) GetPrompt() string {
+	if x != nil {
+		return x.Prompt
+	}
+	return ""
+}
+
+func (x *AutoContinue) GetMaxIterations() int32 {
+	if x != nil {
+		return x.MaxIterations
+	}
+	return 0
+}
+
+func (x *AutoContinue) GetStopPhrase() string {
+	if x != nil {
+		return x.StopPhrase
+	}
+	return ""
+}
+
+type NewSessionResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Whether the worker accepted the session.
+	Accepted bool `protobuf:"varint,1,opt,name=accepted,proto3" json:"accepted,omitempty"`
+	// Human-readable message (e.g. reason for rejection).
+	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// The session ID for the launched session.
+	SessionId string `protobuf:"bytes,3,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// The agent driver used.
+	Agent Agent `protobuf:"varint,4,opt,name=agent,proto3,enum=worker.v1.Agent" json:"agent,omitempty"`
+	// Current status of the session.
+	Status string `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	// Session mode (headless or interactive).
+	Mode string `protobuf:"bytes,6,opt,name=mode,proto3" json:"mode,omitempty"`
+	// The agent-side session ID (assigned by or passed to the CLI).
+	AgentSessionId string `protobuf:"bytes,11,opt,name=agent_session_id,json=agentSessionId,proto3" json:"agent_session_id,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *NewSessionResponse) Reset() {
+	*x = NewSessionResponse{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NewSessionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NewSessionResponse) ProtoMessage() {}
+
+func (x *NewSessionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NewSessionResponse.ProtoReflect.Descriptor instead.
+func (*NewSessionResponse) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *NewSessionResponse) GetAccepted() bool {
+	if x != nil {
+		return x.Accepted
+	}
+	return false
+}
+
+func (x *NewSessionResponse) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *NewSessionResponse) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *NewSessionResponse) GetAgent() Agent {
+	if x != nil {
+		return x.Agent
+	}
+	return Agent_AGENT_UNSPECIFIED
+}
+
+func (x *NewSessionResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *NewSessionResponse) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *NewSessionResponse) GetAgentSessionId() string {
+	if x != nil {
+		return x.AgentSessionId
+	}
+	return ""
+}
+
+type SessionInfo struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	SessionId      string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Agent          Agent                  `protobuf:"varint,2,opt,name=agent,proto3,enum=worker.v1.Agent" json:"agent,omitempty"`
+	Status         SessionStatus          `protobuf:"varint,3,opt,name=status,proto3,enum=worker.v1.SessionStatus" json:"status,omitempty"`
+	Mode           SessionMode            `protobuf:"varint,4,opt,name=mode,proto3,enum=worker.v1.SessionMode" json:"mode,omitempty"`
+	AgentSessionId string                 `protobuf:"bytes,5,opt,name=agent_session_id,json=agentSessionId,proto3" json:"agent_session_id,omitempty"`
+	Model          string                 `protobuf:"bytes,6,opt,name=model,proto3" json:"model,omitempty"`
+	Labels         map[string]string      `protobuf:"bytes,7,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Why the agent ended its last prompt turn; unset before the first.
+	LastStopReason StopReason `protobuf:"varint,8,opt,name=last_stop_reason,json=lastStopReason,proto3,enum=worker.v1.StopReason" json:"last_stop_reason,omitempty"`
+	// Un-acknowledged events buffered for the session; PingResponse has the
+	// total across all sessions.
+	QueuedEvents  int64 `protobuf:"varint,9,opt,name=queued_events,json=queuedEvents,proto3" json:"queued_events,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionInfo) Reset() {
+	*x = SessionInfo{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionInfo) ProtoMessage() {}
+
+func (x *SessionInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionInfo.ProtoReflect.Descriptor instead.
+func (*SessionInfo) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *SessionInfo) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *SessionInfo) GetAgent() Agent {
+	if x != nil {
+		return x.Agent
+	}
+	return Agent_AGENT_UNSPECIFIED
+}
+
+func (x *SessionInfo) GetStatus() SessionStatus {
+	if x != nil {
+		return x.Status
+	}
+	return SessionStatus_SESSION_STATUS_UNSPECIFIED
+}
+
+func (x *SessionInfo) GetMode() SessionMode {
+	if x != nil {
+		return x.Mode
+	}
+	return SessionMode_SESSION_MODE_UNSPECIFIED
+}
+
+func (x *SessionInfo) GetAgentSessionId() string {
+	if x != nil {
+		return x.AgentSessionId
+	}
+	return ""
+}
+
+func (x *SessionInfo) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *SessionInfo) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *SessionInfo) GetLastStopReason() StopReason {
+	if x != nil {
+		return x.LastStopReason
+	}
+	return StopReason_STOP_REASON_UNSPECIFIED
+}
+
+func (x *SessionInfo) GetQueuedEvents() int64 {
+	if x != nil {
+		return x.QueuedEvents
+	}
+	return 0
+}
+
+type ListSessionsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Optional equality selector over session labels, e.g. "project=foo,branch=main".
+	// Empty returns all sessions.
+	LabelSelector string `protobuf:"bytes,1,opt,name=label_selector,json=labelSelector,proto3" json:"label_selector,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsRequest) Reset() {
+	*x = ListSessionsRequest{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsRequest) ProtoMessage() {}
+
+func (x *ListSessionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsRequest.ProtoReflect.Descriptor instead.
+func (*ListSessionsRequest) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListSessionsRequest) GetLabelSelector() string {
+	if x != nil {
+		return x.LabelSelector
+	}
+	return ""
+}
+
+type ListSessionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sessions      []*SessionInfo         `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListSessionsResponse) Reset() {
+	*x = ListSessionsResponse{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListSessionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListSessionsResponse) ProtoMessage() {}
+
+func (x *ListSessionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListSessionsResponse.ProtoReflect.Descriptor instead.
+func (*ListSessionsResponse) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListSessionsResponse) GetSessions() []*SessionInfo {
+	if x != nil {
+		return x.Sessions
+	}
+	return nil
+}
+
+// CP → Worker (bidi stream).
+type StateSyncRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// ACK: CP has processed all events up to and including this sequence for this session.
+	// Worker can drop ACKed events from its queue.
+	AckSessionId  string `protobuf:"bytes,1,opt,name=ack_session_id,json=ackSessionId,proto3" json:"ack_session_id,omitempty"`
+	AckSequence   int64  `protobuf:"varint,2,opt,name=ack_sequence,json=ackSequence,proto3" json:"ack_sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StateSyncRequest) Reset() {
+	*x = StateSyncRequest{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StateSyncRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StateSyncRequest) ProtoMessage() {}
+
+func (x *StateSyncRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StateSyncRequest.ProtoReflect.Descriptor instead.
+func (*StateSyncRequest) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *StateSyncRequest) GetAckSessionId() string {
+	if x != nil {
+		return x.AckSessionId
+	}
+	return ""
+}
+
+func (x *StateSyncRequest) GetAckSequence() int64 {
+	if x != nil {
+		return x.AckSequence
+	}
+	return 0
+}
+
+// Worker → CP
+type StateSyncResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Update:
+	//
+	//	*StateSyncResponse_Snapshot
+	//	*StateSyncResponse_SessionUpdate
+	//	*StateSyncResponse_SessionRemoved
+	//	*StateSyncResponse_SessionEvent
+	Update        isStateSyncResponse_Update `protobuf_oneof:"update"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StateSyncResponse) Reset() {
+	*x = StateSyncResponse{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StateSyncResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StateSyncResponse) ProtoMessage() {}
+
+func (x *StateSyncResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StateSyncResponse.ProtoReflect.Descriptor instead.
+func (*StateSyncResponse) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *StateSyncResponse) GetUpdate() isStateSyncResponse_Update {
+	if x != nil {
+		return x.Update
+	}
+	return nil
+}
+
+func (x *StateSyncResponse) GetSnapshot() *SessionStateSnapshot {
+	if x != nil {
+		if x, ok := x.Update.(*StateSyncResponse_Snapshot); ok {
+			return x.Snapshot
+		}
+	}
+	return nil
+}
+
+func (x *StateSyncResponse) GetSessionUpdate() *SessionState {
+	if x != nil {
+		if x, ok := x.Update.(*StateSyncResponse_SessionUpdate); ok {
+			return x.SessionUpdate
+		}
+	}
+	return nil
+}
+
+func (x *StateSyncResponse) GetSessionRemoved() *SessionRemoved {
+	if x != nil {
+		if x, ok := x.Update.(*StateSyncResponse_SessionRemoved); ok {
+			return x.SessionRemoved
+		}
+	}
+	return nil
+}
+
+func (x *StateSyncResponse) GetSessionEvent() *SessionEvent {
+	if x != nil {
+		if x, ok := x.Update.(*StateSyncResponse_SessionEvent); ok {
+			return x.SessionEvent
+		}
+	}
+	return nil
+}
+
+type isStateSyncResponse_Update interface {
+	isStateSyncResponse_Update()
+}
+
+type StateSyncResponse_Snapshot struct {
+	Snapshot *SessionStateSnapshot `protobuf:"bytes,1,opt,name=snapshot,proto3,oneof"`
+}
+
+type StateSyncResponse_SessionUpdate struct {
+	SessionUpdate *SessionState `protobuf:"bytes,2,opt,name=session_update,json=sessionUpdate,proto3,oneof"`
+}
+
+type StateSyncResponse_SessionRemoved struct {
+	SessionRemoved *SessionRemoved `protobuf:"bytes,3,opt,name=session_removed,json=sessionRemoved,proto3,oneof"`
+}
+
+type StateSyncResponse_SessionEvent struct {
+	SessionEvent *SessionEvent `protobuf:"bytes,4,opt,name=session_event,json=sessionEvent,proto3,oneof"`
+}
+
+func (*StateSyncResponse_Snapshot) isStateSyncResponse_Update() {}
+
+func (*StateSyncResponse_SessionUpdate) isStateSyncResponse_Update() {}
+
+func (*StateSyncResponse_SessionRemoved) isStateSyncResponse_Update() {}
+
+func (*StateSyncResponse_SessionEvent) isStateSyncResponse_Update() {}
+
+// A single ACP event from a running session.
+type SessionEvent struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	Sequence  int64                  `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`  // Monotonic per session, assigned by worker
+	Timestamp string                 `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // RFC 3339
+	// Set on events of ephemeral sessions, which are not persisted.
+	Ephemeral bool `protobuf:"varint,4,opt,name=ephemeral,proto3" json:"ephemeral,omitempty"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*SessionEvent_AgentMessageChunk
+	//	*SessionEvent_AgentThoughtChunk
+	//	*SessionEvent_ToolCall
+	//	*SessionEvent_ToolCallUpdate
+	//	*SessionEvent_StatusChange
+	//	*SessionEvent_CurrentModeUpdate
+	//	*SessionEvent_UserMessage
+	//	*SessionEvent_CurrentModelUpdate
+	//	*SessionEvent_SessionError
+	//	*SessionEvent_PermissionRequest
+	//	*SessionEvent_PermissionResolved
+	//	*SessionEvent_EventsPruned
+	//	*SessionEvent_PlanSubmitted
+	//	*SessionEvent_McpServerStartup
+	//	*SessionEvent_Progress
+	//	*SessionEvent_TurnEnded
+	//	*SessionEvent_AgentPlan
+	//	*SessionEvent_SessionInit
+	Payload       isSessionEvent_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SessionEvent) Reset() {
+	*x = SessionEvent{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionEvent) ProtoMessage() {}
+
+func (x *SessionEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SessionEvent.ProtoReflect.Descriptor instead.
+func (*SessionEvent) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *SessionEvent) GetSessionId() string {
+	if x != nil {
+		return x.SessionId
+	}
+	return ""
+}
+
+func (x *SessionEvent) GetSequence() int64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+func (x *SessionEvent) GetTimestamp() string {
+	if x != nil {
+		return x.Timestamp
+	}
+	return ""
+}
+
+func (x *SessionEvent) GetEphemeral() bool {
+	if x != nil {
+		return x.Ephemeral
+	}
+	return false
+}
+
+func (x *SessionEvent) GetPayload() isSessionEvent_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetAgentMessageChunk() *AgentMessageChunk {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_AgentMessageChunk); ok {
+			return x.AgentMessageChunk
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetAgentThoughtChunk() *AgentThoughtChunk {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_AgentThoughtChunk); ok {
+			return x.AgentThoughtChunk
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetToolCall() *ToolCall {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_ToolCall); ok {
+			return x.ToolCall
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetToolCallUpdate() *ToolCallUpdate {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_ToolCallUpdate); ok {
+			return x.ToolCallUpdate
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetStatusChange() *StatusChange {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_StatusChange); ok {
+			return x.StatusChange
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetCurrentModeUpdate() *CurrentModeUpdate {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_CurrentModeUpdate); ok {
+			return x.CurrentModeUpdate
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetUserMessage() *UserMessage {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_UserMessage); ok {
+			return x.UserMessage
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetCurrentModelUpdate() *CurrentModelUpdate {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_CurrentModelUpdate); ok {
+			return x.CurrentModelUpdate
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetSessionError() *SessionError {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_SessionError); ok {
+			return x.SessionError
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetPermissionRequest() *PermissionRequest {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_PermissionRequest); ok {
+			return x.PermissionRequest
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetPermissionResolved() *PermissionResolved {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_PermissionResolved); ok {
+			return x.PermissionResolved
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetEventsPruned() *EventsPruned {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_EventsPruned); ok {
+			return x.EventsPruned
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetPlanSubmitted() *PlanSubmitted {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_PlanSubmitted); ok {
+			return x.PlanSubmitted
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetMcpServerStartup() *McpServerStartup {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_McpServerStartup); ok {
+			return x.McpServerStartup
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetProgress() *Progress {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetTurnEnded() *TurnEnded {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_TurnEnded); ok {
+			return x.TurnEnded
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetAgentPlan() *AgentPlan {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_AgentPlan); ok {
+			return x.AgentPlan
+		}
+	}
+	return nil
+}
+
+func (x *SessionEvent) GetSessionInit() *SessionInit {
+	if x != nil {
+		if x, ok := x.Payload.(*SessionEvent_SessionInit); ok {
+			return x.SessionInit
+		}
+	}
+	return nil
+}
+
+type isSessionEvent_Payload interface {
+	isSessionEvent_Payload()
+}
+
+type SessionEvent_AgentMessageChunk struct {
+	AgentMessageChunk *AgentMessageChunk `protobuf:"bytes,10,opt,name=agent_message_chunk,json=agentMessageChunk,proto3,oneof"`
+}
+
+type SessionEvent_AgentThoughtChunk struct {
+	AgentThoughtChunk *AgentThoughtChunk `protobuf:"bytes,11,opt,name=agent_thought_chunk,json=agentThoughtChunk,proto3,oneof"`
+}
+
+type SessionEvent_ToolCall struct {
+	ToolCall *ToolCall `protobuf:"bytes,12,opt,name=tool_call,json=toolCall,proto3,oneof"`
+}
+
+type SessionEvent_ToolCallUpdate struct {
+	ToolCallUpdate *ToolCallUpdate `protobuf:"bytes,13,opt,name=tool_call_update,json=toolCallUpdate,proto3,oneof"`
+}
+
+type SessionEvent_StatusChange struct {
+	StatusChange *StatusChange `protobuf:"bytes,14,opt,name=status_change,json=statusChange,proto3,oneof"`
+}
+
+type SessionEvent_CurrentModeUpdate struct {
+	CurrentModeUpdate *CurrentModeUpdate `protobuf:"bytes,15,opt,name=current_mode_update,json=currentModeUpdate,proto3,oneof"`
+}
+
+type SessionEvent_UserMessage struct {
+	UserMessage *UserMessage `protobuf:"bytes,16,opt,name=user_message,json=userMessage,proto3,oneof"`
+}
+
+type SessionEvent_CurrentModelUpdate struct {
+	CurrentModelUpdate *CurrentModelUpdate `protobuf:"bytes,17,opt,name=current_model_update,json=currentModelUpdate,proto3,oneof"`
+}
+
+type SessionEvent_SessionError struct {
+	SessionError *SessionError `protobuf:"bytes,18,opt,name=session_error,json=sessionError,proto3,oneof"`
+}
+
+type SessionEvent_PermissionRequest struct {
+	PermissionRequest *PermissionRequest `protobuf:"bytes,19,opt,name=permission_request,json=permissionRequest,proto3,oneof"`
+}
+
+type SessionEvent_PermissionResolved struct {
+	PermissionResolved *PermissionResolved `protobuf:"bytes,20,opt,name=permission_resolved,json=permissionResolved,proto3,oneof"`
+}
+
+type SessionEvent_EventsPruned struct {
+	EventsPruned *EventsPruned `protobuf:"bytes,21,opt,name=events_pruned,json=eventsPruned,proto3,oneof"`
+}
+
+type SessionEvent_PlanSubmitted struct {
+	PlanSubmitted *PlanSubmitted `protobuf:"bytes,22,opt,name=plan_submitted,json=planSubmitted,proto3,oneof"`
+}
+
+type SessionEvent_McpServerStartup struct {
+	McpServerStartup *McpServerStartup `protobuf:"bytes,23,opt,name=mcp_server_startup,json=mcpServerStartup,proto3,oneof"`
+}
+
+type SessionEvent_Progress struct {
+	Progress *Progress `protobuf:"bytes,24,opt,name=progress,proto3,oneof"`
+}
+
+type SessionEvent_TurnEnded struct {
+	TurnEnded *TurnEnded `protobuf:"bytes,25,opt,name=turn_ended,json=turnEnded,proto3,oneof"`
+}
+
+type SessionEvent_AgentPlan struct {
+	AgentPlan *AgentPlan `protobuf:"bytes,26,opt,name=agent_plan,json=agentPlan,proto3,oneof"`
+}
+
+type SessionEvent_SessionInit struct {
+	SessionInit *SessionInit `protobuf:"bytes,27,opt,name=session_init,json=sessionInit,proto3,oneof"`
+}
+
+func (*SessionEvent_AgentMessageChunk) isSessionEvent_Payload() {}
+
+func (*SessionEvent_AgentThoughtChunk) isSessionEvent_Payload() {}
+
+func (*SessionEvent_ToolCall) isSessionEvent_Payload() {}
+
+func (*SessionEvent_ToolCallUpdate) isSessionEvent_Payload() {}
+
+func (*SessionEvent_StatusChange) isSessionEvent_Payload() {}
+
+func (*SessionEvent_CurrentModeUpdate) isSessionEvent_Payload() {}
+
+func (*SessionEvent_UserMessage) isSessionEvent_Payload() {}
+
+func (*SessionEvent_CurrentModelUpdate) isSessionEvent_Payload() {}
+
+func (*SessionEvent_SessionError) isSessionEvent_Payload() {}
+
+func (*SessionEvent_PermissionRequest) isSessionEvent_Payload() {}
+
+func (*SessionEvent_PermissionResolved) isSessionEvent_Payload() {}
+
+func (*SessionEvent_EventsPruned) isSessionEvent_Payload() {}
+
+func (*SessionEvent_PlanSubmitted) isSessionEvent_Payload() {}
+
+func (*SessionEvent_McpServerStartup) isSessionEvent_Payload() {}
+
+func (*SessionEvent_Progress) isSessionEvent_Payload() {}
+
+func (*SessionEvent_TurnEnded) isSessionEvent_Payload() {}
+
+func (*SessionEvent_AgentPlan) isSessionEvent_Payload() {}
+
+func (*SessionEvent_SessionInit) isSessionEvent_Payload() {}
+
+type AgentMessageChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentMessageChunk) Reset() {
+	*x = AgentMessageChunk{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentMessageChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentMessageChunk) ProtoMessage() {}
+
+func (x *AgentMessageChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentMessageChunk.ProtoReflect.Descriptor instead.
+func (*AgentMessageChunk) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *AgentMessageChunk) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type AgentThoughtChunk struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentThoughtChunk) Reset() {
+	*x = AgentThoughtChunk{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentThoughtChunk) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentThoughtChunk) ProtoMessage() {}
+
+func (x *AgentThoughtChunk) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentThoughtChunk.ProtoReflect.Descriptor instead.
+func (*AgentThoughtChunk) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *AgentThoughtChunk) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+type UserMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Text  string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	// Set on prompts the worker sent by itself to let the agent continue,
+	// numbering them from 1 since the last prompt from the user.
+	AutoContinueIteration int32 `protobuf:"varint,2,opt,name=auto_continue_iteration,json=autoContinueIteration,proto3" json:"auto_continue_iteration,omitempty"`
+	unknownFields         protoimpl.UnknownFields
+	sizeCache             protoimpl.SizeCache
+}
+
+func (x *UserMessage) Reset() {
+	*x = UserMessage{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UserMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UserMessage) ProtoMessage() {}
+
+func (x *UserMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UserMessage.ProtoReflect.Descriptor instead.
+func (*UserMessage) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *UserMessage) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *UserMessage) GetAutoContinueIteration() int32 {
+	if x != nil {
+		return x.AutoContinueIteration
+	}
+	return 0
+}
+
+type ToolCall struct {
+	state      protoimpl.MessageState  `protogen:"open.v1"`
+	ToolCallId string                  `protobuf:"bytes,1,opt,name=tool_call_id,json=toolCallId,proto3" json:"tool_call_id,omitempty"`
+	Title      string                  `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Kind       ToolCallKind            `protobuf:"varint,3,opt,name=kind,proto3,enum=worker.v1.ToolCallKind" json:"kind,omitempty"`
+	RawInput   string                  `protobuf:"bytes,4,opt,name=raw_input,json=rawInput,proto3" json:"raw_input,omitempty"`
+	Locations  []*ToolCallLocation     `protobuf:"bytes,5,rep,name=locations,proto3" json:"locations,omitempty"`
+	Status     ToolCallStatus          `protobuf:"varint,6,opt,name=status,proto3,enum=worker.v1.ToolCallStatus" json:"status,omitempty"`
+	Content    []*ToolCallContentBlock `protobuf:"bytes,7,rep,name=content,proto3" json:"content,omitempty"`
+	Input      *ToolInput              `protobuf:"bytes,8,opt,name=input,proto3" json:"input,omitempty"`
+	// Free-form rendering hints from the agent's ACP _meta, flattened to
+	// dotted keys such as "claudeCode.toolName".
+	Metadata      map[string]string `protobuf:"bytes,9,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolCall) Reset() {
+	*x = ToolCall{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolCall) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolCall) ProtoMessage() {}
+
+func (x *ToolCall) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolCall.ProtoReflect.Descriptor instead.
+func (*ToolCall) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *ToolCall) GetToolCallId() string {
+	if x != nil {
+		return x.ToolCallId
+	}
+	return ""
+}
+
+func (x *ToolCall) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *ToolCall) GetKind() ToolCallKind {
+	if x != nil {
+		return x.Kind
+	}
+	return ToolCallKind_TOOL_CALL_KIND_UNSPECIFIED
+}
+
+func (x *ToolCall) GetRawInput() string {
+	if x != nil {
+		return x.RawInput
+	}
+	return ""
+}
+
+func (x *ToolCall) GetLocations() []*ToolCallLocation {
+	if x != nil {
+		return x.Locations
+	}
+	return nil
+}
+
+func (x *ToolCall) GetStatus() ToolCallStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ToolCallStatus_TOOL_CALL_STATUS_UNSPECIFIED
+}
+
+func (x *ToolCall) GetContent() []*ToolCallContentBlock {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *ToolCall) GetInput() *ToolInput {
+	if x != nil {
+		return x.Input
+	}
+	return nil
+}
+
+func (x *ToolCall) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+type ToolCallUpdate struct {
+	state      protoimpl.MessageState  `protogen:"open.v1"`
+	ToolCallId string                  `protobuf:"bytes,1,opt,name=tool_call_id,json=toolCallId,proto3" json:"tool_call_id,omitempty"`
+	Title      string                  `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Status     ToolCallStatus          `protobuf:"varint,3,opt,name=status,proto3,enum=worker.v1.ToolCallStatus" json:"status,omitempty"`
+	RawOutput  string                  `protobuf:"bytes,4,opt,name=raw_output,json=rawOutput,proto3" json:"raw_output,omitempty"`
+	Locations  []*ToolCallLocation     `protobuf:"bytes,5,rep,name=locations,proto3" json:"locations,omitempty"`
+	Content    []*ToolCallContentBlock `protobuf:"bytes,6,rep,name=content,proto3" json:"content,omitempty"`
+	Input      *ToolInput              `protobuf:"bytes,7,opt,name=input,proto3" json:"input,omitempty"`
+	// Free-form rendering hints from the agent's ACP _meta, flattened to
+	// dotted keys such as "claudeCode.toolName".
+	Metadata map[string]string `protobuf:"bytes,8,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Set when the worker cut raw_output to its limit: the full output's
+	// length in bytes and hex SHA-256.
+	RawOutputSize   int64  `protobuf:"varint,9,opt,name=raw_output_size,json=rawOutputSize,proto3" json:"raw_output_size,omitempty"`
+	RawOutputSha256 string `protobuf:"bytes,10,opt,name=raw_output_sha256,json=rawOutputSha256,proto3" json:"raw_output_sha256,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ToolCallUpdate) Reset() {
+	*x = ToolCallUpdate{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolCallUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolCallUpdate) ProtoMessage() {}
+
+func (x *ToolCallUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolCallUpdate.ProtoReflect.Descriptor instead.
+func (*ToolCallUpdate) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{29}
+}
+
+func (x *ToolCallUpdate) GetToolCallId() string {
+	if x != nil {
+		return x.ToolCallId
+	}
+	return ""
+}
+
+func (x *ToolCallUpdate) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *ToolCallUpdate) GetStatus() ToolCallStatus {
+	if x != nil {
+		return x.Status
+	}
+	return ToolCallStatus_TOOL_CALL_STATUS_UNSPECIFIED
+}
+
+func (x *ToolCallUpdate) GetRawOutput() string {
+	if x != nil {
+		return x.RawOutput
+	}
+	return ""
+}
+
+func (x *ToolCallUpdate) GetLocations() []*ToolCallLocation {
+	if x != nil {
+		return x.Locations
+	}
+	return nil
+}
+
+func (x *ToolCallUpdate) GetContent() []*ToolCallContentBlock {
+	if x != nil {
+		return x.Content
+	}
+	return nil
+}
+
+func (x *ToolCallUpdate) GetInput() *ToolInput {
+	if x != nil {
+		return x.Input
+	}
+	return nil
+}
+
+func (x *ToolCallUpdate) GetMetadata() map[string]string {
+	if x != nil {
+		return x.Metadata
+	}
+	return nil
+}
+
+func (x *ToolCallUpdate) GetRawOutputSize() int64 {
+	if x != nil {
+		return x.RawOutputSize
+	}
+	return 0
+}
+
+func (x *ToolCallUpdate) GetRawOutputSha256() string {
+	if x != nil {
+		return x.RawOutputSha256
+	}
+	return ""
+}
+
+type ToolCallContentBlock struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Block:
+	//
+	//	*ToolCallContentBlock_Diff
+	//	*ToolCallContentBlock_Text
+	//	*ToolCallContentBlock_CommandOutput
+	Block         isToolCallContentBlock_Block `protobuf_oneof:"block"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolCallContentBlock) Reset() {
+	*x = ToolCallContentBlock{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolCallContentBlock) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolCallContentBlock) ProtoMessage() {}
+
+func (x *ToolCallContentBlock) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolCallContentBlock.ProtoReflect.Descriptor instead.
+func (*ToolCallContentBlock) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *ToolCallContentBlock) GetBlock() isToolCallContentBlock_Block {
+	if x != nil {
+		return x.Block
+	}
+	return nil
+}
+
+func (x *ToolCallContentBlock) GetDiff() *ToolCallDiff {
+	if x != nil {
+		if x, ok := x.Block.(*ToolCallContentBlock_Diff); ok {
+			return x.Diff
+		}
+	}
+	return nil
+}
+
+func (x *ToolCallContentBlock) GetText() *ToolCallText {
+	if x != nil {
+		if x, ok := x.Block.(*ToolCallContentBlock_Text); ok {
+			return x.Text
+		}
+	}
+	return nil
+}
+
+func (x *ToolCallContentBlock) GetCommandOutput() *ToolCallCommandOutput {
+	if x != nil {
+		if x, ok := x.Block.(*ToolCallContentBlock_CommandOutput); ok {
+			return x.CommandOutput
+		}
+	}
+	return nil
+}
+
+type isToolCallContentBlock_Block interface {
+	isToolCallContentBlock_Block()
+}
+
+type ToolCallContentBlock_Diff struct {
+	Diff *ToolCallDiff `protobuf:"bytes,1,opt,name=diff,proto3,oneof"`
+}
+
+type ToolCallContentBlock_Text struct {
+	Text *ToolCallText `protobuf:"bytes,2,opt,name=text,proto3,oneof"`
+}
+
+type ToolCallContentBlock_CommandOutput struct {
+	CommandOutput *ToolCallCommandOutput `protobuf:"bytes,3,opt,name=command_output,json=commandOutput,proto3,oneof"`
+}
+
+func (*ToolCallContentBlock_Diff) isToolCallContentBlock_Block() {}
+
+func (*ToolCallContentBlock_Text) isToolCallContentBlock_Block() {}
+
+func (*ToolCallContentBlock_CommandOutput) isToolCallContentBlock_Block() {}
+
+type ToolCallDiff struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	NewText       string                 `protobuf:"bytes,2,opt,name=new_text,json=newText,proto3" json:"new_text,omitempty"`
+	OldText       string                 `protobuf:"bytes,3,opt,name=old_text,json=oldText,proto3" json:"old_text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolCallDiff) Reset() {
+	*x = ToolCallDiff{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolCallDiff) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolCallDiff) ProtoMessage() {}
+
+func (x *ToolCallDiff) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolCallDiff.ProtoReflect.Descriptor instead.
+func (*ToolCallDiff) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *ToolCallDiff) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ToolCallDiff) GetNewText() string {
+	if x != nil {
+		return x.NewText
+	}
+	return ""
+}
+
+func (x *ToolCallDiff) GetOldText() string {
+	if x != nil {
+		return x.OldText
+	}
+	return ""
+}
+
+type ToolCallText struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolCallText) Reset() {
+	*x = ToolCallText{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[32]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolCallText) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolCallText) ProtoMessage() {}
+
+func (x *ToolCallText) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[32]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolCallText.ProtoReflect.Descriptor instead.
+func (*ToolCallText) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{32}
+}
+
+func (x *ToolCallText) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+// Structured output of an execute-kind tool call.
+type ToolCallCommandOutput struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Stdout        string                 `protobuf:"bytes,1,opt,name=stdout,proto3" json:"stdout,omitempty"`
+	Stderr        string                 `protobuf:"bytes,2,opt,name=stderr,proto3" json:"stderr,omitempty"`
+	ExitCode      *int32                 `protobuf:"varint,3,opt,name=exit_code,json=exitCode,proto3,oneof" json:"exit_code,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolCallCommandOutput) Reset() {
+	*x = ToolCallCommandOutput{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[33]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolCallCommandOutput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolCallCommandOutput) ProtoMessage() {}
+
+func (x *ToolCallCommandOutput) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[33]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolCallCommandOutput.ProtoReflect.Descriptor instead.
+func (*ToolCallCommandOutput) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{33}
+}
+
+func (x *ToolCallCommandOutput) GetStdout() string {
+	if x != nil {
+		return x.Stdout
+	}
+	return ""
+}
+
+func (x *ToolCallCommandOutput) GetStderr() string {
+	if x != nil {
+		return x.Stderr
+	}
+	return ""
+}
+
+func (x *ToolCallCommandOutput) GetExitCode() int32 {
+	if x != nil && x.ExitCode != nil {
+		return *x.ExitCode
+	}
+	return 0
+}
+
+// Structured input of a well-known tool, parsed from raw_input so clients
+// need not re-parse it. Unset for other tools.
+type ToolInput struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Tool:
+	//
+	//	*ToolInput_Read
+	//	*ToolInput_Write
+	//	*ToolInput_Edit
+	//	*ToolInput_Bash
+	//	*ToolInput_Grep
+	//	*ToolInput_Glob
+	Tool          isToolInput_Tool `protobuf_oneof:"tool"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolInput) Reset() {
+	*x = ToolInput{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[34]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolInput) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolInput) ProtoMessage() {}
+
+func (x *ToolInput) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[34]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolInput.ProtoReflect.Descriptor instead.
+func (*ToolInput) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{34}
+}
+
+func (x *ToolInput) GetTool() isToolInput_Tool {
+	if x != nil {
+		return x.Tool
+	}
+	return nil
+}
+
+func (x *ToolInput) GetRead() *ToolInputRead {
+	if x != nil {
+		if x, ok := x.Tool.(*ToolInput_Read); ok {
+			return x.Read
+		}
+	}
+	return nil
+}
+
+func (x *ToolInput) GetWrite() *ToolInputWrite {
+	if x != nil {
+		if x, ok := x.Tool.(*ToolInput_Write); ok {
+			return x.Write
+		}
+	}
+	return nil
+}
+
+func (x *ToolInput) GetEdit() *ToolInputEdit {
+	if x != nil {
+		if x, ok := x.Tool.(*ToolInput_Edit); ok {
+			return x.Edit
+		}
+	}
+	return nil
+}
+
+func (x *ToolInput) GetBash() *ToolInputBash {
+	if x != nil {
+		if x, ok := x.Tool.(*ToolInput_Bash); ok {
+			return x.Bash
+		}
+	}
+	return nil
+}
+
+func (x *ToolInput) GetGrep() *ToolInputGrep {
+	if x != nil {
+		if x, ok := x.Tool.(*ToolInput_Grep); ok {
+			return x.Grep
+		}
+	}
+	return nil
+}
+
+func (x *ToolInput) GetGlob() *ToolInputGlob {
+	if x != nil {
+		if x, ok := x.Tool.(*ToolInput_Glob); ok {
+			return x.Glob
+		}
+	}
+	return nil
+}
+
+type isToolInput_Tool interface {
+	isToolInput_Tool()
+}
+
+type ToolInput_Read struct {
+	Read *ToolInputRead `protobuf:"bytes,1,opt,name=read,proto3,oneof"`
+}
+
+type ToolInput_Write struct {
+	Write *ToolInputWrite `protobuf:"bytes,2,opt,name=write,proto3,oneof"`
+}
+
+type ToolInput_Edit struct {
+	Edit *ToolInputEdit `protobuf:"bytes,3,opt,name=edit,proto3,oneof"`
+}
+
+type ToolInput_Bash struct {
+	Bash *ToolInputBash `protobuf:"bytes,4,opt,name=bash,proto3,oneof"`
+}
+
+type ToolInput_Grep struct {
+	Grep *ToolInputGrep `protobuf:"bytes,5,opt,name=grep,proto3,oneof"`
+}
+
+type ToolInput_Glob struct {
+	Glob *ToolInputGlob `protobuf:"bytes,6,opt,name=glob,proto3,oneof"`
+}
+
+func (*ToolInput_Read) isToolInput_Tool() {}
+
+func (*ToolInput_Write) isToolInput_Tool() {}
+
+func (*ToolInput_Edit) isToolInput_Tool() {}
+
+func (*ToolInput_Bash) isToolInput_Tool() {}
+
+func (*ToolInput_Grep) isToolInput_Tool() {}
+
+func (*ToolInput_Glob) isToolInput_Tool() {}
+
+type ToolInputRead struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FilePath      string                 `protobuf:"bytes,1,opt,name=file_path,json=filePath,proto3" json:"file_path,omitempty"`
+	Offset        *int64                 `protobuf:"varint,2,opt,name=offset,proto3,oneof" json:"offset,omitempty"`
+	Limit         *int64                 `protobuf:"varint,3,opt,name=limit,proto3,oneof" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolInputRead) Reset() {
+	*x = ToolInputRead{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[35]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolInputRead) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolInputRead) ProtoMessage() {}
+
+func (x *ToolInputRead) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[35]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolInputRead.ProtoReflect.Descriptor instead.
+func (*ToolInputRead) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{35}
+}
+
+func (x *ToolInputRead) GetFilePath() string {
+	if x != nil {
+		return x.FilePath
+	}
+	return ""
+}
+
+func (x *ToolInputRead) GetOffset() int64 {
+	if x != nil && x.Offset != nil {
+		return *x.Offset
+	}
+	return 0
+}
+
+func (x *ToolInputRead) GetLimit() int64 {
+	if x != nil && x.Limit != nil {
+		return *x.Limit
+	}
+	return 0
+}
+
+type ToolInputWrite struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FilePath      string                 `protobuf:"bytes,1,opt,name=file_path,json=filePath,proto3" json:"file_path,omitempty"`
+	Content       string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-func (x *StateSyncRequest) Reset() {
-	*x = StateSyncRequest{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[12]
+func (x *ToolInputWrite) Reset() {
+	*x = ToolInputWrite{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[36]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StateSyncRequest) String() string {
+func (x *ToolInputWrite) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StateSyncRequest) ProtoMessage() {}
+func (*ToolInputWrite) ProtoMessage() {}
 
-func (x *StateSyncRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[12]
+func (x *ToolInputWrite) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[36]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -995,54 +3200,378 @@ func (x *StateSyncRequest) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StateSyncRequest.ProtoReflect.Descriptor instead.
-func (*StateSyncRequest) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{12}
+// Deprecated: Use ToolInputWrite.ProtoReflect.Descriptor instead.
+func (*ToolInputWrite) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{36}
 }
 
-func (x *StateSyncRequest) GetAckSessionId() string {
+func (x *ToolInputWrite) GetFilePath() string {
 	if x != nil {
-		return x.AckSessionId
+		return x.FilePath
 	}
 	return ""
 }
 
-func (x *StateSyncRequest) GetAckSequence() int64 {
+func (x *ToolInputWrite) GetContent() string {
 	if x != nil {
-		return x.AckSequence
+		return x.Content
+	}
+	return ""
+}
+
+type ToolInputEdit struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FilePath      string                 `protobuf:"bytes,1,opt,name=file_path,json=filePath,proto3" json:"file_path,omitempty"`
+	OldString     string                 `protobuf:"bytes,2,opt,name=old_string,json=oldString,proto3" json:"old_string,omitempty"`
+	NewString     string                 `protobuf:"bytes,3,opt,name=new_string,json=newString,proto3" json:"new_string,omitempty"`
+	ReplaceAll    bool                   `protobuf:"varint,4,opt,name=replace_all,json=replaceAll,proto3" json:"replace_all,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolInputEdit) Reset() {
+	*x = ToolInputEdit{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[37]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolInputEdit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolInputEdit) ProtoMessage() {}
+
+func (x *ToolInputEdit) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[37]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolInputEdit.ProtoReflect.Descriptor instead.
+func (*ToolInputEdit) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{37}
+}
+
+func (x *ToolInputEdit) GetFilePath() string {
+	if x != nil {
+		return x.FilePath
+	}
+	return ""
+}
+
+func (x *ToolInputEdit) GetOldString() string {
+	if x != nil {
+		return x.OldString
+	}
+	return ""
+}
+
+func (x *ToolInputEdit) GetNewString() string {
+	if x != nil {
+		return x.NewString
+	}
+	return ""
+}
+
+func (x *ToolInputEdit) GetReplaceAll() bool {
+	if x != nil {
+		return x.ReplaceAll
+	}
+	return false
+}
+
+type ToolInputBash struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Command         string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	Description     string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	TimeoutMs       *int64                 `protobuf:"varint,3,opt,name=timeout_ms,json=timeoutMs,proto3,oneof" json:"timeout_ms,omitempty"`
+	RunInBackground bool                   `protobuf:"varint,4,opt,name=run_in_background,json=runInBackground,proto3" json:"run_in_background,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ToolInputBash) Reset() {
+	*x = ToolInputBash{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[38]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolInputBash) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolInputBash) ProtoMessage() {}
+
+func (x *ToolInputBash) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[38]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolInputBash.ProtoReflect.Descriptor instead.
+func (*ToolInputBash) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{38}
+}
+
+func (x *ToolInputBash) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *ToolInputBash) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *ToolInputBash) GetTimeoutMs() int64 {
+	if x != nil && x.TimeoutMs != nil {
+		return *x.TimeoutMs
 	}
 	return 0
 }
 
-// Worker → CP
-type StateSyncResponse struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// Types that are valid to be assigned to Update:
-	//
-	//	*StateSyncResponse_Snapshot
-	//	*StateSyncResponse_SessionUpdate
-	//	*StateSyncResponse_SessionRemoved
-	//	*StateSyncResponse_SessionEvent
-	Update        isStateSyncResponse_Update `protobuf_oneof:"update"`
+func (x *ToolInputBash) GetRunInBackground() bool {
+	if x != nil {
+		return x.RunInBackground
+	}
+	return false
+}
+
+type ToolInputGrep struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Pattern         string                 `protobuf:"bytes,1,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	Path            string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
+	Glob            string                 `protobuf:"bytes,3,opt,name=glob,proto3" json:"glob,omitempty"`
+	Type            string                 `protobuf:"bytes,4,opt,name=type,proto3" json:"type,omitempty"`
+	OutputMode      string                 `protobuf:"bytes,5,opt,name=output_mode,json=outputMode,proto3" json:"output_mode,omitempty"`
+	CaseInsensitive bool                   `protobuf:"varint,6,opt,name=case_insensitive,json=caseInsensitive,proto3" json:"case_insensitive,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ToolInputGrep) Reset() {
+	*x = ToolInputGrep{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[39]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolInputGrep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolInputGrep) ProtoMessage() {}
+
+func (x *ToolInputGrep) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[39]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolInputGrep.ProtoReflect.Descriptor instead.
+func (*ToolInputGrep) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{39}
+}
+
+func (x *ToolInputGrep) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *ToolInputGrep) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ToolInputGrep) GetGlob() string {
+	if x != nil {
+		return x.Glob
+	}
+	return ""
+}
+
+func (x *ToolInputGrep) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *ToolInputGrep) GetOutputMode() string {
+	if x != nil {
+		return x.OutputMode
+	}
+	return ""
+}
+
+func (x *ToolInputGrep) GetCaseInsensitive() bool {
+	if x != nil {
+		return x.CaseInsensitive
+	}
+	return false
+}
+
+type ToolInputGlob struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Pattern       string                 `protobuf:"bytes,1,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	Path          string                 `protobuf:"bytes,2,opt,name=path,proto3" json:"path,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StateSyncResponse) Reset() {
-	*x = StateSyncResponse{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[13]
+func (x *ToolInputGlob) Reset() {
+	*x = ToolInputGlob{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[40]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolInputGlob) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolInputGlob) ProtoMessage() {}
+
+func (x *ToolInputGlob) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[40]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolInputGlob.ProtoReflect.Descriptor instead.
+func (*ToolInputGlob) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{40}
+}
+
+func (x *ToolInputGlob) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *ToolInputGlob) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+type ToolCallLocation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
+	Line          int64                  `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ToolCallLocation) Reset() {
+	*x = ToolCallLocation{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[41]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ToolCallLocation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ToolCallLocation) ProtoMessage() {}
+
+func (x *ToolCallLocation) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[41]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ToolCallLocation.ProtoReflect.Descriptor instead.
+func (*ToolCallLocation) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{41}
+}
+
+func (x *ToolCallLocation) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ToolCallLocation) GetLine() int64 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+type StatusChange struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Status SessionStatus          `protobuf:"varint,1,opt,name=status,proto3,enum=worker.v1.SessionStatus" json:"status,omitempty"`
+	// Why the session failed; set when status is errored.
+	Error *SessionError `protobuf:"bytes,2,opt,name=error,proto3" json:"error,omitempty"`
+	// Whether the session takes a prompt now: true while idle and ready,
+	// false while it processes a prompt, is starting, stopping or has ended.
+	// Status alternates between running and idle with every turn while the
+	// session stays alive; clients enable their composer on this instead.
+	AcceptingInput bool `protobuf:"varint,3,opt,name=accepting_input,json=acceptingInput,proto3" json:"accepting_input,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *StatusChange) Reset() {
+	*x = StatusChange{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[42]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StateSyncResponse) String() string {
+func (x *StatusChange) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StateSyncResponse) ProtoMessage() {}
+func (*StatusChange) ProtoMessage() {}
 
-func (x *StateSyncResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[13]
+func (x *StatusChange) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[42]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1053,117 +3582,99 @@ func (x *StateSyncResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StateSyncResponse.ProtoReflect.Descriptor instead.
-func (*StateSyncResponse) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{13}
+// Deprecated: Use StatusChange.ProtoReflect.Descriptor instead.
+func (*StatusChange) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{42}
 }
 
-func (x *StateSyncResponse) GetUpdate() isStateSyncResponse_Update {
+func (x *StatusChange) GetStatus() SessionStatus {
 	if x != nil {
-		return x.Update
+		return x.Status
 	}
-	return nil
+	return SessionStatus_SESSION_STATUS_UNSPECIFIED
 }
 
-func (x *StateSyncResponse) GetSnapshot() *SessionStateSnapshot {
+func (x *StatusChange) GetError() *SessionError {
 	if x != nil {
-		if x, ok := x.Update.(*StateSyncResponse_Snapshot); ok {
-			return x.Snapshot
-		}
+		return x.Error
 	}
 	return nil
 }
 
-func (x *StateSyncResponse) GetSessionUpdate() *SessionState {
+func (x *StatusChange) GetAcceptingInput() bool {
 	if x != nil {
-		if x, ok := x.Update.(*StateSyncResponse_SessionUpdate); ok {
-			return x.SessionUpdate
-		}
+		return x.AcceptingInput
 	}
-	return nil
+	return false
 }
 
-func (x *StateSyncResponse) GetSessionRemoved() *SessionRemoved {
-	if x != nil {
-		if x, ok := x.Update.(*StateSyncResponse_SessionRemoved); ok {
-			return x.SessionRemoved
-		}
-	}
-	return nil
+type CurrentModeUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ModeId        string                 `protobuf:"bytes,1,opt,name=mode_id,json=modeId,proto3" json:"mode_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StateSyncResponse) GetSessionEvent() *SessionEvent {
-	if x != nil {
-		if x, ok := x.Update.(*StateSyncResponse_SessionEvent); ok {
-			return x.SessionEvent
-		}
-	}
-	return nil
+func (x *CurrentModeUpdate) Reset() {
+	*x = CurrentModeUpdate{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[43]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-type isStateSyncResponse_Update interface {
-	isStateSyncResponse_Update()
+func (x *CurrentModeUpdate) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-type StateSyncResponse_Snapshot struct {
-	Snapshot *SessionStateSnapshot `protobuf:"bytes,1,opt,name=snapshot,proto3,oneof"`
-}
+func (*CurrentModeUpdate) ProtoMessage() {}
 
-type StateSyncResponse_SessionUpdate struct {
-	SessionUpdate *SessionState `protobuf:"bytes,2,opt,name=session_update,json=sessionUpdate,proto3,oneof"`
+func (x *CurrentModeUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[43]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-type StateSyncResponse_SessionRemoved struct {
-	SessionRemoved *SessionRemoved `protobuf:"bytes,3,opt,name=session_removed,json=sessionRemoved,proto3,oneof"`
+// Deprecated: Use CurrentModeUpdate.ProtoReflect.Descriptor instead.
+func (*CurrentModeUpdate) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{43}
 }
 
-type StateSyncResponse_SessionEvent struct {
-	SessionEvent *SessionEvent `protobuf:"bytes,4,opt,name=session_event,json=sessionEvent,proto3,oneof"`
+func (x *CurrentModeUpdate) GetModeId() string {
+	if x != nil {
+		return x.ModeId
+	}
+	return ""
 }
 
-func (*StateSyncResponse_Snapshot) isStateSyncResponse_Update() {}
-
-func (*StateSyncResponse_SessionUpdate) isStateSyncResponse_Update() {}
-
-func (*StateSyncResponse_SessionRemoved) isStateSyncResponse_Update() {}
-
-func (*StateSyncResponse_SessionEvent) isStateSyncResponse_Update() {}
-
-// A single ACP event from a running session.
-type SessionEvent struct {
-	state     protoimpl.MessageState `protogen:"open.v1"`
-	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	Sequence  int64                  `protobuf:"varint,2,opt,name=sequence,proto3" json:"sequence,omitempty"`  // Monotonic per session, assigned by worker
-	Timestamp string                 `protobuf:"bytes,3,opt,name=timestamp,proto3" json:"timestamp,omitempty"` // RFC 3339
-	// Types that are valid to be assigned to Payload:
-	//
-	//	*SessionEvent_AgentMessageChunk
-	//	*SessionEvent_AgentThoughtChunk
-	//	*SessionEvent_ToolCall
-	//	*SessionEvent_ToolCallUpdate
-	//	*SessionEvent_StatusChange
-	//	*SessionEvent_CurrentModeUpdate
-	//	*SessionEvent_UserMessage
-	Payload       isSessionEvent_Payload `protobuf_oneof:"payload"`
+// The effective model of the session, resolved after session setup.
+type CurrentModelUpdate struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ModelId       string                 `protobuf:"bytes,1,opt,name=model_id,json=modelId,proto3" json:"model_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SessionEvent) Reset() {
-	*x = SessionEvent{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[14]
+func (x *CurrentModelUpdate) Reset() {
+	*x = CurrentModelUpdate{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[44]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *SessionEvent) String() string {
+func (x *CurrentModelUpdate) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*SessionEvent) ProtoMessage() {}
+func (*CurrentModelUpdate) ProtoMessage() {}
 
-func (x *SessionEvent) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[14]
+func (x *CurrentModelUpdate) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[44]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1174,170 +3685,176 @@ func (x *SessionEvent) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use SessionEvent.ProtoReflect.Descriptor instead.
-func (*SessionEvent) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{14}
+// Deprecated: Use CurrentModelUpdate.ProtoReflect.Descriptor instead.
+func (*CurrentModelUpdate) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{44}
 }
 
-func (x *SessionEvent) GetSessionId() string {
+func (x *CurrentModelUpdate) GetModelId() string {
 	if x != nil {
-		return x.SessionId
+		return x.ModelId
 	}
 	return ""
 }
 
-func (x *SessionEvent) GetSequence() int64 {
-	if x != nil {
-		return x.Sequence
-	}
-	return 0
+// Why a session failed. Emitted right before its errored status change.
+type SessionError struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Reason  SessionErrorReason     `protobuf:"varint,1,opt,name=reason,proto3,enum=worker.v1.SessionErrorReason" json:"reason,omitempty"`
+	Message string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	// The agent's last stderr lines before the failure, oldest first.
+	StderrTail    []string `protobuf:"bytes,3,rep,name=stderr_tail,json=stderrTail,proto3" json:"stderr_tail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-func (x *SessionEvent) GetTimestamp() string {
-	if x != nil {
-		return x.Timestamp
-	}
-	return ""
+func (x *SessionError) Reset() {
+	*x = SessionError{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[45]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-func (x *SessionEvent) GetPayload() isSessionEvent_Payload {
-	if x != nil {
-		return x.Payload
-	}
-	return nil
+func (x *SessionError) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-func (x *SessionEvent) GetAgentMessageChunk() *AgentMessageChunk {
-	if x != nil {
-		if x, ok := x.Payload.(*SessionEvent_AgentMessageChunk); ok {
-			return x.AgentMessageChunk
-		}
-	}
-	return nil
-}
+func (*SessionError) ProtoMessage() {}
 
-func (x *SessionEvent) GetAgentThoughtChunk() *AgentThoughtChunk {
+func (x *SessionError) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[45]
 	if x != nil {
-		if x, ok := x.Payload.(*SessionEvent_AgentThoughtChunk); ok {
-			return x.AgentThoughtChunk
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
 		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *SessionEvent) GetToolCall() *ToolCall {
-	if x != nil {
-		if x, ok := x.Payload.(*SessionEvent_ToolCall); ok {
-			return x.ToolCall
-		}
-	}
-	return nil
+// Deprecated: Use SessionError.ProtoReflect.Descriptor instead.
+func (*SessionError) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{45}
 }
 
-func (x *SessionEvent) GetToolCallUpdate() *ToolCallUpdate {
+func (x *SessionError) GetReason() SessionErrorReason {
 	if x != nil {
-		if x, ok := x.Payload.(*SessionEvent_ToolCallUpdate); ok {
-			return x.ToolCallUpdate
-		}
+		return x.Reason
 	}
-	return nil
+	return SessionErrorReason_SESSION_ERROR_REASON_UNSPECIFIED
 }
 
-func (x *SessionEvent) GetStatusChange() *StatusChange {
+func (x *SessionError) GetMessage() string {
 	if x != nil {
-		if x, ok := x.Payload.(*SessionEvent_StatusChange); ok {
-			return x.StatusChange
-		}
+		return x.Message
 	}
-	return nil
+	return ""
 }
 
-func (x *SessionEvent) GetCurrentModeUpdate() *CurrentModeUpdate {
+func (x *SessionError) GetStderrTail() []string {
 	if x != nil {
-		if x, ok := x.Payload.(*SessionEvent_CurrentModeUpdate); ok {
-			return x.CurrentModeUpdate
-		}
+		return x.StderrTail
 	}
 	return nil
 }
 
-func (x *SessionEvent) GetUserMessage() *UserMessage {
-	if x != nil {
-		if x, ok := x.Payload.(*SessionEvent_UserMessage); ok {
-			return x.UserMessage
-		}
-	}
-	return nil
+// An agent asked for permission to run a tool. request_id is the ID of the
+// tool call awaiting approval.
+type PermissionRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Kind          ToolCallKind           `protobuf:"varint,3,opt,name=kind,proto3,enum=worker.v1.ToolCallKind" json:"kind,omitempty"`
+	Options       []*PermissionOption    `protobuf:"bytes,4,rep,name=options,proto3" json:"options,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
-type isSessionEvent_Payload interface {
-	isSessionEvent_Payload()
+func (x *PermissionRequest) Reset() {
+	*x = PermissionRequest{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[46]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
 }
 
-type SessionEvent_AgentMessageChunk struct {
-	AgentMessageChunk *AgentMessageChunk `protobuf:"bytes,10,opt,name=agent_message_chunk,json=agentMessageChunk,proto3,oneof"`
+func (x *PermissionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
 }
 
-type SessionEvent_AgentThoughtChunk struct {
-	AgentThoughtChunk *AgentThoughtChunk `protobuf:"bytes,11,opt,name=agent_thought_chunk,json=agentThoughtChunk,proto3,oneof"`
-}
+func (*PermissionRequest) ProtoMessage() {}
 
-type SessionEvent_ToolCall struct {
-	ToolCall *ToolCall `protobuf:"bytes,12,opt,name=tool_call,json=toolCall,proto3,oneof"`
+func (x *PermissionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[46]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-type SessionEvent_ToolCallUpdate struct {
-	ToolCallUpdate *ToolCallUpdate `protobuf:"bytes,13,opt,name=tool_call_update,json=toolCallUpdate,proto3,oneof"`
+// Deprecated: Use PermissionRequest.ProtoReflect.Descriptor instead.
+func (*PermissionRequest) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{46}
 }
 
-type SessionEvent_StatusChange struct {
-	StatusChange *StatusChange `protobuf:"bytes,14,opt,name=status_change,json=statusChange,proto3,oneof"`
+func (x *PermissionRequest) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
 }
 
-type SessionEvent_CurrentModeUpdate struct {
-	CurrentModeUpdate *CurrentModeUpdate `protobuf:"bytes,15,opt,name=current_mode_update,json=currentModeUpdate,proto3,oneof"`
+func (x *PermissionRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
 }
 
-type SessionEvent_UserMessage struct {
-	UserMessage *UserMessage `protobuf:"bytes,16,opt,name=user_message,json=userMessage,proto3,oneof"`
+func (x *PermissionRequest) GetKind() ToolCallKind {
+	if x != nil {
+		return x.Kind
+	}
+	return ToolCallKind_TOOL_CALL_KIND_UNSPECIFIED
 }
 
-func (*SessionEvent_AgentMessageChunk) isSessionEvent_Payload() {}
-
-func (*SessionEvent_AgentThoughtChunk) isSessionEvent_Payload() {}
-
-func (*SessionEvent_ToolCall) isSessionEvent_Payload() {}
-
-func (*SessionEvent_ToolCallUpdate) isSessionEvent_Payload() {}
-
-func (*SessionEvent_StatusChange) isSessionEvent_Payload() {}
-
-func (*SessionEvent_CurrentModeUpdate) isSessionEvent_Payload() {}
-
-func (*SessionEvent_UserMessage) isSessionEvent_Payload() {}
+func (x *PermissionRequest) GetOptions() []*PermissionOption {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
 
-type AgentMessageChunk struct {
+// kind is the ACP option kind: "allow_once", "allow_always", "reject_once"
+// or "reject_always".
+type PermissionOption struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	OptionId      string                 `protobuf:"bytes,1,opt,name=option_id,json=optionId,proto3" json:"option_id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Kind          string                 `protobuf:"bytes,3,opt,name=kind,proto3" json:"kind,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AgentMessageChunk) Reset() {
-	*x = AgentMessageChunk{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[15]
+func (x *PermissionOption) Reset() {
+	*x = PermissionOption{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[47]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AgentMessageChunk) String() string {
+func (x *PermissionOption) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AgentMessageChunk) ProtoMessage() {}
+func (*PermissionOption) ProtoMessage() {}
 
-func (x *AgentMessageChunk) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[15]
+func (x *PermissionOption) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[47]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1348,40 +3865,59 @@ func (x *AgentMessageChunk) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AgentMessageChunk.ProtoReflect.Descriptor instead.
-func (*AgentMessageChunk) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{15}
+// Deprecated: Use PermissionOption.ProtoReflect.Descriptor instead.
+func (*PermissionOption) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{47}
 }
 
-func (x *AgentMessageChunk) GetText() string {
+func (x *PermissionOption) GetOptionId() string {
 	if x != nil {
-		return x.Text
+		return x.OptionId
 	}
 	return ""
 }
 
-type AgentThoughtChunk struct {
+func (x *PermissionOption) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *PermissionOption) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+// How a permission request ended. outcome is "allowed", "auto_approved",
+// "denied" or "cancelled"; option_id is the selected option, empty unless
+// the tool was allowed.
+type PermissionResolved struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	RequestId     string                 `protobuf:"bytes,1,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	OptionId      string                 `protobuf:"bytes,2,opt,name=option_id,json=optionId,proto3" json:"option_id,omitempty"`
+	Outcome       string                 `protobuf:"bytes,3,opt,name=outcome,proto3" json:"outcome,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *AgentThoughtChunk) Reset() {
-	*x = AgentThoughtChunk{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[16]
+func (x *PermissionResolved) Reset() {
+	*x = PermissionResolved{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[48]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *AgentThoughtChunk) String() string {
+func (x *PermissionResolved) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*AgentThoughtChunk) ProtoMessage() {}
+func (*PermissionResolved) ProtoMessage() {}
 
-func (x *AgentThoughtChunk) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[16]
+func (x *PermissionResolved) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[48]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1392,40 +3928,57 @@ func (x *AgentThoughtChunk) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use AgentThoughtChunk.ProtoReflect.Descriptor instead.
-func (*AgentThoughtChunk) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{16}
+// Deprecated: Use PermissionResolved.ProtoReflect.Descriptor instead.
+func (*PermissionResolved) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{48}
 }
 
-func (x *AgentThoughtChunk) GetText() string {
+func (x *PermissionResolved) GetRequestId() string {
 	if x != nil {
-		return x.Text
+		return x.RequestId
 	}
 	return ""
 }
 
-type UserMessage struct {
+func (x *PermissionResolved) GetOptionId() string {
+	if x != nil {
+		return x.OptionId
+	}
+	return ""
+}
+
+func (x *PermissionResolved) GetOutcome() string {
+	if x != nil {
+		return x.Outcome
+	}
+	return ""
+}
+
+// The worker dropped count un-acknowledged events because the session's
+// retention limit was reached. The marker takes the sequence of the newest
+// dropped event, so events after it are contiguous.
+type EventsPruned struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	Count         int64                  `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UserMessage) Reset() {
-	*x = UserMessage{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[17]
+func (x *EventsPruned) Reset() {
+	*x = EventsPruned{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[49]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UserMessage) String() string {
+func (x *EventsPruned) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UserMessage) ProtoMessage() {}
+func (*EventsPruned) ProtoMessage() {}
 
-func (x *UserMessage) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[17]
+func (x *EventsPruned) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[49]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1436,46 +3989,47 @@ func (x *UserMessage) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UserMessage.ProtoReflect.Descriptor instead.
-func (*UserMessage) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{17}
+// Deprecated: Use EventsPruned.ProtoReflect.Descriptor instead.
+func (*EventsPruned) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{49}
 }
 
-func (x *UserMessage) GetText() string {
+func (x *EventsPruned) GetCount() int64 {
 	if x != nil {
-		return x.Text
+		return x.Count
 	}
-	return ""
+	return 0
 }
 
-type ToolCall struct {
-	state         protoimpl.MessageState  `protogen:"open.v1"`
-	ToolCallId    string                  `protobuf:"bytes,1,opt,name=tool_call_id,json=toolCallId,proto3" json:"tool_call_id,omitempty"`
-	Title         string                  `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	Kind          ToolCallKind            `protobuf:"varint,3,opt,name=kind,proto3,enum=worker.v1.ToolCallKind" json:"kind,omitempty"`
-	RawInput      string                  `protobuf:"bytes,4,opt,name=raw_input,json=rawInput,proto3" json:"raw_input,omitempty"`
-	Locations     []*ToolCallLocation     `protobuf:"bytes,5,rep,name=locations,proto3" json:"locations,omitempty"`
-	Status        ToolCallStatus          `protobuf:"varint,6,opt,name=status,proto3,enum=worker.v1.ToolCallStatus" json:"status,omitempty"`
-	Content       []*ToolCallContentBlock `protobuf:"bytes,7,rep,name=content,proto3" json:"content,omitempty"`
+// Progress of an MCP server the agent starts for the session. text is a
+// human-readable summary for clients that don't render it separately; ready
+// is set once the agent finished starting its MCP servers.
+type McpServerStartup struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Server        string                 `protobuf:"bytes,1,opt,name=server,proto3" json:"server,omitempty"`
+	Status        string                 `protobuf:"bytes,2,opt,name=status,proto3" json:"status,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	Ready         bool                   `protobuf:"varint,4,opt,name=ready,proto3" json:"ready,omitempty"`
+	Text          string                 `protobuf:"bytes,5,opt,name=text,proto3" json:"text,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ToolCall) Reset() {
-	*x = ToolCall{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[18]
+func (x *McpServerStartup) Reset() {
+	*x = McpServerStartup{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[50]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ToolCall) String() string {
+func (x *McpServerStartup) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ToolCall) ProtoMessage() {}
+func (*McpServerStartup) ProtoMessage() {}
 
-func (x *ToolCall) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[18]
+func (x *McpServerStartup) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[50]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1486,87 +4040,142 @@ func (x *ToolCall) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ToolCall.ProtoReflect.Descriptor instead.
-func (*ToolCall) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{18}
+// Deprecated: Use McpServerStartup.ProtoReflect.Descriptor instead.
+func (*McpServerStartup) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{50}
 }
 
-func (x *ToolCall) GetToolCallId() string {
+func (x *McpServerStartup) GetServer() string {
 	if x != nil {
-		return x.ToolCallId
+		return x.Server
 	}
 	return ""
 }
 
-func (x *ToolCall) GetTitle() string {
+func (x *McpServerStartup) GetStatus() string {
 	if x != nil {
-		return x.Title
+		return x.Status
 	}
 	return ""
 }
 
-func (x *ToolCall) GetKind() ToolCallKind {
+func (x *McpServerStartup) GetMessage() string {
 	if x != nil {
-		return x.Kind
+		return x.Message
 	}
-	return ToolCallKind_TOOL_CALL_KIND_UNSPECIFIED
+	return ""
 }
 
-func (x *ToolCall) GetRawInput() string {
+func (x *McpServerStartup) GetReady() bool {
 	if x != nil {
-		return x.RawInput
+		return x.Ready
+	}
+	return false
+}
+
+func (x *McpServerStartup) GetText() string {
+	if x != nil {
+		return x.Text
 	}
 	return ""
 }
 
-func (x *ToolCall) GetLocations() []*ToolCallLocation {
+// The configuration the agent reported it started the session with, which
+// may differ from the one requested. Sent once per session; text is a
+// human-readable summary for clients that don't render it separately.
+type SessionInit struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Model          string                 `protobuf:"bytes,1,opt,name=model,proto3" json:"model,omitempty"`
+	PermissionMode string                 `protobuf:"bytes,2,opt,name=permission_mode,json=permissionMode,proto3" json:"permission_mode,omitempty"`
+	Tools          []string               `protobuf:"bytes,3,rep,name=tools,proto3" json:"tools,omitempty"`
+	Text           string                 `protobuf:"bytes,4,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *SessionInit) Reset() {
+	*x = SessionInit{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[51]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SessionInit) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SessionInit) ProtoMessage() {}
+
+func (x *SessionInit) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[51]
 	if x != nil {
-		return x.Locations
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return nil
+	return mi.MessageOf(x)
 }
 
-func (x *ToolCall) GetStatus() ToolCallStatus {
+// Deprecated: Use SessionInit.ProtoReflect.Descriptor instead.
+func (*SessionInit) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{51}
+}
+
+func (x *SessionInit) GetModel() string {
 	if x != nil {
-		return x.Status
+		return x.Model
 	}
-	return ToolCallStatus_TOOL_CALL_STATUS_UNSPECIFIED
+	return ""
 }
 
-func (x *ToolCall) GetContent() []*ToolCallContentBlock {
+func (x *SessionInit) GetPermissionMode() string {
 	if x != nil {
-		return x.Content
+		return x.PermissionMode
+	}
+	return ""
+}
+
+func (x *SessionInit) GetTools() []string {
+	if x != nil {
+		return x.Tools
 	}
 	return nil
 }
 
-type ToolCallUpdate struct {
-	state         protoimpl.MessageState  `protogen:"open.v1"`
-	ToolCallId    string                  `protobuf:"bytes,1,opt,name=tool_call_id,json=toolCallId,proto3" json:"tool_call_id,omitempty"`
-	Title         string                  `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
-	Status        ToolCallStatus          `protobuf:"varint,3,opt,name=status,proto3,enum=worker.v1.ToolCallStatus" json:"status,omitempty"`
-	RawOutput     string                  `protobuf:"bytes,4,opt,name=raw_output,json=rawOutput,proto3" json:"raw_output,omitempty"`
-	Locations     []*ToolCallLocation     `protobuf:"bytes,5,rep,name=locations,proto3" json:"locations,omitempty"`
-	Content       []*ToolCallContentBlock `protobuf:"bytes,6,rep,name=content,proto3" json:"content,omitempty"`
+func (x *SessionInit) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+// The agent reported progress on a long task via the report_progress MCP
+// tool. percent is set if the agent gave one (0-100).
+type Progress struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Message       string                 `protobuf:"bytes,1,opt,name=message,proto3" json:"message,omitempty"`
+	Percent       *int32                 `protobuf:"varint,2,opt,name=percent,proto3,oneof" json:"percent,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ToolCallUpdate) Reset() {
-	*x = ToolCallUpdate{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[19]
+func (x *Progress) Reset() {
+	*x = Progress{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[52]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ToolCallUpdate) String() string {
+func (x *Progress) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ToolCallUpdate) ProtoMessage() {}
+func (*Progress) ProtoMessage() {}
 
-func (x *ToolCallUpdate) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[19]
+func (x *Progress) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[52]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1577,79 +4186,115 @@ func (x *ToolCallUpdate) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ToolCallUpdate.ProtoReflect.Descriptor instead.
-func (*ToolCallUpdate) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{19}
+// Deprecated: Use Progress.ProtoReflect.Descriptor instead.
+func (*Progress) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{52}
 }
 
-func (x *ToolCallUpdate) GetToolCallId() string {
+func (x *Progress) GetMessage() string {
 	if x != nil {
-		return x.ToolCallId
+		return x.Message
 	}
 	return ""
 }
 
-func (x *ToolCallUpdate) GetTitle() string {
-	if x != nil {
-		return x.Title
+func (x *Progress) GetPercent() int32 {
+	if x != nil && x.Percent != nil {
+		return *x.Percent
 	}
-	return ""
+	return 0
 }
 
-func (x *ToolCallUpdate) GetStatus() ToolCallStatus {
+// A prompt turn ended. cancel_reason is set if stop_reason is
+// STOP_REASON_CANCELLED. stats is set if the agent answered the prompt.
+type TurnEnded struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StopReason    StopReason             `protobuf:"varint,1,opt,name=stop_reason,json=stopReason,proto3,enum=worker.v1.StopReason" json:"stop_reason,omitempty"`
+	CancelReason  CancelReason           `protobuf:"varint,2,opt,name=cancel_reason,json=cancelReason,proto3,enum=worker.v1.CancelReason" json:"cancel_reason,omitempty"`
+	Stats         *TurnStats             `protobuf:"bytes,3,opt,name=stats,proto3" json:"stats,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TurnEnded) Reset() {
+	*x = TurnEnded{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[53]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TurnEnded) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TurnEnded) ProtoMessage() {}
+
+func (x *TurnEnded) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[53]
 	if x != nil {
-		return x.Status
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
 	}
-	return ToolCallStatus_TOOL_CALL_STATUS_UNSPECIFIED
+	return mi.MessageOf(x)
 }
 
-func (x *ToolCallUpdate) GetRawOutput() string {
+// Deprecated: Use TurnEnded.ProtoReflect.Descriptor instead.
+func (*TurnEnded) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{53}
+}
+
+func (x *TurnEnded) GetStopReason() StopReason {
 	if x != nil {
-		return x.RawOutput
+		return x.StopReason
 	}
-	return ""
+	return StopReason_STOP_REASON_UNSPECIFIED
 }
 
-func (x *ToolCallUpdate) GetLocations() []*ToolCallLocation {
+func (x *TurnEnded) GetCancelReason() CancelReason {
 	if x != nil {
-		return x.Locations
+		return x.CancelReason
 	}
-	return nil
+	return CancelReason_CANCEL_REASON_UNSPECIFIED
 }
 
-func (x *ToolCallUpdate) GetContent() []*ToolCallContentBlock {
+func (x *TurnEnded) GetStats() *TurnStats {
 	if x != nil {
-		return x.Content
+		return x.Stats
 	}
 	return nil
 }
 
-type ToolCallContentBlock struct {
-	state protoimpl.MessageState `protogen:"open.v1"`
-	// Types that are valid to be assigned to Block:
-	//
-	//	*ToolCallContentBlock_Diff
-	//	*ToolCallContentBlock_Text
-	Block         isToolCallContentBlock_Block `protobuf_oneof:"block"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+// Timing and usage of a prompt turn. time_to_first_token_ms is zero if the
+// agent sent no output; the token counts are zero if it doesn't report them.
+type TurnStats struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	DurationMs         int64                  `protobuf:"varint,1,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	TimeToFirstTokenMs int64                  `protobuf:"varint,2,opt,name=time_to_first_token_ms,json=timeToFirstTokenMs,proto3" json:"time_to_first_token_ms,omitempty"`
+	ToolCalls          int32                  `protobuf:"varint,3,opt,name=tool_calls,json=toolCalls,proto3" json:"tool_calls,omitempty"`
+	InputTokens        int64                  `protobuf:"varint,4,opt,name=input_tokens,json=inputTokens,proto3" json:"input_tokens,omitempty"`
+	OutputTokens       int64                  `protobuf:"varint,5,opt,name=output_tokens,json=outputTokens,proto3" json:"output_tokens,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
 }
 
-func (x *ToolCallContentBlock) Reset() {
-	*x = ToolCallContentBlock{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[20]
+func (x *TurnStats) Reset() {
+	*x = TurnStats{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[54]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ToolCallContentBlock) String() string {
+func (x *TurnStats) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ToolCallContentBlock) ProtoMessage() {}
+func (*TurnStats) ProtoMessage() {}
 
-func (x *ToolCallContentBlock) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[20]
+func (x *TurnStats) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[54]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1660,76 +4305,116 @@ func (x *ToolCallContentBlock) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ToolCallContentBlock.ProtoReflect.Descriptor instead.
-func (*ToolCallContentBlock) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{20}
+// Deprecated: Use TurnStats.ProtoReflect.Descriptor instead.
+func (*TurnStats) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{54}
 }
 
-func (x *ToolCallContentBlock) GetBlock() isToolCallContentBlock_Block {
+func (x *TurnStats) GetDurationMs() int64 {
 	if x != nil {
-		return x.Block
+		return x.DurationMs
 	}
-	return nil
+	return 0
 }
 
-func (x *ToolCallContentBlock) GetDiff() *ToolCallDiff {
+func (x *TurnStats) GetTimeToFirstTokenMs() int64 {
 	if x != nil {
-		if x, ok := x.Block.(*ToolCallContentBlock_Diff); ok {
-			return x.Diff
-		}
+		return x.TimeToFirstTokenMs
 	}
-	return nil
+	return 0
 }
 
-func (x *ToolCallContentBlock) GetText() *ToolCallText {
+func (x *TurnStats) GetToolCalls() int32 {
 	if x != nil {
-		if x, ok := x.Block.(*ToolCallContentBlock_Text); ok {
-			return x.Text
-		}
+		return x.ToolCalls
 	}
-	return nil
+	return 0
 }
 
-type isToolCallContentBlock_Block interface {
-	isToolCallContentBlock_Block()
+func (x *TurnStats) GetInputTokens() int64 {
+	if x != nil {
+		return x.InputTokens
+	}
+	return 0
 }
 
-type ToolCallContentBlock_Diff struct {
-	Diff *ToolCallDiff `protobuf:"bytes,1,opt,name=diff,proto3,oneof"`
+func (x *TurnStats) GetOutputTokens() int64 {
+	if x != nil {
+		return x.OutputTokens
+	}
+	return 0
 }
 
-type ToolCallContentBlock_Text struct {
-	Text *ToolCallText `protobuf:"bytes,2,opt,name=text,proto3,oneof"`
+// The agent's current execution plan. Each AgentPlan replaces the previous
+// one; entries is the complete plan with entry statuses merged.
+type AgentPlan struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Entries       []*AgentPlanEntry      `protobuf:"bytes,1,rep,name=entries,proto3" json:"entries,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentPlan) Reset() {
+	*x = AgentPlan{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[55]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentPlan) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentPlan) ProtoMessage() {}
+
+func (x *AgentPlan) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[55]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentPlan.ProtoReflect.Descriptor instead.
+func (*AgentPlan) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{55}
+}
+
+func (x *AgentPlan) GetEntries() []*AgentPlanEntry {
+	if x != nil {
+		return x.Entries
+	}
+	return nil
 }
 
-func (*ToolCallContentBlock_Diff) isToolCallContentBlock_Block() {}
-
-func (*ToolCallContentBlock_Text) isToolCallContentBlock_Block() {}
-
-type ToolCallDiff struct {
+type AgentPlanEntry struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
-	NewText       string                 `protobuf:"bytes,2,opt,name=new_text,json=newText,proto3" json:"new_text,omitempty"`
-	OldText       string                 `protobuf:"bytes,3,opt,name=old_text,json=oldText,proto3" json:"old_text,omitempty"`
+	Content       string                 `protobuf:"bytes,1,opt,name=content,proto3" json:"content,omitempty"`
+	Priority      string                 `protobuf:"bytes,2,opt,name=priority,proto3" json:"priority,omitempty"` // "high", "medium" or "low"
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`     // "pending", "in_progress" or "completed"
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ToolCallDiff) Reset() {
-	*x = ToolCallDiff{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[21]
+func (x *AgentPlanEntry) Reset() {
+	*x = AgentPlanEntry{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[56]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ToolCallDiff) String() string {
+func (x *AgentPlanEntry) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ToolCallDiff) ProtoMessage() {}
+func (*AgentPlanEntry) ProtoMessage() {}
 
-func (x *ToolCallDiff) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[21]
+func (x *AgentPlanEntry) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[56]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1740,54 +4425,55 @@ func (x *ToolCallDiff) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ToolCallDiff.ProtoReflect.Descriptor instead.
-func (*ToolCallDiff) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{21}
+// Deprecated: Use AgentPlanEntry.ProtoReflect.Descriptor instead.
+func (*AgentPlanEntry) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{56}
 }
 
-func (x *ToolCallDiff) GetPath() string {
+func (x *AgentPlanEntry) GetContent() string {
 	if x != nil {
-		return x.Path
+		return x.Content
 	}
 	return ""
 }
 
-func (x *ToolCallDiff) GetNewText() string {
+func (x *AgentPlanEntry) GetPriority() string {
 	if x != nil {
-		return x.NewText
+		return x.Priority
 	}
 	return ""
 }
 
-func (x *ToolCallDiff) GetOldText() string {
+func (x *AgentPlanEntry) GetStatus() string {
 	if x != nil {
-		return x.OldText
+		return x.Status
 	}
 	return ""
 }
 
-type ToolCallText struct {
+// The agent submitted plans via `agentctl plan commit`, one per thread.
+type PlanSubmitted struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Text          string                 `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	Plans         []*Plan                `protobuf:"bytes,1,rep,name=plans,proto3" json:"plans,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ToolCallText) Reset() {
-	*x = ToolCallText{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[22]
+func (x *PlanSubmitted) Reset() {
+	*x = PlanSubmitted{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[57]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ToolCallText) String() string {
+func (x *PlanSubmitted) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ToolCallText) ProtoMessage() {}
+func (*PlanSubmitted) ProtoMessage() {}
 
-func (x *ToolCallText) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[22]
+func (x *PlanSubmitted) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[57]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1798,41 +4484,43 @@ func (x *ToolCallText) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ToolCallText.ProtoReflect.Descriptor instead.
-func (*ToolCallText) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{22}
+// Deprecated: Use PlanSubmitted.ProtoReflect.Descriptor instead.
+func (*PlanSubmitted) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{57}
 }
 
-func (x *ToolCallText) GetText() string {
+func (x *PlanSubmitted) GetPlans() []*Plan {
 	if x != nil {
-		return x.Text
+		return x.Plans
 	}
-	return ""
+	return nil
 }
 
-type ToolCallLocation struct {
+type Plan struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Path          string                 `protobuf:"bytes,1,opt,name=path,proto3" json:"path,omitempty"`
-	Line          int64                  `protobuf:"varint,2,opt,name=line,proto3" json:"line,omitempty"`
+	ThreadId      string                 `protobuf:"bytes,1,opt,name=thread_id,json=threadId,proto3" json:"thread_id,omitempty"`
+	Title         string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Body          string                 `protobuf:"bytes,3,opt,name=body,proto3" json:"body,omitempty"`
+	Steps         []*PlanStep            `protobuf:"bytes,4,rep,name=steps,proto3" json:"steps,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *ToolCallLocation) Reset() {
-	*x = ToolCallLocation{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[23]
+func (x *Plan) Reset() {
+	*x = Plan{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[58]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *ToolCallLocation) String() string {
+func (x *Plan) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*ToolCallLocation) ProtoMessage() {}
+func (*Plan) ProtoMessage() {}
 
-func (x *ToolCallLocation) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[23]
+func (x *Plan) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[58]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1843,47 +4531,66 @@ func (x *ToolCallLocation) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use ToolCallLocation.ProtoReflect.Descriptor instead.
-func (*ToolCallLocation) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{23}
+// Deprecated: Use Plan.ProtoReflect.Descriptor instead.
+func (*Plan) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{58}
 }
 
-func (x *ToolCallLocation) GetPath() string {
+func (x *Plan) GetThreadId() string {
 	if x != nil {
-		return x.Path
+		return x.ThreadId
 	}
 	return ""
 }
 
-func (x *ToolCallLocation) GetLine() int64 {
+func (x *Plan) GetTitle() string {
 	if x != nil {
-		return x.Line
+		return x.Title
 	}
-	return 0
+	return ""
 }
 
-type StatusChange struct {
+func (x *Plan) GetBody() string {
+	if x != nil {
+		return x.Body
+	}
+	return ""
+}
+
+func (x *Plan) GetSteps() []*PlanStep {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+type PlanStep struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
-	Status        SessionStatus          `protobuf:"varint,1,opt,name=status,proto3,enum=worker.v1.SessionStatus" json:"status,omitempty"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	File          string                 `protobuf:"bytes,2,opt,name=file,proto3" json:"file,omitempty"` // task file name, e.g. "01-setup.md"
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	DependsOn     []string               `protobuf:"bytes,4,rep,name=depends_on,json=dependsOn,proto3" json:"depends_on,omitempty"`
+	Agent         string                 `protobuf:"bytes,5,opt,name=agent,proto3" json:"agent,omitempty"`
+	Subtasks      []string               `protobuf:"bytes,6,rep,name=subtasks,proto3" json:"subtasks,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *StatusChange) Reset() {
-	*x = StatusChange{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[24]
+func (x *PlanStep) Reset() {
+	*x = PlanStep{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[59]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *StatusChange) String() string {
+func (x *PlanStep) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*StatusChange) ProtoMessage() {}
+func (*PlanStep) ProtoMessage() {}
 
-func (x *StatusChange) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[24]
+func (x *PlanStep) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[59]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1894,73 +4601,68 @@ func (x *StatusChange) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use StatusChange.ProtoReflect.Descriptor instead.
-func (*StatusChange) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{24}
+// Deprecated: Use PlanStep.ProtoReflect.Descriptor instead.
+func (*PlanStep) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{59}
 }
 
-func (x *StatusChange) GetStatus() SessionStatus {
+func (x *PlanStep) GetId() string {
 	if x != nil {
-		return x.Status
+		return x.Id
 	}
-	return SessionStatus_SESSION_STATUS_UNSPECIFIED
-}
-
-type CurrentModeUpdate struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	ModeId        string                 `protobuf:"bytes,1,opt,name=mode_id,json=modeId,proto3" json:"mode_id,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	return ""
 }
 
-func (x *CurrentModeUpdate) Reset() {
-	*x = CurrentModeUpdate{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[25]
-	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-	ms.StoreMessageInfo(mi)
+func (x *PlanStep) GetFile() string {
+	if x != nil {
+		return x.File
+	}
+	return ""
 }
 
-func (x *CurrentModeUpdate) String() string {
-	return protoimpl.X.MessageStringOf(x)
+func (x *PlanStep) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
 }
 
-func (*CurrentModeUpdate) ProtoMessage() {}
-
-func (x *CurrentModeUpdate) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[25]
+func (x *PlanStep) GetDependsOn() []string {
 	if x != nil {
-		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
-		if ms.LoadMessageInfo() == nil {
-			ms.StoreMessageInfo(mi)
-		}
-		return ms
+		return x.DependsOn
 	}
-	return mi.MessageOf(x)
+	return nil
 }
 
-// Deprecated: Use CurrentModeUpdate.ProtoReflect.Descriptor instead.
-func (*CurrentModeUpdate) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{25}
+func (x *PlanStep) GetAgent() string {
+	if x != nil {
+		return x.Agent
+	}
+	return ""
 }
 
-func (x *CurrentModeUpdate) GetModeId() string {
+func (x *PlanStep) GetSubtasks() []string {
 	if x != nil {
-		return x.ModeId
+		return x.Subtasks
 	}
-	return ""
+	return nil
 }
 
 // Full snapshot of all sessions on this worker.
 type SessionStateSnapshot struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	Sessions      []*SessionState        `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Sessions []*SessionState        `protobuf:"bytes,1,rep,name=sessions,proto3" json:"sessions,omitempty"`
+	// Identifies this run of the worker process. A restarted worker numbers
+	// session events anew, so sequences only compare between snapshots with
+	// the same instance ID.
+	InstanceId    string `protobuf:"bytes,2,opt,name=instance_id,json=instanceId,proto3" json:"instance_id,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SessionStateSnapshot) Reset() {
 	*x = SessionStateSnapshot{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[26]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[60]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1972,7 +4674,7 @@ func (x *SessionStateSnapshot) String() string {
 func (*SessionStateSnapshot) ProtoMessage() {}
 
 func (x *SessionStateSnapshot) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[26]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[60]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1985,7 +4687,7 @@ func (x *SessionStateSnapshot) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SessionStateSnapshot.ProtoReflect.Descriptor instead.
 func (*SessionStateSnapshot) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{26}
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{60}
 }
 
 func (x *SessionStateSnapshot) GetSessions() []*SessionState {
@@ -1995,6 +4697,13 @@ func (x *SessionStateSnapshot) GetSessions() []*SessionState {
 	return nil
 }
 
+func (x *SessionStateSnapshot) GetInstanceId() string {
+	if x != nil {
+		return x.InstanceId
+	}
+	return ""
+}
+
 // Current state of a single session.
 type SessionState struct {
 	state          protoimpl.MessageState `protogen:"open.v1"`
@@ -2004,13 +4713,27 @@ type SessionState struct {
 	Mode           SessionMode            `protobuf:"varint,4,opt,name=mode,proto3,enum=worker.v1.SessionMode" json:"mode,omitempty"`
 	AgentSessionId string                 `protobuf:"bytes,5,opt,name=agent_session_id,json=agentSessionId,proto3" json:"agent_session_id,omitempty"`
 	Topic          string                 `protobuf:"bytes,6,opt,name=topic,proto3" json:"topic,omitempty"`
-	unknownFields  protoimpl.UnknownFields
-	sizeCache      protoimpl.SizeCache
+	// Effective model, including the agent default when none was requested.
+	Model  string            `protobuf:"bytes,7,opt,name=model,proto3" json:"model,omitempty"`
+	Labels map[string]string `protobuf:"bytes,8,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Why the session failed, if it did. Kept after the session stops.
+	Error *SessionError `protobuf:"bytes,9,opt,name=error,proto3" json:"error,omitempty"`
+	// Modes the agent offers, for SetSessionMode; empty if it reports none.
+	Modes []*AgentMode `protobuf:"bytes,10,rep,name=modes,proto3" json:"modes,omitempty"`
+	// The agent's current mode ID, if known.
+	CurrentMode string `protobuf:"bytes,11,opt,name=current_mode,json=currentMode,proto3" json:"current_mode,omitempty"`
+	// The agent's last stderr lines, oldest first.
+	StderrTail []string `protobuf:"bytes,12,rep,name=stderr_tail,json=stderrTail,proto3" json:"stderr_tail,omitempty"`
+	// Sequence of the session's last event. Set only in snapshots; a value
+	// below what the control plane handled means the worker restarted.
+	LastSequence  int64 `protobuf:"varint,13,opt,name=last_sequence,json=lastSequence,proto3" json:"last_sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SessionState) Reset() {
 	*x = SessionState{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[27]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[61]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2022,7 +4745,7 @@ func (x *SessionState) String() string {
 func (*SessionState) ProtoMessage() {}
 
 func (x *SessionState) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[27]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[61]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2035,7 +4758,7 @@ func (x *SessionState) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SessionState.ProtoReflect.Descriptor instead.
 func (*SessionState) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{27}
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{61}
 }
 
 func (x *SessionState) GetSessionId() string {
@@ -2080,18 +4803,130 @@ func (x *SessionState) GetTopic() string {
 	return ""
 }
 
+func (x *SessionState) GetModel() string {
+	if x != nil {
+		return x.Model
+	}
+	return ""
+}
+
+func (x *SessionState) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *SessionState) GetError() *SessionError {
+	if x != nil {
+		return x.Error
+	}
+	return nil
+}
+
+func (x *SessionState) GetModes() []*AgentMode {
+	if x != nil {
+		return x.Modes
+	}
+	return nil
+}
+
+func (x *SessionState) GetCurrentMode() string {
+	if x != nil {
+		return x.CurrentMode
+	}
+	return ""
+}
+
+func (x *SessionState) GetStderrTail() []string {
+	if x != nil {
+		return x.StderrTail
+	}
+	return nil
+}
+
+func (x *SessionState) GetLastSequence() int64 {
+	if x != nil {
+		return x.LastSequence
+	}
+	return 0
+}
+
+// A session mode offered by the agent, e.g. "plan" or "build".
+type AgentMode struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AgentMode) Reset() {
+	*x = AgentMode{}
+	mi := &file_worker_v1_worker_service_proto_msgTypes[62]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AgentMode) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AgentMode) ProtoMessage() {}
+
+func (x *AgentMode) ProtoReflect() protoreflect.Message {
+	mi := &file_worker_v1_worker_service_proto_msgTypes[62]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AgentMode.ProtoReflect.Descriptor instead.
+func (*AgentMode) Descriptor() ([]byte, []int) {
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{62}
+}
+
+func (x *AgentMode) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *AgentMode) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AgentMode) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
 // Notification that a session has been removed.
 type SessionRemoved struct {
-	state         protoimpl.MessageState `protogen:"open.v1"`
-	SessionId     string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
-	FinalStatus   string                 `protobuf:"bytes,2,opt,name=final_status,json=finalStatus,proto3" json:"final_status,omitempty"`
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	SessionId string                 `protobuf:"bytes,1,opt,name=session_id,json=sessionId,proto3" json:"session_id,omitempty"`
+	// The session's status when it was removed: "errored" if it failed,
+	// "stopped" otherwise.
+	FinalStatus   string `protobuf:"bytes,2,opt,name=final_status,json=finalStatus,proto3" json:"final_status,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *SessionRemoved) Reset() {
 	*x = SessionRemoved{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[28]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[63]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2103,7 +4938,7 @@ func (x *SessionRemoved) String() string {
 func (*SessionRemoved) ProtoMessage() {}
 
 func (x *SessionRemoved) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[28]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[63]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2116,7 +4951,7 @@ func (x *SessionRemoved) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use SessionRemoved.ProtoReflect.Descriptor instead.
 func (*SessionRemoved) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{28}
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{63}
 }
 
 func (x *SessionRemoved) GetSessionId() string {
@@ -2144,7 +4979,7 @@ type CheckSessionResumableRequest struct {
 
 func (x *CheckSessionResumableRequest) Reset() {
 	*x = CheckSessionResumableRequest{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[29]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[64]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2156,7 +4991,7 @@ func (x *CheckSessionResumableRequest) String() string {
 func (*CheckSessionResumableRequest) ProtoMessage() {}
 
 func (x *CheckSessionResumableRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[29]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[64]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2169,7 +5004,7 @@ func (x *CheckSessionResumableRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CheckSessionResumableRequest.ProtoReflect.Descriptor instead.
 func (*CheckSessionResumableRequest) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{29}
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{64}
 }
 
 func (x *CheckSessionResumableRequest) GetAgent() string {
@@ -2203,7 +5038,7 @@ type CheckSessionResumableResponse struct {
 
 func (x *CheckSessionResumableResponse) Reset() {
 	*x = CheckSessionResumableResponse{}
-	mi := &file_worker_v1_worker_service_proto_msgTypes[30]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[65]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -2215,7 +5050,7 @@ func (x *CheckSessionResumableResponse) String() string {
 func (*CheckSessionResumableResponse) ProtoMessage() {}
 
 func (x *CheckSessionResumableResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_worker_v1_worker_service_proto_msgTypes[30]
+	mi := &file_worker_v1_worker_service_proto_msgTypes[65]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -2228,7 +5063,7 @@ func (x *CheckSessionResumableResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use CheckSessionResumableResponse.ProtoReflect.Descriptor instead.
 func (*CheckSessionResumableResponse) Descriptor() ([]byte, []int) {
-	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{30}
+	return file_worker_v1_worker_service_proto_rawDescGZIP(), []int{65}
 }
 
 func (x *CheckSessionResumableResponse) GetResumable() bool {
@@ -2253,22 +5088,56 @@ const file_worker_v1_worker_service_proto_rawDesc = "" +
 	"\x16SendUserMessageRequest\x12&\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\tsessionId\x12>\n" +
-	"\x0econtent_blocks\x18\x02 \x03(\v2\x17.worker.v1.ContentBlockR\rcontentBlocks\"6\n" +
+	"\x0econtent_blocks\x18\x02 \x03(\v2\x17.worker.v1.ContentBlockR\rcontentBlocks\"y\n" +
 	"\fContentBlock\x12\x12\n" +
 	"\x04type\x18\x01 \x01(\tR\x04type\x12\x12\n" +
-	"\x04text\x18\x02 \x01(\tR\x04text\":\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x1b\n" +
+	"\tmime_type\x18\x03 \x01(\tR\bmimeType\x12\x12\n" +
+	"\x04data\x18\x04 \x01(\tR\x04data\x12\x10\n" +
+	"\x03uri\x18\x05 \x01(\tR\x03uri\":\n" +
 	"\x17SendUserMessageResponse\x12\x1f\n" +
 	"\vstop_reason\x18\x01 \x01(\tR\n" +
-	"stopReason\">\n" +
+	"stopReason\"\xba\x01\n" +
+	"\rPromptRequest\x12&\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\tsessionId\x12H\n" +
+	"\x0econtent_blocks\x18\x02 \x03(\v2\x17.worker.v1.ContentBlockB\b\xbaH\x05\x92\x01\x02\b\x01R\rcontentBlocks\x12\x14\n" +
+	"\x05model\x18\x03 \x01(\tR\x05model\x12!\n" +
+	"\fsession_mode\x18\x04 \x01(\tR\vsessionMode\"1\n" +
+	"\x0ePromptResponse\x12\x1f\n" +
+	"\vstop_reason\x18\x01 \x01(\tR\n" +
+	"stopReason\"o\n" +
 	"\x14CancelSessionRequest\x12&\n" +
 	"\n" +
-	"session_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\tsessionId\"\x17\n" +
+	"session_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\tsessionId\x12/\n" +
+	"\x06reason\x18\x02 \x01(\x0e2\x17.worker.v1.CancelReasonR\x06reason\"\x17\n" +
 	"\x15CancelSessionResponse\"a\n" +
 	"\x15SetSessionModeRequest\x12&\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\tsessionId\x12 \n" +
 	"\amode_id\x18\x02 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\x06modeId\"\x18\n" +
-	"\x16SetSessionModeResponse\"\xce\x02\n" +
+	"\x16SetSessionModeResponse\"V\n" +
+	"\x16SetAllowedToolsRequest\x12&\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\tsessionId\x12\x14\n" +
+	"\x05tools\x18\x02 \x03(\tR\x05tools\"\x19\n" +
+	"\x17SetAllowedToolsResponse\"t\n" +
+	"\x16SetHostCommandsRequest\x12&\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\tsessionId\x122\n" +
+	"\bcommands\x18\x02 \x03(\v2\x16.worker.v1.HostCommandR\bcommands\"\x19\n" +
+	"\x17SetHostCommandsResponse\"k\n" +
+	"\vHostCommand\x12\x1b\n" +
+	"\x04name\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\x1d\n" +
+	"\n" +
+	"input_hint\x18\x03 \x01(\tR\tinputHint\"h\n" +
+	"\x17GetPendingEventsRequest\x12&\n" +
+	"\n" +
+	"session_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\tsessionId\x12%\n" +
+	"\x0eafter_sequence\x18\x02 \x01(\x03R\rafterSequence\"K\n" +
+	"\x18GetPendingEventsResponse\x12/\n" +
+	"\x06events\x18\x01 \x03(\v2\x17.worker.v1.SessionEventR\x06events\"\xa6\x05\n" +
 	"\x11NewSessionRequest\x12&\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tB\a\xbaH\x04r\x02\x10\x01R\tsessionId\x12&\n" +
@@ -2281,7 +5150,22 @@ const file_worker_v1_worker_service_proto_rawDesc = "" +
 	"\x10agent_session_id\x18\b \x01(\tR\x0eagentSessionId\x12!\n" +
 	"\fsession_mode\x18\t \x01(\tR\vsessionMode\x12#\n" +
 	"\rallowed_tools\x18\n" +
-	" \x03(\tR\fallowedTools\"\xe7\x01\n" +
+	" \x03(\tR\fallowedTools\x12)\n" +
+	"\x10reasoning_effort\x18\v \x01(\tR\x0freasoningEffort\x12@\n" +
+	"\x06labels\x18\f \x03(\v2(.worker.v1.NewSessionRequest.LabelsEntryR\x06labels\x12\x1d\n" +
+	"\n" +
+	"auto_topic\x18\r \x01(\bR\tautoTopic\x12\x1c\n" +
+	"\tephemeral\x18\x0e \x01(\bR\tephemeral\x12<\n" +
+	"\rauto_continue\x18\x0f \x01(\v2\x17.worker.v1.AutoContinueR\fautoContinue\x123\n" +
+	"\x15required_capabilities\x18\x10 \x03(\tR\x14requiredCapabilities\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"n\n" +
+	"\fAutoContinue\x12\x16\n" +
+	"\x06prompt\x18\x01 \x01(\tR\x06prompt\x12%\n" +
+	"\x0emax_iterations\x18\x02 \x01(\x05R\rmaxIterations\x12\x1f\n" +
+	"\vstop_phrase\x18\x03 \x01(\tR\n" +
+	"stopPhrase\"\xe7\x01\n" +
 	"\x12NewSessionResponse\x12\x1a\n" +
 	"\baccepted\x18\x01 \x01(\bR\baccepted\x12\x18\n" +
 	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1d\n" +
@@ -2290,7 +5174,7 @@ const file_worker_v1_worker_service_proto_rawDesc = "" +
 	"\x05agent\x18\x04 \x01(\x0e2\x10.worker.v1.AgentR\x05agent\x12\x16\n" +
 	"\x06status\x18\x05 \x01(\tR\x06status\x12\x12\n" +
 	"\x04mode\x18\x06 \x01(\tR\x04mode\x12(\n" +
-	"\x10agent_session_id\x18\v \x01(\tR\x0eagentSessionId\"\xf2\x01\n" +
+	"\x10agent_session_id\x18\v \x01(\tR\x0eagentSessionId\"\xcf\x03\n" +
 	"\vSessionInfo\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12&\n" +
@@ -2298,8 +5182,15 @@ const file_worker_v1_worker_service_proto_rawDesc = "" +
 	"\x06status\x18\x03 \x01(\x0e2\x18.worker.v1.SessionStatusR\x06status\x12*\n" +
 	"\x04mode\x18\x04 \x01(\x0e2\x16.worker.v1.SessionModeR\x04mode\x12(\n" +
 	"\x10agent_session_id\x18\x05 \x01(\tR\x0eagentSessionId\x12\x14\n" +
-	"\x05model\x18\x06 \x01(\tR\x05model\"\x15\n" +
-	"\x13ListSessionsRequest\"J\n" +
+	"\x05model\x18\x06 \x01(\tR\x05model\x12:\n" +
+	"\x06labels\x18\a \x03(\v2\".worker.v1.SessionInfo.LabelsEntryR\x06labels\x12?\n" +
+	"\x10last_stop_reason\x18\b \x01(\x0e2\x15.worker.v1.StopReasonR\x0elastStopReason\x12#\n" +
+	"\rqueued_events\x18\t \x01(\x03R\fqueuedEvents\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"<\n" +
+	"\x13ListSessionsRequest\x12%\n" +
+	"\x0elabel_selector\x18\x01 \x01(\tR\rlabelSelector\"J\n" +
 	"\x14ListSessionsResponse\x122\n" +
 	"\bsessions\x18\x01 \x03(\v2\x16.worker.v1.SessionInfoR\bsessions\"[\n" +
 	"\x10StateSyncRequest\x12$\n" +
@@ -2310,12 +5201,14 @@ const file_worker_v1_worker_service_proto_rawDesc = "" +
 	"\x0esession_update\x18\x02 \x01(\v2\x17.worker.v1.SessionStateH\x00R\rsessionUpdate\x12D\n" +
 	"\x0fsession_removed\x18\x03 \x01(\v2\x19.worker.v1.SessionRemovedH\x00R\x0esessionRemoved\x12>\n" +
 	"\rsession_event\x18\x04 \x01(\v2\x17.worker.v1.SessionEventH\x00R\fsessionEventB\b\n" +
-	"\x06update\"\xda\x04\n" +
+	"\x06update\"\xda\n" +
+	"\n" +
 	"\fSessionEvent\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12\x1a\n" +
 	"\bsequence\x18\x02 \x01(\x03R\bsequence\x12\x1c\n" +
-	"\ttimestamp\x18\x03 \x01(\tR\ttimestamp\x12N\n" +
+	"\ttimestamp\x18\x03 \x01(\tR\ttimestamp\x12\x1c\n" +
+	"\tephemeral\x18\x04 \x01(\bR\tephemeral\x12N\n" +
 	"\x13agent_message_chunk\x18\n" +
 	" \x01(\v2\x1c.worker.v1.AgentMessageChunkH\x00R\x11agentMessageChunk\x12N\n" +
 	"\x13agent_thought_chunk\x18\v \x01(\v2\x1c.worker.v1.AgentThoughtChunkH\x00R\x11agentThoughtChunk\x122\n" +
@@ -2323,14 +5216,28 @@ const file_worker_v1_worker_service_proto_rawDesc = "" +
 	"\x10tool_call_update\x18\r \x01(\v2\x19.worker.v1.ToolCallUpdateH\x00R\x0etoolCallUpdate\x12>\n" +
 	"\rstatus_change\x18\x0e \x01(\v2\x17.worker.v1.StatusChangeH\x00R\fstatusChange\x12N\n" +
 	"\x13current_mode_update\x18\x0f \x01(\v2\x1c.worker.v1.CurrentModeUpdateH\x00R\x11currentModeUpdate\x12;\n" +
-	"\fuser_message\x18\x10 \x01(\v2\x16.worker.v1.UserMessageH\x00R\vuserMessageB\t\n" +
+	"\fuser_message\x18\x10 \x01(\v2\x16.worker.v1.UserMessageH\x00R\vuserMessage\x12Q\n" +
+	"\x14current_model_update\x18\x11 \x01(\v2\x1d.worker.v1.CurrentModelUpdateH\x00R\x12currentModelUpdate\x12>\n" +
+	"\rsession_error\x18\x12 \x01(\v2\x17.worker.v1.SessionErrorH\x00R\fsessionError\x12M\n" +
+	"\x12permission_request\x18\x13 \x01(\v2\x1c.worker.v1.PermissionRequestH\x00R\x11permissionRequest\x12P\n" +
+	"\x13permission_resolved\x18\x14 \x01(\v2\x1d.worker.v1.PermissionResolvedH\x00R\x12permissionResolved\x12>\n" +
+	"\revents_pruned\x18\x15 \x01(\v2\x17.worker.v1.EventsPrunedH\x00R\feventsPruned\x12A\n" +
+	"\x0eplan_submitted\x18\x16 \x01(\v2\x18.worker.v1.PlanSubmittedH\x00R\rplanSubmitted\x12K\n" +
+	"\x12mcp_server_startup\x18\x17 \x01(\v2\x1b.worker.v1.McpServerStartupH\x00R\x10mcpServerStartup\x121\n" +
+	"\bprogress\x18\x18 \x01(\v2\x13.worker.v1.ProgressH\x00R\bprogress\x125\n" +
+	"\n" +
+	"turn_ended\x18\x19 \x01(\v2\x14.worker.v1.TurnEndedH\x00R\tturnEnded\x125\n" +
+	"\n" +
+	"agent_plan\x18\x1a \x01(\v2\x14.worker.v1.AgentPlanH\x00R\tagentPlan\x12;\n" +
+	"\fsession_init\x18\x1b \x01(\v2\x16.worker.v1.SessionInitH\x00R\vsessionInitB\t\n" +
 	"\apayload\"'\n" +
 	"\x11AgentMessageChunk\x12\x12\n" +
 	"\x04text\x18\x01 \x01(\tR\x04text\"'\n" +
 	"\x11AgentThoughtChunk\x12\x12\n" +
-	"\x04text\x18\x01 \x01(\tR\x04text\"!\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\"Y\n" +
 	"\vUserMessage\x12\x12\n" +
-	"\x04text\x18\x01 \x01(\tR\x04text\"\xb5\x02\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\x126\n" +
+	"\x17auto_continue_iteration\x18\x02 \x01(\x05R\x15autoContinueIteration\"\xdd\x03\n" +
 	"\bToolCall\x12 \n" +
 	"\ftool_call_id\x18\x01 \x01(\tR\n" +
 	"toolCallId\x12\x14\n" +
@@ -2339,7 +5246,12 @@ const file_worker_v1_worker_service_proto_rawDesc = "" +
 	"\traw_input\x18\x04 \x01(\tR\brawInput\x129\n" +
 	"\tlocations\x18\x05 \x03(\v2\x1b.worker.v1.ToolCallLocationR\tlocations\x121\n" +
 	"\x06status\x18\x06 \x01(\x0e2\x19.worker.v1.ToolCallStatusR\x06status\x129\n" +
-	"\acontent\x18\a \x03(\v2\x1f.worker.v1.ToolCallContentBlockR\acontent\"\x90\x02\n" +
+	"\acontent\x18\a \x03(\v2\x1f.worker.v1.ToolCallContentBlockR\acontent\x12*\n" +
+	"\x05input\x18\b \x01(\v2\x14.worker.v1.ToolInputR\x05input\x12=\n" +
+	"\bmetadata\x18\t \x03(\v2!.worker.v1.ToolCall.MetadataEntryR\bmetadata\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x92\x04\n" +
 	"\x0eToolCallUpdate\x12 \n" +
 	"\ftool_call_id\x18\x01 \x01(\tR\n" +
 	"toolCallId\x12\x14\n" +
@@ -2348,26 +5260,162 @@ const file_worker_v1_worker_service_proto_rawDesc = "" +
 	"\n" +
 	"raw_output\x18\x04 \x01(\tR\trawOutput\x129\n" +
 	"\tlocations\x18\x05 \x03(\v2\x1b.worker.v1.ToolCallLocationR\tlocations\x129\n" +
-	"\acontent\x18\x06 \x03(\v2\x1f.worker.v1.ToolCallContentBlockR\acontent\"}\n" +
+	"\acontent\x18\x06 \x03(\v2\x1f.worker.v1.ToolCallContentBlockR\acontent\x12*\n" +
+	"\x05input\x18\a \x01(\v2\x14.worker.v1.ToolInputR\x05input\x12C\n" +
+	"\bmetadata\x18\b \x03(\v2'.worker.v1.ToolCallUpdate.MetadataEntryR\bmetadata\x12&\n" +
+	"\x0fraw_output_size\x18\t \x01(\x03R\rrawOutputSize\x12*\n" +
+	"\x11raw_output_sha256\x18\n" +
+	" \x01(\tR\x0frawOutputSha256\x1a;\n" +
+	"\rMetadataEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc8\x01\n" +
 	"\x14ToolCallContentBlock\x12-\n" +
 	"\x04diff\x18\x01 \x01(\v2\x17.worker.v1.ToolCallDiffH\x00R\x04diff\x12-\n" +
-	"\x04text\x18\x02 \x01(\v2\x17.worker.v1.ToolCallTextH\x00R\x04textB\a\n" +
+	"\x04text\x18\x02 \x01(\v2\x17.worker.v1.ToolCallTextH\x00R\x04text\x12I\n" +
+	"\x0ecommand_output\x18\x03 \x01(\v2 .worker.v1.ToolCallCommandOutputH\x00R\rcommandOutputB\a\n" +
 	"\x05block\"X\n" +
 	"\fToolCallDiff\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12\x19\n" +
 	"\bnew_text\x18\x02 \x01(\tR\anewText\x12\x19\n" +
 	"\bold_text\x18\x03 \x01(\tR\aoldText\"\"\n" +
 	"\fToolCallText\x12\x12\n" +
-	"\x04text\x18\x01 \x01(\tR\x04text\":\n" +
+	"\x04text\x18\x01 \x01(\tR\x04text\"w\n" +
+	"\x15ToolCallCommandOutput\x12\x16\n" +
+	"\x06stdout\x18\x01 \x01(\tR\x06stdout\x12\x16\n" +
+	"\x06stderr\x18\x02 \x01(\tR\x06stderr\x12 \n" +
+	"\texit_code\x18\x03 \x01(\x05H\x00R\bexitCode\x88\x01\x01B\f\n" +
+	"\n" +
+	"_exit_code\"\xb6\x02\n" +
+	"\tToolInput\x12.\n" +
+	"\x04read\x18\x01 \x01(\v2\x18.worker.v1.ToolInputReadH\x00R\x04read\x121\n" +
+	"\x05write\x18\x02 \x01(\v2\x19.worker.v1.ToolInputWriteH\x00R\x05write\x12.\n" +
+	"\x04edit\x18\x03 \x01(\v2\x18.worker.v1.ToolInputEditH\x00R\x04edit\x12.\n" +
+	"\x04bash\x18\x04 \x01(\v2\x18.worker.v1.ToolInputBashH\x00R\x04bash\x12.\n" +
+	"\x04grep\x18\x05 \x01(\v2\x18.worker.v1.ToolInputGrepH\x00R\x04grep\x12.\n" +
+	"\x04glob\x18\x06 \x01(\v2\x18.worker.v1.ToolInputGlobH\x00R\x04globB\x06\n" +
+	"\x04tool\"y\n" +
+	"\rToolInputRead\x12\x1b\n" +
+	"\tfile_path\x18\x01 \x01(\tR\bfilePath\x12\x1b\n" +
+	"\x06offset\x18\x02 \x01(\x03H\x00R\x06offset\x88\x01\x01\x12\x19\n" +
+	"\x05limit\x18\x03 \x01(\x03H\x01R\x05limit\x88\x01\x01B\t\n" +
+	"\a_offsetB\b\n" +
+	"\x06_limit\"G\n" +
+	"\x0eToolInputWrite\x12\x1b\n" +
+	"\tfile_path\x18\x01 \x01(\tR\bfilePath\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\"\x8b\x01\n" +
+	"\rToolInputEdit\x12\x1b\n" +
+	"\tfile_path\x18\x01 \x01(\tR\bfilePath\x12\x1d\n" +
+	"\n" +
+	"old_string\x18\x02 \x01(\tR\toldString\x12\x1d\n" +
+	"\n" +
+	"new_string\x18\x03 \x01(\tR\tnewString\x12\x1f\n" +
+	"\vreplace_all\x18\x04 \x01(\bR\n" +
+	"replaceAll\"\xaa\x01\n" +
+	"\rToolInputBash\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\x12\"\n" +
+	"\n" +
+	"timeout_ms\x18\x03 \x01(\x03H\x00R\ttimeoutMs\x88\x01\x01\x12*\n" +
+	"\x11run_in_background\x18\x04 \x01(\bR\x0frunInBackgroundB\r\n" +
+	"\v_timeout_ms\"\xb1\x01\n" +
+	"\rToolInputGrep\x12\x18\n" +
+	"\apattern\x18\x01 \x01(\tR\apattern\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\x12\x12\n" +
+	"\x04glob\x18\x03 \x01(\tR\x04glob\x12\x12\n" +
+	"\x04type\x18\x04 \x01(\tR\x04type\x12\x1f\n" +
+	"\voutput_mode\x18\x05 \x01(\tR\n" +
+	"outputMode\x12)\n" +
+	"\x10case_insensitive\x18\x06 \x01(\bR\x0fcaseInsensitive\"=\n" +
+	"\rToolInputGlob\x12\x18\n" +
+	"\apattern\x18\x01 \x01(\tR\apattern\x12\x12\n" +
+	"\x04path\x18\x02 \x01(\tR\x04path\":\n" +
 	"\x10ToolCallLocation\x12\x12\n" +
 	"\x04path\x18\x01 \x01(\tR\x04path\x12\x12\n" +
-	"\x04line\x18\x02 \x01(\x03R\x04line\"@\n" +
+	"\x04line\x18\x02 \x01(\x03R\x04line\"\x98\x01\n" +
 	"\fStatusChange\x120\n" +
-	"\x06status\x18\x01 \x01(\x0e2\x18.worker.v1.SessionStatusR\x06status\",\n" +
+	"\x06status\x18\x01 \x01(\x0e2\x18.worker.v1.SessionStatusR\x06status\x12-\n" +
+	"\x05error\x18\x02 \x01(\v2\x17.worker.v1.SessionErrorR\x05error\x12'\n" +
+	"\x0faccepting_input\x18\x03 \x01(\bR\x0eacceptingInput\",\n" +
 	"\x11CurrentModeUpdate\x12\x17\n" +
-	"\amode_id\x18\x01 \x01(\tR\x06modeId\"K\n" +
+	"\amode_id\x18\x01 \x01(\tR\x06modeId\"/\n" +
+	"\x12CurrentModelUpdate\x12\x19\n" +
+	"\bmodel_id\x18\x01 \x01(\tR\amodelId\"\x80\x01\n" +
+	"\fSessionError\x125\n" +
+	"\x06reason\x18\x01 \x01(\x0e2\x1d.worker.v1.SessionErrorReasonR\x06reason\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1f\n" +
+	"\vstderr_tail\x18\x03 \x03(\tR\n" +
+	"stderrTail\"\xac\x01\n" +
+	"\x11PermissionRequest\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12+\n" +
+	"\x04kind\x18\x03 \x01(\x0e2\x17.worker.v1.ToolCallKindR\x04kind\x125\n" +
+	"\aoptions\x18\x04 \x03(\v2\x1b.worker.v1.PermissionOptionR\aoptions\"W\n" +
+	"\x10PermissionOption\x12\x1b\n" +
+	"\toption_id\x18\x01 \x01(\tR\boptionId\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x12\n" +
+	"\x04kind\x18\x03 \x01(\tR\x04kind\"j\n" +
+	"\x12PermissionResolved\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x01 \x01(\tR\trequestId\x12\x1b\n" +
+	"\toption_id\x18\x02 \x01(\tR\boptionId\x12\x18\n" +
+	"\aoutcome\x18\x03 \x01(\tR\aoutcome\"$\n" +
+	"\fEventsPruned\x12\x14\n" +
+	"\x05count\x18\x01 \x01(\x03R\x05count\"\x86\x01\n" +
+	"\x10McpServerStartup\x12\x16\n" +
+	"\x06server\x18\x01 \x01(\tR\x06server\x12\x16\n" +
+	"\x06status\x18\x02 \x01(\tR\x06status\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x14\n" +
+	"\x05ready\x18\x04 \x01(\bR\x05ready\x12\x12\n" +
+	"\x04text\x18\x05 \x01(\tR\x04text\"v\n" +
+	"\vSessionInit\x12\x14\n" +
+	"\x05model\x18\x01 \x01(\tR\x05model\x12'\n" +
+	"\x0fpermission_mode\x18\x02 \x01(\tR\x0epermissionMode\x12\x14\n" +
+	"\x05tools\x18\x03 \x03(\tR\x05tools\x12\x12\n" +
+	"\x04text\x18\x04 \x01(\tR\x04text\"O\n" +
+	"\bProgress\x12\x18\n" +
+	"\amessage\x18\x01 \x01(\tR\amessage\x12\x1d\n" +
+	"\apercent\x18\x02 \x01(\x05H\x00R\apercent\x88\x01\x01B\n" +
+	"\n" +
+	"\b_percent\"\xad\x01\n" +
+	"\tTurnEnded\x126\n" +
+	"\vstop_reason\x18\x01 \x01(\x0e2\x15.worker.v1.StopReasonR\n" +
+	"stopReason\x12<\n" +
+	"\rcancel_reason\x18\x02 \x01(\x0e2\x17.worker.v1.CancelReasonR\fcancelReason\x12*\n" +
+	"\x05stats\x18\x03 \x01(\v2\x14.worker.v1.TurnStatsR\x05stats\"\xc7\x01\n" +
+	"\tTurnStats\x12\x1f\n" +
+	"\vduration_ms\x18\x01 \x01(\x03R\n" +
+	"durationMs\x122\n" +
+	"\x16time_to_first_token_ms\x18\x02 \x01(\x03R\x12timeToFirstTokenMs\x12\x1d\n" +
+	"\n" +
+	"tool_calls\x18\x03 \x01(\x05R\ttoolCalls\x12!\n" +
+	"\finput_tokens\x18\x04 \x01(\x03R\vinputTokens\x12#\n" +
+	"\routput_tokens\x18\x05 \x01(\x03R\foutputTokens\"@\n" +
+	"\tAgentPlan\x123\n" +
+	"\aentries\x18\x01 \x03(\v2\x19.worker.v1.AgentPlanEntryR\aentries\"^\n" +
+	"\x0eAgentPlanEntry\x12\x18\n" +
+	"\acontent\x18\x01 \x01(\tR\acontent\x12\x1a\n" +
+	"\bpriority\x18\x02 \x01(\tR\bpriority\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\"6\n" +
+	"\rPlanSubmitted\x12%\n" +
+	"\x05plans\x18\x01 \x03(\v2\x0f.worker.v1.PlanR\x05plans\"x\n" +
+	"\x04Plan\x12\x1b\n" +
+	"\tthread_id\x18\x01 \x01(\tR\bthreadId\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x12\n" +
+	"\x04body\x18\x03 \x01(\tR\x04body\x12)\n" +
+	"\x05steps\x18\x04 \x03(\v2\x13.worker.v1.PlanStepR\x05steps\"\xa1\x01\n" +
+	"\bPlanStep\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04file\x18\x02 \x01(\tR\x04file\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x1d\n" +
+	"\n" +
+	"depends_on\x18\x04 \x03(\tR\tdependsOn\x12\x14\n" +
+	"\x05agent\x18\x05 \x01(\tR\x05agent\x12\x1a\n" +
+	"\bsubtasks\x18\x06 \x03(\tR\bsubtasks\"l\n" +
 	"\x14SessionStateSnapshot\x123\n" +
-	"\bsessions\x18\x01 \x03(\v2\x17.worker.v1.SessionStateR\bsessions\"\xf3\x01\n" +
+	"\bsessions\x18\x01 \x03(\v2\x17.worker.v1.SessionStateR\bsessions\x12\x1f\n" +
+	"\vinstance_id\x18\x02 \x01(\tR\n" +
+	"instanceId\"\xc5\x04\n" +
 	"\fSessionState\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12&\n" +
@@ -2375,7 +5423,23 @@ const file_worker_v1_worker_service_proto_rawDesc = "" +
 	"\x06status\x18\x03 \x01(\x0e2\x18.worker.v1.SessionStatusR\x06status\x12*\n" +
 	"\x04mode\x18\x04 \x01(\x0e2\x16.worker.v1.SessionModeR\x04mode\x12(\n" +
 	"\x10agent_session_id\x18\x05 \x01(\tR\x0eagentSessionId\x12\x14\n" +
-	"\x05topic\x18\x06 \x01(\tR\x05topic\"R\n" +
+	"\x05topic\x18\x06 \x01(\tR\x05topic\x12\x14\n" +
+	"\x05model\x18\a \x01(\tR\x05model\x12;\n" +
+	"\x06labels\x18\b \x03(\v2#.worker.v1.SessionState.LabelsEntryR\x06labels\x12-\n" +
+	"\x05error\x18\t \x01(\v2\x17.worker.v1.SessionErrorR\x05error\x12*\n" +
+	"\x05modes\x18\n" +
+	" \x03(\v2\x14.worker.v1.AgentModeR\x05modes\x12!\n" +
+	"\fcurrent_mode\x18\v \x01(\tR\vcurrentMode\x12\x1f\n" +
+	"\vstderr_tail\x18\f \x03(\tR\n" +
+	"stderrTail\x12#\n" +
+	"\rlast_sequence\x18\r \x01(\x03R\flastSequence\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"Q\n" +
+	"\tAgentMode\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\"R\n" +
 	"\x0eSessionRemoved\x12\x1d\n" +
 	"\n" +
 	"session_id\x18\x01 \x01(\tR\tsessionId\x12!\n" +
@@ -2397,7 +5461,12 @@ const file_worker_v1_worker_service_proto_rawDesc = "" +
 	"\x16SESSION_STATUS_ERRORED\x10\x06*F\n" +
 	"\vSessionMode\x12\x1c\n" +
 	"\x18SESSION_MODE_UNSPECIFIED\x10\x00\x12\x19\n" +
-	"\x15SESSION_MODE_HEADLESS\x10\x01*\x91\x01\n" +
+	"\x15SESSION_MODE_HEADLESS\x10\x01*y\n" +
+	"\fCancelReason\x12\x1d\n" +
+	"\x19CANCEL_REASON_UNSPECIFIED\x10\x00\x12\x16\n" +
+	"\x12CANCEL_REASON_USER\x10\x01\x12\x19\n" +
+	"\x15CANCEL_REASON_TIMEOUT\x10\x02\x12\x17\n" +
+	"\x13CANCEL_REASON_LIMIT\x10\x03*\x91\x01\n" +
 	"\x0eToolCallStatus\x12 \n" +
 	"\x1cTOOL_CALL_STATUS_UNSPECIFIED\x10\x00\x12 \n" +
 	"\x1cTOOL_CALL_STATUS_IN_PROGRESS\x10\x01\x12\x1e\n" +
@@ -2413,16 +5482,31 @@ const file_worker_v1_worker_service_proto_rawDesc = "" +
 	"\x16TOOL_CALL_KIND_EXECUTE\x10\x06\x12\x18\n" +
 	"\x14TOOL_CALL_KIND_THINK\x10\a\x12\x18\n" +
 	"\x14TOOL_CALL_KIND_FETCH\x10\b\x12\x18\n" +
-	"\x14TOOL_CALL_KIND_OTHER\x10\t2\xf6\x04\n" +
+	"\x14TOOL_CALL_KIND_OTHER\x10\t*Y\n" +
+	"\x12SessionErrorReason\x12$\n" +
+	" SESSION_ERROR_REASON_UNSPECIFIED\x10\x00\x12\x1d\n" +
+	"\x19SESSION_ERROR_REASON_AUTH\x10\x01*\xb6\x01\n" +
+	"\n" +
+	"StopReason\x12\x1b\n" +
+	"\x17STOP_REASON_UNSPECIFIED\x10\x00\x12\x18\n" +
+	"\x14STOP_REASON_END_TURN\x10\x01\x12\x1a\n" +
+	"\x16STOP_REASON_MAX_TOKENS\x10\x02\x12!\n" +
+	"\x1dSTOP_REASON_MAX_TURN_REQUESTS\x10\x03\x12\x17\n" +
+	"\x13STOP_REASON_REFUSAL\x10\x04\x12\x19\n" +
+	"\x15STOP_REASON_CANCELLED\x10\x052\xce\a\n" +
 	"\rWorkerService\x12K\n" +
 	"\n" +
 	"NewSession\x12\x1c.worker.v1.NewSessionRequest\x1a\x1d.worker.v1.NewSessionResponse\"\x00\x12Q\n" +
 	"\fListSessions\x12\x1e.worker.v1.ListSessionsRequest\x1a\x1f.worker.v1.ListSessionsResponse\"\x00\x12L\n" +
 	"\tStateSync\x12\x1b.worker.v1.StateSyncRequest\x1a\x1c.worker.v1.StateSyncResponse\"\x00(\x010\x01\x12W\n" +
 	"\x0eSetSessionMode\x12 .worker.v1.SetSessionModeRequest\x1a!.worker.v1.SetSessionModeResponse\"\x00\x12Z\n" +
-	"\x0fSendUserMessage\x12!.worker.v1.SendUserMessageRequest\x1a\".worker.v1.SendUserMessageResponse\"\x00\x12T\n" +
+	"\x0fSendUserMessage\x12!.worker.v1.SendUserMessageRequest\x1a\".worker.v1.SendUserMessageResponse\"\x00\x12?\n" +
+	"\x06Prompt\x12\x18.worker.v1.PromptRequest\x1a\x19.worker.v1.PromptResponse\"\x00\x12T\n" +
 	"\rCancelSession\x12\x1f.worker.v1.CancelSessionRequest\x1a .worker.v1.CancelSessionResponse\"\x00\x12l\n" +
-	"\x15CheckSessionResumable\x12'.worker.v1.CheckSessionResumableRequest\x1a(.worker.v1.CheckSessionResumableResponse\"\x00B\xb0\x01\n" +
+	"\x15CheckSessionResumable\x12'.worker.v1.CheckSessionResumableRequest\x1a(.worker.v1.CheckSessionResumableResponse\"\x00\x12Z\n" +
+	"\x0fSetAllowedTools\x12!.worker.v1.SetAllowedToolsRequest\x1a\".worker.v1.SetAllowedToolsResponse\"\x00\x12Z\n" +
+	"\x0fSetHostCommands\x12!.worker.v1.SetHostCommandsRequest\x1a\".worker.v1.SetHostCommandsResponse\"\x00\x12]\n" +
+	"\x10GetPendingEvents\x12\".worker.v1.GetPendingEventsRequest\x1a#.worker.v1.GetPendingEventsResponse\"\x00B\xb0\x01\n" +
 	"\rcom.worker.v1B\x12WorkerServiceProtoP\x01ZFgithub.com/sebastianm/flowgentic/internal/proto/gen/worker/v1;workerv1\xa2\x02\x03WXX\xaa\x02\tWorker.V1\xca\x02\tWorker\\V1\xe2\x02\x15Worker\\V1\\GPBMetadata\xea\x02\n" +
 	"Worker::V1b\x06proto3"
 
@@ -2438,98 +5522,192 @@ func file_worker_v1_worker_service_proto_rawDescGZIP() []byte {
 	return file_worker_v1_worker_service_proto_rawDescData
 }
 
-var file_worker_v1_worker_service_proto_enumTypes = make([]protoimpl.EnumInfo, 4)
-var file_worker_v1_worker_service_proto_msgTypes = make([]protoimpl.MessageInfo, 31)
+var file_worker_v1_worker_service_proto_enumTypes = make([]protoimpl.EnumInfo, 7)
+var file_worker_v1_worker_service_proto_msgTypes = make([]protoimpl.MessageInfo, 71)
 var file_worker_v1_worker_service_proto_goTypes = []any{
 	(SessionStatus)(0),                    // 0: worker.v1.SessionStatus
 	(SessionMode)(0),                      // 1: worker.v1.SessionMode
-	(ToolCallStatus)(0),                   // 2: worker.v1.ToolCallStatus
-	(ToolCallKind)(0),                     // 3: worker.v1.ToolCallKind
-	(*SendUserMessageRequest)(nil),        // 4: worker.v1.SendUserMessageRequest
-	(*ContentBlock)(nil),                  // 5: worker.v1.ContentBlock
-	(*SendUserMessageResponse)(nil),       // 6: worker.v1.SendUserMessageResponse
-	(*CancelSessionRequest)(nil),          // 7: worker.v1.CancelSessionRequest
-	(*CancelSessionResponse)(nil),         // 8: worker.v1.CancelSessionResponse
-	(*SetSessionModeRequest)(nil),         // 9: worker.v1.SetSessionModeRequest
-	(*SetSessionModeResponse)(nil),        // 10: worker.v1.SetSessionModeResponse
-	(*NewSessionRequest)(nil),             // 11: worker.v1.NewSessionRequest
-	(*NewSessionResponse)(nil),            // 12: worker.v1.NewSessionResponse
-	(*SessionInfo)(nil),                   // 13: worker.v1.SessionInfo
-	(*ListSessionsRequest)(nil),           // 14: worker.v1.ListSessionsRequest
-	(*ListSessionsResponse)(nil),          // 15: worker.v1.ListSessionsResponse
-	(*StateSyncRequest)(nil),              // 16: worker.v1.StateSyncRequest
-	(*StateSyncResponse)(nil),             // 17: worker.v1.StateSyncResponse
-	(*SessionEvent)(nil),                  // 18: worker.v1.SessionEvent
-	(*AgentMessageChunk)(nil),             // 19: worker.v1.AgentMessageChunk
-	(*AgentThoughtChunk)(nil),             // 20: worker.v1.AgentThoughtChunk
-	(*UserMessage)(nil),                   // 21: worker.v1.UserMessage
-	(*ToolCall)(nil),                      // 22: worker.v1.ToolCall
-	(*ToolCallUpdate)(nil),                // 23: worker.v1.ToolCallUpdate
-	(*ToolCallContentBlock)(nil),          // 24: worker.v1.ToolCallContentBlock
-	(*ToolCallDiff)(nil),                  // 25: worker.v1.ToolCallDiff
-	(*ToolCallText)(nil),                  // 26: worker.v1.ToolCallText
-	(*ToolCallLocation)(nil),              // 27: worker.v1.ToolCallLocation
-	(*StatusChange)(nil),                  // 28: worker.v1.StatusChange
-	(*CurrentModeUpdate)(nil),             // 29: worker.v1.CurrentModeUpdate
-	(*SessionStateSnapshot)(nil),          // 30: worker.v1.SessionStateSnapshot
-	(*SessionState)(nil),                  // 31: worker.v1.SessionState
-	(*SessionRemoved)(nil),                // 32: worker.v1.SessionRemoved
-	(*CheckSessionResumableRequest)(nil),  // 33: worker.v1.CheckSessionResumableRequest
-	(*CheckSessionResumableResponse)(nil), // 34: worker.v1.CheckSessionResumableResponse
-	(Agent)(0),                            // 35: worker.v1.Agent
+	(CancelReason)(0),                     // 2: worker.v1.CancelReason
+	(ToolCallStatus)(0),                   // 3: worker.v1.ToolCallStatus
+	(ToolCallKind)(0),                     // 4: worker.v1.ToolCallKind
+	(SessionErrorReason)(0),               // 5: worker.v1.SessionErrorReason
+	(StopReason)(0),                       // 6: worker.v1.StopReason
+	(*SendUserMessageRequest)(nil),        // 7: worker.v1.SendUserMessageRequest
+	(*ContentBlock)(nil),                  // 8: worker.v1.ContentBlock
+	(*SendUserMessageResponse)(nil),       // 9: worker.v1.SendUserMessageResponse
+	(*PromptRequest)(nil),                 // 10: worker.v1.PromptRequest
+	(*PromptResponse)(nil),                // 11: worker.v1.PromptResponse
+	(*CancelSessionRequest)(nil),          // 12: worker.v1.CancelSessionRequest
+	(*CancelSessionResponse)(nil),         // 13: worker.v1.CancelSessionResponse
+	(*SetSessionModeRequest)(nil),         // 14: worker.v1.SetSessionModeRequest
+	(*SetSessionModeResponse)(nil),        // 15: worker.v1.SetSessionModeResponse
+	(*SetAllowedToolsRequest)(nil),        // 16: worker.v1.SetAllowedToolsRequest
+	(*SetAllowedToolsResponse)(nil),       // 17: worker.v1.SetAllowedToolsResponse
+	(*SetHostCommandsRequest)(nil),        // 18: worker.v1.SetHostCommandsRequest
+	(*SetHostCommandsResponse)(nil),       // 19: worker.v1.SetHostCommandsResponse
+	(*HostCommand)(nil),                   // 20: worker.v1.HostCommand
+	(*GetPendingEventsRequest)(nil),       // 21: worker.v1.GetPendingEventsRequest
+	(*GetPendingEventsResponse)(nil),      // 22: worker.v1.GetPendingEventsResponse
+	(*NewSessionRequest)(nil),             // 23: worker.v1.NewSessionRequest
+	(*AutoContinue)(nil),                  // 24: worker.v1.AutoContinue
+	(*NewSessionResponse)(nil),            // 25: worker.v1.NewSessionResponse
+	(*SessionInfo)(nil),                   // 26: worker.v1.SessionInfo
+	(*ListSessionsRequest)(nil),           // 27: worker.v1.ListSessionsRequest
+	(*ListSessionsResponse)(nil),          // 28: worker.v1.ListSessionsResponse
+	(*StateSyncRequest)(nil),              // 29: worker.v1.StateSyncRequest
+	(*StateSyncResponse)(nil),             // 30: worker.v1.StateSyncResponse
+	(*SessionEvent)(nil),                  // 31: worker.v1.SessionEvent
+	(*AgentMessageChunk)(nil),             // 32: worker.v1.AgentMessageChunk
+	(*AgentThoughtChunk)(nil),             // 33: worker.v1.AgentThoughtChunk
+	(*UserMessage)(nil),                   // 34: worker.v1.UserMessage
+	(*ToolCall)(nil),                      // 35: worker.v1.ToolCall
+	(*ToolCallUpdate)(nil),                // 36: worker.v1.ToolCallUpdate
+	(*ToolCallContentBlock)(nil),          // 37: worker.v1.ToolCallContentBlock
+	(*ToolCallDiff)(nil),                  // 38: worker.v1.ToolCallDiff
+	(*ToolCallText)(nil),                  // 39: worker.v1.ToolCallText
+	(*ToolCallCommandOutput)(nil),         // 40: worker.v1.ToolCallCommandOutput
+	(*ToolInput)(nil),                     // 41: worker.v1.ToolInput
+	(*ToolInputRead)(nil),                 // 42: worker.v1.ToolInputRead
+	(*ToolInputWrite)(nil),                // 43: worker.v1.ToolInputWrite
+	(*ToolInputEdit)(nil),                 // 44: worker.v1.ToolInputEdit
+	(*ToolInputBash)(nil),                 // 45: worker.v1.ToolInputBash
+	(*ToolInputGrep)(nil),                 // 46: worker.v1.ToolInputGrep
+	(*ToolInputGlob)(nil),                 // 47: worker.v1.ToolInputGlob
+	(*ToolCallLocation)(nil),              // 48: worker.v1.ToolCallLocation
+	(*StatusChange)(nil),                  // 49: worker.v1.StatusChange
+	(*CurrentModeUpdate)(nil),             // 50: worker.v1.CurrentModeUpdate
+	(*CurrentModelUpdate)(nil),            // 51: worker.v1.CurrentModelUpdate
+	(*SessionError)(nil),                  // 52: worker.v1.SessionError
+	(*PermissionRequest)(nil),             // 53: worker.v1.PermissionRequest
+	(*PermissionOption)(nil),              // 54: worker.v1.PermissionOption
+	(*PermissionResolved)(nil),            // 55: worker.v1.PermissionResolved
+	(*EventsPruned)(nil),                  // 56: worker.v1.EventsPruned
+	(*McpServerStartup)(nil),              // 57: worker.v1.McpServerStartup
+	(*SessionInit)(nil),                   // 58: worker.v1.SessionInit
+	(*Progress)(nil),                      // 59: worker.v1.Progress
+	(*TurnEnded)(nil),                     // 60: worker.v1.TurnEnded
+	(*TurnStats)(nil),                     // 61: worker.v1.TurnStats
+	(*AgentPlan)(nil),                     // 62: worker.v1.AgentPlan
+	(*AgentPlanEntry)(nil),                // 63: worker.v1.AgentPlanEntry
+	(*PlanSubmitted)(nil),                 // 64: worker.v1.PlanSubmitted
+	(*Plan)(nil),                          // 65: worker.v1.Plan
+	(*PlanStep)(nil),                      // 66: worker.v1.PlanStep
+	(*SessionStateSnapshot)(nil),          // 67: worker.v1.SessionStateSnapshot
+	(*SessionState)(nil),                  // 68: worker.v1.SessionState
+	(*AgentMode)(nil),                     // 69: worker.v1.AgentMode
+	(*SessionRemoved)(nil),                // 70: worker.v1.SessionRemoved
+	(*CheckSessionResumableRequest)(nil),  // 71: worker.v1.CheckSessionResumableRequest
+	(*CheckSessionResumableResponse)(nil), // 72: worker.v1.CheckSessionResumableResponse
+	nil,                                   // 73: worker.v1.NewSessionRequest.LabelsEntry
+	nil,                                   // 74: worker.v1.SessionInfo.LabelsEntry
+	nil,                                   // 75: worker.v1.ToolCall.MetadataEntry
+	nil,                                   // 76: worker.v1.ToolCallUpdate.MetadataEntry
+	nil,                                   // 77: worker.v1.SessionState.LabelsEntry
+	(Agent)(0),                            // 78: worker.v1.Agent
 }
 var file_worker_v1_worker_service_proto_depIdxs = []int32{
-	5,  // 0: worker.v1.SendUserMessageRequest.content_blocks:type_name -> worker.v1.ContentBlock
-	35, // 1: worker.v1.NewSessionRequest.agent:type_name -> worker.v1.Agent
-	35, // 2: worker.v1.NewSessionResponse.agent:type_name -> worker.v1.Agent
-	35, // 3: worker.v1.SessionInfo.agent:type_name -> worker.v1.Agent
-	0,  // 4: worker.v1.SessionInfo.status:type_name -> worker.v1.SessionStatus
-	1,  // 5: worker.v1.SessionInfo.mode:type_name -> worker.v1.SessionMode
-	13, // 6: worker.v1.ListSessionsResponse.sessions:type_name -> worker.v1.SessionInfo
-	30, // 7: worker.v1.StateSyncResponse.snapshot:type_name -> worker.v1.SessionStateSnapshot
-	31, // 8: worker.v1.StateSyncResponse.session_update:type_name -> worker.v1.SessionState
-	32, // 9: worker.v1.StateSyncResponse.session_removed:type_name -> worker.v1.SessionRemoved
-	18, // 10: worker.v1.StateSyncResponse.session_event:type_name -> worker.v1.SessionEvent
-	19, // 11: worker.v1.SessionEvent.agent_message_chunk:type_name -> worker.v1.AgentMessageChunk
-	20, // 12: worker.v1.SessionEvent.agent_thought_chunk:type_name -> worker.v1.AgentThoughtChunk
-	22, // 13: worker.v1.SessionEvent.tool_call:type_name -> worker.v1.ToolCall
-	23, // 14: worker.v1.SessionEvent.tool_call_update:type_name -> worker.v1.ToolCallUpdate
-	28, // 15: worker.v1.SessionEvent.status_change:type_name -> worker.v1.StatusChange
-	29, // 16: worker.v1.SessionEvent.current_mode_update:type_name -> worker.v1.CurrentModeUpdate
-	21, // 17: worker.v1.SessionEvent.user_message:type_name -> worker.v1.UserMessage
-	3,  // 18: worker.v1.ToolCall.kind:type_name -> worker.v1.ToolCallKind
-	27, // 19: worker.v1.ToolCall.locations:type_name -> worker.v1.ToolCallLocation
-	2,  // 20: worker.v1.ToolCall.status:type_name -> worker.v1.ToolCallStatus
-	24, // 21: worker.v1.ToolCall.content:type_name -> worker.v1.ToolCallContentBlock
-	2,  // 22: worker.v1.ToolCallUpdate.status:type_name -> worker.v1.ToolCallStatus
-	27, // 23: worker.v1.ToolCallUpdate.locations:type_name -> worker.v1.ToolCallLocation
-	24, // 24: worker.v1.ToolCallUpdate.content:type_name -> worker.v1.ToolCallContentBlock
-	25, // 25: worker.v1.ToolCallContentBlock.diff:type_name -> worker.v1.ToolCallDiff
-	26, // 26: worker.v1.ToolCallContentBlock.text:type_name -> worker.v1.ToolCallText
-	0,  // 27: worker.v1.StatusChange.status:type_name -> worker.v1.SessionStatus
-	31, // 28: worker.v1.SessionStateSnapshot.sessions:type_name -> worker.v1.SessionState
-	35, // 29: worker.v1.SessionState.agent:type_name -> worker.v1.Agent
-	0,  // 30: worker.v1.SessionState.status:type_name -> worker.v1.SessionStatus
-	1,  // 31: worker.v1.SessionState.mode:type_name -> worker.v1.SessionMode
-	11, // 32: worker.v1.WorkerService.NewSession:input_type -> worker.v1.NewSessionRequest
-	14, // 33: worker.v1.WorkerService.ListSessions:input_type -> worker.v1.ListSessionsRequest
-	16, // 34: worker.v1.WorkerService.StateSync:input_type -> worker.v1.StateSyncRequest
-	9,  // 35: worker.v1.WorkerService.SetSessionMode:input_type -> worker.v1.SetSessionModeRequest
-	4,  // 36: worker.v1.WorkerService.SendUserMessage:input_type -> worker.v1.SendUserMessageRequest
-	7,  // 37: worker.v1.WorkerService.CancelSession:input_type -> worker.v1.CancelSessionRequest
-	33, // 38: worker.v1.WorkerService.CheckSessionResumable:input_type -> worker.v1.CheckSessionResumableRequest
-	12, // 39: worker.v1.WorkerService.NewSession:output_type -> worker.v1.NewSessionResponse
-	15, // 40: worker.v1.WorkerService.ListSessions:output_type -> worker.v1.ListSessionsResponse
-	17, // 41: worker.v1.WorkerService.StateSync:output_type -> worker.v1.StateSyncResponse
-	10, // 42: worker.v1.WorkerService.SetSessionMode:output_type -> worker.v1.SetSessionModeResponse
-	6,  // 43: worker.v1.WorkerService.SendUserMessage:output_type -> worker.v1.SendUserMessageResponse
-	8,  // 44: worker.v1.WorkerService.CancelSession:output_type -> worker.v1.CancelSessionResponse
-	34, // 45: worker.v1.WorkerService.CheckSessionResumable:output_type -> worker.v1.CheckSessionResumableResponse
-	39, // [39:46] is the sub-list for method output_type
-	32, // [32:39] is the sub-list for method input_type
-	32, // [32:32] is the sub-list for extension type_name
-	32, // [32:32] is the sub-list for extension extendee
-	0,  // [0:32] is the sub-list for field type_name
+	8,  // 0: worker.v1.SendUserMessageRequest.content_blocks:type_name -> worker.v1.ContentBlock
+	8,  // 1: worker.v1.PromptRequest.content_blocks:type_name -> worker.v1.ContentBlock
+	2,  // 2: worker.v1.CancelSessionRequest.reason:type_name -> worker.v1.CancelReason
+	20, // 3: worker.v1.SetHostCommandsRequest.commands:type_name -> worker.v1.HostCommand
+	31, // 4: worker.v1.GetPendingEventsResponse.events:type_name -> worker.v1.SessionEvent
+	78, // 5: worker.v1.NewSessionRequest.agent:type_name -> worker.v1.Agent
+	73, // 6: worker.v1.NewSessionRequest.labels:type_name -> worker.v1.NewSessionRequest.LabelsEntry
+	24, // 7: worker.v1.NewSessionRequest.auto_continue:type_name -> worker.v1.AutoContinue
+	78, // 8: worker.v1.NewSessionResponse.agent:type_name -> worker.v1.Agent
+	78, // 9: worker.v1.SessionInfo.agent:type_name -> worker.v1.Agent
+	0,  // 10: worker.v1.SessionInfo.status:type_name -> worker.v1.SessionStatus
+	1,  // 11: worker.v1.SessionInfo.mode:type_name -> worker.v1.SessionMode
+	74, // 12: worker.v1.SessionInfo.labels:type_name -> worker.v1.SessionInfo.LabelsEntry
+	6,  // 13: worker.v1.SessionInfo.last_stop_reason:type_name -> worker.v1.StopReason
+	26, // 14: worker.v1.ListSessionsResponse.sessions:type_name -> worker.v1.SessionInfo
+	67, // 15: worker.v1.StateSyncResponse.snapshot:type_name -> worker.v1.SessionStateSnapshot
+	68, // 16: worker.v1.StateSyncResponse.session_update:type_name -> worker.v1.SessionState
+	70, // 17: worker.v1.StateSyncResponse.session_removed:type_name -> worker.v1.SessionRemoved
+	31, // 18: worker.v1.StateSyncResponse.session_event:type_name -> worker.v1.SessionEvent
+	32, // 19: worker.v1.SessionEvent.agent_message_chunk:type_name -> worker.v1.AgentMessageChunk
+	33, // 20: worker.v1.SessionEvent.agent_thought_chunk:type_name -> worker.v1.AgentThoughtChunk
+	35, // 21: worker.v1.SessionEvent.tool_call:type_name -> worker.v1.ToolCall
+	36, // 22: worker.v1.SessionEvent.tool_call_update:type_name -> worker.v1.ToolCallUpdate
+	49, // 23: worker.v1.SessionEvent.status_change:type_name -> worker.v1.StatusChange
+	50, // 24: worker.v1.SessionEvent.current_mode_update:type_name -> worker.v1.CurrentModeUpdate
+	34, // 25: worker.v1.SessionEvent.user_message:type_name -> worker.v1.UserMessage
+	51, // 26: worker.v1.SessionEvent.current_model_update:type_name -> worker.v1.CurrentModelUpdate
+	52, // 27: worker.v1.SessionEvent.session_error:type_name -> worker.v1.SessionError
+	53, // 28: worker.v1.SessionEvent.permission_request:type_name -> worker.v1.PermissionRequest
+	55, // 29: worker.v1.SessionEvent.permission_resolved:type_name -> worker.v1.PermissionResolved
+	56, // 30: worker.v1.SessionEvent.events_pruned:type_name -> worker.v1.EventsPruned
+	64, // 31: worker.v1.SessionEvent.plan_submitted:type_name -> worker.v1.PlanSubmitted
+	57, // 32: worker.v1.SessionEvent.mcp_server_startup:type_name -> worker.v1.McpServerStartup
+	59, // 33: worker.v1.SessionEvent.progress:type_name -> worker.v1.Progress
+	60, // 34: worker.v1.SessionEvent.turn_ended:type_name -> worker.v1.TurnEnded
+	62, // 35: worker.v1.SessionEvent.agent_plan:type_name -> worker.v1.AgentPlan
+	58, // 36: worker.v1.SessionEvent.session_init:type_name -> worker.v1.SessionInit
+	4,  // 37: worker.v1.ToolCall.kind:type_name -> worker.v1.ToolCallKind
+	48, // 38: worker.v1.ToolCall.locations:type_name -> worker.v1.ToolCallLocation
+	3,  // 39: worker.v1.ToolCall.status:type_name -> worker.v1.ToolCallStatus
+	37, // 40: worker.v1.ToolCall.content:type_name -> worker.v1.ToolCallContentBlock
+	41, // 41: worker.v1.ToolCall.input:type_name -> worker.v1.ToolInput
+	75, // 42: worker.v1.ToolCall.metadata:type_name -> worker.v1.ToolCall.MetadataEntry
+	3,  // 43: worker.v1.ToolCallUpdate.status:type_name -> worker.v1.ToolCallStatus
+	48, // 44: worker.v1.ToolCallUpdate.locations:type_name -> worker.v1.ToolCallLocation
+	37, // 45: worker.v1.ToolCallUpdate.content:type_name -> worker.v1.ToolCallContentBlock
+	41, // 46: worker.v1.ToolCallUpdate.input:type_name -> worker.v1.ToolInput
+	76, // 47: worker.v1.ToolCallUpdate.metadata:type_name -> worker.v1.ToolCallUpdate.MetadataEntry
+	38, // 48: worker.v1.ToolCallContentBlock.diff:type_name -> worker.v1.ToolCallDiff
+	39, // 49: worker.v1.ToolCallContentBlock.text:type_name -> worker.v1.ToolCallText
+	40, // 50: worker.v1.ToolCallContentBlock.command_output:type_name -> worker.v1.ToolCallCommandOutput
+	42, // 51: worker.v1.ToolInput.read:type_name -> worker.v1.ToolInputRead
+	43, // 52: worker.v1.ToolInput.write:type_name -> worker.v1.ToolInputWrite
+	44, // 53: worker.v1.ToolInput.edit:type_name -> worker.v1.ToolInputEdit
+	45, // 54: worker.v1.ToolInput.bash:type_name -> worker.v1.ToolInputBash
+	46, // 55: worker.v1.ToolInput.grep:type_name -> worker.v1.ToolInputGrep
+	47, // 56: worker.v1.ToolInput.glob:type_name -> worker.v1.ToolInputGlob
+	0,  // 57: worker.v1.StatusChange.status:type_name -> worker.v1.SessionStatus
+	52, // 58: worker.v1.StatusChange.error:type_name -> worker.v1.SessionError
+	5,  // 59: worker.v1.SessionError.reason:type_name -> worker.v1.SessionErrorReason
+	4,  // 60: worker.v1.PermissionRequest.kind:type_name -> worker.v1.ToolCallKind
+	54, // 61: worker.v1.PermissionRequest.options:type_name -> worker.v1.PermissionOption
+	6,  // 62: worker.v1.TurnEnded.stop_reason:type_name -> worker.v1.StopReason
+	2,  // 63: worker.v1.TurnEnded.cancel_reason:type_name -> worker.v1.CancelReason
+	61, // 64: worker.v1.TurnEnded.stats:type_name -> worker.v1.TurnStats
+	63, // 65: worker.v1.AgentPlan.entries:type_name -> worker.v1.AgentPlanEntry
+	65, // 66: worker.v1.PlanSubmitted.plans:type_name -> worker.v1.Plan
+	66, // 67: worker.v1.Plan.steps:type_name -> worker.v1.PlanStep
+	68, // 68: worker.v1.SessionStateSnapshot.sessions:type_name -> worker.v1.SessionState
+	78, // 69: worker.v1.SessionState.agent:type_name -> worker.v1.Agent
+	0,  // 70: worker.v1.SessionState.status:type_name -> worker.v1.SessionStatus
+	1,  // 71: worker.v1.SessionState.mode:type_name -> worker.v1.SessionMode
+	77, // 72: worker.v1.SessionState.labels:type_name -> worker.v1.SessionState.LabelsEntry
+	52, // 73: worker.v1.SessionState.error:type_name -> worker.v1.SessionError
+	69, // 74: worker.v1.SessionState.modes:type_name -> worker.v1.AgentMode
+	23, // 75: worker.v1.WorkerService.NewSession:input_type -> worker.v1.NewSessionRequest
+	27, // 76: worker.v1.WorkerService.ListSessions:input_type -> worker.v1.ListSessionsRequest
+	29, // 77: worker.v1.WorkerService.StateSync:input_type -> worker.v1.StateSyncRequest
+	14, // 78: worker.v1.WorkerService.SetSessionMode:input_type -> worker.v1.SetSessionModeRequest
+	7,  // 79: worker.v1.WorkerService.SendUserMessage:input_type -> worker.v1.SendUserMessageRequest
+	10, // 80: worker.v1.WorkerService.Prompt:input_type -> worker.v1.PromptRequest
+	12, // 81: worker.v1.WorkerService.CancelSession:input_type -> worker.v1.CancelSessionRequest
+	71, // 82: worker.v1.WorkerService.CheckSessionResumable:input_type -> worker.v1.CheckSessionResumableRequest
+	16, // 83: worker.v1.WorkerService.SetAllowedTools:input_type -> worker.v1.SetAllowedToolsRequest
+	18, // 84: worker.v1.WorkerService.SetHostCommands:input_type -> worker.v1.SetHostCommandsRequest
+	21, // 85: worker.v1.WorkerService.GetPendingEvents:input_type -> worker.v1.GetPendingEventsRequest
+	25, // 86: worker.v1.WorkerService.NewSession:output_type -> worker.v1.NewSessionResponse
+	28, // 87: worker.v1.WorkerService.ListSessions:output_type -> worker.v1.ListSessionsResponse
+	30, // 88: worker.v1.WorkerService.StateSync:output_type -> worker.v1.StateSyncResponse
+	15, // 89: worker.v1.WorkerService.SetSessionMode:output_type -> worker.v1.SetSessionModeResponse
+	9,  // 90: worker.v1.WorkerService.SendUserMessage:output_type -> worker.v1.SendUserMessageResponse
+	11, // 91: worker.v1.WorkerService.Prompt:output_type -> worker.v1.PromptResponse
+	13, // 92: worker.v1.WorkerService.CancelSession:output_type -> worker.v1.CancelSessionResponse
+	72, // 93: worker.v1.WorkerService.CheckSessionResumable:output_type -> worker.v1.CheckSessionResumableResponse
+	17, // 94: worker.v1.WorkerService.SetAllowedTools:output_type -> worker.v1.SetAllowedToolsResponse
+	19, // 95: worker.v1.WorkerService.SetHostCommands:output_type -> worker.v1.SetHostCommandsResponse
+	22, // 96: worker.v1.WorkerService.GetPendingEvents:output_type -> worker.v1.GetPendingEventsResponse
+	86, // [86:97] is the sub-list for method output_type
+	75, // [75:86] is the sub-list for method input_type
+	75, // [75:75] is the sub-list for extension type_name
+	75, // [75:75] is the sub-list for extension extendee
+	0,  // [0:75] is the sub-list for field type_name
 }
 
 func init() { file_worker_v1_worker_service_proto_init() }
@@ -2538,13 +5716,13 @@ func file_worker_v1_worker_service_proto_init() {
 		return
 	}
 	file_worker_v1_agent_proto_init()
-	file_worker_v1_worker_service_proto_msgTypes[13].OneofWrappers = []any{
+	file_worker_v1_worker_service_proto_msgTypes[23].OneofWrappers = []any{
 		(*StateSyncResponse_Snapshot)(nil),
 		(*StateSyncResponse_SessionUpdate)(nil),
 		(*StateSyncResponse_SessionRemoved)(nil),
 		(*StateSyncResponse_SessionEvent)(nil),
 	}
-	file_worker_v1_worker_service_proto_msgTypes[14].OneofWrappers = []any{
+	file_worker_v1_worker_service_proto_msgTypes[24].OneofWrappers = []any{
 		(*SessionEvent_AgentMessageChunk)(nil),
 		(*SessionEvent_AgentThoughtChunk)(nil),
 		(*SessionEvent_ToolCall)(nil),
@@ -2552,18 +5730,42 @@ func file_worker_v1_worker_service_proto_init() {
 		(*SessionEvent_StatusChange)(nil),
 		(*SessionEvent_CurrentModeUpdate)(nil),
 		(*SessionEvent_UserMessage)(nil),
-	}
-	file_worker_v1_worker_service_proto_msgTypes[20].OneofWrappers = []any{
+		(*SessionEvent_CurrentModelUpdate)(nil),
+		(*SessionEvent_SessionError)(nil),
+		(*SessionEvent_PermissionRequest)(nil),
+		(*SessionEvent_PermissionResolved)(nil),
+		(*SessionEvent_EventsPruned)(nil),
+		(*SessionEvent_PlanSubmitted)(nil),
+		(*SessionEvent_McpServerStartup)(nil),
+		(*SessionEvent_Progress)(nil),
+		(*SessionEvent_TurnEnded)(nil),
+		(*SessionEvent_AgentPlan)(nil),
+		(*SessionEvent_SessionInit)(nil),
+	}
+	file_worker_v1_worker_service_proto_msgTypes[30].OneofWrappers = []any{
 		(*ToolCallContentBlock_Diff)(nil),
 		(*ToolCallContentBlock_Text)(nil),
-	}
+		(*ToolCallContentBlock_CommandOutput)(nil),
+	}
+	file_worker_v1_worker_service_proto_msgTypes[33].OneofWrappers = []any{}
+	file_worker_v1_worker_service_proto_msgTypes[34].OneofWrappers = []any{
+		(*ToolInput_Read)(nil),
+		(*ToolInput_Write)(nil),
+		(*ToolInput_Edit)(nil),
+		(*ToolInput_Bash)(nil),
+		(*ToolInput_Grep)(nil),
+		(*ToolInput_Glob)(nil),
+	}
+	file_worker_v1_worker_service_proto_msgTypes[35].OneofWrappers = []any{}
+	file_worker_v1_worker_service_proto_msgTypes[38].OneofWrappers = []any{}
+	file_worker_v1_worker_service_proto_msgTypes[52].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_worker_v1_worker_service_proto_rawDesc), len(file_worker_v1_worker_service_proto_rawDesc)),
-			NumEnums:      4,
-			NumMessages:   31,
+			NumEnums:      7,
+			NumMessages:   71,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
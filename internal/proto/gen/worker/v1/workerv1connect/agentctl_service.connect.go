@@ -42,6 +42,9 @@ const (
 	// AgentCtlServiceSubmitPlanProcedure is the fully-qualified name of the AgentCtlService's
 	// SubmitPlan RPC.
 	AgentCtlServiceSubmitPlanProcedure = "/worker.v1.AgentCtlService/SubmitPlan"
+	// AgentCtlServiceReportProgressProcedure is the fully-qualified name of the AgentCtlService's
+	// ReportProgress RPC.
+	AgentCtlServiceReportProgressProcedure = "/worker.v1.AgentCtlService/ReportProgress"
 )
 
 // AgentCtlServiceClient is a client for the worker.v1.AgentCtlService service.
@@ -51,6 +54,8 @@ type AgentCtlServiceClient interface {
 	ReportStatus(context.Context, *connect.Request[v1.ReportStatusRequest]) (*connect.Response[v1.ReportStatusResponse], error)
 	// SubmitPlan receives a plan submission from an agent process for human review.
 	SubmitPlan(context.Context, *connect.Request[v1.SubmitPlanRequest]) (*connect.Response[v1.SubmitPlanResponse], error)
+	// ReportProgress receives an intermediate progress report from an agent process.
+	ReportProgress(context.Context, *connect.Request[v1.ReportProgressRequest]) (*connect.Response[v1.ReportProgressResponse], error)
 }
 
 // NewAgentCtlServiceClient constructs a client for the worker.v1.AgentCtlService service. By
@@ -82,14 +87,21 @@ func NewAgentCtlServiceClient(httpClient connect.HTTPClient, baseURL string, opt
 			connect.WithSchema(agentCtlServiceMethods.ByName("SubmitPlan")),
 			connect.WithClientOptions(opts...),
 		),
+		reportProgress: connect.NewClient[v1.ReportProgressRequest, v1.ReportProgressResponse](
+			httpClient,
+			baseURL+AgentCtlServiceReportProgressProcedure,
+			connect.WithSchema(agentCtlServiceMethods.ByName("ReportProgress")),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
 // agentCtlServiceClient implements AgentCtlServiceClient.
 type agentCtlServiceClient struct {
-	setTopic     *connect.Client[v1.SetTopicRequest, v1.SetTopicResponse]
-	reportStatus *connect.Client[v1.ReportStatusRequest, v1.ReportStatusResponse]
-	submitPlan   *connect.Client[v1.SubmitPlanRequest, v1.SubmitPlanResponse]
+	setTopic       *connect.Client[v1.SetTopicRequest, v1.SetTopicResponse]
+	reportStatus   *connect.Client[v1.ReportStatusRequest, v1.ReportStatusResponse]
+	submitPlan     *connect.Client[v1.SubmitPlanRequest, v1.SubmitPlanResponse]
+	reportProgress *connect.Client[v1.ReportProgressRequest, v1.ReportProgressResponse]
 }
 
 // SetTopic calls worker.v1.AgentCtlService.SetTopic.
@@ -107,6 +119,11 @@ func (c *agentCtlServiceClient) SubmitPlan(ctx context.Context, req *connect.Req
 	return c.submitPlan.CallUnary(ctx, req)
 }
 
+// ReportProgress calls worker.v1.AgentCtlService.ReportProgress.
+func (c *agentCtlServiceClient) ReportProgress(ctx context.Context, req *connect.Request[v1.ReportProgressRequest]) (*connect.Response[v1.ReportProgressResponse], error) {
+	return c.reportProgress.CallUnary(ctx, req)
+}
+
 // AgentCtlServiceHandler is an implementation of the worker.v1.AgentCtlService service.
 type AgentCtlServiceHandler interface {
 	SetTopic(context.Context, *connect.Request[v1.SetTopicRequest]) (*connect.Response[v1.SetTopicResponse], error)
@@ -114,6 +131,8 @@ type AgentCtlServiceHandler interface {
 	ReportStatus(context.Context, *connect.Request[v1.ReportStatusRequest]) (*connect.Response[v1.ReportStatusResponse], error)
 	// SubmitPlan receives a plan submission from an agent process for human review.
 	SubmitPlan(context.Context, *connect.Request[v1.SubmitPlanRequest]) (*connect.Response[v1.SubmitPlanResponse], error)
+	// ReportProgress receives an intermediate progress report from an agent process.
+	ReportProgress(context.Context, *connect.Request[v1.ReportProgressRequest]) (*connect.Response[v1.ReportProgressResponse], error)
 }
 
 // NewAgentCtlServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -141,6 +160,12 @@ func NewAgentCtlServiceHandler(svc AgentCtlServiceHandler, opts ...connect.Handl
 		connect.WithSchema(agentCtlServiceMethods.ByName("SubmitPlan")),
 		connect.WithHandlerOptions(opts...),
 	)
+	agentCtlServiceReportProgressHandler := connect.NewUnaryHandler(
+		AgentCtlServiceReportProgressProcedure,
+		svc.ReportProgress,
+		connect.WithSchema(agentCtlServiceMethods.ByName("ReportProgress")),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/worker.v1.AgentCtlService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case AgentCtlServiceSetTopicProcedure:
@@ -149,6 +174,8 @@ func NewAgentCtlServiceHandler(svc AgentCtlServiceHandler, opts ...connect.Handl
 			agentCtlServiceReportStatusHandler.ServeHTTP(w, r)
 		case AgentCtlServiceSubmitPlanProcedure:
 			agentCtlServiceSubmitPlanHandler.ServeHTTP(w, r)
+		case AgentCtlServiceReportProgressProcedure:
+			agentCtlServiceReportProgressHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -169,3 +196,7 @@ func (UnimplementedAgentCtlServiceHandler) ReportStatus(context.Context, *connec
 func (UnimplementedAgentCtlServiceHandler) SubmitPlan(context.Context, *connect.Request[v1.SubmitPlanRequest]) (*connect.Response[v1.SubmitPlanResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worker.v1.AgentCtlService.SubmitPlan is not implemented"))
 }
+
+func (UnimplementedAgentCtlServiceHandler) ReportProgress(context.Context, *connect.Request[v1.ReportProgressRequest]) (*connect.Response[v1.ReportProgressResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worker.v1.AgentCtlService.ReportProgress is not implemented"))
+}
@@ -47,12 +47,23 @@ const (
 	// WorkerServiceSendUserMessageProcedure is the fully-qualified name of the WorkerService's
 	// SendUserMessage RPC.
 	WorkerServiceSendUserMessageProcedure = "/worker.v1.WorkerService/SendUserMessage"
+	// WorkerServicePromptProcedure is the fully-qualified name of the WorkerService's Prompt RPC.
+	WorkerServicePromptProcedure = "/worker.v1.WorkerService/Prompt"
 	// WorkerServiceCancelSessionProcedure is the fully-qualified name of the WorkerService's
 	// CancelSession RPC.
 	WorkerServiceCancelSessionProcedure = "/worker.v1.WorkerService/CancelSession"
 	// WorkerServiceCheckSessionResumableProcedure is the fully-qualified name of the WorkerService's
 	// CheckSessionResumable RPC.
 	WorkerServiceCheckSessionResumableProcedure = "/worker.v1.WorkerService/CheckSessionResumable"
+	// WorkerServiceSetAllowedToolsProcedure is the fully-qualified name of the WorkerService's
+	// SetAllowedTools RPC.
+	WorkerServiceSetAllowedToolsProcedure = "/worker.v1.WorkerService/SetAllowedTools"
+	// WorkerServiceSetHostCommandsProcedure is the fully-qualified name of the WorkerService's
+	// SetHostCommands RPC.
+	WorkerServiceSetHostCommandsProcedure = "/worker.v1.WorkerService/SetHostCommands"
+	// WorkerServiceGetPendingEventsProcedure is the fully-qualified name of the WorkerService's
+	// GetPendingEvents RPC.
+	WorkerServiceGetPendingEventsProcedure = "/worker.v1.WorkerService/GetPendingEvents"
 )
 
 // WorkerServiceClient is a client for the worker.v1.WorkerService service.
@@ -68,10 +79,24 @@ type WorkerServiceClient interface {
 	SetSessionMode(context.Context, *connect.Request[v1.SetSessionModeRequest]) (*connect.Response[v1.SetSessionModeResponse], error)
 	// SendUserMessage sends a follow-up prompt to a running session.
 	SendUserMessage(context.Context, *connect.Request[v1.SendUserMessageRequest]) (*connect.Response[v1.SendUserMessageResponse], error)
+	// Prompt sends a multi-block prompt with optional per-prompt overrides and
+	// returns once the agent finishes the turn.
+	Prompt(context.Context, *connect.Request[v1.PromptRequest]) (*connect.Response[v1.PromptResponse], error)
 	// CancelSession cancels the active prompt on a running session.
 	CancelSession(context.Context, *connect.Request[v1.CancelSessionRequest]) (*connect.Response[v1.CancelSessionResponse], error)
 	// CheckSessionResumable checks if an ACP session can be resumed from disk.
 	CheckSessionResumable(context.Context, *connect.Request[v1.CheckSessionResumableRequest]) (*connect.Response[v1.CheckSessionResumableResponse], error)
+	// SetAllowedTools replaces the tools a running session may use without
+	// asking. Agents that take allowed tools only at launch return
+	// CodeUnimplemented.
+	SetAllowedTools(context.Context, *connect.Request[v1.SetAllowedToolsRequest]) (*connect.Response[v1.SetAllowedToolsResponse], error)
+	// SetHostCommands replaces the slash commands a running session offers in
+	// addition to the agent's own, and announces the combined list to the
+	// session's client as an available-commands update.
+	SetHostCommands(context.Context, *connect.Request[v1.SetHostCommandsRequest]) (*connect.Response[v1.SetHostCommandsResponse], error)
+	// GetPendingEvents returns a session's events the control plane has not
+	// acknowledged, so it can fill gaps after a reconnect.
+	GetPendingEvents(context.Context, *connect.Request[v1.GetPendingEventsRequest]) (*connect.Response[v1.GetPendingEventsResponse], error)
 }
 
 // NewWorkerServiceClient constructs a client for the worker.v1.WorkerService service. By default,
@@ -115,6 +140,12 @@ func NewWorkerServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(workerServiceMethods.ByName("SendUserMessage")),
 			connect.WithClientOptions(opts...),
 		),
+		prompt: connect.NewClient[v1.PromptRequest, v1.PromptResponse](
+			httpClient,
+			baseURL+WorkerServicePromptProcedure,
+			connect.WithSchema(workerServiceMethods.ByName("Prompt")),
+			connect.WithClientOptions(opts...),
+		),
 		cancelSession: connect.NewClient[v1.CancelSessionRequest, v1.CancelSessionResponse](
 			httpClient,
 			baseURL+WorkerServiceCancelSessionProcedure,
@@ -127,6 +158,24 @@ func NewWorkerServiceClient(httpClient connect.HTTPClient, baseURL string, opts
 			connect.WithSchema(workerServiceMethods.ByName("CheckSessionResumable")),
 			connect.WithClientOptions(opts...),
 		),
+		setAllowedTools: connect.NewClient[v1.SetAllowedToolsRequest, v1.SetAllowedToolsResponse](
+			httpClient,
+			baseURL+WorkerServiceSetAllowedToolsProcedure,
+			connect.WithSchema(workerServiceMethods.ByName("SetAllowedTools")),
+			connect.WithClientOptions(opts...),
+		),
+		setHostCommands: connect.NewClient[v1.SetHostCommandsRequest, v1.SetHostCommandsResponse](
+			httpClient,
+			baseURL+WorkerServiceSetHostCommandsProcedure,
+			connect.WithSchema(workerServiceMethods.ByName("SetHostCommands")),
+			connect.WithClientOptions(opts...),
+		),
+		getPendingEvents: connect.NewClient[v1.GetPendingEventsRequest, v1.GetPendingEventsResponse](
+			httpClient,
+			baseURL+WorkerServiceGetPendingEventsProcedure,
+			connect.WithSchema(workerServiceMethods.ByName("GetPendingEvents")),
+			connect.WithClientOptions(opts...),
+		),
 	}
 }
 
@@ -137,8 +186,12 @@ type workerServiceClient struct {
 	stateSync             *connect.Client[v1.StateSyncRequest, v1.StateSyncResponse]
 	setSessionMode        *connect.Client[v1.SetSessionModeRequest, v1.SetSessionModeResponse]
 	sendUserMessage       *connect.Client[v1.SendUserMessageRequest, v1.SendUserMessageResponse]
+	prompt                *connect.Client[v1.PromptRequest, v1.PromptResponse]
 	cancelSession         *connect.Client[v1.CancelSessionRequest, v1.CancelSessionResponse]
 	checkSessionResumable *connect.Client[v1.CheckSessionResumableRequest, v1.CheckSessionResumableResponse]
+	setAllowedTools       *connect.Client[v1.SetAllowedToolsRequest, v1.SetAllowedToolsResponse]
+	setHostCommands       *connect.Client[v1.SetHostCommandsRequest, v1.SetHostCommandsResponse]
+	getPendingEvents      *connect.Client[v1.GetPendingEventsRequest, v1.GetPendingEventsResponse]
 }
 
 // NewSession calls worker.v1.WorkerService.NewSession.
@@ -166,6 +219,11 @@ func (c *workerServiceClient) SendUserMessage(ctx context.Context, req *connect.
 	return c.sendUserMessage.CallUnary(ctx, req)
 }
 
+// Prompt calls worker.v1.WorkerService.Prompt.
+func (c *workerServiceClient) Prompt(ctx context.Context, req *connect.Request[v1.PromptRequest]) (*connect.Response[v1.PromptResponse], error) {
+	return c.prompt.CallUnary(ctx, req)
+}
+
 // CancelSession calls worker.v1.WorkerService.CancelSession.
 func (c *workerServiceClient) CancelSession(ctx context.Context, req *connect.Request[v1.CancelSessionRequest]) (*connect.Response[v1.CancelSessionResponse], error) {
 	return c.cancelSession.CallUnary(ctx, req)
@@ -176,6 +234,21 @@ func (c *workerServiceClient) CheckSessionResumable(ctx context.Context, req *co
 	return c.checkSessionResumable.CallUnary(ctx, req)
 }
 
+// SetAllowedTools calls worker.v1.WorkerService.SetAllowedTools.
+func (c *workerServiceClient) SetAllowedTools(ctx context.Context, req *connect.Request[v1.SetAllowedToolsRequest]) (*connect.Response[v1.SetAllowedToolsResponse], error) {
+	return c.setAllowedTools.CallUnary(ctx, req)
+}
+
+// SetHostCommands calls worker.v1.WorkerService.SetHostCommands.
+func (c *workerServiceClient) SetHostCommands(ctx context.Context, req *connect.Request[v1.SetHostCommandsRequest]) (*connect.Response[v1.SetHostCommandsResponse], error) {
+	return c.setHostCommands.CallUnary(ctx, req)
+}
+
+// GetPendingEvents calls worker.v1.WorkerService.GetPendingEvents.
+func (c *workerServiceClient) GetPendingEvents(ctx context.Context, req *connect.Request[v1.GetPendingEventsRequest]) (*connect.Response[v1.GetPendingEventsResponse], error) {
+	return c.getPendingEvents.CallUnary(ctx, req)
+}
+
 // WorkerServiceHandler is an implementation of the worker.v1.WorkerService service.
 type WorkerServiceHandler interface {
 	// NewSession asks the worker to run an agent workload.
@@ -189,10 +262,24 @@ type WorkerServiceHandler interface {
 	SetSessionMode(context.Context, *connect.Request[v1.SetSessionModeRequest]) (*connect.Response[v1.SetSessionModeResponse], error)
 	// SendUserMessage sends a follow-up prompt to a running session.
 	SendUserMessage(context.Context, *connect.Request[v1.SendUserMessageRequest]) (*connect.Response[v1.SendUserMessageResponse], error)
+	// Prompt sends a multi-block prompt with optional per-prompt overrides and
+	// returns once the agent finishes the turn.
+	Prompt(context.Context, *connect.Request[v1.PromptRequest]) (*connect.Response[v1.PromptResponse], error)
 	// CancelSession cancels the active prompt on a running session.
 	CancelSession(context.Context, *connect.Request[v1.CancelSessionRequest]) (*connect.Response[v1.CancelSessionResponse], error)
 	// CheckSessionResumable checks if an ACP session can be resumed from disk.
 	CheckSessionResumable(context.Context, *connect.Request[v1.CheckSessionResumableRequest]) (*connect.Response[v1.CheckSessionResumableResponse], error)
+	// SetAllowedTools replaces the tools a running session may use without
+	// asking. Agents that take allowed tools only at launch return
+	// CodeUnimplemented.
+	SetAllowedTools(context.Context, *connect.Request[v1.SetAllowedToolsRequest]) (*connect.Response[v1.SetAllowedToolsResponse], error)
+	// SetHostCommands replaces the slash commands a running session offers in
+	// addition to the agent's own, and announces the combined list to the
+	// session's client as an available-commands update.
+	SetHostCommands(context.Context, *connect.Request[v1.SetHostCommandsRequest]) (*connect.Response[v1.SetHostCommandsResponse], error)
+	// GetPendingEvents returns a session's events the control plane has not
+	// acknowledged, so it can fill gaps after a reconnect.
+	GetPendingEvents(context.Context, *connect.Request[v1.GetPendingEventsRequest]) (*connect.Response[v1.GetPendingEventsResponse], error)
 }
 
 // NewWorkerServiceHandler builds an HTTP handler from the service implementation. It returns the
@@ -232,6 +319,12 @@ func NewWorkerServiceHandler(svc WorkerServiceHandler, opts ...connect.HandlerOp
 		connect.WithSchema(workerServiceMethods.ByName("SendUserMessage")),
 		connect.WithHandlerOptions(opts...),
 	)
+	workerServicePromptHandler := connect.NewUnaryHandler(
+		WorkerServicePromptProcedure,
+		svc.Prompt,
+		connect.WithSchema(workerServiceMethods.ByName("Prompt")),
+		connect.WithHandlerOptions(opts...),
+	)
 	workerServiceCancelSessionHandler := connect.NewUnaryHandler(
 		WorkerServiceCancelSessionProcedure,
 		svc.CancelSession,
@@ -244,6 +337,24 @@ func NewWorkerServiceHandler(svc WorkerServiceHandler, opts ...connect.HandlerOp
 		connect.WithSchema(workerServiceMethods.ByName("CheckSessionResumable")),
 		connect.WithHandlerOptions(opts...),
 	)
+	workerServiceSetAllowedToolsHandler := connect.NewUnaryHandler(
+		WorkerServiceSetAllowedToolsProcedure,
+		svc.SetAllowedTools,
+		connect.WithSchema(workerServiceMethods.ByName("SetAllowedTools")),
+		connect.WithHandlerOptions(opts...),
+	)
+	workerServiceSetHostCommandsHandler := connect.NewUnaryHandler(
+		WorkerServiceSetHostCommandsProcedure,
+		svc.SetHostCommands,
+		connect.WithSchema(workerServiceMethods.ByName("SetHostCommands")),
+		connect.WithHandlerOptions(opts...),
+	)
+	workerServiceGetPendingEventsHandler := connect.NewUnaryHandler(
+		WorkerServiceGetPendingEventsProcedure,
+		svc.GetPendingEvents,
+		connect.WithSchema(workerServiceMethods.ByName("GetPendingEvents")),
+		connect.WithHandlerOptions(opts...),
+	)
 	return "/worker.v1.WorkerService/", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		switch r.URL.Path {
 		case WorkerServiceNewSessionProcedure:
@@ -256,10 +367,18 @@ func NewWorkerServiceHandler(svc WorkerServiceHandler, opts ...connect.HandlerOp
 			workerServiceSetSessionModeHandler.ServeHTTP(w, r)
 		case WorkerServiceSendUserMessageProcedure:
 			workerServiceSendUserMessageHandler.ServeHTTP(w, r)
+		case WorkerServicePromptProcedure:
+			workerServicePromptHandler.ServeHTTP(w, r)
 		case WorkerServiceCancelSessionProcedure:
 			workerServiceCancelSessionHandler.ServeHTTP(w, r)
 		case WorkerServiceCheckSessionResumableProcedure:
 			workerServiceCheckSessionResumableHandler.ServeHTTP(w, r)
+		case WorkerServiceSetAllowedToolsProcedure:
+			workerServiceSetAllowedToolsHandler.ServeHTTP(w, r)
+		case WorkerServiceSetHostCommandsProcedure:
+			workerServiceSetHostCommandsHandler.ServeHTTP(w, r)
+		case WorkerServiceGetPendingEventsProcedure:
+			workerServiceGetPendingEventsHandler.ServeHTTP(w, r)
 		default:
 			http.NotFound(w, r)
 		}
@@ -289,6 +408,10 @@ func (UnimplementedWorkerServiceHandler) SendUserMessage(context.Context, *conne
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worker.v1.WorkerService.SendUserMessage is not implemented"))
 }
 
+func (UnimplementedWorkerServiceHandler) Prompt(context.Context, *connect.Request[v1.PromptRequest]) (*connect.Response[v1.PromptResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worker.v1.WorkerService.Prompt is not implemented"))
+}
+
 func (UnimplementedWorkerServiceHandler) CancelSession(context.Context, *connect.Request[v1.CancelSessionRequest]) (*connect.Response[v1.CancelSessionResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worker.v1.WorkerService.CancelSession is not implemented"))
 }
@@ -296,3 +419,15 @@ func (UnimplementedWorkerServiceHandler) CancelSession(context.Context, *connect
 func (UnimplementedWorkerServiceHandler) CheckSessionResumable(context.Context, *connect.Request[v1.CheckSessionResumableRequest]) (*connect.Response[v1.CheckSessionResumableResponse], error) {
 	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worker.v1.WorkerService.CheckSessionResumable is not implemented"))
 }
+
+func (UnimplementedWorkerServiceHandler) SetAllowedTools(context.Context, *connect.Request[v1.SetAllowedToolsRequest]) (*connect.Response[v1.SetAllowedToolsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worker.v1.WorkerService.SetAllowedTools is not implemented"))
+}
+
+func (UnimplementedWorkerServiceHandler) SetHostCommands(context.Context, *connect.Request[v1.SetHostCommandsRequest]) (*connect.Response[v1.SetHostCommandsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worker.v1.WorkerService.SetHostCommands is not implemented"))
+}
+
+func (UnimplementedWorkerServiceHandler) GetPendingEvents(context.Context, *connect.Request[v1.GetPendingEventsRequest]) (*connect.Response[v1.GetPendingEventsResponse], error) {
+	return nil, connect.NewError(connect.CodeUnimplemented, errors.New("worker.v1.WorkerService.GetPendingEvents is not implemented"))
+}
@@ -0,0 +1,102 @@
+// Package tlsutil builds TLS configurations for connections between
+// flowgentic services from config.TLSConfig.
+package tlsutil
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/sebastianm/flowgentic/internal/config"
+)
+
+// ServerConfig returns the TLS configuration for a server with the
+// certificate of c, or nil when c sets none and the server stays plaintext.
+// With c.CAFile, clients must present a certificate signed by one of its CAs.
+// The configuration offers HTTP/2, which gRPC streaming needs, and HTTP/1.
+func ServerConfig(c config.TLSConfig) (*tls.Config, error) {
+	if c.CertFile == "" && c.KeyFile == "" {
+		if c.CAFile != "" {
+			return nil, errors.New("tls caFile requires certFile and keyFile")
+		}
+		return nil, nil
+	}
+	cert, err := loadKeyPair(c)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"h2", "http/1.1"},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if c.CAFile != "" {
+		pool, err := loadCAs(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// ClientConfig returns the TLS configuration for clients to trust the CAs
+// of c.CAFile and present the certificate of c, or nil when c is empty and
+// clients keep Go's defaults.
+func ClientConfig(c config.TLSConfig) (*tls.Config, error) {
+	if c == (config.TLSConfig{}) {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.CAFile != "" {
+		pool, err := loadCAs(c.CAFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	if c.CertFile != "" || c.KeyFile != "" {
+		cert, err := loadKeyPair(c)
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// FromEnv reads a TLSConfig from the environment variables
+// <prefix>_TLS_CA_FILE, <prefix>_TLS_CERT_FILE and <prefix>_TLS_KEY_FILE.
+func FromEnv(prefix string) config.TLSConfig {
+	return config.TLSConfig{
+		CAFile:   os.Getenv(prefix + "_TLS_CA_FILE"),
+		CertFile: os.Getenv(prefix + "_TLS_CERT_FILE"),
+		KeyFile:  os.Getenv(prefix + "_TLS_KEY_FILE"),
+	}
+}
+
+func loadKeyPair(c config.TLSConfig) (tls.Certificate, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return tls.Certificate{}, errors.New("tls certFile and keyFile must be set together")
+	}
+	cert, err := tls.LoadX509KeyPair(c.CertFile, c.KeyFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("loading tls key pair: %w", err)
+	}
+	return cert, nil
+}
+
+func loadCAs(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading tls ca file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in tls ca file %s", path)
+	}
+	return pool, nil
+}
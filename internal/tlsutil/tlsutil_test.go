@@ -0,0 +1,137 @@
+package tlsutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sebastianm/flowgentic/internal/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeSelfSigned writes a self-signed certificate for 127.0.0.1, usable
+// by servers and clients and as its own CA, and its key to dir.
+func writeSelfSigned(t *testing.T, dir string) config.TLSConfig {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "flowgentic-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	c := config.TLSConfig{
+		CertFile: filepath.Join(dir, "cert.pem"),
+		KeyFile:  filepath.Join(dir, "key.pem"),
+		CAFile:   filepath.Join(dir, "cert.pem"),
+	}
+	require.NoError(t, os.WriteFile(c.CertFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(c.KeyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600))
+	return c
+}
+
+func newTLSServer(t *testing.T, c config.TLSConfig) *httptest.Server {
+	t.Helper()
+	cfg, err := ServerConfig(c)
+	require.NoError(t, err)
+	require.NotNil(t, cfg)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Proto))
+	}))
+	srv.TLS = cfg
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func clientFor(t *testing.T, c config.TLSConfig) *http.Client {
+	t.Helper()
+	cfg, err := ClientConfig(c)
+	require.NoError(t, err)
+	p := new(http.Protocols)
+	p.SetHTTP1(true)
+	p.SetHTTP2(true)
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: cfg, Protocols: p}}
+}
+
+func TestClientConfig_TrustsCustomCA(t *testing.T) {
+	c := writeSelfSigned(t, t.TempDir())
+	srv := newTLSServer(t, config.TLSConfig{CertFile: c.CertFile, KeyFile: c.KeyFile})
+
+	resp, err := clientFor(t, config.TLSConfig{CAFile: c.CAFile}).Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, resp.ProtoMajor, "servers offer HTTP/2 for gRPC streaming")
+
+	_, err = clientFor(t, config.TLSConfig{}).Get(srv.URL)
+	assert.ErrorContains(t, err, "certificate", "the system roots do not trust a self-signed server")
+}
+
+func TestServerConfig_RequiresClientCertWithCA(t *testing.T) {
+	c := writeSelfSigned(t, t.TempDir())
+	srv := newTLSServer(t, c)
+
+	resp, err := clientFor(t, c).Get(srv.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	_, err = clientFor(t, config.TLSConfig{CAFile: c.CAFile}).Get(srv.URL)
+	assert.Error(t, err, "clients without a certificate are rejected")
+}
+
+func TestServerConfig_PlaintextWhenUnset(t *testing.T) {
+	cfg, err := ServerConfig(config.TLSConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, cfg)
+
+	cfg, err = ClientConfig(config.TLSConfig{})
+	require.NoError(t, err)
+	assert.Nil(t, cfg)
+}
+
+func TestConfig_Errors(t *testing.T) {
+	c := writeSelfSigned(t, t.TempDir())
+
+	_, err := ServerConfig(config.TLSConfig{CertFile: c.CertFile})
+	assert.ErrorContains(t, err, "certFile and keyFile must be set together")
+	_, err = ServerConfig(config.TLSConfig{CAFile: c.CAFile})
+	assert.ErrorContains(t, err, "caFile requires certFile and keyFile")
+	_, err = ClientConfig(config.TLSConfig{KeyFile: c.KeyFile})
+	assert.ErrorContains(t, err, "certFile and keyFile must be set together")
+	_, err = ClientConfig(config.TLSConfig{CAFile: c.KeyFile})
+	assert.ErrorContains(t, err, "no certificates found")
+	_, err = ClientConfig(config.TLSConfig{CAFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.ErrorContains(t, err, "reading tls ca file")
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("AGENTCTL_TLS_CA_FILE", "/ca.pem")
+	t.Setenv("AGENTCTL_TLS_CERT_FILE", "/cert.pem")
+	t.Setenv("AGENTCTL_TLS_KEY_FILE", "/key.pem")
+	assert.Equal(t, config.TLSConfig{CAFile: "/ca.pem", CertFile: "/cert.pem", KeyFile: "/key.pem"}, FromEnv("AGENTCTL"))
+}
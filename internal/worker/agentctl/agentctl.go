@@ -19,6 +19,7 @@ type EventHandler interface {
 	HandleStatusReport(ctx context.Context, sessionID, agent, status string) error
 	HandlePlanSubmission(ctx context.Context, sessionID, agent string, plan []byte) error
 	HandleSetTopic(ctx context.Context, agentRunID, topic string) error
+	HandleProgressReport(ctx context.Context, sessionID, message string, percent *int32) error
 }
 
 // StartDeps are the dependencies for starting the agentctl feature.
@@ -68,8 +68,27 @@ func (h *agentCtlServiceHandler) SubmitPlan(
 	)
 
 	if err := h.handler.HandlePlanSubmission(ctx, req.Msg.SessionId, string(agentType), req.Msg.Plan); err != nil {
+		if errors.Is(err, driver.ErrInvalidPlan) {
+			return nil, connect.NewError(connect.CodeInvalidArgument, err)
+		}
 		return nil, connect.NewError(connect.CodeNotFound, err)
 	}
 
 	return connect.NewResponse(&workerv1.SubmitPlanResponse{}), nil
 }
+
+func (h *agentCtlServiceHandler) ReportProgress(
+	ctx context.Context,
+	req *connect.Request[workerv1.ReportProgressRequest],
+) (*connect.Response[workerv1.ReportProgressResponse], error) {
+	h.log.Debug("progress report received",
+		"session_id", req.Msg.SessionId,
+		"message", req.Msg.Message,
+	)
+
+	if err := h.handler.HandleProgressReport(ctx, req.Msg.SessionId, req.Msg.Message, req.Msg.Percent); err != nil {
+		return nil, connect.NewError(connect.CodeNotFound, err)
+	}
+
+	return connect.NewResponse(&workerv1.ReportProgressResponse{}), nil
+}
@@ -14,7 +14,7 @@ import (
 
 func TestAgentCtlServiceHandler_SetTopic(t *testing.T) {
 	d := newFakeDriver("claude-code")
-	m := workload.NewSessionManager(testLogger(), "", "", d)
+	m := workload.NewSessionManager(testLogger(), "", "", nil, d)
 
 	agentRunID := "ar-topic"
 	_, err := m.Launch(context.Background(), agentRunID, "claude-code", v2.LaunchOpts{}, nil)
@@ -57,7 +57,7 @@ func TestAgentCtlServiceHandler_SetTopic(t *testing.T) {
 
 func TestAgentCtlServiceHandler_ReportStatus(t *testing.T) {
 	d := newFakeDriver("claude-code")
-	m := workload.NewSessionManager(testLogger(), "", "", d)
+	m := workload.NewSessionManager(testLogger(), "", "", nil, d)
 
 	agentRunID := "ar-status"
 	_, err := m.Launch(context.Background(), agentRunID, "claude-code", v2.LaunchOpts{}, nil)
@@ -79,7 +79,7 @@ func TestAgentCtlServiceHandler_ReportStatus(t *testing.T) {
 
 func TestAgentCtlServiceHandler_SubmitPlan(t *testing.T) {
 	d := newFakeDriver("claude-code")
-	m := workload.NewSessionManager(testLogger(), "", "", d)
+	m := workload.NewSessionManager(testLogger(), "", "", nil, d)
 
 	agentRunID := "ar-plan"
 	_, err := m.Launch(context.Background(), agentRunID, "claude-code", v2.LaunchOpts{}, nil)
@@ -91,10 +91,64 @@ func TestAgentCtlServiceHandler_SubmitPlan(t *testing.T) {
 		req := connect.NewRequest(&workerv1.SubmitPlanRequest{
 			SessionId: agentRunID,
 			Agent:     workerv1.Agent_AGENT_CLAUDE_CODE,
-			Plan:      []byte("# My Plan\n\n1. Do stuff\n2. Do more stuff"),
+			Plan:      []byte(`{"version":1,"plans":[{"thread_id":"th-1","plan":{"title":"My Plan"},"tasks":[{"filename":"01-do.md","id":"do","description":"Do stuff"}]}]}`),
 		})
 		resp, err := h.SubmitPlan(context.Background(), req)
 		require.NoError(t, err)
 		assert.NotNil(t, resp)
+
+		events := m.PendingEvents(agentRunID, 0)
+		require.NotEmpty(t, events)
+		submitted := events[len(events)-1].GetPlanSubmitted()
+		require.NotNil(t, submitted)
+		require.Len(t, submitted.Plans, 1)
+		assert.Equal(t, "My Plan", submitted.Plans[0].Title)
+		assert.Equal(t, "01-do.md", submitted.Plans[0].Steps[0].File)
+	})
+
+	t.Run("malformed plan", func(t *testing.T) {
+		req := connect.NewRequest(&workerv1.SubmitPlanRequest{
+			SessionId: agentRunID,
+			Agent:     workerv1.Agent_AGENT_CLAUDE_CODE,
+			Plan:      []byte("# My Plan\n\n1. Do stuff\n2. Do more stuff"),
+		})
+		_, err := h.SubmitPlan(context.Background(), req)
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	})
+}
+
+func TestAgentCtlServiceHandler_ReportProgress(t *testing.T) {
+	d := newFakeDriver("claude-code")
+	m := workload.NewSessionManager(testLogger(), "", "", nil, d)
+
+	agentRunID := "ar-progress"
+	_, err := m.Launch(context.Background(), agentRunID, "claude-code", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+
+	h := &agentCtlServiceHandler{log: testLogger(), handler: m}
+
+	t.Run("emits a progress event", func(t *testing.T) {
+		percent := int32(20)
+		req := connect.NewRequest(&workerv1.ReportProgressRequest{
+			SessionId: agentRunID,
+			Message:   "analyzing 10/50 files",
+			Percent:   &percent,
+		})
+		_, err := h.ReportProgress(context.Background(), req)
+		require.NoError(t, err)
+
+		events := m.PendingEvents(agentRunID, 0)
+		require.NotEmpty(t, events)
+		progress := events[len(events)-1].GetProgress()
+		require.NotNil(t, progress)
+		assert.Equal(t, "analyzing 10/50 files", progress.Message)
+		require.NotNil(t, progress.Percent)
+		assert.Equal(t, int32(20), *progress.Percent)
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		req := connect.NewRequest(&workerv1.ReportProgressRequest{SessionId: "nonexistent", Message: "hi"})
+		_, err := h.ReportProgress(context.Background(), req)
+		assert.Equal(t, connect.CodeNotFound, connect.CodeOf(err))
 	})
 }
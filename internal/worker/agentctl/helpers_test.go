@@ -78,6 +78,8 @@ func (s *fakeSession) Stop(_ context.Context) error {
 	return nil
 }
 
+func (s *fakeSession) SoftStop(ctx context.Context) error { return s.Stop(ctx) }
+
 func (s *fakeSession) Wait(_ context.Context) error {
 	<-s.done
 	return nil
@@ -98,3 +100,20 @@ func (s *fakeSession) RespondToPermission(_ context.Context, _ string, _ bool, _
 func (s *fakeSession) SetSessionMode(_ context.Context, _ driver.SessionMode) error {
 	return nil
 }
+
+func (s *fakeSession) SetModel(_ context.Context, model string) error {
+	s.info.CurrentModel = model
+	return nil
+}
+
+func (s *fakeSession) AddMCPServer(_ context.Context, _ acp.McpServer) error {
+	return v2.ErrAddMCPServerUnsupported
+}
+
+func (s *fakeSession) SetAllowedTools(_ context.Context, _ []string) error {
+	return v2.ErrSetAllowedToolsUnsupported
+}
+
+func (s *fakeSession) SetHostCommands(_ context.Context, _ []acp.AvailableCommand) error {
+	return nil
+}
@@ -14,7 +14,7 @@ import (
 
 func TestHookCtlServiceHandler_ReportHook(t *testing.T) {
 	d := newFakeDriver("claude-code")
-	m := workload.NewSessionManager(testLogger(), "", "", d)
+	m := workload.NewSessionManager(testLogger(), "", "", nil, d)
 
 	agentRunID := "ar-hook"
 	_, err := m.Launch(context.Background(), agentRunID, "claude-code", v2.LaunchOpts{}, nil)
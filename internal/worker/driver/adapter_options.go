@@ -0,0 +1,31 @@
+package driver
+
+// AdapterOptions returns the adapter-specific options a NewSession request
+// carries in _meta.adapterOptions, or nil if it carries none. Adapters
+// consult the keys they know and ignore the rest.
+func AdapterOptions(meta any) map[string]any {
+	m, _ := meta.(map[string]any)
+	opts, _ := m["adapterOptions"].(map[string]any)
+	return opts
+}
+
+// IntOption returns the integer option at key, accepting both in-memory ints
+// and JSON-decoded float64s.
+func IntOption(opts map[string]any, key string) (int, bool) {
+	if n := intField(opts, key); n != nil {
+		return *n, true
+	}
+	return 0, false
+}
+
+// StringOption returns the string option at key.
+func StringOption(opts map[string]any, key string) (string, bool) {
+	s, ok := opts[key].(string)
+	return s, ok
+}
+
+// BoolOption returns the boolean option at key.
+func BoolOption(opts map[string]any, key string) (bool, bool) {
+	b, ok := opts[key].(bool)
+	return b, ok
+}
@@ -0,0 +1,79 @@
+package driver
+
+import (
+	"errors"
+	"strings"
+
+	acp "github.com/coder/acp-go-sdk"
+)
+
+// AuthRequiredCode is the ACP error code for "authentication required".
+const AuthRequiredCode = -32000
+
+// authFailurePatterns are lowercase fragments of the messages agent CLIs and
+// their APIs print when credentials are missing, invalid or expired. Generic
+// words such as "unauthorized" or "refresh token" are left out: they also
+// turn up in tool output, git and MCP server errors.
+var authFailurePatterns = []string{
+	// Claude Code and the Anthropic API.
+	"please run /login",
+	"oauth token has expired",
+	`"type":"authentication_error"`,
+	"invalid x-api-key",
+	// Codex and the OpenAI API.
+	"please run `codex login`",
+	"your access token could not be refreshed",
+	"incorrect api key provided",
+	// Gemini CLI.
+	"api key not valid. please pass a valid api key",
+}
+
+// LooksLikeAuthFailure reports whether text (an error message or a line of
+// agent stderr) describes an authentication failure.
+func LooksLikeAuthFailure(text string) bool {
+	lower := strings.ToLower(text)
+	for _, p := range authFailurePatterns {
+		if strings.Contains(lower, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewAuthRequiredError returns the ACP "authentication required" error an
+// adapter should report when its agent rejects the configured credentials.
+// It must be returned unwrapped so the ACP connection keeps the error code.
+func NewAuthRequiredError(message string) *acp.RequestError {
+	return acp.NewAuthRequired(map[string]any{"message": message})
+}
+
+// AuthFailureMessage reports whether err is an authentication failure, either
+// an ACP "authentication required" error or an error whose text matches a
+// known auth failure, and returns a human-readable message for it.
+func AuthFailureMessage(err error) (string, bool) {
+	if err == nil {
+		return "", false
+	}
+	var re *acp.RequestError
+	if errors.As(err, &re) {
+		if re.Code == AuthRequiredCode {
+			if data, ok := re.Data.(map[string]any); ok {
+				if msg, ok := data["message"].(string); ok && msg != "" {
+					return msg, true
+				}
+			}
+			return re.Message, true
+		}
+		// Adapters that didn't classify the error surface it as an internal
+		// error carrying the original text.
+		if data, ok := re.Data.(map[string]any); ok {
+			if msg, ok := data["error"].(string); ok && LooksLikeAuthFailure(msg) {
+				return msg, true
+			}
+		}
+	}
+	if LooksLikeAuthFailure(err.Error()) {
+		return err.Error(), true
+	}
+	return "", false
+}
@@ -0,0 +1,61 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLooksLikeAuthFailure(t *testing.T) {
+	for _, text := range []string{
+		"Invalid API key · Please run /login",
+		"Not logged in · Please run /login",
+		`API Error: 401 {"type":"error","error":{"type":"authentication_error","message":"invalid x-api-key"}}`,
+		"OAuth token has expired. Please obtain a new token or refresh your existing token.",
+		"401 Unauthorized: Your access token could not be refreshed. Please run `codex login`.",
+		"Incorrect API key provided: sk-abc***. You can find your API key at https://platform.openai.com/account/api-keys.",
+		"API key not valid. Please pass a valid API key.",
+	} {
+		assert.True(t, LooksLikeAuthFailure(text), text)
+	}
+	for _, text := range []string{
+		"",
+		"connection reset by peer",
+		"model not found: claude-9",
+		"prompt is too long",
+		"401 Unauthorized",
+		"MCP server github: 401 Unauthorized",
+		"Not logged in",
+		"npm ERR! code ENEEDAUTH: authentication required",
+		"fatal: Authentication failed for 'https://github.com/acme/app.git/'",
+		"the refresh token is stored in ~/.config/app",
+		"session token has expired, reconnecting",
+		"api key not found in .env",
+	} {
+		assert.False(t, LooksLikeAuthFailure(text), text)
+	}
+}
+
+func TestAuthFailureMessage(t *testing.T) {
+	msg, ok := AuthFailureMessage(NewAuthRequiredError("Invalid API key"))
+	assert.True(t, ok)
+	assert.Equal(t, "Invalid API key", msg)
+
+	msg, ok = AuthFailureMessage(acp.NewAuthRequired(nil))
+	assert.True(t, ok, "agents may send the ACP code without data")
+	assert.Equal(t, "Authentication required", msg)
+
+	msg, ok = AuthFailureMessage(fmt.Errorf("prompt: %w", acp.NewInternalError(map[string]any{"error": "Not logged in · Please run /login"})))
+	assert.True(t, ok, "unclassified internal errors are matched by text")
+	assert.Equal(t, "Not logged in · Please run /login", msg)
+
+	_, ok = AuthFailureMessage(acp.NewInternalError(map[string]any{"error": "boom"}))
+	assert.False(t, ok)
+	_, ok = AuthFailureMessage(errors.New("connection refused"))
+	assert.False(t, ok)
+	_, ok = AuthFailureMessage(nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,23 @@
+package driver
+
+import "fmt"
+
+// AutoApprovePolicy decides permission requests when no interactive
+// approver is attached, as in headless or automated launches.
+type AutoApprovePolicy string
+
+const (
+	AutoApproveDenyAll   AutoApprovePolicy = "deny-all"   // deny every tool
+	AutoApproveAllowSafe AutoApprovePolicy = "allow-safe" // allow read-only tools, deny mutating ones
+	AutoApproveAllowAll  AutoApprovePolicy = "allow-all"  // allow every tool
+)
+
+// ParseAutoApprovePolicy validates and returns an AutoApprovePolicy from a string.
+func ParseAutoApprovePolicy(s string) (AutoApprovePolicy, error) {
+	switch AutoApprovePolicy(s) {
+	case AutoApproveDenyAll, AutoApproveAllowSafe, AutoApproveAllowAll:
+		return AutoApprovePolicy(s), nil
+	default:
+		return "", fmt.Errorf("unknown auto-approve policy: %q", s)
+	}
+}
@@ -0,0 +1,21 @@
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAutoApprovePolicy(t *testing.T) {
+	for _, s := range []string{"deny-all", "allow-safe", "allow-all"} {
+		policy, err := ParseAutoApprovePolicy(s)
+		require.NoError(t, err)
+		assert.Equal(t, AutoApprovePolicy(s), policy)
+	}
+
+	for _, s := range []string{"", "allow", "ALLOW-ALL"} {
+		_, err := ParseAutoApprovePolicy(s)
+		assert.Error(t, err, s)
+	}
+}
@@ -1,5 +1,7 @@
 package driver
 
+import "slices"
+
 // Capability describes an optional feature a driver supports.
 type Capability string
 
@@ -12,16 +14,45 @@ const (
 	CapPermissionRequest Capability = "permission_request"
 	CapFileSystem        Capability = "file_system"
 	CapTerminal          Capability = "terminal"
+	CapReasoningEffort   Capability = "reasoning_effort"
+	CapAddMCPServer      Capability = "add_mcp_server"
+	CapSetAllowedTools   Capability = "set_allowed_tools"
+	CapToolKindPermissions Capability = "tool_kind_permissions"
 )
 
+// BuiltinCapabilities lists every Cap* constant. Add new constants here too;
+// registry files may only name these.
+var BuiltinCapabilities = []Capability{
+	CapStreaming,
+	CapSessionResume,
+	CapCostTracking,
+	CapCustomModel,
+	CapSystemPrompt,
+	CapPermissionRequest,
+	CapFileSystem,
+	CapTerminal,
+	CapReasoningEffort,
+	CapAddMCPServer,
+	CapSetAllowedTools,
+	CapToolKindPermissions,
+}
+
+// IsBuiltin reports whether c is one of BuiltinCapabilities.
+func (c Capability) IsBuiltin() bool {
+	return slices.Contains(BuiltinCapabilities, c)
+}
+
 // Capabilities describes what a driver supports.
 type Capabilities struct {
 	Agent     string       `json:"agent"`
 	Supported []Capability `json:"supported"`
+	// Extensions names agent-specific features outside the Cap* constants,
+	// such as "image_generation", declared by whoever integrates the agent.
+	Extensions []string `json:"extensions,omitempty"`
 }
 
-// Has returns true if the capability is in the supported list.
-func (c Capabilities) Has(cap Capability) bool {
+// Supports returns true if the capability is in the supported list.
+func (c Capabilities) Supports(cap Capability) bool {
 	for _, s := range c.Supported {
 		if s == cap {
 			return true
@@ -29,3 +60,18 @@ func (c Capabilities) Has(cap Capability) bool {
 	}
 	return false
 }
+
+// Has returns true if name is a supported capability or an extension, so
+// Has("image_generation") checks a custom feature and Has("streaming") a
+// built-in one.
+func (c Capabilities) Has(name string) bool {
+	if c.Supports(Capability(name)) {
+		return true
+	}
+	for _, e := range c.Extensions {
+		if e == name {
+			return true
+		}
+	}
+	return false
+}
@@ -6,7 +6,7 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestCapabilities_Has(t *testing.T) {
+func TestCapabilities_Supports(t *testing.T) {
 	caps := Capabilities{
 		Agent: "test",
 		Supported: []Capability{
@@ -16,21 +16,39 @@ func TestCapabilities_Has(t *testing.T) {
 	}
 
 	t.Run("returns true for supported capability", func(t *testing.T) {
-		assert.True(t, caps.Has(CapStreaming))
-		assert.True(t, caps.Has(CapCostTracking))
+		assert.True(t, caps.Supports(CapStreaming))
+		assert.True(t, caps.Supports(CapCostTracking))
 	})
 
 	t.Run("returns false for unsupported capability", func(t *testing.T) {
-		assert.False(t, caps.Has(CapSessionResume))
-		assert.False(t, caps.Has(CapPermissionRequest))
+		assert.False(t, caps.Supports(CapSessionResume))
+		assert.False(t, caps.Supports(CapPermissionRequest))
 	})
 }
 
-func TestCapabilities_Has_Empty(t *testing.T) {
+func TestCapabilities_Supports_Empty(t *testing.T) {
 	caps := Capabilities{
 		Agent:     "test",
 		Supported: []Capability{},
 	}
 
-	assert.False(t, caps.Has(CapStreaming))
+	assert.False(t, caps.Supports(CapStreaming))
+}
+
+func TestCapabilities_Has_Extensions(t *testing.T) {
+	caps := Capabilities{
+		Agent:      "test",
+		Supported:  []Capability{CapStreaming},
+		Extensions: []string{"image_generation"},
+	}
+
+	assert.True(t, caps.Has("image_generation"))
+	assert.True(t, caps.Has("streaming"), "built-in capabilities by name")
+	assert.False(t, caps.Has("web_search"))
+	assert.False(t, caps.Supports("image_generation"), "extensions are not built-in capabilities")
+}
+
+func TestCapability_IsBuiltin(t *testing.T) {
+	assert.True(t, CapToolKindPermissions.IsBuiltin())
+	assert.False(t, Capability("web_search").IsBuiltin())
 }
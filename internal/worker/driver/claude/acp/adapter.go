@@ -1,15 +1,18 @@
 package acp
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"log/slog"
-	"os"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	acpsdk "github.com/coder/acp-go-sdk"
@@ -18,6 +21,29 @@ import (
 	"github.com/sebastianm/flowgentic/internal/worker/driver"
 )
 
+var errAdapterClosed = errors.New("adapter closed")
+
+// errSubprocessExited is returned by Prompt when the Claude subprocess dies
+// before the turn completes.
+var errSubprocessExited = errors.New("claude subprocess exited")
+
+// errConnectTimeout is returned when the Claude CLI does not become ready
+// within the connect timeout.
+var errConnectTimeout = errors.New("claude CLI did not become ready")
+
+// defaultConnectTimeout bounds connecting to the Claude CLI when the
+// connectTimeoutSeconds adapter option is unset.
+const defaultConnectTimeout = time.Minute
+
+// refusalStopReason is the API stop reason of a message the model refused
+// to write, e.g. for safety reasons. Only this reason counts as a refusal;
+// refusals phrased as normal replies end the turn normally.
+const refusalStopReason = "refusal"
+
+// cancelDrainTimeout bounds how long a cancelled prompt waits for the CLI to
+// end the interrupted turn.
+const cancelDrainTimeout = 5 * time.Second
+
 // updateSender abstracts sending session updates, enabling test injection.
 type updateSender interface {
 	SessionUpdate(ctx context.Context, n acpsdk.SessionNotification) error
@@ -36,17 +62,61 @@ type Adapter struct {
 	// Tests inject a fake to capture updates without a real connection.
 	updater updateSender
 
-	// Per-session state (single session per adapter instance).
+	// Per-session state (single session per adapter instance). NewSession
+	// sets it under mu. cwd, model, sessionMode and sessionID are read under
+	// mu as well since Prompt, SetSessionModel and the message pump use them
+	// concurrently; the remaining fields configure the CLI and its
+	// permission checks and are not changed once it is connected.
 	cwd          string
 	systemPrompt string
 	model        string
 	sessionMode  string
-	allowedTools []string
+	effort       driver.ReasoningEffort
+	allowedTools []string // approved without asking; guarded by mu, see SetAllowedTools
 	sessionID    string
 	envVars      map[string]string
 	mcpServers   map[string]claudecode.McpServerConfig
 	planModeMCP  bool
 
+	// disallowedTools and planModeAllowedTools extend the built-in
+	// isDisallowedTool and isAllowedInFlowgenticPlanMode lists.
+	disallowedTools      []string
+	planModeAllowedTools []string
+
+	// autoApprove decides permission requests when there is no ACP
+	// connection to ask. The zero value denies everything.
+	autoApprove driver.AutoApprovePolicy
+
+	// kindPermissions decides permission requests of tools no name rule
+	// covers by their ACP kind; kinds it lacks are asked about.
+	kindPermissions map[acpsdk.ToolKind]driver.KindPermission
+
+	// maxThinkingTokens overrides the effort's thinking budget when set,
+	// from the maxThinkingTokens adapter option.
+	maxThinkingTokens int
+
+	// connectTimeout bounds connecting to the Claude CLI, from the
+	// connectTimeoutSeconds adapter option; zero uses defaultConnectTimeout.
+	connectTimeout time.Duration
+
+	// newClient creates the SDK client; nil uses claudecode.NewClient.
+	// Tests inject fakes.
+	newClient func(opts ...claudecode.Option) claudecode.Client
+
+	// attachDuplicatePrompts lets a Prompt repeating the in-flight one wait
+	// for that turn's result instead of failing, from the
+	// attachDuplicatePrompts adapter option.
+	attachDuplicatePrompts bool
+
+	// fileDiffs builds Edit and Write diffs from the file in cwd instead of
+	// the agent's strings, from the fileDiffs adapter option.
+	fileDiffs bool
+	// diffSnapshots holds the diffs of tool calls seen at one of the stages
+	// that show them, the permission decision and the assistant message, for
+	// the other one; keyed by diffKey and guarded by mu, and cleared when the
+	// turn ends.
+	diffSnapshots map[string]diffSnapshot
+
 	// Persistent Claude SDK client — lives across Prompt() calls so
 	// multi-turn conversations share the same subprocess and history.
 	mu      sync.Mutex
@@ -62,22 +132,60 @@ type Adapter struct {
 	promptCancel context.CancelFunc
 	// promptDone closes when the active prompt turn receives a ResultMessage.
 	promptDone chan struct{}
+	// turn is the in-flight Prompt call, set alongside promptDone.
+	turn *promptTurn
 	// connectWait is non-nil while a connect attempt is in progress.
 	connectWait chan struct{}
+	// exited closes when the SDK message stream ends, i.e. the Claude
+	// subprocess is gone. It is recreated on every connect.
+	exited chan struct{}
 
 	// activeTools tracks tool calls that have been started but not yet completed.
-	// Maps toolCallId → tool name. Used to deduplicate starts (stream vs batch)
+	// Maps toolCallId → tool. Used to deduplicate starts (stream vs batch)
 	// and synthesize completion events when the next assistant turn begins.
-	activeTools map[string]string
-	// availableCommandsSent guards one-time emission of startup commands.
+	// It is only used by the normalize* methods, which run on the message
+	// pump goroutine, and so needs no lock.
+	activeTools map[string]activeTool
+	// toolSeq numbers tracked tool calls in start order. Like activeTools,
+	// it belongs to the message pump.
+	toolSeq uint64
+	// turnSeq numbers Prompt turns. Tools are tracked per turn so an ID the
+	// agent reuses in a later turn is a new call, not the earlier one.
+	turnSeq atomic.Uint64
+	// turnRefused is set when the model refused during the current turn,
+	// which then ends with StopReasonRefusal. It is reset on every turn.
+	turnRefused atomic.Bool
+	// turnUsage is the token usage of the current turn's result, reported
+	// in the prompt response's _meta; guarded by mu.
+	turnUsage *driver.TurnUsage
+	// availableCommandsSent guards one-time emission of startup commands;
+	// guarded by mu.
 	availableCommandsSent bool
+	// sessionInitSent guards one-time emission of the CLI's init message;
+	// the CLI repeats it at the start of every turn.
+	sessionInitSent bool
+	// closed is set by Close; no new SDK client is connected afterwards.
+	closed bool
+
+	// stderrAuthFailure and resultAuthFailure hold the last authentication
+	// error the CLI printed on stderr or returned as an error result. They
+	// have their own lock because stderr is read while mu is held during
+	// Connect.
+	authMu            sync.Mutex
+	stderrAuthFailure string
+	resultAuthFailure string
+
+	// stderrSink receives the CLI's stderr lines; see SetStderr.
+	stderrSink func(line string)
+	// stderrTail keeps the CLI's last stderr lines for connect errors.
+	stderrTail *driver.StderrTail
 
 	modelProvider modelStateProvider
 }
 
 // NewAdapter creates a new Claude ACP adapter.
 func NewAdapter(log *slog.Logger) acpsdk.Agent {
-	return &Adapter{log: log.With("adapter", "claude-code")}
+	return &Adapter{log: log.With("adapter", "claude-code"), stderrTail: driver.NewStderrTail(0)}
 }
 
 // SetConnection is called after the agent-side connection is created,
@@ -86,6 +194,11 @@ func (a *Adapter) SetConnection(conn *acpsdk.AgentSideConnection) {
 	a.conn = conn
 }
 
+// SetStderr makes the adapter pass every stderr line of the Claude CLI to fn.
+func (a *Adapter) SetStderr(fn func(line string)) {
+	a.stderrSink = fn
+}
+
 func (a *Adapter) Authenticate(_ context.Context, _ acpsdk.AuthenticateRequest) (acpsdk.AuthenticateResponse, error) {
 	return acpsdk.AuthenticateResponse{}, nil
 }
@@ -111,17 +224,19 @@ func (a *Adapter) Cancel(_ context.Context, _ acpsdk.CancelNotification) error {
 }
 
 func (a *Adapter) NewSession(_ context.Context, req acpsdk.NewSessionRequest) (acpsdk.NewSessionResponse, error) {
-	a.cwd = req.Cwd
-	a.sessionID = uuid.New().String()
-	a.mcpServers = convertMCPServers(req.McpServers)
+	mcpServers := convertMCPServers(req.McpServers)
 	a.log.Info(
 		"claude new session",
 		"cwd", req.Cwd,
-		"mcp_servers", len(a.mcpServers),
-		"mcp_server_names", mapKeys(a.mcpServers),
-		"mcp_server_summaries", summarizeMCPServers(a.mcpServers),
+		"mcp_servers", len(mcpServers),
+		"mcp_server_names", mapKeys(mcpServers),
+		"mcp_server_summaries", summarizeMCPServers(mcpServers),
 	)
 
+	a.mu.Lock()
+	a.cwd = req.Cwd
+	a.sessionID = uuid.New().String()
+	a.mcpServers = mcpServers
 	// Parse _meta for adapter-specific options.
 	if meta, ok := req.Meta.(map[string]any); ok {
 		if sp, ok := meta["systemPrompt"].(string); ok {
@@ -133,11 +248,30 @@ func (a *Adapter) NewSession(_ context.Context, req acpsdk.NewSessionRequest) (a
 		if sm, ok := meta["sessionMode"].(string); ok {
 			a.sessionMode = sm
 		}
-		if tools, ok := meta["allowedTools"].([]any); ok {
-			for _, t := range tools {
-				if s, ok := t.(string); ok {
-					a.allowedTools = append(a.allowedTools, s)
-				}
+		if re, ok := meta["reasoningEffort"].(string); ok {
+			if effort, err := driver.ParseReasoningEffort(re); err == nil {
+				a.effort = effort
+			}
+		}
+		if ap, ok := meta["autoApprovePolicy"].(string); ok {
+			if policy, err := driver.ParseAutoApprovePolicy(ap); err == nil {
+				a.autoApprove = policy
+			} else {
+				a.log.Warn("ignoring auto-approve policy", "error", err)
+			}
+		}
+		a.allowedTools = append(a.allowedTools, metaStrings(meta, "allowedTools")...)
+		a.disallowedTools = append(a.disallowedTools, metaStrings(meta, "disallowedTools")...)
+		a.planModeAllowedTools = append(a.planModeAllowedTools, metaStrings(meta, "planModeAllowedTools")...)
+		if kp, ok := meta["toolKindPermissions"].(map[string]any); ok {
+			raw := make(map[string]string, len(kp))
+			for kind, v := range kp {
+				raw[kind], _ = v.(string)
+			}
+			if perms, err := driver.ParseKindPermissions(raw); err == nil {
+				a.kindPermissions = perms
+			} else {
+				a.log.Warn("ignoring tool kind permissions", "error", err)
 			}
 		}
 		if env, ok := meta["envVars"].(map[string]any); ok {
@@ -148,12 +282,23 @@ func (a *Adapter) NewSession(_ context.Context, req acpsdk.NewSessionRequest) (a
 				}
 			}
 		}
+		if n, ok := driver.IntOption(driver.AdapterOptions(meta), "maxThinkingTokens"); ok && n > 0 {
+			a.maxThinkingTokens = n
+		}
+		a.attachDuplicatePrompts, _ = driver.BoolOption(driver.AdapterOptions(meta), "attachDuplicatePrompts")
+		a.fileDiffs, _ = driver.BoolOption(driver.AdapterOptions(meta), "fileDiffs")
+		if n, ok := driver.IntOption(driver.AdapterOptions(meta), "connectTimeoutSeconds"); ok && n > 0 {
+			a.connectTimeout = time.Duration(n) * time.Second
+		}
 	}
 	a.planModeMCP = strings.Contains(a.systemPrompt, "## Flowgentic MCP") && len(a.mcpServers) > 0
 	a.availableCommandsSent = false
+	a.sessionInitSent = false
+	sessionID := acpsdk.SessionId(a.sessionID)
+	a.mu.Unlock()
 
 	resp := acpsdk.NewSessionResponse{
-		SessionId: acpsdk.SessionId(a.sessionID),
+		SessionId: sessionID,
 	}
 	// Eagerly connect so we can discover models and forward startup commands.
 	if a.conn != nil {
@@ -162,7 +307,10 @@ func (a *Adapter) NewSession(_ context.Context, req acpsdk.NewSessionRequest) (a
 				a.log.Debug("background sdk connect failed", "error", err)
 				return
 			}
-			a.emitAvailableCommandsFromSDK(context.Background(), acpsdk.SessionId(a.sessionID))
+			a.mu.Lock()
+			ctx := a.sessionCtx
+			a.mu.Unlock()
+			a.emitAvailableCommandsFromSDK(ctx, sessionID)
 		}()
 	}
 	if a.modelProvider != nil {
@@ -171,9 +319,7 @@ func (a *Adapter) NewSession(_ context.Context, req acpsdk.NewSessionRequest) (a
 			return acpsdk.NewSessionResponse{}, fmt.Errorf("model state: %w", err)
 		}
 		if state != nil {
-			cloned := *state
-			cloned.AvailableModels = append([]acpsdk.ModelInfo(nil), state.AvailableModels...)
-			resp.Models = &cloned
+			resp.Models = a.sessionModelState(state)
 		}
 	} else if a.conn != nil {
 		// No pre-existing model provider — try to discover models from the SDK.
@@ -188,9 +334,7 @@ func (a *Adapter) NewSession(_ context.Context, req acpsdk.NewSessionRequest) (a
 				if err != nil {
 					a.log.Debug("model discovery failed", "error", err)
 				} else if state != nil {
-					cloned := *state
-					cloned.AvailableModels = append([]acpsdk.ModelInfo(nil), state.AvailableModels...)
-					resp.Models = &cloned
+					resp.Models = a.sessionModelState(state)
 				}
 			}
 		}
@@ -200,10 +344,23 @@ func (a *Adapter) NewSession(_ context.Context, req acpsdk.NewSessionRequest) (a
 }
 
 func (a *Adapter) Prompt(ctx context.Context, req acpsdk.PromptRequest) (acpsdk.PromptResponse, error) {
+	// The error is returned unwrapped so the client sees invalid params.
+	prompt, err := newClaudePrompt(req.Prompt)
+	if err != nil {
+		return acpsdk.PromptResponse{}, err
+	}
+
+	// A retried prompt waits for the turn it repeats. It is checked before
+	// promptCancel is replaced so Cancel still reaches that turn.
+	if turn := a.attachableTurn(prompt.key()); turn != nil {
+		return turn.wait(ctx)
+	}
+
 	// Wrap context so Cancel() can abort the in-flight prompt.
 	ctx, cancel := context.WithCancel(ctx)
 	a.mu.Lock()
 	a.promptCancel = cancel
+	sessionID := a.sessionID
 	a.mu.Unlock()
 	defer func() {
 		a.mu.Lock()
@@ -212,18 +369,13 @@ func (a *Adapter) Prompt(ctx context.Context, req acpsdk.PromptRequest) (acpsdk.
 		cancel()
 	}()
 
-	// Extract text from prompt content blocks.
-	var promptText string
-	for _, block := range req.Prompt {
-		if block.Text != nil {
-			promptText += block.Text.Text
-		}
-	}
-
 	if err := a.ensureClientConnected(ctx); err != nil {
+		if authErr := a.authError(err); authErr != nil {
+			return acpsdk.PromptResponse{}, authErr
+		}
 		return acpsdk.PromptResponse{}, fmt.Errorf("connect: %w", err)
 	}
-	a.emitAvailableCommandsFromSDK(ctx, acpsdk.SessionId(a.sessionID))
+	a.emitAvailableCommandsFromSDK(ctx, acpsdk.SessionId(sessionID))
 
 	a.mu.Lock()
 	if a.promptDone != nil {
@@ -232,22 +384,63 @@ func (a *Adapter) Prompt(ctx context.Context, req acpsdk.PromptRequest) (acpsdk.
 	}
 	done := make(chan struct{})
 	a.promptDone = done
+	a.turnSeq.Add(1)
+	a.turnRefused.Store(false)
+	a.turnUsage = nil
+	turn := &promptTurn{key: prompt.key(), finished: make(chan struct{})}
+	a.turn = turn
+	client := a.client
+	exited := a.exited
 	a.mu.Unlock()
+	if client == nil {
+		a.clearPromptDone(done)
+		a.finishTurn(turn, acpsdk.PromptResponse{}, errAdapterClosed)
+		return acpsdk.PromptResponse{}, errAdapterClosed
+	}
 
+	resp, err := a.runTurn(ctx, client, sessionID, prompt, done, exited)
+	a.finishTurn(turn, resp, err)
+	return resp, err
+}
+
+// runTurn sends prompt on client and waits for the turn to end.
+func (a *Adapter) runTurn(ctx context.Context, client claudecode.Client, sessionID string, prompt claudePrompt, done chan struct{}, exited <-chan struct{}) (acpsdk.PromptResponse, error) {
 	// Send prompt on the persistent session.
-	if promptText != "" {
-		if err := a.client.QueryWithSession(ctx, promptText, a.sessionID); err != nil {
-			a.clearPromptDone(done)
-			return acpsdk.PromptResponse{}, fmt.Errorf("query: %w", err)
+	if err := prompt.send(ctx, client, sessionID); err != nil {
+		a.clearPromptDone(done)
+		if authErr := a.authError(err); authErr != nil {
+			return acpsdk.PromptResponse{}, authErr
 		}
+		return acpsdk.PromptResponse{}, fmt.Errorf("query: %w", err)
 	}
 
 	finalStopReason := acpsdk.StopReasonEndTurn
 	for {
 		select {
 		case <-done:
-			return acpsdk.PromptResponse{StopReason: finalStopReason}, nil
+			// The CLI reports rejected credentials as an error result
+			// rather than failing the query.
+			if authErr := a.authError(nil); authErr != nil {
+				return acpsdk.PromptResponse{}, authErr
+			}
+			if a.turnRefused.Load() {
+				finalStopReason = acpsdk.StopReasonRefusal
+			}
+			resp := acpsdk.PromptResponse{StopReason: finalStopReason}
+			a.mu.Lock()
+			if a.turnUsage != nil {
+				resp.Meta = driver.TurnUsageMeta(*a.turnUsage)
+			}
+			a.mu.Unlock()
+			return resp, nil
+		case <-exited:
+			a.clearPromptDone(done)
+			if authErr := a.authError(errSubprocessExited); authErr != nil {
+				return acpsdk.PromptResponse{}, authErr
+			}
+			return acpsdk.PromptResponse{}, errSubprocessExited
 		case <-ctx.Done():
+			a.drainCancelledTurn(done, exited)
 			a.clearPromptDone(done)
 			finalStopReason = acpsdk.StopReasonCancelled
 			return acpsdk.PromptResponse{StopReason: finalStopReason}, nil
@@ -255,6 +448,72 @@ func (a *Adapter) Prompt(ctx context.Context, req acpsdk.PromptRequest) (acpsdk.
 	}
 }
 
+// drainCancelledTurn interrupts the CLI and waits, at most
+// cancelDrainTimeout, for the interrupted turn to end. Messages streamed
+// before the interrupt are pumped ahead of the turn's result, so the partial
+// response reaches the client before Prompt reports the cancellation.
+func (a *Adapter) drainCancelledTurn(done, exited <-chan struct{}) {
+	a.mu.Lock()
+	client := a.client
+	a.mu.Unlock()
+	if client == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cancelDrainTimeout)
+	defer cancel()
+	if err := client.Interrupt(ctx); err != nil {
+		a.log.Warn("claude interrupt failed", "error", err)
+		return
+	}
+	select {
+	case <-done:
+	case <-exited:
+	case <-ctx.Done():
+		a.log.Warn("claude turn did not end after interrupt", "timeout", cancelDrainTimeout)
+	}
+}
+
+// promptTurn is an in-flight Prompt call that identical retries attach to.
+type promptTurn struct {
+	key      string        // claudePrompt.key of the prompt
+	finished chan struct{} // closed once the call has returned
+	resp     acpsdk.PromptResponse
+	err      error
+}
+
+// wait returns the turn's result. A caller giving up early gets a cancelled
+// stop reason; the turn itself keeps running.
+func (t *promptTurn) wait(ctx context.Context) (acpsdk.PromptResponse, error) {
+	select {
+	case <-t.finished:
+		return t.resp, t.err
+	case <-ctx.Done():
+		return acpsdk.PromptResponse{StopReason: acpsdk.StopReasonCancelled}, nil
+	}
+}
+
+// attachableTurn returns the in-flight turn if duplicate prompts may attach
+// to it and it was started with the same prompt key.
+func (a *Adapter) attachableTurn(key string) *promptTurn {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if !a.attachDuplicatePrompts || a.turn == nil || a.turn.key != key {
+		return nil
+	}
+	return a.turn
+}
+
+func (a *Adapter) finishTurn(turn *promptTurn, resp acpsdk.PromptResponse, err error) {
+	a.mu.Lock()
+	if a.turn == turn {
+		a.turn = nil
+	}
+	a.mu.Unlock()
+	turn.resp, turn.err = resp, err
+	close(turn.finished)
+}
+
 func (a *Adapter) clearPromptDone(done chan struct{}) {
 	a.mu.Lock()
 	defer a.mu.Unlock()
@@ -277,6 +536,10 @@ func (a *Adapter) completePromptTurn() {
 
 func (a *Adapter) ensureClientConnected(ctx context.Context) error {
 	a.mu.Lock()
+	if a.closed {
+		a.mu.Unlock()
+		return errAdapterClosed
+	}
 	if a.client != nil {
 		a.mu.Unlock()
 		return nil
@@ -290,6 +553,9 @@ func (a *Adapter) ensureClientConnected(ctx context.Context) error {
 		}
 		a.mu.Lock()
 		defer a.mu.Unlock()
+		if a.closed {
+			return errAdapterClosed
+		}
 		if a.client == nil {
 			return errors.New("sdk connect failed")
 		}
@@ -303,13 +569,18 @@ func (a *Adapter) ensureClientConnected(ctx context.Context) error {
 	a.sessionCtx = sessionCtx
 	a.sessionCancel = sessionCancel
 
+	sessionID := acpsdk.SessionId(a.sessionID)
 	sdkOpts := a.buildSDKOptions()
 	sdkOpts = append(sdkOpts, claudecode.WithCanUseTool(func(toolCtx context.Context, toolName string, input map[string]any, _ claudecode.ToolPermissionContext) (claudecode.PermissionResult, error) {
-		return a.handlePermission(toolCtx, acpsdk.SessionId(a.sessionID), toolName, input)
+		return a.handlePermission(toolCtx, sessionID, toolName, input)
 	}))
 
-	client := claudecode.NewClient(sdkOpts...)
-	if err := client.Connect(sessionCtx); err != nil {
+	newClient := a.newClient
+	if newClient == nil {
+		newClient = claudecode.NewClient
+	}
+	client := newClient(sdkOpts...)
+	if err := a.connectClient(sessionCtx, sessionCancel, client); err != nil {
 		sessionCancel()
 		a.connectWait = nil
 		close(wait)
@@ -320,23 +591,88 @@ func (a *Adapter) ensureClientConnected(ctx context.Context) error {
 	a.modelProvider = &sdkModelProvider{client: client}
 	a.msgChan = client.ReceiveMessages(sessionCtx)
 	msgChan := a.msgChan
-	sessionID := acpsdk.SessionId(a.sessionID)
+	exited := make(chan struct{})
+	a.exited = exited
 	a.connectWait = nil
 	close(wait)
 	a.mu.Unlock()
 
-	go a.pumpMessages(sessionCtx, sessionID, msgChan)
+	go a.pumpMessages(sessionCtx, sessionID, msgChan, exited)
 	return nil
 }
 
-func (a *Adapter) pumpMessages(ctx context.Context, sessionID acpsdk.SessionId, msgChan <-chan claudecode.Message) {
+// connectClient connects client within the connect timeout. Connect gets
+// sessionCtx because the CLI lives as long as that context; on timeout it is
+// cancelled, stopping the CLI, and the error carries the CLI's last stderr
+// lines.
+func (a *Adapter) connectClient(sessionCtx context.Context, sessionCancel context.CancelFunc, client claudecode.Client) error {
+	timeout := a.connectTimeout
+	if timeout <= 0 {
+		timeout = defaultConnectTimeout
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- client.Connect(sessionCtx) }()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case err := <-errCh:
+		return err
+	case <-timer.C:
+	}
+	sessionCancel()
+	go func() {
+		// A Connect that still succeeds leaves a client nobody uses.
+		if err := <-errCh; err == nil {
+			_ = client.Disconnect()
+		}
+	}()
+	err := fmt.Errorf("%w within %s", errConnectTimeout, timeout)
+	if lines := a.stderrTail.Lines(); len(lines) > 0 {
+		err = fmt.Errorf("%w; stderr:\n%s", err, strings.Join(lines, "\n"))
+	}
+	return err
+}
+
+// Close disconnects the SDK client and cancels the session context, which
+// stops the Claude subprocess and the message pump. A connect started by
+// NewSession that is still in flight is torn down once it finishes. Close is
+// safe to call more than once.
+func (a *Adapter) Close() error {
+	a.mu.Lock()
+	a.closed = true
+	client := a.client
+	sessionCancel := a.sessionCancel
+	promptCancel := a.promptCancel
+	a.client = nil
+	a.msgChan = nil
+	a.modelProvider = nil
+	a.mu.Unlock()
+
+	if promptCancel != nil {
+		promptCancel()
+	}
+	if sessionCancel != nil {
+		sessionCancel()
+	}
+	if client != nil {
+		return client.Disconnect()
+	}
+	return nil
+}
+
+// pumpMessages forwards SDK messages until the session context ends or the
+// message stream closes. A closed stream means the subprocess is gone, which
+// is signalled by closing exited so a waiting Prompt fails instead of hanging.
+func (a *Adapter) pumpMessages(ctx context.Context, sessionID acpsdk.SessionId, msgChan <-chan claudecode.Message, exited chan struct{}) {
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case msg, ok := <-msgChan:
 			if !ok || msg == nil {
-				a.completePromptTurn()
+				a.log.Warn("claude message stream closed", "session_id", sessionID)
+				close(exited)
 				return
 			}
 			if a.normalizeAndSend(ctx, sessionID, msg) {
@@ -359,6 +695,7 @@ func (a *Adapter) SetSessionMode(ctx context.Context, req acpsdk.SetSessionModeR
 
 	a.mu.Lock()
 	client := a.client
+	sessionID := acpsdk.SessionId(a.sessionID)
 	a.mu.Unlock()
 
 	if client == nil {
@@ -370,7 +707,7 @@ func (a *Adapter) SetSessionMode(ctx context.Context, req acpsdk.SetSessionModeR
 	}
 
 	// Notify the client of the mode change.
-	a.sendUpdate(ctx, acpsdk.SessionId(a.sessionID), acpsdk.SessionUpdate{
+	a.sendUpdate(ctx, sessionID, acpsdk.SessionUpdate{
 		CurrentModeUpdate: &acpsdk.SessionCurrentModeUpdate{
 			CurrentModeId: req.ModeId,
 		},
@@ -393,11 +730,46 @@ func (a *Adapter) SetSessionModel(ctx context.Context, req acpsdk.SetSessionMode
 	if err := client.SetModel(ctx, &modelStr); err != nil {
 		return acpsdk.SetSessionModelResponse{}, fmt.Errorf("set model: %w", err)
 	}
+	a.mu.Lock()
 	a.model = modelStr
+	a.mu.Unlock()
 
 	return acpsdk.SetSessionModelResponse{}, nil
 }
 
+// Ping checks that the Claude CLI still answers control requests, which lets
+// the driver fail a session whose CLI hangs. Before the first Prompt there is
+// no CLI to check and Ping returns nil.
+func (a *Adapter) Ping(ctx context.Context) error {
+	a.mu.Lock()
+	client := a.client
+	a.mu.Unlock()
+
+	if client == nil {
+		return nil
+	}
+	if err := client.Ping(ctx); err != nil {
+		return fmt.Errorf("ping claude cli: %w", err)
+	}
+	return nil
+}
+
+// thinkingBudget maps a reasoning effort to a Claude extended-thinking token
+// budget, matching the CLI's "think" / "think hard" / "ultrathink" levels.
+// It returns 0 for the agent default.
+func thinkingBudget(effort driver.ReasoningEffort) int {
+	switch effort {
+	case driver.ReasoningEffortLow:
+		return 4000
+	case driver.ReasoningEffortMedium:
+		return 10000
+	case driver.ReasoningEffortHigh:
+		return 31999
+	default:
+		return 0
+	}
+}
+
 // sessionModeToPermission maps a driver.SessionMode to a Claude SDK PermissionMode.
 func sessionModeToPermission(mode driver.SessionMode) (claudecode.PermissionMode, error) {
 	switch mode {
@@ -412,24 +784,52 @@ func sessionModeToPermission(mode driver.SessionMode) (claudecode.PermissionMode
 	}
 }
 
-// handlePermission delegates to the ACP client's RequestPermission.
+// handlePermission decides a permission request by the rules naming the
+// tool, then by the default for its kind, and otherwise delegates to the ACP
+// client's RequestPermission. In Flowgentic plan mode the plan-mode allowlist
+// names the tools that may run, so kind defaults do not apply to them.
 func (a *Adapter) handlePermission(ctx context.Context, sessionID acpsdk.SessionId, toolName string, input map[string]any) (claudecode.PermissionResult, error) {
-	if a.planModeMCP && !isAllowedInFlowgenticPlanMode(toolName) {
-		a.log.Warn("denying tool outside Flowgentic plan mode allowlist", "tool", toolName)
-		return claudecode.NewPermissionResultDeny("tool is not allowed in Flowgentic plan mode"), nil
+	// Take the diff now, before an allowed call runs and changes the file;
+	// the tool call update reuses it.
+	diff, hasDiff := a.fileDiff(toolName, input)
+
+	planAllowed := false
+	if a.planModeMCP {
+		if !a.isAllowedInPlanMode(toolName) {
+			a.log.Warn("denying tool outside Flowgentic plan mode allowlist", "tool", toolName)
+			return claudecode.NewPermissionResultDeny("tool is not allowed in Flowgentic plan mode"), nil
+		}
+		planAllowed = true
 	}
-	if isDisallowedTool(toolName) {
+	if a.isDisallowed(toolName, input) {
 		a.log.Warn("denying disallowed tool call", "tool", toolName)
 		// Do not interrupt the turn; allow the model to continue with plain-text
 		// questions or proceed directly to planning in the same response.
 		return claudecode.NewPermissionResultDeny("tool is not allowed in this session"), nil
 	}
 
+	if a.isAllowedTool(toolName, input) {
+		return claudecode.NewPermissionResultAllow(), nil
+	}
+
+	if !planAllowed {
+		switch kind := toolInfoFromToolUse(toolName, input).Kind; a.kindPermissions[kind] {
+		case driver.KindPermissionAllow:
+			return claudecode.NewPermissionResultAllow(), nil
+		case driver.KindPermissionDeny:
+			a.log.Warn("denying tool call by its kind", "tool", toolName, "kind", kind)
+			return claudecode.NewPermissionResultDeny(fmt.Sprintf("%s tools are not allowed in this session", kind)), nil
+		}
+	}
+
 	if a.conn == nil {
-		return claudecode.NewPermissionResultDeny("no ACP connection"), nil
+		return a.autoApprovePermission(toolName), nil
 	}
 
 	info := toolInfoFromToolUse(toolName, input)
+	if hasDiff {
+		info.Content = diff
+	}
 	meta := newClaudeCodeMeta(toolName)
 
 	// Build permission options — ExitPlanMode gets special options.
@@ -480,6 +880,72 @@ func (a *Adapter) handlePermission(ctx context.Context, sessionID acpsdk.Session
 	}
 }
 
+// autoApprovePermission decides a permission request without an ACP
+// connection, according to the session's auto-approve policy.
+func (a *Adapter) autoApprovePermission(toolName string) claudecode.PermissionResult {
+	switch a.autoApprove {
+	case driver.AutoApproveAllowAll:
+		return claudecode.NewPermissionResultAllow()
+	case driver.AutoApproveAllowSafe:
+		if isReadOnlyTool(toolName) {
+			return claudecode.NewPermissionResultAllow()
+		}
+		return claudecode.NewPermissionResultDeny("no ACP connection; only read-only tools are auto-approved")
+	default:
+		return claudecode.NewPermissionResultDeny("no ACP connection")
+	}
+}
+
+// isReadOnlyTool reports whether toolName only reads the workspace.
+func isReadOnlyTool(toolName string) bool {
+	switch toolName {
+	case "Read", "Glob", "Grep", "LS", "NotebookRead":
+		return true
+	default:
+		return false
+	}
+}
+
+// isDisallowed reports whether a call of toolName with input is denied by
+// default or by a rule in the session's configured disallowedTools.
+func (a *Adapter) isDisallowed(toolName string, input map[string]any) bool {
+	if isDisallowedTool(toolName) {
+		return true
+	}
+	a.mu.Lock()
+	cwd := a.cwd
+	a.mu.Unlock()
+	return matchesToolRules(a.disallowedTools, toolName, input, cwd)
+}
+
+// isAllowedTool reports whether a call of toolName with input is covered by
+// a rule on the session's allowlist, such as "Read" or "Bash(go test:*)".
+func (a *Adapter) isAllowedTool(toolName string, input map[string]any) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return matchesToolRules(a.allowedTools, toolName, input, a.cwd)
+}
+
+// SetAllowedTools replaces the tools approved without asking. Tool calls
+// requested afterwards are checked against the new list; a tool dropped from
+// it goes through the permission request again.
+func (a *Adapter) SetAllowedTools(_ context.Context, sessionID acpsdk.SessionId, tools []string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.sessionID == "" || acpsdk.SessionId(a.sessionID) != sessionID {
+		return fmt.Errorf("unknown session %s", sessionID)
+	}
+	a.allowedTools = slices.Clone(tools)
+	a.log.Info("allowed tools updated", "session_id", sessionID, "tools", tools)
+	return nil
+}
+
+// isAllowedInPlanMode reports whether toolName may run in Flowgentic plan
+// mode, by default or via the session's configured planModeAllowedTools.
+func (a *Adapter) isAllowedInPlanMode(toolName string) bool {
+	return isAllowedInFlowgenticPlanMode(toolName) || slices.Contains(a.planModeAllowedTools, toolName)
+}
+
 func isDisallowedTool(toolName string) bool {
 	switch toolName {
 	case "AskUserQuestion":
@@ -511,6 +977,8 @@ func (a *Adapter) normalizeAndSend(ctx context.Context, sessionID acpsdk.Session
 	switch m := msg.(type) {
 	case *claudecode.AssistantMessage:
 		a.normalizeAssistantMessage(ctx, sessionID, m)
+	case *claudecode.UserMessage:
+		a.normalizeUserMessage(ctx, sessionID, m)
 	case *claudecode.ResultMessage:
 		a.normalizeResultMessage(ctx, sessionID, m)
 		return true
@@ -522,10 +990,72 @@ func (a *Adapter) normalizeAndSend(ctx context.Context, sessionID acpsdk.Session
 	return false
 }
 
-// toolStartOpts builds the StartToolCall options for a given tool, including
-// rich metadata from toolInfoFromToolUse.
-func toolStartOpts(name string, input map[string]any, status acpsdk.ToolCallStatus) (string, []acpsdk.ToolCallStartOpt) {
+// toolInfo is toolInfoFromToolUse with diffs read from the file when the
+// fileDiffs option is set.
+func (a *Adapter) toolInfo(name string, input map[string]any) toolMetadata {
 	info := toolInfoFromToolUse(name, input)
+	if content, ok := a.fileDiff(name, input); ok {
+		info.Content = content
+	}
+	return info
+}
+
+// diffSnapshot is a file diff built when a tool call was first seen.
+type diffSnapshot struct {
+	content []acpsdk.ToolCallContent
+	ok      bool
+}
+
+// fileDiff returns the diff of a file tool call when the fileDiffs option is
+// set. The file is read the first time the call is seen, which is before the
+// tool runs: the CLI waits for the permission decision, and for tools it
+// runs without asking the assistant message arrives first. The second sight
+// reuses that snapshot instead of reading the already changed file.
+func (a *Adapter) fileDiff(name string, input map[string]any) ([]acpsdk.ToolCallContent, bool) {
+	if !a.fileDiffs || input == nil {
+		return nil, false
+	}
+	key, keyed := diffKey(name, input)
+	a.mu.Lock()
+	if snap, ok := a.diffSnapshots[key]; keyed && ok {
+		delete(a.diffSnapshots, key)
+		a.mu.Unlock()
+		return snap.content, snap.ok
+	}
+	cwd := a.cwd
+	a.mu.Unlock()
+
+	content, ok := fileDiffContent(cwd, name, input)
+	if keyed {
+		a.mu.Lock()
+		if a.diffSnapshots == nil {
+			a.diffSnapshots = make(map[string]diffSnapshot)
+		}
+		a.diffSnapshots[key] = diffSnapshot{content: content, ok: ok}
+		a.mu.Unlock()
+	}
+	return content, ok
+}
+
+// diffKey identifies a file tool call by its name and input; the permission
+// callback gets no tool use ID. keyed is false for tools without diffs.
+func diffKey(name string, input map[string]any) (key string, keyed bool) {
+	switch name {
+	case "Edit", "MultiEdit", "Write":
+	default:
+		return "", false
+	}
+	raw, err := json.Marshal(input)
+	if err != nil {
+		return "", false
+	}
+	return name + "\x00" + string(raw), true
+}
+
+// toolStartOpts builds the StartToolCall options for a given tool, including
+// rich metadata from toolInfo.
+func (a *Adapter) toolStartOpts(name string, input map[string]any, status acpsdk.ToolCallStatus) (string, []acpsdk.ToolCallStartOpt) {
+	info := a.toolInfo(name, input)
 	meta := newClaudeCodeMeta(name)
 
 	opts := []acpsdk.ToolCallStartOpt{
@@ -549,14 +1079,29 @@ func toolStartOpts(name string, input map[string]any, status acpsdk.ToolCallStat
 	return info.Title, opts
 }
 
+// withUpdateMeta sets a tool call update's _meta, which has no SDK helper.
+func withUpdateMeta(meta any) acpsdk.ToolCallUpdateOpt {
+	return func(u *acpsdk.SessionToolCallUpdate) {
+		u.Meta = meta
+	}
+}
+
 func (a *Adapter) normalizeAssistantMessage(ctx context.Context, sessionID acpsdk.SessionId, msg *claudecode.AssistantMessage) {
+	if msg.StopReason == refusalStopReason {
+		a.turnRefused.Store(true)
+	}
+
 	// A new assistant message means any previously active tools have completed,
 	// EXCEPT tools that appear in this message (they're being upgraded from
 	// pending → in_progress). Collect those IDs first to avoid premature completion.
 	keep := make(map[string]bool)
+	resultTools := make(map[string]activeTool)
 	for _, block := range msg.Content {
-		if b, ok := block.(*claudecode.ToolUseBlock); ok {
+		switch b := block.(type) {
+		case *claudecode.ToolUseBlock:
 			keep[b.ToolUseID] = true
+		case *claudecode.ToolResultBlock:
+			resultTools[b.ToolUseID] = a.currentTool(b.ToolUseID)
 		}
 	}
 	a.completeActiveToolsExcept(ctx, sessionID, keep)
@@ -569,14 +1114,15 @@ func (a *Adapter) normalizeAssistantMessage(ctx context.Context, sessionID acpsd
 			// Skip — already streamed via thinking_delta stream events.
 		case *claudecode.ToolUseBlock:
 			id := b.ToolUseID
-			if _, already := a.activeTools[id]; already {
+			if a.isActiveTool(id) {
 				// Already started via stream event — upgrade to in_progress with input.
-				info := toolInfoFromToolUse(b.Name, b.Input)
+				info := a.toolInfo(b.Name, b.Input)
 				updateOpts := []acpsdk.ToolCallUpdateOpt{
 					acpsdk.WithUpdateStatus(acpsdk.ToolCallStatusInProgress),
 					acpsdk.WithUpdateRawInput(b.Input),
 					acpsdk.WithUpdateTitle(info.Title),
 					acpsdk.WithUpdateKind(info.Kind),
+					withUpdateMeta(newClaudeCodeMeta(b.Name)),
 				}
 				if len(info.Content) > 0 {
 					updateOpts = append(updateOpts, acpsdk.WithUpdateContent(info.Content))
@@ -587,36 +1133,111 @@ func (a *Adapter) normalizeAssistantMessage(ctx context.Context, sessionID acpsd
 				))
 			} else {
 				// No stream event preceded this — send full StartToolCall.
-				title, opts := toolStartOpts(b.Name, b.Input, acpsdk.ToolCallStatusInProgress)
+				title, opts := a.toolStartOpts(b.Name, b.Input, acpsdk.ToolCallStatusInProgress)
 				a.sendUpdate(ctx, sessionID, acpsdk.StartToolCall(
 					acpsdk.ToolCallId(id),
 					title,
 					opts...,
 				))
-				if a.activeTools == nil {
-					a.activeTools = make(map[string]string)
-				}
-				a.activeTools[id] = b.Name
+				a.trackTool(id, b.Name)
 			}
+			a.setToolInput(id, b.Input)
 		case *claudecode.ToolResultBlock:
-			status := acpsdk.ToolCallStatusCompleted
-			if b.IsError != nil && *b.IsError {
-				status = acpsdk.ToolCallStatusFailed
-			}
-			raw, _ := json.Marshal(b.Content)
-			a.sendUpdate(ctx, sessionID, acpsdk.UpdateToolCall(
-				acpsdk.ToolCallId(b.ToolUseID),
-				acpsdk.WithUpdateStatus(status),
-				acpsdk.WithUpdateRawOutput(json.RawMessage(raw)),
-			))
-			delete(a.activeTools, b.ToolUseID)
+			a.sendToolResult(ctx, sessionID, b, resultTools[b.ToolUseID], nil)
 		}
 	}
 }
 
-func (a *Adapter) normalizeResultMessage(ctx context.Context, sessionID acpsdk.SessionId, _ *claudecode.ResultMessage) {
+// normalizeUserMessage forwards tool results that the CLI reports back as
+// user turns. Other user content is our own prompt echoed back and skipped.
+func (a *Adapter) normalizeUserMessage(ctx context.Context, sessionID acpsdk.SessionId, msg *claudecode.UserMessage) {
+	blocks, ok := msg.Content.([]claudecode.ContentBlock)
+	if !ok {
+		return
+	}
+	for _, block := range blocks {
+		if b, ok := block.(*claudecode.ToolResultBlock); ok {
+			a.sendToolResult(ctx, sessionID, b, a.currentTool(b.ToolUseID), msg.ToolUseResult)
+		}
+	}
+}
+
+// sendToolResult completes a tool call with its output. For execute-kind
+// tools the output is also attached as structured command output, and
+// results of flowgentic's own tools get a summary as content.
+func (a *Adapter) sendToolResult(ctx context.Context, sessionID acpsdk.SessionId, b *claudecode.ToolResultBlock, tool activeTool, toolUseResult map[string]any) {
+	toolName := tool.name
+	isError := b.IsError != nil && *b.IsError
+	status := acpsdk.ToolCallStatusCompleted
+	if isError {
+		status = acpsdk.ToolCallStatusFailed
+	}
+	raw, _ := json.Marshal(b.Content)
+	opts := []acpsdk.ToolCallUpdateOpt{
+		acpsdk.WithUpdateStatus(status),
+		acpsdk.WithUpdateRawOutput(json.RawMessage(raw)),
+	}
+	if toolName != "" && toolInfoFromToolUse(toolName, nil).Kind == acpsdk.ToolKindExecute {
+		opts = append(opts, driver.WithCommandOutput(bashCommandOutput(toolResultText(b.Content), isError, toolUseResult)))
+	}
+	if summary, ok := driver.FlowgenticToolSummary(toolName, tool.input, b.Content); ok && !isError {
+		opts = append(opts, acpsdk.WithUpdateContent([]acpsdk.ToolCallContent{
+			acpsdk.ToolContent(acpsdk.TextBlock(summary)),
+		}))
+	}
+	a.sendUpdate(ctx, sessionID, acpsdk.UpdateToolCall(acpsdk.ToolCallId(b.ToolUseID), opts...))
+	delete(a.activeTools, b.ToolUseID)
+}
+
+func (a *Adapter) normalizeResultMessage(ctx context.Context, sessionID acpsdk.SessionId, msg *claudecode.ResultMessage) {
+	if msg.IsError && msg.Result != nil && driver.LooksLikeAuthFailure(*msg.Result) {
+		a.authMu.Lock()
+		a.resultAuthFailure = *msg.Result
+		a.authMu.Unlock()
+	}
+	if msg.Usage != nil {
+		var u driver.TurnUsage
+		if n, ok := driver.IntOption(*msg.Usage, "input_tokens"); ok {
+			u.InputTokens = int64(n)
+		}
+		if n, ok := driver.IntOption(*msg.Usage, "output_tokens"); ok {
+			u.OutputTokens = int64(n)
+		}
+		a.mu.Lock()
+		a.turnUsage = &u
+		a.mu.Unlock()
+	}
 	// Result message signals conversation completion — complete any remaining tools.
 	a.completeActiveTools(ctx, sessionID)
+	a.mu.Lock()
+	clear(a.diffSnapshots)
+	a.mu.Unlock()
+}
+
+// authError returns an ACP "authentication required" error when the CLI
+// rejected its credentials, and nil otherwise. A non-nil err is a failed
+// connect or query, classified by its text or the stderr the CLI printed
+// before failing; a nil err checks the result of a completed turn. Recorded
+// failures are consumed.
+func (a *Adapter) authError(err error) error {
+	a.authMu.Lock()
+	stderrMsg, resultMsg := a.stderrAuthFailure, a.resultAuthFailure
+	a.stderrAuthFailure, a.resultAuthFailure = "", ""
+	a.authMu.Unlock()
+
+	var msg string
+	switch {
+	case err == nil:
+		msg = resultMsg
+	case stderrMsg != "":
+		msg = stderrMsg
+	case driver.LooksLikeAuthFailure(err.Error()):
+		msg = err.Error()
+	}
+	if msg == "" {
+		return nil
+	}
+	return driver.NewAuthRequiredError(msg)
 }
 
 // completeActiveTools sends completion updates for all tracked tool calls
@@ -627,11 +1248,26 @@ func (a *Adapter) completeActiveTools(ctx context.Context, sessionID acpsdk.Sess
 
 // completeActiveToolsExcept sends completion updates for tracked tool calls,
 // skipping any IDs in the keep set. Completed tools are removed from activeTools.
+// Tools left over from an earlier turn are dropped without an update: the
+// driver closed them when that turn ended, and their ID may now name a new
+// call.
 func (a *Adapter) completeActiveToolsExcept(ctx context.Context, sessionID acpsdk.SessionId, keep map[string]bool) {
-	for id := range a.activeTools {
-		if keep[id] {
+	turn := a.turnSeq.Load()
+	var done []string
+	for id, tool := range a.activeTools {
+		if tool.turn != turn {
+			delete(a.activeTools, id)
 			continue
 		}
+		if !keep[id] {
+			done = append(done, id)
+		}
+	}
+	// Complete in start order so parallel tools finish in a stable order.
+	slices.SortFunc(done, func(x, y string) int {
+		return cmp.Or(cmp.Compare(a.activeTools[x].seq, a.activeTools[y].seq), strings.Compare(x, y))
+	})
+	for _, id := range done {
 		a.sendUpdate(ctx, sessionID, acpsdk.UpdateToolCall(
 			acpsdk.ToolCallId(id),
 			acpsdk.WithUpdateStatus(acpsdk.ToolCallStatusCompleted),
@@ -643,6 +1279,50 @@ func (a *Adapter) completeActiveToolsExcept(ctx context.Context, sessionID acpsd
 	}
 }
 
+// activeTool is a started tool call, the turn it was started in and its
+// position in start order.
+type activeTool struct {
+	name  string
+	input map[string]any // nil until the assistant message carries it
+	turn  uint64
+	seq   uint64
+}
+
+// trackTool records id as a tool call started in the current turn,
+// replacing any earlier call that used the same ID.
+func (a *Adapter) trackTool(id, name string) {
+	if a.activeTools == nil {
+		a.activeTools = make(map[string]activeTool)
+	}
+	a.toolSeq++
+	a.activeTools[id] = activeTool{name: name, turn: a.turnSeq.Load(), seq: a.toolSeq}
+}
+
+// isActiveTool reports whether id names a tool call started in the current
+// turn and not yet completed.
+func (a *Adapter) isActiveTool(id string) bool {
+	tool, ok := a.activeTools[id]
+	return ok && tool.turn == a.turnSeq.Load()
+}
+
+// currentTool returns the current turn's tool call id, or the zero
+// activeTool if there is none.
+func (a *Adapter) currentTool(id string) activeTool {
+	if !a.isActiveTool(id) {
+		return activeTool{}
+	}
+	return a.activeTools[id]
+}
+
+// setToolInput records the input of the current turn's tool call id.
+func (a *Adapter) setToolInput(id string, input map[string]any) {
+	if a.isActiveTool(id) {
+		tool := a.activeTools[id]
+		tool.input = input
+		a.activeTools[id] = tool
+	}
+}
+
 func (a *Adapter) normalizeStreamEvent(ctx context.Context, sessionID acpsdk.SessionId, msg *claudecode.StreamEvent) bool {
 	if msg.Event == nil {
 		return false
@@ -651,6 +1331,13 @@ func (a *Adapter) normalizeStreamEvent(ctx context.Context, sessionID acpsdk.Ses
 	eventType, _ := msg.Event["type"].(string)
 
 	switch eventType {
+	case "message_delta":
+		// The API reports a refusal as the message's stop reason.
+		if delta, ok := msg.Event["delta"].(map[string]any); ok && delta["stop_reason"] == refusalStopReason {
+			a.turnRefused.Store(true)
+		}
+		return false
+
 	case "message_stop":
 		// Some SDK/client combinations can end a turn with stream boundary
 		// events between assistant chunks. Ensure no tool card is left
@@ -668,13 +1355,10 @@ func (a *Adapter) normalizeStreamEvent(ctx context.Context, sessionID acpsdk.Ses
 		case "tool_use":
 			name, _ := cb["name"].(string)
 			id, _ := cb["id"].(string)
-			if a.activeTools == nil {
-				a.activeTools = make(map[string]string)
-			}
-			a.activeTools[id] = name
+			a.trackTool(id, name)
 			// Stream events don't have input yet, so we pass nil — metadata
 			// will be enriched when the AssistantMessage arrives with input.
-			title, opts := toolStartOpts(name, nil, acpsdk.ToolCallStatusPending)
+			title, opts := a.toolStartOpts(name, nil, acpsdk.ToolCallStatusPending)
 			a.sendUpdate(ctx, sessionID, acpsdk.StartToolCall(
 				acpsdk.ToolCallId(id),
 				title,
@@ -706,7 +1390,7 @@ func (a *Adapter) normalizeStreamEvent(ctx context.Context, sessionID acpsdk.Ses
 			}
 		}
 		if toolID != "" {
-			if _, active := a.activeTools[toolID]; active {
+			if a.isActiveTool(toolID) {
 				a.sendUpdate(ctx, sessionID, acpsdk.UpdateToolCall(
 					acpsdk.ToolCallId(toolID),
 					acpsdk.WithUpdateStatus(acpsdk.ToolCallStatusCompleted),
@@ -748,6 +1432,9 @@ func (a *Adapter) normalizeSystemMessage(ctx context.Context, sessionID acpsdk.S
 		"message_keys", nestedMapKeys(msg.Data["message"]),
 	)
 
+	if msg.Subtype == "init" {
+		a.emitSessionInit(ctx, sessionID, msg.Data)
+	}
 	if cmds, ok := extractAvailableCommands(msg.Data); ok {
 		a.sendUpdate(ctx, sessionID, acpsdk.SessionUpdate{
 			AvailableCommandsUpdate: &acpsdk.SessionAvailableCommandsUpdate{
@@ -791,6 +1478,40 @@ func (a *Adapter) normalizeSystemMessage(ctx context.Context, sessionID acpsdk.S
 	}
 }
 
+// emitSessionInit reports the configuration of the CLI's first init message
+// as a driver.SessionInit.
+func (a *Adapter) emitSessionInit(ctx context.Context, sessionID acpsdk.SessionId, data map[string]any) {
+	info, ok := parseSessionInit(data)
+	if !ok {
+		return
+	}
+	a.mu.Lock()
+	if a.sessionInitSent {
+		a.mu.Unlock()
+		return
+	}
+	a.sessionInitSent = true
+	a.mu.Unlock()
+
+	a.log.Info("claude session initialized", "model", info.Model, "permission_mode", info.PermissionMode, "tools", len(info.Tools))
+	a.sendUpdate(ctx, sessionID, driver.SessionInitUpdate(info))
+}
+
+// parseSessionInit reads the model, permission mode and tools of an init
+// system message. It reports false if the message carries none of them.
+func parseSessionInit(data map[string]any) (driver.SessionInit, bool) {
+	var s driver.SessionInit
+	s.Model, _ = data["model"].(string)
+	s.PermissionMode, _ = data["permissionMode"].(string)
+	tools, hasTools := data["tools"].([]any)
+	for _, t := range tools {
+		if name, ok := t.(string); ok {
+			s.Tools = append(s.Tools, name)
+		}
+	}
+	return s, s.Model != "" || s.PermissionMode != "" || hasTools
+}
+
 func extractAvailableCommands(data map[string]any) ([]acpsdk.AvailableCommand, bool) {
 	if len(data) == 0 {
 		return nil, false
@@ -952,8 +1673,12 @@ func (a *Adapter) buildSDKOptions() []claudecode.Option {
 		claudecode.SettingSourceProject,
 		claudecode.SettingSourceLocal,
 	))
-	if len(a.allowedTools) > 0 {
-		sdkOpts = append(sdkOpts, claudecode.WithAllowedTools(a.allowedTools...))
+	// allowedTools are not passed to the CLI: it would run them without
+	// asking handlePermission, so SetAllowedTools could not revoke them.
+	// Also hand configured denials to the CLI: in bypassPermissions mode it
+	// never asks handlePermission.
+	if len(a.disallowedTools) > 0 {
+		sdkOpts = append(sdkOpts, claudecode.WithDisallowedTools(a.disallowedTools...))
 	}
 	if sm, err := driver.ParseSessionMode(a.sessionMode); err == nil {
 		if perm, err := sessionModeToPermission(sm); err == nil {
@@ -965,21 +1690,22 @@ func (a *Adapter) buildSDKOptions() []claudecode.Option {
 	if len(a.envVars) > 0 {
 		sdkOpts = append(sdkOpts, claudecode.WithEnv(a.envVars))
 	}
+	budget := thinkingBudget(a.effort)
+	if a.maxThinkingTokens > 0 {
+		budget = a.maxThinkingTokens
+	}
+	if budget > 0 {
+		// The CLI has no --max-thinking-tokens flag; it reads the budget
+		// from MAX_THINKING_TOKENS instead.
+		sdkOpts = append(sdkOpts,
+			claudecode.WithMaxThinkingTokens(budget),
+			claudecode.WithEnvVar("MAX_THINKING_TOKENS", strconv.Itoa(budget)),
+		)
+	}
 	if len(a.mcpServers) > 0 {
 		sdkOpts = append(sdkOpts, claudecode.WithMcpServers(a.mcpServers))
-		sdkOpts = append(sdkOpts, claudecode.WithStderrCallback(func(line string) {
-			l := strings.TrimSpace(line)
-			if l == "" {
-				return
-			}
-			a.appendSubprocessDebugLine("stderr", l)
-			if strings.Contains(strings.ToLower(l), "mcp") {
-				a.log.Warn("claude stderr (mcp)", "line", l)
-				return
-			}
-			a.log.Debug("claude stderr", "line", l)
-		}))
 	}
+	sdkOpts = append(sdkOpts, claudecode.WithStderrCallback(a.handleStderrLine))
 
 	sdkOpts = append(sdkOpts, claudecode.WithPartialStreaming())
 	sdkOpts = append(sdkOpts, claudecode.WithDebugWriter(io.Discard))
@@ -987,23 +1713,41 @@ func (a *Adapter) buildSDKOptions() []claudecode.Option {
 	return sdkOpts
 }
 
-func (a *Adapter) appendSubprocessDebugLine(stream, line string) {
-	const debugPath = "/tmp/flowgentic-claude-acp.log"
-
-	f, err := os.OpenFile(debugPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
-	if err != nil {
+// handleStderrLine forwards CLI stderr to the session's stderr sink and
+// watches it for authentication failures and MCP startup issues.
+func (a *Adapter) handleStderrLine(line string) {
+	l := strings.TrimSpace(line)
+	if l == "" {
 		return
 	}
-	defer f.Close()
+	a.stderrTail.Add(l)
+	if a.stderrSink != nil {
+		a.stderrSink(l)
+	}
+	// MCP servers print their own auth errors; those don't concern the CLI.
+	if driver.LooksLikeAuthFailure(l) && !strings.Contains(strings.ToLower(l), "mcp") {
+		a.log.Warn("claude stderr (auth)", "line", l)
+		a.authMu.Lock()
+		a.stderrAuthFailure = l
+		a.authMu.Unlock()
+	}
+	if len(a.mcpServers) > 0 && strings.Contains(strings.ToLower(l), "mcp") {
+		a.log.Warn("claude stderr (mcp)", "line", l)
+	}
+}
 
-	_, _ = fmt.Fprintf(
-		f,
-		"%s stream=%s session=%s line=%s\n",
-		time.Now().UTC().Format(time.RFC3339Nano),
-		stream,
-		a.sessionID,
-		line,
-	)
+// sessionModelState clones state and reports the requested model as current,
+// falling back to the SDK default when no model was requested.
+func (a *Adapter) sessionModelState(state *acpsdk.SessionModelState) *acpsdk.SessionModelState {
+	cloned := *state
+	cloned.AvailableModels = append([]acpsdk.ModelInfo(nil), state.AvailableModels...)
+	a.mu.Lock()
+	model := a.model
+	a.mu.Unlock()
+	if model != "" {
+		cloned.CurrentModelId = acpsdk.ModelId(model)
+	}
+	return &cloned
 }
 
 // sdkModelProvider implements modelStateProvider using the SDK's SupportedModels method.
@@ -1046,6 +1790,22 @@ var (
 	_ acpsdk.AgentExperimental = (*Adapter)(nil)
 )
 
+// metaStrings returns the string entries of the _meta list at key.
+func metaStrings(meta map[string]any, key string) []string {
+	var out []string
+	switch v := meta[key].(type) {
+	case []any:
+		for _, t := range v {
+			if s, ok := t.(string); ok {
+				out = append(out, s)
+			}
+		}
+	case []string:
+		out = append(out, v...)
+	}
+	return out
+}
+
 func mapKeys[K comparable, V any](m map[K]V) []K {
 	if len(m) == 0 {
 		return nil
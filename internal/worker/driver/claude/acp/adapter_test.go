@@ -1,11 +1,19 @@
 package acp
 
 import (
+	"cmp"
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	acpsdk "github.com/coder/acp-go-sdk"
 	claudecode "github.com/sebastianm/flowgentic/internal/claude-agent-sdk-go"
+	"github.com/sebastianm/flowgentic/internal/worker/driver"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -52,6 +60,27 @@ func TestNewSession_ReturnsModelStateWhenProviderAvailable(t *testing.T) {
 	assert.Equal(t, "claude-sonnet", string(resp.Models.AvailableModels[0].ModelId))
 }
 
+func TestNewSession_ReportsRequestedModelAsCurrent(t *testing.T) {
+	a, _ := newTestAdapter()
+	a.modelProvider = staticModelProvider{
+		state: &acpsdk.SessionModelState{
+			AvailableModels: []acpsdk.ModelInfo{
+				{ModelId: "claude-sonnet"},
+				{ModelId: "claude-opus"},
+			},
+			CurrentModelId: "claude-sonnet",
+		},
+	}
+
+	resp, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{
+		Cwd:  "/tmp",
+		Meta: map[string]any{"model": "claude-opus"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, resp.Models)
+	assert.Equal(t, "claude-opus", string(resp.Models.CurrentModelId))
+}
+
 func TestNewSession_DoesNotEmitAvailableCommandsOnStartup(t *testing.T) {
 	a, fake := newTestAdapter()
 	resp, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{Cwd: t.TempDir()})
@@ -75,6 +104,44 @@ func TestBuildSDKOptions_SetsAllSettingSources(t *testing.T) {
 	}, opts.SettingSources)
 }
 
+func TestBuildSDKOptions_ReasoningEffort(t *testing.T) {
+	a, _ := newTestAdapter()
+	_, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{
+		Cwd:  t.TempDir(),
+		Meta: map[string]any{"reasoningEffort": "high"},
+	})
+	require.NoError(t, err)
+
+	opts := claudecode.NewOptions(a.buildSDKOptions()...)
+	assert.Equal(t, 31999, opts.MaxThinkingTokens)
+	assert.Equal(t, "31999", opts.ExtraEnv["MAX_THINKING_TOKENS"])
+}
+
+func TestBuildSDKOptions_MaxThinkingTokensAdapterOption(t *testing.T) {
+	a, _ := newTestAdapter()
+	_, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{
+		Cwd: t.TempDir(),
+		Meta: map[string]any{
+			"reasoningEffort": "high",
+			"adapterOptions":  map[string]any{"maxThinkingTokens": float64(4096), "unknown": true},
+		},
+	})
+	require.NoError(t, err)
+
+	opts := claudecode.NewOptions(a.buildSDKOptions()...)
+	assert.Equal(t, 4096, opts.MaxThinkingTokens, "the adapter option overrides the effort budget")
+	assert.Equal(t, "4096", opts.ExtraEnv["MAX_THINKING_TOKENS"])
+}
+
+func TestBuildSDKOptions_NoReasoningEffortKeepsDefault(t *testing.T) {
+	a, _ := newTestAdapter()
+	a.cwd = t.TempDir()
+
+	opts := claudecode.NewOptions(a.buildSDKOptions()...)
+	assert.Equal(t, claudecode.NewOptions().MaxThinkingTokens, opts.MaxThinkingTokens)
+	assert.NotContains(t, opts.ExtraEnv, "MAX_THINKING_TOKENS")
+}
+
 func TestNormalizeAndSend_AssistantMessage_SkipsTextAndThinking(t *testing.T) {
 	a, fake := newTestAdapter()
 	ctx := context.Background()
@@ -170,6 +237,7 @@ func TestToolCallLifecycle_StreamThenBatch(t *testing.T) {
 	assert.Equal(t, "Read /tmp/foo", *u1.ToolCallUpdate.Title)
 	require.NotNil(t, u1.ToolCallUpdate.Kind)
 	assert.Equal(t, acpsdk.ToolKindRead, *u1.ToolCallUpdate.Kind)
+	assert.Equal(t, "Read", driver.ToolNameFromMeta(u1.ToolCallUpdate.Meta), "upgrade names the tool for input parsing")
 
 	// Update 2: UpdateToolCall(t1, completed) — from completeActiveTools on next message
 	u2 := updates[2].Update
@@ -316,19 +384,41 @@ func TestToolCallLifecycle_MultipleTools(t *testing.T) {
 	}
 
 	// Each tool started once (pending from stream).
-	assert.Len(t, starts, 2, "each tool started once (pending from stream)")
-	assert.Contains(t, starts, acpsdk.ToolCallId("t1"))
-	assert.Contains(t, starts, acpsdk.ToolCallId("t2"))
+	assert.Equal(t, []acpsdk.ToolCallId{"t1", "t2"}, starts, "each tool started once (pending from stream)")
 
 	// Each tool upgraded once (in_progress from batch).
-	assert.Len(t, inProgress, 2, "each tool upgraded once (in_progress from batch)")
-	assert.Contains(t, inProgress, acpsdk.ToolCallId("t1"))
-	assert.Contains(t, inProgress, acpsdk.ToolCallId("t2"))
+	assert.Equal(t, []acpsdk.ToolCallId{"t1", "t2"}, inProgress, "each tool upgraded once (in_progress from batch)")
+
+	// Each tool completed once (from completeActiveTools on next message),
+	// in start order.
+	assert.Equal(t, []acpsdk.ToolCallId{"t1", "t2"}, completions, "each tool completed once, in start order")
+}
+
+func TestToolCallLifecycle_CompletesInStartOrder(t *testing.T) {
+	a, fake := newTestAdapter()
+	ctx := context.Background()
+
+	ids := []string{"toolu_c", "toolu_a", "toolu_d", "toolu_b"}
+	for _, id := range ids {
+		a.normalizeAndSend(ctx, testSessionID, &claudecode.StreamEvent{
+			Event: map[string]any{
+				"type":          "content_block_start",
+				"content_block": map[string]any{"type": "tool_use", "id": id, "name": "Read"},
+			},
+		})
+	}
+	a.normalizeAndSend(ctx, testSessionID, &claudecode.AssistantMessage{
+		MessageType: "assistant",
+		Content:     []claudecode.ContentBlock{&claudecode.TextBlock{MessageType: "text", Text: "done"}},
+	})
 
-	// Each tool completed once (from completeActiveTools on next message).
-	assert.Len(t, completions, 2, "each tool completed once")
-	assert.Contains(t, completions, acpsdk.ToolCallId("t1"))
-	assert.Contains(t, completions, acpsdk.ToolCallId("t2"))
+	var completions []string
+	for _, u := range fake.allUpdates() {
+		if tu := u.Update.ToolCallUpdate; tu != nil && tu.Status != nil && *tu.Status == acpsdk.ToolCallStatusCompleted {
+			completions = append(completions, string(tu.ToolCallId))
+		}
+	}
+	assert.Equal(t, ids, completions)
 }
 
 func TestToolCallLifecycle_CompletedOnResult(t *testing.T) {
@@ -459,12 +549,211 @@ func TestIsAllowedInFlowgenticPlanMode(t *testing.T) {
 	assert.False(t, isAllowedInFlowgenticPlanMode("AskUserQuestion"))
 }
 
+func TestHandlePermission_ConfiguredToolPolicy(t *testing.T) {
+	// newTestAdapter has no ACP connection, so a tool that passes the policy
+	// checks is denied with "no ACP connection" instead of a policy message.
+	a, _ := newTestAdapter()
+	_, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{
+		Cwd: t.TempDir(),
+		Meta: map[string]any{
+			"disallowedTools":      []any{"WebFetch"},
+			"planModeAllowedTools": []any{"WebSearch"},
+		},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"WebFetch"}, a.disallowedTools)
+	assert.Equal(t, []string{"WebSearch"}, a.planModeAllowedTools)
+
+	deny := func(tool string) string {
+		t.Helper()
+		res, err := a.handlePermission(context.Background(), testSessionID, tool, nil)
+		require.NoError(t, err)
+		d, ok := res.(claudecode.PermissionResultDeny)
+		require.True(t, ok, "expected deny for %s", tool)
+		return d.Message
+	}
+
+	t.Run("configured denied tool is rejected", func(t *testing.T) {
+		assert.Equal(t, "tool is not allowed in this session", deny("WebFetch"))
+		assert.Equal(t, "tool is not allowed in this session", deny("AskUserQuestion"), "built-in default still applies")
+		assert.Equal(t, "no ACP connection", deny("Read"))
+	})
+
+	t.Run("configured plan-mode tool is allowed", func(t *testing.T) {
+		a.planModeMCP = true
+		defer func() { a.planModeMCP = false }()
+
+		assert.Equal(t, "no ACP connection", deny("WebSearch"))
+		assert.Equal(t, "no ACP connection", deny("Grep"), "built-in allowlist still applies")
+		assert.Equal(t, "tool is not allowed in Flowgentic plan mode", deny("Bash"))
+	})
+}
+
+func TestHandlePermission_AutoApprovePolicyWithoutConnection(t *testing.T) {
+	allowed := func(t *testing.T, a *Adapter, tool string) bool {
+		t.Helper()
+		res, err := a.handlePermission(context.Background(), testSessionID, tool, nil)
+		require.NoError(t, err)
+		_, ok := res.(claudecode.PermissionResultAllow)
+		return ok
+	}
+
+	for _, tc := range []struct {
+		policy        string
+		read, mutates bool
+	}{
+		{policy: "", read: false, mutates: false},
+		{policy: "deny-all", read: false, mutates: false},
+		{policy: "allow-safe", read: true, mutates: false},
+		{policy: "allow-all", read: true, mutates: true},
+	} {
+		t.Run(cmp.Or(tc.policy, "unset"), func(t *testing.T) {
+			a, _ := newTestAdapter()
+			meta := map[string]any{}
+			if tc.policy != "" {
+				meta["autoApprovePolicy"] = tc.policy
+			}
+			_, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{Cwd: t.TempDir(), Meta: meta})
+			require.NoError(t, err)
+
+			assert.Equal(t, tc.read, allowed(t, a, "Read"))
+			assert.Equal(t, tc.read, allowed(t, a, "Grep"))
+			assert.Equal(t, tc.mutates, allowed(t, a, "Write"))
+			assert.Equal(t, tc.mutates, allowed(t, a, "Bash"))
+			assert.False(t, allowed(t, a, "AskUserQuestion"), "built-in denials still apply")
+		})
+	}
+}
+
+func TestHandlePermission_KindPermissions(t *testing.T) {
+	decide := func(t *testing.T, a *Adapter, tool string) (bool, string) {
+		t.Helper()
+		res, err := a.handlePermission(context.Background(), testSessionID, tool, nil)
+		require.NoError(t, err)
+		if d, ok := res.(claudecode.PermissionResultDeny); ok {
+			return false, d.Message
+		}
+		return true, ""
+	}
+
+	// Without an ACP connection and with the default deny-all policy, a
+	// tool left to ask is denied with "no ACP connection".
+	a, _ := newTestAdapter()
+	_, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{
+		Cwd: t.TempDir(),
+		Meta: map[string]any{
+			"toolKindPermissions": map[string]any{"read": "allow", "search": "allow", "edit": "ask", "execute": "deny"},
+			"allowedTools":        []any{"Bash"},
+			"disallowedTools":     []any{"Grep"},
+		},
+	})
+	require.NoError(t, err)
+
+	t.Run("kind defaults", func(t *testing.T) {
+		allowed, _ := decide(t, a, "Read")
+		assert.True(t, allowed, "read")
+		allowed, _ = decide(t, a, "Glob")
+		assert.True(t, allowed, "search")
+		_, msg := decide(t, a, "Edit")
+		assert.Equal(t, "no ACP connection", msg, "edit is asked about")
+		_, msg = decide(t, a, "WebFetch")
+		assert.Equal(t, "no ACP connection", msg, "kinds without a default are asked about")
+	})
+
+	t.Run("name rules win", func(t *testing.T) {
+		allowed, _ := decide(t, a, "Bash")
+		assert.True(t, allowed, "allowed by name although execute is denied")
+		_, msg := decide(t, a, "Grep")
+		assert.Equal(t, "tool is not allowed in this session", msg, "disallowed by name although search is allowed")
+	})
+
+	t.Run("denied kind", func(t *testing.T) {
+		// Bash is allowed by name above; a session without that rule denies it by kind.
+		b, _ := newTestAdapter()
+		_, err := b.NewSession(context.Background(), acpsdk.NewSessionRequest{
+			Cwd:  t.TempDir(),
+			Meta: map[string]any{"toolKindPermissions": map[string]any{"execute": "deny"}, "autoApprovePolicy": "allow-all"},
+		})
+		require.NoError(t, err)
+		allowed, msg := decide(t, b, "Bash")
+		assert.False(t, allowed)
+		assert.Equal(t, "execute tools are not allowed in this session", msg)
+		allowed, _ = decide(t, b, "Write")
+		assert.True(t, allowed, "other kinds fall through to the auto-approve policy")
+	})
+
+	t.Run("plan-mode tools skip kind defaults", func(t *testing.T) {
+		b, _ := newTestAdapter()
+		_, err := b.NewSession(context.Background(), acpsdk.NewSessionRequest{
+			Cwd:  t.TempDir(),
+			Meta: map[string]any{"toolKindPermissions": map[string]any{"edit": "deny", "read": "allow"}},
+		})
+		require.NoError(t, err)
+		b.planModeMCP = true
+		_, msg := decide(t, b, "Write")
+		assert.Equal(t, "no ACP connection", msg, "asked about although edit is denied")
+		_, msg = decide(t, b, "Read")
+		assert.Equal(t, "no ACP connection", msg, "asked about although read is allowed")
+		b.planModeMCP = false
+		_, msg = decide(t, b, "Write")
+		assert.Equal(t, "edit tools are not allowed in this session", msg, "outside plan mode the kind applies")
+	})
+
+	t.Run("invalid config is ignored", func(t *testing.T) {
+		b, _ := newTestAdapter()
+		_, err := b.NewSession(context.Background(), acpsdk.NewSessionRequest{
+			Cwd:  t.TempDir(),
+			Meta: map[string]any{"toolKindPermissions": map[string]any{"read": "yes"}},
+		})
+		require.NoError(t, err)
+		assert.Nil(t, b.kindPermissions)
+	})
+}
+
+func TestSetAllowedTools_AppliesToLaterToolCalls(t *testing.T) {
+	allowed := func(t *testing.T, a *Adapter, tool string) bool {
+		t.Helper()
+		res, err := a.handlePermission(context.Background(), testSessionID, tool, nil)
+		require.NoError(t, err)
+		_, ok := res.(claudecode.PermissionResultAllow)
+		return ok
+	}
+
+	// Without an ACP connection and with the default deny-all policy, only
+	// allowed tools run.
+	a, _ := newTestAdapter()
+	resp, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{
+		Cwd:  t.TempDir(),
+		Meta: map[string]any{"allowedTools": []any{"Bash"}},
+	})
+	require.NoError(t, err)
+	assert.Empty(t, claudecode.NewOptions(a.buildSDKOptions()...).AllowedTools, "the CLI must not approve allowed tools itself")
+	assert.True(t, allowed(t, a, "Bash"))
+	assert.False(t, allowed(t, a, "Write"))
+
+	require.NoError(t, a.SetAllowedTools(context.Background(), resp.SessionId, []string{"Write"}))
+	assert.False(t, allowed(t, a, "Bash"), "revoked tool is denied")
+	assert.True(t, allowed(t, a, "Write"), "granted tool is allowed")
+
+	assert.Error(t, a.SetAllowedTools(context.Background(), "other-session", nil))
+}
+
+func TestBuildSDKOptions_DisallowedTools(t *testing.T) {
+	a, _ := newTestAdapter()
+	a.cwd = t.TempDir()
+	assert.Empty(t, claudecode.NewOptions(a.buildSDKOptions()...).DisallowedTools)
+
+	a.disallowedTools = []string{"Bash", "WebFetch"}
+	opts := claudecode.NewOptions(a.buildSDKOptions()...)
+	assert.Equal(t, []string{"Bash", "WebFetch"}, opts.DisallowedTools)
+}
+
 func TestToolCallLifecycle_ToolResultBlock(t *testing.T) {
 	a, fake := newTestAdapter()
 	ctx := context.Background()
 
 	// Pre-track the tool as active (simulating it was started earlier).
-	a.activeTools = map[string]string{"t1": "Read"}
+	a.activeTools = map[string]activeTool{"t1": {name: "Read"}}
 
 	a.normalizeAndSend(ctx, testSessionID, &claudecode.AssistantMessage{
 		MessageType: "assistant",
@@ -497,6 +786,116 @@ func TestToolCallLifecycle_ToolResultBlock(t *testing.T) {
 	assert.NotNil(t, u1.ToolCallUpdate.RawOutput, "ToolResultBlock update should include raw output")
 }
 
+func TestToolCallLifecycle_BashResultNonzeroExit(t *testing.T) {
+	a, fake := newTestAdapter()
+	ctx := context.Background()
+	a.activeTools = map[string]activeTool{"t1": {name: "Bash"}}
+
+	isError := true
+	a.normalizeAndSend(ctx, testSessionID, &claudecode.UserMessage{
+		MessageType: "user",
+		Content: []claudecode.ContentBlock{
+			&claudecode.ToolResultBlock{
+				MessageType: "tool_result",
+				ToolUseID:   "t1",
+				Content:     "Exit code 1\ncompiling\nerror: boom",
+				IsError:     &isError,
+			},
+		},
+		ToolUseResult: map[string]any{
+			"stdout":      "compiling",
+			"stderr":      "error: boom",
+			"interrupted": false,
+		},
+	})
+
+	updates := fake.allUpdates()
+	require.Len(t, updates, 1)
+	upd := updates[0].Update.ToolCallUpdate
+	require.NotNil(t, upd)
+	require.NotNil(t, upd.Status)
+	assert.Equal(t, acpsdk.ToolCallStatusFailed, *upd.Status)
+
+	out, ok := driver.ParseCommandOutput(upd.Meta)
+	require.True(t, ok)
+	assert.Equal(t, "compiling", out.Stdout)
+	assert.Equal(t, "error: boom", out.Stderr)
+	require.NotNil(t, out.ExitCode)
+	assert.Equal(t, 1, *out.ExitCode)
+	assert.Empty(t, a.activeTools)
+}
+
+func TestToolCallLifecycle_NonExecuteResultHasNoCommandOutput(t *testing.T) {
+	a, fake := newTestAdapter()
+	a.activeTools = map[string]activeTool{"t1": {name: "Read"}}
+
+	a.normalizeAndSend(context.Background(), testSessionID, &claudecode.UserMessage{
+		MessageType: "user",
+		Content: []claudecode.ContentBlock{
+			&claudecode.ToolResultBlock{MessageType: "tool_result", ToolUseID: "t1", Content: "file contents"},
+		},
+	})
+
+	updates := fake.allUpdates()
+	require.Len(t, updates, 1)
+	_, ok := driver.ParseCommandOutput(updates[0].Update.ToolCallUpdate.Meta)
+	assert.False(t, ok)
+}
+
+func TestToolCallLifecycle_FlowgenticToolSummary(t *testing.T) {
+	tests := []struct {
+		tool   string
+		input  map[string]any
+		result string
+		want   string
+	}{
+		{tool: "mcp__flowgentic__set_topic", input: map[string]any{"topic": "Fix login"}, result: "Topic set successfully", want: "Topic set to: Fix login"},
+		{tool: "mcp__flowgentic__ask_question", input: map[string]any{"question": "Keep v1?"}, result: "Yes", want: "Answer: Yes"},
+		{tool: "mcp__flowgentic__plan_commit", input: map[string]any{}, result: "Plan submitted successfully (2 plan dirs)", want: "Plan submitted: 2 dirs"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			a, fake := newTestAdapter()
+			ctx := context.Background()
+			a.normalizeAndSend(ctx, testSessionID, &claudecode.AssistantMessage{
+				MessageType: "assistant",
+				Content: []claudecode.ContentBlock{
+					&claudecode.ToolUseBlock{MessageType: "tool_use", ToolUseID: "t1", Name: tt.tool, Input: tt.input},
+				},
+			})
+			a.normalizeAndSend(ctx, testSessionID, &claudecode.UserMessage{
+				MessageType: "user",
+				Content: []claudecode.ContentBlock{
+					&claudecode.ToolResultBlock{
+						MessageType: "tool_result",
+						ToolUseID:   "t1",
+						Content:     []any{map[string]any{"type": "text", "text": tt.result}},
+					},
+				},
+			})
+
+			updates := fake.allUpdates()
+			require.Len(t, updates, 2)
+			upd := updates[1].Update.ToolCallUpdate
+			require.NotNil(t, upd)
+			require.Len(t, upd.Content, 1)
+			assert.Equal(t, tt.want, upd.Content[0].Content.Content.Text.Text)
+			assert.NotNil(t, upd.RawOutput, "the raw result is kept")
+		})
+	}
+}
+
+func TestBashCommandOutput_FallsBackToResultText(t *testing.T) {
+	out := bashCommandOutput("hello", false, nil)
+	assert.Equal(t, "hello", out.Stdout)
+	assert.Empty(t, out.Stderr)
+	require.NotNil(t, out.ExitCode)
+	assert.Equal(t, 0, *out.ExitCode)
+
+	out = bashCommandOutput("permission denied", true, nil)
+	assert.Nil(t, out.ExitCode, "exit code is unknown without the CLI header")
+}
+
 func TestToolCallLifecycle_FailedToolResult(t *testing.T) {
 	a, fake := newTestAdapter()
 	ctx := context.Background()
@@ -528,6 +927,57 @@ func TestToolCallLifecycle_FailedToolResult(t *testing.T) {
 	assert.True(t, found, "should find a failed update for t1")
 }
 
+func TestToolCallLifecycle_IDReusedInLaterTurn(t *testing.T) {
+	a, fake := newTestAdapter()
+	ctx := context.Background()
+	toolUse := func() *claudecode.AssistantMessage {
+		return &claudecode.AssistantMessage{
+			MessageType: "assistant",
+			Content: []claudecode.ContentBlock{
+				&claudecode.ToolUseBlock{MessageType: "tool_use", ToolUseID: "t1", Name: "Read", Input: map[string]any{"file_path": "/tmp/a"}},
+			},
+		}
+	}
+	toolResult := &claudecode.UserMessage{
+		MessageType: "user",
+		Content: []claudecode.ContentBlock{
+			&claudecode.ToolResultBlock{MessageType: "tool_result", ToolUseID: "t1", Content: "ok"},
+		},
+	}
+
+	// Turn 1 starts t1 and is cancelled before its result arrives.
+	a.turnSeq.Add(1)
+	a.normalizeAndSend(ctx, testSessionID, toolUse())
+	require.Len(t, fake.allUpdates(), 1)
+	require.NotNil(t, fake.allUpdates()[0].Update.ToolCall, "turn 1 starts t1")
+
+	// Turn 2 reuses t1: it is a new call, and the stale one is not
+	// completed on its behalf.
+	a.turnSeq.Add(1)
+	a.normalizeAndSend(ctx, testSessionID, toolUse())
+	a.normalizeAndSend(ctx, testSessionID, toolResult)
+
+	// Turn 3 reuses t1 after turn 2 completed it.
+	a.turnSeq.Add(1)
+	a.normalizeAndSend(ctx, testSessionID, toolUse())
+	a.normalizeAndSend(ctx, testSessionID, toolResult)
+
+	var kinds []string
+	for _, u := range fake.allUpdates() {
+		switch {
+		case u.Update.ToolCall != nil:
+			assert.Equal(t, acpsdk.ToolCallId("t1"), u.Update.ToolCall.ToolCallId)
+			kinds = append(kinds, "start")
+		case u.Update.ToolCallUpdate != nil:
+			assert.Equal(t, acpsdk.ToolCallId("t1"), u.Update.ToolCallUpdate.ToolCallId)
+			require.NotNil(t, u.Update.ToolCallUpdate.Status)
+			kinds = append(kinds, string(*u.Update.ToolCallUpdate.Status))
+		}
+	}
+	assert.Equal(t, []string{"start", "start", "completed", "start", "completed"}, kinds)
+	assert.Empty(t, a.activeTools)
+}
+
 func TestStreamEvent_TextDelta(t *testing.T) {
 	a, fake := newTestAdapter()
 	ctx := context.Background()
@@ -589,7 +1039,7 @@ func TestStreamEvent_MessageStop_CompletesActiveTools(t *testing.T) {
 	a, fake := newTestAdapter()
 	ctx := context.Background()
 
-	a.activeTools = map[string]string{"t1": "mcp__flowgentic__set_topic"}
+	a.activeTools = map[string]activeTool{"t1": {name: "mcp__flowgentic__set_topic"}}
 
 	a.normalizeAndSend(ctx, testSessionID, &claudecode.StreamEvent{
 		Event: map[string]any{
@@ -623,7 +1073,7 @@ func TestStreamEvent_ContentBlockStop_CompletesMatchingTool(t *testing.T) {
 	a, fake := newTestAdapter()
 	ctx := context.Background()
 
-	a.activeTools = map[string]string{"t1": "mcp__flowgentic__set_topic", "t2": "Read"}
+	a.activeTools = map[string]activeTool{"t1": {name: "mcp__flowgentic__set_topic"}, "t2": {name: "Read"}}
 
 	a.normalizeAndSend(ctx, testSessionID, &claudecode.StreamEvent{
 		Event: map[string]any{
@@ -670,6 +1120,40 @@ func TestSystemMessage_ExtractsText(t *testing.T) {
 	require.NotNil(t, updates[0].Update.AgentMessageChunk, "should send AgentMessageChunk for system message text")
 }
 
+func TestSystemMessage_InitReportsSessionConfiguration(t *testing.T) {
+	a, fake := newTestAdapter()
+	ctx := context.Background()
+
+	msg := &claudecode.SystemMessage{
+		MessageType: "system",
+		Subtype:     "init",
+		Data: map[string]any{
+			"type":           "system",
+			"subtype":        "init",
+			"cwd":            "/tmp/project",
+			"session_id":     "claude-session",
+			"tools":          []any{"Task", "Bash", "mcp__flowgentic__plan"},
+			"mcp_servers":    []any{map[string]any{"name": "flowgentic", "status": "connected"}},
+			"model":          "claude-sonnet-4-5-20250929",
+			"permissionMode": "acceptEdits",
+			"apiKeySource":   "none",
+		},
+	}
+	a.normalizeAndSend(ctx, testSessionID, msg)
+	a.normalizeAndSend(ctx, testSessionID, msg)
+
+	updates := fake.allUpdates()
+	require.Len(t, updates, 1, "the init message is reported once")
+	require.NotNil(t, updates[0].Update.AgentThoughtChunk)
+	s, ok := driver.ParseSessionInit(updates[0].Update.AgentThoughtChunk.Meta)
+	require.True(t, ok)
+	assert.Equal(t, driver.SessionInit{
+		Model:          "claude-sonnet-4-5-20250929",
+		PermissionMode: "acceptEdits",
+		Tools:          []string{"Task", "Bash", "mcp__flowgentic__plan"},
+	}, s)
+}
+
 func TestSystemMessage_AvailableCommandsRootLevel(t *testing.T) {
 	a, fake := newTestAdapter()
 	ctx := context.Background()
@@ -896,6 +1380,75 @@ func TestToolCallMetadata_EditWithDiffContent(t *testing.T) {
 	assert.Equal(t, "src/main.go", u0.ToolCall.Locations[0].Path)
 }
 
+func TestToolCallMetadata_WriteDiffFromFile(t *testing.T) {
+	cwd := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(cwd, "main.go"), []byte("package main\n"), 0o644))
+	a, fake := newTestAdapter()
+	a.cwd = cwd
+	a.fileDiffs = true
+
+	a.normalizeAndSend(context.Background(), testSessionID, &claudecode.AssistantMessage{
+		MessageType: "assistant",
+		Content: []claudecode.ContentBlock{
+			&claudecode.ToolUseBlock{
+				MessageType: "tool_use",
+				ToolUseID:   "t1",
+				Name:        "Write",
+				Input: map[string]any{
+					"file_path": filepath.Join(cwd, "main.go"),
+					"content":   "package main\n\nfunc main() {}\n",
+				},
+			},
+		},
+	})
+
+	updates := fake.allUpdates()
+	require.GreaterOrEqual(t, len(updates), 1)
+	u0 := updates[0].Update
+	require.NotNil(t, u0.ToolCall)
+	require.Len(t, u0.ToolCall.Content, 1)
+	diff := u0.ToolCall.Content[0].Diff
+	require.NotNil(t, diff)
+	assert.Equal(t, "package main\n\nfunc main() {}\n", diff.NewText)
+	require.NotNil(t, diff.OldText, "the diff has the prior file as old text")
+	assert.Equal(t, "package main\n", *diff.OldText)
+}
+
+func TestToolCallMetadata_DiffSnapshotBeforeAutoApprovedWrite(t *testing.T) {
+	cwd := t.TempDir()
+	path := filepath.Join(cwd, "main.go")
+	require.NoError(t, os.WriteFile(path, []byte("package main\n"), 0o644))
+	a, fake := newTestAdapter()
+	a.cwd = cwd
+	a.fileDiffs = true
+	a.allowedTools = []string{"Write"}
+	input := map[string]any{"file_path": path, "content": "package main\n\nfunc main() {}\n"}
+
+	res, err := a.handlePermission(context.Background(), testSessionID, "Write", input)
+	require.NoError(t, err)
+	require.IsType(t, claudecode.PermissionResultAllow{}, res)
+	// The CLI runs the allowed Write before the assistant message arrives.
+	require.NoError(t, os.WriteFile(path, []byte("package main\n\nfunc main() {}\n"), 0o644))
+
+	a.normalizeAndSend(context.Background(), testSessionID, &claudecode.AssistantMessage{
+		MessageType: "assistant",
+		Content: []claudecode.ContentBlock{
+			&claudecode.ToolUseBlock{MessageType: "tool_use", ToolUseID: "t1", Name: "Write", Input: input},
+		},
+	})
+
+	updates := fake.allUpdates()
+	require.GreaterOrEqual(t, len(updates), 1)
+	u0 := updates[0].Update
+	require.NotNil(t, u0.ToolCall)
+	require.Len(t, u0.ToolCall.Content, 1)
+	diff := u0.ToolCall.Content[0].Diff
+	require.NotNil(t, diff)
+	require.NotNil(t, diff.OldText, "the diff keeps the file as it was before the write")
+	assert.Equal(t, "package main\n", *diff.OldText)
+	assert.Empty(t, a.diffSnapshots, "the snapshot is used once")
+}
+
 func TestToolCallMetadata_GrepSearch(t *testing.T) {
 	a, fake := newTestAdapter()
 	ctx := context.Background()
@@ -992,3 +1545,595 @@ func TestSetSessionModel_NoClient(t *testing.T) {
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "no active session")
 }
+
+// disconnectRecorder is a claudecode.Client that only records Disconnect.
+type disconnectRecorder struct {
+	claudecode.Client
+	disconnects int
+}
+
+func (c *disconnectRecorder) Disconnect() error {
+	c.disconnects++
+	return nil
+}
+
+func TestClose_ReleasesSDKClientAndSessionContext(t *testing.T) {
+	a, _ := newTestAdapter()
+	client := &disconnectRecorder{}
+	sessionCtx, sessionCancel := context.WithCancel(context.Background())
+	a.client = client
+	a.sessionCtx = sessionCtx
+	a.sessionCancel = sessionCancel
+	a.modelProvider = staticModelProvider{}
+
+	require.NoError(t, a.Close())
+	assert.Equal(t, 1, client.disconnects)
+	assert.ErrorIs(t, sessionCtx.Err(), context.Canceled)
+	assert.Nil(t, a.modelProvider)
+
+	// No new SDK client is connected after Close.
+	require.ErrorIs(t, a.ensureClientConnected(context.Background()), errAdapterClosed)
+
+	require.NoError(t, a.Close())
+	assert.Equal(t, 1, client.disconnects, "second Close is a no-op")
+}
+
+// connectClient is a claudecode.Client whose Connect blocks until release is
+// closed or its context ends, recording that context.
+type connectClient struct {
+	claudecode.Client
+	release chan struct{}
+	ctx     chan context.Context
+}
+
+func (c *connectClient) Connect(ctx context.Context, _ ...claudecode.StreamMessage) error {
+	c.ctx <- ctx
+	select {
+	case <-c.release:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *connectClient) ReceiveMessages(context.Context) <-chan claudecode.Message {
+	return make(chan claudecode.Message)
+}
+
+func (c *connectClient) Disconnect() error { return nil }
+
+func TestEnsureClientConnected_ConnectTimeout(t *testing.T) {
+	a, _ := newTestAdapter()
+	a.stderrTail = driver.NewStderrTail(0)
+	a.connectTimeout = 50 * time.Millisecond
+	client := &connectClient{release: make(chan struct{}), ctx: make(chan context.Context, 1)}
+	a.newClient = func(...claudecode.Option) claudecode.Client { return client }
+	a.handleStderrLine("waiting for keychain access")
+
+	start := time.Now()
+	_, err := a.Prompt(context.Background(), acpsdk.PromptRequest{Prompt: []acpsdk.ContentBlock{acpsdk.TextBlock("hi")}})
+	require.ErrorIs(t, err, errConnectTimeout)
+	assert.Less(t, time.Since(start), 5*time.Second)
+	assert.Contains(t, err.Error(), "waiting for keychain access")
+
+	connectCtx := <-client.ctx
+	select {
+	case <-connectCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("the timed out CLI was not stopped")
+	}
+}
+
+func TestEnsureClientConnected_SessionContextOutlivesConnect(t *testing.T) {
+	a, _ := newTestAdapter()
+	a.connectTimeout = 50 * time.Millisecond
+	client := &connectClient{release: make(chan struct{}), ctx: make(chan context.Context, 1)}
+	close(client.release)
+	a.newClient = func(...claudecode.Option) claudecode.Client { return client }
+
+	require.NoError(t, a.ensureClientConnected(context.Background()))
+	defer a.Close()
+	connectCtx := <-client.ctx
+	time.Sleep(2 * a.connectTimeout)
+	assert.NoError(t, connectCtx.Err(), "the connect timeout does not end the session")
+}
+
+// queryRecorder is a claudecode.Client that accepts queries and reports no
+// slash commands.
+type queryRecorder struct {
+	claudecode.Client
+	queried chan string
+}
+
+func (c *queryRecorder) QueryWithSession(_ context.Context, prompt, _ string) error {
+	c.queried <- prompt
+	return nil
+}
+
+func (c *queryRecorder) SupportedCommands(context.Context) ([]claudecode.SlashCommand, error) {
+	return nil, nil
+}
+
+func TestPrompt_FailsWhenSubprocessDies(t *testing.T) {
+	a, _ := newTestAdapter()
+	client := &queryRecorder{queried: make(chan string, 1)}
+	msgChan := make(chan claudecode.Message)
+	exited := make(chan struct{})
+	a.client = client
+	a.exited = exited
+	go a.pumpMessages(context.Background(), testSessionID, msgChan, exited)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := a.Prompt(context.Background(), acpsdk.PromptRequest{
+			Prompt: []acpsdk.ContentBlock{acpsdk.TextBlock("hello")},
+		})
+		errCh <- err
+	}()
+
+	// The subprocess dies after the prompt was sent, before any reply.
+	assert.Equal(t, "hello", <-client.queried)
+	close(msgChan)
+
+	select {
+	case err := <-errCh:
+		assert.ErrorIs(t, err, errSubprocessExited)
+	case <-time.After(time.Second):
+		t.Fatal("Prompt did not return after the subprocess died")
+	}
+
+	// Later prompts fail straight away instead of waiting on a dead process.
+	_, err := a.Prompt(context.Background(), acpsdk.PromptRequest{
+		Prompt: []acpsdk.ContentBlock{acpsdk.TextBlock("again")},
+	})
+	assert.ErrorIs(t, err, errSubprocessExited)
+}
+
+// contentRecorder records prompts sent as content blocks.
+type contentRecorder struct {
+	queryRecorder
+	content chan []map[string]any
+}
+
+func (c *contentRecorder) QueryContentWithSession(_ context.Context, content []map[string]any, _ string) error {
+	c.content <- content
+	return nil
+}
+
+func TestPrompt_ImageOnly(t *testing.T) {
+	a, _ := newTestAdapter()
+	client := &contentRecorder{
+		queryRecorder: queryRecorder{queried: make(chan string, 1)},
+		content:       make(chan []map[string]any, 1),
+	}
+	msgChan := make(chan claudecode.Message, 1)
+	a.client = client
+	a.exited = make(chan struct{})
+	go a.pumpMessages(context.Background(), testSessionID, msgChan, a.exited)
+
+	respCh := make(chan acpsdk.PromptResponse, 1)
+	go func() {
+		resp, err := a.Prompt(context.Background(), acpsdk.PromptRequest{
+			Prompt: []acpsdk.ContentBlock{acpsdk.ImageBlock("aW1n", "image/png")},
+		})
+		assert.NoError(t, err)
+		respCh <- resp
+	}()
+
+	select {
+	case content := <-client.content:
+		assert.Equal(t, []map[string]any{{
+			"type":   "image",
+			"source": map[string]any{"type": "base64", "media_type": "image/png", "data": "aW1n"},
+		}}, content)
+	case <-time.After(time.Second):
+		t.Fatal("image prompt was not sent")
+	}
+	msgChan <- &claudecode.ResultMessage{MessageType: "result", Subtype: "success"}
+
+	select {
+	case resp := <-respCh:
+		assert.Equal(t, acpsdk.StopReasonEndTurn, resp.StopReason)
+	case <-time.After(time.Second):
+		t.Fatal("Prompt did not return")
+	}
+}
+
+func TestPrompt_RejectsUnsendablePrompts(t *testing.T) {
+	a, _ := newTestAdapter()
+	client := &contentRecorder{
+		queryRecorder: queryRecorder{queried: make(chan string, 1)},
+		content:       make(chan []map[string]any, 1),
+	}
+	a.client = client
+	a.exited = make(chan struct{})
+
+	prompts := map[string][]acpsdk.ContentBlock{
+		"empty":             nil,
+		"empty text":        {acpsdk.TextBlock("")},
+		"audio":             {acpsdk.AudioBlock("YXVkaW8=", "audio/wav")},
+		"unsupported image": {acpsdk.ImageBlock("aW1n", "image/bmp")},
+		"resource link":     {acpsdk.ResourceLinkBlock("notes", "file:///notes.txt")},
+	}
+	for name, blocks := range prompts {
+		_, err := a.Prompt(context.Background(), acpsdk.PromptRequest{Prompt: blocks})
+		_, ok := driver.InvalidPromptMessage(err)
+		assert.True(t, ok, "%s: got %v", name, err)
+	}
+	assert.Empty(t, client.queried)
+	assert.Empty(t, client.content)
+}
+
+func TestPrompt_RefusalStopReason(t *testing.T) {
+	a, _ := newTestAdapter()
+	client := &queryRecorder{queried: make(chan string, 1)}
+	msgChan := make(chan claudecode.Message, 4)
+	a.client = client
+	a.exited = make(chan struct{})
+	go a.pumpMessages(context.Background(), testSessionID, msgChan, a.exited)
+
+	result := &claudecode.ResultMessage{MessageType: "result", Subtype: "success"}
+	refusal := &claudecode.AssistantMessage{
+		MessageType: "assistant",
+		Content:     []claudecode.ContentBlock{&claudecode.TextBlock{MessageType: "text", Text: "I can't help with that."}},
+		StopReason:  "refusal",
+	}
+	reply := &claudecode.AssistantMessage{
+		MessageType: "assistant",
+		Content:     []claudecode.ContentBlock{&claudecode.TextBlock{MessageType: "text", Text: "I won't do that, but here is an alternative."}},
+		StopReason:  "end_turn",
+	}
+	refusalDelta := &claudecode.StreamEvent{Event: map[string]any{
+		"type":  "message_delta",
+		"delta": map[string]any{"stop_reason": "refusal"},
+	}}
+
+	turns := []struct {
+		name     string
+		messages []claudecode.Message
+		want     acpsdk.StopReason
+	}{
+		{"refusal stop reason", []claudecode.Message{refusal, result}, acpsdk.StopReasonRefusal},
+		{"refusal-like text is a normal turn", []claudecode.Message{reply, result}, acpsdk.StopReasonEndTurn},
+		{"refusal in the stream", []claudecode.Message{refusalDelta, result}, acpsdk.StopReasonRefusal},
+	}
+	for _, turn := range turns {
+		respCh := make(chan acpsdk.PromptResponse, 1)
+		go func() {
+			resp, err := a.Prompt(context.Background(), acpsdk.PromptRequest{
+				Prompt: []acpsdk.ContentBlock{acpsdk.TextBlock("do something")},
+			})
+			assert.NoError(t, err)
+			respCh <- resp
+		}()
+		<-client.queried
+		for _, m := range turn.messages {
+			msgChan <- m
+		}
+
+		select {
+		case resp := <-respCh:
+			assert.Equal(t, turn.want, resp.StopReason, turn.name)
+		case <-time.After(time.Second):
+			t.Fatalf("%s: Prompt did not return", turn.name)
+		}
+	}
+}
+
+func TestPrompt_ReportsTurnUsage(t *testing.T) {
+	a, _ := newTestAdapter()
+	client := &queryRecorder{queried: make(chan string, 1)}
+	msgChan := make(chan claudecode.Message, 1)
+	a.client = client
+	a.exited = make(chan struct{})
+	go a.pumpMessages(context.Background(), testSessionID, msgChan, a.exited)
+
+	respCh := make(chan acpsdk.PromptResponse, 1)
+	go func() {
+		resp, err := a.Prompt(context.Background(), acpsdk.PromptRequest{
+			Prompt: []acpsdk.ContentBlock{acpsdk.TextBlock("do something")},
+		})
+		assert.NoError(t, err)
+		respCh <- resp
+	}()
+	<-client.queried
+	// The CLI's JSON decodes numbers as float64.
+	usage := map[string]any{"input_tokens": float64(1200), "output_tokens": float64(345), "cache_read_input_tokens": float64(9000)}
+	msgChan <- &claudecode.ResultMessage{MessageType: "result", Subtype: "success", Usage: &usage}
+
+	select {
+	case resp := <-respCh:
+		u, ok := driver.ParseTurnUsage(resp.Meta)
+		require.True(t, ok)
+		assert.Equal(t, driver.TurnUsage{InputTokens: 1200, OutputTokens: 345}, u)
+	case <-time.After(time.Second):
+		t.Fatal("Prompt did not return")
+	}
+}
+
+// interruptingClient is a queryRecorder whose Interrupt makes the CLI finish
+// streaming the current turn and report its result.
+type interruptingClient struct {
+	queryRecorder
+	msgChan chan<- claudecode.Message
+	tail    string
+}
+
+func (c *interruptingClient) Interrupt(context.Context) error {
+	c.msgChan <- textDelta(c.tail)
+	c.msgChan <- &claudecode.ResultMessage{MessageType: "result", Subtype: "error_during_execution"}
+	return nil
+}
+
+func textDelta(text string) *claudecode.StreamEvent {
+	return &claudecode.StreamEvent{Event: map[string]any{
+		"type":  "content_block_delta",
+		"delta": map[string]any{"type": "text_delta", "text": text},
+	}}
+}
+
+func TestPrompt_CancelFlushesStreamedText(t *testing.T) {
+	a, fake := newTestAdapter()
+	msgChan := make(chan claudecode.Message, 4)
+	a.client = &interruptingClient{
+		queryRecorder: queryRecorder{queried: make(chan string, 1)},
+		msgChan:       msgChan,
+		tail:          "response",
+	}
+	a.exited = make(chan struct{})
+	go a.pumpMessages(context.Background(), testSessionID, msgChan, a.exited)
+
+	respCh := make(chan acpsdk.PromptResponse, 1)
+	go func() {
+		resp, err := a.Prompt(context.Background(), acpsdk.PromptRequest{
+			Prompt: []acpsdk.ContentBlock{acpsdk.TextBlock("hello")},
+		})
+		assert.NoError(t, err)
+		respCh <- resp
+	}()
+
+	<-a.client.(*interruptingClient).queried
+	msgChan <- textDelta("partial ")
+	require.NoError(t, a.Cancel(context.Background(), acpsdk.CancelNotification{}))
+
+	var resp acpsdk.PromptResponse
+	select {
+	case resp = <-respCh:
+	case <-time.After(time.Second):
+		t.Fatal("Prompt did not return after cancel")
+	}
+	assert.Equal(t, acpsdk.StopReasonCancelled, resp.StopReason)
+
+	var text string
+	for _, u := range fake.allUpdates() {
+		if c := u.Update.AgentMessageChunk; c != nil && c.Content.Text != nil {
+			text += c.Content.Text.Text
+		}
+	}
+	assert.Equal(t, "partial response", text, "text streamed up to the cancel is sent before Prompt returns")
+}
+
+func TestPrompt_SubprocessDeathReportsAuthFailure(t *testing.T) {
+	a, _ := newTestAdapter()
+	exited := make(chan struct{})
+	close(exited)
+	a.client = &queryRecorder{queried: make(chan string, 1)}
+	a.exited = exited
+	a.handleStderrLine("OAuth token has expired. Please run /login")
+
+	_, err := a.Prompt(context.Background(), acpsdk.PromptRequest{
+		Prompt: []acpsdk.ContentBlock{acpsdk.TextBlock("hello")},
+	})
+	msg, ok := driver.AuthFailureMessage(err)
+	require.True(t, ok)
+	assert.Equal(t, "OAuth token has expired. Please run /login", msg)
+}
+
+func TestAuthError_FromErrorResult(t *testing.T) {
+	a, _ := newTestAdapter()
+	result := "Invalid API key · Please run /login"
+
+	a.normalizeAndSend(context.Background(), testSessionID, &claudecode.ResultMessage{
+		MessageType: "result",
+		Subtype:     "success",
+		IsError:     true,
+		Result:      &result,
+	})
+
+	err := a.authError(nil)
+	var re *acpsdk.RequestError
+	require.ErrorAs(t, err, &re)
+	assert.Equal(t, driver.AuthRequiredCode, re.Code)
+	msg, ok := driver.AuthFailureMessage(err)
+	require.True(t, ok)
+	assert.Equal(t, result, msg)
+
+	assert.NoError(t, a.authError(nil), "failure is consumed")
+}
+
+func TestAuthError_FromConnectFailure(t *testing.T) {
+	a, _ := newTestAdapter()
+
+	// The CLI explains the failure on stderr; the SDK only sees the exit.
+	a.handleStderrLine("OAuth token has expired. Please run /login")
+	err := a.authError(errors.New("claude exited with status 1"))
+	msg, ok := driver.AuthFailureMessage(err)
+	require.True(t, ok)
+	assert.Equal(t, "OAuth token has expired. Please run /login", msg)
+
+	// Stderr is only consulted for failed connects/queries.
+	a.handleStderrLine("Not logged in · Please run /login")
+	assert.NoError(t, a.authError(nil))
+
+	a.handleStderrLine("MCP server github: 401 Unauthorized")
+	assert.NoError(t, a.authError(errors.New("exit status 1")), "MCP auth errors are not CLI auth errors")
+	assert.NoError(t, a.authError(errors.New("connection refused")))
+}
+
+func TestHandleStderrLine_ForwardsToSink(t *testing.T) {
+	a, _ := newTestAdapter()
+	var lines []string
+	a.SetStderr(func(line string) { lines = append(lines, line) })
+
+	a.handleStderrLine("  Error: unknown option '--bogus'\n")
+	a.handleStderrLine("   ")
+	a.handleStderrLine("Not logged in")
+
+	assert.Equal(t, []string{"Error: unknown option '--bogus'", "Not logged in"}, lines)
+}
+
+func TestPrompt_DuplicateAttachesToInFlightTurn(t *testing.T) {
+	a, _ := newTestAdapter()
+	a.attachDuplicatePrompts = true
+	client := &queryRecorder{queried: make(chan string, 2)}
+	msgChan := make(chan claudecode.Message)
+	a.client = client
+	a.exited = make(chan struct{})
+	go a.pumpMessages(context.Background(), testSessionID, msgChan, a.exited)
+
+	prompt := acpsdk.PromptRequest{Prompt: []acpsdk.ContentBlock{acpsdk.TextBlock("hello")}}
+	respCh := make(chan acpsdk.PromptResponse, 2)
+	runPrompt := func() {
+		resp, err := a.Prompt(context.Background(), prompt)
+		assert.NoError(t, err)
+		respCh <- resp
+	}
+	go runPrompt()
+	assert.Equal(t, "hello", <-client.queried)
+	go runPrompt()
+
+	// A differing prompt still fails while the turn runs.
+	_, err := a.Prompt(context.Background(), acpsdk.PromptRequest{
+		Prompt: []acpsdk.ContentBlock{acpsdk.TextBlock("other")},
+	})
+	require.ErrorContains(t, err, "prompt already in progress")
+	assert.Never(t, func() bool { return len(respCh) > 0 }, 50*time.Millisecond, 5*time.Millisecond,
+		"the retry waits for the turn")
+
+	msgChan <- &claudecode.ResultMessage{MessageType: "result", Subtype: "success"}
+	for range 2 {
+		select {
+		case resp := <-respCh:
+			assert.Equal(t, acpsdk.StopReasonEndTurn, resp.StopReason)
+		case <-time.After(time.Second):
+			t.Fatal("Prompt did not return after the turn ended")
+		}
+	}
+	assert.Empty(t, client.queried, "the retry is not sent to the CLI")
+}
+
+// turnClient is a claudecode.Client that answers every query with a Write
+// tool call and a result, and accepts model changes.
+type turnClient struct {
+	claudecode.Client
+	msgChan chan<- claudecode.Message
+}
+
+func (c *turnClient) QueryWithSession(_ context.Context, prompt, _ string) error {
+	c.msgChan <- &claudecode.AssistantMessage{
+		MessageType: "assistant",
+		Content: []claudecode.ContentBlock{&claudecode.ToolUseBlock{
+			MessageType: "tool_use",
+			ToolUseID:   "t-" + prompt,
+			Name:        "Write",
+			Input:       map[string]any{"file_path": "notes.txt", "content": prompt},
+		}},
+	}
+	c.msgChan <- &claudecode.ResultMessage{MessageType: "result", Subtype: "success"}
+	return nil
+}
+
+func (c *turnClient) SupportedCommands(context.Context) ([]claudecode.SlashCommand, error) {
+	return nil, nil
+}
+
+func (c *turnClient) SetModel(context.Context, *string) error { return nil }
+
+// TestConcurrentModelChangesAndPrompts is meant for -race: it changes the
+// model and starts new sessions while prompt turns stream tool calls.
+func TestConcurrentModelChangesAndPrompts(t *testing.T) {
+	a, _ := newTestAdapter()
+	a.fileDiffs = true
+	a.modelProvider = staticModelProvider{state: &acpsdk.SessionModelState{
+		AvailableModels: []acpsdk.ModelInfo{{ModelId: "claude-sonnet"}, {ModelId: "claude-opus"}},
+	}}
+	msgChan := make(chan claudecode.Message, 4)
+	a.client = &turnClient{msgChan: msgChan}
+	a.exited = make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go a.pumpMessages(ctx, testSessionID, msgChan, a.exited)
+
+	const turns = 20
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := range turns {
+			resp, err := a.Prompt(context.Background(), acpsdk.PromptRequest{
+				Prompt: []acpsdk.ContentBlock{acpsdk.TextBlock(fmt.Sprint(i))},
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, acpsdk.StopReasonEndTurn, resp.StopReason)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := range turns {
+			model := []acpsdk.ModelId{"claude-sonnet", "claude-opus"}[i%2]
+			_, err := a.SetSessionModel(context.Background(), acpsdk.SetSessionModelRequest{SessionId: testSessionID, ModelId: model})
+			assert.NoError(t, err)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for range turns {
+			resp, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{Cwd: t.TempDir()})
+			assert.NoError(t, err)
+			assert.NotNil(t, resp.Models)
+		}
+	}()
+	wg.Wait()
+
+	_, err := a.SetSessionModel(context.Background(), acpsdk.SetSessionModelRequest{SessionId: testSessionID, ModelId: "claude-opus"})
+	require.NoError(t, err)
+	resp, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{Cwd: t.TempDir()})
+	require.NoError(t, err)
+	assert.Equal(t, acpsdk.ModelId("claude-opus"), resp.Models.CurrentModelId, "the last model set is reported")
+}
+
+func TestPrompt_DuplicateFailsWithoutOption(t *testing.T) {
+	a, _ := newTestAdapter()
+	client := &queryRecorder{queried: make(chan string, 1)}
+	a.client = client
+	a.exited = make(chan struct{})
+
+	prompt := acpsdk.PromptRequest{Prompt: []acpsdk.ContentBlock{acpsdk.TextBlock("hello")}}
+	go func() { _, _ = a.Prompt(context.Background(), prompt) }()
+	<-client.queried
+
+	_, err := a.Prompt(context.Background(), prompt)
+	require.ErrorContains(t, err, "prompt already in progress")
+	close(a.exited)
+}
+
+// pingClient is a claudecode.Client answering Ping with err.
+type pingClient struct {
+	claudecode.Client
+	err error
+}
+
+func (c *pingClient) Ping(context.Context) error { return c.err }
+
+func TestPing(t *testing.T) {
+	a, _ := newTestAdapter()
+	assert.NoError(t, a.Ping(context.Background()), "nothing to check before the CLI is started")
+
+	a.client = &pingClient{}
+	assert.NoError(t, a.Ping(context.Background()))
+
+	a.client = &pingClient{err: context.DeadlineExceeded}
+	err := a.Ping(context.Background())
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "ping claude cli")
+}
@@ -0,0 +1,115 @@
+package acp
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	acpsdk "github.com/coder/acp-go-sdk"
+)
+
+// maxDiffFileBytes is the largest file read to build a diff; larger files
+// keep the agent-provided strings. The diff carries the file twice, old and
+// new, in the permission request and the tool call, so this stays small.
+const maxDiffFileBytes = 64 << 10
+
+// fileDiffContent builds the diff of an Edit, MultiEdit or Write call from
+// the file's current contents, so the diff shows the whole change rather
+// than the strings the agent passed. The file is read through an os.Root on
+// cwd, so paths and symlinks leaving it are not read. ok is false when the
+// diff cannot be built this way; the caller keeps the agent's strings then.
+func fileDiffContent(cwd, toolName string, input map[string]any) (_ []acpsdk.ToolCallContent, ok bool) {
+	path, _ := input["file_path"].(string)
+	if cwd == "" || path == "" {
+		return nil, false
+	}
+	rel := path
+	if filepath.IsAbs(path) {
+		var err error
+		if rel, err = filepath.Rel(cwd, path); err != nil {
+			return nil, false
+		}
+	}
+	if !filepath.IsLocal(rel) {
+		return nil, false
+	}
+
+	old, exists, err := readCwdFile(cwd, rel)
+	if err != nil {
+		return nil, false
+	}
+
+	switch toolName {
+	case "Write":
+		content, _ := input["content"].(string)
+		if !exists {
+			return []acpsdk.ToolCallContent{acpsdk.ToolDiffContent(path, content)}, true
+		}
+		return []acpsdk.ToolCallContent{acpsdk.ToolDiffContent(path, content, old)}, true
+	case "Edit":
+		if !exists {
+			return nil, false
+		}
+		next, ok := applyEdit(old, input)
+		if !ok {
+			return nil, false
+		}
+		return []acpsdk.ToolCallContent{acpsdk.ToolDiffContent(path, next, old)}, true
+	case "MultiEdit":
+		if !exists {
+			return nil, false
+		}
+		next := old
+		for _, edit := range multiEditEdits(input["edits"]) {
+			if next, ok = applyEdit(next, edit); !ok {
+				return nil, false
+			}
+		}
+		return []acpsdk.ToolCallContent{acpsdk.ToolDiffContent(path, next, old)}, true
+	default:
+		return nil, false
+	}
+}
+
+// readCwdFile reads the file at rel below cwd. exists is false if there is
+// no such file yet.
+func readCwdFile(cwd, rel string) (contents string, exists bool, err error) {
+	root, err := os.OpenRoot(cwd)
+	if err != nil {
+		return "", false, err
+	}
+	defer root.Close()
+
+	info, err := root.Stat(rel)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	if !info.Mode().IsRegular() || info.Size() > maxDiffFileBytes {
+		return "", false, errors.New("not a small regular file")
+	}
+	data, err := root.ReadFile(rel)
+	if err != nil {
+		return "", false, err
+	}
+	return string(data), true, nil
+}
+
+// applyEdit applies one Edit input to contents the way the tool does: the
+// first occurrence of old_string is replaced, or all of them with
+// replace_all. ok is false if old_string does not occur.
+func applyEdit(contents string, edit map[string]any) (string, bool) {
+	oldStr, _ := edit["old_string"].(string)
+	newStr, _ := edit["new_string"].(string)
+	if oldStr == "" || !strings.Contains(contents, oldStr) {
+		return "", false
+	}
+	if all, _ := edit["replace_all"].(bool); all {
+		return strings.ReplaceAll(contents, oldStr, newStr), true
+	}
+	return strings.Replace(contents, oldStr, newStr, 1), true
+}
@@ -0,0 +1,82 @@
+package acp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileDiffContent(t *testing.T) {
+	cwd := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(cwd, "a.txt"), []byte("one\ntwo\none\n"), 0o644))
+	outside := filepath.Join(t.TempDir(), "secret.txt")
+	require.NoError(t, os.WriteFile(outside, []byte("secret\n"), 0o644))
+	require.NoError(t, os.Symlink(outside, filepath.Join(cwd, "link.txt")))
+
+	tests := []struct {
+		name     string
+		tool     string
+		input    map[string]any
+		wantOld  *string
+		wantNew  string
+		fallback bool
+	}{
+		{
+			name:    "edit replaces the first occurrence",
+			tool:    "Edit",
+			input:   map[string]any{"file_path": "a.txt", "old_string": "one", "new_string": "1"},
+			wantOld: ptr("one\ntwo\none\n"),
+			wantNew: "1\ntwo\none\n",
+		},
+		{
+			name:    "edit with replace_all",
+			tool:    "Edit",
+			input:   map[string]any{"file_path": filepath.Join(cwd, "a.txt"), "old_string": "one", "new_string": "1", "replace_all": true},
+			wantOld: ptr("one\ntwo\none\n"),
+			wantNew: "1\ntwo\n1\n",
+		},
+		{
+			name: "multi edit applies edits in order",
+			tool: "MultiEdit",
+			input: map[string]any{"file_path": "a.txt", "edits": []any{
+				map[string]any{"old_string": "two", "new_string": "2"},
+				map[string]any{"old_string": "2\none", "new_string": "2\n3"},
+			}},
+			wantOld: ptr("one\ntwo\none\n"),
+			wantNew: "one\n2\n3\n",
+		},
+		{
+			name:    "write of a new file",
+			tool:    "Write",
+			input:   map[string]any{"file_path": "new.txt", "content": "hello\n"},
+			wantNew: "hello\n",
+		},
+		{name: "edit string not in file", tool: "Edit", input: map[string]any{"file_path": "a.txt", "old_string": "three", "new_string": "3"}, fallback: true},
+		{name: "edit of a missing file", tool: "Edit", input: map[string]any{"file_path": "missing.txt", "old_string": "x", "new_string": "y"}, fallback: true},
+		{name: "path outside cwd", tool: "Write", input: map[string]any{"file_path": outside, "content": "x"}, fallback: true},
+		{name: "relative path leaving cwd", tool: "Write", input: map[string]any{"file_path": "../secret.txt", "content": "x"}, fallback: true},
+		{name: "symlink leaving cwd", tool: "Write", input: map[string]any{"file_path": "link.txt", "content": "x"}, fallback: true},
+		{name: "other tool", tool: "Read", input: map[string]any{"file_path": "a.txt"}, fallback: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, ok := fileDiffContent(cwd, tt.tool, tt.input)
+			if tt.fallback {
+				assert.False(t, ok)
+				return
+			}
+			require.True(t, ok)
+			require.Len(t, content, 1)
+			diff := content[0].Diff
+			require.NotNil(t, diff)
+			assert.Equal(t, tt.input["file_path"], diff.Path)
+			assert.Equal(t, tt.wantNew, diff.NewText)
+			assert.Equal(t, tt.wantOld, diff.OldText)
+		})
+	}
+}
+
+func ptr(s string) *string { return &s }
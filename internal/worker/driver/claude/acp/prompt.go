@@ -0,0 +1,91 @@
+package acp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	acpsdk "github.com/coder/acp-go-sdk"
+	claudecode "github.com/sebastianm/flowgentic/internal/claude-agent-sdk-go"
+	"github.com/sebastianm/flowgentic/internal/worker/driver"
+)
+
+// claudeImageTypes are the image MIME types the Messages API accepts.
+var claudeImageTypes = map[string]bool{"image/jpeg": true, "image/png": true, "image/gif": true, "image/webp": true}
+
+// claudePrompt is an ACP prompt converted for the Claude CLI.
+type claudePrompt struct {
+	text    string           // the text blocks, joined
+	content []map[string]any // Messages API content; nil for text-only prompts
+}
+
+// newClaudePrompt converts prompt blocks. Text-only prompts are sent as
+// text like before; others as content blocks, with images and PDFs as base64
+// sources and text resources inlined as text. Other blocks, and prompts
+// without content, are rejected with an invalid-params error.
+func newClaudePrompt(blocks []acpsdk.ContentBlock) (claudePrompt, error) {
+	var p claudePrompt
+	var content []map[string]any
+	textOnly := true
+	for i, b := range blocks {
+		switch {
+		case b.Text != nil:
+			if b.Text.Text == "" {
+				continue
+			}
+			p.text += b.Text.Text
+			content = append(content, map[string]any{"type": "text", "text": b.Text.Text})
+		case b.Image != nil:
+			if !claudeImageTypes[b.Image.MimeType] {
+				return claudePrompt{}, driver.NewInvalidPromptError(fmt.Sprintf("content block %d: claude does not support images of type %q", i, b.Image.MimeType))
+			}
+			textOnly = false
+			content = append(content, base64Block("image", b.Image.MimeType, b.Image.Data))
+		case b.Resource != nil:
+			if text, ok := driver.ResourcePromptText(b.Resource); ok {
+				textOnly = false
+				content = append(content, map[string]any{"type": "text", "text": text})
+				continue
+			}
+			blob := b.Resource.Resource.BlobResourceContents
+			if blob == nil || blob.MimeType == nil || *blob.MimeType != "application/pdf" {
+				return claudePrompt{}, driver.NewInvalidPromptError(fmt.Sprintf("content block %d: claude supports only text and PDF resources", i))
+			}
+			textOnly = false
+			content = append(content, base64Block("document", *blob.MimeType, blob.Blob))
+		default:
+			return claudePrompt{}, driver.NewInvalidPromptError(fmt.Sprintf("content block %d: unsupported by claude", i))
+		}
+	}
+	if len(content) == 0 {
+		return claudePrompt{}, driver.NewInvalidPromptError("prompt has no content")
+	}
+	if !textOnly {
+		p.content = content
+	}
+	return p, nil
+}
+
+func base64Block(typ, mediaType, data string) map[string]any {
+	return map[string]any{
+		"type":   typ,
+		"source": map[string]any{"type": "base64", "media_type": mediaType, "data": data},
+	}
+}
+
+// key identifies the prompt for attaching identical retries.
+func (p claudePrompt) key() string {
+	if p.content == nil {
+		return p.text
+	}
+	b, _ := json.Marshal(p.content)
+	return string(b)
+}
+
+// send sends the prompt as a user message of the session.
+func (p claudePrompt) send(ctx context.Context, client claudecode.Client, sessionID string) error {
+	if p.content == nil {
+		return client.QueryWithSession(ctx, p.text, sessionID)
+	}
+	return client.QueryContentWithSession(ctx, p.content, sessionID)
+}
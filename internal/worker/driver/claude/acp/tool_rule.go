@@ -0,0 +1,231 @@
+package acp
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// matchesToolRules reports whether any of rules covers a call of toolName
+// with input. Rules use the Claude CLI's permission rule syntax: a bare tool
+// name covers every call of the tool, "mcp__server" covers every tool of that
+// MCP server, and "Tool(specifier)" covers only calls whose input matches the
+// specifier (see matchesToolRule).
+func matchesToolRules(rules []string, toolName string, input map[string]any, cwd string) bool {
+	for _, rule := range rules {
+		if matchesToolRule(rule, toolName, input, cwd) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesToolRule reports whether rule covers a call of toolName with input.
+// The specifier is matched against the part of the input the tool acts on:
+//
+//   - Bash: the command. "go test ./..." matches only that command,
+//     "npm run:*" any command starting with "npm run", and "*" anywhere
+//     matches any characters. Commands chained with ;, &&, || or | match
+//     only when every part does, and commands with substitutions only
+//     match a rule naming them in full.
+//   - Read, Edit, MultiEdit, Write, NotebookEdit, Glob, Grep, LS: the path,
+//     as a gitignore-style pattern. "*" stays within one path segment and
+//     "**" crosses them. Patterns starting with "//" are absolute, with "~/"
+//     relative to the home directory, and otherwise relative to cwd.
+//   - WebFetch: "domain:example.com" matches URLs on that host.
+//
+// A specifier on any other tool never matches.
+func matchesToolRule(rule, toolName string, input map[string]any, cwd string) bool {
+	name, spec, ok := parseToolRule(rule)
+	if !ok {
+		return false
+	}
+	if spec == "" {
+		return name == toolName || isMCPServerRule(name, toolName)
+	}
+	if name != toolName {
+		return false
+	}
+
+	switch toolName {
+	case "Bash":
+		return matchesBashRule(spec, inputString(input, "command"))
+	case "Read", "Edit", "MultiEdit", "Write", "NotebookEdit", "Glob", "Grep", "LS":
+		return matchesPathRule(spec, toolRulePath(toolName, input), cwd)
+	case "WebFetch":
+		return matchesDomainRule(spec, inputString(input, "url"))
+	default:
+		return false
+	}
+}
+
+// parseToolRule splits "Tool(specifier)" into its tool name and specifier.
+// A bare tool name has an empty specifier; a malformed rule is not ok.
+func parseToolRule(rule string) (name, spec string, ok bool) {
+	rule = strings.TrimSpace(rule)
+	open := strings.IndexByte(rule, '(')
+	if open < 0 {
+		return rule, "", rule != ""
+	}
+	if !strings.HasSuffix(rule, ")") || open == 0 {
+		return "", "", false
+	}
+	spec = rule[open+1 : len(rule)-1]
+	if spec == "" || spec == "*" {
+		// "Tool()" and "Tool(*)" cover the whole tool, as in the CLI.
+		return rule[:open], "", true
+	}
+	return rule[:open], spec, true
+}
+
+// isMCPServerRule reports whether name is an "mcp__server" rule covering
+// toolName, which the CLI names "mcp__server__tool".
+func isMCPServerRule(name, toolName string) bool {
+	if !strings.HasPrefix(name, "mcp__") || strings.Contains(strings.TrimPrefix(name, "mcp__"), "__") {
+		return false
+	}
+	return strings.HasPrefix(toolName, name+"__")
+}
+
+func matchesBashRule(spec, command string) bool {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return false
+	}
+	if command == spec {
+		return true
+	}
+	if strings.Contains(command, "`") || strings.Contains(command, "$(") || strings.Contains(command, "<(") || strings.Contains(command, ">(") {
+		return false
+	}
+	parts := splitShellCommand(command)
+	for _, part := range parts {
+		if !matchesBashPattern(spec, part) {
+			return false
+		}
+	}
+	return len(parts) > 0
+}
+
+// splitShellCommand splits command at the ;, &&, || and | operators and
+// newlines. Quoting is not parsed, so an operator inside quotes splits too;
+// that only ever makes a rule match less.
+func splitShellCommand(command string) []string {
+	fields := strings.FieldsFunc(command, func(r rune) bool {
+		return r == ';' || r == '&' || r == '|' || r == '\n'
+	})
+	parts := fields[:0]
+	for _, f := range fields {
+		if f = strings.TrimSpace(f); f != "" {
+			parts = append(parts, f)
+		}
+	}
+	return parts
+}
+
+func matchesBashPattern(spec, command string) bool {
+	if prefix, ok := strings.CutSuffix(spec, ":*"); ok {
+		return command == prefix || strings.HasPrefix(command, prefix+" ")
+	}
+	if !strings.Contains(spec, "*") {
+		return command == spec
+	}
+	return wildcardMatch(spec, command, false)
+}
+
+func matchesPathRule(spec, path, cwd string) bool {
+	if path == "" {
+		return false
+	}
+	var pattern string
+	switch {
+	case strings.HasPrefix(spec, "//"):
+		pattern = spec[1:]
+	case strings.HasPrefix(spec, "~/"):
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return false
+		}
+		pattern = filepath.Join(home, spec[2:])
+	default:
+		if cwd == "" {
+			return false
+		}
+		pattern = filepath.Join(cwd, spec)
+	}
+	if !filepath.IsAbs(path) {
+		if cwd == "" {
+			return false
+		}
+		path = filepath.Join(cwd, path)
+	}
+	path = filepath.Clean(path)
+	if strings.HasSuffix(spec, "/") || strings.HasSuffix(spec, "/**") {
+		// A directory covers everything below it.
+		dir := strings.TrimSuffix(pattern, "/**")
+		return wildcardMatch(dir, path, true) || wildcardMatch(dir+"/**", path, true)
+	}
+	return wildcardMatch(pattern, path, true)
+}
+
+func matchesDomainRule(spec, rawURL string) bool {
+	domain, ok := strings.CutPrefix(spec, "domain:")
+	if !ok || rawURL == "" {
+		return false
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Hostname(), domain)
+}
+
+// toolRulePath returns the path a file tool call acts on.
+func toolRulePath(toolName string, input map[string]any) string {
+	switch toolName {
+	case "NotebookEdit":
+		return inputString(input, "notebook_path")
+	case "Glob", "Grep", "LS":
+		return inputString(input, "path")
+	default:
+		return inputString(input, "file_path")
+	}
+}
+
+func inputString(input map[string]any, key string) string {
+	s, _ := input[key].(string)
+	return s
+}
+
+// wildcardMatch reports whether s matches pattern, where "*" matches any run
+// of characters. With paths set, "*" does not match "/" and "**" does.
+func wildcardMatch(pattern, s string, paths bool) bool {
+	for len(pattern) > 0 {
+		if pattern[0] != '*' {
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+			pattern, s = pattern[1:], s[1:]
+			continue
+		}
+		anySegment := !paths || strings.HasPrefix(pattern, "**")
+		pattern = strings.TrimLeft(pattern, "*")
+		if paths && anySegment && strings.HasPrefix(pattern, "/") {
+			// "**/" also matches no directories at all.
+			if wildcardMatch(pattern[1:], s, paths) {
+				return true
+			}
+		}
+		for i := 0; i <= len(s); i++ {
+			if wildcardMatch(pattern, s[i:], paths) {
+				return true
+			}
+			if i < len(s) && !anySegment && s[i] == '/' {
+				break
+			}
+		}
+		return false
+	}
+	return len(s) == 0
+}
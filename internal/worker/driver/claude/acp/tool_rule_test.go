@@ -0,0 +1,85 @@
+package acp
+
+import (
+	"context"
+	"testing"
+
+	acpsdk "github.com/coder/acp-go-sdk"
+	claudecode "github.com/sebastianm/flowgentic/internal/claude-agent-sdk-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchesToolRule(t *testing.T) {
+	bash := func(cmd string) map[string]any { return map[string]any{"command": cmd} }
+	file := func(path string) map[string]any { return map[string]any{"file_path": path} }
+
+	tests := []struct {
+		name  string
+		rule  string
+		tool  string
+		input map[string]any
+		want  bool
+	}{
+		{"bare name", "Bash", "Bash", bash("rm -rf /"), true},
+		{"bare name other tool", "Bash", "Read", file("/repo/a.go"), false},
+		{"whole tool wildcard", "Bash(*)", "Bash", bash("ls"), true},
+		{"exact command", "Bash(go test ./...)", "Bash", bash("go test ./..."), true},
+		{"exact command differs", "Bash(go test ./...)", "Bash", bash("go test -run X ./..."), false},
+		{"exact command other tool", "Bash(go test ./...)", "Read", file("/repo/a.go"), false},
+		{"prefix", "Bash(npm run:*)", "Bash", bash("npm run build"), true},
+		{"prefix alone", "Bash(npm run:*)", "Bash", bash("npm run"), true},
+		{"prefix needs word boundary", "Bash(npm run:*)", "Bash", bash("npm runx"), false},
+		{"wildcard", "Bash(git * main)", "Bash", bash("git push origin main"), true},
+		{"chained parts all match", "Bash(go:*)", "Bash", bash("go vet ./... && go test ./..."), true},
+		{"chained part does not match", "Bash(go test:*)", "Bash", bash("go test ./... && rm -rf /"), false},
+		{"piped part does not match", "Bash(go test:*)", "Bash", bash("go test ./... | sh"), false},
+		{"substitution", "Bash(echo:*)", "Bash", bash("echo $(rm -rf /)"), false},
+		{"no command", "Bash(go test ./...)", "Bash", nil, false},
+		{"relative path", "Edit(src/**)", "Edit", file("/repo/src/pkg/a.go"), true},
+		{"relative path outside", "Edit(src/**)", "Edit", file("/repo/docs/a.md"), false},
+		{"directory", "Read(docs/)", "Read", file("/repo/docs/guide/intro.md"), true},
+		{"single segment", "Edit(src/*.go)", "Edit", file("/repo/src/a.go"), true},
+		{"single segment does not cross", "Edit(src/*.go)", "Edit", file("/repo/src/pkg/a.go"), false},
+		{"double star matches no dirs", "Edit(**/*.go)", "Edit", file("/repo/a.go"), true},
+		{"absolute path", "Read(//etc/hosts)", "Read", file("/etc/hosts"), true},
+		{"path is cleaned", "Edit(src/**)", "Edit", file("/repo/src/../secrets/key"), false},
+		{"notebook path", "NotebookEdit(*.ipynb)", "NotebookEdit", map[string]any{"notebook_path": "/repo/a.ipynb"}, true},
+		{"domain", "WebFetch(domain:example.com)", "WebFetch", map[string]any{"url": "https://example.com/docs"}, true},
+		{"other domain", "WebFetch(domain:example.com)", "WebFetch", map[string]any{"url": "https://example.com.evil.test/"}, false},
+		{"mcp server", "mcp__github", "mcp__github__create_issue", nil, true},
+		{"mcp server prefix", "mcp__git", "mcp__github__create_issue", nil, false},
+		{"specifier on other tool", "WebSearch(foo)", "WebSearch", map[string]any{"query": "foo"}, false},
+		{"malformed", "Bash(go test", "Bash", bash("go test"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, matchesToolRule(tt.rule, tt.tool, tt.input, "/repo"))
+		})
+	}
+}
+
+func TestHandlePermission_AllowedToolSpecifiers(t *testing.T) {
+	// Without an ACP connection and with the default deny-all policy, only
+	// calls an allowedTools rule covers run.
+	a, _ := newTestAdapter()
+	_, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{
+		Cwd: t.TempDir(),
+		Meta: map[string]any{
+			"allowedTools":    []any{"Bash(go test ./...)"},
+			"disallowedTools": []any{"Bash(rm:*)"},
+		},
+	})
+	require.NoError(t, err)
+
+	decide := func(cmd string) claudecode.PermissionResult {
+		t.Helper()
+		res, err := a.handlePermission(context.Background(), testSessionID, "Bash", map[string]any{"command": cmd})
+		require.NoError(t, err)
+		return res
+	}
+
+	assert.IsType(t, claudecode.PermissionResultAllow{}, decide("go test ./..."))
+	assert.Equal(t, claudecode.NewPermissionResultDeny("no ACP connection"), decide("go build ./..."))
+	assert.Equal(t, claudecode.NewPermissionResultDeny("tool is not allowed in this session"), decide("rm -rf build"))
+}
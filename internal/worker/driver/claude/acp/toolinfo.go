@@ -2,9 +2,12 @@ package acp
 
 import (
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 
 	acpsdk "github.com/coder/acp-go-sdk"
+	"github.com/sebastianm/flowgentic/internal/worker/driver"
 )
 
 // toolMetadata holds ACP-enriched metadata derived from a Claude tool name and its input.
@@ -39,6 +42,8 @@ func toolInfoFromToolUse(toolName string, input map[string]any) toolMetadata {
 		return readToolInfo(input)
 	case "Edit":
 		return editToolInfo(input)
+	case "MultiEdit":
+		return multiEditToolInfo(input)
 	case "Write":
 		return writeToolInfo(input)
 	case "Glob":
@@ -147,6 +152,51 @@ func editToolInfo(input map[string]any) toolMetadata {
 	return tm
 }
 
+// multiEditToolInfo builds one diff per edit so every change in the call is
+// shown, not just the first.
+func multiEditToolInfo(input map[string]any) toolMetadata {
+	path, _ := input["file_path"].(string)
+	title := "Edit"
+	if path != "" {
+		title = fmt.Sprintf("Edit `%s`", path)
+	}
+
+	tm := toolMetadata{
+		Title: title,
+		Kind:  acpsdk.ToolKindEdit,
+	}
+	if path == "" {
+		return tm
+	}
+
+	for _, edit := range multiEditEdits(input["edits"]) {
+		oldStr, _ := edit["old_string"].(string)
+		newStr, _ := edit["new_string"].(string)
+		tm.Content = append(tm.Content, acpsdk.ToolDiffContent(path, newStr, oldStr))
+	}
+	tm.Locations = []acpsdk.ToolCallLocation{{Path: path}}
+	return tm
+}
+
+// multiEditEdits returns the edits of a MultiEdit input, which is
+// []any after a JSON round trip and may be []map[string]any otherwise.
+func multiEditEdits(v any) []map[string]any {
+	switch edits := v.(type) {
+	case []map[string]any:
+		return edits
+	case []any:
+		out := make([]map[string]any, 0, len(edits))
+		for _, e := range edits {
+			if m, ok := e.(map[string]any); ok {
+				out = append(out, m)
+			}
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
 func writeToolInfo(input map[string]any) toolMetadata {
 	path, _ := input["file_path"].(string)
 	title := "Write"
@@ -286,6 +336,56 @@ func exitPlanModeToolInfo(input map[string]any) toolMetadata {
 	return tm
 }
 
+// exitCodePrefix matches the "Exit code N" header the CLI puts on failed
+// Bash results.
+var exitCodePrefix = regexp.MustCompile(`^Exit code (-?\d+)`)
+
+// bashCommandOutput builds structured output for a Bash tool result. The
+// CLI's tool_use_result carries stdout and stderr separately; without it the
+// result text is reported as stdout. The exit code is 0 for successful
+// results and parsed from the error text otherwise.
+func bashCommandOutput(resultText string, isError bool, toolUseResult map[string]any) driver.CommandOutput {
+	var out driver.CommandOutput
+	stdout, hasStdout := toolUseResult["stdout"].(string)
+	stderr, hasStderr := toolUseResult["stderr"].(string)
+	if hasStdout || hasStderr {
+		out.Stdout = stdout
+		out.Stderr = stderr
+	} else {
+		out.Stdout = resultText
+	}
+	if !isError {
+		code := 0
+		out.ExitCode = &code
+	} else if m := exitCodePrefix.FindStringSubmatch(resultText); m != nil {
+		if code, err := strconv.Atoi(m[1]); err == nil {
+			out.ExitCode = &code
+		}
+	}
+	return out
+}
+
+// toolResultText flattens a ToolResultBlock's content, which is either a
+// string or a list of text blocks.
+func toolResultText(content any) string {
+	switch c := content.(type) {
+	case string:
+		return c
+	case []any:
+		var parts []string
+		for _, item := range c {
+			if m, ok := item.(map[string]any); ok {
+				if text, ok := m["text"].(string); ok {
+					parts = append(parts, text)
+				}
+			}
+		}
+		return strings.Join(parts, "\n")
+	default:
+		return ""
+	}
+}
+
 // truncate shortens s to maxLen characters, appending "..." if truncated.
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
@@ -89,6 +89,29 @@ func TestToolInfoFromToolUse_Edit(t *testing.T) {
 	assert.Equal(t, "src/main.go", info.Locations[0].Path)
 }
 
+func TestToolInfoFromToolUse_MultiEdit(t *testing.T) {
+	info := toolInfoFromToolUse("MultiEdit", map[string]any{
+		"file_path": "src/main.go",
+		"edits": []any{
+			map[string]any{"old_string": "foo", "new_string": "bar"},
+			map[string]any{"old_string": "baz", "new_string": "qux", "replace_all": true},
+		},
+	})
+	assert.Equal(t, "Edit `src/main.go`", info.Title)
+	assert.Equal(t, acpsdk.ToolKindEdit, info.Kind)
+	require.Len(t, info.Content, 2)
+	for i, want := range [][2]string{{"foo", "bar"}, {"baz", "qux"}} {
+		diff := info.Content[i].Diff
+		require.NotNil(t, diff)
+		assert.Equal(t, "src/main.go", diff.Path)
+		require.NotNil(t, diff.OldText)
+		assert.Equal(t, want[0], *diff.OldText)
+		assert.Equal(t, want[1], diff.NewText)
+	}
+	require.Len(t, info.Locations, 1)
+	assert.Equal(t, "src/main.go", info.Locations[0].Path)
+}
+
 func TestToolInfoFromToolUse_Write(t *testing.T) {
 	info := toolInfoFromToolUse("Write", map[string]any{
 		"file_path": "src/new.go",
@@ -1,26 +1,35 @@
 package acp
 
 import (
+	"cmp"
 	"context"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
+	"unicode/utf8"
 
 	acpsdk "github.com/coder/acp-go-sdk"
 	"github.com/google/uuid"
+	"github.com/sebastianm/flowgentic/internal/worker/driver"
 )
 
 const (
 	methodItemStarted         = "item/started"
 	methodItemCompleted       = "item/completed"
 	methodTurnCompleted       = "turn/completed"
+	methodTurnFailed          = "turn/failed"
+	methodError               = "error"
 	methodItemCommandApproval = "item/commandExecution/requestApproval"
 	methodAgentMessageDelta   = "item/agentMessage/delta"
 	methodReasoningTextDelta  = "item/reasoning/textDelta"
 	methodMCPToolCallProgress = "item/mcpToolCall/progress"
+	methodCommandOutputDelta  = "item/commandExecution/outputDelta"
 	methodMCPStartupUpdate    = "codex/event/mcp_startup_update"
 	methodMCPStartupComplete  = "codex/event/mcp_startup_complete"
 	methodSessionConfigured   = "sessionConfigured"
@@ -29,6 +38,10 @@ const (
 	methodSkillsUpdated       = "codex/event/skills_update_available"
 )
 
+// cancelDrainTimeout bounds how long a cancelled prompt waits for the
+// app-server to complete the interrupted turn.
+const cancelDrainTimeout = 5 * time.Second
+
 type agentMessageDeltaParams struct {
 	Delta string `json:"delta"`
 }
@@ -43,6 +56,7 @@ type itemStartedParams struct {
 		Type      string `json:"type"`
 		Command   string `json:"command,omitempty"`
 		Server    string `json:"server,omitempty"`
+		Tool      string `json:"tool,omitempty"`
 		ToolName  string `json:"toolName,omitempty"`
 		Name      string `json:"name,omitempty"`
 		Arguments any    `json:"arguments,omitempty"`
@@ -58,6 +72,28 @@ type mcpToolCallProgressParams struct {
 	Output   string `json:"output,omitempty"`
 }
 
+type commandOutputDeltaParams struct {
+	ThreadID string `json:"threadId"`
+	ItemID   string `json:"itemId"`
+	Delta    string `json:"delta"`
+}
+
+// maxLiveCommandOutput bounds the output shown while a command runs; longer
+// output keeps its tail. The complete output arrives with item/completed.
+const maxLiveCommandOutput = 64 << 10
+
+// commandOutputInterval is the least time between two live output updates
+// of one command. Deltas arriving sooner are coalesced into the next one.
+const commandOutputInterval = 200 * time.Millisecond
+
+// liveCommandOutput is the output streamed so far by a running command.
+type liveCommandOutput struct {
+	sessionID acpsdk.SessionId
+	text      string
+	sent      time.Time   // when the last update went out
+	flush     *time.Timer // sends the coalesced deltas; nil when none are pending
+}
+
 type fileChange struct {
 	Path string `json:"path"`
 	Diff string `json:"diff"`
@@ -77,9 +113,94 @@ type itemCompletedParams struct {
 		Output           any          `json:"output,omitempty"`
 		Status           string       `json:"status,omitempty"`
 		Changes          []fileChange `json:"changes,omitempty"`
+		Arguments        any          `json:"arguments,omitempty"`
+		Server           string       `json:"server,omitempty"`
+		Tool             string       `json:"tool,omitempty"`
+		ToolName         string       `json:"toolName,omitempty"`
+		Name             string       `json:"name,omitempty"`
 	} `json:"item"`
 }
 
+// turnCompletedParams is the part of turn/completed used to tell why a
+// turn ended.
+type turnCompletedParams struct {
+	Turn struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+		Error  *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"turn"`
+}
+
+// policyRefusal matches the errors OpenAI fails a request with when it
+// violates its policies, e.g. "Invalid prompt: your prompt was flagged as
+// potentially violating our usage policy".
+var policyRefusal = regexp.MustCompile(`(?i)\b(usage|content) polic(y|ies)\b`)
+
+// isPolicyRefusal reports whether turn/completed params describe a turn the
+// model declined for policy reasons. Only failed turns whose error cites a
+// policy count, so ordinary failures are not reported as refusals.
+func isPolicyRefusal(params json.RawMessage) bool {
+	var p turnCompletedParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return false
+	}
+	return p.Turn.Status == "failed" && p.Turn.Error != nil && policyRefusal.MatchString(p.Turn.Error.Message)
+}
+
+// turnErrorParams is the part of turn/failed and error notifications used
+// to end a failed turn. The error is top-level on error notifications and
+// may be on the turn for turn/failed.
+type turnErrorParams struct {
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+	Turn struct {
+		Error *struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	} `json:"turn"`
+	// WillRetry is set on error notifications the app-server recovers from
+	// by retrying the request; the turn goes on then.
+	WillRetry bool `json:"willRetry"`
+}
+
+// notificationTurnID returns the turn a turn/completed, turn/failed or error
+// notification is about: turn.id on the former, turnId on error
+// notifications. It is empty if the app-server did not say.
+func notificationTurnID(params json.RawMessage) string {
+	var p struct {
+		TurnID string `json:"turnId"`
+		Turn   struct {
+			ID string `json:"id"`
+		} `json:"turn"`
+	}
+	if err := json.Unmarshal(params, &p); err != nil {
+		return ""
+	}
+	return cmp.Or(p.Turn.ID, p.TurnID)
+}
+
+// parseTurnError returns the error message of a turn/failed or error
+// notification. ok is false if the turn is being retried.
+func parseTurnError(params json.RawMessage) (message string, ok bool) {
+	var p turnErrorParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return "turn failed", true
+	}
+	if p.WillRetry {
+		return "", false
+	}
+	switch {
+	case p.Error != nil && p.Error.Message != "":
+		return p.Error.Message, true
+	case p.Turn.Error != nil && p.Turn.Error.Message != "":
+		return p.Turn.Error.Message, true
+	}
+	return "turn failed", true
+}
+
 type commandApprovalParams struct {
 	Command string `json:"command"`
 }
@@ -95,13 +216,16 @@ type updateSender interface {
 
 type bridgeClient interface {
 	start(ctx context.Context, envVars map[string]string) error
-	threadStart(model, cwd, systemPrompt, sessionMode string, mcpServers []acpsdk.McpServer) (string, error)
-	turnStart(threadID, prompt, cwd, sessionMode string) (string, error)
+	threadStart(model, cwd, systemPrompt, sessionMode, effort string, mcpServers []acpsdk.McpServer) (string, error)
+	turnStart(threadID string, input []map[string]string, cwd, sessionMode string) (string, error)
 	turnInterrupt(threadID, turnID string) error
 	respondToServerRequest(id int64, result any)
 	request(method string, params any) (json.RawMessage, error)
 	modelSnapshot() *acpsdk.SessionModelState
 	availableCommandsSnapshot() []acpsdk.AvailableCommand
+	// setStderr passes the app-server's stderr lines to fn; call it before
+	// start.
+	setStderr(fn func(line string))
 	doneChan() <-chan struct{}
 	close()
 }
@@ -113,9 +237,10 @@ var notificationHandlers = map[string]notificationHandler{
 	methodReasoningTextDelta:  (*Adapter).handleReasoningDelta,
 	methodItemStarted:         (*Adapter).handleItemStarted,
 	methodMCPToolCallProgress: (*Adapter).handleMCPToolCallProgress,
+	methodCommandOutputDelta:  (*Adapter).handleCommandOutputDelta,
 	methodItemCompleted:       (*Adapter).handleItemCompleted,
 	methodMCPStartupUpdate:    (*Adapter).handleMCPStartupUpdate,
-	methodMCPStartupComplete:  (*Adapter).handleMCPStartupUpdate,
+	methodMCPStartupComplete:  (*Adapter).handleMCPStartupComplete,
 	methodSessionConfigured:   (*Adapter).handleAvailableCommandsUpdate,
 	methodSessionConfiguredV2: (*Adapter).handleAvailableCommandsUpdate,
 	methodCommandsUpdated:     (*Adapter).handleAvailableCommandsUpdate,
@@ -137,6 +262,23 @@ type Adapter struct {
 	turnID   string
 	cwd      string
 
+	// turnInterrupted is set by Cancel so Prompt reports the interrupted
+	// turn as cancelled once the app-server completes it.
+	turnInterrupted bool
+	// turnRefused is set when the turn failed on a policy refusal, so
+	// Prompt reports StopReasonRefusal.
+	turnRefused bool
+	// turnErr is set when the turn ended on turn/failed or an error
+	// notification, so Prompt returns it.
+	turnErr error
+
+	// commandOutput holds the output streamed so far per running command
+	// item, until the command or its turn ends. commandOutputMu guards it
+	// and is held while its updates are sent, so a coalesced update cannot
+	// overtake the command's completion.
+	commandOutputMu sync.Mutex
+	commandOutput   map[string]*liveCommandOutput
+
 	latestAvailableCommands []acpsdk.AvailableCommand
 	turnDoneCh              chan struct{}
 
@@ -144,15 +286,24 @@ type Adapter struct {
 	pendingPermissionsMu sync.Mutex
 
 	bridgeFactory func(log *slog.Logger, dispatch func(threadID string, method string, params json.RawMessage, serverRequestID *int64)) bridgeClient
+
+	// stderrSink receives the app-server's stderr lines; see SetStderr.
+	stderrSink func(line string)
 }
 
+// NewAdapter returns a Codex adapter that runs its own app-server. See
+// SharedAppServer for adapters sharing one.
 func NewAdapter(log *slog.Logger) acpsdk.Agent {
+	return newAdapter(log, func(log *slog.Logger, dispatch dispatchFunc) bridgeClient {
+		return newBridge(log, dispatch)
+	})
+}
+
+func newAdapter(log *slog.Logger, bridgeFactory func(log *slog.Logger, dispatch dispatchFunc) bridgeClient) *Adapter {
 	return &Adapter{
 		log:                log.With("adapter", "codex"),
 		pendingPermissions: make(map[string]pendingPermission),
-		bridgeFactory: func(log *slog.Logger, dispatch func(threadID string, method string, params json.RawMessage, serverRequestID *int64)) bridgeClient {
-			return newBridge(log, dispatch)
-		},
+		bridgeFactory:      bridgeFactory,
 	}
 }
 
@@ -160,6 +311,11 @@ func (a *Adapter) SetConnection(conn *acpsdk.AgentSideConnection) {
 	a.conn.Store(conn)
 }
 
+// SetStderr makes the adapter pass every stderr line of its app-server to fn.
+func (a *Adapter) SetStderr(fn func(line string)) {
+	a.stderrSink = fn
+}
+
 func (a *Adapter) Authenticate(_ context.Context, _ acpsdk.AuthenticateRequest) (acpsdk.AuthenticateResponse, error) {
 	return acpsdk.AuthenticateResponse{}, nil
 }
@@ -175,27 +331,44 @@ func (a *Adapter) Initialize(_ context.Context, _ acpsdk.InitializeRequest) (acp
 }
 
 func (a *Adapter) Cancel(_ context.Context, _ acpsdk.CancelNotification) error {
+	a.interruptTurn()
+	return nil
+}
+
+// interruptTurn asks the app-server to interrupt the turn in flight, once.
+func (a *Adapter) interruptTurn() {
 	a.mu.Lock()
 	srv := a.server
 	threadID := a.threadID
 	turnID := a.turnID
+	if srv == nil || turnID == "" || a.turnInterrupted {
+		a.mu.Unlock()
+		return
+	}
+	a.turnInterrupted = true
 	a.mu.Unlock()
 
-	if srv != nil && turnID != "" {
-		_ = srv.turnInterrupt(threadID, turnID)
+	if err := srv.turnInterrupt(threadID, turnID); err != nil {
+		a.log.Warn("turn/interrupt failed", "turn_id", turnID, "error", err)
 	}
-	return nil
 }
 
 func (a *Adapter) NewSession(ctx context.Context, req acpsdk.NewSessionRequest) (acpsdk.NewSessionResponse, error) {
 	a.cwd = req.Cwd
 
-	var model, systemPrompt, sessionMode string
+	var model, systemPrompt, sessionMode, effort string
 	var envVars map[string]string
 	if meta, ok := req.Meta.(map[string]any); ok {
 		model, _ = meta["model"].(string)
 		systemPrompt, _ = meta["systemPrompt"].(string)
 		sessionMode, _ = meta["sessionMode"].(string)
+		effort, _ = meta["reasoningEffort"].(string)
+		if e, ok := driver.StringOption(driver.AdapterOptions(meta), "reasoningEffort"); ok && effort == "" {
+			if _, err := driver.ParseReasoningEffort(e); err != nil {
+				return acpsdk.NewSessionResponse{}, fmt.Errorf("adapterOptions.reasoningEffort: %w", err)
+			}
+			effort = e
+		}
 		if ev, ok := meta["envVars"].(map[string]any); ok {
 			envVars = make(map[string]string, len(ev))
 			for k, v := range ev {
@@ -211,6 +384,9 @@ func (a *Adapter) NewSession(ctx context.Context, req acpsdk.NewSessionRequest)
 	a.mu.Unlock()
 
 	b := a.bridgeFactory(a.log, a.dispatchNotification)
+	if a.stderrSink != nil {
+		b.setStderr(a.stderrSink)
+	}
 	if err := b.start(ctx, envVars); err != nil {
 		return acpsdk.NewSessionResponse{}, fmt.Errorf("start app-server: %w", err)
 	}
@@ -219,9 +395,12 @@ func (a *Adapter) NewSession(ctx context.Context, req acpsdk.NewSessionRequest)
 	a.server = b
 	a.mu.Unlock()
 
-	threadID, err := b.threadStart(model, req.Cwd, systemPrompt, sessionMode, req.McpServers)
+	threadID, err := b.threadStart(model, req.Cwd, systemPrompt, sessionMode, effort, req.McpServers)
 	if err != nil {
 		b.close()
+		if driver.LooksLikeAuthFailure(err.Error()) {
+			return acpsdk.NewSessionResponse{}, driver.NewAuthRequiredError(err.Error())
+		}
 		return acpsdk.NewSessionResponse{}, fmt.Errorf("thread/start: %w", err)
 	}
 
@@ -247,11 +426,9 @@ func (a *Adapter) NewSession(ctx context.Context, req acpsdk.NewSessionRequest)
 }
 
 func (a *Adapter) Prompt(ctx context.Context, req acpsdk.PromptRequest) (acpsdk.PromptResponse, error) {
-	var promptText string
-	for _, block := range req.Prompt {
-		if block.Text != nil {
-			promptText += block.Text.Text
-		}
+	input, err := turnInput(req.Prompt)
+	if err != nil {
+		return acpsdk.PromptResponse{}, err
 	}
 
 	a.mu.Lock()
@@ -269,24 +446,59 @@ func (a *Adapter) Prompt(ctx context.Context, req acpsdk.PromptRequest) (acpsdk.
 		sessionMode, _ = meta["sessionMode"].(string)
 	}
 
-	turnID, err := srv.turnStart(threadID, promptText, a.cwd, sessionMode)
+	turnID, err := srv.turnStart(threadID, input, a.cwd, sessionMode)
 	if err != nil {
+		if driver.LooksLikeAuthFailure(err.Error()) {
+			return acpsdk.PromptResponse{}, driver.NewAuthRequiredError(err.Error())
+		}
 		return acpsdk.PromptResponse{}, fmt.Errorf("turn/start: %w", err)
 	}
 
-	a.mu.Lock()
-	a.turnID = turnID
-	a.mu.Unlock()
-
 	turnDone := make(chan struct{})
 	a.mu.Lock()
+	a.turnID = turnID
+	a.turnInterrupted = false
+	a.turnRefused = false
+	a.turnErr = nil
 	a.turnDoneCh = turnDone
 	a.mu.Unlock()
+	defer func() {
+		a.mu.Lock()
+		if a.turnID == turnID {
+			a.turnID = ""
+		}
+		a.mu.Unlock()
+	}()
 
+	// An interrupted turn still ends with turn/completed, sent after the
+	// deltas streamed before the interrupt, so waiting for it keeps the
+	// partial response ahead of the cancellation.
 	select {
 	case <-turnDone:
+		a.mu.Lock()
+		interrupted, refused, turnErr := a.turnInterrupted, a.turnRefused, a.turnErr
+		a.mu.Unlock()
+		if interrupted {
+			return acpsdk.PromptResponse{StopReason: acpsdk.StopReasonCancelled}, nil
+		}
+		if turnErr != nil {
+			return acpsdk.PromptResponse{}, turnErr
+		}
+		if refused {
+			return acpsdk.PromptResponse{StopReason: acpsdk.StopReasonRefusal}, nil
+		}
 		return acpsdk.PromptResponse{StopReason: acpsdk.StopReasonEndTurn}, nil
 	case <-ctx.Done():
+		// ACP cancels the prompt context on session/cancel before calling
+		// Cancel, so interrupt here and wait for the turn to wind down.
+		a.interruptTurn()
+		select {
+		case <-turnDone:
+		case <-adapterCtx.Done():
+		case <-srv.doneChan():
+		case <-time.After(cancelDrainTimeout):
+			a.log.Warn("codex turn did not complete after interrupt", "turn_id", turnID, "timeout", cancelDrainTimeout)
+		}
 		return acpsdk.PromptResponse{StopReason: acpsdk.StopReasonCancelled}, nil
 	case <-adapterCtx.Done():
 		return acpsdk.PromptResponse{StopReason: acpsdk.StopReasonEndTurn}, nil
@@ -349,14 +561,66 @@ func (a *Adapter) dispatchNotification(threadID string, method string, params js
 		a.refreshSkillsSnapshot(context.Background(), sessionID)
 	}
 
-	if method == methodTurnCompleted {
-		a.mu.Lock()
-		ch := a.turnDoneCh
-		a.turnDoneCh = nil
+	switch method {
+	case methodTurnCompleted:
+		a.endTurn(notificationTurnID(params), isPolicyRefusal(params), nil)
+	case methodTurnFailed, methodError:
+		a.handleTurnError(method, params)
+	}
+}
+
+// handleTurnError ends a turn that failed without turn/completed, making
+// Prompt return the error; a policy refusal ends the turn as refused
+// instead.
+func (a *Adapter) handleTurnError(method string, params json.RawMessage) {
+	msg, ok := parseTurnError(params)
+	if !ok {
+		a.log.Debug("codex request failed, retrying", "method", method)
+		return
+	}
+	a.mu.Lock()
+	active := a.turnDoneCh != nil
+	a.mu.Unlock()
+	if !active {
+		a.log.Warn("codex error outside a turn", "method", method, "error", msg)
+		return
+	}
+	turnID := notificationTurnID(params)
+	if policyRefusal.MatchString(msg) {
+		a.endTurn(turnID, true, nil)
+		return
+	}
+	var err error = fmt.Errorf("codex turn failed: %s", msg)
+	if driver.LooksLikeAuthFailure(msg) {
+		err = driver.NewAuthRequiredError(msg)
+	}
+	a.endTurn(turnID, false, err)
+}
+
+// endTurn completes the running turn, if any, recording why it ended for
+// Prompt. A notification about another turn than the running one, such as
+// the turn/completed the app-server still sends for a turn an error
+// notification already ended, is dropped; one that names no turn ends the
+// running turn.
+func (a *Adapter) endTurn(turnID string, refused bool, err error) {
+	a.mu.Lock()
+	if turnID != "" && turnID != a.turnID {
 		a.mu.Unlock()
-		if ch != nil {
-			close(ch)
-		}
+		a.log.Debug("ignoring end of a stale codex turn", "turn_id", turnID)
+		return
+	}
+	ch := a.turnDoneCh
+	a.turnDoneCh = nil
+	if ch != nil {
+		a.turnRefused = refused
+		a.turnErr = err
+	}
+	a.mu.Unlock()
+	if ch != nil {
+		// Commands the turn left running, such as ones interrupted by a
+		// cancel, get no item/completed.
+		a.dropCommandOutput("")
+		close(ch)
 	}
 }
 
@@ -465,30 +729,71 @@ func (a *Adapter) handleItemStarted(params json.RawMessage) []acpsdk.SessionUpda
 		}
 	}
 	if p.Item.Type == "mcpToolCall" {
-		title := p.Item.ToolName
-		if title == "" {
-			title = p.Item.Name
-		}
-		if title == "" {
-			title = "MCP tool call"
-		}
-		if p.Item.Server != "" {
-			title = p.Item.Server + "." + title
-		}
+		tool := cmp.Or(p.Item.Tool, p.Item.ToolName, p.Item.Name)
 		opts := []acpsdk.ToolCallStartOpt{
-			acpsdk.WithStartKind(acpsdk.ToolKindOther),
+			acpsdk.WithStartKind(mcpToolKind(tool)),
 			acpsdk.WithStartStatus(acpsdk.ToolCallStatusInProgress),
 		}
-		if p.Item.Arguments != nil {
-			opts = append(opts, acpsdk.WithStartRawInput(p.Item.Arguments))
+		if args := mcpToolArguments(p.Item.Arguments); args != nil {
+			opts = append(opts, acpsdk.WithStartRawInput(args))
 		}
 		return []acpsdk.SessionUpdate{
-			acpsdk.StartToolCall(acpsdk.ToolCallId(p.Item.ID), title, opts...),
+			acpsdk.StartToolCall(acpsdk.ToolCallId(p.Item.ID), mcpToolTitle(p.Item.Server, tool), opts...),
 		}
 	}
 	return nil
 }
 
+// mcpToolTitle names an MCP tool call "server.tool".
+func mcpToolTitle(server, tool string) string {
+	if tool == "" {
+		tool = "MCP tool call"
+	}
+	if server == "" {
+		return tool
+	}
+	return server + "." + tool
+}
+
+// mcpToolKind guesses the ACP tool kind from an MCP tool's name. MCP tools
+// carry no kind, so only search- and fetch-like names are classified.
+func mcpToolKind(tool string) acpsdk.ToolKind {
+	name := strings.ToLower(tool)
+	switch {
+	case strings.Contains(name, "search"), strings.Contains(name, "grep"),
+		strings.Contains(name, "find"), strings.Contains(name, "query"):
+		return acpsdk.ToolKindSearch
+	case strings.Contains(name, "fetch"), strings.Contains(name, "download"),
+		strings.Contains(name, "http"), strings.Contains(name, "url"):
+		return acpsdk.ToolKindFetch
+	}
+	return acpsdk.ToolKindOther
+}
+
+// mcpToolArguments returns an MCP tool call's arguments as structured input.
+// Arguments sent as a JSON-encoded string are decoded; an empty string or
+// object counts as none.
+func mcpToolArguments(args any) any {
+	switch v := args.(type) {
+	case nil:
+		return nil
+	case string:
+		if strings.TrimSpace(v) == "" {
+			return nil
+		}
+		var decoded any
+		if err := json.Unmarshal([]byte(v), &decoded); err != nil {
+			return v
+		}
+		return mcpToolArguments(decoded)
+	case map[string]any:
+		if len(v) == 0 {
+			return nil
+		}
+	}
+	return args
+}
+
 func (a *Adapter) handleMCPToolCallProgress(params json.RawMessage) []acpsdk.SessionUpdate {
 	var p mcpToolCallProgressParams
 	if err := json.Unmarshal(params, &p); err != nil {
@@ -523,6 +828,100 @@ func (a *Adapter) handleMCPToolCallProgress(params json.RawMessage) []acpsdk.Ses
 	}
 }
 
+// handleCommandOutputDelta shows a running command's output as it arrives.
+// ACP tool call content replaces the previous content, so each update
+// carries the output so far; to keep that from resending it on every delta,
+// updates go out at most every commandOutputInterval and deltas arriving in
+// between are sent together once it has passed. The updates are sent here
+// rather than returned, under commandOutputMu.
+func (a *Adapter) handleCommandOutputDelta(params json.RawMessage) []acpsdk.SessionUpdate {
+	var p commandOutputDeltaParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		a.log.Debug("failed to unmarshal commandOutputDelta", "error", err)
+		return nil
+	}
+	if p.ItemID == "" || p.Delta == "" {
+		return nil
+	}
+	sessionID := acpsdk.SessionId(p.ThreadID)
+	if sessionID == "" {
+		a.mu.Lock()
+		sessionID = acpsdk.SessionId(a.threadID)
+		a.mu.Unlock()
+	}
+
+	a.commandOutputMu.Lock()
+	defer a.commandOutputMu.Unlock()
+	if a.commandOutput == nil {
+		a.commandOutput = make(map[string]*liveCommandOutput)
+	}
+	out := a.commandOutput[p.ItemID]
+	if out == nil {
+		out = &liveCommandOutput{sessionID: sessionID}
+		a.commandOutput[p.ItemID] = out
+	}
+	out.text += p.Delta
+	if len(out.text) > maxLiveCommandOutput {
+		cut := len(out.text) - maxLiveCommandOutput
+		for cut < len(out.text) && !utf8.RuneStart(out.text[cut]) {
+			cut++
+		}
+		out.text = out.text[cut:]
+	}
+
+	if wait := commandOutputInterval - time.Since(out.sent); wait > 0 {
+		if out.flush == nil {
+			out.flush = time.AfterFunc(wait, func() { a.flushCommandOutput(p.ItemID, out) })
+		}
+		return nil
+	}
+	a.sendCommandOutputLocked(p.ItemID, out)
+	return nil
+}
+
+// flushCommandOutput sends the deltas coalesced into out, unless its command
+// has ended since.
+func (a *Adapter) flushCommandOutput(itemID string, out *liveCommandOutput) {
+	a.commandOutputMu.Lock()
+	defer a.commandOutputMu.Unlock()
+	if a.commandOutput[itemID] != out {
+		return
+	}
+	a.sendCommandOutputLocked(itemID, out)
+}
+
+// sendCommandOutputLocked sends the output out holds. The caller holds
+// commandOutputMu.
+func (a *Adapter) sendCommandOutputLocked(itemID string, out *liveCommandOutput) {
+	if out.flush != nil {
+		out.flush.Stop()
+		out.flush = nil
+	}
+	out.sent = time.Now()
+	a.sendUpdate(context.Background(), out.sessionID, acpsdk.UpdateToolCall(acpsdk.ToolCallId(itemID),
+		acpsdk.WithUpdateStatus(acpsdk.ToolCallStatusInProgress),
+		acpsdk.WithUpdateContent([]acpsdk.ToolCallContent{
+			acpsdk.ToolContent(acpsdk.TextBlock(out.text)),
+		}),
+	))
+}
+
+// dropCommandOutput forgets the streamed output of itemID, or of every
+// command when itemID is empty, cancelling coalesced updates not sent yet.
+func (a *Adapter) dropCommandOutput(itemID string) {
+	a.commandOutputMu.Lock()
+	defer a.commandOutputMu.Unlock()
+	for id, out := range a.commandOutput {
+		if itemID != "" && id != itemID {
+			continue
+		}
+		if out.flush != nil {
+			out.flush.Stop()
+		}
+		delete(a.commandOutput, id)
+	}
+}
+
 func (a *Adapter) handleItemCompleted(params json.RawMessage) []acpsdk.SessionUpdate {
 	var p itemCompletedParams
 	if err := json.Unmarshal(params, &p); err != nil {
@@ -535,28 +934,51 @@ func (a *Adapter) handleItemCompleted(params json.RawMessage) []acpsdk.SessionUp
 	case "reasoning":
 		return nil
 	case "commandExecution":
+		a.dropCommandOutput(p.Item.ID)
 		status := acpsdk.ToolCallStatusCompleted
 		if p.Item.ExitCode != nil && *p.Item.ExitCode != 0 {
 			status = acpsdk.ToolCallStatusFailed
 		}
+		opts := []acpsdk.ToolCallUpdateOpt{
+			acpsdk.WithUpdateStatus(status),
+			acpsdk.WithUpdateRawOutput(p.Item.AggregatedOutput),
+		}
+		if p.Item.ExitCode != nil {
+			// app-server merges both streams into aggregatedOutput, so only
+			// the exit code is separated; the output is reported as stdout.
+			opts = append(opts, driver.WithCommandOutput(driver.CommandOutput{
+				Stdout:   p.Item.AggregatedOutput,
+				ExitCode: p.Item.ExitCode,
+			}))
+		} else if p.Item.AggregatedOutput != "" {
+			opts = append(opts, acpsdk.WithUpdateContent([]acpsdk.ToolCallContent{
+				acpsdk.ToolContent(acpsdk.TextBlock(p.Item.AggregatedOutput)),
+			}))
+		}
 		return []acpsdk.SessionUpdate{
-			acpsdk.UpdateToolCall(
-				acpsdk.ToolCallId(p.Item.ID),
-				acpsdk.WithUpdateStatus(status),
-				acpsdk.WithUpdateRawOutput(p.Item.AggregatedOutput),
-			),
+			acpsdk.UpdateToolCall(acpsdk.ToolCallId(p.Item.ID), opts...),
 		}
 	case "fileChange":
-		var content []acpsdk.ToolCallContent
+		var (
+			content   []acpsdk.ToolCallContent
+			locations []acpsdk.ToolCallLocation
+		)
 		for _, c := range p.Item.Changes {
 			content = append(content, acpsdk.ToolDiffContent(c.Path, c.Diff))
+			// A file changed in several hunks is listed once.
+			if c.Path != "" && !slices.ContainsFunc(locations, func(l acpsdk.ToolCallLocation) bool { return l.Path == c.Path }) {
+				locations = append(locations, acpsdk.ToolCallLocation{Path: c.Path})
+			}
+		}
+		opts := []acpsdk.ToolCallUpdateOpt{
+			acpsdk.WithUpdateStatus(acpsdk.ToolCallStatusCompleted),
+			acpsdk.WithUpdateContent(content),
+		}
+		if len(locations) > 0 {
+			opts = append(opts, acpsdk.WithUpdateLocations(locations))
 		}
 		return []acpsdk.SessionUpdate{
-			acpsdk.UpdateToolCall(
-				acpsdk.ToolCallId(p.Item.ID),
-				acpsdk.WithUpdateStatus(acpsdk.ToolCallStatusCompleted),
-				acpsdk.WithUpdateContent(content),
-			),
+			acpsdk.UpdateToolCall(acpsdk.ToolCallId(p.Item.ID), opts...),
 		}
 	case "mcpToolCall":
 		status := acpsdk.ToolCallStatusCompleted
@@ -570,8 +992,19 @@ func (a *Adapter) handleItemCompleted(params json.RawMessage) []acpsdk.SessionUp
 		opts := []acpsdk.ToolCallUpdateOpt{
 			acpsdk.WithUpdateStatus(status),
 		}
+		args := mcpToolArguments(p.Item.Arguments)
+		if args != nil {
+			opts = append(opts, acpsdk.WithUpdateRawInput(args))
+		}
 		if output != nil {
 			opts = append(opts, acpsdk.WithUpdateRawOutput(output))
+			// Results of flowgentic's own tools also get a readable summary.
+			tool := "mcp__" + p.Item.Server + "__" + cmp.Or(p.Item.Tool, p.Item.ToolName, p.Item.Name)
+			if summary, ok := driver.FlowgenticToolSummary(tool, args, output); ok && status == acpsdk.ToolCallStatusCompleted {
+				opts = append(opts, acpsdk.WithUpdateContent([]acpsdk.ToolCallContent{
+					acpsdk.ToolContent(acpsdk.TextBlock(summary)),
+				}))
+			}
 		} else if p.Item.Error != "" {
 			opts = append(opts, acpsdk.WithUpdateRawOutput(p.Item.Error))
 		}
@@ -583,16 +1016,36 @@ func (a *Adapter) handleItemCompleted(params json.RawMessage) []acpsdk.SessionUp
 }
 
 func (a *Adapter) handleMCPStartupUpdate(params json.RawMessage) []acpsdk.SessionUpdate {
+	return a.mcpStartupUpdates(methodMCPStartupUpdate, params)
+}
+
+func (a *Adapter) handleMCPStartupComplete(params json.RawMessage) []acpsdk.SessionUpdate {
+	return a.mcpStartupUpdates(methodMCPStartupComplete, params)
+}
+
+// mcpStartupUpdates reports MCP startup progress as a thought that carries
+// the structured report in _meta, so Flowgentic can show it outside the
+// reasoning stream.
+func (a *Adapter) mcpStartupUpdates(method string, params json.RawMessage) []acpsdk.SessionUpdate {
 	var p map[string]any
 	if err := json.Unmarshal(params, &p); err != nil {
 		a.log.Debug("failed to unmarshal mcpStartupUpdate", "error", err)
 		return nil
 	}
-	text := formatMCPStartupUpdate("", p)
+	text := formatMCPStartupUpdate(method, p)
 	if text == "" {
 		return nil
 	}
-	return []acpsdk.SessionUpdate{acpsdk.UpdateAgentThoughtText(text)}
+	s := driver.MCPStartup{Ready: method == methodMCPStartupComplete}
+	s.Server, _ = p["server"].(string)
+	s.Status, _ = p["status"].(string)
+	for _, key := range []string{"message", "detail", "error"} {
+		if msg, ok := p[key].(string); ok && msg != "" {
+			s.Message = msg
+			break
+		}
+	}
+	return []acpsdk.SessionUpdate{driver.MCPStartupUpdate(text, s)}
 }
 
 func (a *Adapter) handleAvailableCommandsUpdate(params json.RawMessage) []acpsdk.SessionUpdate {
@@ -679,13 +1132,16 @@ func formatMCPStartupUpdate(method string, params map[string]any) string {
 			break
 		}
 	}
-	if len(parts) == 0 {
-		return ""
-	}
 	prefix := "[mcp startup]"
 	if method == methodMCPStartupComplete {
 		prefix = "[mcp ready]"
 	}
+	if len(parts) == 0 {
+		if method == methodMCPStartupComplete {
+			return prefix
+		}
+		return ""
+	}
 	return prefix + " " + strings.Join(parts, " - ")
 }
 
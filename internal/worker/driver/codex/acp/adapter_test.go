@@ -3,12 +3,15 @@ package acp
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	"sync"
 	"testing"
+	"time"
 
 	acpsdk "github.com/coder/acp-go-sdk"
+	"github.com/sebastianm/flowgentic/internal/worker/driver"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -35,21 +38,31 @@ func (f *fakeUpdateSender) allUpdates() []acpsdk.SessionNotification {
 
 type fakeBridge struct {
 	threadID          string
+	threadEffort      string
 	modelState        *acpsdk.SessionModelState
 	availableCommands []acpsdk.AvailableCommand
 	requestResult     json.RawMessage
+	threadErr         error
+	turnInputs        [][]map[string]string
 	done              chan struct{}
 }
 
 func (f *fakeBridge) start(context.Context, map[string]string) error { return nil }
-func (f *fakeBridge) threadStart(string, string, string, string, []acpsdk.McpServer) (string, error) {
+func (f *fakeBridge) threadStart(_, _, _, _, effort string, _ []acpsdk.McpServer) (string, error) {
+	f.threadEffort = effort
+	if f.threadErr != nil {
+		return "", f.threadErr
+	}
 	return f.threadID, nil
 }
-func (f *fakeBridge) turnStart(string, string, string, string) (string, error) { return "turn-1", nil }
-func (f *fakeBridge) turnInterrupt(string, string) error                       { return nil }
-func (f *fakeBridge) respondToServerRequest(int64, any)                        {}
-func (f *fakeBridge) request(string, any) (json.RawMessage, error)             { return f.requestResult, nil }
-func (f *fakeBridge) modelSnapshot() *acpsdk.SessionModelState                 { return f.modelState }
+func (f *fakeBridge) turnStart(_ string, input []map[string]string, _, _ string) (string, error) {
+	f.turnInputs = append(f.turnInputs, input)
+	return fmt.Sprintf("turn-%d", len(f.turnInputs)), nil
+}
+func (f *fakeBridge) turnInterrupt(string, string) error           { return nil }
+func (f *fakeBridge) respondToServerRequest(int64, any)            {}
+func (f *fakeBridge) request(string, any) (json.RawMessage, error) { return f.requestResult, nil }
+func (f *fakeBridge) modelSnapshot() *acpsdk.SessionModelState     { return f.modelState }
 func (f *fakeBridge) availableCommandsSnapshot() []acpsdk.AvailableCommand {
 	return append([]acpsdk.AvailableCommand(nil), f.availableCommands...)
 }
@@ -59,7 +72,8 @@ func (f *fakeBridge) doneChan() <-chan struct{} {
 	}
 	return f.done
 }
-func (f *fakeBridge) close() {}
+func (f *fakeBridge) setStderr(func(string)) {}
+func (f *fakeBridge) close()                 {}
 
 func newCodexTestAdapter() (*Adapter, *fakeUpdateSender) {
 	updater := &fakeUpdateSender{}
@@ -108,6 +122,75 @@ func TestNewSession_NoStartupCommandsNoUpdate(t *testing.T) {
 	assert.Empty(t, updater.allUpdates())
 }
 
+func TestNewSession_PassesReasoningEffortToThreadStart(t *testing.T) {
+	a, _ := newCodexTestAdapter()
+	fakeSrv := &fakeBridge{threadID: "thread-1"}
+	a.bridgeFactory = func(_ *slog.Logger, _ func(threadID string, method string, params json.RawMessage, serverRequestID *int64)) bridgeClient {
+		return fakeSrv
+	}
+
+	_, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{
+		Cwd:  "/tmp",
+		Meta: map[string]any{"reasoningEffort": "high"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "high", fakeSrv.threadEffort)
+}
+
+func TestNewSession_ReasoningEffortAdapterOption(t *testing.T) {
+	newSession := func(meta map[string]any) (*fakeBridge, error) {
+		a, _ := newCodexTestAdapter()
+		fakeSrv := &fakeBridge{threadID: "thread-1"}
+		a.bridgeFactory = func(_ *slog.Logger, _ func(threadID string, method string, params json.RawMessage, serverRequestID *int64)) bridgeClient {
+			return fakeSrv
+		}
+		_, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{Cwd: "/tmp", Meta: meta})
+		return fakeSrv, err
+	}
+
+	fakeSrv, err := newSession(map[string]any{"adapterOptions": map[string]any{"reasoningEffort": "low"}})
+	require.NoError(t, err)
+	assert.Equal(t, "low", fakeSrv.threadEffort)
+
+	fakeSrv, err = newSession(map[string]any{"reasoningEffort": "high", "adapterOptions": map[string]any{"reasoningEffort": "low"}})
+	require.NoError(t, err)
+	assert.Equal(t, "high", fakeSrv.threadEffort, "the top-level effort wins")
+
+	_, err = newSession(map[string]any{"adapterOptions": map[string]any{"reasoningEffort": "max"}})
+	assert.ErrorContains(t, err, "adapterOptions.reasoningEffort")
+}
+
+func TestNewSession_ThreadStartAuthFailureIsAuthRequired(t *testing.T) {
+	a, _ := newCodexTestAdapter()
+	fakeSrv := &fakeBridge{
+		threadErr: &jsonrpcError{Code: -32603, Message: "401 Unauthorized: Your access token could not be refreshed. Please run `codex login`."},
+	}
+	a.bridgeFactory = func(_ *slog.Logger, _ func(threadID string, method string, params json.RawMessage, serverRequestID *int64)) bridgeClient {
+		return fakeSrv
+	}
+
+	_, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{Cwd: "/tmp"})
+	var re *acpsdk.RequestError
+	require.ErrorAs(t, err, &re)
+	assert.Equal(t, driver.AuthRequiredCode, re.Code)
+	msg, ok := driver.AuthFailureMessage(err)
+	require.True(t, ok)
+	assert.Contains(t, msg, "codex login")
+}
+
+func TestNewSession_ThreadStartOtherFailureIsWrapped(t *testing.T) {
+	a, _ := newCodexTestAdapter()
+	fakeSrv := &fakeBridge{threadErr: &jsonrpcError{Code: -32603, Message: "model not found"}}
+	a.bridgeFactory = func(_ *slog.Logger, _ func(threadID string, method string, params json.RawMessage, serverRequestID *int64)) bridgeClient {
+		return fakeSrv
+	}
+
+	_, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{Cwd: "/tmp"})
+	require.ErrorContains(t, err, "thread/start: ")
+	_, ok := driver.AuthFailureMessage(err)
+	assert.False(t, ok)
+}
+
 func TestDispatchNotification_SkillsUpdateRefreshesAvailableCommands(t *testing.T) {
 	a, updater := newCodexTestAdapter()
 	refreshed := map[string]any{
@@ -136,6 +219,327 @@ func TestDispatchNotification_SkillsUpdateRefreshesAvailableCommands(t *testing.
 	assert.Equal(t, "vercel-react-best-practices", updates[0].Update.AvailableCommandsUpdate.AvailableCommands[0].Name)
 }
 
+// requestBridge is a fakeBridge recording the generic requests it gets.
+func TestPrompt_CancelKeepsStreamedTextAndReportsCancelled(t *testing.T) {
+	a, updater := newCodexTestAdapter()
+	a.ctx = context.Background()
+	a.threadID = "thread-1"
+	a.server = &fakeBridge{}
+
+	respCh := make(chan acpsdk.PromptResponse, 1)
+	go func() {
+		resp, err := a.Prompt(context.Background(), acpsdk.PromptRequest{
+			Prompt: []acpsdk.ContentBlock{acpsdk.TextBlock("hello")},
+		})
+		assert.NoError(t, err)
+		respCh <- resp
+	}()
+	require.Eventually(t, func() bool {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		return a.turnDoneCh != nil
+	}, time.Second, 5*time.Millisecond)
+
+	a.dispatchNotification("thread-1", methodAgentMessageDelta, rawJSON(t, map[string]any{"delta": "partial"}), nil)
+	require.NoError(t, a.Cancel(context.Background(), acpsdk.CancelNotification{}))
+	a.dispatchNotification("thread-1", methodTurnCompleted, rawJSON(t, map[string]any{}), nil)
+
+	select {
+	case resp := <-respCh:
+		assert.Equal(t, acpsdk.StopReasonCancelled, resp.StopReason)
+	case <-time.After(time.Second):
+		t.Fatal("Prompt did not return after the interrupted turn completed")
+	}
+	updates := updater.allUpdates()
+	require.Len(t, updates, 1)
+	require.NotNil(t, updates[0].Update.AgentMessageChunk)
+	assert.Equal(t, "partial", updates[0].Update.AgentMessageChunk.Content.Text.Text)
+}
+
+func TestPrompt_PolicyRefusal(t *testing.T) {
+	a, _ := newCodexTestAdapter()
+	a.ctx = context.Background()
+	a.threadID = "thread-1"
+	a.server = &fakeBridge{}
+
+	failed := func(message string) map[string]any {
+		return map[string]any{"turn": map[string]any{
+			"id":     "turn-1",
+			"status": "failed",
+			"error":  map[string]any{"message": message},
+		}}
+	}
+	tests := []struct {
+		name   string
+		params map[string]any
+		want   acpsdk.StopReason
+	}{
+		{"policy refusal", failed("Invalid prompt: your prompt was flagged as potentially violating our usage policy."), acpsdk.StopReasonRefusal},
+		{"other failure", failed("stream disconnected before completion"), acpsdk.StopReasonEndTurn},
+		{"completed turn mentioning a policy", map[string]any{"turn": map[string]any{"status": "completed"}, "message": "content policy"}, acpsdk.StopReasonEndTurn},
+	}
+	for _, tt := range tests {
+		a.server = &fakeBridge{} // each case is turn-1
+		respCh := make(chan acpsdk.PromptResponse, 1)
+		go func() {
+			resp, err := a.Prompt(context.Background(), acpsdk.PromptRequest{
+				Prompt: []acpsdk.ContentBlock{acpsdk.TextBlock("hello")},
+			})
+			assert.NoError(t, err)
+			respCh <- resp
+		}()
+		require.Eventually(t, func() bool {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			return a.turnDoneCh != nil
+		}, time.Second, 5*time.Millisecond)
+
+		a.dispatchNotification("thread-1", methodTurnCompleted, rawJSON(t, tt.params), nil)
+		select {
+		case resp := <-respCh:
+			assert.Equal(t, tt.want, resp.StopReason, tt.name)
+		case <-time.After(time.Second):
+			t.Fatalf("%s: Prompt did not return", tt.name)
+		}
+	}
+}
+
+func TestPrompt_ImageOnly(t *testing.T) {
+	a, _ := newCodexTestAdapter()
+	a.ctx = context.Background()
+	a.threadID = "thread-1"
+	srv := &fakeBridge{}
+	a.server = srv
+
+	respCh := make(chan acpsdk.PromptResponse, 1)
+	go func() {
+		resp, err := a.Prompt(context.Background(), acpsdk.PromptRequest{
+			Prompt: []acpsdk.ContentBlock{acpsdk.ImageBlock("aW1n", "image/png")},
+		})
+		assert.NoError(t, err)
+		respCh <- resp
+	}()
+	require.Eventually(t, func() bool {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		return a.turnDoneCh != nil
+	}, time.Second, 5*time.Millisecond)
+
+	a.dispatchNotification("thread-1", methodTurnCompleted, rawJSON(t, map[string]any{"turn": map[string]any{"status": "completed"}}), nil)
+	select {
+	case resp := <-respCh:
+		assert.Equal(t, acpsdk.StopReasonEndTurn, resp.StopReason)
+	case <-time.After(time.Second):
+		t.Fatal("Prompt did not return")
+	}
+	assert.Equal(t, [][]map[string]string{{{"type": "image", "url": "data:image/png;base64,aW1n"}}}, srv.turnInputs)
+}
+
+func TestPrompt_TextAndResourceInput(t *testing.T) {
+	input, err := turnInput([]acpsdk.ContentBlock{
+		acpsdk.TextBlock("look at "),
+		acpsdk.TextBlock("this"),
+		acpsdk.ResourceBlock(acpsdk.EmbeddedResourceResource{
+			TextResourceContents: &acpsdk.TextResourceContents{Uri: "file:///notes.txt", Text: "notes"},
+		}),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []map[string]string{{
+		"type": "text",
+		"text": "look at this<resource uri=\"file:///notes.txt\">\nnotes\n</resource>",
+	}}, input)
+}
+
+func TestPrompt_RejectsUnsendablePrompts(t *testing.T) {
+	a, _ := newCodexTestAdapter()
+	a.ctx = context.Background()
+	a.threadID = "thread-1"
+	srv := &fakeBridge{}
+	a.server = srv
+
+	prompts := map[string][]acpsdk.ContentBlock{
+		"empty":         nil,
+		"empty text":    {acpsdk.TextBlock("")},
+		"audio":         {acpsdk.AudioBlock("YXVkaW8=", "audio/wav")},
+		"resource link": {acpsdk.ResourceLinkBlock("notes", "file:///notes.txt")},
+		"blob resource": {acpsdk.ResourceBlock(acpsdk.EmbeddedResourceResource{
+			BlobResourceContents: &acpsdk.BlobResourceContents{Uri: "file:///a.pdf", Blob: "cGRm"},
+		})},
+	}
+	for name, blocks := range prompts {
+		_, err := a.Prompt(context.Background(), acpsdk.PromptRequest{Prompt: blocks})
+		_, ok := driver.InvalidPromptMessage(err)
+		assert.True(t, ok, "%s: got %v", name, err)
+	}
+	assert.Empty(t, srv.turnInputs)
+}
+
+func TestPrompt_TurnFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		params     map[string]any
+		wantErr    string
+		wantRefuse bool
+	}{
+		{
+			name:    "turn failed",
+			method:  methodTurnFailed,
+			params:  map[string]any{"turn": map[string]any{"id": "turn-1", "error": map[string]any{"message": "stream disconnected"}}},
+			wantErr: "codex turn failed: stream disconnected",
+		},
+		{
+			name:    "error notification",
+			method:  methodError,
+			params:  map[string]any{"error": map[string]any{"message": "quota exceeded"}, "willRetry": false, "turnId": "turn-1"},
+			wantErr: "codex turn failed: quota exceeded",
+		},
+		{
+			name:       "policy error",
+			method:     methodError,
+			params:     map[string]any{"error": map[string]any{"message": "flagged as violating our usage policy"}},
+			wantRefuse: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, updater := newCodexTestAdapter()
+			a.ctx = context.Background()
+			a.threadID = "thread-1"
+			a.server = &fakeBridge{}
+
+			type result struct {
+				resp acpsdk.PromptResponse
+				err  error
+			}
+			resCh := make(chan result, 1)
+			go func() {
+				resp, err := a.Prompt(context.Background(), acpsdk.PromptRequest{
+					Prompt: []acpsdk.ContentBlock{acpsdk.TextBlock("hello")},
+				})
+				resCh <- result{resp, err}
+			}()
+			require.Eventually(t, func() bool {
+				a.mu.Lock()
+				defer a.mu.Unlock()
+				return a.turnDoneCh != nil
+			}, time.Second, 5*time.Millisecond)
+
+			retry := map[string]any{"error": map[string]any{"message": "reconnecting"}, "willRetry": true}
+			a.dispatchNotification("thread-1", methodError, rawJSON(t, retry), nil)
+			a.dispatchNotification("thread-1", tt.method, rawJSON(t, tt.params), nil)
+
+			var res result
+			select {
+			case res = <-resCh:
+			case <-time.After(time.Second):
+				t.Fatal("Prompt did not return")
+			}
+			// A retried error does not end the turn; turn/completed may
+			// still follow the failure.
+			a.dispatchNotification("thread-1", methodTurnCompleted, rawJSON(t, map[string]any{"turn": map[string]any{"status": "failed"}}), nil)
+
+			assert.Empty(t, updater.allUpdates(), "the failure is not reported as agent text")
+			if tt.wantRefuse {
+				require.NoError(t, res.err)
+				assert.Equal(t, acpsdk.StopReasonRefusal, res.resp.StopReason)
+				return
+			}
+			require.EqualError(t, res.err, tt.wantErr)
+		})
+	}
+}
+
+func TestPrompt_StaleTurnCompletedIsIgnored(t *testing.T) {
+	a, _ := newCodexTestAdapter()
+	a.ctx = context.Background()
+	a.threadID = "thread-1"
+	a.server = &fakeBridge{}
+
+	type result struct {
+		resp acpsdk.PromptResponse
+		err  error
+	}
+	prompt := func() chan result {
+		resCh := make(chan result, 1)
+		go func() {
+			resp, err := a.Prompt(context.Background(), acpsdk.PromptRequest{
+				Prompt: []acpsdk.ContentBlock{acpsdk.TextBlock("hello")},
+			})
+			resCh <- result{resp, err}
+		}()
+		require.Eventually(t, func() bool {
+			a.mu.Lock()
+			defer a.mu.Unlock()
+			return a.turnDoneCh != nil
+		}, time.Second, 5*time.Millisecond)
+		return resCh
+	}
+
+	first := prompt()
+	a.dispatchNotification("thread-1", methodError, rawJSON(t, map[string]any{"error": map[string]any{"message": "quota exceeded"}, "turnId": "turn-1"}), nil)
+	require.Error(t, (<-first).err)
+
+	second := prompt()
+	// The app-server still completes the failed turn.
+	a.dispatchNotification("thread-1", methodTurnCompleted, rawJSON(t, map[string]any{"turn": map[string]any{"id": "turn-1", "status": "failed"}}), nil)
+	select {
+	case res := <-second:
+		t.Fatalf("the late turn/completed of turn-1 ended turn-2: %+v", res)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	a.dispatchNotification("thread-1", methodTurnCompleted, rawJSON(t, map[string]any{"turn": map[string]any{"id": "turn-2", "status": "completed"}}), nil)
+	select {
+	case res := <-second:
+		require.NoError(t, res.err)
+		assert.Equal(t, acpsdk.StopReasonEndTurn, res.resp.StopReason)
+	case <-time.After(time.Second):
+		t.Fatal("Prompt did not return")
+	}
+}
+
+func TestPrompt_CancelledContextWaitsForInterruptedTurn(t *testing.T) {
+	a, updater := newCodexTestAdapter()
+	a.ctx = context.Background()
+	a.threadID = "thread-1"
+	a.server = &fakeBridge{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	respCh := make(chan acpsdk.PromptResponse, 1)
+	go func() {
+		resp, err := a.Prompt(ctx, acpsdk.PromptRequest{
+			Prompt: []acpsdk.ContentBlock{acpsdk.TextBlock("hello")},
+		})
+		assert.NoError(t, err)
+		respCh <- resp
+	}()
+	require.Eventually(t, func() bool {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		return a.turnDoneCh != nil
+	}, time.Second, 5*time.Millisecond)
+
+	// session/cancel cancels the prompt context first; the turn keeps
+	// streaming until the app-server completes it.
+	cancel()
+	a.dispatchNotification("thread-1", methodAgentMessageDelta, rawJSON(t, map[string]any{"delta": "partial"}), nil)
+	select {
+	case <-respCh:
+		t.Fatal("Prompt returned before the interrupted turn completed")
+	default:
+	}
+	a.dispatchNotification("thread-1", methodTurnCompleted, rawJSON(t, map[string]any{}), nil)
+
+	select {
+	case resp := <-respCh:
+		assert.Equal(t, acpsdk.StopReasonCancelled, resp.StopReason)
+	case <-time.After(time.Second):
+		t.Fatal("Prompt did not return after the interrupted turn completed")
+	}
+	require.Len(t, updater.allUpdates(), 1)
+}
+
 func TestNotificationHandlers_McpToolCallLifecycle(t *testing.T) {
 	a := &Adapter{}
 
@@ -152,7 +556,9 @@ func TestNotificationHandlers_McpToolCallLifecycle(t *testing.T) {
 	require.NotNil(t, started[0].ToolCall)
 	assert.Equal(t, acpsdk.ToolCallId("mcp-1"), started[0].ToolCall.ToolCallId)
 	assert.Equal(t, "flowgentic.plan_commit", started[0].ToolCall.Title)
+	assert.Equal(t, acpsdk.ToolKindOther, started[0].ToolCall.Kind)
 	assert.Equal(t, acpsdk.ToolCallStatusInProgress, started[0].ToolCall.Status)
+	assert.Equal(t, map[string]any{"foo": "bar"}, started[0].ToolCall.RawInput)
 
 	progress := notificationHandlers[methodMCPToolCallProgress](a, rawJSON(t, map[string]any{
 		"itemId":   "mcp-1",
@@ -178,6 +584,108 @@ func TestNotificationHandlers_McpToolCallLifecycle(t *testing.T) {
 	assert.Equal(t, acpsdk.ToolCallStatusCompleted, *completed[0].ToolCallUpdate.Status)
 }
 
+func TestNotificationHandlers_McpToolCallFlowgenticSummary(t *testing.T) {
+	tests := []struct {
+		name string
+		item map[string]any
+		want string
+	}{
+		{
+			name: "set_topic",
+			item: map[string]any{
+				"server": "flowgentic", "tool": "set_topic", "arguments": `{"topic":"Fix login"}`,
+				"result": map[string]any{"content": []any{map[string]any{"type": "text", "text": "Topic set successfully"}}},
+			},
+			want: "Topic set to: Fix login",
+		},
+		{
+			name: "ask_question",
+			item: map[string]any{
+				"server": "flowgentic", "tool": "ask_question",
+				"result": map[string]any{"structuredContent": map[string]any{"question": "Keep v1?", "answer": "Yes", "mocked": true}},
+			},
+			want: "Answer: Yes",
+		},
+		{
+			name: "plan_commit",
+			item: map[string]any{
+				"server": "flowgentic", "tool": "plan_commit",
+				"result": map[string]any{"structuredContent": map[string]any{"submitted_plans": 2}},
+			},
+			want: "Plan submitted: 2 dirs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := map[string]any{"id": "mcp-1", "type": "mcpToolCall"}
+			for k, v := range tt.item {
+				item[k] = v
+			}
+			completed := notificationHandlers[methodItemCompleted](&Adapter{}, rawJSON(t, map[string]any{"item": item}))
+			require.Len(t, completed, 1)
+			u := completed[0].ToolCallUpdate
+			require.NotNil(t, u)
+			require.Len(t, u.Content, 1)
+			assert.Equal(t, tt.want, u.Content[0].Content.Content.Text.Text)
+			assert.NotNil(t, u.RawOutput, "the raw result is kept")
+		})
+	}
+
+	t.Run("other tools keep only the raw result", func(t *testing.T) {
+		completed := notificationHandlers[methodItemCompleted](&Adapter{}, rawJSON(t, map[string]any{"item": map[string]any{
+			"id": "mcp-1", "type": "mcpToolCall", "server": "exa", "tool": "set_topic",
+			"result": map[string]any{"structuredContent": map[string]any{"topic": "x"}},
+		}}))
+		require.Len(t, completed, 1)
+		assert.Empty(t, completed[0].ToolCallUpdate.Content)
+	})
+}
+
+func TestNotificationHandlers_McpToolCallStartTitleAndKind(t *testing.T) {
+	tests := []struct {
+		name      string
+		item      map[string]any
+		wantTitle string
+		wantKind  acpsdk.ToolKind
+		wantInput any
+	}{
+		{
+			name:      "search tool",
+			item:      map[string]any{"server": "exa", "tool": "web_search", "arguments": map[string]any{"query": "acp"}},
+			wantTitle: "exa.web_search",
+			wantKind:  acpsdk.ToolKindSearch,
+			wantInput: map[string]any{"query": "acp"},
+		},
+		{
+			name:      "fetch tool with JSON-encoded arguments",
+			item:      map[string]any{"server": "web", "tool": "fetch_url", "arguments": `{"url":"https://example.com"}`},
+			wantTitle: "web.fetch_url",
+			wantKind:  acpsdk.ToolKindFetch,
+			wantInput: map[string]any{"url": "https://example.com"},
+		},
+		{
+			name:      "unnamed tool without server or arguments",
+			item:      map[string]any{"arguments": map[string]any{}},
+			wantTitle: "MCP tool call",
+			wantKind:  acpsdk.ToolKindOther,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := map[string]any{"id": "mcp-1", "type": "mcpToolCall"}
+			for k, v := range tt.item {
+				item[k] = v
+			}
+			started := notificationHandlers[methodItemStarted](&Adapter{}, rawJSON(t, map[string]any{"item": item}))
+			require.Len(t, started, 1)
+			require.NotNil(t, started[0].ToolCall)
+			assert.Equal(t, tt.wantTitle, started[0].ToolCall.Title)
+			assert.Equal(t, tt.wantKind, started[0].ToolCall.Kind)
+			assert.Equal(t, tt.wantInput, started[0].ToolCall.RawInput)
+		})
+	}
+}
+
 func TestNotificationHandlers_McpStartupUpdate(t *testing.T) {
 	a := &Adapter{}
 
@@ -189,6 +697,21 @@ func TestNotificationHandlers_McpStartupUpdate(t *testing.T) {
 	require.NotNil(t, updates[0].AgentThoughtChunk)
 	assert.Contains(t, updates[0].AgentThoughtChunk.Content.Text.Text, "[mcp startup]")
 	assert.Contains(t, updates[0].AgentThoughtChunk.Content.Text.Text, "flowgentic")
+
+	s, ok := driver.ParseMCPStartup(updates[0].AgentThoughtChunk.Meta)
+	require.True(t, ok, "startup progress is marked for separate rendering")
+	assert.Equal(t, driver.MCPStartup{Server: "flowgentic", Message: "connected"}, s)
+}
+
+func TestNotificationHandlers_McpStartupComplete(t *testing.T) {
+	a := &Adapter{}
+
+	updates := notificationHandlers[methodMCPStartupComplete](a, rawJSON(t, map[string]any{}))
+	require.Len(t, updates, 1)
+	assert.Equal(t, "[mcp ready]", updates[0].AgentThoughtChunk.Content.Text.Text)
+	s, ok := driver.ParseMCPStartup(updates[0].AgentThoughtChunk.Meta)
+	require.True(t, ok)
+	assert.True(t, s.Ready)
 }
 
 func rawJSON(t *testing.T, v any) json.RawMessage {
@@ -197,3 +720,115 @@ func rawJSON(t *testing.T, v any) json.RawMessage {
 	require.NoError(t, err)
 	return b
 }
+
+func TestNotificationHandlers_CommandExecutionNonzeroExit(t *testing.T) {
+	a := &Adapter{}
+
+	completed := notificationHandlers[methodItemCompleted](a, rawJSON(t, map[string]any{
+		"item": map[string]any{
+			"id":               "cmd-1",
+			"type":             "commandExecution",
+			"command":          "go build ./...",
+			"aggregatedOutput": "main.go:3: undefined: foo\n",
+			"exitCode":         2,
+		},
+	}))
+	require.Len(t, completed, 1)
+	upd := completed[0].ToolCallUpdate
+	require.NotNil(t, upd)
+	require.NotNil(t, upd.Status)
+	assert.Equal(t, acpsdk.ToolCallStatusFailed, *upd.Status)
+
+	out, ok := driver.ParseCommandOutput(upd.Meta)
+	require.True(t, ok)
+	assert.Equal(t, "main.go:3: undefined: foo\n", out.Stdout)
+	require.NotNil(t, out.ExitCode)
+	assert.Equal(t, 2, *out.ExitCode)
+}
+
+func TestNotificationHandlers_CommandOutputDeltaStreamsOutput(t *testing.T) {
+	a, updater := newCodexTestAdapter()
+	handle := notificationHandlers[methodCommandOutputDelta]
+	outputs := func() []string {
+		var texts []string
+		for _, n := range updater.allUpdates() {
+			upd := n.Update.ToolCallUpdate
+			require.NotNil(t, upd)
+			assert.Equal(t, acpsdk.SessionId("t"), n.SessionId)
+			assert.Equal(t, acpsdk.ToolCallId("cmd-1"), upd.ToolCallId)
+			require.NotNil(t, upd.Status)
+			assert.Equal(t, acpsdk.ToolCallStatusInProgress, *upd.Status)
+			require.Len(t, upd.Content, 1)
+			texts = append(texts, upd.Content[0].Content.Content.Text.Text)
+		}
+		return texts
+	}
+
+	// The first delta goes out at once; the ones right after it are
+	// coalesced into a single update carrying the output so far.
+	for _, delta := range []string{"compiling a\n", "compiling b\n", "compiling c\n"} {
+		assert.Empty(t, handle(a, rawJSON(t, map[string]any{"threadId": "t", "turnId": "u", "itemId": "cmd-1", "delta": delta})))
+	}
+	assert.Equal(t, []string{"compiling a\n"}, outputs())
+	assert.Eventually(t, func() bool { return len(updater.allUpdates()) == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"compiling a\n", "compiling a\ncompiling b\ncompiling c\n"}, outputs())
+
+	handle(a, rawJSON(t, map[string]any{"threadId": "t", "turnId": "u", "itemId": "cmd-1", "delta": "linking\n"}))
+	notificationHandlers[methodItemCompleted](a, rawJSON(t, map[string]any{
+		"item": map[string]any{"id": "cmd-1", "type": "commandExecution", "aggregatedOutput": "compiling a\ncompiling b\ncompiling c\nlinking\n", "exitCode": 0},
+	}))
+	assert.Empty(t, a.commandOutput, "completed commands drop their streamed output")
+	time.Sleep(2 * commandOutputInterval)
+	assert.Len(t, updater.allUpdates(), 2, "coalesced output is not sent after the command completed")
+}
+
+func TestEndTurn_DropsCommandOutput(t *testing.T) {
+	a, _ := newCodexTestAdapter()
+	a.turnID = "turn-1"
+	a.turnDoneCh = make(chan struct{})
+	handle := notificationHandlers[methodCommandOutputDelta]
+	handle(a, rawJSON(t, map[string]any{"threadId": "t", "turnId": "turn-1", "itemId": "cmd-1", "delta": "sleeping\n"}))
+	require.Len(t, a.commandOutput, 1)
+
+	a.endTurn("turn-1", false, nil)
+	assert.Empty(t, a.commandOutput, "commands the turn left running drop their output")
+}
+
+func TestNotificationHandlers_CommandExecutionWithoutExitCodeFallsBackToText(t *testing.T) {
+	a := &Adapter{}
+
+	completed := notificationHandlers[methodItemCompleted](a, rawJSON(t, map[string]any{
+		"item": map[string]any{
+			"id":               "cmd-1",
+			"type":             "commandExecution",
+			"aggregatedOutput": "partial output",
+		},
+	}))
+	require.Len(t, completed, 1)
+	upd := completed[0].ToolCallUpdate
+	_, ok := driver.ParseCommandOutput(upd.Meta)
+	assert.False(t, ok)
+	require.Len(t, upd.Content, 1)
+	assert.Equal(t, "partial output", upd.Content[0].Content.Content.Text.Text)
+}
+
+func TestNotificationHandlers_FileChangeLocations(t *testing.T) {
+	a := &Adapter{}
+
+	completed := notificationHandlers[methodItemCompleted](a, rawJSON(t, map[string]any{
+		"item": map[string]any{
+			"id":   "fc-1",
+			"type": "fileChange",
+			"changes": []map[string]any{
+				{"path": "/repo/main.go", "diff": "@@ -1 +1 @@\n-a\n+b\n"},
+				{"path": "/repo/util.go", "diff": "@@ -3 +3 @@\n-c\n+d\n"},
+				{"path": "/repo/main.go", "diff": "@@ -9 +9 @@\n-e\n+f\n"},
+			},
+		},
+	}))
+	require.Len(t, completed, 1)
+	upd := completed[0].ToolCallUpdate
+	require.NotNil(t, upd)
+	assert.Len(t, upd.Content, 3, "each change keeps its diff")
+	assert.Equal(t, []acpsdk.ToolCallLocation{{Path: "/repo/main.go"}, {Path: "/repo/util.go"}}, upd.Locations)
+}
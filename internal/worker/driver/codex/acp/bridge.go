@@ -4,13 +4,17 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
+	"os"
 	"os/exec"
 	"strings"
 	"sync"
 	"sync/atomic"
+	"syscall"
+	"time"
 
 	acpsdk "github.com/coder/acp-go-sdk"
 	"github.com/sebastianm/flowgentic/internal/worker/driver"
@@ -50,6 +54,23 @@ type jsonrpcNotification struct {
 	Params  json.RawMessage `json:"params,omitempty"`
 }
 
+// errAppServerClosed is returned when writing to an app-server that has
+// exited or whose stdin has been closed.
+var errAppServerClosed = errors.New("app-server closed")
+
+// errRequestTimeout is returned when the app-server does not answer a
+// request within its timeout.
+var errRequestTimeout = errors.New("app-server request timed out")
+
+const (
+	// defaultRequestTimeout bounds every app-server request. thread/start can
+	// take a while when MCP servers are starting, so it is generous.
+	defaultRequestTimeout = 2 * time.Minute
+	// interruptTimeout bounds turn/interrupt, which runs on the cancel path
+	// and must not hold it up.
+	interruptTimeout = 10 * time.Second
+)
+
 // bridge manages the Codex app-server subprocess and JSON-RPC communication.
 type bridge struct {
 	log     *slog.Logger
@@ -63,18 +84,24 @@ type bridge struct {
 	nextID    atomic.Int64
 	pending   map[int64]chan jsonrpcResponse
 	pendingMu sync.Mutex
+	// requestTimeout applies to requests sent without an explicit timeout.
+	requestTimeout time.Duration
 
 	dispatch func(threadID string, method string, params json.RawMessage, serverRequestID *int64)
+	// onStderr, if set, receives the app-server's stderr lines. It is set
+	// before start.
+	onStderr func(line string)
 
 	done chan struct{}
 }
 
 func newBridge(log *slog.Logger, dispatch func(threadID string, method string, params json.RawMessage, serverRequestID *int64)) *bridge {
 	return &bridge{
-		log:      log,
-		pending:  make(map[int64]chan jsonrpcResponse),
-		dispatch: dispatch,
-		done:     make(chan struct{}),
+		log:            log.With("side", "app-server"),
+		pending:        make(map[int64]chan jsonrpcResponse),
+		requestTimeout: defaultRequestTimeout,
+		dispatch:       dispatch,
+		done:           make(chan struct{}),
 	}
 }
 
@@ -122,6 +149,12 @@ func (b *bridge) start(ctx context.Context, envVars map[string]string) error {
 }
 
 func (b *bridge) sendRequest(method string, params any) (json.RawMessage, error) {
+	return b.sendRequestTimeout(method, params, b.requestTimeout)
+}
+
+// sendRequestTimeout sends a request and waits up to timeout for its
+// response. A zero timeout waits until the app-server exits.
+func (b *bridge) sendRequestTimeout(method string, params any, timeout time.Duration) (json.RawMessage, error) {
 	id := b.nextID.Add(1)
 	ch := make(chan jsonrpcResponse, 1)
 
@@ -137,6 +170,13 @@ func (b *bridge) sendRequest(method string, params any) (json.RawMessage, error)
 		return nil, err
 	}
 
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+
 	select {
 	case resp := <-ch:
 		if resp.Error != nil {
@@ -144,12 +184,20 @@ func (b *bridge) sendRequest(method string, params any) (json.RawMessage, error)
 		}
 		return resp.Result, nil
 	case <-b.done:
-		return nil, fmt.Errorf("app-server closed before response for %s (id=%d)", method, id)
+		return nil, fmt.Errorf("%w before response for %s (id=%d)", errAppServerClosed, method, id)
+	case <-timeoutCh:
+		// A late response finds no pending entry and is dropped by readLoop.
+		b.pendingMu.Lock()
+		delete(b.pending, id)
+		b.pendingMu.Unlock()
+		return nil, fmt.Errorf("%w: %s (id=%d) after %s", errRequestTimeout, method, id, timeout)
 	}
 }
 
 func (b *bridge) sendNotification(method string, params any) {
-	_ = b.writeJSON(jsonrpcRequest{JSONRPC: "2.0", Method: method, Params: params})
+	if err := b.writeJSON(jsonrpcRequest{JSONRPC: "2.0", Method: method, Params: params}); err != nil {
+		b.log.Debug("send notification failed", "method", method, "error", err)
+	}
 }
 
 func (b *bridge) respondToServerRequest(id int64, result any) {
@@ -158,19 +206,39 @@ func (b *bridge) respondToServerRequest(id int64, result any) {
 		ID      int64  `json:"id"`
 		Result  any    `json:"result"`
 	}
-	_ = b.writeJSON(resp{JSONRPC: "2.0", ID: id, Result: result})
+	if err := b.writeJSON(resp{JSONRPC: "2.0", ID: id, Result: result}); err != nil {
+		b.log.Debug("send server request response failed", "id", id, "error", err)
+	}
 }
 
+// writeJSON writes one JSON-RPC message to the app-server's stdin. It returns
+// an error wrapping errAppServerClosed once the process has exited or stdin
+// has been closed, rather than writing into a broken pipe.
 func (b *bridge) writeJSON(v any) error {
 	data, err := json.Marshal(v)
 	if err != nil {
 		return fmt.Errorf("marshal JSON-RPC: %w", err)
 	}
 	data = append(data, '\n')
+
+	select {
+	case <-b.done:
+		return errAppServerClosed
+	default:
+	}
+
 	b.stdinMu.Lock()
 	defer b.stdinMu.Unlock()
-	_, err = b.stdin.Write(data)
-	return err
+	if b.stdin == nil {
+		return errAppServerClosed
+	}
+	if _, err := b.stdin.Write(data); err != nil {
+		if errors.Is(err, os.ErrClosed) || errors.Is(err, io.ErrClosedPipe) || errors.Is(err, syscall.EPIPE) {
+			return fmt.Errorf("%w: %v", errAppServerClosed, err)
+		}
+		return fmt.Errorf("write to app-server: %w", err)
+	}
+	return nil
 }
 
 func (b *bridge) readLoop(r io.Reader) {
@@ -219,31 +287,18 @@ func (b *bridge) readStderrLoop(r io.Reader) {
 		if line == "" {
 			continue
 		}
+		if b.onStderr != nil {
+			b.onStderr(line)
+			continue
+		}
 		b.log.Debug("codex stderr", "line", line)
 	}
 }
 
-func (b *bridge) threadStart(model, cwd, systemPrompt, sessionMode string, mcpServers []acpsdk.McpServer) (string, error) {
-	policy := "on-failure"
-	if sessionMode == "code" {
-		policy = "never"
-	}
-	params := map[string]any{
-		"cwd":            cwd,
-		"approvalPolicy": policy,
-	}
-	if cfg := codexMCPServers(mcpServers); len(cfg) > 0 {
-		params["config"] = map[string]any{
-			"mcp_servers": cfg,
-		}
-	}
-	if model != "" {
-		params["model"] = model
-	}
-	if systemPrompt != "" {
-		params["developerInstructions"] = systemPrompt
-	}
+func (b *bridge) setStderr(fn func(line string)) { b.onStderr = fn }
 
+func (b *bridge) threadStart(model, cwd, systemPrompt, sessionMode, effort string, mcpServers []acpsdk.McpServer) (string, error) {
+	params := threadStartParams(model, cwd, systemPrompt, sessionMode, effort, mcpServers)
 	result, err := b.sendRequest("thread/start", params)
 	if err != nil {
 		return "", err
@@ -275,7 +330,37 @@ func (b *bridge) threadStart(model, cwd, systemPrompt, sessionMode string, mcpSe
 	}
 }
 
-func (b *bridge) turnStart(threadID, prompt, cwd, sessionMode string) (string, error) {
+// threadStartParams builds the thread/start request params.
+func threadStartParams(model, cwd, systemPrompt, sessionMode, effort string, mcpServers []acpsdk.McpServer) map[string]any {
+	policy := "on-failure"
+	if sessionMode == "code" {
+		policy = "never"
+	}
+	params := map[string]any{
+		"cwd":            cwd,
+		"approvalPolicy": policy,
+	}
+	config := map[string]any{}
+	if cfg := codexMCPServers(mcpServers); len(cfg) > 0 {
+		config["mcp_servers"] = cfg
+	}
+	// Codex accepts the same low/medium/high levels as driver.ReasoningEffort.
+	if effort != "" {
+		config["model_reasoning_effort"] = effort
+	}
+	if len(config) > 0 {
+		params["config"] = config
+	}
+	if model != "" {
+		params["model"] = model
+	}
+	if systemPrompt != "" {
+		params["developerInstructions"] = systemPrompt
+	}
+	return params
+}
+
+func (b *bridge) turnStart(threadID string, input []map[string]string, cwd, sessionMode string) (string, error) {
 	sp := map[string]any{
 		"type":          "workspaceWrite",
 		"writableRoots": []string{cwd},
@@ -290,7 +375,7 @@ func (b *bridge) turnStart(threadID, prompt, cwd, sessionMode string) (string, e
 
 	result, err := b.sendRequest("turn/start", map[string]any{
 		"threadId":      threadID,
-		"input":         []map[string]string{{"type": "text", "text": prompt}},
+		"input":         input,
 		"sandboxPolicy": sp,
 	})
 	if err != nil {
@@ -308,17 +393,19 @@ func (b *bridge) turnStart(threadID, prompt, cwd, sessionMode string) (string, e
 }
 
 func (b *bridge) turnInterrupt(threadID, turnID string) error {
-	_, err := b.sendRequest("turn/interrupt", map[string]string{
+	_, err := b.sendRequestTimeout("turn/interrupt", map[string]string{
 		"threadId": threadID,
 		"turnId":   turnID,
-	})
+	}, interruptTimeout)
 	return err
 }
 
 func (b *bridge) close() {
+	b.stdinMu.Lock()
 	if b.stdin != nil {
 		_ = b.stdin.Close()
 	}
+	b.stdinMu.Unlock()
 	if b.cmd != nil && b.cmd.Process != nil {
 		_ = b.cmd.Process.Kill()
 		_ = b.cmd.Wait()
@@ -469,9 +556,14 @@ func parseModelState(raw json.RawMessage) *acpsdk.SessionModelState {
 		seen[e.id] = struct{}{}
 		uniq = append(uniq, e)
 	}
-	if len(uniq) == 0 || currentModel == "" {
+	if len(uniq) == 0 {
 		return nil
 	}
+	// Some agents list models without marking one as current; the first is
+	// then taken as the default rather than discarding the list.
+	if currentModel == "" {
+		currentModel = uniq[0].id
+	}
 
 	state := &acpsdk.SessionModelState{
 		AvailableModels: make([]acpsdk.ModelInfo, 0, len(uniq)),
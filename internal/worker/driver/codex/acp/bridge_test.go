@@ -3,9 +3,11 @@ package acp
 import (
 	"bytes"
 	"encoding/json"
+	"io"
 	"log/slog"
 	"strings"
 	"testing"
+	"time"
 
 	acpsdk "github.com/coder/acp-go-sdk"
 	"github.com/stretchr/testify/assert"
@@ -125,10 +127,11 @@ func TestParseModelState_ExtractsDisplayNameAndDescription(t *testing.T) {
 	assert.Nil(t, state.AvailableModels[1].Description)
 }
 
-func TestParseModelState_ReturnsNilWhenIncomplete(t *testing.T) {
+func TestParseModelState_DefaultsCurrentToFirstModel(t *testing.T) {
 	raw := map[string]any{
 		"models": map[string]any{
 			"available": []any{
+				map[string]any{"id": "gpt-5-mini"},
 				map[string]any{"id": "gpt-5"},
 			},
 		},
@@ -136,6 +139,20 @@ func TestParseModelState_ReturnsNilWhenIncomplete(t *testing.T) {
 	b, err := json.Marshal(raw)
 	require.NoError(t, err)
 
+	state := parseModelState(b)
+	require.NotNil(t, state)
+	require.Len(t, state.AvailableModels, 2)
+	assert.Equal(t, "gpt-5-mini", string(state.CurrentModelId))
+}
+
+func TestParseModelState_ReturnsNilWithoutModels(t *testing.T) {
+	raw := map[string]any{
+		"models":       map[string]any{"available": []any{}},
+		"currentModel": "gpt-5",
+	}
+	b, err := json.Marshal(raw)
+	require.NoError(t, err)
+
 	assert.Nil(t, parseModelState(b))
 }
 
@@ -181,3 +198,121 @@ func TestCodexMCPServers(t *testing.T) {
 	assert.Equal(t, "https://example.com/mcp", httpSrv["url"])
 	assert.Equal(t, map[string]string{"Authorization": "Bearer token"}, httpSrv["headers"])
 }
+
+func TestThreadStartParams_ReasoningEffort(t *testing.T) {
+	params := threadStartParams("gpt-5", "/tmp", "", "ask", "medium", nil)
+	assert.Equal(t, map[string]any{"model_reasoning_effort": "medium"}, params["config"])
+
+	params = threadStartParams("", "/tmp", "", "ask", "", nil)
+	assert.NotContains(t, params, "config")
+}
+
+func TestBridgeWriteJSON_ClosedPipeReturnsCleanError(t *testing.T) {
+	var logBuf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logBuf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	b := newBridge(logger, nil)
+	r, w := io.Pipe()
+	b.stdin = w
+	require.NoError(t, r.Close())
+
+	err := b.writeJSON(map[string]string{"hello": "world"})
+	require.ErrorIs(t, err, errAppServerClosed)
+
+	_, err = b.sendRequest("thread/start", nil)
+	require.ErrorIs(t, err, errAppServerClosed)
+
+	b.sendNotification("initialized", nil)
+	assert.Contains(t, logBuf.String(), "send notification failed")
+	assert.Contains(t, logBuf.String(), "method=initialized")
+
+	b.pendingMu.Lock()
+	assert.Empty(t, b.pending, "failed requests are not left pending")
+	b.pendingMu.Unlock()
+}
+
+func TestBridgeWriteJSON_AfterExit(t *testing.T) {
+	b := newBridge(slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+	_, w := io.Pipe()
+	b.stdin = w
+	close(b.done)
+
+	require.ErrorIs(t, b.writeJSON(map[string]string{}), errAppServerClosed)
+}
+
+func TestBridgeTurnInterrupt_DuringShutdownDoesNotPanic(t *testing.T) {
+	b := newBridge(slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+
+	// Never started: no stdin.
+	require.NotPanics(t, func() {
+		require.ErrorIs(t, b.turnInterrupt("thread-1", "turn-1"), errAppServerClosed)
+	})
+
+	// Closed mid-session.
+	_, w := io.Pipe()
+	b.stdin = w
+	b.close()
+	require.NotPanics(t, func() {
+		require.ErrorIs(t, b.turnInterrupt("thread-1", "turn-1"), errAppServerClosed)
+	})
+}
+
+// newSilentBridge returns a bridge whose app-server reads every request but
+// never responds.
+func newSilentBridge(t *testing.T) *bridge {
+	t.Helper()
+	b := newBridge(slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+	r, w := io.Pipe()
+	go func() { _, _ = io.Copy(io.Discard, r) }()
+	b.stdin = w
+	t.Cleanup(func() { _ = w.Close() })
+	return b
+}
+
+func TestBridgeSendRequest_TimesOut(t *testing.T) {
+	b := newSilentBridge(t)
+	b.requestTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	_, err := b.threadStart("", "/tmp", "", "ask", "", nil)
+	require.ErrorIs(t, err, errRequestTimeout)
+	assert.Contains(t, err.Error(), "thread/start")
+	assert.Less(t, time.Since(start), time.Second)
+
+	b.pendingMu.Lock()
+	assert.Empty(t, b.pending, "timed-out requests are not left pending")
+	b.pendingMu.Unlock()
+}
+
+func TestBridgeSendRequestTimeout_PerCallOverride(t *testing.T) {
+	b := newSilentBridge(t)
+	b.requestTimeout = time.Hour
+
+	_, err := b.sendRequestTimeout("turn/start", nil, 20*time.Millisecond)
+	require.ErrorIs(t, err, errRequestTimeout)
+
+	// Without a timeout the call waits for the response.
+	b.requestTimeout = 0
+	resCh := make(chan error, 1)
+	go func() {
+		_, err := b.sendRequest("turn/start", nil)
+		resCh <- err
+	}()
+	require.Eventually(t, func() bool {
+		b.pendingMu.Lock()
+		defer b.pendingMu.Unlock()
+		return len(b.pending) == 1
+	}, time.Second, 5*time.Millisecond)
+	select {
+	case err := <-resCh:
+		t.Fatalf("request returned early: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	b.pendingMu.Lock()
+	for id, ch := range b.pending {
+		delete(b.pending, id)
+		ch <- jsonrpcResponse{Result: json.RawMessage(`{}`)}
+	}
+	b.pendingMu.Unlock()
+	require.NoError(t, <-resCh)
+}
@@ -0,0 +1,52 @@
+package acp
+
+import (
+	"fmt"
+	"strings"
+
+	acpsdk "github.com/coder/acp-go-sdk"
+	"github.com/sebastianm/flowgentic/internal/worker/driver"
+)
+
+// turnInput converts prompt blocks to turn/start input items. Adjacent text
+// blocks and text resources are joined into one text item and images are
+// sent as data URLs. Other blocks, and prompts without content, are rejected
+// with an invalid-params error.
+func turnInput(blocks []acpsdk.ContentBlock) ([]map[string]string, error) {
+	var input []map[string]string
+	addText := func(text string) {
+		if n := len(input); n > 0 && input[n-1]["type"] == "text" {
+			input[n-1]["text"] += text
+			return
+		}
+		input = append(input, map[string]string{"type": "text", "text": text})
+	}
+	for i, b := range blocks {
+		switch {
+		case b.Text != nil:
+			if b.Text.Text != "" {
+				addText(b.Text.Text)
+			}
+		case b.Image != nil:
+			if !strings.HasPrefix(b.Image.MimeType, "image/") || b.Image.Data == "" {
+				return nil, driver.NewInvalidPromptError(fmt.Sprintf("content block %d: image needs an image MIME type and data", i))
+			}
+			input = append(input, map[string]string{
+				"type": "image",
+				"url":  "data:" + b.Image.MimeType + ";base64," + b.Image.Data,
+			})
+		case b.Resource != nil:
+			text, ok := driver.ResourcePromptText(b.Resource)
+			if !ok {
+				return nil, driver.NewInvalidPromptError(fmt.Sprintf("content block %d: codex supports only text resources", i))
+			}
+			addText(text)
+		default:
+			return nil, driver.NewInvalidPromptError(fmt.Sprintf("content block %d: unsupported by codex", i))
+		}
+	}
+	if len(input) == 0 {
+		return nil, driver.NewInvalidPromptError("prompt has no content")
+	}
+	return input, nil
+}
@@ -0,0 +1,257 @@
+package acp
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+
+	acpsdk "github.com/coder/acp-go-sdk"
+)
+
+// dispatchFunc receives app-server notifications and server requests.
+type dispatchFunc = func(threadID string, method string, params json.RawMessage, serverRequestID *int64)
+
+// SharedAppServer runs one Codex app-server for many sessions instead of one
+// per session. Every session starts its own thread on the shared process and
+// notifications are routed to the session owning the thread they name. The
+// app-server is started by the first session and closed when the last one
+// ends; a session started after that, or after the process died, gets a
+// fresh one.
+//
+// The app-server inherits the environment of the session that started it, so
+// per-session settings must travel in thread/start (model, cwd, MCP servers)
+// rather than in environment variables.
+type SharedAppServer struct {
+	log       *slog.Logger
+	newBridge func(log *slog.Logger, dispatch dispatchFunc) bridgeClient
+
+	mu      sync.Mutex
+	current *sharedInstance
+}
+
+// sharedInstance is one app-server process and the sessions attached to it.
+type sharedInstance struct {
+	b       bridgeClient
+	ready   chan struct{} // closed once start has returned
+	err     error         // start error, valid after ready
+	handles map[*sharedHandle]struct{}
+	threads map[string]*sharedHandle
+}
+
+// NewSharedAppServer returns a SharedAppServer. Use its NewAdapter as the
+// Codex AdapterFactory.
+func NewSharedAppServer(log *slog.Logger) *SharedAppServer {
+	return &SharedAppServer{
+		log: log.With("adapter", "codex", "app_server", "shared"),
+		newBridge: func(log *slog.Logger, dispatch dispatchFunc) bridgeClient {
+			return newBridge(log, dispatch)
+		},
+	}
+}
+
+// NewAdapter returns a Codex adapter that runs its session on the shared
+// app-server.
+func (s *SharedAppServer) NewAdapter(log *slog.Logger) acpsdk.Agent {
+	return newAdapter(log, func(_ *slog.Logger, dispatch dispatchFunc) bridgeClient {
+		return &sharedHandle{s: s, dispatch: dispatch}
+	})
+}
+
+// acquire attaches h to the running app-server, starting one if needed.
+// Concurrent callers wait for a single start.
+func (s *SharedAppServer) acquire(h *sharedHandle, envVars map[string]string) error {
+	s.mu.Lock()
+	inst := s.current
+	if inst == nil || inst.dead() {
+		inst = &sharedInstance{
+			ready:   make(chan struct{}),
+			handles: make(map[*sharedHandle]struct{}),
+			threads: make(map[string]*sharedHandle),
+		}
+		inst.b = s.newBridge(s.log, func(threadID string, method string, params json.RawMessage, serverRequestID *int64) {
+			s.dispatch(inst, threadID, method, params, serverRequestID)
+		})
+		inst.b.setStderr(func(line string) { s.stderr(inst, line) })
+		s.current = inst
+		s.mu.Unlock()
+
+		// The process outlives the session that starts it, so it is not
+		// bound to that session's context.
+		inst.err = inst.b.start(context.Background(), envVars)
+		if inst.err != nil {
+			s.mu.Lock()
+			if s.current == inst {
+				s.current = nil
+			}
+			s.mu.Unlock()
+		}
+		close(inst.ready)
+		s.mu.Lock()
+	}
+	inst.handles[h] = struct{}{}
+	s.mu.Unlock()
+
+	<-inst.ready
+	if inst.err != nil {
+		s.mu.Lock()
+		delete(inst.handles, h)
+		s.mu.Unlock()
+		return inst.err
+	}
+	h.inst = inst
+	return nil
+}
+
+// route sends notifications for threadID to h.
+func (s *SharedAppServer) route(h *sharedHandle, threadID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h.inst.threads[threadID] = h
+}
+
+// release detaches h and closes the app-server once no session uses it.
+func (s *SharedAppServer) release(h *sharedHandle) {
+	inst := h.inst
+	if inst == nil {
+		return
+	}
+	s.mu.Lock()
+	delete(inst.handles, h)
+	for id, owner := range inst.threads {
+		if owner == h {
+			delete(inst.threads, id)
+		}
+	}
+	last := len(inst.handles) == 0
+	if last && s.current == inst {
+		s.current = nil
+	}
+	s.mu.Unlock()
+
+	if last {
+		s.log.Debug("last session detached, closing shared app-server")
+		inst.b.close()
+	}
+}
+
+func (s *SharedAppServer) dispatch(inst *sharedInstance, threadID string, method string, params json.RawMessage, serverRequestID *int64) {
+	s.mu.Lock()
+	h, ok := inst.threads[threadID]
+	if !ok && len(inst.handles) == 1 {
+		// Messages without a known thread (e.g. sent while thread/start is
+		// in flight) are unambiguous with a single session.
+		for only := range inst.handles {
+			h, ok = only, true
+		}
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		s.log.Debug("dropping app-server message for unknown thread", "thread_id", threadID, "method", method)
+		return
+	}
+	h.dispatch(threadID, method, params, serverRequestID)
+}
+
+// stderr passes a stderr line of the shared process to the session whose
+// thread it names, or to the only session attached. Other lines concern no
+// session or several, so they are only logged; copying them into every
+// session's tail would leak one session's errors into another's.
+func (s *SharedAppServer) stderr(inst *sharedInstance, line string) {
+	s.mu.Lock()
+	var sink func(string)
+	for id, h := range inst.threads {
+		if strings.Contains(line, id) {
+			sink = h.onStderr
+			break
+		}
+	}
+	if sink == nil && len(inst.handles) == 1 {
+		for only := range inst.handles {
+			sink = only.onStderr
+		}
+	}
+	s.mu.Unlock()
+
+	if sink == nil {
+		s.log.Debug("codex stderr", "line", line)
+		return
+	}
+	sink(line)
+}
+
+func (inst *sharedInstance) dead() bool {
+	select {
+	case <-inst.ready:
+	default:
+		return false // still starting
+	}
+	if inst.err != nil {
+		return true
+	}
+	select {
+	case <-inst.b.doneChan():
+		return true
+	default:
+		return false
+	}
+}
+
+// sharedHandle is one session's view of the shared app-server.
+type sharedHandle struct {
+	s        *SharedAppServer
+	dispatch dispatchFunc
+	inst     *sharedInstance
+	onStderr func(line string)
+	once     sync.Once
+}
+
+func (h *sharedHandle) start(_ context.Context, envVars map[string]string) error {
+	return h.s.acquire(h, envVars)
+}
+
+func (h *sharedHandle) threadStart(model, cwd, systemPrompt, sessionMode, effort string, mcpServers []acpsdk.McpServer) (string, error) {
+	threadID, err := h.inst.b.threadStart(model, cwd, systemPrompt, sessionMode, effort, mcpServers)
+	if err != nil {
+		return "", err
+	}
+	h.s.route(h, threadID)
+	return threadID, nil
+}
+
+func (h *sharedHandle) turnStart(threadID string, input []map[string]string, cwd, sessionMode string) (string, error) {
+	return h.inst.b.turnStart(threadID, input, cwd, sessionMode)
+}
+
+func (h *sharedHandle) turnInterrupt(threadID, turnID string) error {
+	return h.inst.b.turnInterrupt(threadID, turnID)
+}
+
+func (h *sharedHandle) respondToServerRequest(id int64, result any) {
+	h.inst.b.respondToServerRequest(id, result)
+}
+
+func (h *sharedHandle) request(method string, params any) (json.RawMessage, error) {
+	return h.inst.b.request(method, params)
+}
+
+func (h *sharedHandle) modelSnapshot() *acpsdk.SessionModelState {
+	return h.inst.b.modelSnapshot()
+}
+
+func (h *sharedHandle) availableCommandsSnapshot() []acpsdk.AvailableCommand {
+	return h.inst.b.availableCommandsSnapshot()
+}
+
+func (h *sharedHandle) setStderr(fn func(line string)) { h.onStderr = fn }
+
+func (h *sharedHandle) doneChan() <-chan struct{} {
+	return h.inst.b.doneChan()
+}
+
+// close detaches the session; the adapter may call it more than once.
+func (h *sharedHandle) close() {
+	h.once.Do(func() { h.s.release(h) })
+}
@@ -0,0 +1,181 @@
+package acp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+
+	acpsdk "github.com/coder/acp-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// threadBridge is a fakeBridge that hands out a new thread per thread/start
+// and records how often it was started and closed.
+type threadBridge struct {
+	fakeBridge
+	dispatch dispatchFunc
+	stderr   func(line string)
+
+	mu      sync.Mutex
+	threads int
+	starts  int
+	closes  int
+}
+
+func (b *threadBridge) start(context.Context, map[string]string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.starts++
+	return nil
+}
+
+func (b *threadBridge) threadStart(string, string, string, string, string, []acpsdk.McpServer) (string, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.threads++
+	return fmt.Sprintf("thread-%d", b.threads), nil
+}
+
+func (b *threadBridge) setStderr(fn func(string)) { b.stderr = fn }
+
+func (b *threadBridge) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closes++
+}
+
+func (b *threadBridge) closeCount() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closes
+}
+
+func newTestSharedAppServer() (*SharedAppServer, *[]*threadBridge) {
+	var bridges []*threadBridge
+	s := NewSharedAppServer(slog.New(slog.NewTextHandler(io.Discard, nil)))
+	s.newBridge = func(_ *slog.Logger, dispatch dispatchFunc) bridgeClient {
+		b := &threadBridge{dispatch: dispatch}
+		bridges = append(bridges, b)
+		return b
+	}
+	return s, &bridges
+}
+
+func newSharedTestSession(t *testing.T, s *SharedAppServer) (*Adapter, *fakeUpdateSender, acpsdk.SessionId) {
+	t.Helper()
+	a := s.NewAdapter(slog.New(slog.NewTextHandler(io.Discard, nil))).(*Adapter)
+	updater := &fakeUpdateSender{}
+	a.updater = updater
+	resp, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{Cwd: "/tmp"})
+	require.NoError(t, err)
+	return a, updater, resp.SessionId
+}
+
+func agentMessageTexts(updates []acpsdk.SessionNotification) map[acpsdk.SessionId][]string {
+	texts := make(map[acpsdk.SessionId][]string)
+	for _, u := range updates {
+		if u.Update.AgentMessageChunk != nil {
+			texts[u.SessionId] = append(texts[u.SessionId], u.Update.AgentMessageChunk.Content.Text.Text)
+		}
+	}
+	return texts
+}
+
+func TestSharedAppServer_RoutesByThread(t *testing.T) {
+	s, bridges := newTestSharedAppServer()
+
+	a1, updates1, session1 := newSharedTestSession(t, s)
+	a2, updates2, session2 := newSharedTestSession(t, s)
+	require.Len(t, *bridges, 1, "sessions share one app-server")
+	b := (*bridges)[0]
+	assert.Equal(t, 1, b.starts)
+	assert.NotEqual(t, session1, session2)
+
+	b.dispatch(string(session2), methodAgentMessageDelta, rawJSON(t, map[string]any{"delta": "for two"}), nil)
+	b.dispatch(string(session1), methodAgentMessageDelta, rawJSON(t, map[string]any{"delta": "for one"}), nil)
+	b.dispatch("thread-unknown", methodAgentMessageDelta, rawJSON(t, map[string]any{"delta": "dropped"}), nil)
+
+	assert.Equal(t, map[acpsdk.SessionId][]string{session1: {"for one"}}, agentMessageTexts(updates1.allUpdates()))
+	assert.Equal(t, map[acpsdk.SessionId][]string{session2: {"for two"}}, agentMessageTexts(updates2.allUpdates()))
+
+	require.NoError(t, a1.Close())
+	require.NoError(t, a1.Close())
+	assert.Equal(t, 0, b.closeCount(), "app-server stays up while a session uses it")
+
+	b.dispatch(string(session1), methodAgentMessageDelta, rawJSON(t, map[string]any{"delta": "late"}), nil)
+	b.dispatch(string(session2), methodAgentMessageDelta, rawJSON(t, map[string]any{"delta": "still there"}), nil)
+	assert.Len(t, updates1.allUpdates(), 1)
+	assert.Equal(t, []string{"for two", "still there"}, agentMessageTexts(updates2.allUpdates())[session2])
+
+	require.NoError(t, a2.Close())
+	assert.Equal(t, 1, b.closeCount(), "last session closes the app-server")
+}
+
+func TestSharedAppServer_RestartsAfterLastSession(t *testing.T) {
+	s, bridges := newTestSharedAppServer()
+
+	a1, _, _ := newSharedTestSession(t, s)
+	require.NoError(t, a1.Close())
+
+	a2, _, _ := newSharedTestSession(t, s)
+	require.Len(t, *bridges, 2)
+	assert.Equal(t, 1, (*bridges)[1].starts)
+	require.NoError(t, a2.Close())
+	assert.Equal(t, 1, (*bridges)[1].closeCount())
+}
+
+func TestSharedAppServer_RestartsAfterExit(t *testing.T) {
+	s, bridges := newTestSharedAppServer()
+
+	a1, _, _ := newSharedTestSession(t, s)
+	(*bridges)[0].doneChan()
+	close((*bridges)[0].done)
+
+	a2, _, _ := newSharedTestSession(t, s)
+	require.Len(t, *bridges, 2, "a dead app-server is not reused")
+
+	require.NoError(t, a2.Close())
+	assert.Equal(t, 1, (*bridges)[1].closeCount())
+	require.NoError(t, a1.Close())
+	assert.Equal(t, 1, (*bridges)[0].closeCount())
+}
+
+func TestSharedAppServer_SingleSessionGetsThreadlessMessages(t *testing.T) {
+	s, bridges := newTestSharedAppServer()
+
+	_, updates, sessionID := newSharedTestSession(t, s)
+	(*bridges)[0].dispatch("", methodAgentMessageDelta, json.RawMessage(`{"delta":"hi"}`), nil)
+
+	assert.Equal(t, map[acpsdk.SessionId][]string{sessionID: {"hi"}}, agentMessageTexts(updates.allUpdates()))
+}
+
+func TestSharedAppServer_StderrGoesToItsThread(t *testing.T) {
+	s, bridges := newTestSharedAppServer()
+	newStderrSession := func() (*Adapter, *[]string) {
+		var lines []string
+		a := s.NewAdapter(slog.New(slog.NewTextHandler(io.Discard, nil))).(*Adapter)
+		a.updater = &fakeUpdateSender{}
+		a.SetStderr(func(line string) { lines = append(lines, line) })
+		_, err := a.NewSession(context.Background(), acpsdk.NewSessionRequest{Cwd: "/tmp"})
+		require.NoError(t, err)
+		return a, &lines
+	}
+
+	a1, lines1 := newStderrSession()
+	b := (*bridges)[0]
+	b.stderr("starting up")
+	a2, lines2 := newStderrSession()
+	b.stderr("ERROR thread-2: token invalid")
+	b.stderr("ERROR thread-1: rate limited")
+	b.stderr("config reloaded")
+
+	assert.Equal(t, []string{"starting up", "ERROR thread-1: rate limited"}, *lines1)
+	assert.Equal(t, []string{"ERROR thread-2: token invalid"}, *lines2, "lines naming no thread stay out of a shared session's tail")
+	require.NoError(t, a1.Close())
+	require.NoError(t, a2.Close())
+}
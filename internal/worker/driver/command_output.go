@@ -0,0 +1,62 @@
+package driver
+
+import (
+	acp "github.com/coder/acp-go-sdk"
+)
+
+// commandOutputMetaKey is the tool call update _meta key carrying structured
+// command output. ACP has no content type for it, and content-block _meta is
+// dropped on the wire, so it travels on the update itself.
+const commandOutputMetaKey = "commandOutput"
+
+// CommandOutput is the structured result of an execute-kind tool call.
+type CommandOutput struct {
+	Stdout   string
+	Stderr   string
+	ExitCode *int // nil when the agent doesn't report one
+}
+
+// WithCommandOutput attaches out to a tool call update's _meta, preserving
+// any other _meta keys already set.
+func WithCommandOutput(out CommandOutput) acp.ToolCallUpdateOpt {
+	fields := map[string]any{
+		"stdout": out.Stdout,
+		"stderr": out.Stderr,
+	}
+	if out.ExitCode != nil {
+		fields["exit_code"] = *out.ExitCode
+	}
+	return func(u *acp.SessionToolCallUpdate) {
+		meta, ok := u.Meta.(map[string]any)
+		if !ok {
+			meta = map[string]any{}
+		}
+		meta[commandOutputMetaKey] = fields
+		u.Meta = meta
+	}
+}
+
+// ParseCommandOutput extracts structured command output from a tool call
+// update's _meta. It accepts both the in-memory form and the form that
+// results from a JSON round trip over an ACP connection.
+func ParseCommandOutput(meta any) (CommandOutput, bool) {
+	m, ok := meta.(map[string]any)
+	if !ok {
+		return CommandOutput{}, false
+	}
+	fields, ok := m[commandOutputMetaKey].(map[string]any)
+	if !ok {
+		return CommandOutput{}, false
+	}
+	var out CommandOutput
+	out.Stdout, _ = fields["stdout"].(string)
+	out.Stderr, _ = fields["stderr"].(string)
+	switch v := fields["exit_code"].(type) {
+	case int:
+		out.ExitCode = &v
+	case float64:
+		code := int(v)
+		out.ExitCode = &code
+	}
+	return out, true
+}
@@ -0,0 +1,60 @@
+package driver
+
+import (
+	"encoding/json"
+	"testing"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandOutput_RoundTrip(t *testing.T) {
+	code := 2
+	upd := acp.UpdateToolCall("tc-1",
+		acp.WithUpdateStatus(acp.ToolCallStatusFailed),
+		WithCommandOutput(CommandOutput{Stdout: "building\n", Stderr: "error: boom\n", ExitCode: &code}),
+	)
+
+	t.Run("in memory", func(t *testing.T) {
+		out, ok := ParseCommandOutput(upd.ToolCallUpdate.Meta)
+		require.True(t, ok)
+		assert.Equal(t, "building\n", out.Stdout)
+		assert.Equal(t, "error: boom\n", out.Stderr)
+		require.NotNil(t, out.ExitCode)
+		assert.Equal(t, 2, *out.ExitCode)
+	})
+
+	t.Run("over the wire", func(t *testing.T) {
+		b, err := json.Marshal(upd)
+		require.NoError(t, err)
+		var decoded acp.SessionUpdate
+		require.NoError(t, json.Unmarshal(b, &decoded))
+		require.NotNil(t, decoded.ToolCallUpdate)
+
+		out, ok := ParseCommandOutput(decoded.ToolCallUpdate.Meta)
+		require.True(t, ok)
+		assert.Equal(t, "error: boom\n", out.Stderr)
+		require.NotNil(t, out.ExitCode)
+		assert.Equal(t, 2, *out.ExitCode)
+	})
+}
+
+func TestParseCommandOutput_Missing(t *testing.T) {
+	_, ok := ParseCommandOutput(nil)
+	assert.False(t, ok)
+	_, ok = ParseCommandOutput(map[string]any{"claudeCode": map[string]any{}})
+	assert.False(t, ok)
+}
+
+func TestWithCommandOutput_PreservesExistingMeta(t *testing.T) {
+	upd := acp.UpdateToolCall("tc-1", func(u *acp.SessionToolCallUpdate) {
+		u.Meta = map[string]any{"other": true}
+	}, WithCommandOutput(CommandOutput{Stdout: "ok"}))
+
+	meta := upd.ToolCallUpdate.Meta.(map[string]any)
+	assert.Equal(t, true, meta["other"])
+	out, ok := ParseCommandOutput(meta)
+	require.True(t, ok)
+	assert.Nil(t, out.ExitCode)
+}
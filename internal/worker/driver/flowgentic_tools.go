@@ -0,0 +1,115 @@
+package driver
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// FlowgenticToolPrefix starts the names agents give the tools of
+// flowgentic's own MCP server, e.g. "mcp__flowgentic__set_topic".
+const FlowgenticToolPrefix = "mcp__flowgentic__"
+
+// planDirsPattern finds the plan dir count in plan_commit's text result.
+var planDirsPattern = regexp.MustCompile(`\((\d+) plan dirs?\)`)
+
+// FlowgenticToolSummary returns a one-line summary of the result of one of
+// flowgentic's own MCP tools, whose result schemas are defined by agentctl,
+// to show in place of the raw result. input holds the call's arguments and
+// result what the agent reported: an MCP CallToolResult, its structured
+// content, content blocks or text, decoded or as JSON. It reports false for
+// other tools, whose results stay raw JSON, and for results it cannot read.
+func FlowgenticToolSummary(toolName string, input, result any) (string, bool) {
+	tool, ok := strings.CutPrefix(toolName, FlowgenticToolPrefix)
+	if !ok {
+		return "", false
+	}
+	structured, text := readToolResult(result)
+	args, _ := decodeJSON(input).(map[string]any)
+	switch tool {
+	case "set_topic":
+		if topic := cmp.Or(stringField(structured, "topic"), stringField(args, "topic")); topic != "" {
+			return "Topic set to: " + topic, true
+		}
+	case "ask_question":
+		if answer := cmp.Or(stringField(structured, "answer"), text); answer != "" {
+			return "Answer: " + answer, true
+		}
+	case "plan_commit":
+		n := intField(structured, "submitted_plans")
+		if n == nil {
+			if m := planDirsPattern.FindStringSubmatch(text); m != nil {
+				if v, err := strconv.Atoi(m[1]); err == nil {
+					n = &v
+				}
+			}
+		}
+		if n != nil {
+			return fmt.Sprintf("Plan submitted: %d dirs", *n), true
+		}
+	}
+	return "", false
+}
+
+// readToolResult returns the structured content and the text of an MCP
+// tool result. A JSON object that is not a CallToolResult is taken to be
+// the structured content itself.
+func readToolResult(result any) (map[string]any, string) {
+	switch r := decodeJSON(result).(type) {
+	case string:
+		return nil, r
+	case []any:
+		return nil, contentText(r)
+	case map[string]any:
+		structured, hasStructured := r["structuredContent"].(map[string]any)
+		content, hasContent := r["content"].([]any)
+		if !hasStructured && !hasContent {
+			if text, ok := r["text"].(string); ok {
+				return nil, text
+			}
+			return r, ""
+		}
+		return structured, contentText(content)
+	}
+	return nil, ""
+}
+
+// contentText joins the text of MCP content blocks.
+func contentText(blocks []any) string {
+	var parts []string
+	for _, b := range blocks {
+		if m, ok := b.(map[string]any); ok {
+			if text, ok := m["text"].(string); ok {
+				parts = append(parts, text)
+			}
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// decodeJSON decodes v if it is a JSON object or array, given as text or
+// bytes, and returns other values unchanged, with bytes as a string.
+func decodeJSON(v any) any {
+	var data []byte
+	switch r := v.(type) {
+	case json.RawMessage:
+		data = r
+	case []byte:
+		data = r
+	case string:
+		data = []byte(r)
+	default:
+		return v
+	}
+	var decoded any
+	if err := json.Unmarshal(data, &decoded); err == nil {
+		switch decoded.(type) {
+		case map[string]any, []any:
+			return decoded
+		}
+	}
+	return string(data)
+}
@@ -0,0 +1,77 @@
+package driver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlowgenticToolSummary(t *testing.T) {
+	tests := []struct {
+		name   string
+		tool   string
+		input  any
+		result any
+		want   string
+	}{
+		{
+			name:   "set_topic from structured content",
+			tool:   "mcp__flowgentic__set_topic",
+			result: map[string]any{"content": textResult("Topic set successfully"), "structuredContent": map[string]any{"topic": "Fix login"}},
+			want:   "Topic set to: Fix login",
+		},
+		{
+			name:   "set_topic from the arguments",
+			tool:   "mcp__flowgentic__set_topic",
+			input:  map[string]any{"topic": "Fix login"},
+			result: textResult("Topic set successfully"),
+			want:   "Topic set to: Fix login",
+		},
+		{
+			name:   "ask_question from structured content",
+			tool:   "mcp__flowgentic__ask_question",
+			result: json.RawMessage(`{"content":[{"type":"text","text":"Yes"}],"structuredContent":{"question":"Keep v1?","answer":"Yes","mocked":true}}`),
+			want:   "Answer: Yes",
+		},
+		{
+			name:   "ask_question from the text",
+			tool:   "mcp__flowgentic__ask_question",
+			result: "42",
+			want:   "Answer: 42",
+		},
+		{
+			name:   "plan_commit from structured content",
+			tool:   "mcp__flowgentic__plan_commit",
+			result: map[string]any{"submitted_plans": float64(2)},
+			want:   "Plan submitted: 2 dirs",
+		},
+		{
+			name:   "plan_commit from the text",
+			tool:   "mcp__flowgentic__plan_commit",
+			result: textResult("Plan submitted successfully (3 plan dirs)"),
+			want:   "Plan submitted: 3 dirs",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := FlowgenticToolSummary(tt.tool, tt.input, tt.result)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFlowgenticToolSummary_Unknown(t *testing.T) {
+	_, ok := FlowgenticToolSummary("mcp__exa__web_search", nil, map[string]any{"topic": "x"})
+	assert.False(t, ok, "other servers' tools stay raw")
+	_, ok = FlowgenticToolSummary("mcp__flowgentic__plan_get_current_dir", nil, textResult("/plans/a"))
+	assert.False(t, ok, "tools without a summary stay raw")
+	_, ok = FlowgenticToolSummary("mcp__flowgentic__set_topic", nil, textResult("Topic set successfully"))
+	assert.False(t, ok, "no topic to show")
+}
+
+// textResult is an MCP result's content holding text.
+func textResult(text string) []any {
+	return []any{map[string]any{"type": "text", "text": text}}
+}
@@ -0,0 +1,38 @@
+package driver
+
+import (
+	"fmt"
+
+	acp "github.com/coder/acp-go-sdk"
+)
+
+// KindPermission is the default decision for permission requests of tools
+// of one ACP kind. Rules that name a tool take precedence over it.
+type KindPermission string
+
+const (
+	KindPermissionAsk   KindPermission = "ask"   // request permission as usual
+	KindPermissionAllow KindPermission = "allow" // allow without asking
+	KindPermissionDeny  KindPermission = "deny"  // deny without asking
+)
+
+// ParseKindPermissions validates a map from ACP tool kind ("read",
+// "execute", ...) to KindPermission and returns it typed.
+func ParseKindPermissions(m map[string]string) (map[acp.ToolKind]KindPermission, error) {
+	out := make(map[acp.ToolKind]KindPermission, len(m))
+	for kind, p := range m {
+		switch acp.ToolKind(kind) {
+		case acp.ToolKindRead, acp.ToolKindEdit, acp.ToolKindDelete, acp.ToolKindMove, acp.ToolKindSearch,
+			acp.ToolKindExecute, acp.ToolKindThink, acp.ToolKindFetch, acp.ToolKindSwitchMode, acp.ToolKindOther:
+		default:
+			return nil, fmt.Errorf("unknown tool kind: %q", kind)
+		}
+		switch KindPermission(p) {
+		case KindPermissionAsk, KindPermissionAllow, KindPermissionDeny:
+		default:
+			return nil, fmt.Errorf("unknown permission %q for tool kind %q", p, kind)
+		}
+		out[acp.ToolKind(kind)] = KindPermission(p)
+	}
+	return out, nil
+}
@@ -0,0 +1,24 @@
+package driver
+
+import (
+	"testing"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseKindPermissions(t *testing.T) {
+	got, err := ParseKindPermissions(map[string]string{"read": "allow", "edit": "ask", "execute": "deny"})
+	require.NoError(t, err)
+	assert.Equal(t, map[acp.ToolKind]KindPermission{
+		acp.ToolKindRead:    KindPermissionAllow,
+		acp.ToolKindEdit:    KindPermissionAsk,
+		acp.ToolKindExecute: KindPermissionDeny,
+	}, got)
+
+	_, err = ParseKindPermissions(map[string]string{"bash": "deny"})
+	assert.ErrorContains(t, err, `unknown tool kind: "bash"`)
+	_, err = ParseKindPermissions(map[string]string{"read": "allow-all"})
+	assert.ErrorContains(t, err, `unknown permission "allow-all"`)
+}
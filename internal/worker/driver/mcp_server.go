@@ -0,0 +1,17 @@
+package driver
+
+import acp "github.com/coder/acp-go-sdk"
+
+// MCPServerName returns the name of an MCP server of any transport.
+func MCPServerName(s acp.McpServer) string {
+	switch {
+	case s.Stdio != nil:
+		return s.Stdio.Name
+	case s.Http != nil:
+		return s.Http.Name
+	case s.Sse != nil:
+		return s.Sse.Name
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,52 @@
+package driver
+
+import acp "github.com/coder/acp-go-sdk"
+
+const mcpStartupMetaKey = "mcpStartup"
+
+// MCPStartup is a progress report for an MCP server the agent starts.
+type MCPStartup struct {
+	Server  string
+	Status  string
+	Message string
+	Ready   bool // the agent finished starting its MCP servers
+}
+
+// MCPStartupUpdate returns a thought update showing text to plain ACP
+// clients and carrying s in _meta for clients that render MCP startup apart
+// from the agent's reasoning.
+func MCPStartupUpdate(text string, s MCPStartup) acp.SessionUpdate {
+	u := acp.UpdateAgentThoughtText(text)
+	fields := map[string]any{"ready": s.Ready}
+	if s.Server != "" {
+		fields["server"] = s.Server
+	}
+	if s.Status != "" {
+		fields["status"] = s.Status
+	}
+	if s.Message != "" {
+		fields["message"] = s.Message
+	}
+	u.AgentThoughtChunk.Meta = map[string]any{mcpStartupMetaKey: fields}
+	return u
+}
+
+// ParseMCPStartup extracts an MCP startup report from a thought chunk's
+// _meta. Like ParseCommandOutput, it accepts both the in-memory and the JSON
+// round-tripped form.
+func ParseMCPStartup(meta any) (MCPStartup, bool) {
+	m, ok := meta.(map[string]any)
+	if !ok {
+		return MCPStartup{}, false
+	}
+	fields, ok := m[mcpStartupMetaKey].(map[string]any)
+	if !ok {
+		return MCPStartup{}, false
+	}
+	var s MCPStartup
+	s.Server, _ = fields["server"].(string)
+	s.Status, _ = fields["status"].(string)
+	s.Message, _ = fields["message"].(string)
+	s.Ready, _ = fields["ready"].(bool)
+	return s, true
+}
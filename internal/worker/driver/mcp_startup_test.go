@@ -0,0 +1,33 @@
+package driver
+
+import (
+	"encoding/json"
+	"testing"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMCPStartup_RoundTrip(t *testing.T) {
+	upd := MCPStartupUpdate("[mcp startup] flowgentic - starting", MCPStartup{Server: "flowgentic", Status: "starting"})
+	require.NotNil(t, upd.AgentThoughtChunk)
+	assert.Equal(t, "[mcp startup] flowgentic - starting", upd.AgentThoughtChunk.Content.Text.Text, "plain clients see the text")
+
+	b, err := json.Marshal(upd)
+	require.NoError(t, err)
+	var decoded acp.SessionUpdate
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	require.NotNil(t, decoded.AgentThoughtChunk)
+
+	s, ok := ParseMCPStartup(decoded.AgentThoughtChunk.Meta)
+	require.True(t, ok)
+	assert.Equal(t, MCPStartup{Server: "flowgentic", Status: "starting"}, s)
+}
+
+func TestParseMCPStartup_Missing(t *testing.T) {
+	_, ok := ParseMCPStartup(nil)
+	assert.False(t, ok)
+	_, ok = ParseMCPStartup(acp.UpdateAgentThoughtText("thinking").AgentThoughtChunk.Meta)
+	assert.False(t, ok)
+}
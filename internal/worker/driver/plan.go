@@ -0,0 +1,91 @@
+package driver
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidPlan is returned by ParsePlans for submissions that are not a
+// well-formed plan payload.
+var ErrInvalidPlan = errors.New("invalid plan")
+
+// planPayloadVersion is the payload version `agentctl plan commit` sends.
+const planPayloadVersion = 1
+
+// Plan is one thread's plan as submitted by `agentctl plan commit`.
+type Plan struct {
+	ThreadID string
+	Title    string
+	Body     string
+	Steps    []PlanStep
+}
+
+// PlanStep is one task of a plan, read from a file in the plan's tasks
+// directory.
+type PlanStep struct {
+	ID          string
+	File        string // task file name, e.g. "01-setup.md"
+	Description string
+	DependsOn   []string
+	Agent       string
+	Subtasks    []string
+}
+
+// planPayload mirrors the JSON document `agentctl plan commit` submits.
+type planPayload struct {
+	Version int `json:"version"`
+	Plans   []struct {
+		ThreadID string `json:"thread_id"`
+		Plan     struct {
+			Title string `json:"title"`
+			Body  string `json:"body"`
+		} `json:"plan"`
+		Tasks []struct {
+			Filename    string   `json:"filename"`
+			ID          string   `json:"id"`
+			DependsOn   []string `json:"depends_on"`
+			Agent       string   `json:"agent"`
+			Subtasks    []string `json:"subtasks"`
+			Description string   `json:"description"`
+		} `json:"tasks"`
+	} `json:"plans"`
+}
+
+// ParsePlans decodes a plan submission. Errors wrap ErrInvalidPlan.
+func ParsePlans(data []byte) ([]Plan, error) {
+	var p planPayload
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPlan, err)
+	}
+	if p.Version != planPayloadVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidPlan, p.Version)
+	}
+	if len(p.Plans) == 0 {
+		return nil, fmt.Errorf("%w: no plans", ErrInvalidPlan)
+	}
+
+	plans := make([]Plan, 0, len(p.Plans))
+	for i, u := range p.Plans {
+		if strings.TrimSpace(u.Plan.Title) == "" {
+			return nil, fmt.Errorf("%w: plan %d has no title", ErrInvalidPlan, i)
+		}
+		plan := Plan{ThreadID: u.ThreadID, Title: u.Plan.Title, Body: u.Plan.Body}
+		for _, t := range u.Tasks {
+			if t.ID == "" {
+				return nil, fmt.Errorf("%w: plan %q has a task without id", ErrInvalidPlan, u.Plan.Title)
+			}
+			plan.Steps = append(plan.Steps, PlanStep{
+				ID:          t.ID,
+				File:        t.Filename,
+				Description: t.Description,
+				DependsOn:   t.DependsOn,
+				Agent:       t.Agent,
+				Subtasks:    t.Subtasks,
+			})
+		}
+		plans = append(plans, plan)
+	}
+	return plans, nil
+}
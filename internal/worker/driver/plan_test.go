@@ -0,0 +1,45 @@
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePlans(t *testing.T) {
+	payload := `{"version":1,"plans":[{
+		"thread_id":"th-1","plan_dir":"/tmp/plan",
+		"plan":{"title":"Add caching","body":"Cache lookups."},
+		"tasks":[
+			{"filename":"01-store.md","id":"store","description":"Add the store."},
+			{"filename":"02-wire.md","id":"wire","depends_on":["store"],"agent":"codex","subtasks":["a","b"],"description":"Wire it up."}
+		]}]}`
+
+	plans, err := ParsePlans([]byte(payload))
+	require.NoError(t, err)
+	assert.Equal(t, []Plan{{
+		ThreadID: "th-1",
+		Title:    "Add caching",
+		Body:     "Cache lookups.",
+		Steps: []PlanStep{
+			{ID: "store", File: "01-store.md", Description: "Add the store."},
+			{ID: "wire", File: "02-wire.md", Description: "Wire it up.", DependsOn: []string{"store"}, Agent: "codex", Subtasks: []string{"a", "b"}},
+		},
+	}}, plans)
+}
+
+func TestParsePlans_Invalid(t *testing.T) {
+	for name, payload := range map[string]string{
+		"not json":        "# My Plan\n\n1. Do stuff",
+		"unknown version": `{"version":2,"plans":[{"plan":{"title":"x"}}]}`,
+		"no plans":        `{"version":1,"plans":[]}`,
+		"missing title":   `{"version":1,"plans":[{"plan":{"title":" "}}]}`,
+		"task without id": `{"version":1,"plans":[{"plan":{"title":"x"},"tasks":[{"filename":"01-a.md"}]}]}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := ParsePlans([]byte(payload))
+			assert.ErrorIs(t, err, ErrInvalidPlan)
+		})
+	}
+}
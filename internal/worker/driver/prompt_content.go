@@ -0,0 +1,46 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+
+	acp "github.com/coder/acp-go-sdk"
+)
+
+// invalidParamsCode is the JSON-RPC error code for invalid method params.
+const invalidParamsCode = -32602
+
+// NewInvalidPromptError returns the ACP "invalid params" error an adapter
+// should report for a prompt its agent cannot take: one without content or
+// with blocks of a type the agent does not support. Like
+// NewAuthRequiredError, it must be returned unwrapped so the ACP connection
+// keeps the error code.
+func NewInvalidPromptError(message string) *acp.RequestError {
+	return acp.NewInvalidParams(map[string]any{"message": message})
+}
+
+// InvalidPromptMessage reports whether err is an ACP "invalid params" error,
+// the agent's rejection of a prompt, and returns its message.
+func InvalidPromptMessage(err error) (string, bool) {
+	var re *acp.RequestError
+	if !errors.As(err, &re) || re.Code != invalidParamsCode {
+		return "", false
+	}
+	if data, ok := re.Data.(map[string]any); ok {
+		if msg, ok := data["message"].(string); ok && msg != "" {
+			return msg, true
+		}
+	}
+	return re.Message, true
+}
+
+// ResourcePromptText returns an embedded text resource as prompt text, its
+// contents wrapped in a tag naming its URI, for agents that take files only
+// as text. It reports false for binary resources.
+func ResourcePromptText(r *acp.ContentBlockResource) (string, bool) {
+	if r == nil || r.Resource.TextResourceContents == nil {
+		return "", false
+	}
+	t := r.Resource.TextResourceContents
+	return fmt.Sprintf("<resource uri=%q>\n%s\n</resource>", t.Uri, t.Text), true
+}
@@ -0,0 +1,23 @@
+package driver
+
+import "fmt"
+
+// ReasoningEffort is a standardised level of extended thinking an agent
+// should spend before answering. Each agent maps it onto its own setting.
+type ReasoningEffort string
+
+const (
+	ReasoningEffortLow    ReasoningEffort = "low"
+	ReasoningEffortMedium ReasoningEffort = "medium"
+	ReasoningEffortHigh   ReasoningEffort = "high"
+)
+
+// ParseReasoningEffort validates and returns a ReasoningEffort from a string.
+func ParseReasoningEffort(s string) (ReasoningEffort, error) {
+	switch ReasoningEffort(s) {
+	case ReasoningEffortLow, ReasoningEffortMedium, ReasoningEffortHigh:
+		return ReasoningEffort(s), nil
+	default:
+		return "", fmt.Errorf("unknown reasoning effort: %q", s)
+	}
+}
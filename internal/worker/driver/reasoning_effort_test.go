@@ -0,0 +1,21 @@
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReasoningEffort(t *testing.T) {
+	for _, s := range []string{"low", "medium", "high"} {
+		effort, err := ParseReasoningEffort(s)
+		require.NoError(t, err)
+		assert.Equal(t, ReasoningEffort(s), effort)
+	}
+
+	for _, s := range []string{"", "HIGH", "max"} {
+		_, err := ParseReasoningEffort(s)
+		assert.Error(t, err, s)
+	}
+}
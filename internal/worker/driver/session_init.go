@@ -0,0 +1,71 @@
+package driver
+
+import (
+	"fmt"
+	"strings"
+
+	acp "github.com/coder/acp-go-sdk"
+)
+
+const sessionInitMetaKey = "sessionInit"
+
+// SessionInit is the configuration the agent reports it started the session
+// with, which may differ from the one requested.
+type SessionInit struct {
+	Model          string   `json:"model,omitempty"`           // effective model
+	PermissionMode string   `json:"permission_mode,omitempty"` // agent's own name for it
+	Tools          []string `json:"tools,omitempty"`           // tools enabled, in the agent's order
+}
+
+// SessionInitUpdate returns a thought update showing a summary of s to
+// plain ACP clients and carrying s in _meta for clients that record it
+// apart from the agent's reasoning.
+func SessionInitUpdate(s SessionInit) acp.SessionUpdate {
+	var parts []string
+	if s.Model != "" {
+		parts = append(parts, "model "+s.Model)
+	}
+	if s.PermissionMode != "" {
+		parts = append(parts, "permission mode "+s.PermissionMode)
+	}
+	parts = append(parts, fmt.Sprintf("%d tools", len(s.Tools)))
+	u := acp.UpdateAgentThoughtText("Session started: " + strings.Join(parts, ", "))
+
+	fields := map[string]any{"tools": s.Tools}
+	if s.Model != "" {
+		fields["model"] = s.Model
+	}
+	if s.PermissionMode != "" {
+		fields["permissionMode"] = s.PermissionMode
+	}
+	u.AgentThoughtChunk.Meta = map[string]any{sessionInitMetaKey: fields}
+	return u
+}
+
+// ParseSessionInit extracts a session init record from a thought chunk's
+// _meta. Like ParseMCPStartup, it accepts both the in-memory and the JSON
+// round-tripped form.
+func ParseSessionInit(meta any) (SessionInit, bool) {
+	m, ok := meta.(map[string]any)
+	if !ok {
+		return SessionInit{}, false
+	}
+	fields, ok := m[sessionInitMetaKey].(map[string]any)
+	if !ok {
+		return SessionInit{}, false
+	}
+	var s SessionInit
+	s.Model, _ = fields["model"].(string)
+	s.PermissionMode, _ = fields["permissionMode"].(string)
+	switch tools := fields["tools"].(type) {
+	case []string:
+		s.Tools = tools
+	case []any:
+		for _, t := range tools {
+			if name, ok := t.(string); ok {
+				s.Tools = append(s.Tools, name)
+			}
+		}
+	}
+	return s, true
+}
@@ -0,0 +1,38 @@
+package driver
+
+import (
+	"encoding/json"
+	"testing"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionInit_RoundTrip(t *testing.T) {
+	want := SessionInit{Model: "claude-sonnet-4-5", PermissionMode: "default", Tools: []string{"Bash", "Read"}}
+	upd := SessionInitUpdate(want)
+	require.NotNil(t, upd.AgentThoughtChunk)
+	assert.Equal(t, "Session started: model claude-sonnet-4-5, permission mode default, 2 tools", upd.AgentThoughtChunk.Content.Text.Text, "plain clients see a summary")
+
+	s, ok := ParseSessionInit(upd.AgentThoughtChunk.Meta)
+	require.True(t, ok)
+	assert.Equal(t, want, s)
+
+	b, err := json.Marshal(upd)
+	require.NoError(t, err)
+	var decoded acp.SessionUpdate
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	require.NotNil(t, decoded.AgentThoughtChunk)
+
+	s, ok = ParseSessionInit(decoded.AgentThoughtChunk.Meta)
+	require.True(t, ok)
+	assert.Equal(t, want, s)
+}
+
+func TestParseSessionInit_Missing(t *testing.T) {
+	_, ok := ParseSessionInit(nil)
+	assert.False(t, ok)
+	_, ok = ParseSessionInit(MCPStartupUpdate("[mcp startup] done", MCPStartup{Ready: true}).AgentThoughtChunk.Meta)
+	assert.False(t, ok)
+}
@@ -0,0 +1,56 @@
+package driver
+
+import "sync"
+
+// DefaultStderrTailLines is how many stderr lines a StderrTail keeps when no
+// size is configured.
+const DefaultStderrTailLines = 50
+
+// StderrTail keeps the last lines an agent process wrote to stderr, which
+// often name the real cause when the agent fails. It is safe for concurrent
+// use; a nil StderrTail keeps nothing.
+type StderrTail struct {
+	mu    sync.Mutex
+	lines []string
+	next  int // index of the oldest line once lines is full
+	max   int
+}
+
+// NewStderrTail returns a StderrTail keeping up to n lines. n <= 0 uses
+// DefaultStderrTailLines.
+func NewStderrTail(n int) *StderrTail {
+	if n <= 0 {
+		n = DefaultStderrTailLines
+	}
+	return &StderrTail{max: n}
+}
+
+// Add records line, dropping the oldest line if the tail is full.
+func (t *StderrTail) Add(line string) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.lines) < t.max {
+		t.lines = append(t.lines, line)
+		return
+	}
+	t.lines[t.next] = line
+	t.next = (t.next + 1) % t.max
+}
+
+// Lines returns the kept lines, oldest first, or nil if there are none.
+func (t *StderrTail) Lines() []string {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.lines) == 0 {
+		return nil
+	}
+	out := make([]string, 0, len(t.lines))
+	out = append(out, t.lines[t.next:]...)
+	return append(out, t.lines[:t.next]...)
+}
@@ -0,0 +1,27 @@
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStderrTail_KeepsLastLines(t *testing.T) {
+	tail := NewStderrTail(3)
+	assert.Nil(t, tail.Lines())
+
+	tail.Add("one")
+	tail.Add("two")
+	assert.Equal(t, []string{"one", "two"}, tail.Lines())
+
+	tail.Add("three")
+	tail.Add("four")
+	tail.Add("five")
+	assert.Equal(t, []string{"three", "four", "five"}, tail.Lines())
+}
+
+func TestStderrTail_Nil(t *testing.T) {
+	var tail *StderrTail
+	tail.Add("ignored")
+	assert.Nil(t, tail.Lines())
+}
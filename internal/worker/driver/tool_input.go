@@ -0,0 +1,95 @@
+package driver
+
+// ToolInput is the parsed input of a well-known tool. Only the fields of
+// Tool are set.
+type ToolInput struct {
+	Tool string // "Read", "Write", "Edit", "Bash", "Grep" or "Glob"
+
+	FilePath string // Read, Write, Edit
+	Offset   *int   // Read
+	Limit    *int   // Read
+	Content  string // Write
+
+	OldString  string // Edit
+	NewString  string // Edit
+	ReplaceAll bool   // Edit
+
+	Command         string // Bash
+	Description     string // Bash
+	TimeoutMs       *int   // Bash
+	RunInBackground bool   // Bash
+
+	Pattern         string // Grep, Glob
+	Path            string // Grep, Glob
+	Glob            string // Grep
+	Type            string // Grep
+	OutputMode      string // Grep
+	CaseInsensitive bool   // Grep
+}
+
+// ToolNameFromMeta returns the agent's tool name from a tool call's _meta,
+// or "" if it carries none. Claude Code reports it as claudeCode.toolName.
+func ToolNameFromMeta(meta any) string {
+	cc, _ := wireMeta(meta)["claudeCode"].(map[string]any)
+	name, _ := cc["toolName"].(string)
+	return name
+}
+
+// ParseToolInput parses the raw input of a well-known tool. It reports false
+// for other tools and for input that is not a JSON object.
+func ParseToolInput(toolName string, rawInput any) (ToolInput, bool) {
+	in, ok := rawInput.(map[string]any)
+	if !ok {
+		return ToolInput{}, false
+	}
+	ti := ToolInput{Tool: toolName}
+	switch toolName {
+	case "Read":
+		ti.FilePath = stringField(in, "file_path")
+		ti.Offset = intField(in, "offset")
+		ti.Limit = intField(in, "limit")
+	case "Write":
+		ti.FilePath = stringField(in, "file_path")
+		ti.Content = stringField(in, "content")
+	case "Edit":
+		ti.FilePath = stringField(in, "file_path")
+		ti.OldString = stringField(in, "old_string")
+		ti.NewString = stringField(in, "new_string")
+		ti.ReplaceAll, _ = in["replace_all"].(bool)
+	case "Bash":
+		ti.Command = stringField(in, "command")
+		ti.Description = stringField(in, "description")
+		ti.TimeoutMs = intField(in, "timeout")
+		ti.RunInBackground, _ = in["run_in_background"].(bool)
+	case "Grep":
+		ti.Pattern = stringField(in, "pattern")
+		ti.Path = stringField(in, "path")
+		ti.Glob = stringField(in, "glob")
+		ti.Type = stringField(in, "type")
+		ti.OutputMode = stringField(in, "output_mode")
+		ti.CaseInsensitive, _ = in["-i"].(bool)
+	case "Glob":
+		ti.Pattern = stringField(in, "pattern")
+		ti.Path = stringField(in, "path")
+	default:
+		return ToolInput{}, false
+	}
+	return ti, true
+}
+
+func stringField(in map[string]any, key string) string {
+	s, _ := in[key].(string)
+	return s
+}
+
+// intField accepts both in-memory ints and JSON-decoded float64s.
+func intField(in map[string]any, key string) *int {
+	switch v := in[key].(type) {
+	case int:
+		return &v
+	case float64:
+		n := int(v)
+		return &n
+	}
+	return nil
+}
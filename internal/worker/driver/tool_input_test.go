@@ -0,0 +1,94 @@
+package driver
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func intPtr(n int) *int { return &n }
+
+func TestParseToolInput(t *testing.T) {
+	tests := []struct {
+		tool  string
+		input string
+		want  ToolInput
+	}{
+		{
+			tool:  "Read",
+			input: `{"file_path": "/src/main.go", "offset": 10, "limit": 50}`,
+			want:  ToolInput{Tool: "Read", FilePath: "/src/main.go", Offset: intPtr(10), Limit: intPtr(50)},
+		},
+		{
+			tool:  "Write",
+			input: `{"file_path": "/src/new.go", "content": "package main\n"}`,
+			want:  ToolInput{Tool: "Write", FilePath: "/src/new.go", Content: "package main\n"},
+		},
+		{
+			tool:  "Edit",
+			input: `{"file_path": "/src/main.go", "old_string": "foo", "new_string": "bar", "replace_all": true}`,
+			want:  ToolInput{Tool: "Edit", FilePath: "/src/main.go", OldString: "foo", NewString: "bar", ReplaceAll: true},
+		},
+		{
+			tool:  "Bash",
+			input: `{"command": "go test ./...", "description": "Run tests", "timeout": 60000, "run_in_background": true}`,
+			want:  ToolInput{Tool: "Bash", Command: "go test ./...", Description: "Run tests", TimeoutMs: intPtr(60000), RunInBackground: true},
+		},
+		{
+			tool:  "Grep",
+			input: `{"pattern": "func main", "path": "cmd", "glob": "*.go", "type": "go", "output_mode": "content", "-i": true}`,
+			want:  ToolInput{Tool: "Grep", Pattern: "func main", Path: "cmd", Glob: "*.go", Type: "go", OutputMode: "content", CaseInsensitive: true},
+		},
+		{
+			tool:  "Glob",
+			input: `{"pattern": "**/*.go", "path": "internal"}`,
+			want:  ToolInput{Tool: "Glob", Pattern: "**/*.go", Path: "internal"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.tool, func(t *testing.T) {
+			var raw map[string]any
+			require.NoError(t, json.Unmarshal([]byte(tt.input), &raw))
+
+			got, ok := ParseToolInput(tt.tool, raw)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseToolInput_InMemoryInts(t *testing.T) {
+	got, ok := ParseToolInput("Read", map[string]any{"file_path": "a.go", "limit": 5})
+	require.True(t, ok)
+	require.NotNil(t, got.Limit)
+	assert.Equal(t, 5, *got.Limit)
+	assert.Nil(t, got.Offset)
+}
+
+func TestParseToolInput_Unknown(t *testing.T) {
+	_, ok := ParseToolInput("WebFetch", map[string]any{"url": "https://example.com"})
+	assert.False(t, ok, "unknown tool")
+
+	_, ok = ParseToolInput("Bash", `{"command": "ls"}`)
+	assert.False(t, ok, "input must be an object")
+
+	_, ok = ParseToolInput("", map[string]any{"command": "ls"})
+	assert.False(t, ok, "no tool name")
+}
+
+func TestToolNameFromMeta(t *testing.T) {
+	type claudeCodeMeta struct {
+		ClaudeCode struct {
+			ToolName string `json:"toolName"`
+		} `json:"claudeCode"`
+	}
+	var typed claudeCodeMeta
+	typed.ClaudeCode.ToolName = "Bash"
+
+	assert.Equal(t, "Bash", ToolNameFromMeta(typed), "in memory")
+	assert.Equal(t, "Grep", ToolNameFromMeta(map[string]any{"claudeCode": map[string]any{"toolName": "Grep"}}), "over the wire")
+	assert.Empty(t, ToolNameFromMeta(nil))
+	assert.Empty(t, ToolNameFromMeta(map[string]any{"commandOutput": map[string]any{}}))
+}
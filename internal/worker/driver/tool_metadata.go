@@ -0,0 +1,62 @@
+package driver
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+)
+
+// ToolMetadata flattens a tool call's _meta into string pairs clients can
+// use as rendering hints, e.g. claudeCode.toolName=Bash. Nested objects
+// become dotted keys and arrays their JSON encoding. Command output is left
+// out, as it is reported as content. It returns nil if _meta has no values.
+func ToolMetadata(meta any) map[string]string {
+	m := wireMeta(meta)
+	delete(m, commandOutputMetaKey)
+	out := make(map[string]string)
+	flattenMeta(out, "", m)
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+func flattenMeta(out map[string]string, prefix string, m map[string]any) {
+	for k, v := range m {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case nil:
+		case string:
+			out[key] = v
+		case map[string]any:
+			flattenMeta(out, key, v)
+		case []any:
+			b, _ := json.Marshal(v)
+			out[key] = string(b)
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// wireMeta returns _meta in the form it has after a JSON round trip over an
+// ACP connection, or nil if it is not an object. The result may be
+// modified.
+func wireMeta(meta any) map[string]any {
+	if m, ok := meta.(map[string]any); ok {
+		return maps.Clone(m)
+	}
+	if meta == nil {
+		return nil
+	}
+	// In-process adapters attach typed _meta; normalize it to the wire form.
+	var m map[string]any
+	b, err := json.Marshal(meta)
+	if err != nil || json.Unmarshal(b, &m) != nil {
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,38 @@
+package driver
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolMetadata(t *testing.T) {
+	type claudeCodeMeta struct {
+		ClaudeCode struct {
+			ToolName string `json:"toolName"`
+		} `json:"claudeCode"`
+	}
+	var typed claudeCodeMeta
+	typed.ClaudeCode.ToolName = "Bash"
+	assert.Equal(t, map[string]string{"claudeCode.toolName": "Bash"}, ToolMetadata(typed), "in memory")
+
+	wire := map[string]any{
+		"claudeCode":    map[string]any{"toolName": "Grep"},
+		"background":    true,
+		"severity":      float64(2),
+		"tags":          []any{"a", "b"},
+		"empty":         nil,
+		"commandOutput": map[string]any{"stdout": "lots of output"},
+	}
+	assert.Equal(t, map[string]string{
+		"claudeCode.toolName": "Grep",
+		"background":          "true",
+		"severity":            "2",
+		"tags":                `["a","b"]`,
+	}, ToolMetadata(wire), "over the wire")
+	assert.Contains(t, wire, "commandOutput", "the caller's _meta is not modified")
+
+	assert.Nil(t, ToolMetadata(nil))
+	assert.Nil(t, ToolMetadata("not an object"))
+	assert.Nil(t, ToolMetadata(map[string]any{"commandOutput": map[string]any{}}))
+}
@@ -0,0 +1,39 @@
+package driver
+
+const turnUsageMetaKey = "usage"
+
+// TurnUsage is the token usage an agent reports for one prompt turn.
+type TurnUsage struct {
+	InputTokens  int64 `json:"input_tokens,omitempty"`
+	OutputTokens int64 `json:"output_tokens,omitempty"`
+}
+
+// TurnUsageMeta returns the _meta of a prompt response reporting u.
+func TurnUsageMeta(u TurnUsage) map[string]any {
+	return map[string]any{turnUsageMetaKey: map[string]any{
+		"inputTokens":  int(u.InputTokens),
+		"outputTokens": int(u.OutputTokens),
+	}}
+}
+
+// ParseTurnUsage extracts the token usage from a prompt response's _meta.
+// Like ParseMCPStartup, it accepts both the in-memory and the JSON
+// round-tripped form.
+func ParseTurnUsage(meta any) (TurnUsage, bool) {
+	m, ok := meta.(map[string]any)
+	if !ok {
+		return TurnUsage{}, false
+	}
+	fields, ok := m[turnUsageMetaKey].(map[string]any)
+	if !ok {
+		return TurnUsage{}, false
+	}
+	var u TurnUsage
+	if n := intField(fields, "inputTokens"); n != nil {
+		u.InputTokens = int64(*n)
+	}
+	if n := intField(fields, "outputTokens"); n != nil {
+		u.OutputTokens = int64(*n)
+	}
+	return u, true
+}
@@ -0,0 +1,29 @@
+package driver
+
+import (
+	"encoding/json"
+	"testing"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTurnUsage_RoundTrip(t *testing.T) {
+	want := TurnUsage{InputTokens: 1200, OutputTokens: 345}
+	resp := acp.PromptResponse{StopReason: acp.StopReasonEndTurn, Meta: TurnUsageMeta(want)}
+	u, ok := ParseTurnUsage(resp.Meta)
+	require.True(t, ok)
+	assert.Equal(t, want, u)
+
+	b, err := json.Marshal(resp)
+	require.NoError(t, err)
+	var decoded acp.PromptResponse
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	u, ok = ParseTurnUsage(decoded.Meta)
+	require.True(t, ok)
+	assert.Equal(t, want, u)
+
+	_, ok = ParseTurnUsage(nil)
+	assert.False(t, ok)
+}
@@ -0,0 +1,84 @@
+package v2
+
+import (
+	"cmp"
+	"context"
+	"strings"
+
+	acp "github.com/coder/acp-go-sdk"
+)
+
+// defaultAutoContinuePrompt is sent when AutoContinue.Prompt is empty.
+const defaultAutoContinuePrompt = "Continue."
+
+const autoContinueMetaKey = "autoContinue"
+
+// AutoContinue makes a session prompt the agent again by itself after a turn
+// ends normally, for autonomous work that takes several turns. The count
+// starts over with every prompt from the user, and a prompt from the user
+// cancels the auto-continued turn in flight and ends the run.
+type AutoContinue struct {
+	Prompt        string // sent to continue; empty uses "Continue."
+	MaxIterations int    // prompts sent per user prompt at most; zero disables
+	StopPhrase    string // the agent's reply containing it ends the run; empty never does
+	// Wrap, if set, turns Prompt into the text the agent is sent, e.g. to
+	// add the standing instructions user prompts get. The emitted user
+	// message carries Prompt unwrapped.
+	Wrap func(prompt string) string
+}
+
+// AutoContinueIteration reports whether a user message update was sent by
+// AutoContinue rather than the user, and which of its prompts it was,
+// counting from 1.
+func AutoContinueIteration(u *acp.SessionUpdateUserMessageChunk) (int, bool) {
+	m, ok := u.Meta.(map[string]any)
+	if !ok {
+		return 0, false
+	}
+	iteration, ok := m[autoContinueMetaKey].(int)
+	return iteration, ok
+}
+
+// autoContinue prompts the agent with ac.Prompt while the previous turn, whose
+// response is resp, ended with end_turn, its reply lacks ac.StopPhrase and
+// fewer than ac.MaxIterations prompts were sent, the session is not
+// soft-stopped and no user prompt is waiting. Each prompt is emitted as a
+// user message marked with its iteration first. It returns the error of a
+// failed turn.
+func (d *acpDriver) autoContinue(ctx context.Context, sess *acpSession, conn *acp.ClientSideConnection, sessionID acp.SessionId, resp *acp.PromptResponse, ac AutoContinue) error {
+	prompt := cmp.Or(ac.Prompt, defaultAutoContinuePrompt)
+	sent := prompt
+	if ac.Wrap != nil {
+		sent = ac.Wrap(prompt)
+	}
+	for i := 1; i <= ac.MaxIterations; i++ {
+		if resp == nil || resp.StopReason != acp.StopReasonEndTurn || sess.softStopped() {
+			return nil
+		}
+		// runTurn returned after the turn's updates, so the reply is complete.
+		if ac.StopPhrase != "" && strings.Contains(sess.client.turnReply(), ac.StopPhrase) {
+			d.log.Info("agent signalled it is done", "iterations", i-1)
+			return nil
+		}
+		if !sess.startAutoTurn() {
+			d.log.Info("user prompt preempts auto-continue", "iterations", i-1)
+			return nil
+		}
+
+		d.log.Info("auto-continuing session", "iteration", i, "max_iterations", ac.MaxIterations)
+		u := acp.UpdateUserMessageText(prompt)
+		u.UserMessageChunk.Meta = map[string]any{autoContinueMetaKey: i}
+		sess.client.emit(acp.SessionNotification{SessionId: sessionID, Update: u})
+
+		var err error
+		resp, err = d.runTurn(ctx, nil, sess, conn, sessionID, []acp.ContentBlock{acp.TextBlock(sent)})
+		sess.autoTurn.Store(false)
+		if err != nil {
+			if ctx.Err() == nil {
+				d.log.Warn("auto-continue prompt failed", "iteration", i, "error", err)
+			}
+			return err
+		}
+	}
+	return nil
+}
@@ -3,120 +3,318 @@ package v2
 import (
 	"context"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	acp "github.com/coder/acp-go-sdk"
 	"github.com/sebastianm/flowgentic/internal/worker/driver"
+	"github.com/sebastianm/flowgentic/internal/worker/metrics"
 )
 
 // flowgenticClient implements acp.Client.
 // It forwards session updates to the EventCallback and handles permission requests
-// by blocking until RespondToPermission is called.
+// by blocking until RespondToPermission is called. Requests that arrive within
+// batchWindow of each other are surfaced together as one batch event.
 type flowgenticClient struct {
-	onEvent     EventCallback
-	handlers    *ClientHandlers
-	sessionMode driver.SessionMode
+	onEvent      EventCallback
+	onPermission PermissionCallback
+	handlers     *ClientHandlers
+	sessionMode  driver.SessionMode
+	metrics      metrics.Metrics
+	agentID      string
+	batchWindow  time.Duration
 
 	mu          sync.Mutex
 	permissions map[string]chan bool // requestID -> response channel
+	queued      []queuedPermission   // waiting for the batch window to close
+	batches     map[string]*permissionBatch
+	// stopping is set by softStop; permission requests are denied from
+	// then on.
+	stopping bool
+	// onStopSettled is set by softStop and called, once, when no tool call
+	// of the turn is running any more.
+	onStopSettled func()
+
+	// activeTools holds the tool calls of the current turn, true once they
+	// completed or failed, so a turn that ends without finishing them can
+	// close them. Finished calls stay until the turn ends because the
+	// connection may deliver a call's updates out of order.
+	activeTools map[acp.ToolCallId]bool
+
+	// reply collects the agent's message text of the current turn.
+	reply strings.Builder
+	// turnStart and firstToken time the current turn for its TurnStats;
+	// firstToken is zero until the agent's first output arrives.
+	turnStart  time.Time
+	firstToken time.Time
+
+	// updatesRead and updatesHandled count the session updates read from
+	// the connection and handled by SessionUpdate; updatesChanged is closed
+	// and replaced whenever one is handled. See awaitUpdates.
+	updatesRead    int64
+	updatesHandled int64
+	updatesChanged chan struct{}
+
+	// plansRead numbers the plan updates in the order they were read from
+	// the connection, and planSeqs queues those numbers by planKey until
+	// SessionUpdate handles the update. See planRead.
+	plansRead int64
+	planSeqs  map[string][]int64
+	planMu    sync.Mutex
+
+	// onPlan, if set, receives the entries of each plan update with its
+	// read order and returns the consolidated plan, which is forwarded in
+	// their place; a plan that is not ok is stale and dropped.
+	onPlan func(entries []acp.PlanEntry, seq int64) ([]acp.PlanEntry, bool)
+	// onInit, if set, receives the session configuration the agent reports.
+	onInit func(driver.SessionInit)
+	// onMode, if set, receives the mode of each current-mode update.
+	onMode func(mode string)
+	// onCommands, if set, receives available-commands updates instead of
+	// emit and forwards them itself.
+	onCommands func(n acp.SessionNotification)
 }
 
-func newFlowgenticClient(onEvent EventCallback, handlers *ClientHandlers, sessionMode string) *flowgenticClient {
-	mode := driver.SessionModeAsk
-	if parsed, err := driver.ParseSessionMode(sessionMode); err == nil {
-		mode = parsed
+// launchSessionMode returns the mode a session launched with
+// LaunchOpts.SessionMode runs in: ask unless a standard mode is given.
+func launchSessionMode(sessionMode string) driver.SessionMode {
+	if mode, err := driver.ParseSessionMode(sessionMode); err == nil {
+		return mode
 	}
+	return driver.SessionModeAsk
+}
+
+func newFlowgenticClient(onEvent EventCallback, handlers *ClientHandlers, sessionMode string) *flowgenticClient {
 	return &flowgenticClient{
-		onEvent:      onEvent,
-		handlers:     handlers,
-		sessionMode:  mode,
-		permissions:  make(map[string]chan bool),
+		onEvent:     onEvent,
+		handlers:    handlers,
+		sessionMode: launchSessionMode(sessionMode),
+		metrics:     metrics.Nop(),
+		batchWindow: defaultPermissionBatchWindow,
+		permissions: make(map[string]chan bool),
+		batches:     make(map[string]*permissionBatch),
+		activeTools: make(map[acp.ToolCallId]bool),
+
+		updatesChanged: make(chan struct{}),
 	}
 }
 
 func (c *flowgenticClient) SessionUpdate(_ context.Context, n acp.SessionNotification) error {
+	defer c.updateHandled()
+	c.trackTool(n.Update)
+	if isFirstTokenUpdate(n.Update) {
+		c.mu.Lock()
+		if c.firstToken.IsZero() && !c.turnStart.IsZero() {
+			c.firstToken = time.Now()
+		}
+		c.mu.Unlock()
+	}
+	if chunk := n.Update.AgentMessageChunk; chunk != nil && chunk.Content.Text != nil {
+		c.mu.Lock()
+		c.reply.WriteString(chunk.Content.Text.Text)
+		c.mu.Unlock()
+	}
+	if n.Update.Plan != nil && c.onPlan != nil {
+		// Emitting under planMu keeps a plan merged later from being
+		// overtaken by one merged earlier.
+		c.planMu.Lock()
+		defer c.planMu.Unlock()
+		plan := *n.Update.Plan
+		entries, ok := c.onPlan(plan.Entries, c.planHandled(n))
+		if !ok {
+			return nil
+		}
+		plan.Entries = entries
+		n.Update.Plan = &plan
+	}
+	if u := n.Update.CurrentModeUpdate; u != nil && c.onMode != nil {
+		c.onMode(string(u.CurrentModeId))
+	}
+	if n.Update.AvailableCommandsUpdate != nil && c.onCommands != nil {
+		c.onCommands(n)
+		return nil
+	}
+	if thought := n.Update.AgentThoughtChunk; thought != nil && c.onInit != nil {
+		if s, ok := driver.ParseSessionInit(thought.Meta); ok {
+			c.onInit(s)
+		}
+	}
+	c.emit(n)
+	c.checkStopSettled()
+	return nil
+}
+
+// trackTool records tool calls as they start and marks them finished once
+// they reach a terminal status.
+func (c *flowgenticClient) trackTool(u acp.SessionUpdate) {
+	var id acp.ToolCallId
+	var status acp.ToolCallStatus
+	switch {
+	case u.ToolCall != nil:
+		id, status = u.ToolCall.ToolCallId, u.ToolCall.Status
+	case u.ToolCallUpdate != nil && u.ToolCallUpdate.Status != nil:
+		id, status = u.ToolCallUpdate.ToolCallId, *u.ToolCallUpdate.Status
+	default:
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if status == acp.ToolCallStatusCompleted || status == acp.ToolCallStatusFailed {
+		c.activeTools[id] = true
+	} else if !c.activeTools[id] {
+		c.activeTools[id] = false
+	}
+}
+
+// finishActiveTools emits a terminal status for every tool call the agent
+// left unfinished when a turn ended, so clients don't show them running
+// forever.
+func (c *flowgenticClient) finishActiveTools(sessionID acp.SessionId, status acp.ToolCallStatus) {
+	c.mu.Lock()
+	var ids []acp.ToolCallId
+	for id, finished := range c.activeTools {
+		if !finished {
+			ids = append(ids, id)
+		}
+	}
+	clear(c.activeTools)
+	c.mu.Unlock()
+
+	slices.Sort(ids)
+	for _, id := range ids {
+		c.emit(acp.SessionNotification{
+			SessionId: sessionID,
+			Update:    acp.UpdateToolCall(id, acp.WithUpdateStatus(status)),
+		})
+	}
+}
+
+// startTurn forgets the reply of the previous turn and starts timing the
+// next one.
+func (c *flowgenticClient) startTurn() {
+	c.mu.Lock()
+	c.reply.Reset()
+	c.turnStart = time.Now()
+	c.firstToken = time.Time{}
+	c.mu.Unlock()
+}
+
+// turnStats returns the stats of the current turn, which ends now. It must
+// be called before finishActiveTools forgets the turn's tool calls.
+func (c *flowgenticClient) turnStats() TurnStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := TurnStats{Duration: time.Since(c.turnStart), ToolCalls: len(c.activeTools)}
+	if !c.firstToken.IsZero() {
+		stats.TimeToFirstToken = c.firstToken.Sub(c.turnStart)
+	}
+	return stats
+}
+
+// turnReply returns the agent's message text of the current turn so far.
+func (c *flowgenticClient) turnReply() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reply.String()
+}
+
+func (c *flowgenticClient) emit(n acp.SessionNotification) {
 	if c.onEvent != nil {
 		c.onEvent(n)
 	}
-	return nil
 }
 
 func (c *flowgenticClient) RequestPermission(ctx context.Context, p acp.RequestPermissionRequest) (acp.RequestPermissionResponse, error) {
 	allowOptionID := findAllowOptionID(p.Options)
-
-	// Emit permission request as a session update so the caller knows to prompt the user.
 	requestID := string(p.ToolCall.ToolCallId)
-	if c.onEvent != nil {
-		title := ""
-		if p.ToolCall.Title != nil {
-			title = *p.ToolCall.Title
-		}
-		c.onEvent(acp.SessionNotification{
-			SessionId: p.SessionId,
-			Update: acp.SessionUpdate{
-				ToolCall: &acp.SessionUpdateToolCall{
-					ToolCallId:    p.ToolCall.ToolCallId,
-					Title:         title,
-					Kind:          derefToolKind(p.ToolCall.Kind),
-					Status:        acp.ToolCallStatusPending,
-					SessionUpdate: "tool_call",
-					RawInput: map[string]any{
-						"_permissionRequest": true,
-						"requestId":          requestID,
-						"options":            p.Options,
-					},
-				},
-			},
-		})
+	q := queuedPermission{sessionID: p.SessionId, toolCall: p.ToolCall, options: p.Options}
+	c.reportPermission(PermissionEvent{SessionID: p.SessionId, RequestID: requestID, ToolCall: p.ToolCall, Options: p.Options})
+
+	if c.isStopping() {
+		return c.denyStopping(q), nil
 	}
+
 	if c.shouldAutoApprovePermission() && allowOptionID != "" {
-		if c.onEvent != nil {
-			status := acp.ToolCallStatusCompleted
-			c.onEvent(acp.SessionNotification{
-				SessionId: p.SessionId,
-				Update: acp.SessionUpdate{
-					ToolCallUpdate: &acp.SessionToolCallUpdate{
-						ToolCallId:    p.ToolCall.ToolCallId,
-						Status:        &status,
-						SessionUpdate: "tool_call_update",
-					},
-				},
-			})
-		}
+		// Emit the request and its completion so the caller sees what was approved.
+		c.emit(permissionRequestEvent(q))
+		c.emit(toolCallCompletedEvent(p.SessionId, p.ToolCall.ToolCallId))
+		c.finishRequest(q, "auto_approved", allowOptionID)
 		return acp.RequestPermissionResponse{
 			Outcome: acp.NewRequestPermissionOutcomeSelected(allowOptionID),
 		}, nil
 	}
 
 	// Create a channel and block until RespondToPermission resolves it.
+	// Checked again under mu so that softStop either closes ch or the
+	// request sees it stopping.
 	ch := make(chan bool, 1)
 	c.mu.Lock()
-	c.permissions[requestID] = ch
+	stopping := c.stopping
+	if !stopping {
+		c.permissions[requestID] = ch
+	}
 	c.mu.Unlock()
+	if stopping {
+		return c.denyStopping(q), nil
+	}
+	defer c.finishPermission(requestID)
 
-	defer func() {
-		c.mu.Lock()
-		delete(c.permissions, requestID)
-		c.mu.Unlock()
-	}()
+	// Emit permission request as a session update so the caller knows to prompt the user.
+	c.enqueuePermission(q)
 
 	select {
 	case <-ctx.Done():
+		c.finishRequest(q, "cancelled", "")
 		return acp.RequestPermissionResponse{
 			Outcome: acp.NewRequestPermissionOutcomeCancelled(),
 		}, nil
 	case allowed := <-ch:
 		if allowed && allowOptionID != "" {
+			c.finishRequest(q, "allowed", allowOptionID)
 			return acp.RequestPermissionResponse{
 				Outcome: acp.NewRequestPermissionOutcomeSelected(allowOptionID),
 			}, nil
 		}
+		c.finishRequest(q, "denied", "")
 		return acp.RequestPermissionResponse{
 			Outcome: acp.NewRequestPermissionOutcomeCancelled(),
 		}, nil
 	}
 }
 
+// denyStopping denies q because the session is soft-stopping.
+func (c *flowgenticClient) denyStopping(q queuedPermission) acp.RequestPermissionResponse {
+	c.finishRequest(q, "denied", "")
+	return acp.RequestPermissionResponse{
+		Outcome: acp.NewRequestPermissionOutcomeCancelled(),
+	}
+}
+
+// finishRequest records how a permission request ended.
+func (c *flowgenticClient) finishRequest(q queuedPermission, outcome string, optionID acp.PermissionOptionId) {
+	c.countPermission(outcome)
+	c.reportPermission(PermissionEvent{
+		SessionID: q.sessionID,
+		RequestID: q.requestID(),
+		Resolved:  true,
+		OptionID:  optionID,
+		Outcome:   outcome,
+	})
+}
+
+func (c *flowgenticClient) countPermission(outcome string) {
+	c.metrics.Counter(metrics.PermissionRequests, 1, metrics.Labels{"agent": c.agentID, "outcome": outcome})
+}
+
+func (c *flowgenticClient) reportPermission(e PermissionEvent) {
+	if c.onPermission != nil {
+		c.onPermission(e)
+	}
+}
+
 func findAllowOptionID(options []acp.PermissionOption) acp.PermissionOptionId {
 	var allowAlwaysOptionID acp.PermissionOptionId
 	for _, opt := range options {
@@ -136,9 +334,14 @@ func (c *flowgenticClient) shouldAutoApprovePermission() bool {
 	return c.sessionMode == driver.SessionModeArchitect || c.sessionMode == driver.SessionModeCode
 }
 
-// resolvePermission unblocks a pending RequestPermission call.
+// resolvePermission unblocks a pending RequestPermission call, or every
+// pending call in a batch when requestID is a batch ID.
 func (c *flowgenticClient) resolvePermission(requestID string, allow bool) error {
 	c.mu.Lock()
+	if c.resolveBatchLocked(requestID, allow) {
+		c.mu.Unlock()
+		return nil
+	}
 	ch, ok := c.permissions[requestID]
 	c.mu.Unlock()
 	if !ok {
@@ -151,6 +354,48 @@ func (c *flowgenticClient) resolvePermission(requestID string, allow bool) error
 	return nil
 }
 
+// softStop denies pending and future permission requests and calls
+// onSettled once the tool calls running now, and any the agent starts
+// without asking before they end, have finished.
+func (c *flowgenticClient) softStop(onSettled func()) {
+	c.mu.Lock()
+	c.stopping = true
+	c.onStopSettled = onSettled
+	c.mu.Unlock()
+	c.closePendingPermissions()
+	// A tool call the agent just started may still be on its way.
+	go func() {
+		c.awaitUpdates(context.Background())
+		c.checkStopSettled()
+	}()
+}
+
+// checkStopSettled calls onStopSettled, in its own goroutine, if no tool
+// call is running.
+func (c *flowgenticClient) checkStopSettled() {
+	c.mu.Lock()
+	fn := c.onStopSettled
+	for _, finished := range c.activeTools {
+		if !finished {
+			fn = nil
+			break
+		}
+	}
+	if fn != nil {
+		c.onStopSettled = nil
+	}
+	c.mu.Unlock()
+	if fn != nil {
+		go fn()
+	}
+}
+
+func (c *flowgenticClient) isStopping() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stopping
+}
+
 // closePendingPermissions unblocks all pending permission requests (used on session stop).
 func (c *flowgenticClient) closePendingPermissions() {
 	c.mu.Lock()
@@ -159,6 +404,8 @@ func (c *flowgenticClient) closePendingPermissions() {
 		close(ch)
 		delete(c.permissions, id)
 	}
+	c.queued = nil
+	clear(c.batches)
 }
 
 // Client capabilities — delegate to handlers when available, otherwise return errors.
@@ -2,10 +2,13 @@ package v2
 
 import (
 	"context"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
 	acp "github.com/coder/acp-go-sdk"
+	"github.com/sebastianm/flowgentic/internal/worker/metrics"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -80,3 +83,251 @@ func TestRequestPermission_BlocksInAskModeUntilResolved(t *testing.T) {
 	require.NotNil(t, outcome)
 	assert.Equal(t, acp.PermissionOptionId("allow"), outcome.OptionId)
 }
+
+func TestRequestPermission_CountsOutcomes(t *testing.T) {
+	mtr := newFakeMetrics()
+	req := func(id string) acp.RequestPermissionRequest {
+		return acp.RequestPermissionRequest{
+			SessionId: "sess-1",
+			ToolCall:  acp.RequestPermissionToolCall{ToolCallId: acp.ToolCallId(id)},
+			Options: []acp.PermissionOption{
+				{OptionId: "allow", Kind: acp.PermissionOptionKindAllowOnce},
+			},
+		}
+	}
+	labels := func(outcome string) metrics.Labels {
+		return metrics.Labels{"agent": "test-agent", "outcome": outcome}
+	}
+
+	auto := newFlowgenticClient(nil, nil, "code")
+	auto.metrics, auto.agentID = mtr, "test-agent"
+	_, err := auto.RequestPermission(context.Background(), req("call-auto"))
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, mtr.value(metrics.PermissionRequests, labels("auto_approved")))
+
+	ask := newFlowgenticClient(nil, nil, "ask")
+	ask.metrics, ask.agentID = mtr, "test-agent"
+	for _, tc := range []struct {
+		id      string
+		allowed bool
+	}{{"call-allow", true}, {"call-deny", false}} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = ask.RequestPermission(context.Background(), req(tc.id))
+		}()
+		require.Eventually(t, func() bool {
+			return ask.resolvePermission(tc.id, tc.allowed) == nil
+		}, time.Second, 5*time.Millisecond)
+		<-done
+	}
+	assert.Equal(t, 1.0, mtr.value(metrics.PermissionRequests, labels("allowed")))
+	assert.Equal(t, 1.0, mtr.value(metrics.PermissionRequests, labels("denied")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ask.RequestPermission(ctx, req("call-cancel"))
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, mtr.value(metrics.PermissionRequests, labels("cancelled")))
+}
+
+// eventRecorder collects notifications emitted from concurrent goroutines.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []acp.SessionNotification
+}
+
+func (r *eventRecorder) record(n acp.SessionNotification) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, n)
+}
+
+func (r *eventRecorder) snapshot() []acp.SessionNotification {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]acp.SessionNotification(nil), r.events...)
+}
+
+// permissionRequests returns the RawInput of emitted permission events.
+func (r *eventRecorder) permissionRequests() []map[string]any {
+	var out []map[string]any
+	for _, n := range r.snapshot() {
+		if tc := n.Update.ToolCall; tc != nil {
+			if raw, ok := tc.RawInput.(map[string]any); ok && raw["_permissionRequest"] == true {
+				out = append(out, raw)
+			}
+		}
+	}
+	return out
+}
+
+// requestPermissionsConcurrently fires one ask-mode request per tool call ID
+// and returns a channel that yields each response keyed by ID.
+func requestPermissionsConcurrently(client *flowgenticClient, ids ...string) <-chan map[string]acp.RequestPermissionResponse {
+	out := make(chan map[string]acp.RequestPermissionResponse, 1)
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		resps = make(map[string]acp.RequestPermissionResponse)
+	)
+	for _, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			title := "run " + id
+			resp, _ := client.RequestPermission(context.Background(), acp.RequestPermissionRequest{
+				SessionId: "sess-1",
+				ToolCall:  acp.RequestPermissionToolCall{ToolCallId: acp.ToolCallId(id), Title: &title},
+				Options: []acp.PermissionOption{
+					{OptionId: "allow", Kind: acp.PermissionOptionKindAllowOnce},
+					{OptionId: "reject", Kind: acp.PermissionOptionKindRejectOnce},
+				},
+			})
+			mu.Lock()
+			resps[id] = resp
+			mu.Unlock()
+		}()
+	}
+	go func() {
+		wg.Wait()
+		out <- resps
+	}()
+	return out
+}
+
+func TestRequestPermission_BatchesSimultaneousRequests(t *testing.T) {
+	rec := &eventRecorder{}
+	client := newFlowgenticClient(rec.record, nil, "ask")
+
+	done := requestPermissionsConcurrently(client, "call-1", "call-2", "call-3")
+
+	require.Eventually(t, func() bool { return len(rec.permissionRequests()) > 0 }, time.Second, 5*time.Millisecond)
+	reqs := rec.permissionRequests()
+	require.Len(t, reqs, 1, "three parallel requests surface as one event")
+	batch := reqs[0]
+	assert.Equal(t, true, batch["_permissionBatch"])
+	batchID, ok := batch["requestId"].(string)
+	require.True(t, ok)
+
+	members, ok := batch["requests"].([]map[string]any)
+	require.True(t, ok)
+	require.Len(t, members, 3)
+	var memberIDs []string
+	for _, m := range members {
+		memberIDs = append(memberIDs, m["requestId"].(string))
+		assert.Equal(t, "run "+m["requestId"].(string), m["title"])
+	}
+	assert.ElementsMatch(t, []string{"call-1", "call-2", "call-3"}, memberIDs)
+
+	// Approve all at once.
+	require.NoError(t, client.resolvePermission(batchID, true))
+	select {
+	case resps := <-done:
+		require.Len(t, resps, 3)
+		for id, resp := range resps {
+			require.NotNil(t, resp.Outcome.Selected, id)
+			assert.Equal(t, acp.PermissionOptionId("allow"), resp.Outcome.Selected.OptionId, id)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("batched requests did not complete after approve-all")
+	}
+
+	// The batch's pending tool call is completed once all members resolve.
+	events := rec.snapshot()
+	last := events[len(events)-1].Update.ToolCallUpdate
+	require.NotNil(t, last)
+	assert.Equal(t, acp.ToolCallId(batchID), last.ToolCallId)
+	assert.Equal(t, acp.ToolCallStatusCompleted, *last.Status)
+	assert.Error(t, client.resolvePermission(batchID, true), "batch is gone once resolved")
+}
+
+func TestRequestPermission_BatchMembersResolveIndividually(t *testing.T) {
+	rec := &eventRecorder{}
+	client := newFlowgenticClient(rec.record, nil, "ask")
+
+	done := requestPermissionsConcurrently(client, "call-a", "call-b", "call-c")
+	require.Eventually(t, func() bool { return len(rec.permissionRequests()) > 0 }, time.Second, 5*time.Millisecond)
+	batchID := rec.permissionRequests()[0]["requestId"].(string)
+
+	require.NoError(t, client.resolvePermission("call-a", true))
+	// Deny the rest in one go.
+	require.NoError(t, client.resolvePermission(batchID, false))
+
+	select {
+	case resps := <-done:
+		require.NotNil(t, resps["call-a"].Outcome.Selected)
+		assert.NotNil(t, resps["call-b"].Outcome.Cancelled)
+		assert.NotNil(t, resps["call-c"].Outcome.Cancelled)
+	case <-time.After(time.Second):
+		t.Fatal("batched requests did not complete")
+	}
+}
+
+func TestRequestPermission_LoneRequestIsNotBatched(t *testing.T) {
+	rec := &eventRecorder{}
+	client := newFlowgenticClient(rec.record, nil, "ask")
+
+	done := requestPermissionsConcurrently(client, "call-solo")
+	require.Eventually(t, func() bool { return len(rec.permissionRequests()) > 0 }, time.Second, 5*time.Millisecond)
+	req := rec.permissionRequests()[0]
+	assert.Nil(t, req["_permissionBatch"])
+	assert.Equal(t, "call-solo", req["requestId"])
+
+	require.NoError(t, client.resolvePermission("call-solo", true))
+	<-done
+}
+
+func TestRequestPermission_ReportsRequestedAndResolved(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []PermissionEvent
+	)
+	client := newFlowgenticClient(nil, nil, "ask")
+	client.batchWindow = 0
+	client.onPermission = func(e PermissionEvent) {
+		mu.Lock()
+		events = append(events, e)
+		mu.Unlock()
+	}
+	reported := func() []PermissionEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(events)
+	}
+
+	done := requestPermissionsConcurrently(client, "call-1")
+	require.Eventually(t, func() bool { return len(reported()) == 1 }, time.Second, 5*time.Millisecond)
+	requested := reported()[0]
+	assert.False(t, requested.Resolved)
+	assert.Equal(t, "call-1", requested.RequestID)
+	assert.Equal(t, acp.SessionId("sess-1"), requested.SessionID)
+	assert.Len(t, requested.Options, 2)
+
+	require.NoError(t, client.resolvePermission("call-1", true))
+	<-done
+
+	got := reported()
+	require.Len(t, got, 2)
+	assert.Equal(t, PermissionEvent{
+		SessionID: "sess-1",
+		RequestID: "call-1",
+		Resolved:  true,
+		OptionID:  "allow",
+		Outcome:   "allowed",
+	}, got[1])
+}
+
+func TestRequestPermission_ReportsAutoApproval(t *testing.T) {
+	var events []PermissionEvent
+	client := newFlowgenticClient(nil, nil, "code")
+	client.onPermission = func(e PermissionEvent) { events = append(events, e) }
+
+	<-requestPermissionsConcurrently(client, "call-1")
+
+	require.Len(t, events, 2)
+	assert.False(t, events[0].Resolved)
+	assert.True(t, events[1].Resolved)
+	assert.Equal(t, "auto_approved", events[1].Outcome)
+	assert.Equal(t, acp.PermissionOptionId("allow"), events[1].OptionID)
+}
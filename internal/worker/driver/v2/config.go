@@ -1,6 +1,7 @@
 package v2
 
 import (
+	"context"
 	"log/slog"
 
 	acp "github.com/coder/acp-go-sdk"
@@ -11,6 +12,9 @@ import (
 type AgentConfig struct {
 	AgentID      string
 	Capabilities []driver.Capability
+	// Extensions are agent-specific capabilities; see
+	// driver.Capabilities.Extensions.
+	Extensions []string
 
 	// For native ACP agents (subprocess).
 	Command string
@@ -21,6 +25,24 @@ type AgentConfig struct {
 
 	// MetaBuilder constructs the _meta field for NewSession/Prompt from LaunchOpts.
 	MetaBuilder func(opts LaunchOpts) map[string]any
+
+	// ModelDiscoverer, if set, lists models for agents that report no model
+	// metadata over ACP. DiscoverModels uses it instead of an ACP session.
+	ModelDiscoverer func(ctx context.Context, log *slog.Logger, cwd string) (ModelInventory, error)
+
+	// ModelAliases maps short model names (e.g. "sonnet") to the model IDs
+	// the agent expects. Launch and SetModel resolve them; other values pass
+	// through unchanged.
+	ModelAliases map[string]string
+}
+
+// resolveModel returns the model ID for alias, or alias itself if it is not
+// a known alias.
+func resolveModel(aliases map[string]string, alias string) string {
+	if id, ok := aliases[alias]; ok {
+		return id
+	}
+	return alias
 }
 
 // defaultMetaBuilder produces a _meta map from common LaunchOpts fields.
@@ -35,12 +57,30 @@ func defaultMetaBuilder(opts LaunchOpts) map[string]any {
 	if opts.SessionMode != "" {
 		meta["sessionMode"] = opts.SessionMode
 	}
+	if opts.ReasoningEffort != "" {
+		meta["reasoningEffort"] = opts.ReasoningEffort
+	}
+	if opts.AutoApprovePolicy != "" {
+		meta["autoApprovePolicy"] = opts.AutoApprovePolicy
+	}
 	if len(opts.AllowedTools) > 0 {
 		meta["allowedTools"] = opts.AllowedTools
 	}
+	if len(opts.DisallowedTools) > 0 {
+		meta["disallowedTools"] = opts.DisallowedTools
+	}
+	if len(opts.PlanModeAllowedTools) > 0 {
+		meta["planModeAllowedTools"] = opts.PlanModeAllowedTools
+	}
+	if len(opts.ToolKindPermissions) > 0 {
+		meta["toolKindPermissions"] = opts.ToolKindPermissions
+	}
 	if len(opts.EnvVars) > 0 {
 		meta["envVars"] = opts.EnvVars
 	}
+	if len(opts.AdapterOptions) > 0 {
+		meta["adapterOptions"] = opts.AdapterOptions
+	}
 	return meta
 }
 
@@ -50,14 +90,16 @@ var OpenCodeConfig = AgentConfig{
 	AgentID: string(driver.AgentTypeOpenCode),
 	Capabilities: []driver.Capability{
 		driver.CapStreaming,
+		driver.CapSessionResume,
 		driver.CapCustomModel,
 		driver.CapSystemPrompt,
 		driver.CapPermissionRequest,
 		driver.CapCostTracking,
 	},
-	Command:     "opencode",
-	Args:        []string{"acp"},
-	MetaBuilder: defaultMetaBuilder,
+	Command:         "opencode",
+	Args:            []string{"acp"},
+	MetaBuilder:     defaultMetaBuilder,
+	ModelDiscoverer: discoverOpenCodeModels,
 }
 
 var GeminiConfig = AgentConfig{
@@ -82,8 +124,16 @@ var ClaudeCodeConfig = AgentConfig{
 		driver.CapCustomModel,
 		driver.CapSystemPrompt,
 		driver.CapPermissionRequest,
+		driver.CapReasoningEffort,
+		driver.CapSetAllowedTools,
+		driver.CapToolKindPermissions,
 	},
 	MetaBuilder: defaultMetaBuilder,
+	ModelAliases: map[string]string{
+		"sonnet": "claude-sonnet-4-5-20250929",
+		"opus":   "claude-opus-4-1-20250805",
+		"haiku":  "claude-haiku-4-5-20251001",
+	},
 }
 
 // CodexConfig is set by the codex/acp package via SetCodexConfig.
@@ -94,6 +144,12 @@ var CodexConfig = AgentConfig{
 		driver.CapCustomModel,
 		driver.CapSystemPrompt,
 		driver.CapPermissionRequest,
+		driver.CapReasoningEffort,
 	},
 	MetaBuilder: defaultMetaBuilder,
+	ModelAliases: map[string]string{
+		"codex":      "gpt-5-codex",
+		"codex-mini": "gpt-5-codex-mini",
+		"mini":       "gpt-5-mini",
+	},
 }
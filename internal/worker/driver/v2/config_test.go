@@ -1,10 +1,15 @@
 package v2
 
 import (
+	"context"
+	"log/slog"
 	"testing"
+	"time"
 
+	acp "github.com/coder/acp-go-sdk"
 	"github.com/sebastianm/flowgentic/internal/worker/driver"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDefaultMetaBuilder(t *testing.T) {
@@ -19,11 +24,22 @@ func TestDefaultMetaBuilder(t *testing.T) {
 			Model:        "claude-4",
 			SessionMode:  "code",
 			AllowedTools: []string{"Read", "Write"},
+
+			DisallowedTools:      []string{"WebFetch"},
+			PlanModeAllowedTools: []string{"WebSearch"},
+			ToolKindPermissions:  map[string]string{"execute": "deny"},
+			AdapterOptions:       map[string]any{"maxThinkingTokens": 2048},
+			AutoApprovePolicy:    "allow-safe",
 		})
 		assert.Equal(t, "be helpful", meta["systemPrompt"])
 		assert.Equal(t, "claude-4", meta["model"])
 		assert.Equal(t, "code", meta["sessionMode"])
 		assert.Equal(t, []string{"Read", "Write"}, meta["allowedTools"])
+		assert.Equal(t, []string{"WebFetch"}, meta["disallowedTools"])
+		assert.Equal(t, []string{"WebSearch"}, meta["planModeAllowedTools"])
+		assert.Equal(t, map[string]string{"execute": "deny"}, meta["toolKindPermissions"])
+		assert.Equal(t, map[string]any{"maxThinkingTokens": 2048}, meta["adapterOptions"])
+		assert.Equal(t, "allow-safe", meta["autoApprovePolicy"])
 	})
 }
 
@@ -47,5 +63,51 @@ func TestNewDriver(t *testing.T) {
 	d := NewDriver(testLogger(), OpenCodeConfig)
 	assert.Equal(t, string(driver.AgentTypeOpenCode), d.Agent())
 	caps := d.Capabilities()
-	assert.True(t, caps.Has(driver.CapStreaming))
+	assert.True(t, caps.Supports(driver.CapStreaming))
+}
+
+func TestResolveModel(t *testing.T) {
+	aliases := map[string]string{"sonnet": "claude-sonnet-4-5-20250929"}
+	assert.Equal(t, "claude-sonnet-4-5-20250929", resolveModel(aliases, "sonnet"))
+	assert.Equal(t, "claude-opus-4-1-20250805", resolveModel(aliases, "claude-opus-4-1-20250805"), "unknown values pass through")
+	assert.Equal(t, "", resolveModel(aliases, ""))
+	assert.Equal(t, "sonnet", resolveModel(nil, "sonnet"))
+}
+
+// metaAgent reports the _meta of each NewSession request.
+type metaAgent struct {
+	modelAgent
+	metaCh chan any
+}
+
+func (a *metaAgent) NewSession(ctx context.Context, req acp.NewSessionRequest) (acp.NewSessionResponse, error) {
+	a.metaCh <- req.Meta
+	return a.modelAgent.NewSession(ctx, req)
+}
+
+func TestLaunch_PassesAdapterOptions(t *testing.T) {
+	agent := &metaAgent{metaCh: make(chan any, 1)}
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+		MetaBuilder:    defaultMetaBuilder,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sess, err := d.Launch(ctx, LaunchOpts{
+		Cwd:            "/tmp",
+		AdapterOptions: map[string]any{"maxThinkingTokens": 2048},
+	}, nil)
+	require.NoError(t, err)
+
+	select {
+	case meta := <-agent.metaCh:
+		n, ok := driver.IntOption(driver.AdapterOptions(meta), "maxThinkingTokens")
+		assert.True(t, ok)
+		assert.Equal(t, 2048, n)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for NewSession meta")
+	}
+	require.NoError(t, sess.Stop(ctx))
 }
@@ -5,11 +5,45 @@ import (
 
 	acp "github.com/coder/acp-go-sdk"
 	"github.com/sebastianm/flowgentic/internal/worker/driver"
+	"github.com/sebastianm/flowgentic/internal/worker/procutil"
 )
 
 // EventCallback receives ACP session notifications.
 type EventCallback func(acp.SessionNotification)
 
+// PermissionCallback receives the lifecycle of permission requests.
+type PermissionCallback func(PermissionEvent)
+
+// PermissionEvent reports a permission request being raised or resolved.
+// Every raised request is followed by exactly one resolved event.
+type PermissionEvent struct {
+	SessionID acp.SessionId
+	RequestID string
+	Resolved  bool
+
+	// Raised requests only.
+	ToolCall acp.RequestPermissionToolCall
+	Options  []acp.PermissionOption
+
+	// Resolved requests only.
+	OptionID acp.PermissionOptionId // selected option; empty unless the tool was allowed
+	Outcome  string                 // "auto_approved", "allowed", "denied" or "cancelled"
+}
+
+// TurnCallback is told when a prompt turn ends.
+type TurnCallback func(TurnEnd)
+
+// TurnEnd describes a prompt turn that ended, whether the launch prompt,
+// Session.Prompt or AutoContinue started it. Turns that fail are not
+// reported unless they timed out.
+type TurnEnd struct {
+	StopReason acp.StopReason
+	// TimedOut is set when the driver cancelled the turn because the
+	// deadline of the Prompt call that started it passed.
+	TimedOut bool
+	Stats    *TurnStats // nil if the session reports none
+}
+
 // ModelMeta describes a single model with optional display metadata.
 type ModelMeta struct {
 	ID          string
@@ -25,17 +59,37 @@ type ModelInventory struct {
 
 // LaunchOpts configures a new agent session.
 type LaunchOpts struct {
-	Prompt          string
-	SystemPrompt    string
-	Model           string
-	Cwd             string
-	ResumeSessionID string // ACP agent session ID to resume; empty = new session
-	SessionMode     string // "ask", "architect", "code"
-	AllowedTools    []string
-	MCPServers      []acp.McpServer
-	EnvVars         map[string]string
-	Handlers        *ClientHandlers
-	StatusCh        chan<- SessionStatus // optional: receives status transitions (non-blocking send)
+	Prompt       string
+	SystemPrompt string
+	// SystemPromptTemplate, if set, is rendered with SystemPromptVars and
+	// replaces SystemPrompt. See renderSystemPrompt.
+	SystemPromptTemplate string
+	Topic                string // known session topic, exposed to SystemPromptTemplate
+	AutoTopic            bool   // derive a topic from the first exchange if the agent sets none
+	Ephemeral            bool   // stream events live only, without queueing or persisting them
+	Model                string
+	Cwd                  string
+	SessionID            string // ID for a new session, e.g. for idempotent retries; empty = random UUID. Does not resume
+	ResumeSessionID      string // ACP agent session ID to resume; empty = new session
+	SessionMode          string // "ask", "architect", "code"
+	ReasoningEffort      string // "low", "medium", "high"; empty = agent default
+	AutoApprovePolicy    string // "deny-all", "allow-safe", "allow-all" without an interactive approver; empty = deny-all
+	AllowedTools         []string
+	DisallowedTools      []string          // denied in addition to the adapter's built-in denylist
+	PlanModeAllowedTools []string          // allowed in Flowgentic plan mode in addition to the built-in allowlist
+	ToolKindPermissions  map[string]string // ACP tool kind to "allow", "ask" or "deny"; see driver.KindPermission
+	Labels               map[string]string // user-assigned labels, returned in snapshots
+	MCPServers           []acp.McpServer
+	EnvVars              map[string]string
+	ResourceLimits       procutil.ResourceLimits // caps a subprocess agent; in-process adapters are not limited
+	AdapterOptions       map[string]any          // adapter-specific options, sent as _meta.adapterOptions
+	AutoContinue         AutoContinue            // prompt the agent again by itself after each turn
+	RequiredCapabilities []string                // built-in or extension capability names the agent must have, checked by the session manager
+	ProtocolTracePath    string                  // optional: write every ACP message in both directions to this file as JSONL
+	Handlers             *ClientHandlers
+	StatusCh             chan<- SessionStatus // optional: receives status transitions (non-blocking send)
+	OnPermission         PermissionCallback   // optional: told when permission requests are raised and resolved
+	OnTurnEnded          TurnCallback         // optional: told when each prompt turn ends
 }
 
 // Driver launches and manages ACP agent sessions.
@@ -3,8 +3,70 @@ package v2
 import (
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/sebastianm/flowgentic/internal/worker/metrics"
 )
 
+var _ metrics.Metrics = (*fakeMetrics)(nil)
+
 func testLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
 }
+
+// fakeMetrics records counter and gauge totals keyed by name and labels.
+type fakeMetrics struct {
+	mu     sync.Mutex
+	values map[string]float64
+	obs    map[string]int
+}
+
+func newFakeMetrics() *fakeMetrics {
+	return &fakeMetrics{values: make(map[string]float64), obs: make(map[string]int)}
+}
+
+func metricKey(name string, labels metrics.Labels) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range keys {
+		b.WriteString("," + k + "=" + labels[k])
+	}
+	return b.String()
+}
+
+func (f *fakeMetrics) Counter(name string, delta float64, labels metrics.Labels) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[metricKey(name, labels)] += delta
+}
+
+func (f *fakeMetrics) Gauge(name string, delta float64, labels metrics.Labels) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[metricKey(name, labels)] += delta
+}
+
+func (f *fakeMetrics) Histogram(name string, _ float64, labels metrics.Labels) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.obs[metricKey(name, labels)]++
+}
+
+func (f *fakeMetrics) value(name string, labels metrics.Labels) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.values[metricKey(name, labels)]
+}
+
+func (f *fakeMetrics) observations(name string, labels metrics.Labels) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.obs[metricKey(name, labels)]
+}
@@ -0,0 +1,68 @@
+package v2
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	acp "github.com/coder/acp-go-sdk"
+)
+
+// SetHostCommands replaces the commands the session offers on behalf of the
+// host rather than the agent, and emits them with the agent's own commands
+// as an available-commands update. A host command named like one of the
+// agent's is left out.
+func (s *acpSession) SetHostCommands(_ context.Context, cmds []acp.AvailableCommand) error {
+	s.mu.Lock()
+	sessionID := s.info.AgentSessionID
+	s.mu.Unlock()
+	if sessionID == "" {
+		return fmt.Errorf("session not started")
+	}
+
+	s.commandsMu.Lock()
+	defer s.commandsMu.Unlock()
+	s.hostCommands = slices.Clone(cmds)
+	s.client.emit(availableCommandsUpdate(acp.SessionId(sessionID), mergeCommands(s.agentCommands, s.hostCommands)))
+	return nil
+}
+
+// forwardCommands records the commands an agent update announces and emits
+// the update with the host commands added. Holding commandsMu while emitting
+// keeps a concurrent SetHostCommands from being overtaken by a stale list.
+func (s *acpSession) forwardCommands(n acp.SessionNotification) {
+	s.commandsMu.Lock()
+	defer s.commandsMu.Unlock()
+	s.agentCommands = slices.Clone(n.Update.AvailableCommandsUpdate.AvailableCommands)
+	s.client.emit(availableCommandsUpdate(n.SessionId, mergeCommands(s.agentCommands, s.hostCommands)))
+}
+
+// isHostCommand reports whether name is one of the host commands and not
+// also one of the agent's, which would take the prompt itself.
+func (s *acpSession) isHostCommand(name string) bool {
+	s.commandsMu.Lock()
+	defer s.commandsMu.Unlock()
+	named := func(c acp.AvailableCommand) bool { return c.Name == name }
+	return slices.ContainsFunc(s.hostCommands, named) && !slices.ContainsFunc(s.agentCommands, named)
+}
+
+func availableCommandsUpdate(sessionID acp.SessionId, cmds []acp.AvailableCommand) acp.SessionNotification {
+	return acp.SessionNotification{
+		SessionId: sessionID,
+		Update: acp.SessionUpdate{
+			AvailableCommandsUpdate: &acp.SessionAvailableCommandsUpdate{AvailableCommands: cmds},
+		},
+	}
+}
+
+// mergeCommands returns the agent's commands followed by the host commands
+// whose names the agent does not use.
+func mergeCommands(agent, host []acp.AvailableCommand) []acp.AvailableCommand {
+	merged := slices.Clone(agent)
+	for _, c := range host {
+		if !slices.ContainsFunc(agent, func(a acp.AvailableCommand) bool { return a.Name == c.Name }) {
+			merged = append(merged, c)
+		}
+	}
+	return merged
+}
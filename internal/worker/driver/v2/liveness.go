@@ -0,0 +1,77 @@
+package v2
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"time"
+)
+
+// Pinger is implemented by in-process adapters that can check their agent
+// subprocess still answers. Ping returns an error if it does not before ctx
+// ends, and nil while there is nothing to check yet.
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// defaultLivenessFailures is how many checks in a row must fail before the
+// session is failed when LivenessProbe.Failures is zero.
+const defaultLivenessFailures = 3
+
+// LivenessProbe configures the periodic Ping of in-process adapters that
+// implement Pinger, which catches an agent subprocess that is alive but no
+// longer answers.
+type LivenessProbe struct {
+	Interval time.Duration // between checks; zero disables them
+	Timeout  time.Duration // per check; zero uses Interval
+	Failures int           // checks in a row that must fail; zero uses 3
+}
+
+// WithLiveness makes sessions of in-process adapters that implement Pinger
+// check their agent as configured by p, failing the session once the agent
+// stops answering.
+func WithLiveness(p LivenessProbe) Option {
+	return func(d *acpDriver) { d.liveness = p }
+}
+
+// watchLiveness pings the session's adapter every d.liveness.Interval until
+// ctx ends. After Failures failed checks in a row it fails the session and
+// cancels it, which also ends a turn the agent hangs in.
+func (d *acpDriver) watchLiveness(ctx context.Context, sess *acpSession) {
+	p := d.liveness
+	timeout := cmp.Or(p.Timeout, p.Interval)
+	threshold := cmp.Or(p.Failures, defaultLivenessFailures)
+
+	ticker := time.NewTicker(p.Interval)
+	defer ticker.Stop()
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pingCtx, cancel := context.WithTimeout(ctx, timeout)
+		err := sess.pinger.Ping(pingCtx)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			failures = 0
+			continue
+		}
+		failures++
+		d.log.Warn("agent liveness check failed", "session_id", sess.info.ID, "failures", failures, "error", err)
+		if failures < threshold {
+			continue
+		}
+
+		d.log.Error("agent stopped responding", "session_id", sess.info.ID, "failures", failures)
+		d.countError("liveness")
+		sess.fail(fmt.Errorf("agent %s did not respond to %d liveness checks: %w", d.config.AgentID, failures, err))
+		sess.cancel()
+		return
+	}
+}
@@ -0,0 +1,86 @@
+package v2
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wedgingAgent answers pings until wedged is set. From then on Ping and
+// Prompt hang until their context ends, like an agent that stopped reading
+// its input.
+type wedgingAgent struct {
+	modelAgent
+	wedged atomic.Bool
+	pings  atomic.Int32
+}
+
+func (a *wedgingAgent) Ping(ctx context.Context) error {
+	a.pings.Add(1)
+	if !a.wedged.Load() {
+		return nil
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (a *wedgingAgent) Prompt(ctx context.Context, _ acp.PromptRequest) (acp.PromptResponse, error) {
+	<-ctx.Done()
+	return acp.PromptResponse{}, ctx.Err()
+}
+
+func TestLiveness_FailsUnresponsiveAgent(t *testing.T) {
+	agent := &wedgingAgent{}
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(*slog.Logger) acp.Agent { return agent },
+	}, WithLiveness(LivenessProbe{Interval: 10 * time.Millisecond, Failures: 2}))
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	waitForStatus(t, statusCh, SessionStatusIdle)
+
+	require.Eventually(t, func() bool { return agent.pings.Load() >= 3 }, time.Second, 5*time.Millisecond)
+	assert.Equal(t, SessionStatusIdle, sess.Info().Status, "an answering agent is left alone")
+
+	agent.wedged.Store(true)
+	promptErr := make(chan error, 1)
+	go func() {
+		_, err := sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("hi")})
+		promptErr <- err
+	}()
+
+	waitForStatus(t, statusCh, SessionStatusErrored)
+	select {
+	case err := <-promptErr:
+		assert.Error(t, err, "the hung turn ends")
+	case <-time.After(time.Second):
+		t.Fatal("Prompt did not return")
+	}
+	require.NoError(t, sess.Wait(context.Background()))
+	info := sess.Info()
+	require.NotNil(t, info.Error)
+	assert.Contains(t, info.Error.Message, "agent test-agent did not respond to 2 liveness checks")
+}
+
+func TestLiveness_DisabledByDefault(t *testing.T) {
+	agent := &wedgingAgent{}
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(*slog.Logger) acp.Agent { return agent },
+	})
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	waitForStatus(t, statusCh, SessionStatusIdle)
+	t.Cleanup(func() { _ = sess.Stop(context.Background()) })
+
+	time.Sleep(30 * time.Millisecond)
+	assert.Zero(t, agent.pings.Load())
+}
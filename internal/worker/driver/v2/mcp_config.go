@@ -0,0 +1,188 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	acp "github.com/coder/acp-go-sdk"
+	"gopkg.in/yaml.v3"
+)
+
+// MCPConfigEnv names the environment variable holding the path of the MCP
+// server catalog the worker adds to every session; see LoadMCPServersFromEnv.
+const MCPConfigEnv = "FLOWGENTIC_MCP_CONFIG"
+
+// MCPServerSpec declares an MCP server in a catalog file, in the format of
+// Claude's .mcp.json. Every string value may reference environment
+// variables as ${VAR} or ${VAR:-default}.
+type MCPServerSpec struct {
+	// Type is "stdio", "sse" or "http"; empty means "stdio".
+	Type    string            `json:"type" yaml:"type"`
+	Command string            `json:"command" yaml:"command"` // stdio only
+	Args    []string          `json:"args" yaml:"args"`       // stdio only
+	Env     map[string]string `json:"env" yaml:"env"`         // stdio only
+	URL     string            `json:"url" yaml:"url"`         // sse and http only
+	Headers map[string]string `json:"headers" yaml:"headers"` // sse and http only
+}
+
+// MCPConfig is the content of an MCP server catalog file.
+type MCPConfig struct {
+	MCPServers map[string]MCPServerSpec `json:"mcpServers" yaml:"mcpServers"`
+}
+
+// LoadMCPServers reads an MCP server catalog file and returns its servers,
+// ordered by name. Files ending in .yaml or .yml are parsed as YAML,
+// anything else as JSON. Unknown fields, invalid entries and undefined
+// variables are errors, reported together.
+func LoadMCPServers(path string) ([]acp.McpServer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read mcp config: %w", err)
+	}
+
+	var cfg MCPConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&cfg)
+	default:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse mcp config %s: %w", path, err)
+	}
+
+	servers, err := cfg.Servers()
+	if err != nil {
+		return nil, fmt.Errorf("mcp config %s: %w", path, err)
+	}
+	return servers, nil
+}
+
+// LoadMCPServersFromEnv loads the catalog MCPConfigEnv points to. It
+// returns no servers if the variable is unset.
+func LoadMCPServersFromEnv() ([]acp.McpServer, error) {
+	path := os.Getenv(MCPConfigEnv)
+	if path == "" {
+		return nil, nil
+	}
+	return LoadMCPServers(path)
+}
+
+// Servers validates the catalog and converts its entries, ordered by name.
+func (c MCPConfig) Servers() ([]acp.McpServer, error) {
+	var errs []error
+	servers := make([]acp.McpServer, 0, len(c.MCPServers))
+	for _, name := range slices.Sorted(maps.Keys(c.MCPServers)) {
+		server, err := c.MCPServers[name].mcpServer(name)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("mcpServers.%s: %w", name, err))
+			continue
+		}
+		servers = append(servers, server)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return servers, nil
+}
+
+func (s MCPServerSpec) mcpServer(name string) (acp.McpServer, error) {
+	var x expander
+	switch s.Type {
+	case "", "stdio":
+		if s.URL != "" || len(s.Headers) > 0 {
+			return acp.McpServer{}, errors.New("url and headers are not used by stdio servers")
+		}
+		if s.Command == "" {
+			return acp.McpServer{}, errors.New("command is required")
+		}
+		stdio := &acp.McpServerStdio{
+			Name:    name,
+			Command: x.expand(s.Command),
+			Args:    make([]string, 0, len(s.Args)),
+			Env:     make([]acp.EnvVariable, 0, len(s.Env)),
+		}
+		for _, arg := range s.Args {
+			stdio.Args = append(stdio.Args, x.expand(arg))
+		}
+		for _, k := range slices.Sorted(maps.Keys(s.Env)) {
+			stdio.Env = append(stdio.Env, acp.EnvVariable{Name: k, Value: x.expand(s.Env[k])})
+		}
+		return acp.McpServer{Stdio: stdio}, x.err()
+	case "sse", "http":
+		if s.Command != "" || len(s.Args) > 0 || len(s.Env) > 0 {
+			return acp.McpServer{}, fmt.Errorf("command, args and env are not used by %s servers", s.Type)
+		}
+		if s.URL == "" {
+			return acp.McpServer{}, errors.New("url is required")
+		}
+		url := x.expand(s.URL)
+		headers := make([]acp.HttpHeader, 0, len(s.Headers))
+		for _, k := range slices.Sorted(maps.Keys(s.Headers)) {
+			headers = append(headers, acp.HttpHeader{Name: k, Value: x.expand(s.Headers[k])})
+		}
+		if s.Type == "sse" {
+			return acp.McpServer{Sse: &acp.McpServerSse{Name: name, Type: "sse", Url: url, Headers: headers}}, x.err()
+		}
+		return acp.McpServer{Http: &acp.McpServerHttp{Name: name, Type: "http", Url: url, Headers: headers}}, x.err()
+	default:
+		return acp.McpServer{}, fmt.Errorf("unknown type %q", s.Type)
+	}
+}
+
+// varRef matches ${VAR} and ${VAR:-default}.
+var varRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// expander replaces variable references with their values from the
+// environment, collecting the variables that are undefined and have no
+// default.
+type expander struct {
+	undefined []string
+}
+
+func (x *expander) expand(s string) string {
+	return varRef.ReplaceAllStringFunc(s, func(ref string) string {
+		m := varRef.FindStringSubmatch(ref)
+		if v, ok := os.LookupEnv(m[1]); ok {
+			return v
+		}
+		if strings.Contains(ref, ":-") {
+			return m[2]
+		}
+		if !slices.Contains(x.undefined, m[1]) {
+			x.undefined = append(x.undefined, m[1])
+		}
+		return ""
+	})
+}
+
+func (x *expander) err() error {
+	if len(x.undefined) == 0 {
+		return nil
+	}
+	return fmt.Errorf("undefined variable %s", strings.Join(x.undefined, ", "))
+}
+
+// MergeMCPServers appends the servers of add whose name no server in dst
+// has, so servers a caller sets explicitly win over a catalog's.
+func MergeMCPServers(dst, add []acp.McpServer) []acp.McpServer {
+	for _, s := range add {
+		name := mcpServerName(s)
+		if !slices.ContainsFunc(dst, func(d acp.McpServer) bool { return mcpServerName(d) == name }) {
+			dst = append(dst, s)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,101 @@
+package v2
+
+import (
+	"testing"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMCPServers(t *testing.T) {
+	t.Setenv("GITHUB_TOKEN", "ghp_secret")
+	t.Setenv("DOCS_HOST", "docs.internal")
+	path := writeRegistry(t, ".mcp.json", `{
+  "mcpServers": {
+    "github": {
+      "command": "npx",
+      "args": ["-y", "@modelcontextprotocol/server-github"],
+      "env": {"GITHUB_TOKEN": "${GITHUB_TOKEN}", "LOG_LEVEL": "${MCP_LOG_LEVEL:-info}"}
+    },
+    "docs": {"type": "sse", "url": "https://${DOCS_HOST}/sse"},
+    "api": {"type": "http", "url": "https://api.example.com/mcp", "headers": {"Authorization": "Bearer ${GITHUB_TOKEN}"}}
+  }
+}`)
+
+	servers, err := LoadMCPServers(path)
+	require.NoError(t, err)
+	assert.Equal(t, []acp.McpServer{
+		{Http: &acp.McpServerHttp{
+			Name:    "api",
+			Type:    "http",
+			Url:     "https://api.example.com/mcp",
+			Headers: []acp.HttpHeader{{Name: "Authorization", Value: "Bearer ghp_secret"}},
+		}},
+		{Sse: &acp.McpServerSse{Name: "docs", Type: "sse", Url: "https://docs.internal/sse", Headers: []acp.HttpHeader{}}},
+		{Stdio: &acp.McpServerStdio{
+			Name:    "github",
+			Command: "npx",
+			Args:    []string{"-y", "@modelcontextprotocol/server-github"},
+			Env:     []acp.EnvVariable{{Name: "GITHUB_TOKEN", Value: "ghp_secret"}, {Name: "LOG_LEVEL", Value: "info"}},
+		}},
+	}, servers)
+}
+
+func TestLoadMCPServers_YAML(t *testing.T) {
+	path := writeRegistry(t, "mcp.yaml", `
+mcpServers:
+  fs:
+    type: stdio
+    command: mcp-fs
+    args: [/srv]
+`)
+	servers, err := LoadMCPServers(path)
+	require.NoError(t, err)
+	require.Len(t, servers, 1)
+	require.NotNil(t, servers[0].Stdio)
+	assert.Equal(t, "mcp-fs", servers[0].Stdio.Command)
+	assert.Equal(t, []string{"/srv"}, servers[0].Stdio.Args)
+}
+
+func TestLoadMCPServers_Invalid(t *testing.T) {
+	for name, tc := range map[string]struct {
+		content string
+		want    string
+	}{
+		"unknown field":      {`{"mcpServers": {"a": {"command": "x", "cwd": "/"}}}`, `unknown field "cwd"`},
+		"missing command":    {`{"mcpServers": {"a": {"args": ["x"]}}}`, "mcpServers.a: command is required"},
+		"missing url":        {`{"mcpServers": {"a": {"type": "http"}}}`, "mcpServers.a: url is required"},
+		"mixed transports":   {`{"mcpServers": {"a": {"type": "sse", "url": "https://x", "command": "x"}}}`, "not used by sse servers"},
+		"unknown type":       {`{"mcpServers": {"a": {"type": "websocket", "url": "wss://x"}}}`, `mcpServers.a: unknown type "websocket"`},
+		"undefined variable": {`{"mcpServers": {"a": {"command": "x", "env": {"T": "${FLOWGENTIC_TEST_UNSET}"}}}}`, "mcpServers.a: undefined variable FLOWGENTIC_TEST_UNSET"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadMCPServers(writeRegistry(t, "mcp.json", tc.content))
+			assert.ErrorContains(t, err, tc.want)
+		})
+	}
+}
+
+func TestLoadMCPServersFromEnv(t *testing.T) {
+	t.Setenv(MCPConfigEnv, "")
+	servers, err := LoadMCPServersFromEnv()
+	require.NoError(t, err)
+	assert.Nil(t, servers, "no catalog is configured")
+
+	t.Setenv(MCPConfigEnv, writeRegistry(t, "mcp.json", `{"mcpServers": {"fs": {"command": "mcp-fs"}}}`))
+	servers, err = LoadMCPServersFromEnv()
+	require.NoError(t, err)
+	require.Len(t, servers, 1)
+	assert.Equal(t, "fs", mcpServerName(servers[0]))
+}
+
+func TestMergeMCPServers(t *testing.T) {
+	own := acp.McpServer{Stdio: &acp.McpServerStdio{Name: "fs", Command: "my-fs"}}
+	catalog := []acp.McpServer{
+		{Stdio: &acp.McpServerStdio{Name: "fs", Command: "mcp-fs"}},
+		{Http: &acp.McpServerHttp{Name: "api", Type: "http", Url: "https://api.example.com/mcp"}},
+	}
+	merged := MergeMCPServers([]acp.McpServer{own}, catalog)
+	assert.Equal(t, []acp.McpServer{own, catalog[1]}, merged, "the caller's server wins")
+}
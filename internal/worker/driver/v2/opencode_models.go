@@ -0,0 +1,174 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os/exec"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// defaultOpenCodeServerURL is where `opencode serve` listens by default.
+const defaultOpenCodeServerURL = "http://127.0.0.1:4096"
+
+// openCodeServeTimeout bounds how long discovery waits for a transient
+// `opencode serve` to start answering.
+const openCodeServeTimeout = 15 * time.Second
+
+// openCodeRequestTimeout bounds each HTTP request to the OpenCode server, so a
+// hung server fails discovery instead of blocking it.
+const openCodeRequestTimeout = 10 * time.Second
+
+// openCodeProviders is the response of GET /config/providers.
+type openCodeProviders struct {
+	Providers []struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Models map[string]struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"models"`
+	} `json:"providers"`
+	// Default maps provider ID to that provider's default model ID.
+	Default map[string]string `json:"default"`
+}
+
+// openCodeModelDiscovery lists the models an OpenCode server offers. OpenCode
+// reports no model metadata over ACP, so it queries the HTTP API instead.
+type openCodeModelDiscovery struct {
+	log        *slog.Logger
+	serverURL  string
+	httpClient *http.Client
+	// startServer launches a transient server when none answers at serverURL.
+	startServer func(ctx context.Context, cwd string) (url string, stop func(), err error)
+}
+
+// discoverOpenCodeModels is OpenCodeConfig's ModelDiscoverer.
+func discoverOpenCodeModels(ctx context.Context, log *slog.Logger, cwd string) (ModelInventory, error) {
+	d := openCodeModelDiscovery{
+		log:         log,
+		serverURL:   defaultOpenCodeServerURL,
+		httpClient:  &http.Client{Timeout: openCodeRequestTimeout},
+		startServer: startOpenCodeServer,
+	}
+	return d.discover(ctx, cwd)
+}
+
+func (d openCodeModelDiscovery) discover(ctx context.Context, cwd string) (ModelInventory, error) {
+	providers, err := d.fetch(ctx, d.serverURL)
+	if err == nil {
+		return flattenOpenCodeProviders(providers)
+	}
+	var netErr *net.OpError
+	if !errors.As(err, &netErr) {
+		return ModelInventory{}, err
+	}
+
+	d.log.Debug("opencode server not reachable, starting transient server", "url", d.serverURL, "error", err)
+	url, stop, err := d.startServer(ctx, cwd)
+	if err != nil {
+		return ModelInventory{}, fmt.Errorf("starting opencode serve: %w", err)
+	}
+	defer stop()
+
+	waitCtx, cancel := context.WithTimeout(ctx, openCodeServeTimeout)
+	defer cancel()
+	for {
+		providers, err = d.fetch(waitCtx, url)
+		if err == nil {
+			return flattenOpenCodeProviders(providers)
+		}
+		if !errors.As(err, &netErr) {
+			return ModelInventory{}, err
+		}
+		select {
+		case <-waitCtx.Done():
+			return ModelInventory{}, fmt.Errorf("waiting for opencode serve: %w", err)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func (d openCodeModelDiscovery) fetch(ctx context.Context, baseURL string) (openCodeProviders, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/config/providers", nil)
+	if err != nil {
+		return openCodeProviders{}, err
+	}
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return openCodeProviders{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return openCodeProviders{}, fmt.Errorf("opencode /config/providers returned %s", resp.Status)
+	}
+	var out openCodeProviders
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return openCodeProviders{}, fmt.Errorf("decoding opencode providers: %w", err)
+	}
+	return out, nil
+}
+
+// flattenOpenCodeProviders lists every model as "provider/model", the form
+// OpenCode accepts as a model override. The default is the first provider's
+// default model.
+func flattenOpenCodeProviders(p openCodeProviders) (ModelInventory, error) {
+	var inv ModelInventory
+	for _, prov := range p.Providers {
+		ids := make([]string, 0, len(prov.Models))
+		for id := range prov.Models {
+			ids = append(ids, id)
+		}
+		slices.Sort(ids)
+		for _, id := range ids {
+			m := prov.Models[id]
+			name := m.Name
+			if name == "" {
+				name = id
+			}
+			if prov.Name != "" {
+				name = prov.Name + " " + name
+			}
+			inv.Models = append(inv.Models, ModelMeta{ID: prov.ID + "/" + id, DisplayName: name})
+		}
+		if inv.DefaultModel == "" {
+			if def, ok := p.Default[prov.ID]; ok && def != "" {
+				inv.DefaultModel = prov.ID + "/" + def
+			}
+		}
+	}
+	if len(inv.Models) == 0 {
+		return ModelInventory{}, fmt.Errorf("opencode server returned no models")
+	}
+	if inv.DefaultModel == "" {
+		inv.DefaultModel = inv.Models[0].ID
+	}
+	return inv, nil
+}
+
+// startOpenCodeServer runs `opencode serve` on a free localhost port.
+func startOpenCodeServer(ctx context.Context, cwd string) (string, func(), error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, err
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	cmd := exec.CommandContext(ctx, "opencode", "serve", "--hostname", "127.0.0.1", "--port", strconv.Itoa(port))
+	cmd.Dir = cwd
+	if err := cmd.Start(); err != nil {
+		return "", nil, err
+	}
+	stop := func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	}
+	return "http://127.0.0.1:" + strconv.Itoa(port), stop, nil
+}
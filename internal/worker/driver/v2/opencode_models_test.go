@@ -0,0 +1,165 @@
+package v2
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const openCodeProvidersJSON = `{
+  "providers": [
+    {
+      "id": "anthropic",
+      "name": "Anthropic",
+      "models": {
+        "claude-sonnet-4": {"id": "claude-sonnet-4", "name": "Claude Sonnet 4"},
+        "claude-haiku-4": {"id": "claude-haiku-4", "name": "Claude Haiku 4"}
+      }
+    },
+    {
+      "id": "openai",
+      "name": "OpenAI",
+      "models": {
+        "gpt-5": {"id": "gpt-5", "name": "GPT-5"}
+      }
+    }
+  ],
+  "default": {"openai": "gpt-5", "anthropic": "claude-sonnet-4"}
+}`
+
+func newOpenCodeStub(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/config/providers" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// unusedURL returns the URL of a localhost port nothing listens on.
+func unusedURL(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	url := "http://" + ln.Addr().String()
+	require.NoError(t, ln.Close())
+	return url
+}
+
+func noServer(t *testing.T) func(context.Context, string) (string, func(), error) {
+	return func(context.Context, string) (string, func(), error) {
+		t.Fatal("transient server should not be started")
+		return "", nil, nil
+	}
+}
+
+func TestOpenCodeModelDiscovery_FlattensProviders(t *testing.T) {
+	srv := newOpenCodeStub(t, openCodeProvidersJSON)
+	d := openCodeModelDiscovery{
+		log:         testLogger(),
+		serverURL:   srv.URL,
+		httpClient:  srv.Client(),
+		startServer: noServer(t),
+	}
+
+	inv, err := d.discover(context.Background(), t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, []ModelMeta{
+		{ID: "anthropic/claude-haiku-4", DisplayName: "Anthropic Claude Haiku 4"},
+		{ID: "anthropic/claude-sonnet-4", DisplayName: "Anthropic Claude Sonnet 4"},
+		{ID: "openai/gpt-5", DisplayName: "OpenAI GPT-5"},
+	}, inv.Models)
+	assert.Equal(t, "anthropic/claude-sonnet-4", inv.DefaultModel, "first provider's default wins")
+}
+
+func TestOpenCodeModelDiscovery_StartsTransientServer(t *testing.T) {
+	srv := newOpenCodeStub(t, openCodeProvidersJSON)
+	var started, stopped bool
+	d := openCodeModelDiscovery{
+		log:        testLogger(),
+		serverURL:  unusedURL(t),
+		httpClient: srv.Client(),
+		startServer: func(_ context.Context, cwd string) (string, func(), error) {
+			started = true
+			assert.Equal(t, "/work/dir", cwd)
+			return srv.URL, func() { stopped = true }, nil
+		},
+	}
+
+	inv, err := d.discover(context.Background(), "/work/dir")
+	require.NoError(t, err)
+	assert.Len(t, inv.Models, 3)
+	assert.True(t, started)
+	assert.True(t, stopped, "transient server is stopped after discovery")
+}
+
+func TestOpenCodeModelDiscovery_Errors(t *testing.T) {
+	t.Run("no models", func(t *testing.T) {
+		srv := newOpenCodeStub(t, `{"providers": [{"id": "anthropic", "models": {}}]}`)
+		d := openCodeModelDiscovery{log: testLogger(), serverURL: srv.URL, httpClient: srv.Client(), startServer: noServer(t)}
+		_, err := d.discover(context.Background(), "")
+		assert.ErrorContains(t, err, "no models")
+	})
+
+	t.Run("server error does not start a transient server", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			http.Error(w, "boom", http.StatusInternalServerError)
+		}))
+		t.Cleanup(srv.Close)
+		d := openCodeModelDiscovery{log: testLogger(), serverURL: srv.URL, httpClient: srv.Client(), startServer: noServer(t)}
+		_, err := d.discover(context.Background(), "")
+		assert.ErrorContains(t, err, "500")
+	})
+}
+
+func TestDiscoverModels_UsesModelDiscoverer(t *testing.T) {
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID: "test-agent",
+		Command: "does-not-exist",
+		ModelDiscoverer: func(context.Context, *slog.Logger, string) (ModelInventory, error) {
+			return ModelInventory{Models: []ModelMeta{{ID: "p/m"}}, DefaultModel: "p/m"}, nil
+		},
+	})
+
+	inv, err := d.DiscoverModels(context.Background(), t.TempDir())
+	require.NoError(t, err)
+	assert.Equal(t, "p/m", inv.DefaultModel)
+}
+
+func TestOpenCodeModelDiscovery_HungServerTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	client := srv.Client()
+	client.Timeout = 50 * time.Millisecond
+	d := openCodeModelDiscovery{
+		log:         testLogger(),
+		serverURL:   srv.URL,
+		httpClient:  client,
+		startServer: noServer(t),
+	}
+
+	start := time.Now()
+	_, err := d.discover(context.Background(), t.TempDir())
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
@@ -0,0 +1,204 @@
+package v2
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/google/uuid"
+)
+
+// defaultPermissionBatchWindow is how long the client collects permission
+// requests before surfacing them. Agents that run tools in parallel (e.g.
+// Claude multi-tool turns) ask within a few milliseconds of each other.
+const defaultPermissionBatchWindow = 50 * time.Millisecond
+
+// permissionBatchPrefix marks request IDs that resolve a whole batch.
+const permissionBatchPrefix = "perm-batch-"
+
+// queuedPermission is a permission request waiting for its batch window to
+// close.
+type queuedPermission struct {
+	sessionID acp.SessionId
+	toolCall  acp.RequestPermissionToolCall
+	options   []acp.PermissionOption
+}
+
+func (q queuedPermission) requestID() string { return string(q.toolCall.ToolCallId) }
+
+// permissionBatch groups requests surfaced as one event. Responding to the
+// batch ID resolves every member (approve-all / deny-all); each member can
+// still be resolved on its own by its request ID.
+type permissionBatch struct {
+	sessionID acp.SessionId
+	pending   []string // member request IDs not yet resolved
+}
+
+// enqueuePermission surfaces q to the caller, grouping it with other
+// requests that arrive within the batch window.
+func (c *flowgenticClient) enqueuePermission(q queuedPermission) {
+	if c.batchWindow <= 0 {
+		c.emit(permissionRequestEvent(q))
+		return
+	}
+	c.mu.Lock()
+	c.queued = append(c.queued, q)
+	first := len(c.queued) == 1
+	c.mu.Unlock()
+	if first {
+		time.AfterFunc(c.batchWindow, c.flushPermissions)
+	}
+}
+
+// flushPermissions emits the queued requests: a lone request as a regular
+// permission event, several as one batch event.
+func (c *flowgenticClient) flushPermissions() {
+	c.mu.Lock()
+	queued := make([]queuedPermission, 0, len(c.queued))
+	for _, q := range c.queued {
+		// Skip requests cancelled while they waited.
+		if _, ok := c.permissions[q.requestID()]; ok {
+			queued = append(queued, q)
+		}
+	}
+	c.queued = nil
+	var batchID string
+	if len(queued) > 1 {
+		batchID = permissionBatchPrefix + uuid.NewString()
+		b := &permissionBatch{sessionID: queued[0].sessionID}
+		for _, q := range queued {
+			b.pending = append(b.pending, q.requestID())
+		}
+		c.batches[batchID] = b
+	}
+	c.mu.Unlock()
+
+	switch len(queued) {
+	case 0:
+	case 1:
+		c.emit(permissionRequestEvent(queued[0]))
+	default:
+		c.emit(permissionBatchEvent(batchID, queued))
+	}
+}
+
+// finishPermission forgets a resolved request. Once every member of its
+// batch is resolved, the batch's pending tool call is marked completed.
+// Callers must not hold c.mu.
+func (c *flowgenticClient) finishPermission(requestID string) {
+	c.mu.Lock()
+	delete(c.permissions, requestID)
+	var done []acp.SessionNotification
+	for id, b := range c.batches {
+		i := slices.Index(b.pending, requestID)
+		if i < 0 {
+			continue
+		}
+		b.pending = slices.Delete(b.pending, i, i+1)
+		if len(b.pending) == 0 {
+			delete(c.batches, id)
+			done = append(done, toolCallCompletedEvent(b.sessionID, acp.ToolCallId(id)))
+		}
+	}
+	c.mu.Unlock()
+
+	for _, n := range done {
+		c.emit(n)
+	}
+}
+
+// resolveBatchLocked resolves every pending member of the batch. It reports
+// false if batchID is not a pending batch. Callers must hold c.mu.
+func (c *flowgenticClient) resolveBatchLocked(batchID string, allow bool) bool {
+	b, ok := c.batches[batchID]
+	if !ok {
+		return false
+	}
+	for _, id := range b.pending {
+		if ch, ok := c.permissions[id]; ok {
+			select {
+			case ch <- allow:
+			default:
+			}
+		}
+	}
+	return true
+}
+
+// permissionRequestEvent is the pending tool call that asks the caller to
+// respond to a single permission request.
+func permissionRequestEvent(q queuedPermission) acp.SessionNotification {
+	title := ""
+	if q.toolCall.Title != nil {
+		title = *q.toolCall.Title
+	}
+	return acp.SessionNotification{
+		SessionId: q.sessionID,
+		Update: acp.SessionUpdate{
+			ToolCall: &acp.SessionUpdateToolCall{
+				ToolCallId:    q.toolCall.ToolCallId,
+				Title:         title,
+				Kind:          derefToolKind(q.toolCall.Kind),
+				Status:        acp.ToolCallStatusPending,
+				SessionUpdate: "tool_call",
+				RawInput: map[string]any{
+					"_permissionRequest": true,
+					"requestId":          q.requestID(),
+					"options":            q.options,
+				},
+			},
+		},
+	}
+}
+
+// permissionBatchEvent is a single pending tool call listing several
+// permission requests. Its requestId resolves all of them at once; each
+// entry in requests carries the requestId that resolves only that tool.
+func permissionBatchEvent(batchID string, queued []queuedPermission) acp.SessionNotification {
+	requests := make([]map[string]any, 0, len(queued))
+	for _, q := range queued {
+		title := ""
+		if q.toolCall.Title != nil {
+			title = *q.toolCall.Title
+		}
+		requests = append(requests, map[string]any{
+			"requestId": q.requestID(),
+			"title":     title,
+			"kind":      derefToolKind(q.toolCall.Kind),
+			"options":   q.options,
+		})
+	}
+	return acp.SessionNotification{
+		SessionId: queued[0].sessionID,
+		Update: acp.SessionUpdate{
+			ToolCall: &acp.SessionUpdateToolCall{
+				ToolCallId:    acp.ToolCallId(batchID),
+				Title:         fmt.Sprintf("%d permission requests", len(queued)),
+				Kind:          acp.ToolKindOther,
+				Status:        acp.ToolCallStatusPending,
+				SessionUpdate: "tool_call",
+				RawInput: map[string]any{
+					"_permissionRequest": true,
+					"_permissionBatch":   true,
+					"requestId":          batchID,
+					"requests":           requests,
+				},
+			},
+		},
+	}
+}
+
+func toolCallCompletedEvent(sessionID acp.SessionId, id acp.ToolCallId) acp.SessionNotification {
+	status := acp.ToolCallStatusCompleted
+	return acp.SessionNotification{
+		SessionId: sessionID,
+		Update: acp.SessionUpdate{
+			ToolCallUpdate: &acp.SessionToolCallUpdate{
+				ToolCallId:    id,
+				Status:        &status,
+				SessionUpdate: "tool_call_update",
+			},
+		},
+	}
+}
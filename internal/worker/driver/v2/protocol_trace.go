@@ -0,0 +1,111 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// Directions recorded in a protocol trace, seen from the client side.
+const (
+	traceSend = "send" // client → agent
+	traceRecv = "recv" // agent → client
+)
+
+// traceRecord is one line of a protocol trace file.
+type traceRecord struct {
+	Timestamp string          `json:"ts"`
+	Direction string          `json:"dir"`
+	Message   json.RawMessage `json:"msg,omitempty"`
+	Text      string          `json:"text,omitempty"` // lines that are not valid JSON
+}
+
+// protocolTrace writes every ACP message exchanged on a connection to a file
+// as JSONL. ACP frames messages one per line, so both directions are split on
+// newlines before being recorded.
+type protocolTrace struct {
+	mu     sync.Mutex
+	f      *os.File
+	enc    *json.Encoder
+	closed bool
+}
+
+// openProtocolTrace creates (or truncates) the trace file at path.
+func openProtocolTrace(path string) (*protocolTrace, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open protocol trace: %w", err)
+	}
+	return &protocolTrace{f: f, enc: json.NewEncoder(f)}, nil
+}
+
+// writer returns an io.Writer that records the bytes written to it as
+// messages in the given direction.
+func (t *protocolTrace) writer(dir string) io.Writer {
+	return &traceWriter{trace: t, dir: dir}
+}
+
+// wrap tees both directions of a client-side connection into the trace.
+func (t *protocolTrace) wrap(w io.Writer, r io.Reader) (io.Writer, io.Reader) {
+	if t == nil {
+		return w, r
+	}
+	return io.MultiWriter(w, t.writer(traceSend)), io.TeeReader(r, t.writer(traceRecv))
+}
+
+func (t *protocolTrace) record(dir string, line []byte) {
+	rec := traceRecord{Timestamp: time.Now().UTC().Format(time.RFC3339Nano), Direction: dir}
+	if json.Valid(line) {
+		rec.Message = json.RawMessage(line)
+	} else {
+		rec.Text = string(line)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return
+	}
+	// A failing trace must not break the session; drop the record.
+	_ = t.enc.Encode(rec)
+}
+
+// Close closes the trace file. Messages recorded afterwards are dropped.
+func (t *protocolTrace) Close() error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed {
+		return nil
+	}
+	t.closed = true
+	return t.f.Close()
+}
+
+// traceWriter buffers partial lines until their newline arrives.
+type traceWriter struct {
+	trace *protocolTrace
+	dir   string
+	buf   []byte
+}
+
+func (w *traceWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		if line := bytes.TrimSpace(w.buf[:i]); len(line) > 0 {
+			w.trace.record(w.dir, line)
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(p), nil
+}
@@ -0,0 +1,97 @@
+package v2
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLaunch_WritesProtocolTrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return &modelAgent{} },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(ctx, LaunchOpts{Cwd: "/tmp", StatusCh: statusCh, ProtocolTracePath: path}, nil)
+	require.NoError(t, err)
+	waitForStatus(t, statusCh, SessionStatusIdle)
+
+	_, err = sess.Prompt(ctx, []acp.ContentBlock{acp.TextBlock("hi")})
+	require.NoError(t, err)
+	require.NoError(t, sess.Stop(ctx))
+	require.NoError(t, sess.Wait(ctx))
+
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	type message struct {
+		ID     *int            `json:"id"`
+		Method string          `json:"method"`
+		Result json.RawMessage `json:"result"`
+	}
+	var sent []string
+	responses := map[int]bool{}
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		var rec traceRecord
+		require.NoError(t, json.Unmarshal(sc.Bytes(), &rec))
+		assert.NotEmpty(t, rec.Timestamp)
+		var msg message
+		require.NoError(t, json.Unmarshal(rec.Message, &msg))
+		switch rec.Direction {
+		case traceSend:
+			if msg.Method != "" {
+				sent = append(sent, msg.Method)
+			}
+		case traceRecv:
+			if msg.ID != nil && msg.Result != nil {
+				responses[*msg.ID] = true
+			}
+		default:
+			t.Fatalf("unexpected direction %q", rec.Direction)
+		}
+	}
+	require.NoError(t, sc.Err())
+
+	assert.Equal(t, []string{"initialize", "session/new", "session/prompt"}, sent)
+	assert.Len(t, responses, 3, "each request's response is recorded")
+}
+
+func TestTraceWriter_SplitsLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	trace, err := openProtocolTrace(path)
+	require.NoError(t, err)
+
+	w := trace.writer(traceRecv)
+	_, _ = w.Write([]byte(`{"a":1}` + "\n" + `{"b":`))
+	_, _ = w.Write([]byte("2}\nnot json\n"))
+	require.NoError(t, trace.Close())
+	_, _ = w.Write([]byte(`{"c":3}` + "\n")) // dropped after Close
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var recs []traceRecord
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var rec traceRecord
+		require.NoError(t, json.Unmarshal([]byte(line), &rec))
+		recs = append(recs, rec)
+	}
+	require.Len(t, recs, 3)
+	assert.JSONEq(t, `{"a":1}`, string(recs[0].Message))
+	assert.JSONEq(t, `{"b":2}`, string(recs[1].Message))
+	assert.Equal(t, "not json", recs[2].Text)
+}
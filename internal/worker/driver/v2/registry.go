@@ -0,0 +1,139 @@
+package v2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sebastianm/flowgentic/internal/worker/driver"
+	"gopkg.in/yaml.v3"
+)
+
+// AgentSpec declares a subprocess ACP agent in a registry file. In-process
+// adapters have no spec; they are registered in code.
+type AgentSpec struct {
+	ID           string   `json:"id" yaml:"id"`
+	Command      string   `json:"command" yaml:"command"`
+	Args         []string `json:"args" yaml:"args"`
+	Capabilities []string `json:"capabilities" yaml:"capabilities"`
+	// Extensions names agent-specific capabilities outside
+	// driver.BuiltinCapabilities, e.g. "web_search".
+	Extensions []string `json:"extensions" yaml:"extensions"`
+	// Meta selects the builder for the NewSession/Prompt _meta field; see
+	// metaBuilders. Empty means "default".
+	Meta string `json:"meta" yaml:"meta"`
+}
+
+// AgentRegistry is the content of an agent registry file.
+type AgentRegistry struct {
+	Agents []AgentSpec `json:"agents" yaml:"agents"`
+}
+
+// metaBuilders are the MetaBuilders a registry entry can select by name.
+var metaBuilders = map[string]func(LaunchOpts) map[string]any{
+	"default": defaultMetaBuilder,
+	"none":    func(LaunchOpts) map[string]any { return nil },
+}
+
+// LoadAgentRegistry reads a registry file and returns an AgentConfig per
+// declared agent. Files ending in .yaml or .yml are parsed as YAML, anything
+// else as JSON. Unknown fields and invalid entries are errors, reported
+// together.
+func LoadAgentRegistry(path string) ([]AgentConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read agent registry: %w", err)
+	}
+
+	var reg AgentRegistry
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&reg)
+	default:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&reg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse agent registry %s: %w", path, err)
+	}
+
+	configs, err := reg.AgentConfigs()
+	if err != nil {
+		return nil, fmt.Errorf("agent registry %s: %w", path, err)
+	}
+	return configs, nil
+}
+
+// AgentConfigs validates the registry and converts its entries.
+func (r AgentRegistry) AgentConfigs() ([]AgentConfig, error) {
+	var errs []error
+	seen := make(map[string]bool, len(r.Agents))
+	configs := make([]AgentConfig, 0, len(r.Agents))
+	for i, spec := range r.Agents {
+		cfg, err := spec.agentConfig()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("agents[%d]: %w", i, err))
+			continue
+		}
+		if seen[cfg.AgentID] {
+			errs = append(errs, fmt.Errorf("agents[%d]: duplicate agent id %q", i, cfg.AgentID))
+			continue
+		}
+		seen[cfg.AgentID] = true
+		configs = append(configs, cfg)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+func (s AgentSpec) agentConfig() (AgentConfig, error) {
+	if s.ID == "" {
+		return AgentConfig{}, errors.New("id is required")
+	}
+	if s.Command == "" {
+		return AgentConfig{}, fmt.Errorf("agent %q: command is required", s.ID)
+	}
+
+	caps := make([]driver.Capability, 0, len(s.Capabilities))
+	for _, c := range s.Capabilities {
+		if !driver.Capability(c).IsBuiltin() {
+			return AgentConfig{}, fmt.Errorf("agent %q: unknown capability %q", s.ID, c)
+		}
+		caps = append(caps, driver.Capability(c))
+	}
+	for _, e := range s.Extensions {
+		switch {
+		case e == "":
+			return AgentConfig{}, fmt.Errorf("agent %q: empty extension capability", s.ID)
+		case driver.Capability(e).IsBuiltin():
+			return AgentConfig{}, fmt.Errorf("agent %q: extension %q is a built-in capability; list it under capabilities", s.ID, e)
+		}
+	}
+
+	meta := s.Meta
+	if meta == "" {
+		meta = "default"
+	}
+	builder, ok := metaBuilders[meta]
+	if !ok {
+		return AgentConfig{}, fmt.Errorf("agent %q: unknown meta builder %q", s.ID, s.Meta)
+	}
+
+	return AgentConfig{
+		AgentID:      s.ID,
+		Capabilities: caps,
+		Extensions:   s.Extensions,
+		Command:      s.Command,
+		Args:         s.Args,
+		MetaBuilder:  builder,
+	}, nil
+}
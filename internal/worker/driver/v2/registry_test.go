@@ -0,0 +1,167 @@
+package v2
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/sebastianm/flowgentic/internal/worker/driver"
+	"github.com/sebastianm/flowgentic/internal/worker/procutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const stubAgentEnv = "FLOWGENTIC_TEST_STUB_ACP_AGENT"
+
+// TestStubACPAgent is not a test: run as a subprocess with stubAgentEnv set,
+// it serves a modelAgent over stdio, standing in for an ACP agent binary.
+// With stubAgentEnv=limits its session ID reports its cgroup memory limit.
+func TestStubACPAgent(t *testing.T) {
+	var agent acp.Agent
+	switch os.Getenv(stubAgentEnv) {
+	case "1":
+		agent = &modelAgent{}
+	case "limits":
+		agent = &limitsAgent{}
+	default:
+		t.Skip("stub agent subprocess only")
+	}
+	conn := acp.NewAgentSideConnection(agent, os.Stdout, os.Stdin)
+	<-conn.Done()
+	os.Exit(0)
+}
+
+// limitsAgent names its session after the memory.max of its cgroup v2.
+type limitsAgent struct {
+	modelAgent
+}
+
+func (a *limitsAgent) NewSession(context.Context, acp.NewSessionRequest) (acp.NewSessionResponse, error) {
+	data, err := os.ReadFile("/proc/self/cgroup")
+	if err != nil {
+		return acp.NewSessionResponse{}, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			max, err := os.ReadFile(filepath.Join("/sys/fs/cgroup", path, "memory.max"))
+			if err != nil {
+				return acp.NewSessionResponse{}, err
+			}
+			return acp.NewSessionResponse{SessionId: acp.SessionId(strings.TrimSpace(string(max)))}, nil
+		}
+	}
+	return acp.NewSessionResponse{SessionId: "unknown"}, nil
+}
+
+func writeRegistry(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadAgentRegistry_LaunchesDeclaredAgent(t *testing.T) {
+	path := writeRegistry(t, "agents.yaml", `
+agents:
+  - id: stub
+    command: `+os.Args[0]+`
+    args: ["-test.run=^TestStubACPAgent$"]
+    capabilities: [streaming, custom_model]
+`)
+	configs, err := LoadAgentRegistry(path)
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	assert.Equal(t, "stub", configs[0].AgentID)
+	assert.Equal(t, []driver.Capability{driver.CapStreaming, driver.CapCustomModel}, configs[0].Capabilities)
+
+	d := NewDriver(testLogger(), configs[0])
+	assert.Equal(t, "stub", d.Capabilities().Agent)
+
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(context.Background(), LaunchOpts{
+		Cwd:      t.TempDir(),
+		EnvVars:  map[string]string{stubAgentEnv: "1"},
+		StatusCh: statusCh,
+	}, nil)
+	require.NoError(t, err)
+	defer sess.Stop(context.Background())
+	waitForStatus(t, statusCh, SessionStatusRunning)
+	assert.Equal(t, "session-1", sess.Info().AgentSessionID)
+}
+
+func TestLaunch_SubprocessRunsUnderResourceLimits(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("resource limits are enforced on Linux only")
+	}
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID: "stub",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^TestStubACPAgent$"},
+	})
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(context.Background(), LaunchOpts{
+		Cwd:            t.TempDir(),
+		EnvVars:        map[string]string{stubAgentEnv: "limits"},
+		ResourceLimits: procutil.ResourceLimits{MemoryBytes: 32 << 30},
+		StatusCh:       statusCh,
+	}, nil)
+	if err != nil {
+		// Without a writable cgroup v2 the limit cannot be enforced.
+		assert.ErrorContains(t, err, "cgroup")
+		return
+	}
+	defer sess.Stop(context.Background())
+	waitForStatus(t, statusCh, SessionStatusRunning)
+	assert.Equal(t, "34359738368", sess.Info().AgentSessionID)
+}
+
+func TestLoadAgentRegistry_Extensions(t *testing.T) {
+	path := writeRegistry(t, "agents.yaml", `
+agents:
+  - id: painter
+    command: painter-acp
+    capabilities: [streaming]
+    extensions: [image_generation]
+`)
+	configs, err := LoadAgentRegistry(path)
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+
+	caps := NewDriver(testLogger(), configs[0]).Capabilities()
+	assert.Equal(t, []string{"image_generation"}, caps.Extensions)
+	assert.True(t, caps.Has("image_generation"))
+	assert.False(t, caps.Has("web_search"))
+}
+
+func TestLoadAgentRegistry_JSON(t *testing.T) {
+	path := writeRegistry(t, "agents.json", `{"agents": [{"id": "a", "command": "a-acp", "args": ["--acp"], "meta": "none"}]}`)
+	configs, err := LoadAgentRegistry(path)
+	require.NoError(t, err)
+	require.Len(t, configs, 1)
+	assert.Equal(t, []string{"--acp"}, configs[0].Args)
+	assert.Nil(t, configs[0].MetaBuilder(LaunchOpts{Model: "m"}))
+}
+
+func TestLoadAgentRegistry_RejectsInvalidEntries(t *testing.T) {
+	cases := map[string]string{
+		"missing id":       `{"agents": [{"command": "x"}]}`,
+		"missing command":  `{"agents": [{"id": "x"}]}`,
+		"unknown cap":      `{"agents": [{"id": "x", "command": "x", "capabilities": ["teleport"]}]}`,
+		"unknown meta":     `{"agents": [{"id": "x", "command": "x", "meta": "fancy"}]}`,
+		"duplicate id":     `{"agents": [{"id": "x", "command": "x"}, {"id": "x", "command": "y"}]}`,
+		"unknown field":    `{"agents": [{"id": "x", "command": "x", "cmd": "y"}]}`,
+		"empty extension":  `{"agents": [{"id": "x", "command": "x", "extensions": [""]}]}`,
+		"builtin as ext":   `{"agents": [{"id": "x", "command": "x", "extensions": ["streaming"]}]}`,
+		"malformed syntax": `{"agents": [`,
+	}
+	for name, content := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := LoadAgentRegistry(writeRegistry(t, "agents.json", content))
+			assert.Error(t, err)
+		})
+	}
+}
@@ -2,8 +2,12 @@ package v2
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	acp "github.com/coder/acp-go-sdk"
@@ -23,15 +27,69 @@ const (
 
 // SessionInfo describes a running or completed session.
 type SessionInfo struct {
-	ID             string        `json:"id"`
-	AgentID        string        `json:"agent_id"`
-	AgentSessionID string        `json:"agent_session_id,omitempty"`
-	Status         SessionStatus `json:"status"`
-	Cwd            string        `json:"cwd"`
-	StartedAt      time.Time     `json:"started_at"`
-	Modes          []string      `json:"modes,omitempty"`  // available session modes
-	Models         []string      `json:"models,omitempty"` // available models
-	CurrentModel   string        `json:"current_model,omitempty"`
+	ID             string            `json:"id"`
+	AgentID        string            `json:"agent_id"`
+	AgentSessionID string            `json:"agent_session_id,omitempty"`
+	Status         SessionStatus     `json:"status"`
+	Cwd            string            `json:"cwd"`
+	StartedAt      time.Time         `json:"started_at"`
+	Modes          []SessionModeInfo `json:"modes,omitempty"` // modes the agent offers, if it reports any
+	CurrentMode    string            `json:"current_mode,omitempty"`
+	Models         []string          `json:"models,omitempty"` // available models
+	CurrentModel   string            `json:"current_model,omitempty"`
+	Error          *SessionError     `json:"error,omitempty"`       // set when Status is errored
+	StderrTail     []string          `json:"stderr_tail,omitempty"` // last lines the agent wrote to stderr, oldest first
+
+	// ProtocolVersion is the ACP protocol version the agent answered
+	// Initialize with; 0 if it reported none.
+	ProtocolVersion int `json:"protocol_version,omitempty"`
+
+	// LastStopReason is why the agent ended its last prompt turn, such as
+	// acp.StopReasonMaxTokens; empty until a turn has finished.
+	LastStopReason acp.StopReason `json:"last_stop_reason,omitempty"`
+
+	// Plan is the agent's current plan. Each plan update replaces it; see
+	// mergePlan for how entry statuses carry over.
+	Plan []acp.PlanEntry `json:"plan,omitempty"`
+
+	// Init is the configuration the agent reported it started with; nil
+	// until it does, and for agents that never report one.
+	Init *driver.SessionInit `json:"init,omitempty"`
+}
+
+// SessionModeInfo describes a session mode the agent offers.
+type SessionModeInfo struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+}
+
+// ErrUnknownSessionMode is returned by SetSessionMode for modes the session
+// does not offer.
+var ErrUnknownSessionMode = errors.New("unknown session mode")
+
+// ErrAddMCPServerUnsupported is returned by AddMCPServer for agents that
+// only take MCP servers when the session is created.
+var ErrAddMCPServerUnsupported = errors.New("agent cannot add MCP servers to a running session")
+
+// ErrSetAllowedToolsUnsupported is returned by SetAllowedTools for agents
+// that only take allowed tools when the session is created.
+var ErrSetAllowedToolsUnsupported = errors.New("agent cannot change the allowed tools of a running session")
+
+// ErrSessionStopping is returned by Prompt once SoftStop was called.
+var ErrSessionStopping = errors.New("session is stopping")
+
+// ErrorReasonAuth marks sessions that failed because the agent rejected its
+// credentials (e.g. an expired API key); the user has to re-authenticate.
+const ErrorReasonAuth = "auth"
+
+// SessionError describes why a session entered SessionStatusErrored.
+type SessionError struct {
+	Reason  string `json:"reason,omitempty"` // e.g. ErrorReasonAuth; empty for other failures
+	Message string `json:"message"`
+	// StderrTail is the agent's stderr up to the failure, which often names
+	// the actual cause.
+	StderrTail []string `json:"stderr_tail,omitempty"`
 }
 
 // Session represents a running ACP agent session.
@@ -39,15 +97,41 @@ type Session interface {
 	Info() SessionInfo
 	Prompt(ctx context.Context, blocks []acp.ContentBlock) (*acp.PromptResponse, error)
 	Cancel(ctx context.Context) error
+	// Stop asks the session to shut down and returns without waiting for
+	// the teardown to finish; use Wait for that.
 	Stop(ctx context.Context) error
+	// SoftStop stops the session once the turn in flight, if any, has ended.
+	// Tool calls already running complete, but further permission requests
+	// are denied, the turn is cancelled once no tool call is running, and
+	// Prompt returns ErrSessionStopping. Like Stop, it does not wait for the
+	// session to end.
+	SoftStop(ctx context.Context) error
+	// Wait blocks until the session has fully stopped, including its agent
+	// subprocess or in-process adapter, or until ctx is done.
 	Wait(ctx context.Context) error
 	RespondToPermission(ctx context.Context, requestID string, allow bool, reason string) error
 	SetSessionMode(ctx context.Context, mode driver.SessionMode) error
+	SetModel(ctx context.Context, model string) error
+	// AddMCPServer registers server on the running session, or returns
+	// ErrAddMCPServerUnsupported.
+	AddMCPServer(ctx context.Context, server acp.McpServer) error
+	// SetAllowedTools replaces the tools the running session may use
+	// without asking, or returns ErrSetAllowedToolsUnsupported.
+	SetAllowedTools(ctx context.Context, tools []string) error
+	// SetHostCommands replaces the slash commands offered in addition to
+	// the agent's own and announces the combined list to the client.
+	SetHostCommands(ctx context.Context, cmds []acp.AvailableCommand) error
 }
 
-// promptRequest is sent over promptCh to request a new prompt turn.
+// promptRequest is sent over promptCh to request a new prompt turn. ctx is
+// the caller's; the turn is cancelled if its deadline passes.
 type promptRequest struct {
-	blocks   []acp.ContentBlock
+	ctx    context.Context
+	blocks []acp.ContentBlock
+	// command, if set, is the host slash command blocks start with. The
+	// session runs it between turns and only sends blocks to the agent if
+	// it says so.
+	command  *slashCommand
 	resultCh chan promptResult
 }
 
@@ -65,17 +149,142 @@ type acpSession struct {
 	cancel   context.CancelFunc
 	done     chan struct{}
 	statusCh chan<- SessionStatus // optional push-based status notifications
+	trace    *protocolTrace       // optional raw protocol capture, closed when the session ends
 
 	promptCh chan promptRequest
-	cancelCh chan struct{}
+	// onTurnEnded is LaunchOpts.OnTurnEnded, told at the end of runTurn.
+	onTurnEnded TurnCallback
+	// promptsWaiting counts Prompt calls not yet taken by the session loop,
+	// and autoTurn is set while an auto-continued turn runs; a user prompt
+	// preempts auto-continue. See startAutoTurn.
+	promptsWaiting atomic.Int32
+	autoTurn       atomic.Bool
+
+	// stopping is closed by SoftStop; the session ends once it is idle.
+	stopping     chan struct{}
+	softStopOnce sync.Once
+
+	// modelAliases resolves short model names passed to SetModel.
+	modelAliases map[string]string
+	// slashCommands are run by Prompt instead of the agent.
+	slashCommands SlashCommands
+
+	// stderr keeps the agent's last stderr lines; stderrLog logs each one.
+	stderr    *driver.StderrTail
+	stderrLog func(line string)
+
+	// mcpAdder is the in-process adapter, if it can add MCP servers live.
+	mcpAdder MCPServerAdder
+	// toolsSetter is the in-process adapter, if it can change the allowed
+	// tools live.
+	toolsSetter AllowedToolsSetter
+	// pinger is the in-process adapter, if it can check its agent answers.
+	pinger Pinger
+
+	// releaseLimits frees what enforcing LaunchOpts.ResourceLimits set up
+	// for the agent subprocess; it runs once the process has been reaped.
+	releaseLimits func()
+
+	// commandsMu serializes announcing the available commands; see
+	// forwardCommands.
+	commandsMu    sync.Mutex
+	agentCommands []acp.AvailableCommand // last list the agent announced
+	hostCommands  []acp.AvailableCommand // set by SetHostCommands
+
+	// planSeq is the read order of the plan update info.Plan was last
+	// replaced by, planEntries that update's entries as sent, and planPrev
+	// the plan they were merged onto, read planPrevSeq-th. All are guarded
+	// by mu; see replacePlan.
+	planSeq     int64
+	planEntries []acp.PlanEntry
+	planPrev    []acp.PlanEntry
+	planPrevSeq int64
 
 	mu sync.Mutex
 }
 
 func (s *acpSession) Info() SessionInfo {
+	s.mu.Lock()
+	info := s.info
+	info.Plan = slices.Clone(s.info.Plan)
+	s.mu.Unlock()
+	info.StderrTail = s.stderr.Lines()
+	return info
+}
+
+// replacePlan makes entries, from the plan update read seq-th, the session's
+// current plan and returns the merged plan. The connection handles updates
+// concurrently, so an update may be handled after a later one. It then only
+// lends its statuses to the entries of the later update that have none, as
+// if handled in order, and is not ok if that changes nothing. A seq of 0 is
+// unordered and always replaces the plan.
+func (s *acpSession) replacePlan(entries []acp.PlanEntry, seq int64) ([]acp.PlanEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if seq == 0 || seq > s.planSeq {
+		s.planPrev, s.planPrevSeq = s.info.Plan, s.planSeq
+		s.planEntries, s.planSeq = entries, max(s.planSeq, seq)
+		s.info.Plan = mergePlan(s.info.Plan, entries)
+		return slices.Clone(s.info.Plan), true
+	}
+	if seq <= s.planPrevSeq {
+		return nil, false
+	}
+	s.planPrev, s.planPrevSeq = mergePlan(s.planPrev, entries), seq
+	plan := mergePlan(s.planPrev, s.planEntries)
+	if slices.EqualFunc(plan, s.info.Plan, func(a, b acp.PlanEntry) bool { return a.Status == b.Status }) {
+		return nil, false
+	}
+	s.info.Plan = plan
+	return slices.Clone(plan), true
+}
+
+// recordInit stores the session configuration the agent reported.
+func (s *acpSession) recordInit(cfg driver.SessionInit) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return s.info
+	s.info.Init = &cfg
+}
+
+// recordMode stores the mode the agent reports it switched to, e.g. when it
+// leaves plan mode by itself.
+func (s *acpSession) recordMode(mode string) {
+	if mode == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.info.CurrentMode = mode
+}
+
+// mergePlan returns next as the new plan. ACP plan updates carry the complete
+// list, so next replaces prev; an entry of next without a status keeps the
+// status of the prev entry with the same content.
+func mergePlan(prev, next []acp.PlanEntry) []acp.PlanEntry {
+	statuses := make(map[string]acp.PlanEntryStatus, len(prev))
+	for _, e := range prev {
+		statuses[e.Content] = e.Status
+	}
+	merged := make([]acp.PlanEntry, len(next))
+	for i, e := range next {
+		if e.Status == "" {
+			e.Status = statuses[e.Content]
+		}
+		merged[i] = e
+	}
+	return merged
+}
+
+// noteStderr records a line the agent wrote to stderr.
+func (s *acpSession) noteStderr(line string) {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return
+	}
+	s.stderr.Add(line)
+	if s.stderrLog != nil {
+		s.stderrLog(line)
+	}
 }
 
 func (s *acpSession) setStatus(status SessionStatus) {
@@ -92,6 +301,21 @@ func (s *acpSession) setStatus(status SessionStatus) {
 	}
 }
 
+// fail records why the session failed and moves it to SessionStatusErrored.
+// Authentication failures are classified so clients can ask the user to
+// re-authenticate instead of showing a generic error.
+func (s *acpSession) fail(err error) {
+	se := &SessionError{Message: err.Error()}
+	if msg, ok := driver.AuthFailureMessage(err); ok {
+		se = &SessionError{Reason: ErrorReasonAuth, Message: msg}
+	}
+	se.StderrTail = s.stderr.Lines()
+	s.mu.Lock()
+	s.info.Error = se
+	s.mu.Unlock()
+	s.setStatus(SessionStatusErrored)
+}
+
 func (s *acpSession) closeStatusCh() {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -102,15 +326,32 @@ func (s *acpSession) closeStatusCh() {
 }
 
 func (s *acpSession) Prompt(ctx context.Context, blocks []acp.ContentBlock) (*acp.PromptResponse, error) {
+	if s.softStopped() {
+		return nil, ErrSessionStopping
+	}
+	command, err := s.slashCommand(blocks)
+	if err != nil {
+		return nil, err
+	}
 	req := promptRequest{
+		ctx:      ctx,
 		blocks:   blocks,
+		command:  command,
 		resultCh: make(chan promptResult, 1),
 	}
+	s.promptsWaiting.Add(1)
+	if s.autoTurn.Load() {
+		// Don't wait for the agent to finish a turn the user did not ask for.
+		_ = s.Cancel(ctx)
+	}
 	select {
 	case s.promptCh <- req:
+		s.promptsWaiting.Add(-1)
 	case <-ctx.Done():
+		s.promptsWaiting.Add(-1)
 		return nil, ctx.Err()
 	case <-s.done:
+		s.promptsWaiting.Add(-1)
 		return nil, fmt.Errorf("session closed")
 	}
 	select {
@@ -123,42 +364,175 @@ func (s *acpSession) Prompt(ctx context.Context, blocks []acp.ContentBlock) (*ac
 	}
 }
 
-func (s *acpSession) Cancel(_ context.Context) error {
-	select {
-	case s.cancelCh <- struct{}{}:
-	default:
+// Cancel asks the agent to cancel the prompt in flight. The prompt still
+// returns through Prompt, after the agent has sent the partial response, and
+// only then does the session report idle.
+func (s *acpSession) Cancel(ctx context.Context) error {
+	s.mu.Lock()
+	conn := s.conn
+	sessionID := s.info.AgentSessionID
+	s.mu.Unlock()
+
+	if conn == nil || sessionID == "" {
+		return nil
 	}
-	return nil
+	return conn.Cancel(ctx, acp.CancelNotification{SessionId: acp.SessionId(sessionID)})
 }
 
 func (s *acpSession) Stop(_ context.Context) error {
 	s.cancel()
-	<-s.done
 	return nil
 }
 
-func (s *acpSession) Wait(_ context.Context) error {
-	<-s.done
+// SoftStop denies the permission requests of tool calls that have not
+// started, including those waiting for an answer, and makes the session loop
+// stop once the current turn has ended instead of continuing it or taking
+// another prompt. Agents run some tools without asking, so the turn is
+// cancelled as soon as no tool call is running, which lets the ones in
+// flight finish but starts no more.
+func (s *acpSession) SoftStop(_ context.Context) error {
+	s.softStopOnce.Do(func() {
+		s.client.softStop(func() {
+			if s.Info().Status == SessionStatusRunning {
+				_ = s.Cancel(context.Background())
+			}
+		})
+		close(s.stopping)
+	})
 	return nil
 }
 
+func (s *acpSession) softStopped() bool {
+	select {
+	case <-s.stopping:
+		return true
+	default:
+		return false
+	}
+}
+
+// startAutoTurn marks an auto-continued turn as running, unless a user
+// prompt is waiting. Prompt checks the mark after counting itself, so a
+// prompt either stops the turn from starting or cancels it.
+func (s *acpSession) startAutoTurn() bool {
+	s.autoTurn.Store(true)
+	if s.promptsWaiting.Load() > 0 {
+		s.autoTurn.Store(false)
+		return false
+	}
+	return true
+}
+
+func (s *acpSession) Wait(ctx context.Context) error {
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 func (s *acpSession) RespondToPermission(_ context.Context, requestID string, allow bool, _ string) error {
 	return s.client.resolvePermission(requestID, allow)
 }
 
+// SetSessionMode switches the agent to mode. Agents that report their modes
+// accept only those; for others mode must be a standard driver.SessionMode.
 func (s *acpSession) SetSessionMode(ctx context.Context, mode driver.SessionMode) error {
 	s.mu.Lock()
 	conn := s.conn
 	sessionID := s.info.AgentSessionID
+	modes := s.info.Modes
 	s.mu.Unlock()
 
+	if err := checkSessionMode(modes, mode); err != nil {
+		return err
+	}
 	if conn == nil {
 		return fmt.Errorf("session not connected")
 	}
 
-	_, err := conn.SetSessionMode(ctx, acp.SetSessionModeRequest{
+	if _, err := conn.SetSessionMode(ctx, acp.SetSessionModeRequest{
 		SessionId: acp.SessionId(sessionID),
 		ModeId:    acp.SessionModeId(mode),
-	})
-	return err
+	}); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.info.CurrentMode = string(mode)
+	s.mu.Unlock()
+	return nil
+}
+
+func checkSessionMode(modes []SessionModeInfo, mode driver.SessionMode) error {
+	if len(modes) == 0 {
+		if _, err := driver.ParseSessionMode(string(mode)); err != nil {
+			return fmt.Errorf("%w %q", ErrUnknownSessionMode, mode)
+		}
+		return nil
+	}
+	ids := make([]string, len(modes))
+	for i, m := range modes {
+		if m.ID == string(mode) {
+			return nil
+		}
+		ids[i] = m.ID
+	}
+	return fmt.Errorf("%w %q (available: %s)", ErrUnknownSessionMode, mode, strings.Join(ids, ", "))
+}
+
+// SetModel switches the agent to model, resolving ModelAliases, for
+// subsequent prompts and records it as the session's current model.
+func (s *acpSession) SetModel(ctx context.Context, model string) error {
+	model = resolveModel(s.modelAliases, model)
+
+	s.mu.Lock()
+	conn := s.conn
+	sessionID := s.info.AgentSessionID
+	s.mu.Unlock()
+
+	if conn == nil {
+		return fmt.Errorf("session not connected")
+	}
+
+	if _, err := conn.SetSessionModel(ctx, acp.SetSessionModelRequest{
+		SessionId: acp.SessionId(sessionID),
+		ModelId:   acp.ModelId(model),
+	}); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.info.CurrentModel = model
+	s.mu.Unlock()
+	return nil
+}
+
+// AddMCPServer asks the adapter to start server for the running session.
+func (s *acpSession) AddMCPServer(ctx context.Context, server acp.McpServer) error {
+	if s.mcpAdder == nil {
+		return ErrAddMCPServerUnsupported
+	}
+	s.mu.Lock()
+	sessionID := s.info.AgentSessionID
+	s.mu.Unlock()
+	if sessionID == "" {
+		return fmt.Errorf("session not started")
+	}
+	return s.mcpAdder.AddMCPServer(ctx, acp.SessionId(sessionID), server)
+}
+
+// SetAllowedTools asks the adapter to replace the session's allowed tools.
+func (s *acpSession) SetAllowedTools(ctx context.Context, tools []string) error {
+	if s.toolsSetter == nil {
+		return ErrSetAllowedToolsUnsupported
+	}
+	s.mu.Lock()
+	sessionID := s.info.AgentSessionID
+	s.mu.Unlock()
+	if sessionID == "" {
+		return fmt.Errorf("session not started")
+	}
+	return s.toolsSetter.SetAllowedTools(ctx, acp.SessionId(sessionID), tools)
 }
@@ -0,0 +1,1346 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/sebastianm/flowgentic/internal/worker/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingPromptAgent fails every prompt with promptErr.
+type failingPromptAgent struct {
+	modelAgent
+	promptErr error
+}
+
+func (a *failingPromptAgent) Prompt(context.Context, acp.PromptRequest) (acp.PromptResponse, error) {
+	return acp.PromptResponse{}, a.promptErr
+}
+
+func launchFailingPrompt(t *testing.T, promptErr error, prompt string) (Session, <-chan SessionStatus) {
+	t.Helper()
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID: "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent {
+			return &failingPromptAgent{promptErr: promptErr}
+		},
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(ctx, LaunchOpts{Cwd: "/tmp", Prompt: prompt, StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	return sess, statusCh
+}
+
+func TestLaunch_AuthFailureMarksSessionErrored(t *testing.T) {
+	sess, statusCh := launchFailingPrompt(t, driver.NewAuthRequiredError("Invalid API key · Please run /login"), "hello")
+	waitForStatus(t, statusCh, SessionStatusErrored)
+
+	info := sess.Info()
+	require.NotNil(t, info.Error)
+	assert.Equal(t, ErrorReasonAuth, info.Error.Reason)
+	assert.Equal(t, "Invalid API key · Please run /login", info.Error.Message)
+}
+
+func TestLaunch_OtherFailureHasNoAuthReason(t *testing.T) {
+	sess, statusCh := launchFailingPrompt(t, errors.New("boom"), "hello")
+	waitForStatus(t, statusCh, SessionStatusErrored)
+
+	info := sess.Info()
+	require.NotNil(t, info.Error)
+	assert.Empty(t, info.Error.Reason)
+	assert.Contains(t, info.Error.Message, "boom")
+}
+
+func TestPrompt_AuthFailureEndsSession(t *testing.T) {
+	sess, statusCh := launchFailingPrompt(t, driver.NewAuthRequiredError("OAuth token has expired"), "")
+	waitForStatus(t, statusCh, SessionStatusIdle)
+
+	_, err := sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("hi")})
+	require.Error(t, err)
+	waitForStatus(t, statusCh, SessionStatusErrored)
+	require.NoError(t, sess.Wait(context.Background()))
+	assert.Equal(t, ErrorReasonAuth, sess.Info().Error.Reason)
+}
+
+func TestPrompt_OtherFailureKeepsSessionUsable(t *testing.T) {
+	sess, statusCh := launchFailingPrompt(t, errors.New("boom"), "")
+	waitForStatus(t, statusCh, SessionStatusIdle)
+
+	_, err := sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("hi")})
+	require.Error(t, err)
+	waitForStatus(t, statusCh, SessionStatusIdle)
+	assert.Nil(t, sess.Info().Error)
+}
+
+// failingNewSessionAgent rejects every NewSession call.
+type failingNewSessionAgent struct {
+	modelAgent
+}
+
+func (a *failingNewSessionAgent) NewSession(context.Context, acp.NewSessionRequest) (acp.NewSessionResponse, error) {
+	return acp.NewSessionResponse{}, errors.New("model gpt-nope not found")
+}
+
+func TestLaunch_NewSessionFailureRecordsError(t *testing.T) {
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID: "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent {
+			return &failingNewSessionAgent{}
+		},
+	})
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	waitForStatus(t, statusCh, SessionStatusErrored)
+	require.NoError(t, sess.Wait(context.Background()))
+
+	info := sess.Info()
+	require.NotNil(t, info.Error, "errored sessions keep their cause")
+	assert.Empty(t, info.Error.Reason)
+	assert.Contains(t, info.Error.Message, "model gpt-nope not found")
+}
+
+// stderrAgent prints to the stderr sink of its "subprocess" before failing
+// NewSession, like an adapter whose CLI crashed on startup.
+type stderrAgent struct {
+	modelAgent
+	stderr func(line string)
+}
+
+func (a *stderrAgent) SetStderr(fn func(line string)) { a.stderr = fn }
+
+func (a *stderrAgent) NewSession(context.Context, acp.NewSessionRequest) (acp.NewSessionResponse, error) {
+	a.stderr("loading config")
+	a.stderr("  ")
+	a.stderr("error: invalid config.toml")
+	return acp.NewSessionResponse{}, errors.New("app-server exited")
+}
+
+func TestLaunch_InProcessStderrInFailure(t *testing.T) {
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID: "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent {
+			return &stderrAgent{}
+		},
+	}, WithStderr(2, slog.LevelWarn))
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	waitForStatus(t, statusCh, SessionStatusErrored)
+	require.NoError(t, sess.Wait(context.Background()))
+
+	info := sess.Info()
+	want := []string{"loading config", "error: invalid config.toml"}
+	assert.Equal(t, want, info.StderrTail)
+	require.NotNil(t, info.Error)
+	assert.Equal(t, want, info.Error.StderrTail)
+}
+
+func TestLaunch_SubprocessStderrIsCaptured(t *testing.T) {
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID: "test-agent",
+		Command: "sh",
+		Args:    []string{"-c", "echo starting >&2; echo 'fatal: unknown flag --acp' >&2; exit 2"},
+	})
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(context.Background(), LaunchOpts{Cwd: t.TempDir(), StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	waitForStatus(t, statusCh, SessionStatusErrored)
+	require.NoError(t, sess.Wait(context.Background()))
+
+	assert.Equal(t, []string{"starting", "fatal: unknown flag --acp"}, sess.Info().StderrTail)
+}
+
+func TestLineWriter_SplitsLines(t *testing.T) {
+	var lines []string
+	w := &lineWriter{fn: func(line string) { lines = append(lines, line) }}
+	_, _ = w.Write([]byte("one\ntw"))
+	_, _ = w.Write([]byte("o\nthree"))
+	assert.Equal(t, []string{"one", "two"}, lines)
+
+	_, _ = w.Write([]byte(strings.Repeat("x", maxStderrLine)))
+	require.Len(t, lines, 3)
+	assert.Equal(t, "three"+strings.Repeat("x", maxStderrLine-len("three")), lines[2])
+}
+
+// resumableAgent records LoadSession/NewSession calls. loadable controls the
+// loadSession capability it advertises.
+type resumableAgent struct {
+	modelAgent
+	loadable bool
+
+	mu        sync.Mutex
+	loaded    []acp.LoadSessionRequest
+	newCalled bool
+}
+
+func (a *resumableAgent) Initialize(ctx context.Context, req acp.InitializeRequest) (acp.InitializeResponse, error) {
+	resp, err := a.modelAgent.Initialize(ctx, req)
+	resp.AgentCapabilities.LoadSession = a.loadable
+	return resp, err
+}
+
+func (a *resumableAgent) NewSession(ctx context.Context, req acp.NewSessionRequest) (acp.NewSessionResponse, error) {
+	a.mu.Lock()
+	a.newCalled = true
+	a.mu.Unlock()
+	return a.modelAgent.NewSession(ctx, req)
+}
+
+func (a *resumableAgent) LoadSession(_ context.Context, req acp.LoadSessionRequest) (acp.LoadSessionResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.loaded = append(a.loaded, req)
+	return acp.LoadSessionResponse{
+		Models: &acp.SessionModelState{
+			AvailableModels: []acp.ModelInfo{{ModelId: "anthropic/claude-sonnet-4", Name: "Claude Sonnet 4"}},
+			CurrentModelId:  "anthropic/claude-sonnet-4",
+		},
+	}, nil
+}
+
+// launchResume launches a session resuming agentSessionID with the OpenCode
+// capabilities against an in-process stub agent.
+func launchResume(t *testing.T, agent *resumableAgent, agentSessionID string) (Session, <-chan SessionStatus) {
+	t.Helper()
+	cfg := OpenCodeConfig
+	cfg.Command = ""
+	cfg.AdapterFactory = func(*slog.Logger) acp.Agent { return agent }
+	d := NewDriver(testLogger(), cfg)
+	require.True(t, d.Capabilities().Supports(driver.CapSessionResume))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(ctx, LaunchOpts{Cwd: "/work", ResumeSessionID: agentSessionID, StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	return sess, statusCh
+}
+
+func TestLaunch_ResumeLoadsExistingSession(t *testing.T) {
+	agent := &resumableAgent{loadable: true}
+	sess, statusCh := launchResume(t, agent, "ses_abc123")
+	waitForStatus(t, statusCh, SessionStatusIdle)
+
+	agent.mu.Lock()
+	require.Len(t, agent.loaded, 1)
+	assert.Equal(t, acp.SessionId("ses_abc123"), agent.loaded[0].SessionId)
+	assert.Equal(t, "/work", agent.loaded[0].Cwd)
+	assert.False(t, agent.newCalled, "resume must not create a new session")
+	agent.mu.Unlock()
+
+	info := sess.Info()
+	assert.Equal(t, "ses_abc123", info.ID)
+	assert.Equal(t, "ses_abc123", info.AgentSessionID, "agent session ID is kept for the next resume")
+	assert.Equal(t, "anthropic/claude-sonnet-4", info.CurrentModel)
+	assert.Nil(t, info.Error)
+}
+
+func TestLaunch_ResumeFailsWhenAgentCannotLoad(t *testing.T) {
+	agent := &resumableAgent{loadable: false}
+	sess, statusCh := launchResume(t, agent, "ses_abc123")
+	waitForStatus(t, statusCh, SessionStatusErrored)
+
+	agent.mu.Lock()
+	assert.Empty(t, agent.loaded)
+	assert.False(t, agent.newCalled)
+	agent.mu.Unlock()
+	require.NotNil(t, sess.Info().Error)
+	assert.Contains(t, sess.Info().Error.Message, "does not support loading session ses_abc123")
+}
+
+func TestLaunch_SessionIDDoesNotResume(t *testing.T) {
+	agent := &resumableAgent{loadable: true}
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(*slog.Logger) acp.Agent { return agent },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(ctx, LaunchOpts{Cwd: "/work", SessionID: "run-42", StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "run-42", sess.Info().ID)
+	waitForStatus(t, statusCh, SessionStatusIdle)
+
+	agent.mu.Lock()
+	assert.Empty(t, agent.loaded, "a caller-chosen ID must not load a session")
+	assert.True(t, agent.newCalled)
+	agent.mu.Unlock()
+	assert.Equal(t, "session-1", sess.Info().AgentSessionID)
+}
+
+func TestWait_ReturnsAfterStop(t *testing.T) {
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return &modelAgent{} },
+	})
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	waitForStatus(t, statusCh, SessionStatusRunning)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, sess.Wait(ctx), context.DeadlineExceeded, "Wait blocks while the session runs")
+
+	require.NoError(t, sess.Stop(context.Background()))
+	ctx, cancel = context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, sess.Wait(ctx))
+	assert.Equal(t, SessionStatusStopped, sess.Info().Status)
+}
+
+// mcpAgent is a modelAgent that can add MCP servers to a running session.
+type mcpAgent struct {
+	modelAgent
+	mu      sync.Mutex
+	added   []acp.McpServer
+	session acp.SessionId
+}
+
+func (a *mcpAgent) AddMCPServer(_ context.Context, sessionID acp.SessionId, server acp.McpServer) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.session = sessionID
+	a.added = append(a.added, server)
+	return nil
+}
+
+func TestAddMCPServer(t *testing.T) {
+	server := acp.McpServer{Http: &acp.McpServerHttp{Name: "db", Url: "http://127.0.0.1:7000/mcp", Headers: []acp.HttpHeader{}}}
+
+	agent := &mcpAgent{}
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+	})
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	defer sess.Stop(context.Background())
+	waitForStatus(t, statusCh, SessionStatusRunning)
+
+	require.NoError(t, sess.AddMCPServer(context.Background(), server))
+	agent.mu.Lock()
+	assert.Equal(t, []acp.McpServer{server}, agent.added)
+	assert.Equal(t, acp.SessionId("session-1"), agent.session)
+	agent.mu.Unlock()
+
+	d = NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return &modelAgent{} },
+	})
+	statusCh = make(chan SessionStatus, 8)
+	sess, err = d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	defer sess.Stop(context.Background())
+	waitForStatus(t, statusCh, SessionStatusRunning)
+	assert.ErrorIs(t, sess.AddMCPServer(context.Background(), server), ErrAddMCPServerUnsupported)
+}
+
+// toolsAgent is a modelAgent that can change the allowed tools live.
+type toolsAgent struct {
+	modelAgent
+	mu      sync.Mutex
+	tools   []string
+	session acp.SessionId
+}
+
+func (a *toolsAgent) SetAllowedTools(_ context.Context, sessionID acp.SessionId, tools []string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.session = sessionID
+	a.tools = tools
+	return nil
+}
+
+func TestSetAllowedTools(t *testing.T) {
+	agent := &toolsAgent{}
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+	})
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	defer sess.Stop(context.Background())
+	waitForStatus(t, statusCh, SessionStatusRunning)
+
+	require.NoError(t, sess.SetAllowedTools(context.Background(), []string{"Read"}))
+	agent.mu.Lock()
+	assert.Equal(t, []string{"Read"}, agent.tools)
+	assert.Equal(t, acp.SessionId("session-1"), agent.session)
+	agent.mu.Unlock()
+
+	d = NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return &modelAgent{} },
+	})
+	statusCh = make(chan SessionStatus, 8)
+	sess, err = d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	defer sess.Stop(context.Background())
+	waitForStatus(t, statusCh, SessionStatusRunning)
+	assert.ErrorIs(t, sess.SetAllowedTools(context.Background(), []string{"Read"}), ErrSetAllowedToolsUnsupported)
+}
+
+// commandsAgent announces its slash commands on every prompt.
+type commandsAgent struct {
+	modelAgent
+	conn *acp.AgentSideConnection
+}
+
+func (a *commandsAgent) SetConnection(conn *acp.AgentSideConnection) { a.conn = conn }
+
+func (a *commandsAgent) Prompt(ctx context.Context, req acp.PromptRequest) (acp.PromptResponse, error) {
+	_ = a.conn.SessionUpdate(ctx, acp.SessionNotification{SessionId: req.SessionId, Update: acp.SessionUpdate{
+		AvailableCommandsUpdate: &acp.SessionAvailableCommandsUpdate{
+			AvailableCommands: []acp.AvailableCommand{{Name: "review", Description: "review changes"}},
+		},
+	}})
+	return acp.PromptResponse{StopReason: acp.StopReasonEndTurn}, nil
+}
+
+func TestSetHostCommands(t *testing.T) {
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return &commandsAgent{} },
+	})
+	var mu sync.Mutex
+	var announced [][]acp.AvailableCommand
+	onEvent := func(n acp.SessionNotification) {
+		if u := n.Update.AvailableCommandsUpdate; u != nil {
+			mu.Lock()
+			announced = append(announced, u.AvailableCommands)
+			mu.Unlock()
+		}
+	}
+	latest := func(n int) []acp.AvailableCommand {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(announced) == n
+		}, 2*time.Second, 5*time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		return announced[n-1]
+	}
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, onEvent)
+	require.NoError(t, err)
+	defer sess.Stop(context.Background())
+	waitForStatus(t, statusCh, SessionStatusRunning)
+
+	deploy := acp.AvailableCommand{
+		Name:        "deploy",
+		Description: "deploy the branch",
+		Input:       &acp.AvailableCommandInput{UnstructuredCommandInput: &acp.AvailableCommandUnstructuredCommandInput{Hint: "environment"}},
+	}
+	hostReview := acp.AvailableCommand{Name: "review", Description: "host review"}
+	require.NoError(t, sess.SetHostCommands(context.Background(), []acp.AvailableCommand{deploy, hostReview}))
+	assert.Equal(t, []acp.AvailableCommand{deploy, hostReview}, latest(1), "pushed commands reach the client")
+
+	_, err = sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("hi")})
+	require.NoError(t, err)
+	agentReview := acp.AvailableCommand{Name: "review", Description: "review changes"}
+	assert.Equal(t, []acp.AvailableCommand{agentReview, deploy}, latest(2), "the agent's update keeps the host commands, and its own win")
+
+	_, err = sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("/deploy staging")})
+	assert.ErrorIs(t, err, ErrNoSlashCommandHandler, "a host command without a handler does not reach the agent")
+
+	require.NoError(t, sess.SetHostCommands(context.Background(), nil))
+	assert.Equal(t, []acp.AvailableCommand{agentReview}, latest(3), "clearing leaves the agent's commands")
+}
+
+// stopAgent ends every prompt turn with stopReason.
+type stopAgent struct {
+	modelAgent
+	stopReason acp.StopReason
+}
+
+func (a *stopAgent) Prompt(context.Context, acp.PromptRequest) (acp.PromptResponse, error) {
+	return acp.PromptResponse{StopReason: a.stopReason}, nil
+}
+
+func TestSessionInfo_LastStopReason(t *testing.T) {
+	for _, reason := range []acp.StopReason{acp.StopReasonEndTurn, acp.StopReasonMaxTokens, acp.StopReasonRefusal} {
+		t.Run(string(reason), func(t *testing.T) {
+			d := NewDriver(testLogger(), AgentConfig{
+				AgentID:        "test-agent",
+				AdapterFactory: func(_ *slog.Logger) acp.Agent { return &stopAgent{stopReason: reason} },
+			})
+			sess, err := d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", Prompt: "hi"}, nil)
+			require.NoError(t, err)
+			defer sess.Stop(context.Background())
+			require.Eventually(t, func() bool { return sess.Info().Status == SessionStatusIdle }, time.Second, time.Millisecond)
+			assert.Equal(t, reason, sess.Info().LastStopReason, "initial prompt")
+
+			_, err = sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("again")})
+			require.NoError(t, err)
+			assert.Equal(t, reason, sess.Info().LastStopReason, "follow-up prompt")
+		})
+	}
+}
+
+// planAgent sends two plan updates per prompt: the first lists both steps,
+// the second marks the first step done and leaves the other without a status.
+type planAgent struct {
+	modelAgent
+	conn *acp.AgentSideConnection
+}
+
+func (a *planAgent) SetConnection(conn *acp.AgentSideConnection) { a.conn = conn }
+
+func (a *planAgent) Prompt(_ context.Context, req acp.PromptRequest) (acp.PromptResponse, error) {
+	send := func(entries ...acp.PlanEntry) {
+		_ = a.conn.SessionUpdate(context.Background(), acp.SessionNotification{SessionId: req.SessionId, Update: acp.UpdatePlan(entries...)})
+	}
+	send(
+		acp.PlanEntry{Content: "read", Priority: acp.PlanEntryPriorityHigh, Status: acp.PlanEntryStatusInProgress},
+		acp.PlanEntry{Content: "write", Priority: acp.PlanEntryPriorityLow, Status: acp.PlanEntryStatusPending},
+	)
+	send(
+		acp.PlanEntry{Content: "read", Priority: acp.PlanEntryPriorityHigh, Status: acp.PlanEntryStatusCompleted},
+		acp.PlanEntry{Content: "write", Priority: acp.PlanEntryPriorityMedium},
+	)
+	return acp.PromptResponse{StopReason: acp.StopReasonEndTurn}, nil
+}
+
+func TestSessionInfo_PlanReplacedByLatestUpdate(t *testing.T) {
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return &planAgent{} },
+	})
+
+	var mu sync.Mutex
+	var plans [][]acp.PlanEntry
+	onEvent := func(n acp.SessionNotification) {
+		if p := n.Update.Plan; p != nil {
+			mu.Lock()
+			plans = append(plans, p.Entries)
+			mu.Unlock()
+		}
+	}
+	sess, err := d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", Prompt: "hi"}, onEvent)
+	require.NoError(t, err)
+	defer sess.Stop(context.Background())
+
+	want := []acp.PlanEntry{
+		{Content: "read", Priority: acp.PlanEntryPriorityHigh, Status: acp.PlanEntryStatusCompleted},
+		{Content: "write", Priority: acp.PlanEntryPriorityMedium, Status: acp.PlanEntryStatusPending},
+	}
+	require.Eventually(t, func() bool { return sess.Info().Status == SessionStatusIdle }, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, want, sess.Info().Plan)
+	mu.Lock()
+	require.NotEmpty(t, plans)
+	// The first update is dropped if the connection handles it last.
+	assert.Equal(t, want, plans[len(plans)-1], "the forwarded update carries the consolidated plan")
+	mu.Unlock()
+}
+
+// initAgent reports its session configuration on the first prompt.
+type initAgent struct {
+	modelAgent
+	conn *acp.AgentSideConnection
+}
+
+func (a *initAgent) SetConnection(conn *acp.AgentSideConnection) { a.conn = conn }
+
+func (a *initAgent) Prompt(ctx context.Context, req acp.PromptRequest) (acp.PromptResponse, error) {
+	u := driver.SessionInitUpdate(driver.SessionInit{Model: "opus", PermissionMode: "plan", Tools: []string{"Read"}})
+	_ = a.conn.SessionUpdate(ctx, acp.SessionNotification{SessionId: req.SessionId, Update: u})
+	return acp.PromptResponse{StopReason: acp.StopReasonEndTurn}, nil
+}
+
+func TestSessionInfo_RecordsReportedInit(t *testing.T) {
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return &initAgent{} },
+	})
+	sess, err := d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", Prompt: "hi"}, nil)
+	require.NoError(t, err)
+	defer sess.Stop(context.Background())
+
+	require.Eventually(t, func() bool { return sess.Info().Init != nil }, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, &driver.SessionInit{Model: "opus", PermissionMode: "plan", Tools: []string{"Read"}}, sess.Info().Init)
+}
+
+// streamingAgent streams the start of a reply, then waits for a cancel and
+// streams the rest of the partial reply before returning.
+type streamingAgent struct {
+	modelAgent
+	conn      *acp.AgentSideConnection
+	cancelled chan struct{}
+}
+
+func (a *streamingAgent) SetConnection(conn *acp.AgentSideConnection) { a.conn = conn }
+
+func (a *streamingAgent) Cancel(context.Context, acp.CancelNotification) error {
+	close(a.cancelled)
+	return nil
+}
+
+func (a *streamingAgent) Prompt(_ context.Context, req acp.PromptRequest) (acp.PromptResponse, error) {
+	send := func(text string) {
+		_ = a.conn.SessionUpdate(context.Background(), acp.SessionNotification{SessionId: req.SessionId, Update: acp.UpdateAgentMessageText(text)})
+	}
+	send("partial ")
+	<-a.cancelled
+	send("response")
+	return acp.PromptResponse{StopReason: acp.StopReasonCancelled}, nil
+}
+
+func TestCancel_MidStreamKeepsPartialText(t *testing.T) {
+	agent := &streamingAgent{cancelled: make(chan struct{})}
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+	})
+
+	var mu sync.Mutex
+	var text string
+	received := func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return text
+	}
+	onEvent := func(n acp.SessionNotification) {
+		if c := n.Update.AgentMessageChunk; c != nil && c.Content.Text != nil {
+			mu.Lock()
+			text += c.Content.Text.Text
+			mu.Unlock()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(ctx, LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, onEvent)
+	require.NoError(t, err)
+	waitForStatus(t, statusCh, SessionStatusIdle)
+
+	respCh := make(chan *acp.PromptResponse, 1)
+	go func() {
+		resp, err := sess.Prompt(ctx, []acp.ContentBlock{acp.TextBlock("hi")})
+		assert.NoError(t, err)
+		respCh <- resp
+	}()
+	require.Eventually(t, func() bool { return received() == "partial " }, 2*time.Second, 5*time.Millisecond)
+
+	require.NoError(t, sess.Cancel(ctx))
+	select {
+	case resp := <-respCh:
+		require.NotNil(t, resp)
+		assert.Equal(t, acp.StopReasonCancelled, resp.StopReason)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Prompt did not return after Cancel")
+	}
+	assert.Eventually(t, func() bool { return received() == "partial response" }, 2*time.Second, 5*time.Millisecond)
+	waitForStatus(t, statusCh, SessionStatusIdle)
+}
+
+// toolAgent starts two tool calls, finishes only the first and then waits
+// for a cancel without finishing the second.
+type toolAgent struct {
+	streamingAgent
+}
+
+func (a *toolAgent) Prompt(_ context.Context, req acp.PromptRequest) (acp.PromptResponse, error) {
+	send := func(u acp.SessionUpdate) {
+		_ = a.conn.SessionUpdate(context.Background(), acp.SessionNotification{SessionId: req.SessionId, Update: u})
+	}
+	send(acp.StartToolCall("tc-1", "Read", acp.WithStartStatus(acp.ToolCallStatusInProgress)))
+	send(acp.UpdateToolCall("tc-1", acp.WithUpdateStatus(acp.ToolCallStatusCompleted)))
+	send(acp.StartToolCall("tc-2", "Bash", acp.WithStartStatus(acp.ToolCallStatusInProgress)))
+	<-a.cancelled
+	return acp.PromptResponse{StopReason: acp.StopReasonCancelled}, nil
+}
+
+func TestCancel_FailsOutstandingToolCalls(t *testing.T) {
+	agent := &toolAgent{streamingAgent{cancelled: make(chan struct{})}}
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+	})
+
+	var mu sync.Mutex
+	var started []acp.ToolCallId
+	finished := map[acp.ToolCallId][]acp.ToolCallStatus{}
+	onEvent := func(n acp.SessionNotification) {
+		mu.Lock()
+		defer mu.Unlock()
+		if tc := n.Update.ToolCall; tc != nil {
+			started = append(started, tc.ToolCallId)
+		}
+		if tu := n.Update.ToolCallUpdate; tu != nil && tu.Status != nil {
+			finished[tu.ToolCallId] = append(finished[tu.ToolCallId], *tu.Status)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(ctx, LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, onEvent)
+	require.NoError(t, err)
+	waitForStatus(t, statusCh, SessionStatusIdle)
+
+	respCh := make(chan *acp.PromptResponse, 1)
+	go func() {
+		resp, err := sess.Prompt(ctx, []acp.ContentBlock{acp.TextBlock("hi")})
+		assert.NoError(t, err)
+		respCh <- resp
+	}()
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(started) == 2 && len(finished["tc-1"]) == 1
+	}, 2*time.Second, 5*time.Millisecond)
+
+	require.NoError(t, sess.Cancel(ctx))
+	select {
+	case resp := <-respCh:
+		require.NotNil(t, resp)
+		assert.Equal(t, acp.StopReasonCancelled, resp.StopReason)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Prompt did not return after Cancel")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []acp.ToolCallStatus{acp.ToolCallStatusFailed}, finished["tc-2"], "the outstanding tool call is failed before Prompt returns")
+	assert.Equal(t, []acp.ToolCallStatus{acp.ToolCallStatusCompleted}, finished["tc-1"], "finished tool calls are left alone")
+}
+
+// versionAgent answers Initialize with a fixed protocol version.
+type versionAgent struct {
+	modelAgent
+	version acp.ProtocolVersion
+}
+
+func (a *versionAgent) Initialize(context.Context, acp.InitializeRequest) (acp.InitializeResponse, error) {
+	return acp.InitializeResponse{ProtocolVersion: a.version}, nil
+}
+
+// logBuffer collects log output written from several goroutines.
+type logBuffer struct {
+	mu  sync.Mutex
+	buf strings.Builder
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *logBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func launchWithProtocolVersion(t *testing.T, version acp.ProtocolVersion) (Session, <-chan SessionStatus, *logBuffer) {
+	t.Helper()
+	logs := &logBuffer{}
+	d := NewDriver(slog.New(slog.NewTextHandler(logs, nil)), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return &versionAgent{version: version} },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(ctx, LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	return sess, statusCh, logs
+}
+
+func TestLaunch_ProtocolVersion(t *testing.T) {
+	t.Run("matching version is recorded", func(t *testing.T) {
+		sess, statusCh, logs := launchWithProtocolVersion(t, acp.ProtocolVersionNumber)
+		waitForStatus(t, statusCh, SessionStatusIdle)
+		assert.Equal(t, int(acp.ProtocolVersionNumber), sess.Info().ProtocolVersion)
+		assert.NotContains(t, logs.String(), "level=WARN")
+	})
+
+	t.Run("missing version warns and continues", func(t *testing.T) {
+		sess, statusCh, logs := launchWithProtocolVersion(t, 0)
+		waitForStatus(t, statusCh, SessionStatusIdle)
+		assert.Zero(t, sess.Info().ProtocolVersion)
+		assert.Contains(t, logs.String(), "reported no protocol version")
+	})
+
+	t.Run("different version fails the session", func(t *testing.T) {
+		sess, statusCh, logs := launchWithProtocolVersion(t, acp.ProtocolVersionNumber+1)
+		waitForStatus(t, statusCh, SessionStatusErrored)
+		info := sess.Info()
+		assert.Equal(t, int(acp.ProtocolVersionNumber+1), info.ProtocolVersion)
+		require.NotNil(t, info.Error)
+		assert.Contains(t, info.Error.Message, "ACP protocol version 2, want 1")
+		assert.Contains(t, logs.String(), "ACP protocol version mismatch")
+	})
+}
+
+// modeAgent offers a set of session modes and records mode switches. Its
+// prompt turns leave the mode for promptMode, if set.
+type modeAgent struct {
+	modelAgent
+	modes      *acp.SessionModeState
+	promptMode acp.SessionModeId
+	conn       *acp.AgentSideConnection
+
+	mu       sync.Mutex
+	switched []acp.SessionModeId
+}
+
+func (a *modeAgent) NewSession(context.Context, acp.NewSessionRequest) (acp.NewSessionResponse, error) {
+	return acp.NewSessionResponse{SessionId: "session-1", Modes: a.modes}, nil
+}
+
+func (a *modeAgent) SetSessionMode(_ context.Context, req acp.SetSessionModeRequest) (acp.SetSessionModeResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.switched = append(a.switched, req.ModeId)
+	return acp.SetSessionModeResponse{}, nil
+}
+
+func (a *modeAgent) SetConnection(conn *acp.AgentSideConnection) { a.conn = conn }
+
+func (a *modeAgent) Prompt(ctx context.Context, req acp.PromptRequest) (acp.PromptResponse, error) {
+	if a.promptMode != "" {
+		_ = a.conn.SessionUpdate(ctx, acp.SessionNotification{SessionId: req.SessionId, Update: acp.SessionUpdate{
+			CurrentModeUpdate: &acp.SessionCurrentModeUpdate{CurrentModeId: a.promptMode},
+		}})
+	}
+	return acp.PromptResponse{StopReason: acp.StopReasonEndTurn}, nil
+}
+
+func TestSession_ModesFromAgent(t *testing.T) {
+	planDesc := "read-only planning"
+	agent := &modeAgent{modes: &acp.SessionModeState{
+		AvailableModes: []acp.SessionMode{
+			{Id: "build", Name: "Build"},
+			{Id: "plan", Name: "Plan", Description: &planDesc},
+		},
+		CurrentModeId: "build",
+	}, promptMode: "build"}
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(ctx, LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	waitForStatus(t, statusCh, SessionStatusIdle)
+
+	info := sess.Info()
+	assert.Equal(t, []SessionModeInfo{
+		{ID: "build", Name: "Build"},
+		{ID: "plan", Name: "Plan", Description: "read-only planning"},
+	}, info.Modes)
+	assert.Equal(t, "build", info.CurrentMode)
+
+	require.NoError(t, sess.SetSessionMode(context.Background(), "plan"))
+	assert.Equal(t, "plan", sess.Info().CurrentMode)
+
+	// Standard modes are not accepted when the agent names its own.
+	err = sess.SetSessionMode(context.Background(), driver.SessionModeCode)
+	require.ErrorIs(t, err, ErrUnknownSessionMode)
+	assert.ErrorContains(t, err, "available: build, plan")
+
+	_, err = sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("go ahead")})
+	require.NoError(t, err)
+	assert.Equal(t, "build", sess.Info().CurrentMode, "the agent leaving plan mode by itself is tracked")
+
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	assert.Equal(t, []acp.SessionModeId{"plan"}, agent.switched, "unknown modes are not forwarded")
+}
+
+func TestSession_StandardModesWithoutAgentModes(t *testing.T) {
+	sess, statusCh := launchFailingPrompt(t, errors.New("unused"), "")
+	waitForStatus(t, statusCh, SessionStatusIdle)
+
+	assert.Empty(t, sess.Info().Modes)
+	assert.Equal(t, "ask", sess.Info().CurrentMode, "the launch mode stands in for the unreported one")
+	require.NoError(t, sess.SetSessionMode(context.Background(), driver.SessionModeArchitect))
+	assert.Equal(t, "architect", sess.Info().CurrentMode)
+	require.ErrorIs(t, sess.SetSessionMode(context.Background(), "turbo"), ErrUnknownSessionMode)
+}
+
+func TestSession_LaunchModeWithoutAgentModes(t *testing.T) {
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return &modeAgent{} },
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(ctx, LaunchOpts{Cwd: "/tmp", SessionMode: "code", StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	waitForStatus(t, statusCh, SessionStatusIdle)
+
+	assert.Equal(t, "code", sess.Info().CurrentMode)
+}
+
+// scriptedAgent answers the prompts it gets with replies in turn, repeating
+// the last one once they run out, and records the prompt texts.
+type scriptedAgent struct {
+	modelAgent
+	conn    *acp.AgentSideConnection
+	replies []string
+
+	mu      sync.Mutex
+	prompts []string
+}
+
+func (a *scriptedAgent) SetConnection(conn *acp.AgentSideConnection) { a.conn = conn }
+
+func (a *scriptedAgent) Prompt(_ context.Context, req acp.PromptRequest) (acp.PromptResponse, error) {
+	a.mu.Lock()
+	a.prompts = append(a.prompts, req.Prompt[len(req.Prompt)-1].Text.Text)
+	reply := a.replies[min(len(a.prompts), len(a.replies))-1]
+	a.mu.Unlock()
+	_ = a.conn.SessionUpdate(context.Background(), acp.SessionNotification{SessionId: req.SessionId, Update: acp.UpdateAgentMessageText(reply)})
+	return acp.PromptResponse{StopReason: acp.StopReasonEndTurn}, nil
+}
+
+func (a *scriptedAgent) promptTexts() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(a.prompts)
+}
+
+func TestAutoContinue(t *testing.T) {
+	launch := func(t *testing.T, agent *scriptedAgent, ac AutoContinue) (Session, chan SessionStatus, func() []int) {
+		d := NewDriver(testLogger(), AgentConfig{
+			AgentID:        "test-agent",
+			AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+		})
+		var mu sync.Mutex
+		var iterations []int
+		onEvent := func(n acp.SessionNotification) {
+			if u := n.Update.UserMessageChunk; u != nil {
+				i, ok := AutoContinueIteration(u)
+				assert.True(t, ok, "only auto-continue prompts are emitted by the driver")
+				assert.Equal(t, "Keep going.", u.Content.Text.Text)
+				mu.Lock()
+				iterations = append(iterations, i)
+				mu.Unlock()
+			}
+		}
+		statusCh := make(chan SessionStatus, 16)
+		sess, err := d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", Prompt: "build it", StatusCh: statusCh, AutoContinue: ac}, onEvent)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = sess.Stop(context.Background()) })
+		return sess, statusCh, func() []int {
+			mu.Lock()
+			defer mu.Unlock()
+			return slices.Clone(iterations)
+		}
+	}
+
+	t.Run("stops at the stop phrase", func(t *testing.T) {
+		agent := &scriptedAgent{replies: []string{"started", "halfway", "all DONE now"}}
+		_, statusCh, iterations := launch(t, agent, AutoContinue{Prompt: "Keep going.", MaxIterations: 5, StopPhrase: "DONE"})
+		waitForStatus(t, statusCh, SessionStatusIdle)
+
+		assert.Equal(t, []string{"build it", "Keep going.", "Keep going."}, agent.promptTexts())
+		assert.Equal(t, []int{1, 2}, iterations())
+	})
+
+	t.Run("stops after max iterations and restarts the count per prompt", func(t *testing.T) {
+		agent := &scriptedAgent{replies: []string{"working"}}
+		sess, statusCh, iterations := launch(t, agent, AutoContinue{Prompt: "Keep going.", MaxIterations: 2, StopPhrase: "DONE"})
+		waitForStatus(t, statusCh, SessionStatusIdle)
+		assert.Equal(t, []string{"build it", "Keep going.", "Keep going."}, agent.promptTexts())
+
+		_, err := sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("more")})
+		require.NoError(t, err)
+		waitForStatus(t, statusCh, SessionStatusIdle)
+		assert.Equal(t, []string{"build it", "Keep going.", "Keep going.", "more", "Keep going.", "Keep going."}, agent.promptTexts())
+		assert.Equal(t, []int{1, 2, 1, 2}, iterations())
+	})
+
+	t.Run("sends the wrapped prompt", func(t *testing.T) {
+		agent := &scriptedAgent{replies: []string{"working"}}
+		wrap := func(p string) string { return "Follow the style guide.\n\n" + p }
+		_, statusCh, iterations := launch(t, agent, AutoContinue{Prompt: "Keep going.", MaxIterations: 1, Wrap: wrap})
+		waitForStatus(t, statusCh, SessionStatusIdle)
+
+		assert.Equal(t, []string{"build it", "Follow the style guide.\n\nKeep going."}, agent.promptTexts())
+		assert.Equal(t, []int{1}, iterations(), "the user message is emitted unwrapped")
+	})
+}
+
+// preemptedAgent answers prompts at once except the first "Keep going.",
+// which runs until it is cancelled.
+type preemptedAgent struct {
+	modelAgent
+	started   chan struct{} // closed once the held turn runs
+	cancelled chan struct{}
+
+	mu      sync.Mutex
+	prompts []string
+	held    bool
+}
+
+func (a *preemptedAgent) Cancel(context.Context, acp.CancelNotification) error {
+	select {
+	case a.cancelled <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (a *preemptedAgent) Prompt(ctx context.Context, req acp.PromptRequest) (acp.PromptResponse, error) {
+	text := req.Prompt[len(req.Prompt)-1].Text.Text
+	a.mu.Lock()
+	a.prompts = append(a.prompts, text)
+	hold := text == "Keep going." && !a.held
+	a.held = a.held || hold
+	a.mu.Unlock()
+	if !hold {
+		return acp.PromptResponse{StopReason: acp.StopReasonEndTurn}, nil
+	}
+	close(a.started)
+	select {
+	case <-a.cancelled:
+	case <-ctx.Done():
+	}
+	return acp.PromptResponse{StopReason: acp.StopReasonCancelled}, nil
+}
+
+func (a *preemptedAgent) promptTexts() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(a.prompts)
+}
+
+func TestAutoContinue_UserPromptPreempts(t *testing.T) {
+	agent := &preemptedAgent{started: make(chan struct{}), cancelled: make(chan struct{}, 1)}
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+	})
+	sess, err := d.Launch(context.Background(), LaunchOpts{
+		Cwd:          "/tmp",
+		Prompt:       "build it",
+		AutoContinue: AutoContinue{Prompt: "Keep going.", MaxIterations: 2},
+	}, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sess.Stop(context.Background()) })
+	<-agent.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	resp, err := sess.Prompt(ctx, []acp.ContentBlock{acp.TextBlock("more")})
+	require.NoError(t, err, "the user prompt does not wait for the auto-continued turn")
+	assert.Equal(t, acp.StopReasonEndTurn, resp.StopReason)
+
+	// The user's prompt starts a new run of auto-continued turns.
+	require.Eventually(t, func() bool { return len(agent.promptTexts()) == 5 }, 2*time.Second, 5*time.Millisecond)
+	assert.Equal(t, []string{"build it", "Keep going.", "more", "Keep going.", "Keep going."}, agent.promptTexts())
+}
+
+// permissionToolAgent asks permission for a tool call, runs it until release is
+// closed, then asks permission for a second one and records the outcomes.
+type permissionToolAgent struct {
+	modelAgent
+	conn    *acp.AgentSideConnection
+	running chan struct{} // closed once the first tool call runs
+	release chan struct{}
+
+	mu       sync.Mutex
+	prompts  int
+	outcomes map[acp.ToolCallId]bool // tool call -> allowed
+}
+
+func (a *permissionToolAgent) SetConnection(conn *acp.AgentSideConnection) { a.conn = conn }
+
+func (a *permissionToolAgent) Prompt(ctx context.Context, req acp.PromptRequest) (acp.PromptResponse, error) {
+	a.mu.Lock()
+	a.prompts++
+	a.mu.Unlock()
+	ask := func(id acp.ToolCallId) bool {
+		resp, err := a.conn.RequestPermission(ctx, acp.RequestPermissionRequest{
+			SessionId: req.SessionId,
+			ToolCall:  acp.RequestPermissionToolCall{ToolCallId: id},
+			Options:   []acp.PermissionOption{{OptionId: "allow", Kind: acp.PermissionOptionKindAllowOnce, Name: "Allow"}},
+		})
+		allowed := err == nil && resp.Outcome.Selected != nil
+		a.mu.Lock()
+		a.outcomes[id] = allowed
+		a.mu.Unlock()
+		return allowed
+	}
+	if ask("tc-1") {
+		_ = a.conn.SessionUpdate(ctx, acp.SessionNotification{SessionId: req.SessionId, Update: acp.StartToolCall("tc-1", "Write", acp.WithStartStatus(acp.ToolCallStatusInProgress))})
+		close(a.running)
+		<-a.release
+		_ = a.conn.SessionUpdate(ctx, acp.SessionNotification{SessionId: req.SessionId, Update: acp.UpdateToolCall("tc-1", acp.WithUpdateStatus(acp.ToolCallStatusCompleted))})
+	}
+	ask("tc-2")
+	return acp.PromptResponse{StopReason: acp.StopReasonEndTurn}, nil
+}
+
+func TestSoftStop(t *testing.T) {
+	agent := &permissionToolAgent{running: make(chan struct{}), release: make(chan struct{}), outcomes: make(map[acp.ToolCallId]bool)}
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+	})
+	var (
+		mu        sync.Mutex
+		completed bool
+	)
+	onEvent := func(n acp.SessionNotification) {
+		if u := n.Update.ToolCallUpdate; u != nil && u.ToolCallId == "tc-1" && u.Status != nil && *u.Status == acp.ToolCallStatusCompleted {
+			mu.Lock()
+			completed = true
+			mu.Unlock()
+		}
+	}
+	pending := make(chan string, 4)
+	statusCh := make(chan SessionStatus, 16)
+	sess, err := d.Launch(context.Background(), LaunchOpts{
+		Cwd:          "/tmp",
+		Prompt:       "write it",
+		StatusCh:     statusCh,
+		AutoContinue: AutoContinue{MaxIterations: 3},
+		OnPermission: func(e PermissionEvent) {
+			if !e.Resolved {
+				pending <- e.RequestID
+			}
+		},
+	}, onEvent)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sess.Stop(context.Background()) })
+
+	require.Equal(t, "tc-1", <-pending)
+	require.NoError(t, sess.RespondToPermission(context.Background(), "tc-1", true, ""))
+	<-agent.running
+
+	require.NoError(t, sess.SoftStop(context.Background()))
+	_, err = sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("more")})
+	require.ErrorIs(t, err, ErrSessionStopping)
+	close(agent.release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, sess.Wait(ctx), "the session stops once the turn ends")
+
+	agent.mu.Lock()
+	defer agent.mu.Unlock()
+	assert.Equal(t, map[acp.ToolCallId]bool{"tc-1": true, "tc-2": false}, agent.outcomes, "the tool call in flight ran, the next one was denied")
+	assert.Equal(t, 1, agent.prompts, "the turn is not auto-continued")
+	mu.Lock()
+	assert.True(t, completed, "the tool call in flight completed")
+	mu.Unlock()
+	assert.Equal(t, SessionStatusStopped, sess.Info().Status)
+}
+
+// autoToolAgent runs a tool call without asking for permission until release
+// is closed, then starts a second one unless the turn was cancelled first,
+// the way agents run their allowed tools.
+type autoToolAgent struct {
+	modelAgent
+	conn      *acp.AgentSideConnection
+	running   chan struct{} // closed once the first tool call runs
+	release   chan struct{}
+	cancelled chan struct{}
+	ranSecond atomic.Bool
+}
+
+func (a *autoToolAgent) SetConnection(conn *acp.AgentSideConnection) { a.conn = conn }
+
+func (a *autoToolAgent) Cancel(context.Context, acp.CancelNotification) error {
+	close(a.cancelled)
+	return nil
+}
+
+func (a *autoToolAgent) Prompt(ctx context.Context, req acp.PromptRequest) (acp.PromptResponse, error) {
+	update := func(u acp.SessionUpdate) {
+		_ = a.conn.SessionUpdate(ctx, acp.SessionNotification{SessionId: req.SessionId, Update: u})
+	}
+	update(acp.StartToolCall("tc-1", "Read", acp.WithStartStatus(acp.ToolCallStatusInProgress)))
+	close(a.running)
+	<-a.release
+	update(acp.UpdateToolCall("tc-1", acp.WithUpdateStatus(acp.ToolCallStatusCompleted)))
+
+	select {
+	case <-a.cancelled:
+		return acp.PromptResponse{StopReason: acp.StopReasonCancelled}, nil
+	case <-time.After(2 * time.Second):
+	}
+	a.ranSecond.Store(true)
+	update(acp.StartToolCall("tc-2", "Bash", acp.WithStartStatus(acp.ToolCallStatusInProgress)))
+	update(acp.UpdateToolCall("tc-2", acp.WithUpdateStatus(acp.ToolCallStatusCompleted)))
+	return acp.PromptResponse{StopReason: acp.StopReasonEndTurn}, nil
+}
+
+func TestSoftStop_CancelsTurnBeforeAutoApprovedTool(t *testing.T) {
+	agent := &autoToolAgent{running: make(chan struct{}), release: make(chan struct{}), cancelled: make(chan struct{})}
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+	})
+	var ended atomic.Value
+	sess, err := d.Launch(context.Background(), LaunchOpts{
+		Cwd:         "/tmp",
+		Prompt:      "look around",
+		OnTurnEnded: func(te TurnEnd) { ended.Store(te.StopReason) },
+	}, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sess.Stop(context.Background()) })
+	<-agent.running
+
+	require.NoError(t, sess.SoftStop(context.Background()))
+	select {
+	case <-agent.cancelled:
+		t.Fatal("the turn was cancelled while a tool call was running")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(agent.release)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, sess.Wait(ctx), "the session stops once the running tool call finished")
+	assert.False(t, agent.ranSecond.Load(), "no tool call starts after the soft stop")
+	assert.Equal(t, acp.StopReasonCancelled, ended.Load())
+}
+
+// heldAgent holds every prompt until release is closed or the turn is
+// cancelled, and counts the cancels.
+type heldAgent struct {
+	modelAgent
+	started   chan struct{} // receives once per prompt
+	release   chan struct{}
+	cancelled chan struct{}
+	cancels   atomic.Int32
+}
+
+func (a *heldAgent) Cancel(context.Context, acp.CancelNotification) error {
+	a.cancels.Add(1)
+	select {
+	case a.cancelled <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+func (a *heldAgent) Prompt(ctx context.Context, _ acp.PromptRequest) (acp.PromptResponse, error) {
+	a.started <- struct{}{}
+	select {
+	case <-a.release:
+		return acp.PromptResponse{StopReason: acp.StopReasonEndTurn}, nil
+	case <-a.cancelled:
+	case <-ctx.Done():
+	}
+	return acp.PromptResponse{StopReason: acp.StopReasonCancelled}, nil
+}
+
+// launchWithTurnEnds launches agent and returns the turn ends it reports so
+// far.
+func launchWithTurnEnds(t *testing.T, agent acp.Agent, opts LaunchOpts) (Session, func() []TurnEnd) {
+	t.Helper()
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+	})
+	var mu sync.Mutex
+	var ends []TurnEnd
+	opts.Cwd = "/tmp"
+	opts.OnTurnEnded = func(te TurnEnd) {
+		mu.Lock()
+		ends = append(ends, te)
+		mu.Unlock()
+	}
+	sess, err := d.Launch(context.Background(), opts, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = sess.Stop(context.Background()) })
+	return sess, func() []TurnEnd {
+		mu.Lock()
+		defer mu.Unlock()
+		return slices.Clone(ends)
+	}
+}
+
+func TestPrompt_Timeout(t *testing.T) {
+	newAgent := func() *heldAgent {
+		return &heldAgent{started: make(chan struct{}, 4), release: make(chan struct{}), cancelled: make(chan struct{}, 1)}
+	}
+
+	t.Run("cancels the timed out turn", func(t *testing.T) {
+		agent := newAgent()
+		sess, ends := launchWithTurnEnds(t, agent, LaunchOpts{})
+		require.Eventually(t, func() bool { return sess.Info().Status == SessionStatusIdle }, time.Second, time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := sess.Prompt(ctx, []acp.ContentBlock{acp.TextBlock("hi")})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+
+		require.Eventually(t, func() bool { return len(ends()) == 1 }, 2*time.Second, 5*time.Millisecond)
+		te := ends()[0]
+		assert.Equal(t, acp.StopReasonCancelled, te.StopReason)
+		assert.True(t, te.TimedOut)
+		assert.NotNil(t, te.Stats)
+		assert.Equal(t, int32(1), agent.cancels.Load())
+	})
+
+	t.Run("a queued prompt timing out leaves the turn in flight alone", func(t *testing.T) {
+		agent := newAgent()
+		sess, ends := launchWithTurnEnds(t, agent, LaunchOpts{})
+		require.Eventually(t, func() bool { return sess.Info().Status == SessionStatusIdle }, time.Second, time.Millisecond)
+
+		first := make(chan *acp.PromptResponse, 1)
+		go func() {
+			resp, err := sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("first")})
+			assert.NoError(t, err)
+			first <- resp
+		}()
+		<-agent.started
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := sess.Prompt(ctx, []acp.ContentBlock{acp.TextBlock("second")})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Zero(t, agent.cancels.Load(), "the queued prompt does not own the turn")
+
+		close(agent.release)
+		assert.Equal(t, acp.StopReasonEndTurn, (<-first).StopReason)
+		require.Eventually(t, func() bool { return len(ends()) == 1 }, 2*time.Second, 5*time.Millisecond)
+		assert.Equal(t, acp.StopReasonEndTurn, ends()[0].StopReason)
+		assert.False(t, ends()[0].TimedOut)
+		assert.Len(t, agent.started, 0, "the timed out prompt never reaches the agent")
+	})
+}
+
+func TestOnTurnEnded_TurnsTheDriverStarts(t *testing.T) {
+	agent := &stopAgent{stopReason: acp.StopReasonEndTurn}
+	_, ends := launchWithTurnEnds(t, agent, LaunchOpts{Prompt: "build it", AutoContinue: AutoContinue{MaxIterations: 2}})
+
+	// The launch prompt and both auto-continued turns.
+	require.Eventually(t, func() bool { return len(ends()) == 3 }, 2*time.Second, 5*time.Millisecond)
+	for _, te := range ends() {
+		assert.Equal(t, acp.StopReasonEndTurn, te.StopReason)
+		assert.NotNil(t, te.Stats)
+	}
+}
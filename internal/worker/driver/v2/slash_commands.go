@@ -0,0 +1,92 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	acp "github.com/coder/acp-go-sdk"
+)
+
+// ErrNoSlashCommandHandler is returned by Session.Prompt for a prompt that
+// names a host command without a handler.
+var ErrNoSlashCommandHandler = errors.New("host command has no handler")
+
+// SlashCommandHandler runs a host-defined slash command, such as /export,
+// with arg, the text after the command name. It returns whether the prompt
+// is still sent to the agent afterwards. Handlers run in the session loop
+// between turns, so they must not call sess.Prompt.
+type SlashCommandHandler func(ctx context.Context, sess Session, arg string) (forward bool, err error)
+
+// SlashCommands maps command names, without the slash, to their handlers.
+type SlashCommands map[string]SlashCommandHandler
+
+// WithSlashCommands makes Session.Prompt run the handler of a prompt whose
+// first text block starts with one of cmds. Other prompts, including the
+// agent's own slash commands, go to the agent unchanged.
+func WithSlashCommands(cmds SlashCommands) Option {
+	return func(d *acpDriver) { d.slashCommands = cmds }
+}
+
+// ParseSlashCommand splits input of the form "/name arg" into the command
+// name and its argument. It reports false if input is not a slash command.
+func ParseSlashCommand(input string) (name, arg string, ok bool) {
+	if !strings.HasPrefix(input, "/") {
+		return "", "", false
+	}
+	trimmed := strings.TrimSpace(strings.TrimPrefix(input, "/"))
+	if trimmed == "" {
+		return "", "", false
+	}
+	parts := strings.SplitN(trimmed, " ", 2)
+	name = strings.TrimSpace(parts[0])
+	if name == "" {
+		return "", "", false
+	}
+	if len(parts) == 2 {
+		arg = strings.TrimSpace(parts[1])
+	}
+	return name, arg, true
+}
+
+// slashCommand is a host slash command a prompt starts with.
+type slashCommand struct {
+	name, arg string
+	handler   SlashCommandHandler
+}
+
+// slashCommand returns the host command blocks start with, or nil if the
+// prompt goes to the agent as is. A prompt naming a host command announced
+// with SetHostCommands that has no handler is rejected rather than sent to
+// an agent that does not know it.
+func (s *acpSession) slashCommand(blocks []acp.ContentBlock) (*slashCommand, error) {
+	if len(blocks) == 0 || blocks[0].Text == nil {
+		return nil, nil
+	}
+	name, arg, ok := ParseSlashCommand(blocks[0].Text.Text)
+	if !ok {
+		return nil, nil
+	}
+	if handler, ok := s.slashCommands[name]; ok {
+		return &slashCommand{name: name, arg: arg, handler: handler}, nil
+	}
+	if s.isHostCommand(name) {
+		return nil, fmt.Errorf("/%s: %w", name, ErrNoSlashCommandHandler)
+	}
+	return nil, nil
+}
+
+// run runs the command in the session loop, between turns. It reports
+// whether the prompt is still sent to the agent; if not, resp ends the turn
+// the agent never saw.
+func (c *slashCommand) run(ctx context.Context, sess Session) (resp *acp.PromptResponse, forward bool, err error) {
+	forward, err = c.handler(ctx, sess, c.arg)
+	if err != nil {
+		return nil, false, fmt.Errorf("/%s: %w", c.name, err)
+	}
+	if forward {
+		return nil, true, nil
+	}
+	return &acp.PromptResponse{StopReason: acp.StopReasonEndTurn}, false, nil
+}
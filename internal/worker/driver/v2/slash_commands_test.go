@@ -0,0 +1,116 @@
+package v2
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlashCommands(t *testing.T) {
+	var args []string
+	cmds := SlashCommands{
+		"model": func(_ context.Context, _ Session, arg string) (bool, error) {
+			args = append(args, arg)
+			return false, nil
+		},
+		"export": func(context.Context, Session, string) (bool, error) { return true, nil },
+		"clear":  func(context.Context, Session, string) (bool, error) { return false, errors.New("nothing to clear") },
+	}
+	agent := &scriptedAgent{replies: []string{"ok"}}
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+	}, WithSlashCommands(cmds))
+	statusCh := make(chan SessionStatus, 16)
+	sess, err := d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, func(acp.SessionNotification) {})
+	require.NoError(t, err)
+	defer sess.Stop(context.Background())
+	waitForStatus(t, statusCh, SessionStatusRunning)
+
+	resp, err := sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("/model sonnet")})
+	require.NoError(t, err)
+	assert.Equal(t, acp.StopReasonEndTurn, resp.StopReason)
+	assert.Equal(t, []string{"sonnet"}, args)
+	assert.Empty(t, agent.promptTexts(), "an intercepted command does not reach the agent")
+
+	_, err = sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("/clear")})
+	assert.EqualError(t, err, "/clear: nothing to clear")
+
+	_, err = sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("/export")})
+	require.NoError(t, err)
+	_, err = sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("/review the diff")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"/export", "/review the diff"}, agent.promptTexts(), "forwarded and unknown commands reach the agent")
+}
+
+// gatedAgent holds every prompt turn until release is closed.
+type gatedAgent struct {
+	modelAgent
+	started chan struct{}
+	release chan struct{}
+}
+
+func (a *gatedAgent) Prompt(context.Context, acp.PromptRequest) (acp.PromptResponse, error) {
+	a.started <- struct{}{}
+	<-a.release
+	return acp.PromptResponse{StopReason: acp.StopReasonEndTurn}, nil
+}
+
+func TestSlashCommands_WaitForTurn(t *testing.T) {
+	var ran atomic.Bool
+	cmds := SlashCommands{
+		"model": func(context.Context, Session, string) (bool, error) {
+			ran.Store(true)
+			return false, nil
+		},
+	}
+	agent := &gatedAgent{started: make(chan struct{}, 1), release: make(chan struct{})}
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+	}, WithSlashCommands(cmds))
+	statusCh := make(chan SessionStatus, 16)
+	sess, err := d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, func(acp.SessionNotification) {})
+	require.NoError(t, err)
+	defer sess.Stop(context.Background())
+	waitForStatus(t, statusCh, SessionStatusRunning)
+
+	go func() { _, _ = sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("work")}) }()
+	<-agent.started
+	cmdErr := make(chan error, 1)
+	go func() {
+		_, err := sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("/model sonnet")})
+		cmdErr <- err
+	}()
+	assert.Never(t, ran.Load, 100*time.Millisecond, 5*time.Millisecond, "the command waits for the turn in flight")
+
+	close(agent.release)
+	require.NoError(t, <-cmdErr)
+	assert.True(t, ran.Load())
+}
+
+func TestParseSlashCommand(t *testing.T) {
+	for _, tc := range []struct {
+		input     string
+		name, arg string
+		ok        bool
+	}{
+		{input: "/model sonnet", name: "model", arg: "sonnet", ok: true},
+		{input: "/clear", name: "clear", ok: true},
+		{input: "/review  the diff ", name: "review", arg: "the diff", ok: true},
+		{input: "/"},
+		{input: "hello /model"},
+	} {
+		name, arg, ok := ParseSlashCommand(tc.input)
+		assert.Equal(t, tc.ok, ok, tc.input)
+		assert.Equal(t, tc.name, name, tc.input)
+		assert.Equal(t, tc.arg, arg, tc.input)
+	}
+}
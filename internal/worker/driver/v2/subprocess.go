@@ -1,7 +1,9 @@
 package v2
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log/slog"
@@ -9,48 +11,105 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	acp "github.com/coder/acp-go-sdk"
 	"github.com/google/uuid"
 	"github.com/sebastianm/flowgentic/internal/worker/driver"
+	"github.com/sebastianm/flowgentic/internal/worker/metrics"
+	"github.com/sebastianm/flowgentic/internal/worker/procutil"
 )
 
 // acpDriver implements Driver using ACP connections.
 type acpDriver struct {
-	log    *slog.Logger
-	config AgentConfig
-	caps   driver.Capabilities
+	log     *slog.Logger
+	config  AgentConfig
+	caps    driver.Capabilities
+	metrics metrics.Metrics
+
+	permissionBatchWindow time.Duration
+
+	// stderrLines is the per-session stderr tail size; stderrLevel is the
+	// level agent stderr is logged at.
+	stderrLines int
+	stderrLevel slog.Level
+
+	// liveness configures the checks of Pinger adapters; see WithLiveness.
+	liveness LivenessProbe
+
+	// slashCommands are the host commands Prompt intercepts; see
+	// WithSlashCommands.
+	slashCommands SlashCommands
+}
+
+// Option configures optional driver dependencies.
+type Option func(*acpDriver)
+
+// WithMetrics makes the driver record permission and error metrics to m.
+func WithMetrics(m metrics.Metrics) Option {
+	return func(d *acpDriver) { d.metrics = metrics.OrNop(m) }
+}
+
+// WithPermissionBatchWindow sets how long permission requests are collected
+// before being surfaced, so parallel tool calls produce one batch event
+// instead of one prompt each. Zero surfaces every request immediately.
+func WithPermissionBatchWindow(window time.Duration) Option {
+	return func(d *acpDriver) { d.permissionBatchWindow = window }
+}
+
+// WithStderr sets how many of an agent's last stderr lines each session
+// keeps (see SessionInfo.StderrTail; zero keeps driver.DefaultStderrTailLines)
+// and the level every stderr line is logged at. The default is debug.
+func WithStderr(lines int, level slog.Level) Option {
+	return func(d *acpDriver) {
+		d.stderrLines = lines
+		d.stderrLevel = level
+	}
 }
 
 // NewDriver creates a V2 driver from an AgentConfig.
-func NewDriver(log *slog.Logger, config AgentConfig) Driver {
-	return &acpDriver{
+func NewDriver(log *slog.Logger, config AgentConfig, opts ...Option) Driver {
+	d := &acpDriver{
 		log:    log.With("driver", config.AgentID),
 		config: config,
 		caps: driver.Capabilities{
-			Agent:     config.AgentID,
-			Supported: config.Capabilities,
+			Agent:      config.AgentID,
+			Supported:  config.Capabilities,
+			Extensions: config.Extensions,
 		},
+		metrics:               metrics.Nop(),
+		permissionBatchWindow: defaultPermissionBatchWindow,
+		stderrLevel:           slog.LevelDebug,
 	}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
 }
 
 func (d *acpDriver) Agent() string                     { return d.config.AgentID }
 func (d *acpDriver) Capabilities() driver.Capabilities { return d.caps }
 
 func (d *acpDriver) DiscoverModels(ctx context.Context, cwd string) (ModelInventory, error) {
+	if d.config.ModelDiscoverer != nil {
+		return d.config.ModelDiscoverer(ctx, d.log, cwd)
+	}
+
 	client := newFlowgenticClient(nil, nil, "")
 
 	var (
-		conn *acp.ClientSideConnection
-		cmd  *exec.Cmd
-		err  error
+		conn    *acp.ClientSideConnection
+		cmd     *exec.Cmd
+		release func()
+		err     error
 	)
 
 	if d.config.AdapterFactory != nil {
-		conn, cmd, err = d.launchInProcess(ctx, client, LaunchOpts{Cwd: cwd})
+		conn, _, release, err = d.launchInProcess(ctx, client, nil, LaunchOpts{Cwd: cwd}, nil)
 	} else if d.config.Command != "" {
-		conn, cmd, err = d.launchSubprocess(ctx, client, LaunchOpts{Cwd: cwd})
+		conn, cmd, _, err = d.launchSubprocess(ctx, client, nil, LaunchOpts{Cwd: cwd}, nil)
 	} else {
 		return ModelInventory{}, fmt.Errorf("agent config has neither AdapterFactory nor Command")
 	}
@@ -64,6 +123,11 @@ func (d *acpDriver) DiscoverModels(ctx context.Context, cwd string) (ModelInvent
 			_ = cmd.Wait()
 		}()
 	}
+	if release != nil {
+		// NewSession may have started background work in the adapter (e.g. the
+		// Claude SDK connect); release it along with the pipes.
+		defer release()
+	}
 
 	_, err = conn.Initialize(ctx, acp.InitializeRequest{
 		ProtocolVersion: acp.ProtocolVersion(acp.ProtocolVersionNumber),
@@ -113,11 +177,29 @@ func (d *acpDriver) DiscoverModels(ctx context.Context, cwd string) (ModelInvent
 }
 
 func (d *acpDriver) Launch(ctx context.Context, opts LaunchOpts, onEvent EventCallback) (Session, error) {
-	sessionID := opts.ResumeSessionID
+	sessionID := opts.SessionID
+	if sessionID == "" {
+		sessionID = opts.ResumeSessionID
+	}
 	if sessionID == "" {
 		sessionID = uuid.New().String()
 	}
 
+	if opts.SystemPromptTemplate != "" {
+		rendered, err := renderSystemPrompt(opts.SystemPromptTemplate, SystemPromptVars{
+			Cwd:   opts.Cwd,
+			Agent: d.config.AgentID,
+			Date:  time.Now().Format(time.DateOnly),
+			Topic: opts.Topic,
+		})
+		if err != nil {
+			return nil, err
+		}
+		opts.SystemPrompt = rendered
+	}
+
+	opts.Model = resolveModel(d.config.ModelAliases, opts.Model)
+
 	info := SessionInfo{
 		ID:        sessionID,
 		AgentID:   d.config.AgentID,
@@ -127,6 +209,18 @@ func (d *acpDriver) Launch(ctx context.Context, opts LaunchOpts, onEvent EventCa
 	}
 
 	client := newFlowgenticClient(onEvent, opts.Handlers, opts.SessionMode)
+	client.metrics = d.metrics
+	client.batchWindow = d.permissionBatchWindow
+	client.agentID = d.config.AgentID
+	client.onPermission = opts.OnPermission
+
+	var trace *protocolTrace
+	if opts.ProtocolTracePath != "" {
+		var err error
+		if trace, err = openProtocolTrace(opts.ProtocolTracePath); err != nil {
+			return nil, err
+		}
+	}
 
 	launchCtx, cancel := context.WithCancel(ctx)
 
@@ -136,41 +230,72 @@ func (d *acpDriver) Launch(ctx context.Context, opts LaunchOpts, onEvent EventCa
 		cancel:   cancel,
 		done:     make(chan struct{}),
 		statusCh: opts.StatusCh,
+		trace:    trace,
 		promptCh: make(chan promptRequest),
-		cancelCh: make(chan struct{}, 1),
+		stopping: make(chan struct{}),
+
+		onTurnEnded: opts.OnTurnEnded,
+
+		slashCommands: d.slashCommands,
+
+		modelAliases: d.config.ModelAliases,
+		stderr:       driver.NewStderrTail(d.stderrLines),
+		stderrLog: func(line string) {
+			d.log.Log(context.Background(), d.stderrLevel, "agent stderr", "session_id", sessionID, "line", line)
+		},
 	}
 
+	client.onPlan = sess.replacePlan
+	client.onInit = sess.recordInit
+	client.onMode = sess.recordMode
+	client.onCommands = sess.forwardCommands
+
 	var (
-		conn *acp.ClientSideConnection
-		cmd  *exec.Cmd
+		conn    *acp.ClientSideConnection
+		cmd     *exec.Cmd
+		release func()
 	)
 
 	if d.config.AdapterFactory != nil {
 		// In-process adapter: use io.Pipe pairs.
-		var err error
-		conn, cmd, err = d.launchInProcess(launchCtx, client, opts)
+		var (
+			agent acp.Agent
+			err   error
+		)
+		conn, agent, release, err = d.launchInProcess(launchCtx, client, trace, opts, sess.noteStderr)
 		if err != nil {
 			cancel()
+			_ = trace.Close()
 			return nil, err
 		}
-		_ = cmd // nil for in-process
+		if adder, ok := agent.(MCPServerAdder); ok {
+			sess.mcpAdder = adder
+		}
+		if setter, ok := agent.(AllowedToolsSetter); ok {
+			sess.toolsSetter = setter
+		}
+		if pinger, ok := agent.(Pinger); ok {
+			sess.pinger = pinger
+		}
 	} else if d.config.Command != "" {
 		// Subprocess: spawn external ACP agent.
 		var err error
-		conn, cmd, err = d.launchSubprocess(launchCtx, client, opts)
+		conn, cmd, sess.releaseLimits, err = d.launchSubprocess(launchCtx, client, trace, opts, sess.noteStderr)
 		if err != nil {
 			cancel()
+			_ = trace.Close()
 			return nil, err
 		}
 	} else {
 		cancel()
+		_ = trace.Close()
 		return nil, fmt.Errorf("agent config has neither AdapterFactory nor Command")
 	}
 
 	sess.conn = conn
 
 	// Run the ACP Initialize → NewSession → Prompt flow in a goroutine.
-	go d.runSession(launchCtx, sess, conn, cmd, opts)
+	go d.runSession(launchCtx, sess, conn, cmd, release, opts)
 
 	return sess, nil
 }
@@ -181,7 +306,33 @@ type ConnectionSetter interface {
 	SetConnection(conn *acp.AgentSideConnection)
 }
 
-func (d *acpDriver) launchInProcess(_ context.Context, client *flowgenticClient, opts LaunchOpts) (*acp.ClientSideConnection, *exec.Cmd, error) {
+// StderrSetter is implemented by in-process adapters that run an agent
+// subprocess. The adapter passes each stderr line of that process to fn,
+// which records it like the stderr of a subprocess agent.
+type StderrSetter interface {
+	SetStderr(fn func(line string))
+}
+
+// MCPServerAdder is implemented by in-process adapters that can start an MCP
+// server for a running session. Once the server is added, the adapter sends
+// an available_commands_update with the refreshed commands.
+type MCPServerAdder interface {
+	AddMCPServer(ctx context.Context, sessionID acp.SessionId, server acp.McpServer) error
+}
+
+// AllowedToolsSetter is implemented by in-process adapters that can replace
+// the allowed tools of a running session. The new list applies to tool calls
+// requested after it returns.
+type AllowedToolsSetter interface {
+	SetAllowedTools(ctx context.Context, sessionID acp.SessionId, tools []string) error
+}
+
+// launchInProcess connects to an in-process adapter over io.Pipe pairs and
+// returns the connection and the adapter. The returned release func closes
+// the pipes and, if the adapter implements io.Closer, the adapter itself; it
+// must be called once the connection is no longer needed. A non-nil trace records the client side of the connection;
+// a non-nil stderr receives the stderr lines of the adapter's subprocess.
+func (d *acpDriver) launchInProcess(_ context.Context, client *flowgenticClient, trace *protocolTrace, opts LaunchOpts, stderr func(line string)) (*acp.ClientSideConnection, acp.Agent, func(), error) {
 	agent := d.config.AdapterFactory(d.log)
 
 	// Two pipe pairs: client writes to agent's stdin, agent writes to client's stdin.
@@ -189,7 +340,8 @@ func (d *acpDriver) launchInProcess(_ context.Context, client *flowgenticClient,
 	agentToClientR, agentToClientW := io.Pipe()
 
 	// Client side: writes to clientToAgentW (agent's stdin), reads from agentToClientR (agent's stdout).
-	conn := acp.NewClientSideConnection(client, clientToAgentW, agentToClientR)
+	clientW, clientR := trace.wrap(clientToAgentW, agentToClientR)
+	conn := acp.NewClientSideConnection(client, clientW, countUpdates(client, clientR))
 	conn.SetLogger(d.log.With("side", "client"))
 
 	// Agent side: writes to agentToClientW (client's stdin), reads from clientToAgentR (client's stdout).
@@ -200,49 +352,118 @@ func (d *acpDriver) launchInProcess(_ context.Context, client *flowgenticClient,
 	if setter, ok := agent.(ConnectionSetter); ok {
 		setter.SetConnection(agentConn)
 	}
+	if setter, ok := agent.(StderrSetter); ok && stderr != nil {
+		setter.SetStderr(stderr)
+	}
 
 	_ = opts // env vars not applicable for in-process
 
-	return conn, nil, nil
+	var once sync.Once
+	release := func() {
+		once.Do(func() {
+			if closer, ok := agent.(io.Closer); ok {
+				if err := closer.Close(); err != nil {
+					d.log.Debug("in-process adapter close failed", "error", err)
+				}
+			}
+			_ = clientToAgentW.Close()
+			_ = agentToClientW.Close()
+		})
+	}
+
+	return conn, agent, release, nil
 }
 
-func (d *acpDriver) launchSubprocess(ctx context.Context, client *flowgenticClient, opts LaunchOpts) (*acp.ClientSideConnection, *exec.Cmd, error) {
-	cmd := exec.CommandContext(ctx, d.config.Command, d.config.Args...)
+// launchSubprocess starts the agent command under opts.ResourceLimits. A
+// non-nil stderr receives its stderr lines; otherwise stderr is discarded.
+// releaseLimits must be called once the command has been waited for.
+func (d *acpDriver) launchSubprocess(ctx context.Context, client *flowgenticClient, trace *protocolTrace, opts LaunchOpts, stderr func(line string)) (conn *acp.ClientSideConnection, cmd *exec.Cmd, releaseLimits func(), err error) {
+	cmd = exec.CommandContext(ctx, d.config.Command, d.config.Args...)
 	cmd.Env = driver.BuildEnv(opts.EnvVars)
 	if opts.Cwd != "" {
 		cmd.Dir = opts.Cwd
 	}
+	if stderr != nil {
+		cmd.Stderr = &lineWriter{fn: stderr}
+		// Children of the agent (e.g. MCP servers) may keep stderr open
+		// after it exits; don't let them hold up Wait.
+		cmd.WaitDelay = stderrWaitDelay
+	}
 
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
-		return nil, nil, fmt.Errorf("stdin pipe: %w", err)
+		return nil, nil, nil, fmt.Errorf("stdin pipe: %w", err)
 	}
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return nil, nil, fmt.Errorf("stdout pipe: %w", err)
+		return nil, nil, nil, fmt.Errorf("stdout pipe: %w", err)
 	}
 
-	if err := cmd.Start(); err != nil {
-		return nil, nil, fmt.Errorf("start %s: %w", d.config.Command, err)
+	releaseLimits, err = procutil.StartLimited(cmd, opts.ResourceLimits)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("start %s: %w", d.config.Command, err)
 	}
 
-	conn := acp.NewClientSideConnection(client, stdin, stdout)
+	w, r := trace.wrap(stdin, stdout)
+	conn = acp.NewClientSideConnection(client, w, countUpdates(client, r))
 	conn.SetLogger(d.log)
 
-	return conn, cmd, nil
+	return conn, cmd, releaseLimits, nil
 }
 
-func (d *acpDriver) runSession(ctx context.Context, sess *acpSession, conn *acp.ClientSideConnection, cmd *exec.Cmd, opts LaunchOpts) {
+// stderrWaitDelay bounds how long Wait keeps reading an exited agent's
+// stderr.
+const stderrWaitDelay = 2 * time.Second
+
+// lineWriter passes each line written to it to fn. Overlong lines are passed
+// on in pieces of maxStderrLine bytes.
+type lineWriter struct {
+	fn  func(line string)
+	buf []byte
+}
+
+const maxStderrLine = 64 << 10
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		i := bytes.IndexByte(w.buf, '\n')
+		if i < 0 {
+			break
+		}
+		w.fn(string(w.buf[:i]))
+		w.buf = w.buf[i+1:]
+	}
+	for len(w.buf) >= maxStderrLine {
+		w.fn(string(w.buf[:maxStderrLine]))
+		w.buf = w.buf[maxStderrLine:]
+	}
+	return len(p), nil
+}
+
+func (d *acpDriver) runSession(ctx context.Context, sess *acpSession, conn *acp.ClientSideConnection, cmd *exec.Cmd, release func(), opts LaunchOpts) {
 	defer func() {
 		sess.client.closePendingPermissions()
+		// Tear down in-process adapters so they don't outlive the session.
+		if release != nil {
+			release()
+		}
 		sess.setStatus(SessionStatusStopped)
 		// Close the status channel so consumers (e.g. forwardStatusEvents) exit.
 		sess.closeStatusCh()
-		close(sess.done)
-		// Wait for subprocess to exit if applicable.
+		// Kill the subprocess, if any, and reap it before reporting the
+		// session done so Wait covers the full teardown.
+		sess.cancel()
 		if cmd != nil {
 			_ = cmd.Wait()
 		}
+		if sess.releaseLimits != nil {
+			sess.releaseLimits()
+		}
+		if err := sess.trace.Close(); err != nil {
+			d.log.Debug("protocol trace close failed", "error", err)
+		}
+		close(sess.done)
 	}()
 
 	// Step 1: Initialize
@@ -255,10 +476,20 @@ func (d *acpDriver) runSession(ctx context.Context, sess *acpSession, conn *acp.
 	})
 	if err != nil {
 		d.log.Error("ACP initialize failed", "error", err)
-		sess.setStatus(SessionStatusErrored)
+		d.countError("initialize")
+		sess.fail(err)
 		return
 	}
 	d.log.Info("ACP initialized", "agent_info", initResp.AgentInfo, "protocol_version", initResp.ProtocolVersion)
+	sess.mu.Lock()
+	sess.info.ProtocolVersion = int(initResp.ProtocolVersion)
+	sess.mu.Unlock()
+	if err := d.checkProtocolVersion(initResp.ProtocolVersion); err != nil {
+		d.log.Error("ACP protocol version mismatch", "error", err)
+		d.countError("initialize")
+		sess.fail(err)
+		return
+	}
 
 	// Step 2: NewSession (or LoadSession if resuming)
 	meta := d.buildMeta(opts)
@@ -266,19 +497,32 @@ func (d *acpDriver) runSession(ctx context.Context, sess *acpSession, conn *acp.
 	var sessionID acp.SessionId
 
 	if opts.ResumeSessionID != "" {
-		// Resume an existing session.
-		_, loadErr := conn.LoadSession(ctx, acp.LoadSessionRequest{
+		// Resume an existing session. The agent reloads it from its own
+		// storage, so this works across worker restarts.
+		if !initResp.AgentCapabilities.LoadSession {
+			d.log.Error("ACP agent cannot load sessions", "agent_session_id", opts.ResumeSessionID)
+			d.countError("load_session")
+			sess.fail(fmt.Errorf("agent %s does not support loading session %s", d.config.AgentID, opts.ResumeSessionID))
+			return
+		}
+		loadResp, loadErr := conn.LoadSession(ctx, acp.LoadSessionRequest{
 			SessionId:  acp.SessionId(opts.ResumeSessionID),
 			Cwd:        opts.Cwd,
 			McpServers: mcpServers,
 		})
 		if loadErr != nil {
 			d.log.Error("ACP load session failed", "error", loadErr)
-			sess.setStatus(SessionStatusErrored)
+			d.countError("load_session")
+			sess.fail(loadErr)
 			return
 		}
 		sessionID = acp.SessionId(opts.ResumeSessionID)
 		d.log.Info("ACP session loaded", "agent_session_id", sessionID)
+
+		sess.mu.Lock()
+		applyModelState(&sess.info, loadResp.Models)
+		applyModeState(&sess.info, loadResp.Modes)
+		sess.mu.Unlock()
 	} else {
 		newSessResp, newErr := conn.NewSession(ctx, acp.NewSessionRequest{
 			Cwd:        opts.Cwd,
@@ -287,44 +531,37 @@ func (d *acpDriver) runSession(ctx context.Context, sess *acpSession, conn *acp.
 		})
 		if newErr != nil {
 			d.log.Error("ACP new session failed", "error", newErr)
-			sess.setStatus(SessionStatusErrored)
+			d.countError("new_session")
+			sess.fail(newErr)
 			return
 		}
 		sessionID = newSessResp.SessionId
 		d.log.Info("ACP session created", "agent_session_id", sessionID)
 
 		sess.mu.Lock()
-		if newSessResp.Models != nil {
-			models := make([]string, 0, len(newSessResp.Models.AvailableModels))
-			for _, m := range newSessResp.Models.AvailableModels {
-				if m.ModelId == "" {
-					continue
-				}
-				models = append(models, string(m.ModelId))
-			}
-			sess.info.Models = models
-			if newSessResp.Models.CurrentModelId != "" {
-				sess.info.CurrentModel = string(newSessResp.Models.CurrentModelId)
-			}
-		}
-		if newSessResp.Modes != nil {
-			modes := make([]string, 0, len(newSessResp.Modes.AvailableModes))
-			for _, m := range newSessResp.Modes.AvailableModes {
-				if m.Id == "" {
-					continue
-				}
-				modes = append(modes, string(m.Id))
-			}
-			sess.info.Modes = modes
-		}
+		applyModelState(&sess.info, newSessResp.Models)
+		applyModeState(&sess.info, newSessResp.Modes)
 		sess.mu.Unlock()
 	}
 
 	sess.mu.Lock()
 	sess.info.AgentSessionID = string(sessionID)
+	// Agents that don't report model state still run the requested model.
+	if sess.info.CurrentModel == "" {
+		sess.info.CurrentModel = opts.Model
+	}
+	// Nor do agents that don't report modes leave the launch mode, so a
+	// per-prompt mode override has a mode to go back to.
+	if sess.info.CurrentMode == "" {
+		sess.info.CurrentMode = string(launchSessionMode(opts.SessionMode))
+	}
 	sess.mu.Unlock()
 	sess.setStatus(SessionStatusRunning)
 
+	if sess.pinger != nil && d.liveness.Interval > 0 {
+		go d.watchLiveness(ctx, sess)
+	}
+
 	// Step 3: Initial prompt (optional).
 	if strings.TrimSpace(opts.Prompt) != "" {
 		// For subprocess agents, _meta.systemPrompt is non-standard and may be
@@ -337,14 +574,18 @@ func (d *acpDriver) runSession(ctx context.Context, sess *acpSession, conn *acp.
 		}
 		blocks = append(blocks, acp.TextBlock(opts.Prompt))
 
-		promptResp, promptErr := d.doPrompt(ctx, conn, sessionID, blocks)
+		promptResp, promptErr := d.runTurn(ctx, nil, sess, conn, sessionID, blocks)
+		if promptErr == nil {
+			promptErr = d.autoContinue(ctx, sess, conn, sessionID, promptResp, opts.AutoContinue)
+		}
 		if promptErr != nil {
 			if ctx.Err() != nil {
 				d.log.Info("ACP session cancelled")
 				return
 			}
 			d.log.Error("ACP prompt failed", "error", promptErr)
-			sess.setStatus(SessionStatusErrored)
+			d.countError("prompt")
+			sess.fail(promptErr)
 			return
 		}
 		d.log.Info("ACP prompt completed", "stop_reason", promptResp.StopReason)
@@ -356,20 +597,55 @@ func (d *acpDriver) runSession(ctx context.Context, sess *acpSession, conn *acp.
 	for {
 		select {
 		case req := <-sess.promptCh:
+			if sess.softStopped() {
+				req.resultCh <- promptResult{err: ErrSessionStopping}
+				d.log.Info("ACP session soft-stopped")
+				return
+			}
+			if err := req.ctx.Err(); err != nil {
+				// The caller gave up before the session took the prompt.
+				req.resultCh <- promptResult{err: err}
+				continue
+			}
+			if req.command != nil {
+				resp, forward, err := req.command.run(req.ctx, sess)
+				if !forward {
+					req.resultCh <- promptResult{resp: resp, err: err}
+					continue
+				}
+			}
 			sess.setStatus(SessionStatusRunning)
-			resp, pErr := d.doPrompt(ctx, conn, sessionID, req.blocks)
+			resp, pErr := d.runTurn(ctx, req.ctx, sess, conn, sessionID, req.blocks)
 			req.resultCh <- promptResult{resp: resp, err: pErr}
+			if pErr == nil {
+				pErr = d.autoContinue(ctx, sess, conn, sessionID, resp, opts.AutoContinue)
+			}
 			if pErr != nil && ctx.Err() != nil {
 				return
 			}
+			// Other prompt errors leave the session usable, but a rejected
+			// credential fails every later turn too.
+			if _, ok := driver.AuthFailureMessage(pErr); ok {
+				d.log.Error("ACP prompt failed: authentication required", "error", pErr)
+				d.countError("prompt")
+				sess.fail(pErr)
+				return
+			}
 			sess.setStatus(SessionStatusIdle)
 
-		case <-sess.cancelCh:
-			// Drain any pending cancel signals.
-			for len(sess.cancelCh) > 0 {
-				<-sess.cancelCh
+		case <-conn.Done():
+			// The agent went away (e.g. the subprocess crashed) without
+			// the session being stopped.
+			if ctx.Err() == nil {
+				d.log.Error("ACP agent connection closed unexpectedly")
+				d.countError("connection")
+				sess.fail(fmt.Errorf("agent %s exited unexpectedly", d.config.AgentID))
 			}
-			d.log.Info("ACP session cancel requested")
+			return
+
+		case <-sess.stopping:
+			d.log.Info("ACP session soft-stopped")
+			return
 
 		case <-ctx.Done():
 			return
@@ -377,6 +653,114 @@ func (d *acpDriver) runSession(ctx context.Context, sess *acpSession, conn *acp.
 	}
 }
 
+// applyModeState records the modes the agent offers and the one it is in.
+func applyModeState(info *SessionInfo, state *acp.SessionModeState) {
+	if state == nil {
+		return
+	}
+	modes := make([]SessionModeInfo, 0, len(state.AvailableModes))
+	for _, m := range state.AvailableModes {
+		if m.Id == "" {
+			continue
+		}
+		mode := SessionModeInfo{ID: string(m.Id), Name: m.Name}
+		if m.Description != nil {
+			mode.Description = *m.Description
+		}
+		modes = append(modes, mode)
+	}
+	info.Modes = modes
+	info.CurrentMode = string(state.CurrentModeId)
+}
+
+// checkProtocolVersion compares the ACP protocol version the agent chose with
+// ours. ACP versions are bumped only for breaking changes, so any other
+// version is incompatible. Agents that report no version are assumed to speak
+// ours, with a warning.
+func (d *acpDriver) checkProtocolVersion(v acp.ProtocolVersion) error {
+	switch v {
+	case acp.ProtocolVersionNumber:
+		return nil
+	case 0:
+		d.log.Warn("ACP agent reported no protocol version, assuming ours", "protocol_version", acp.ProtocolVersionNumber)
+		return nil
+	}
+	return fmt.Errorf("agent %s speaks ACP protocol version %d, want %d", d.config.AgentID, v, acp.ProtocolVersionNumber)
+}
+
+// applyModelState records the agent-reported model list and effective model
+// on info. The current model reflects the agent's default when the caller
+// didn't request one.
+func applyModelState(info *SessionInfo, state *acp.SessionModelState) {
+	if state == nil {
+		return
+	}
+	models := make([]string, 0, len(state.AvailableModels))
+	for _, m := range state.AvailableModels {
+		if m.ModelId == "" {
+			continue
+		}
+		models = append(models, string(m.ModelId))
+	}
+	info.Models = models
+	if state.CurrentModelId != "" {
+		info.CurrentModel = string(state.CurrentModelId)
+	}
+}
+
+func (d *acpDriver) countError(op string) {
+	d.metrics.Counter(metrics.Errors, 1, metrics.Labels{"agent": d.config.AgentID, "op": op})
+}
+
+// runTurn runs one prompt turn, waits for the updates the agent sent during
+// it, and then closes any tool call the agent left in progress: completed
+// when the turn ended normally, failed when it was cancelled or errored. If
+// the deadline of promptCtx, the context of the Prompt call that started
+// the turn, passes first, the agent is asked to cancel the turn, which
+// nobody waits for anymore. Turns the driver starts itself have no
+// promptCtx. The end of the turn is reported to sess.onTurnEnded.
+func (d *acpDriver) runTurn(ctx, promptCtx context.Context, sess *acpSession, conn *acp.ClientSideConnection, sessionID acp.SessionId, blocks []acp.ContentBlock) (*acp.PromptResponse, error) {
+	sess.client.startTurn()
+	var timedOut atomic.Bool
+	stopTimeout := func() bool { return false }
+	if promptCtx != nil {
+		stopTimeout = context.AfterFunc(promptCtx, func() {
+			if !errors.Is(promptCtx.Err(), context.DeadlineExceeded) {
+				return
+			}
+			timedOut.Store(true)
+			if err := sess.Cancel(context.WithoutCancel(promptCtx)); err != nil {
+				d.log.Warn("failed to cancel timed out prompt", "error", err)
+			}
+		})
+	}
+	resp, err := d.doPrompt(ctx, conn, sessionID, blocks)
+	stopTimeout()
+	sess.client.awaitUpdates(ctx)
+	stats := sess.client.turnStats()
+	status := acp.ToolCallStatusCompleted
+	if err != nil || resp.StopReason == acp.StopReasonCancelled {
+		status = acp.ToolCallStatusFailed
+	}
+	sess.client.finishActiveTools(sessionID, status)
+	if resp != nil {
+		sess.mu.Lock()
+		sess.info.LastStopReason = resp.StopReason
+		sess.mu.Unlock()
+		stats.Usage, _ = driver.ParseTurnUsage(resp.Meta)
+		WithTurnStats(resp, stats)
+	}
+	if sess.onTurnEnded != nil {
+		switch {
+		case err == nil:
+			sess.onTurnEnded(TurnEnd{StopReason: resp.StopReason, TimedOut: timedOut.Load(), Stats: &stats})
+		case timedOut.Load():
+			sess.onTurnEnded(TurnEnd{StopReason: acp.StopReasonCancelled, TimedOut: true, Stats: &stats})
+		}
+	}
+	return resp, err
+}
+
 // doPrompt sends a single prompt turn to the ACP connection.
 func (d *acpDriver) doPrompt(ctx context.Context, conn *acp.ClientSideConnection, sessionID acp.SessionId, blocks []acp.ContentBlock) (*acp.PromptResponse, error) {
 	resp, err := conn.Prompt(ctx, acp.PromptRequest{
@@ -428,6 +812,12 @@ func defaultFlowgenticMCPServer(envVars map[string]string) (acp.McpServer, bool)
 		{Name: "AGENTCTL_SESSION_ID", Value: envVars["AGENTCTL_SESSION_ID"]},
 		{Name: "AGENTCTL_AGENT", Value: envVars["AGENTCTL_AGENT"]},
 	}
+	// Optional settings are only passed when set, so agentctl keeps its defaults.
+	for _, name := range []string{"AGENTCTL_PLAN_ROOT", "AGENTCTL_LOG_DIR", "AGENTCTL_LOG_MAX_BYTES", "AGENTCTL_LOG_MAX_FILES"} {
+		if v := envVars[name]; v != "" {
+			env = append(env, acp.EnvVariable{Name: name, Value: v})
+		}
+	}
 
 	command, commandArgs := resolveAgentctlInvocation(envVars)
 	return acp.McpServer{
@@ -144,6 +144,36 @@ func TestDefaultFlowgenticMCPServer_ArgsSerializeAsEmptyArray(t *testing.T) {
 		"args must serialize as [] not null; ACP agents using Zod validation reject null arrays")
 }
 
+func TestDefaultFlowgenticMCPServer_ForwardsPlanRoot(t *testing.T) {
+	envNames := func(envVars map[string]string) map[string]string {
+		server, ok := defaultFlowgenticMCPServer(envVars)
+		require.True(t, ok)
+		got := map[string]string{}
+		for _, e := range server.Stdio.Env {
+			got[e.Name] = e.Value
+		}
+		return got
+	}
+
+	env := envNames(map[string]string{
+		"AGENTCTL_WORKER_URL":    "http://127.0.0.1:9999",
+		"AGENTCTL_SESSION_ID":    "run-1",
+		"AGENTCTL_PLAN_ROOT":     "/srv/plans",
+		"AGENTCTL_LOG_DIR":       "/var/log/flowgentic",
+		"AGENTCTL_LOG_MAX_BYTES": "1048576",
+	})
+	assert.Equal(t, "/srv/plans", env["AGENTCTL_PLAN_ROOT"])
+	assert.Equal(t, "/var/log/flowgentic", env["AGENTCTL_LOG_DIR"])
+	assert.Equal(t, "1048576", env["AGENTCTL_LOG_MAX_BYTES"])
+	assert.NotContains(t, env, "AGENTCTL_LOG_MAX_FILES")
+
+	env = envNames(map[string]string{
+		"AGENTCTL_WORKER_URL": "http://127.0.0.1:9999",
+		"AGENTCTL_SESSION_ID": "run-1",
+	})
+	assert.NotContains(t, env, "AGENTCTL_PLAN_ROOT", "agentctl keeps its default root")
+}
+
 func TestResolveAgentctlInvocation_ArgsNeverNil(t *testing.T) {
 	// Ensure resolveAgentctlInvocation always returns a non-nil slice so that
 	// JSON serialization produces [] instead of null.
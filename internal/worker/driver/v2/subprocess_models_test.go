@@ -4,6 +4,7 @@ import (
 	"context"
 	"log/slog"
 	"testing"
+	"time"
 
 	acp "github.com/coder/acp-go-sdk"
 	"github.com/stretchr/testify/assert"
@@ -83,3 +84,177 @@ func TestDiscoverModels_ErrorsWhenMetadataMissing(t *testing.T) {
 	require.Error(t, err)
 	assert.ErrorContains(t, err, "returned no model metadata")
 }
+
+// closingModelAgent starts background work in NewSession, like the Claude
+// adapter's eager SDK connect, and stops it when closed.
+type closingModelAgent struct {
+	modelAgent
+	ctx     context.Context
+	cancel  context.CancelFunc
+	stopped chan struct{}
+	closes  int
+}
+
+func newClosingModelAgent(state *acp.SessionModelState) *closingModelAgent {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &closingModelAgent{
+		modelAgent: modelAgent{state: state},
+		ctx:        ctx,
+		cancel:     cancel,
+		stopped:    make(chan struct{}),
+	}
+}
+
+func (a *closingModelAgent) NewSession(ctx context.Context, req acp.NewSessionRequest) (acp.NewSessionResponse, error) {
+	go func() {
+		<-a.ctx.Done()
+		close(a.stopped)
+	}()
+	return a.modelAgent.NewSession(ctx, req)
+}
+
+func (a *closingModelAgent) Close() error {
+	a.closes++
+	a.cancel()
+	return nil
+}
+
+func TestDiscoverModels_ClosesInProcessAdapter(t *testing.T) {
+	agent := newClosingModelAgent(&acp.SessionModelState{
+		AvailableModels: []acp.ModelInfo{{ModelId: "model-a"}},
+		CurrentModelId:  "model-a",
+	})
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+	})
+
+	inventory, err := d.DiscoverModels(context.Background(), "/tmp")
+	require.NoError(t, err)
+	assert.Equal(t, "model-a", inventory.DefaultModel)
+
+	assert.Equal(t, 1, agent.closes)
+	select {
+	case <-agent.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("adapter background work still running after discovery")
+	}
+}
+
+func TestDiscoverModels_ClosesInProcessAdapterOnError(t *testing.T) {
+	agent := newClosingModelAgent(nil)
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+	})
+
+	_, err := d.DiscoverModels(context.Background(), "/tmp")
+	require.Error(t, err)
+	assert.Equal(t, 1, agent.closes)
+}
+
+// waitForStatus blocks until want is received on statusCh.
+func waitForStatus(t *testing.T, statusCh <-chan SessionStatus, want SessionStatus) {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case s := <-statusCh:
+			if s == want {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for status %s", want)
+		}
+	}
+}
+
+func TestLaunch_ResolvesAgentDefaultModel(t *testing.T) {
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID: "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent {
+			return &modelAgent{
+				state: &acp.SessionModelState{
+					AvailableModels: []acp.ModelInfo{{ModelId: "model-a"}, {ModelId: "model-b"}},
+					CurrentModelId:  "model-b",
+				},
+			}
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(ctx, LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	waitForStatus(t, statusCh, SessionStatusRunning)
+
+	info := sess.Info()
+	assert.Equal(t, "model-b", info.CurrentModel)
+	assert.Equal(t, []string{"model-a", "model-b"}, info.Models)
+	require.NoError(t, sess.Stop(ctx))
+}
+
+func TestLaunch_FallsBackToRequestedModel(t *testing.T) {
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return &modelAgent{} },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(ctx, LaunchOpts{Cwd: "/tmp", Model: "custom", StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	waitForStatus(t, statusCh, SessionStatusRunning)
+
+	assert.Equal(t, "custom", sess.Info().CurrentModel)
+	require.NoError(t, sess.Stop(ctx))
+}
+
+func TestLaunch_ResolvesModelAliases(t *testing.T) {
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return &modelAgent{} },
+		ModelAliases:   map[string]string{"fast": "model-fast-20250101"},
+	})
+
+	for model, want := range map[string]string{
+		"fast":   "model-fast-20250101",
+		"custom": "custom",
+	} {
+		t.Run(model, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			statusCh := make(chan SessionStatus, 8)
+			sess, err := d.Launch(ctx, LaunchOpts{Cwd: "/tmp", Model: model, StatusCh: statusCh}, nil)
+			require.NoError(t, err)
+			waitForStatus(t, statusCh, SessionStatusRunning)
+
+			assert.Equal(t, want, sess.Info().CurrentModel)
+			require.NoError(t, sess.Stop(ctx))
+		})
+	}
+}
+
+func TestLaunch_ClosesInProcessAdapterOnStop(t *testing.T) {
+	agent := newClosingModelAgent(nil)
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	statusCh := make(chan SessionStatus, 8)
+	sess, err := d.Launch(ctx, LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, nil)
+	require.NoError(t, err)
+	waitForStatus(t, statusCh, SessionStatusRunning)
+
+	require.NoError(t, sess.Stop(ctx))
+	select {
+	case <-agent.stopped:
+	case <-time.After(time.Second):
+		t.Fatal("adapter not closed after stop")
+	}
+}
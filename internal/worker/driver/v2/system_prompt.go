@@ -0,0 +1,35 @@
+package v2
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// SystemPromptVars are the session-scoped variables available to
+// LaunchOpts.SystemPromptTemplate.
+type SystemPromptVars struct {
+	Cwd   string
+	Agent string
+	Date  string // YYYY-MM-DD, local time at launch
+	Topic string
+}
+
+// renderSystemPrompt executes tmpl as a text/template with vars. Strings
+// without template markers are returned verbatim so plain prompts never
+// trip over template parse errors. Values are inserted as-is (no HTML
+// escaping), and template syntax inside values is not re-evaluated.
+func renderSystemPrompt(tmpl string, vars SystemPromptVars) (string, error) {
+	if !strings.Contains(tmpl, "{{") {
+		return tmpl, nil
+	}
+	t, err := template.New("system_prompt").Option("missingkey=error").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("parse system prompt template: %w", err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, vars); err != nil {
+		return "", fmt.Errorf("render system prompt template: %w", err)
+	}
+	return b.String(), nil
+}
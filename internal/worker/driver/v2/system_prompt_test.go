@@ -0,0 +1,106 @@
+package v2
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	acp "github.com/coder/acp-go-sdk"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderSystemPrompt(t *testing.T) {
+	vars := SystemPromptVars{
+		Cwd:   "/home/dev/project",
+		Agent: "claude-code",
+		Date:  "2026-01-02",
+		Topic: "refactor auth",
+	}
+
+	t.Run("renders session variables", func(t *testing.T) {
+		out, err := renderSystemPrompt("You are {{.Agent}} working in {{.Cwd}}.", vars)
+		require.NoError(t, err)
+		assert.Equal(t, "You are claude-code working in /home/dev/project.", out)
+	})
+
+	t.Run("renders date and topic", func(t *testing.T) {
+		out, err := renderSystemPrompt("{{.Date}}: {{.Topic}}", vars)
+		require.NoError(t, err)
+		assert.Equal(t, "2026-01-02: refactor auth", out)
+	})
+
+	t.Run("literal without markers is returned verbatim", func(t *testing.T) {
+		literal := "Return JSON like {\"a\": {\"b\": 1}} and be terse."
+		out, err := renderSystemPrompt(literal, vars)
+		require.NoError(t, err)
+		assert.Equal(t, literal, out)
+	})
+
+	t.Run("values are not html escaped", func(t *testing.T) {
+		out, err := renderSystemPrompt("cwd={{.Cwd}}", SystemPromptVars{Cwd: `/tmp/<a&b>"q"`})
+		require.NoError(t, err)
+		assert.Equal(t, `cwd=/tmp/<a&b>"q"`, out)
+	})
+
+	t.Run("template syntax in values is not evaluated", func(t *testing.T) {
+		out, err := renderSystemPrompt("topic: {{.Topic}}", SystemPromptVars{Topic: "{{.Cwd}}"})
+		require.NoError(t, err)
+		assert.Equal(t, "topic: {{.Cwd}}", out)
+	})
+
+	t.Run("escaped markers render literally", func(t *testing.T) {
+		out, err := renderSystemPrompt(`{{"{{"}}.Cwd}} is {{.Cwd}}`, vars)
+		require.NoError(t, err)
+		assert.Equal(t, "{{.Cwd}} is /home/dev/project", out)
+	})
+
+	t.Run("unknown field errors", func(t *testing.T) {
+		_, err := renderSystemPrompt("{{.Nope}}", vars)
+		assert.Error(t, err)
+	})
+
+	t.Run("parse error", func(t *testing.T) {
+		_, err := renderSystemPrompt("{{.Cwd", vars)
+		assert.Error(t, err)
+	})
+}
+
+func TestLaunch_RendersSystemPromptTemplate(t *testing.T) {
+	metaCh := make(chan string, 1)
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return &modelAgent{} },
+		MetaBuilder: func(opts LaunchOpts) map[string]any {
+			metaCh <- opts.SystemPrompt
+			return defaultMetaBuilder(opts)
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sess, err := d.Launch(ctx, LaunchOpts{
+		Cwd:                  "/work",
+		SystemPromptTemplate: "agent={{.Agent}} cwd={{.Cwd}}",
+	}, nil)
+	require.NoError(t, err)
+
+	select {
+	case got := <-metaCh:
+		assert.Equal(t, "agent=test-agent cwd=/work", got)
+	case <-time.After(2 * time.Second):
+		t.Fatal("timed out waiting for NewSession meta")
+	}
+	require.NoError(t, sess.Stop(ctx))
+}
+
+func TestLaunch_InvalidSystemPromptTemplate(t *testing.T) {
+	d := NewDriver(testLogger(), AgentConfig{
+		AgentID:        "test-agent",
+		AdapterFactory: func(_ *slog.Logger) acp.Agent { return &modelAgent{} },
+	})
+	_, err := d.Launch(context.Background(), LaunchOpts{SystemPromptTemplate: "{{.Cwd"}, nil)
+	assert.ErrorContains(t, err, "parse system prompt template")
+}
@@ -0,0 +1,66 @@
+package v2
+
+import (
+	"time"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/sebastianm/flowgentic/internal/worker/driver"
+)
+
+const turnStatsMetaKey = "turnStats"
+
+// TurnStats describes one prompt turn, for performance analysis.
+type TurnStats struct {
+	Duration time.Duration `json:"duration"` // from sending the prompt to the response
+	// TimeToFirstToken is how long the agent took to send its first message
+	// or thought chunk; zero if it sent none.
+	TimeToFirstToken time.Duration    `json:"time_to_first_token,omitempty"`
+	ToolCalls        int              `json:"tool_calls"`      // distinct tool calls started in the turn
+	Usage            driver.TurnUsage `json:"usage,omitempty"` // as reported by the agent, if it does
+}
+
+// TurnStatsFromResponse returns the stats the driver attached to the
+// response of Session.Prompt.
+func TurnStatsFromResponse(resp *acp.PromptResponse) (TurnStats, bool) {
+	if resp == nil {
+		return TurnStats{}, false
+	}
+	m, ok := resp.Meta.(map[string]any)
+	if !ok {
+		return TurnStats{}, false
+	}
+	stats, ok := m[turnStatsMetaKey].(TurnStats)
+	return stats, ok
+}
+
+// WithTurnStats adds stats to the _meta of resp, keeping what the agent put
+// there. Session implementations use it to report a turn's stats.
+func WithTurnStats(resp *acp.PromptResponse, stats TurnStats) {
+	meta := map[string]any{turnStatsMetaKey: stats}
+	if m, ok := resp.Meta.(map[string]any); ok {
+		for k, v := range m {
+			if _, exists := meta[k]; !exists {
+				meta[k] = v
+			}
+		}
+	}
+	resp.Meta = meta
+}
+
+// isFirstTokenUpdate reports whether u is agent output that counts towards
+// the time to first token. Thoughts carrying adapter reports in _meta are
+// not the agent's output.
+func isFirstTokenUpdate(u acp.SessionUpdate) bool {
+	switch {
+	case u.AgentMessageChunk != nil:
+		return true
+	case u.AgentThoughtChunk != nil:
+		if _, ok := driver.ParseMCPStartup(u.AgentThoughtChunk.Meta); ok {
+			return false
+		}
+		_, ok := driver.ParseSessionInit(u.AgentThoughtChunk.Meta)
+		return !ok
+	default:
+		return false
+	}
+}
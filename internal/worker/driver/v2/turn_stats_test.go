@@ -0,0 +1,82 @@
+package v2
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+	"time"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/sebastianm/flowgentic/internal/worker/driver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statsAgent replies after firstTokenDelay, then starts a tool call and
+// ends the turn reporting usage. Without a reply it only ends the turn.
+type statsAgent struct {
+	modelAgent
+	conn            *acp.AgentSideConnection
+	reply           bool
+	firstTokenDelay time.Duration
+}
+
+func (a *statsAgent) SetConnection(conn *acp.AgentSideConnection) { a.conn = conn }
+
+func (a *statsAgent) Prompt(ctx context.Context, req acp.PromptRequest) (acp.PromptResponse, error) {
+	if !a.reply {
+		return acp.PromptResponse{StopReason: acp.StopReasonEndTurn}, nil
+	}
+	send := func(u acp.SessionUpdate) {
+		_ = a.conn.SessionUpdate(ctx, acp.SessionNotification{SessionId: req.SessionId, Update: u})
+	}
+	time.Sleep(a.firstTokenDelay)
+	send(acp.UpdateAgentMessageText("on it"))
+	send(acp.StartToolCall("tc-1", "Read", acp.WithStartStatus(acp.ToolCallStatusInProgress)))
+	// Notifications are handled concurrently with the response; give them
+	// time to arrive first.
+	time.Sleep(50 * time.Millisecond)
+	return acp.PromptResponse{
+		StopReason: acp.StopReasonEndTurn,
+		Meta:       driver.TurnUsageMeta(driver.TurnUsage{InputTokens: 120, OutputTokens: 34}),
+	}, nil
+}
+
+func TestTurnStats(t *testing.T) {
+	prompt := func(t *testing.T, agent *statsAgent) *acp.PromptResponse {
+		t.Helper()
+		d := NewDriver(testLogger(), AgentConfig{
+			AgentID:        "test-agent",
+			AdapterFactory: func(_ *slog.Logger) acp.Agent { return agent },
+		})
+		statusCh := make(chan SessionStatus, 8)
+		sess, err := d.Launch(context.Background(), LaunchOpts{Cwd: "/tmp", StatusCh: statusCh}, func(acp.SessionNotification) {})
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = sess.Stop(context.Background()) })
+		waitForStatus(t, statusCh, SessionStatusRunning)
+
+		resp, err := sess.Prompt(context.Background(), []acp.ContentBlock{acp.TextBlock("hi")})
+		require.NoError(t, err)
+		return resp
+	}
+
+	t.Run("records the time to first token", func(t *testing.T) {
+		resp := prompt(t, &statsAgent{reply: true, firstTokenDelay: 20 * time.Millisecond})
+		stats, ok := TurnStatsFromResponse(resp)
+		require.True(t, ok)
+		assert.GreaterOrEqual(t, stats.TimeToFirstToken, 20*time.Millisecond)
+		assert.Greater(t, stats.Duration, stats.TimeToFirstToken)
+		assert.Equal(t, 1, stats.ToolCalls)
+		assert.Equal(t, driver.TurnUsage{InputTokens: 120, OutputTokens: 34}, stats.Usage)
+		_, ok = driver.ParseTurnUsage(resp.Meta)
+		assert.True(t, ok, "the agent's _meta is kept")
+	})
+
+	t.Run("no output leaves the time to first token unset", func(t *testing.T) {
+		stats, ok := TurnStatsFromResponse(prompt(t, &statsAgent{}))
+		require.True(t, ok)
+		assert.Zero(t, stats.TimeToFirstToken)
+		assert.Zero(t, stats.ToolCalls)
+		assert.Zero(t, stats.Usage)
+	})
+}
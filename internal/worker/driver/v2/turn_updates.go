@@ -0,0 +1,151 @@
+package v2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	acp "github.com/coder/acp-go-sdk"
+)
+
+// updateWaitTimeout bounds how long a finished turn waits for its session
+// updates to be handled, in case one fails to decode and never reaches the
+// client.
+const updateWaitTimeout = time.Second
+
+// countUpdates returns r, counting the session/update notifications read
+// from it for client.awaitUpdates. The connection handles notifications
+// concurrently with responses, so a prompt response may be handled before
+// the last updates of its turn; they are counted before either is handled.
+func countUpdates(client *flowgenticClient, r io.Reader) io.Reader {
+	return io.TeeReader(r, &updateCounter{client: client})
+}
+
+// updateCounter buffers partial lines until their newline arrives.
+type updateCounter struct {
+	client *flowgenticClient
+	buf    []byte
+}
+
+func (u *updateCounter) Write(p []byte) (int, error) {
+	u.buf = append(u.buf, p...)
+	for {
+		i := bytes.IndexByte(u.buf, '\n')
+		if i < 0 {
+			break
+		}
+		var msg struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+			Params struct {
+				Update struct {
+					SessionUpdate string `json:"sessionUpdate"`
+				} `json:"update"`
+			} `json:"params"`
+		}
+		if json.Unmarshal(u.buf[:i], &msg) == nil && msg.ID == nil && msg.Method == acp.ClientMethodSessionUpdate {
+			if msg.Params.Update.SessionUpdate == "plan" {
+				u.client.planRead(u.buf[:i])
+			}
+			u.client.updateRead()
+		}
+		u.buf = u.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// planRead numbers the plan update in line by the order it was read.
+func (c *flowgenticClient) planRead(line []byte) {
+	var msg struct {
+		Params acp.SessionNotification `json:"params"`
+	}
+	if json.Unmarshal(line, &msg) != nil {
+		return
+	}
+	key, ok := planKey(msg.Params)
+	if !ok {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.plansRead++
+	if c.planSeqs == nil {
+		c.planSeqs = make(map[string][]int64)
+	}
+	c.planSeqs[key] = append(c.planSeqs[key], c.plansRead)
+}
+
+// planHandled returns the read order planRead gave the plan update n, or 0
+// if it was not read through countUpdates. Identical updates share a key
+// and take their numbers in turn.
+func (c *flowgenticClient) planHandled(n acp.SessionNotification) int64 {
+	key, ok := planKey(n)
+	if !ok {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	seqs := c.planSeqs[key]
+	if len(seqs) == 0 {
+		return 0
+	}
+	if len(seqs) == 1 {
+		delete(c.planSeqs, key)
+	} else {
+		c.planSeqs[key] = seqs[1:]
+	}
+	return seqs[0]
+}
+
+// planKey identifies a plan update by its session and entries, as decoded,
+// so the line read and the notification handled give the same key.
+func planKey(n acp.SessionNotification) (string, bool) {
+	if n.Update.Plan == nil {
+		return "", false
+	}
+	raw, err := json.Marshal(n.Update.Plan.Entries)
+	if err != nil {
+		return "", false
+	}
+	return string(n.SessionId) + "\x00" + string(raw), true
+}
+
+func (c *flowgenticClient) updateRead() {
+	c.mu.Lock()
+	c.updatesRead++
+	c.mu.Unlock()
+}
+
+func (c *flowgenticClient) updateHandled() {
+	c.mu.Lock()
+	c.updatesHandled++
+	close(c.updatesChanged)
+	c.updatesChanged = make(chan struct{})
+	c.mu.Unlock()
+}
+
+// awaitUpdates waits until the session updates read so far have been
+// handled, or ctx ends, or updateWaitTimeout passes. After a prompt
+// response it is the end of the turn: the reply and tool calls are complete.
+func (c *flowgenticClient) awaitUpdates(ctx context.Context) {
+	timeout := time.NewTimer(updateWaitTimeout)
+	defer timeout.Stop()
+
+	c.mu.Lock()
+	target := c.updatesRead
+	for c.updatesHandled < target {
+		changed := c.updatesChanged
+		c.mu.Unlock()
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return
+		case <-timeout.C:
+			return
+		}
+		c.mu.Lock()
+	}
+	c.mu.Unlock()
+}
@@ -0,0 +1,113 @@
+package v2
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAwaitUpdates(t *testing.T) {
+	c := newFlowgenticClient(nil, nil, "")
+	stream := strings.Join([]string{
+		`{"jsonrpc":"2.0","method":"session/update","params":{"sessionId":"s1"}}`,
+		`{"jsonrpc":"2.0","id":2,"method":"session/request_permission","params":{}}`,
+		`{"jsonrpc":"2.0","id":1,"result":{"stopReason":"end_turn"}}`,
+		`{"jsonrpc":"2.0","method":"session/upd`,
+	}, "\n")
+	_, err := io.Copy(io.Discard, countUpdates(c, strings.NewReader(stream)))
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		c.awaitUpdates(context.Background())
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("awaitUpdates returned before the update was handled")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Only the complete notification counts, not requests, responses or a
+	// partial line.
+	require.NoError(t, c.SessionUpdate(context.Background(), acp.SessionNotification{SessionId: "s1", Update: acp.UpdateAgentMessageText("all done")}))
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("awaitUpdates did not return once the update was handled")
+	}
+	assert.Equal(t, "all done", c.turnReply())
+}
+
+func TestAwaitUpdates_ContextEnds(t *testing.T) {
+	c := newFlowgenticClient(nil, nil, "")
+	c.updateRead()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	c.awaitUpdates(ctx)
+	assert.Less(t, time.Since(start), updateWaitTimeout)
+}
+
+func TestPlanUpdates_HandledOutOfOrder(t *testing.T) {
+	c := newFlowgenticClient(nil, nil, "")
+	sess := &acpSession{}
+	c.onPlan = sess.replacePlan
+	first := acp.SessionNotification{SessionId: "s1", Update: acp.UpdatePlan(acp.PlanEntry{Content: "read", Status: acp.PlanEntryStatusInProgress})}
+	second := acp.SessionNotification{SessionId: "s1", Update: acp.UpdatePlan(acp.PlanEntry{Content: "read", Status: acp.PlanEntryStatusCompleted})}
+	var stream strings.Builder
+	for _, n := range []acp.SessionNotification{first, second} {
+		params, err := json.Marshal(n)
+		require.NoError(t, err)
+		fmt.Fprintf(&stream, `{"jsonrpc":"2.0","method":"session/update","params":%s}`+"\n", params)
+	}
+	_, err := io.Copy(io.Discard, countUpdates(c, strings.NewReader(stream.String())))
+	require.NoError(t, err)
+
+	var forwarded []acp.PlanEntryStatus
+	c.onEvent = func(n acp.SessionNotification) {
+		if n.Update.Plan != nil {
+			forwarded = append(forwarded, n.Update.Plan.Entries[0].Status)
+		}
+	}
+	// The connection handles the second update before the first.
+	require.NoError(t, c.SessionUpdate(context.Background(), second))
+	require.NoError(t, c.SessionUpdate(context.Background(), first))
+
+	assert.Equal(t, []acp.PlanEntryStatus{acp.PlanEntryStatusCompleted}, forwarded, "the stale first update is dropped")
+	assert.Equal(t, acp.PlanEntryStatusCompleted, sess.info.Plan[0].Status)
+	assert.Empty(t, c.planSeqs)
+}
+
+func TestReplacePlan_LateUpdateFillsStatuses(t *testing.T) {
+	sess := &acpSession{}
+	later := []acp.PlanEntry{{Content: "read", Status: acp.PlanEntryStatusCompleted}, {Content: "write"}}
+	plan, ok := sess.replacePlan(later, 2)
+	require.True(t, ok)
+	assert.Empty(t, plan[1].Status)
+
+	// The earlier update, handled last, supplies the status the later one
+	// left out.
+	plan, ok = sess.replacePlan([]acp.PlanEntry{
+		{Content: "read", Status: acp.PlanEntryStatusInProgress},
+		{Content: "write", Status: acp.PlanEntryStatusPending},
+	}, 1)
+	require.True(t, ok)
+	assert.Equal(t, []acp.PlanEntry{
+		{Content: "read", Status: acp.PlanEntryStatusCompleted},
+		{Content: "write", Status: acp.PlanEntryStatusPending},
+	}, plan)
+	assert.Equal(t, plan, sess.info.Plan)
+
+	_, ok = sess.replacePlan([]acp.PlanEntry{{Content: "write", Status: acp.PlanEntryStatusCompleted}}, 1)
+	assert.False(t, ok, "an update older than both is dropped")
+}
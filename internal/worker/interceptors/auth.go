@@ -4,31 +4,159 @@ import (
 	"context"
 	"crypto/subtle"
 	"errors"
+	"net/http"
 
 	"connectrpc.com/connect"
 )
 
 const bearerPrefix = "Bearer "
 
+// SharedSecretSubject is the identity subject of callers authenticated by
+// the shared secret.
+const SharedSecretSubject = "shared-secret"
+
+var errInvalidAuth = errors.New("invalid or missing authorization")
+
+// Identity is the caller a request was authenticated as.
+type Identity struct {
+	// Subject names the caller, e.g. a worker or client ID.
+	Subject string
+	// Attributes carries scheme-specific details such as a tenant or roles.
+	Attributes map[string]string
+}
+
+// IncomingRequest is what an Authenticator sees of an incoming unary
+// request or stream. connect.AnyRequest implements it.
+type IncomingRequest interface {
+	Spec() connect.Spec
+	Peer() connect.Peer
+	Header() http.Header
+}
+
+// Authenticator validates the credentials of an incoming request and returns
+// the caller's identity. A returned *connect.Error keeps its code; any other
+// error is reported as Unauthenticated.
+type Authenticator interface {
+	Authenticate(ctx context.Context, req IncomingRequest) (Identity, error)
+}
+
+// TokenAuthenticator is an Authenticator for bearer-token schemes: it is
+// called with the token from the Authorization header. Requests without a
+// bearer token are rejected before it is called.
+type TokenAuthenticator func(ctx context.Context, token string) (Identity, error)
+
+func (f TokenAuthenticator) Authenticate(ctx context.Context, req IncomingRequest) (Identity, error) {
+	token := extractBearer(req.Header().Get("Authorization"))
+	if token == "" {
+		return Identity{}, errInvalidAuth
+	}
+	return f(ctx, token)
+}
+
+// SharedSecret returns the default Authenticator, which accepts requests
+// whose bearer token is secret.
+func SharedSecret(secret string) Authenticator {
+	return TokenAuthenticator(func(_ context.Context, token string) (Identity, error) {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+			return Identity{}, errInvalidAuth
+		}
+		return Identity{Subject: SharedSecretSubject}, nil
+	})
+}
+
 // NewAuth returns a Connect interceptor that validates the shared
-// secret on incoming requests and attaches it on outgoing client requests.
-func NewAuth(secret string) connect.UnaryInterceptorFunc {
-	return func(next connect.UnaryFunc) connect.UnaryFunc {
-		return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
-			if req.Spec().IsClient {
-				req.Header().Set("Authorization", bearerPrefix+secret)
-				return next(ctx, req)
-			}
+// secret on incoming requests and streams and attaches it on outgoing client
+// requests and streams.
+func NewAuth(secret string) connect.Interceptor {
+	return &authInterceptor{auth: SharedSecret(secret), clientToken: secret}
+}
 
-			token := extractBearer(req.Header().Get("Authorization"))
-			if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
-				return nil, connect.NewError(connect.CodeUnauthenticated, errors.New("invalid or missing authorization"))
+// NewAuthenticator returns a Connect interceptor that validates incoming
+// requests and streams with auth and stores the caller's identity in the
+// handler's context; see IdentityFromContext. Outgoing client requests are
+// passed through unchanged.
+func NewAuthenticator(auth Authenticator) connect.Interceptor {
+	return &authInterceptor{auth: auth}
+}
+
+type authInterceptor struct {
+	auth        Authenticator
+	clientToken string
+}
+
+func (i *authInterceptor) WrapUnary(next connect.UnaryFunc) connect.UnaryFunc {
+	return func(ctx context.Context, req connect.AnyRequest) (connect.AnyResponse, error) {
+		if req.Spec().IsClient {
+			if i.clientToken != "" {
+				req.Header().Set("Authorization", bearerPrefix+i.clientToken)
 			}
 			return next(ctx, req)
 		}
+
+		ctx, err := i.authenticate(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return next(ctx, req)
+	}
+}
+
+func (i *authInterceptor) WrapStreamingClient(next connect.StreamingClientFunc) connect.StreamingClientFunc {
+	return func(ctx context.Context, spec connect.Spec) connect.StreamingClientConn {
+		conn := next(ctx, spec)
+		if i.clientToken != "" {
+			conn.RequestHeader().Set("Authorization", bearerPrefix+i.clientToken)
+		}
+		return conn
 	}
 }
 
+func (i *authInterceptor) WrapStreamingHandler(next connect.StreamingHandlerFunc) connect.StreamingHandlerFunc {
+	return func(ctx context.Context, conn connect.StreamingHandlerConn) error {
+		ctx, err := i.authenticate(ctx, streamRequest{conn})
+		if err != nil {
+			return err
+		}
+		return next(ctx, conn)
+	}
+}
+
+// authenticate runs the Authenticator and returns ctx with the caller's
+// identity.
+func (i *authInterceptor) authenticate(ctx context.Context, req IncomingRequest) (context.Context, error) {
+	id, err := i.auth.Authenticate(ctx, req)
+	if err != nil {
+		if connectErr := new(connect.Error); errors.As(err, &connectErr) {
+			return nil, connectErr
+		}
+		return nil, connect.NewError(connect.CodeUnauthenticated, err)
+	}
+	return ContextWithIdentity(ctx, id), nil
+}
+
+// streamRequest presents a stream's request headers as an IncomingRequest.
+type streamRequest struct {
+	conn connect.StreamingHandlerConn
+}
+
+func (r streamRequest) Spec() connect.Spec  { return r.conn.Spec() }
+func (r streamRequest) Peer() connect.Peer  { return r.conn.Peer() }
+func (r streamRequest) Header() http.Header { return r.conn.RequestHeader() }
+
+type identityKey struct{}
+
+// ContextWithIdentity returns a copy of ctx carrying id.
+func ContextWithIdentity(ctx context.Context, id Identity) context.Context {
+	return context.WithValue(ctx, identityKey{}, id)
+}
+
+// IdentityFromContext returns the identity the request was authenticated
+// as, if any.
+func IdentityFromContext(ctx context.Context) (Identity, bool) {
+	id, ok := ctx.Value(identityKey{}).(Identity)
+	return id, ok
+}
+
 // extractBearer strips the "Bearer " prefix from the Authorization header value.
 func extractBearer(val string) string {
 	if len(val) > len(bearerPrefix) && val[:len(bearerPrefix)] == bearerPrefix {
@@ -0,0 +1,121 @@
+package interceptors
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"connectrpc.com/connect"
+	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
+	"github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1/workerv1connect"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// call runs an incoming request with the given Authorization header through
+// interceptor and returns the identity the handler saw.
+func call(t *testing.T, interceptor connect.Interceptor, authorization string) (Identity, error) {
+	t.Helper()
+	var (
+		got    Identity
+		called bool
+	)
+	next := func(ctx context.Context, _ connect.AnyRequest) (connect.AnyResponse, error) {
+		got, called = IdentityFromContext(ctx)
+		require.True(t, called, "handler sees an identity")
+		return connect.NewResponse(&emptypb.Empty{}), nil
+	}
+	req := connect.NewRequest(&emptypb.Empty{})
+	if authorization != "" {
+		req.Header().Set("Authorization", authorization)
+	}
+	_, err := interceptor.WrapUnary(next)(context.Background(), req)
+	return got, err
+}
+
+func TestNewAuth_SharedSecret(t *testing.T) {
+	auth := NewAuth("s3cret")
+
+	id, err := call(t, auth, "Bearer s3cret")
+	require.NoError(t, err)
+	assert.Equal(t, SharedSecretSubject, id.Subject)
+
+	for _, header := range []string{"", "Bearer wrong", "s3cret"} {
+		_, err := call(t, auth, header)
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err), "header %q", header)
+	}
+}
+
+func TestNewAuthenticator_CustomScheme(t *testing.T) {
+	tokens := map[string]Identity{
+		"worker-a-token": {Subject: "worker-a", Attributes: map[string]string{"tenant": "acme"}},
+	}
+	auth := NewAuthenticator(TokenAuthenticator(func(_ context.Context, token string) (Identity, error) {
+		if token == "revoked" {
+			return Identity{}, connect.NewError(connect.CodePermissionDenied, errors.New("token revoked"))
+		}
+		id, ok := tokens[token]
+		if !ok {
+			return Identity{}, errors.New("unknown token")
+		}
+		return id, nil
+	}))
+
+	id, err := call(t, auth, "Bearer worker-a-token")
+	require.NoError(t, err)
+	assert.Equal(t, tokens["worker-a-token"], id)
+
+	_, err = call(t, auth, "Bearer other")
+	assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err))
+	_, err = call(t, auth, "")
+	assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err), "missing token is rejected before the scheme runs")
+	_, err = call(t, auth, "Bearer revoked")
+	assert.Equal(t, connect.CodePermissionDenied, connect.CodeOf(err), "connect errors keep their code")
+}
+
+// identityProject is a ProjectService whose WatchFileTree records the
+// caller's identity and ends the stream.
+type identityProject struct {
+	workerv1connect.UnimplementedProjectServiceHandler
+	seen chan Identity
+}
+
+func (p *identityProject) WatchFileTree(ctx context.Context, _ *connect.Request[workerv1.WatchFileTreeRequest], _ *connect.ServerStream[workerv1.WatchFileTreeResponse]) error {
+	id, _ := IdentityFromContext(ctx)
+	p.seen <- id
+	return nil
+}
+
+func TestNewAuth_Streams(t *testing.T) {
+	project := &identityProject{seen: make(chan Identity, 1)}
+	mux := http.NewServeMux()
+	mux.Handle(workerv1connect.NewProjectServiceHandler(project, connect.WithInterceptors(NewAuth("s3cret"))))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	watch := func(opts ...connect.ClientOption) error {
+		client := workerv1connect.NewProjectServiceClient(srv.Client(), srv.URL, opts...)
+		stream, err := client.WatchFileTree(context.Background(), connect.NewRequest(&workerv1.WatchFileTreeRequest{}))
+		require.NoError(t, err)
+		defer stream.Close()
+		for stream.Receive() {
+		}
+		return stream.Err()
+	}
+
+	for _, secret := range []string{"", "wrong"} {
+		var opts []connect.ClientOption
+		if secret != "" {
+			opts = append(opts, connect.WithInterceptors(NewAuth(secret)))
+		}
+		err := watch(opts...)
+		assert.Equal(t, connect.CodeUnauthenticated, connect.CodeOf(err), "secret %q", secret)
+	}
+	assert.Empty(t, project.seen, "rejected streams do not reach the handler")
+
+	require.NoError(t, watch(connect.WithInterceptors(NewAuth("s3cret"))))
+	assert.Equal(t, Identity{Subject: SharedSecretSubject}, <-project.seen)
+}
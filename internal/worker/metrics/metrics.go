@@ -0,0 +1,47 @@
+// Package metrics defines the worker's instrumentation interface along with a
+// no-op implementation and one that serves the Prometheus text format.
+package metrics
+
+// Metric names emitted by the worker.
+const (
+	SessionsLaunched   = "flowgentic_worker_sessions_launched_total"
+	SessionsActive     = "flowgentic_worker_sessions_active"
+	SessionsStopped    = "flowgentic_worker_sessions_stopped_total"
+	Prompts            = "flowgentic_worker_prompts_total"
+	PromptDuration     = "flowgentic_worker_prompt_duration_seconds"
+	ToolCalls          = "flowgentic_worker_tool_calls_total"
+	PermissionRequests = "flowgentic_worker_permission_requests_total"
+	Errors             = "flowgentic_worker_errors_total"
+	EventQueueDepth    = "flowgentic_worker_event_queue_depth"
+)
+
+// Labels are the label pairs attached to a single observation.
+type Labels map[string]string
+
+// Metrics records worker measurements. Implementations must be safe for
+// concurrent use.
+type Metrics interface {
+	// Counter adds delta (>= 0) to a monotonically increasing counter.
+	Counter(name string, delta float64, labels Labels)
+	// Gauge adds delta, which may be negative, to a gauge.
+	Gauge(name string, delta float64, labels Labels)
+	// Histogram records a single observation.
+	Histogram(name string, value float64, labels Labels)
+}
+
+// Nop returns a Metrics that discards everything.
+func Nop() Metrics { return nop{} }
+
+type nop struct{}
+
+func (nop) Counter(string, float64, Labels)   {}
+func (nop) Gauge(string, float64, Labels)     {}
+func (nop) Histogram(string, float64, Labels) {}
+
+// OrNop returns m, or Nop() if m is nil.
+func OrNop(m Metrics) Metrics {
+	if m == nil {
+		return Nop()
+	}
+	return m
+}
@@ -0,0 +1,212 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are histogram upper bounds in seconds, tuned for agent
+// prompt turns that run from sub-second to several minutes.
+var DefaultBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600}
+
+type metricKind int
+
+const (
+	kindCounter metricKind = iota
+	kindGauge
+	kindHistogram
+)
+
+func (k metricKind) String() string {
+	switch k {
+	case kindCounter:
+		return "counter"
+	case kindGauge:
+		return "gauge"
+	default:
+		return "histogram"
+	}
+}
+
+// series is one label combination of a metric family.
+type series struct {
+	labels  Labels
+	value   float64  // counter/gauge value, histogram sum
+	count   uint64   // histogram only
+	buckets []uint64 // histogram only, cumulative per DefaultBuckets bound
+}
+
+type family struct {
+	kind   metricKind
+	series map[string]*series
+}
+
+// Prometheus is an in-memory Metrics implementation that serves the
+// Prometheus text exposition format over HTTP.
+type Prometheus struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+var _ Metrics = (*Prometheus)(nil)
+
+// NewPrometheus creates an empty registry.
+func NewPrometheus() *Prometheus {
+	return &Prometheus{families: make(map[string]*family)}
+}
+
+func (p *Prometheus) Counter(name string, delta float64, labels Labels) {
+	if delta < 0 {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s := p.series(name, kindCounter, labels); s != nil {
+		s.value += delta
+	}
+}
+
+func (p *Prometheus) Gauge(name string, delta float64, labels Labels) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if s := p.series(name, kindGauge, labels); s != nil {
+		s.value += delta
+	}
+}
+
+func (p *Prometheus) Histogram(name string, value float64, labels Labels) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	s := p.series(name, kindHistogram, labels)
+	if s == nil {
+		return
+	}
+	s.value += value
+	s.count++
+	for i, bound := range DefaultBuckets {
+		if value <= bound {
+			s.buckets[i]++
+		}
+	}
+}
+
+// series returns the series for name+labels, creating it if needed. A name
+// keeps the kind it was first used with; calls with another kind return nil
+// and are dropped. Callers must hold p.mu.
+func (p *Prometheus) series(name string, kind metricKind, labels Labels) *series {
+	f, ok := p.families[name]
+	if !ok {
+		f = &family{kind: kind, series: make(map[string]*series)}
+		p.families[name] = f
+	}
+	if f.kind != kind {
+		return nil
+	}
+	key := labelKey(labels)
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: cloneLabels(labels)}
+		if f.kind == kindHistogram {
+			s.buckets = make([]uint64, len(DefaultBuckets))
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// ServeHTTP writes all metrics in the Prometheus text format.
+func (p *Prometheus) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = p.Write(w)
+}
+
+// Write renders all metrics in the Prometheus text format, sorted by name
+// and label set so output is stable.
+func (p *Prometheus) Write(w io.Writer) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var b strings.Builder
+	names := make([]string, 0, len(p.families))
+	for name := range p.families {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		f := p.families[name]
+		fmt.Fprintf(&b, "# TYPE %s %s\n", name, f.kind)
+		keys := make([]string, 0, len(f.series))
+		for k := range f.series {
+			keys = append(keys, k)
+		}
+		slices.Sort(keys)
+		for _, k := range keys {
+			s := f.series[k]
+			if f.kind != kindHistogram {
+				fmt.Fprintf(&b, "%s%s %s\n", name, formatLabels(s.labels, "", ""), formatFloat(s.value))
+				continue
+			}
+			for i, bound := range DefaultBuckets {
+				fmt.Fprintf(&b, "%s_bucket%s %d\n", name, formatLabels(s.labels, "le", formatFloat(bound)), s.buckets[i])
+			}
+			fmt.Fprintf(&b, "%s_bucket%s %d\n", name, formatLabels(s.labels, "le", "+Inf"), s.count)
+			fmt.Fprintf(&b, "%s_sum%s %s\n", name, formatLabels(s.labels, "", ""), formatFloat(s.value))
+			fmt.Fprintf(&b, "%s_count%s %d\n", name, formatLabels(s.labels, "", ""), s.count)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func labelKey(labels Labels) string {
+	return formatLabels(labels, "", "")
+}
+
+func cloneLabels(labels Labels) Labels {
+	if len(labels) == 0 {
+		return nil
+	}
+	out := make(Labels, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
+
+// formatLabels renders {k="v",...} sorted by key, optionally appending one
+// extra pair (used for histogram "le").
+func formatLabels(labels Labels, extraKey, extraValue string) string {
+	if len(labels) == 0 && extraKey == "" {
+		return ""
+	}
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	pairs := make([]string, 0, len(keys)+1)
+	for _, k := range keys {
+		pairs = append(pairs, k+`="`+labelEscaper.Replace(labels[k])+`"`)
+	}
+	if extraKey != "" {
+		pairs = append(pairs, extraKey+`="`+labelEscaper.Replace(extraValue)+`"`)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// labelEscaper applies the escaping the text format requires in label values.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPrometheus_CounterAndGauge(t *testing.T) {
+	p := NewPrometheus()
+	p.Counter(SessionsLaunched, 1, Labels{"agent": "codex"})
+	p.Counter(SessionsLaunched, 2, Labels{"agent": "codex"})
+	p.Counter(SessionsLaunched, 1, Labels{"agent": "claude-code"})
+	p.Counter(SessionsLaunched, -5, Labels{"agent": "codex"}) // ignored
+	p.Gauge(SessionsActive, 1, nil)
+	p.Gauge(SessionsActive, 1, nil)
+	p.Gauge(SessionsActive, -1, nil)
+
+	var b strings.Builder
+	require.NoError(t, p.Write(&b))
+	out := b.String()
+
+	assert.Contains(t, out, "# TYPE flowgentic_worker_sessions_launched_total counter\n")
+	assert.Contains(t, out, `flowgentic_worker_sessions_launched_total{agent="claude-code"} 1`+"\n")
+	assert.Contains(t, out, `flowgentic_worker_sessions_launched_total{agent="codex"} 3`+"\n")
+	assert.Contains(t, out, "# TYPE flowgentic_worker_sessions_active gauge\n")
+	assert.Contains(t, out, "flowgentic_worker_sessions_active 1\n")
+}
+
+func TestPrometheus_Histogram(t *testing.T) {
+	p := NewPrometheus()
+	p.Histogram(PromptDuration, 0.3, Labels{"agent": "a"})
+	p.Histogram(PromptDuration, 700, Labels{"agent": "a"})
+
+	var b strings.Builder
+	require.NoError(t, p.Write(&b))
+	out := b.String()
+
+	assert.Contains(t, out, "# TYPE flowgentic_worker_prompt_duration_seconds histogram\n")
+	assert.Contains(t, out, `flowgentic_worker_prompt_duration_seconds_bucket{agent="a",le="0.1"} 0`+"\n")
+	assert.Contains(t, out, `flowgentic_worker_prompt_duration_seconds_bucket{agent="a",le="0.5"} 1`+"\n")
+	assert.Contains(t, out, `flowgentic_worker_prompt_duration_seconds_bucket{agent="a",le="600"} 1`+"\n")
+	assert.Contains(t, out, `flowgentic_worker_prompt_duration_seconds_bucket{agent="a",le="+Inf"} 2`+"\n")
+	assert.Contains(t, out, `flowgentic_worker_prompt_duration_seconds_sum{agent="a"} 700.3`+"\n")
+	assert.Contains(t, out, `flowgentic_worker_prompt_duration_seconds_count{agent="a"} 2`+"\n")
+}
+
+func TestPrometheus_EscapesLabelValues(t *testing.T) {
+	p := NewPrometheus()
+	p.Counter(Errors, 1, Labels{"op": "say \"hi\"\nback\\slash"})
+
+	var b strings.Builder
+	require.NoError(t, p.Write(&b))
+	assert.Contains(t, b.String(), `flowgentic_worker_errors_total{op="say \"hi\"\nback\\slash"} 1`)
+}
+
+func TestPrometheus_ServeHTTP(t *testing.T) {
+	p := NewPrometheus()
+	p.Counter(Prompts, 1, Labels{"agent": "a"})
+
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	assert.Contains(t, rec.Body.String(), `flowgentic_worker_prompts_total{agent="a"} 1`)
+}
+
+func TestPrometheus_KindMismatchIsDropped(t *testing.T) {
+	p := NewPrometheus()
+	p.Gauge(SessionsActive, 1, nil)
+	p.Histogram(SessionsActive, 5, nil)
+	p.Counter(SessionsActive, 5, nil)
+
+	var b strings.Builder
+	require.NoError(t, p.Write(&b))
+	assert.Equal(t, "# TYPE flowgentic_worker_sessions_active gauge\nflowgentic_worker_sessions_active 1\n", b.String())
+}
+
+func TestOrNop(t *testing.T) {
+	assert.Equal(t, Nop(), OrNop(nil))
+	p := NewPrometheus()
+	assert.Same(t, p, OrNop(p))
+}
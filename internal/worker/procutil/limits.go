@@ -0,0 +1,19 @@
+package procutil
+
+// ResourceLimits caps the resources of a child process. Zero fields are
+// unlimited.
+type ResourceLimits struct {
+	// MemoryBytes caps the process's memory.
+	MemoryBytes int64
+	// CPUQuota caps CPU time in CPUs, e.g. 0.5 for half of one CPU.
+	CPUQuota float64
+	// CgroupParent is the cgroup v2 directory the process's cgroup is
+	// created in, e.g. one delegated to the worker by systemd. Empty uses
+	// the worker's own cgroup.
+	CgroupParent string
+}
+
+// IsZero reports whether l sets no limit.
+func (l ResourceLimits) IsZero() bool {
+	return l.MemoryBytes <= 0 && l.CPUQuota <= 0
+}
@@ -0,0 +1,209 @@
+//go:build linux
+
+package procutil
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	cgroupRoot = "/sys/fs/cgroup"
+	// cpuPeriod is the cgroup cpu.max period in microseconds.
+	cpuPeriod = 100000
+
+	// cgroupRemoveAttempts and cgroupRemoveDelay bound how long removing
+	// a busy cgroup is retried.
+	cgroupRemoveAttempts = 20
+	cgroupRemoveDelay    = 50 * time.Millisecond
+)
+
+// StartLimited starts the command with limits applied. The process is
+// started in a new cgroup v2 below limits.CgroupParent, placed there by
+// clone before it execs, so the limits hold from its first instruction.
+// Without a cgroup the limits cannot be enforced and the command is not
+// started. An address space rlimit is deliberately not used as a memory
+// fallback: runtimes like V8 reserve far more address space than they use
+// and fail to start under one.
+//
+// release removes the cgroup; call it once the process has been waited for.
+func StartLimited(cmd *exec.Cmd, limits ResourceLimits) (release func(), err error) {
+	if limits.IsZero() {
+		if err := cmd.Start(); err != nil {
+			return nil, err
+		}
+		return func() {}, nil
+	}
+
+	cg, err := newCgroup(limits)
+	if err != nil {
+		return nil, fmt.Errorf("resource limits need a writable cgroup v2: %w", err)
+	}
+	defer cg.Close()
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.UseCgroupFD = true
+	cmd.SysProcAttr.CgroupFD = int(cg.Fd())
+	dir := cg.Name()
+	if err := cmd.Start(); err != nil {
+		_ = removeCgroup(dir)
+		return nil, err
+	}
+	return func() { _ = removeCgroup(dir) }, nil
+}
+
+// removeCgroup removes the cgroup dir. The directory stays busy while
+// processes are in it, such as ones the agent left behind or ones still
+// exiting, so those are killed and the removal retried for a while.
+func removeCgroup(dir string) error {
+	var err error
+	for i := range cgroupRemoveAttempts {
+		if err = os.Remove(dir); err == nil || errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		if !errors.Is(err, unix.EBUSY) {
+			return err
+		}
+		if i == 0 {
+			_ = os.WriteFile(filepath.Join(dir, "cgroup.kill"), []byte("1"), 0)
+		}
+		time.Sleep(cgroupRemoveDelay)
+	}
+	return err
+}
+
+// newCgroup creates a cgroup with limits and returns its open directory.
+func newCgroup(limits ResourceLimits) (*os.File, error) {
+	parent := limits.CgroupParent
+	if parent == "" {
+		own, err := ownCgroup()
+		if err != nil {
+			return nil, err
+		}
+		parent = filepath.Join(cgroupRoot, own)
+	}
+	var fs unix.Statfs_t
+	if err := unix.Statfs(parent, &fs); err != nil {
+		return nil, fmt.Errorf("stat cgroup: %w", err)
+	}
+	if fs.Type != unix.CGROUP2_SUPER_MAGIC {
+		return nil, fmt.Errorf("%s is not a cgroup v2 directory", parent)
+	}
+
+	var controllers []string
+	if limits.MemoryBytes > 0 {
+		controllers = append(controllers, "memory")
+	}
+	if limits.CPUQuota > 0 {
+		controllers = append(controllers, "cpu")
+	}
+	if err := enableControllers(parent, controllers); err != nil {
+		return nil, err
+	}
+
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(parent, "flowgentic-agent-"+hex.EncodeToString(suffix))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create cgroup: %w", err)
+	}
+
+	err := func() error {
+		if limits.MemoryBytes > 0 {
+			if err := os.WriteFile(filepath.Join(dir, "memory.max"), []byte(strconv.FormatInt(limits.MemoryBytes, 10)), 0); err != nil {
+				return fmt.Errorf("set memory.max: %w", err)
+			}
+		}
+		if limits.CPUQuota > 0 {
+			quota := max(int64(limits.CPUQuota*cpuPeriod), 1000)
+			if err := os.WriteFile(filepath.Join(dir, "cpu.max"), []byte(fmt.Sprintf("%d %d", quota, cpuPeriod)), 0); err != nil {
+				return fmt.Errorf("set cpu.max: %w", err)
+			}
+		}
+		return nil
+	}()
+	if err != nil {
+		_ = os.Remove(dir)
+		return nil, err
+	}
+
+	f, err := os.Open(dir)
+	if err != nil {
+		_ = os.Remove(dir)
+		return nil, err
+	}
+	return f, nil
+}
+
+// enableControllers makes sure the children of the cgroup parent get
+// controllers. Only a cgroup without processes of its own may enable them
+// (the no internal processes rule), apart from the root, so with the worker
+// still in parent the controllers must already be enabled, e.g. by setting
+// CgroupParent to a cgroup delegated to the worker.
+func enableControllers(parent string, controllers []string) error {
+	data, err := os.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+	if err != nil {
+		return fmt.Errorf("read subtree controllers: %w", err)
+	}
+	enabled := strings.Fields(string(data))
+	var missing []string
+	for _, c := range controllers {
+		if !slices.Contains(enabled, c) {
+			missing = append(missing, "+"+c)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if filepath.Clean(parent) != cgroupRoot {
+		procs, err := os.ReadFile(filepath.Join(parent, "cgroup.procs"))
+		if err != nil {
+			return fmt.Errorf("read cgroup processes: %w", err)
+		}
+		if len(bytes.TrimSpace(procs)) > 0 {
+			return fmt.Errorf("%s has processes, so it cannot enable %s for its children; set cgroupParent to a cgroup without processes",
+				parent, strings.Join(missing, " "))
+		}
+	}
+	if err := os.WriteFile(filepath.Join(parent, "cgroup.subtree_control"), []byte(strings.Join(missing, " ")), 0); err != nil {
+		return fmt.Errorf("enable %s: %w", strings.Join(missing, " "), err)
+	}
+	return nil
+}
+
+// ownCgroup returns the cgroup v2 path of the current process.
+func ownCgroup() (string, error) {
+	f, err := os.Open("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		if path, ok := strings.CutPrefix(s.Text(), "0::"); ok {
+			return path, nil
+		}
+	}
+	if err := s.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("no cgroup v2 hierarchy")
+}
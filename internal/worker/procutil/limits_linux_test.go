@@ -0,0 +1,115 @@
+//go:build linux
+
+package procutil_test
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/sebastianm/flowgentic/internal/worker/procutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStartLimited_AppliesMemoryLimit(t *testing.T) {
+	const limit = 1 << 30
+	cmd := exec.Command("sleep", "30")
+	release, err := procutil.StartLimited(cmd, procutil.ResourceLimits{MemoryBytes: limit})
+	if err != nil {
+		assert.Contains(t, err.Error(), "cgroup")
+		assert.Nil(t, cmd.Process, "the process is not started without its limit")
+		return
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		release()
+	}()
+	max, ok := cgroupFile(t, cmd.Process.Pid, "memory.max")
+	require.True(t, ok)
+	assert.Equal(t, strconv.Itoa(limit), max)
+}
+
+func TestStartLimited_ParentWithProcessesKeepsItsControllers(t *testing.T) {
+	// The test process's own cgroup has a process: the test itself.
+	parent := ownCgroupDir(t)
+	if parent == "" || parent == "/sys/fs/cgroup" {
+		t.Skip("needs a non-root cgroup v2")
+	}
+	before, err := os.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+	if err != nil {
+		t.Skip("not a cgroup v2 directory")
+	}
+	if strings.Contains(string(before), "memory") {
+		t.Skip("the memory controller is already enabled for children")
+	}
+
+	_, err = procutil.StartLimited(exec.Command("true"), procutil.ResourceLimits{MemoryBytes: 1 << 30, CgroupParent: parent})
+	require.ErrorContains(t, err, "has processes")
+	after, err := os.ReadFile(filepath.Join(parent, "cgroup.subtree_control"))
+	require.NoError(t, err)
+	assert.Equal(t, string(before), string(after), "controllers are not enabled on a cgroup with processes")
+}
+
+func TestStartLimited_CPUQuotaNeedsCgroup(t *testing.T) {
+	cmd := exec.Command("sleep", "30")
+	release, err := procutil.StartLimited(cmd, procutil.ResourceLimits{CPUQuota: 0.5})
+	if err != nil {
+		assert.Contains(t, err.Error(), "cgroup")
+		assert.Nil(t, cmd.Process, "the process is not started without its limit")
+		return
+	}
+	defer func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		release()
+	}()
+	max, ok := cgroupFile(t, cmd.Process.Pid, "cpu.max")
+	require.True(t, ok)
+	assert.Equal(t, "50000 100000", max)
+}
+
+func TestStartLimited_ZeroLimitsJustStart(t *testing.T) {
+	cmd := exec.Command("true")
+	release, err := procutil.StartLimited(cmd, procutil.ResourceLimits{})
+	require.NoError(t, err)
+	require.NoError(t, cmd.Wait())
+	release()
+}
+
+// ownCgroupDir returns the cgroup v2 directory of the test process, or ""
+// without one.
+func ownCgroupDir(t *testing.T) string {
+	t.Helper()
+	data, err := os.ReadFile("/proc/self/cgroup")
+	require.NoError(t, err)
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			return filepath.Join("/sys/fs/cgroup", path)
+		}
+	}
+	return ""
+}
+
+// cgroupFile reads name from the cgroup v2 directory of pid, if the process
+// is in one that has the file.
+func cgroupFile(t *testing.T, pid int, name string) (string, bool) {
+	t.Helper()
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid))
+	require.NoError(t, err)
+	for _, line := range strings.Split(string(data), "\n") {
+		if path, ok := strings.CutPrefix(line, "0::"); ok {
+			v, err := os.ReadFile(filepath.Join("/sys/fs/cgroup", path, name))
+			if err != nil {
+				return "", false
+			}
+			return strings.TrimSpace(string(v)), true
+		}
+	}
+	return "", false
+}
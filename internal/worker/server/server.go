@@ -1,27 +1,40 @@
 package server
 
 import (
+	"context"
 	"crypto/rand"
+	"crypto/tls"
 	"encoding/hex"
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"syscall"
+	"time"
 
 	"connectrpc.com/connect"
 	"connectrpc.com/grpcreflect"
 	"connectrpc.com/validate"
 	"github.com/sebastianm/flowgentic/internal/config"
 	"github.com/sebastianm/flowgentic/internal/connectutil"
+	"github.com/sebastianm/flowgentic/internal/logutil"
 	workerv1connect "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1/workerv1connect"
+	"github.com/sebastianm/flowgentic/internal/tlsutil"
 	"github.com/sebastianm/flowgentic/internal/tsnetutil"
 	"github.com/sebastianm/flowgentic/internal/worker/agentctl"
+	"github.com/sebastianm/flowgentic/internal/worker/driver"
 	claudeacp "github.com/sebastianm/flowgentic/internal/worker/driver/claude/acp"
 	codexacp "github.com/sebastianm/flowgentic/internal/worker/driver/codex/acp"
 	v2 "github.com/sebastianm/flowgentic/internal/worker/driver/v2"
 	"github.com/sebastianm/flowgentic/internal/worker/interceptors"
+	"github.com/sebastianm/flowgentic/internal/worker/metrics"
+	"github.com/sebastianm/flowgentic/internal/worker/procutil"
 	"github.com/sebastianm/flowgentic/internal/worker/project"
 	"github.com/sebastianm/flowgentic/internal/worker/systeminfo"
 	"github.com/sebastianm/flowgentic/internal/worker/systeminfo/agentinfo"
@@ -32,20 +45,35 @@ import (
 // Opts holds optional CLI overrides for the worker server.
 type Opts struct {
 	ListenAddr string
+	// Authenticator validates requests to the public API, e.g. with
+	// per-client credentials. Nil uses the FLOWGENTIC_WORKER_SECRET shared
+	// secret.
+	Authenticator interceptors.Authenticator
+	// Plaintext serves the public API without TLS even if worker.tls is
+	// configured, for the control plane's embedded worker.
+	Plaintext bool
 }
 
+// shutdownTimeout bounds how long graceful shutdown waits for sessions and
+// in-flight requests before the process exits anyway.
+const shutdownTimeout = 15 * time.Second
+
 type Server struct {
-	log  *slog.Logger
-	cfg  *config.Config
-	ln   *tsnetutil.Listener
-	opts Opts
+	log    *slog.Logger
+	levels *logutil.Levels
+	cfg    *config.Config
+	ln     *tsnetutil.Listener
+	opts   Opts
 }
 
 func New(opts Opts) *Server {
-	log := slog.New(slog.NewTextHandler(os.Stdout, nil)).With("component", "flowgentic-worker")
+	// Levels are filtered per component once the config is read.
+	levels := logutil.NewLevels(slog.LevelInfo)
+	handler := logutil.NewComponentHandler(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}), levels)
 	return &Server{
-		log:  log,
-		opts: opts,
+		log:    slog.New(handler).With("component", "flowgentic-worker"),
+		levels: levels,
+		opts:   opts,
 	}
 }
 
@@ -58,6 +86,13 @@ func (s *Server) Start() error {
 	s.cfg = cfg
 
 	w := cfg.Worker
+	def, components, err := logLevels(w, os.Getenv("FLOWGENTIC_LOG_LEVELS"))
+	if err != nil {
+		s.log.Error("config error", "error", err)
+		return err
+	}
+	s.levels.Set(def, components)
+
 	s.log.Info("Starting flowgentic-worker", "tailscale_enabled", w.Tailscale.Enabled)
 
 	// --- Public listener (Tailscale-aware) ---
@@ -67,38 +102,85 @@ func (s *Server) Start() error {
 		listenAddr = fmt.Sprintf(":%d", w.Port)
 	}
 
+	var tlsCfg *tls.Config
+	if !s.opts.Plaintext {
+		tlsCfg, err = tlsutil.ServerConfig(w.TLS)
+		if err != nil {
+			s.log.Error("config error", "error", err)
+			return fmt.Errorf("worker tls: %w", err)
+		}
+		if tlsCfg != nil && w.Tailscale.HTTPS {
+			return fmt.Errorf("worker tls cannot be combined with tailscale https")
+		}
+	}
+
 	ln, err := tsnetutil.ListenAddr(listenAddr, w.Tailscale)
 	if err != nil {
 		s.log.Error("listen failed", "addr", listenAddr, "error", err)
 		return err
 	}
+	if tlsCfg != nil {
+		ln.Listener = tls.NewListener(ln.Listener, tlsCfg)
+	}
 	s.ln = ln
 	defer s.ln.Close()
 
-	secret := os.Getenv("FLOWGENTIC_WORKER_SECRET")
-	if secret == "" {
-		s.log.Error("FLOWGENTIC_WORKER_SECRET environment variable is required")
-		return fmt.Errorf("FLOWGENTIC_WORKER_SECRET environment variable is required")
+	auth := s.opts.Authenticator
+	if auth == nil {
+		secret := os.Getenv("FLOWGENTIC_WORKER_SECRET")
+		if secret == "" {
+			s.log.Error("FLOWGENTIC_WORKER_SECRET environment variable is required")
+			return fmt.Errorf("FLOWGENTIC_WORKER_SECRET environment variable is required")
+		}
+		auth = interceptors.SharedSecret(secret)
 	}
 
 	validateInterceptor := validate.NewInterceptor()
 
-	publicAuth := connect.WithInterceptors(interceptors.NewAuth(secret), validateInterceptor)
+	publicAuth := connect.WithInterceptors(interceptors.NewAuthenticator(auth), validateInterceptor)
 
 	publicMux := http.NewServeMux()
 
+	mtr := metrics.NewPrometheus()
+	publicMux.Handle("/metrics", mtr)
+
 	// Build V2 driver configs with adapter factories.
 	claudeConfig := v2.ClaudeCodeConfig
 	claudeConfig.AdapterFactory = claudeacp.NewAdapter
 
 	codexConfig := v2.CodexConfig
 	codexConfig.AdapterFactory = codexacp.NewAdapter
+	if w.CodexSharedAppServer {
+		codexConfig.AdapterFactory = codexacp.NewSharedAppServer(s.log).NewAdapter
+	}
+
+	stderr, err := agentStderr(w.AgentStderr)
+	if err != nil {
+		return err
+	}
+
+	liveness := agentLiveness(w.AgentLiveness)
+	slash := v2.WithSlashCommands(hostSlashCommands())
+
+	mcpServers, err := v2.LoadMCPServersFromEnv()
+	if err != nil {
+		s.log.Error("config error", "error", err)
+		return err
+	}
+	if len(mcpServers) > 0 {
+		s.log.Info("loaded MCP server catalog", "path", os.Getenv(v2.MCPConfigEnv), "servers", len(mcpServers))
+	}
 
 	drivers := []v2.Driver{
-		v2.NewDriver(s.log, claudeConfig),
-		v2.NewDriver(s.log, codexConfig),
-		v2.NewDriver(s.log, v2.OpenCodeConfig),
-		v2.NewDriver(s.log, v2.GeminiConfig),
+		v2.NewDriver(s.log, withModelAliases(claudeConfig, s.cfg.Worker), v2.WithMetrics(mtr), stderr, liveness, slash),
+		v2.NewDriver(s.log, withModelAliases(codexConfig, s.cfg.Worker), v2.WithMetrics(mtr), stderr, liveness, slash),
+		v2.NewDriver(s.log, withModelAliases(v2.OpenCodeConfig, s.cfg.Worker), v2.WithMetrics(mtr), stderr, slash),
+		v2.NewDriver(s.log, withModelAliases(v2.GeminiConfig, s.cfg.Worker), v2.WithMetrics(mtr), stderr, slash),
+	}
+
+	tools, err := toolPolicies(w, drivers)
+	if err != nil {
+		return err
 	}
 
 	modelProbeCwd, err := os.Getwd()
@@ -106,15 +188,6 @@ func (s *Server) Start() error {
 		return fmt.Errorf("get working directory: %w", err)
 	}
 
-	systeminfo.Start(systeminfo.StartDeps{
-		Mux:           publicMux,
-		Log:           s.log,
-		Interceptors:  publicAuth,
-		Agents:        agentinfo.NewDiscoverer(),
-		Drivers:       drivers,
-		ModelProbeCwd: modelProbeCwd,
-	})
-
 	project.Start(project.StartDeps{
 		Mux:          publicMux,
 		Log:          s.log,
@@ -154,6 +227,28 @@ func (s *Server) Start() error {
 		Drivers:      drivers,
 		CtlURL:       ctlURL,
 		CtlSecret:    ctlSecret,
+		Metrics:      mtr,
+		PromptWraps:  promptWraps(s.cfg.Worker),
+		ToolPolicies: tools,
+
+		ResourceLimits: resourceLimits(s.cfg.Worker),
+
+		EventRetention: eventRetention(s.cfg.Worker.EventRetention),
+		MaxPromptBytes: s.cfg.Worker.MaxPromptBytes,
+		MaxAttachment:  s.cfg.Worker.MaxAttachmentBytes,
+		MaxRawOutput:   s.cfg.Worker.MaxRawOutputBytes,
+		AgentctlEnv:    agentctlLogEnv(s.cfg.Worker.AgentctlLog),
+		MCPServers:     mcpServers,
+	})
+
+	systeminfo.Start(systeminfo.StartDeps{
+		Mux:           publicMux,
+		Log:           s.log,
+		Interceptors:  publicAuth,
+		Agents:        agentinfo.NewDiscoverer(),
+		Drivers:       drivers,
+		ModelProbeCwd: modelProbeCwd,
+		QueueDepth:    mgr.TotalQueueDepth,
 	})
 
 	// Wire agentctl RPC handlers, passing the SessionManager as EventHandler.
@@ -189,6 +284,7 @@ func (s *Server) Start() error {
 		"ctl_addr", ctlLn.Addr().String(),
 		"tailscale_enabled", w.Tailscale.Enabled,
 		"https", w.Tailscale.HTTPS,
+		"tls", tlsCfg != nil,
 	)
 
 	// Run private CTL server in background goroutine.
@@ -199,9 +295,36 @@ func (s *Server) Start() error {
 		}
 	}()
 
-	// Run public server (blocking).
 	publicSrv := &http.Server{Handler: publicMux, Protocols: protocols}
+
+	// Graceful shutdown on SIGINT/SIGTERM: stop agent sessions first so their
+	// final events still reach connected StateSync streams, then the servers.
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		sig := <-sigCh
+		s.log.Info("received signal, shutting down", "signal", sig)
+		ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := mgr.Shutdown(ctx); err != nil {
+			s.log.Warn("error shutting down sessions", "error", err)
+		}
+		if err := ctlSrv.Shutdown(ctx); err != nil {
+			s.log.Warn("error shutting down ctl server", "error", err)
+		}
+		if err := publicSrv.Shutdown(ctx); err != nil {
+			s.log.Warn("error shutting down public server", "error", err)
+		}
+	}()
+
+	// Run public server (blocking).
 	if err := publicSrv.Serve(s.ln); err != nil {
+		if errors.Is(err, http.ErrServerClosed) {
+			<-shutdownDone
+			return nil
+		}
 		s.log.Error("serve error", "error", err)
 		return fmt.Errorf("serve: %w", err)
 	}
@@ -216,3 +339,170 @@ func generateCtlSecret() (string, error) {
 	}
 	return hex.EncodeToString(b), nil
 }
+
+// promptWraps converts the worker prompt wrap config for the SessionManager.
+func promptWraps(w config.WorkerConfig) workload.PromptWraps {
+	pw := workload.PromptWraps{
+		Default: workload.PromptWrap{Prefix: w.PromptWrap.Prefix, Suffix: w.PromptWrap.Suffix},
+	}
+	if len(w.AgentPromptWrap) > 0 {
+		pw.Agents = make(map[string]workload.PromptWrap, len(w.AgentPromptWrap))
+		for agent, c := range w.AgentPromptWrap {
+			pw.Agents[agent] = workload.PromptWrap{Prefix: c.Prefix, Suffix: c.Suffix}
+		}
+	}
+	return pw
+}
+
+// eventRetention converts the worker event retention config, filling unset
+// limits from workload.DefaultEventRetention.
+func eventRetention(c config.EventRetentionConfig) workload.EventRetention {
+	r := workload.DefaultEventRetention
+	switch {
+	case c.MaxEvents > 0:
+		r.MaxEvents = c.MaxEvents
+	case c.MaxEvents < 0:
+		r.MaxEvents = 0
+	}
+	switch {
+	case c.MaxAgeSeconds > 0:
+		r.MaxAge = time.Duration(c.MaxAgeSeconds) * time.Second
+	case c.MaxAgeSeconds < 0:
+		r.MaxAge = 0
+	}
+	return r
+}
+
+// logLevels returns the worker's log level and the per-component levels
+// from its config, overridden by env in ParseLevels form.
+func logLevels(w config.WorkerConfig, env string) (slog.Level, map[string]slog.Level, error) {
+	def := slog.LevelInfo
+	if w.LogLevel != "" {
+		if err := def.UnmarshalText([]byte(w.LogLevel)); err != nil {
+			return 0, nil, fmt.Errorf("worker.logLevel: %w", err)
+		}
+	}
+	components := make(map[string]slog.Level, len(w.ComponentLogLevels))
+	for component, text := range w.ComponentLogLevels {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(text)); err != nil {
+			return 0, nil, fmt.Errorf("worker.componentLogLevels.%s: %w", component, err)
+		}
+		components[component] = level
+	}
+	overrides, err := logutil.ParseLevels(env)
+	if err != nil {
+		return 0, nil, fmt.Errorf("FLOWGENTIC_LOG_LEVELS: %w", err)
+	}
+	maps.Copy(components, overrides)
+	return def, components, nil
+}
+
+// agentStderr converts the agent stderr config to a driver option.
+func agentStderr(c config.AgentStderrConfig) (v2.Option, error) {
+	level := slog.LevelDebug
+	if c.LogLevel != "" {
+		if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+			return nil, fmt.Errorf("worker.agentStderr.logLevel: %w", err)
+		}
+	}
+	return v2.WithStderr(c.TailLines, level), nil
+}
+
+// agentLiveness converts the agent liveness config to a driver option.
+func agentLiveness(c config.AgentLivenessConfig) v2.Option {
+	return v2.WithLiveness(v2.LivenessProbe{
+		Interval: time.Duration(c.IntervalSeconds) * time.Second,
+		Timeout:  time.Duration(c.TimeoutSeconds) * time.Second,
+		Failures: c.Failures,
+	})
+}
+
+// hostSlashCommands are the slash commands the worker runs itself for every
+// agent: /model switches the session's model instead of asking the agent.
+func hostSlashCommands() v2.SlashCommands {
+	return v2.SlashCommands{
+		"model": func(ctx context.Context, sess v2.Session, arg string) (bool, error) {
+			if arg == "" {
+				return false, errors.New("usage: /model <model>")
+			}
+			return false, sess.SetModel(ctx, arg)
+		},
+	}
+}
+
+// toolPolicies converts the worker tool policy config for the SessionManager.
+// Kind permissions are rejected for agents among drivers that would ignore
+// them.
+func toolPolicies(w config.WorkerConfig, drivers []v2.Driver) (map[string]workload.ToolPolicy, error) {
+	if len(w.AgentToolPolicy) == 0 {
+		return nil, nil
+	}
+	out := make(map[string]workload.ToolPolicy, len(w.AgentToolPolicy))
+	for agent, c := range w.AgentToolPolicy {
+		if _, err := driver.ParseKindPermissions(c.KindPermissions); err != nil {
+			return nil, fmt.Errorf("worker.agentToolPolicy.%s.kindPermissions: %w", agent, err)
+		}
+		if len(c.KindPermissions) > 0 {
+			i := slices.IndexFunc(drivers, func(d v2.Driver) bool { return d.Agent() == agent })
+			if i >= 0 && !drivers[i].Capabilities().Supports(driver.CapToolKindPermissions) {
+				return nil, fmt.Errorf("worker.agentToolPolicy.%s.kindPermissions: agent %s does not support tool kind permissions", agent, agent)
+			}
+		}
+		out[agent] = workload.ToolPolicy{
+			DisallowedTools:      c.DisallowedTools,
+			PlanModeAllowedTools: c.PlanModeAllowedTools,
+			KindPermissions:      c.KindPermissions,
+		}
+	}
+	return out, nil
+}
+
+// resourceLimits converts the worker resource limit config for the
+// SessionManager.
+func resourceLimits(w config.WorkerConfig) map[string]procutil.ResourceLimits {
+	if len(w.AgentResourceLimits) == 0 {
+		return nil
+	}
+	out := make(map[string]procutil.ResourceLimits, len(w.AgentResourceLimits))
+	for agent, c := range w.AgentResourceLimits {
+		out[agent] = procutil.ResourceLimits{
+			MemoryBytes:  c.MemoryBytes,
+			CPUQuota:     c.CPUQuota,
+			CgroupParent: c.CgroupParent,
+		}
+	}
+	return out
+}
+
+// agentctlLogEnv converts the agentctl log config to the AGENTCTL_LOG_* env
+// agentctl reads; unset fields are left out.
+func agentctlLogEnv(c config.AgentctlLogConfig) map[string]string {
+	env := make(map[string]string)
+	if c.Dir != "" {
+		env["AGENTCTL_LOG_DIR"] = c.Dir
+	}
+	if c.MaxBytes != 0 {
+		env["AGENTCTL_LOG_MAX_BYTES"] = strconv.FormatInt(c.MaxBytes, 10)
+	}
+	if c.MaxFiles != 0 {
+		env["AGENTCTL_LOG_MAX_FILES"] = strconv.Itoa(c.MaxFiles)
+	}
+	return env
+}
+
+// withModelAliases layers the configured model aliases for cfg's agent over
+// its built-in ones.
+func withModelAliases(cfg v2.AgentConfig, w config.WorkerConfig) v2.AgentConfig {
+	extra := w.AgentModelAliases[cfg.AgentID]
+	if len(extra) == 0 {
+		return cfg
+	}
+	aliases := maps.Clone(cfg.ModelAliases)
+	if aliases == nil {
+		aliases = make(map[string]string, len(extra))
+	}
+	maps.Copy(aliases, extra)
+	cfg.ModelAliases = aliases
+	return cfg
+}
@@ -20,8 +20,10 @@ func (h *systemServiceHandler) Ping(
 	_ context.Context,
 	_ *connect.Request[workerv1.PingRequest],
 ) (*connect.Response[workerv1.PingResponse], error) {
-	h.svc.Ping()
-	return connect.NewResponse(&workerv1.PingResponse{}), nil
+	health := h.svc.Ping()
+	return connect.NewResponse(&workerv1.PingResponse{
+		QueuedEvents: int64(health.QueuedEvents),
+	}), nil
 }
 
 func (h *systemServiceHandler) ListAgents(
@@ -65,3 +65,17 @@ func TestSystemServiceHandler_GetAgentModels(t *testing.T) {
 		assert.Equal(t, connect.CodeUnavailable, connect.CodeOf(err))
 	})
 }
+
+func TestSystemServiceHandler_PingReportsQueuedEvents(t *testing.T) {
+	svc := NewSystemInfoService(fakeAgentInfo{}, nil, "/tmp")
+	h := &systemServiceHandler{svc: svc}
+
+	resp, err := h.Ping(context.Background(), connect.NewRequest(&workerv1.PingRequest{}))
+	require.NoError(t, err)
+	assert.Zero(t, resp.Msg.QueuedEvents, "no queue source reports zero")
+
+	svc.queueDepth = func() int { return 7 }
+	resp, err = h.Ping(context.Background(), connect.NewRequest(&workerv1.PingRequest{}))
+	require.NoError(t, err)
+	assert.Equal(t, int64(7), resp.Msg.QueuedEvents)
+}
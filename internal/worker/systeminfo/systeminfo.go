@@ -18,11 +18,13 @@ type StartDeps struct {
 	Agents        agentinfo.AgentInfo
 	Drivers       []v2.Driver
 	ModelProbeCwd string
+	QueueDepth    func() int // un-acknowledged session events, reported by Ping
 }
 
 // Start registers the SystemService RPC handler on the mux.
 func Start(d StartDeps) {
 	svc := NewSystemInfoService(d.Agents, d.Drivers, d.ModelProbeCwd)
+	svc.queueDepth = d.QueueDepth
 	h := &systemServiceHandler{log: d.Log, svc: svc}
 	d.Mux.Handle(workerv1connect.NewSystemServiceHandler(h, d.Interceptors))
 }
@@ -32,6 +32,10 @@ type SystemInfoService struct {
 	modelCache    map[driver.AgentType]cachedModels
 	mu            sync.Mutex
 	now           func() time.Time
+
+	// queueDepth reports the worker's un-acknowledged session events; nil
+	// reports zero.
+	queueDepth func() int
 }
 
 type cachedModels struct {
@@ -54,8 +58,19 @@ func NewSystemInfoService(agents agentinfo.AgentInfo, drivers []v2.Driver, model
 	}
 }
 
+// Health is the worker state reported by Ping.
+type Health struct {
+	QueuedEvents int
+}
+
 // Ping is a lightweight health-check that confirms the worker is reachable.
-func (s *SystemInfoService) Ping() {}
+func (s *SystemInfoService) Ping() Health {
+	var h Health
+	if s.queueDepth != nil {
+		h.QueuedEvents = s.queueDepth()
+	}
+	return h
+}
 
 // ListAgents returns all discovered coding agents.
 func (s *SystemInfoService) ListAgents(ctx context.Context, disableCache bool) ([]agentinfo.Agent, error) {
@@ -0,0 +1,141 @@
+package workload
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxAutoTopicRunes bounds derived topics; longer text is cut at a word
+	// boundary and suffixed with "...".
+	maxAutoTopicRunes = 60
+	// minAutoTopicWords is the shortest prompt-derived topic accepted before
+	// falling back to the agent's reply.
+	minAutoTopicWords = 3
+	// maxAutoTopicReplyBytes caps how much of the first reply is buffered.
+	maxAutoTopicReplyBytes = 2048
+)
+
+// Leading phrases that carry no topic information. Matched case-insensitively
+// and stripped repeatedly, so "Hey, can you please ..." reduces fully.
+var (
+	promptFillers = []string{
+		"hi", "hello", "hey", "please", "can you", "could you", "would you",
+		"i want you to", "i'd like you to", "i need you to", "help me", "i need to", "i want to",
+	}
+	replyFillers = []string{
+		"sure", "okay", "ok", "certainly", "great", "of course",
+		"i'll", "i will", "let me", "i'm going to", "i am going to",
+	}
+)
+
+// autoTopic collects the opening exchange of a session that opted into
+// LaunchOpts.AutoTopic. All methods are safe for concurrent use.
+type autoTopic struct {
+	mu     sync.Mutex
+	prompt string
+	reply  strings.Builder
+	done   bool
+}
+
+// notePrompt records the first user prompt; later prompts are ignored.
+func (a *autoTopic) notePrompt(text string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.prompt == "" {
+		a.prompt = text
+	}
+}
+
+// noteReply appends agent message text until the topic has been derived.
+func (a *autoTopic) noteReply(text string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.done || a.prompt == "" || a.reply.Len() >= maxAutoTopicReplyBytes {
+		return
+	}
+	a.reply.WriteString(text)
+}
+
+// take returns the opening exchange once, after the first prompt has been
+// answered. It reports false if there is no prompt yet or it already fired.
+func (a *autoTopic) take() (prompt, reply string, ok bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.done || a.prompt == "" {
+		return "", "", false
+	}
+	a.done = true
+	return a.prompt, a.reply.String(), true
+}
+
+// deriveAutoTopic builds a short topic from the first user prompt, falling
+// back to the first agent reply when the prompt is too terse to describe the
+// session (e.g. "hi" or "fix it").
+func deriveAutoTopic(prompt, reply string) string {
+	topic := topicFromText(prompt, promptFillers)
+	if len(strings.Fields(topic)) < minAutoTopicWords {
+		if fromReply := topicFromText(reply, replyFillers); fromReply != "" {
+			topic = fromReply
+		}
+	}
+	if topic == "" {
+		return ""
+	}
+	return truncateTopic(capitalize(topic))
+}
+
+// topicFromText strips leading fillers from text and reduces the rest to its
+// first sentence. Fillers go first so "Sure! I'll fix X." yields "fix X".
+func topicFromText(text string, fillers []string) string {
+	s := strings.Join(strings.Fields(text), " ")
+	for {
+		trimmed := strings.TrimLeft(s, " ,.!:;-")
+		for _, f := range fillers {
+			if len(trimmed) > len(f) && strings.EqualFold(trimmed[:len(f)], f) {
+				if r, _ := utf8.DecodeRuneInString(trimmed[len(f):]); !unicode.IsLetter(r) {
+					trimmed = trimmed[len(f):]
+					break
+				}
+			}
+		}
+		if trimmed == s {
+			break
+		}
+		s = trimmed
+	}
+	return strings.Trim(firstSentence(s), " \t\"'`.,!?:;")
+}
+
+// firstSentence returns text up to the first sentence terminator followed by
+// a space, so dotted names like "main.go" stay intact.
+func firstSentence(text string) string {
+	for i, r := range text {
+		if r == '.' || r == '?' || r == '!' {
+			next := i + 1
+			if next >= len(text) || text[next] == ' ' {
+				return text[:i]
+			}
+		}
+	}
+	return text
+}
+
+func capitalize(s string) string {
+	r, size := utf8.DecodeRuneInString(s)
+	return string(unicode.ToUpper(r)) + s[size:]
+}
+
+func truncateTopic(s string) string {
+	runes := []rune(s)
+	if len(runes) <= maxAutoTopicRunes {
+		return s
+	}
+	cut := string(runes[:maxAutoTopicRunes-3])
+	if i := strings.LastIndexByte(cut, ' '); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, " ,.;:") + "..."
+}
@@ -0,0 +1,83 @@
+package workload
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeriveAutoTopic(t *testing.T) {
+	tests := []struct {
+		name   string
+		prompt string
+		reply  string
+		want   string
+	}{
+		{
+			name:   "uses first sentence of prompt",
+			prompt: "Refactor the session manager to use a worker pool. It is too slow.",
+			want:   "Refactor the session manager to use a worker pool",
+		},
+		{
+			name:   "strips leading fillers",
+			prompt: "Hey, can you please add retries to the webhook client?",
+			want:   "Add retries to the webhook client",
+		},
+		{
+			name:   "keeps dotted file names",
+			prompt: "fix the flaky test in main_test.go",
+			want:   "Fix the flaky test in main_test.go",
+		},
+		{
+			name:   "falls back to reply for terse prompt",
+			prompt: "fix it",
+			reply:  "Sure! I'll fix the nil pointer dereference in the config loader. First, let me look.",
+			want:   "Fix the nil pointer dereference in the config loader",
+		},
+		{
+			name:   "keeps terse prompt when reply is empty",
+			prompt: "fix it",
+			want:   "Fix it",
+		},
+		{
+			name: "empty exchange",
+			want: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, deriveAutoTopic(tt.prompt, tt.reply))
+		})
+	}
+}
+
+func TestDeriveAutoTopic_TruncatesAtWordBoundary(t *testing.T) {
+	prompt := "Investigate why the nightly integration build keeps timing out on the arm64 runners and propose a fix"
+	got := deriveAutoTopic(prompt, "")
+
+	assert.True(t, strings.HasSuffix(got, "..."))
+	assert.LessOrEqual(t, utf8.RuneCountInString(got), maxAutoTopicRunes)
+	assert.True(t, strings.HasPrefix(prompt, strings.TrimSuffix(got, "...")+" "), "cut falls on a word boundary")
+}
+
+func TestAutoTopic_TakeOnce(t *testing.T) {
+	a := &autoTopic{}
+	a.noteReply("ignored before a prompt")
+	_, _, ok := a.take()
+	assert.False(t, ok, "nothing to take before a prompt")
+
+	a.notePrompt("first prompt")
+	a.notePrompt("second prompt")
+	a.noteReply("first ")
+	a.noteReply("reply")
+
+	prompt, reply, ok := a.take()
+	assert.True(t, ok)
+	assert.Equal(t, "first prompt", prompt)
+	assert.Equal(t, "first reply", reply)
+
+	_, _, ok = a.take()
+	assert.False(t, ok, "take fires once")
+}
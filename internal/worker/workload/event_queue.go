@@ -1,28 +1,60 @@
 package workload
 
 import (
+	"log/slog"
 	"sync"
+	"time"
 
 	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
+	"github.com/sebastianm/flowgentic/internal/worker/metrics"
 )
 
-// sessionEventQueue holds events for a single session.
+// EventRetention bounds the un-acknowledged events kept per session, so a
+// control plane that stops acknowledging cannot grow the queue without
+// limit. A zero field disables that limit.
+type EventRetention struct {
+	MaxEvents int           // oldest events beyond this count are dropped
+	MaxAge    time.Duration // events queued for longer are dropped
+}
+
+// DefaultEventRetention is used for limits the worker config leaves unset.
+var DefaultEventRetention = EventRetention{MaxEvents: 10000, MaxAge: 24 * time.Hour}
+
+// queuedEvent is an event and the time it was queued.
+type queuedEvent struct {
+	event    *workerv1.SessionEvent
+	queuedAt time.Time
+}
+
+// sessionEventQueue holds events for a single session. When events were
+// pruned, the first entry is an EventsPruned marker.
 type sessionEventQueue struct {
 	mu     sync.RWMutex
-	events []*workerv1.SessionEvent
+	events []queuedEvent
 }
 
 // EventQueue is a per-session event queue that buffers SessionEvents
 // until they are acknowledged by the control plane.
 type EventQueue struct {
+	log       *slog.Logger
+	retention EventRetention
+	now       func() time.Time
+	// metrics receives the change of the total depth on every append, ack
+	// and removal, as the EventQueueDepth gauge.
+	metrics metrics.Metrics
+
 	mu       sync.RWMutex
 	sessions map[string]*sessionEventQueue
 }
 
-// NewEventQueue creates a new EventQueue.
-func NewEventQueue() *EventQueue {
+// NewEventQueue creates a new EventQueue that prunes events beyond retention.
+func NewEventQueue(log *slog.Logger, retention EventRetention) *EventQueue {
 	return &EventQueue{
-		sessions: make(map[string]*sessionEventQueue),
+		log:       log,
+		retention: retention,
+		now:       time.Now,
+		metrics:   metrics.Nop(),
+		sessions:  make(map[string]*sessionEventQueue),
 	}
 }
 
@@ -38,17 +70,81 @@ func (q *EventQueue) getOrCreate(sessionID string) *sessionEventQueue {
 }
 
 // Append adds an event to the given session's queue, creating the session
-// queue if it does not already exist.
+// queue if it does not already exist. Events beyond the retention limits are
+// pruned here, since appending is the only way the queue grows.
 func (q *EventQueue) Append(sessionID string, event *workerv1.SessionEvent) {
 	q.mu.Lock()
 	sq := q.getOrCreate(sessionID)
 	q.mu.Unlock()
 
+	now := q.now()
 	sq.mu.Lock()
-	sq.events = append(sq.events, event)
+	before := len(sq.events)
+	sq.events = append(sq.events, queuedEvent{event: event, queuedAt: now})
+	q.pruneLocked(sessionID, sq, now)
+	q.depthChanged(before, len(sq.events))
 	sq.mu.Unlock()
 }
 
+// depthChanged reports that a session queue went from before to after
+// events.
+func (q *EventQueue) depthChanged(before, after int) {
+	if before != after {
+		q.metrics.Gauge(metrics.EventQueueDepth, float64(after-before), nil)
+	}
+}
+
+// pruneLocked drops the oldest events beyond the retention limits and
+// replaces them with a single EventsPruned marker carrying the sequence of
+// the newest dropped event. A client resuming from before the marker learns
+// that events are missing. Must be called with sq.mu held.
+func (q *EventQueue) pruneLocked(sessionID string, sq *sessionEventQueue, now time.Time) {
+	start := 0
+	var alreadyPruned int64
+	if len(sq.events) > 0 {
+		if m := sq.events[0].event.GetEventsPruned(); m != nil {
+			start = 1
+			alreadyPruned = m.GetCount()
+		}
+	}
+
+	excess := 0
+	if q.retention.MaxEvents > 0 {
+		excess = len(sq.events) - start - q.retention.MaxEvents
+	}
+	cut := start
+	for cut < len(sq.events) {
+		tooMany := cut-start < excess
+		tooOld := q.retention.MaxAge > 0 && now.Sub(sq.events[cut].queuedAt) > q.retention.MaxAge
+		if !tooMany && !tooOld {
+			break
+		}
+		cut++
+	}
+	if cut == start {
+		return
+	}
+
+	newest := sq.events[cut-1]
+	marker := queuedEvent{
+		event: &workerv1.SessionEvent{
+			SessionId: sessionID,
+			Sequence:  newest.event.GetSequence(),
+			Timestamp: newest.event.GetTimestamp(),
+			Payload: &workerv1.SessionEvent_EventsPruned{
+				EventsPruned: &workerv1.EventsPruned{Count: alreadyPruned + int64(cut-start)},
+			},
+		},
+		queuedAt: newest.queuedAt,
+	}
+	sq.events = append([]queuedEvent{marker}, sq.events[cut:]...)
+	q.log.Warn("pruned un-acknowledged session events",
+		"session_id", sessionID,
+		"dropped", cut-start,
+		"through_sequence", newest.event.GetSequence(),
+	)
+}
+
 // Pending returns all events for the given session whose Sequence is
 // strictly greater than afterSeq.
 func (q *EventQueue) Pending(sessionID string, afterSeq int64) []*workerv1.SessionEvent {
@@ -64,8 +160,8 @@ func (q *EventQueue) Pending(sessionID string, afterSeq int64) []*workerv1.Sessi
 
 	var result []*workerv1.SessionEvent
 	for _, e := range sq.events {
-		if e.GetSequence() > afterSeq {
-			result = append(result, e)
+		if e.event.GetSequence() > afterSeq {
+			result = append(result, e.event)
 		}
 	}
 	return result
@@ -84,20 +180,31 @@ func (q *EventQueue) Ack(sessionID string, sequence int64) {
 	sq.mu.Lock()
 	defer sq.mu.Unlock()
 
+	before := len(sq.events)
 	kept := sq.events[:0]
 	for _, e := range sq.events {
-		if e.GetSequence() > sequence {
+		if e.event.GetSequence() > sequence {
 			kept = append(kept, e)
 		}
 	}
 	sq.events = kept
+	q.depthChanged(before, len(kept))
 }
 
 // Remove drops the entire event queue for the given session.
 func (q *EventQueue) Remove(sessionID string) {
 	q.mu.Lock()
+	sq, ok := q.sessions[sessionID]
 	delete(q.sessions, sessionID)
 	q.mu.Unlock()
+	if !ok {
+		return
+	}
+
+	sq.mu.Lock()
+	q.depthChanged(len(sq.events), 0)
+	sq.events = nil
+	sq.mu.Unlock()
 }
 
 // AllPending returns a snapshot of all pending events across every session.
@@ -111,10 +218,41 @@ func (q *EventQueue) AllPending() map[string][]*workerv1.SessionEvent {
 		sq.mu.RLock()
 		if len(sq.events) > 0 {
 			copied := make([]*workerv1.SessionEvent, len(sq.events))
-			copy(copied, sq.events)
+			for i, e := range sq.events {
+				copied[i] = e.event
+			}
 			result[id] = copied
 		}
 		sq.mu.RUnlock()
 	}
 	return result
 }
+
+// Depth returns the number of un-acknowledged events queued for the given
+// session, including an EventsPruned marker.
+func (q *EventQueue) Depth(sessionID string) int {
+	q.mu.RLock()
+	sq, ok := q.sessions[sessionID]
+	q.mu.RUnlock()
+	if !ok {
+		return 0
+	}
+
+	sq.mu.RLock()
+	defer sq.mu.RUnlock()
+	return len(sq.events)
+}
+
+// TotalDepth returns the number of un-acknowledged events across every session.
+func (q *EventQueue) TotalDepth() int {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+
+	total := 0
+	for _, sq := range q.sessions {
+		sq.mu.RLock()
+		total += len(sq.events)
+		sq.mu.RUnlock()
+	}
+	return total
+}
@@ -0,0 +1,100 @@
+package workload
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
+	"github.com/sebastianm/flowgentic/internal/worker/metrics"
+)
+
+func appendEvents(q *EventQueue, sessionID string, from, to int64) {
+	for seq := from; seq <= to; seq++ {
+		q.Append(sessionID, &workerv1.SessionEvent{SessionId: sessionID, Sequence: seq})
+	}
+}
+
+func sequences(events []*workerv1.SessionEvent) []int64 {
+	out := make([]int64, len(events))
+	for i, e := range events {
+		out[i] = e.GetSequence()
+	}
+	return out
+}
+
+func TestEventQueue_PrunesBeyondMaxEvents(t *testing.T) {
+	q := NewEventQueue(testLogger(), EventRetention{MaxEvents: 3})
+
+	appendEvents(q, "sess-1", 1, 5)
+
+	pending := q.Pending("sess-1", 0)
+	assert.Equal(t, []int64{2, 3, 4, 5}, sequences(pending), "marker replaces events 1-2")
+	require.NotNil(t, pending[0].GetEventsPruned())
+	assert.Equal(t, int64(2), pending[0].GetEventsPruned().GetCount())
+
+	// Further pruning folds into the same marker.
+	appendEvents(q, "sess-1", 6, 7)
+	pending = q.Pending("sess-1", 0)
+	assert.Equal(t, []int64{4, 5, 6, 7}, sequences(pending))
+	assert.Equal(t, int64(4), pending[0].GetEventsPruned().GetCount())
+
+	// A client that already saw the dropped events does not get the marker.
+	assert.Equal(t, []int64{5, 6, 7}, sequences(q.Pending("sess-1", 4)))
+	assert.Nil(t, q.Pending("sess-1", 4)[0].GetEventsPruned())
+
+	// Acknowledging past the marker drops it.
+	q.Ack("sess-1", 5)
+	assert.Equal(t, []int64{6, 7}, sequences(q.AllPending()["sess-1"]))
+}
+
+func TestEventQueue_PrunesBeyondMaxAge(t *testing.T) {
+	q := NewEventQueue(testLogger(), EventRetention{MaxAge: time.Minute})
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	q.now = func() time.Time { return now }
+
+	appendEvents(q, "sess-1", 1, 2)
+	now = now.Add(2 * time.Minute)
+	appendEvents(q, "sess-1", 3, 3)
+
+	pending := q.Pending("sess-1", 0)
+	assert.Equal(t, []int64{2, 3}, sequences(pending))
+	assert.Equal(t, int64(2), pending[0].GetEventsPruned().GetCount())
+}
+
+func TestEventQueue_ZeroRetentionKeepsEverything(t *testing.T) {
+	q := NewEventQueue(testLogger(), EventRetention{})
+
+	appendEvents(q, "sess-1", 1, 100)
+
+	pending := q.Pending("sess-1", 0)
+	assert.Len(t, pending, 100)
+	assert.Nil(t, pending[0].GetEventsPruned())
+}
+
+func TestEventQueue_Depth(t *testing.T) {
+	q := NewEventQueue(testLogger(), EventRetention{})
+	mtr := newFakeMetrics()
+	q.metrics = mtr
+	assert.Equal(t, 0, q.Depth("sess-1"))
+	assert.Equal(t, 0, q.TotalDepth())
+
+	appendEvents(q, "sess-1", 1, 3)
+	appendEvents(q, "sess-2", 1, 2)
+	assert.Equal(t, 3, q.Depth("sess-1"))
+	assert.Equal(t, 2, q.Depth("sess-2"))
+	assert.Equal(t, 5, q.TotalDepth())
+	assert.Equal(t, 5.0, mtr.value(metrics.EventQueueDepth, nil))
+
+	q.Ack("sess-1", 2)
+	assert.Equal(t, 1, q.Depth("sess-1"))
+	assert.Equal(t, 3, q.TotalDepth())
+	assert.Equal(t, 3.0, mtr.value(metrics.EventQueueDepth, nil))
+
+	q.Remove("sess-2")
+	assert.Equal(t, 0, q.Depth("sess-2"))
+	assert.Equal(t, 1, q.TotalDepth())
+	assert.Equal(t, 1.0, mtr.value(metrics.EventQueueDepth, nil))
+}
@@ -5,15 +5,21 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
 	acp "github.com/coder/acp-go-sdk"
 	"github.com/sebastianm/flowgentic/internal/worker/driver"
 	v2 "github.com/sebastianm/flowgentic/internal/worker/driver/v2"
+	"github.com/sebastianm/flowgentic/internal/worker/metrics"
 )
 
-var _ v2.Session = (*fakeSession)(nil)
+var (
+	_ v2.Session      = (*fakeSession)(nil)
+	_ metrics.Metrics = (*fakeMetrics)(nil)
+)
 
 func testLogger() *slog.Logger {
 	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
@@ -27,7 +33,9 @@ type fakeDriver struct {
 	launchErr  error
 	launchSess *fakeSession
 	lastOpts   v2.LaunchOpts
-	mu         sync.Mutex
+	// launchStatuses are sent on LaunchOpts.StatusCh from Launch.
+	launchStatuses []v2.SessionStatus
+	mu             sync.Mutex
 }
 
 func newFakeDriver(id string, caps ...driver.Capability) *fakeDriver {
@@ -60,12 +68,18 @@ func (d *fakeDriver) Launch(_ context.Context, opts v2.LaunchOpts, onEvent v2.Ev
 	if sess == nil {
 		sess = newFakeSession(opts.ResumeSessionID, d.id)
 	}
+	sess.onEvent = onEvent
+	sess.statusCh = opts.StatusCh
+	sess.onTurnEnded = opts.OnTurnEnded
 	if onEvent != nil {
 		onEvent(acp.SessionNotification{
 			SessionId: acp.SessionId(opts.ResumeSessionID),
 			Update:    acp.UpdateAgentMessageText("session started"),
 		})
 	}
+	for _, st := range d.launchStatuses {
+		opts.StatusCh <- st
+	}
 	return sess, nil
 }
 
@@ -75,6 +89,44 @@ type fakeSession struct {
 	stopErr error
 	done    chan struct{}
 	mu      sync.Mutex
+
+	// teardown, if set, delays done after Stop until it is closed.
+	teardown chan struct{}
+
+	// promptReply, if set, scripts each Prompt call as a turn: running
+	// status, one agent message chunk with this text, then idle.
+	promptReply string
+	onEvent     v2.EventCallback
+	statusCh    chan<- v2.SessionStatus
+	onTurnEnded v2.TurnCallback
+
+	// holdPrompt, if set, makes Prompt wait until Cancel closes it, ending
+	// the turn as cancelled, or until its context ends.
+	holdPrompt chan struct{}
+
+	// promptErr, if set, fails every Prompt call.
+	promptErr error
+	// stopReason is the stop reason of turns not ended by holdPrompt.
+	stopReason acp.StopReason
+	// turnStats, if set, is reported in the response of those turns.
+	turnStats *v2.TurnStats
+
+	// liveMCP makes AddMCPServer record the server and send an
+	// available_commands_update, like an adapter that adds servers live.
+	liveMCP    bool
+	mcpServers []acp.McpServer
+
+	// allowedTools records the last SetAllowedTools call.
+	allowedTools []string
+	// hostCommands records the last SetHostCommands call.
+	hostCommands []acp.AvailableCommand
+
+	// prompts records the content blocks of each Prompt call.
+	prompts [][]acp.ContentBlock
+	// promptModels and promptModes record the model and mode each Prompt
+	// call ran with.
+	promptModels []string
+	promptModes  []string
 }
 
 func newFakeSession(id, agentID string) *fakeSession {
@@ -98,24 +150,89 @@ func (s *fakeSession) Stop(_ context.Context) error {
 		return s.stopErr
 	}
 	s.info.Status = v2.SessionStatusStopped
+	if s.teardown != nil {
+		go func() {
+			<-s.teardown
+			s.closeDone()
+		}()
+		return nil
+	}
+	s.closeDone()
+	return nil
+}
+
+// SoftStop stops the session like Stop; teardown stands in for the turn
+// it lets finish.
+func (s *fakeSession) SoftStop(ctx context.Context) error { return s.Stop(ctx) }
+
+func (s *fakeSession) closeDone() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
 	select {
 	case <-s.done:
 	default:
 		close(s.done)
 	}
-	return nil
 }
 
-func (s *fakeSession) Wait(_ context.Context) error {
-	<-s.done
-	return nil
+func (s *fakeSession) Wait(ctx context.Context) error {
+	select {
+	case <-s.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-func (s *fakeSession) Prompt(_ context.Context, _ []acp.ContentBlock) (*acp.PromptResponse, error) {
-	return &acp.PromptResponse{}, nil
+func (s *fakeSession) Prompt(ctx context.Context, blocks []acp.ContentBlock) (*acp.PromptResponse, error) {
+	s.mu.Lock()
+	s.prompts = append(s.prompts, blocks)
+	s.promptModels = append(s.promptModels, s.info.CurrentModel)
+	s.promptModes = append(s.promptModes, s.info.CurrentMode)
+	hold, promptErr := s.holdPrompt, s.promptErr
+	s.mu.Unlock()
+	if promptErr != nil {
+		return nil, promptErr
+	}
+	if hold != nil {
+		select {
+		case <-hold:
+			s.turnEnded(v2.TurnEnd{StopReason: acp.StopReasonCancelled})
+			return &acp.PromptResponse{StopReason: acp.StopReasonCancelled}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	if s.promptReply != "" {
+		s.statusCh <- v2.SessionStatusRunning
+		s.onEvent(acp.SessionNotification{
+			SessionId: acp.SessionId(s.info.ID),
+			Update:    acp.UpdateAgentMessageText(s.promptReply),
+		})
+		s.statusCh <- v2.SessionStatusIdle
+	}
+	resp := &acp.PromptResponse{StopReason: s.stopReason}
+	if s.turnStats != nil {
+		v2.WithTurnStats(resp, *s.turnStats)
+	}
+	s.turnEnded(v2.TurnEnd{StopReason: s.stopReason, Stats: s.turnStats})
+	return resp, nil
+}
+
+// turnEnded reports the end of a turn like the driver does.
+func (s *fakeSession) turnEnded(te v2.TurnEnd) {
+	if s.onTurnEnded != nil {
+		s.onTurnEnded(te)
+	}
 }
 
 func (s *fakeSession) Cancel(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.holdPrompt != nil {
+		close(s.holdPrompt)
+		s.holdPrompt = nil
+	}
 	return nil
 }
 
@@ -123,7 +240,43 @@ func (s *fakeSession) RespondToPermission(_ context.Context, _ string, _ bool, _
 	return nil
 }
 
-func (s *fakeSession) SetSessionMode(_ context.Context, _ driver.SessionMode) error {
+func (s *fakeSession) SetSessionMode(_ context.Context, mode driver.SessionMode) error {
+	s.info.CurrentMode = string(mode)
+	return nil
+}
+
+func (s *fakeSession) SetModel(_ context.Context, model string) error {
+	s.info.CurrentModel = model
+	return nil
+}
+
+func (s *fakeSession) AddMCPServer(_ context.Context, server acp.McpServer) error {
+	if !s.liveMCP {
+		return v2.ErrAddMCPServerUnsupported
+	}
+	s.mu.Lock()
+	s.mcpServers = append(s.mcpServers, server)
+	s.mu.Unlock()
+	s.onEvent(acp.SessionNotification{
+		SessionId: acp.SessionId(s.info.ID),
+		Update: acp.SessionUpdate{AvailableCommandsUpdate: &acp.SessionAvailableCommandsUpdate{
+			AvailableCommands: []acp.AvailableCommand{{Name: server.Stdio.Name + ":query"}},
+		}},
+	})
+	return nil
+}
+
+func (s *fakeSession) SetAllowedTools(_ context.Context, tools []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.allowedTools = tools
+	return nil
+}
+
+func (s *fakeSession) SetHostCommands(_ context.Context, cmds []acp.AvailableCommand) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.hostCommands = cmds
 	return nil
 }
 
@@ -142,3 +295,58 @@ func (d *errDriver) DiscoverModels(_ context.Context, _ string) (v2.ModelInvento
 func (d *errDriver) Launch(_ context.Context, _ v2.LaunchOpts, _ v2.EventCallback) (v2.Session, error) {
 	return nil, fmt.Errorf("launch failed")
 }
+
+// fakeMetrics records counter and gauge totals keyed by name and labels.
+type fakeMetrics struct {
+	mu     sync.Mutex
+	values map[string]float64
+	obs    map[string]int
+}
+
+func newFakeMetrics() *fakeMetrics {
+	return &fakeMetrics{values: make(map[string]float64), obs: make(map[string]int)}
+}
+
+func metricKey(name string, labels metrics.Labels) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range keys {
+		b.WriteString("," + k + "=" + labels[k])
+	}
+	return b.String()
+}
+
+func (f *fakeMetrics) Counter(name string, delta float64, labels metrics.Labels) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[metricKey(name, labels)] += delta
+}
+
+func (f *fakeMetrics) Gauge(name string, delta float64, labels metrics.Labels) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values[metricKey(name, labels)] += delta
+}
+
+func (f *fakeMetrics) Histogram(name string, _ float64, labels metrics.Labels) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.obs[metricKey(name, labels)]++
+}
+
+func (f *fakeMetrics) value(name string, labels metrics.Labels) float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.values[metricKey(name, labels)]
+}
+
+func (f *fakeMetrics) observations(name string, labels metrics.Labels) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.obs[metricKey(name, labels)]
+}
@@ -0,0 +1,45 @@
+package workload
+
+import (
+	"fmt"
+	"strings"
+)
+
+// LabelSelector is a set of equality requirements on session labels. A
+// session matches when every key is present with the given value. An empty
+// selector matches every session.
+type LabelSelector map[string]string
+
+// ParseLabelSelector parses a comma-separated list of key=value pairs, e.g.
+// "project=foo,branch=main". Whitespace around keys and values is ignored
+// and an empty string yields an empty selector.
+func ParseLabelSelector(s string) (LabelSelector, error) {
+	sel := LabelSelector{}
+	if strings.TrimSpace(s) == "" {
+		return sel, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(part, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid label selector %q: expected key=value", part)
+		}
+		value = strings.TrimSpace(value)
+		if prev, dup := sel[key]; dup && prev != value {
+			return nil, fmt.Errorf("invalid label selector: conflicting values for %q", key)
+		}
+		sel[key] = value
+	}
+	return sel, nil
+}
+
+// Matches reports whether labels satisfy every requirement in the selector.
+func (s LabelSelector) Matches(labels map[string]string) bool {
+	for k, v := range s {
+		got, ok := labels[k]
+		if !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
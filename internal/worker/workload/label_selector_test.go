@@ -0,0 +1,52 @@
+package workload
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLabelSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    LabelSelector
+		wantErr bool
+	}{
+		{name: "empty", input: "", want: LabelSelector{}},
+		{name: "single", input: "project=foo", want: LabelSelector{"project": "foo"}},
+		{name: "multiple", input: "project=foo,branch=main", want: LabelSelector{"project": "foo", "branch": "main"}},
+		{name: "whitespace", input: " project = foo , branch=main ", want: LabelSelector{"project": "foo", "branch": "main"}},
+		{name: "empty value", input: "branch=", want: LabelSelector{"branch": ""}},
+		{name: "missing equals", input: "project", wantErr: true},
+		{name: "missing key", input: "=foo", wantErr: true},
+		{name: "trailing comma", input: "project=foo,", wantErr: true},
+		{name: "conflicting keys", input: "project=foo,project=bar", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sel, err := ParseLabelSelector(tt.input)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sel)
+		})
+	}
+}
+
+func TestLabelSelector_Matches(t *testing.T) {
+	labels := map[string]string{"project": "foo", "branch": "main", "user": "ana"}
+
+	assert.True(t, LabelSelector{}.Matches(labels))
+	assert.True(t, LabelSelector(nil).Matches(nil))
+	assert.True(t, LabelSelector{"project": "foo"}.Matches(labels))
+	assert.True(t, LabelSelector{"project": "foo", "branch": "main"}.Matches(labels))
+
+	assert.False(t, LabelSelector{"project": "bar"}.Matches(labels))
+	assert.False(t, LabelSelector{"team": "core"}.Matches(labels))
+	assert.False(t, LabelSelector{"branch": ""}.Matches(labels), "empty value requires the key to be set to empty")
+	assert.False(t, LabelSelector{"project": "foo"}.Matches(nil))
+}
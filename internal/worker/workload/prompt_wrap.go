@@ -0,0 +1,96 @@
+package workload
+
+import (
+	"strings"
+
+	acp "github.com/coder/acp-go-sdk"
+)
+
+// PromptWrap holds standing instructions the worker wraps around every user
+// prompt before it reaches the agent. The wrapping is invisible to clients:
+// emitted user_message events carry the prompt as typed.
+type PromptWrap struct {
+	Prefix string
+	Suffix string
+}
+
+// PromptWraps selects the PromptWrap for each agent. An entry in Agents
+// replaces Default entirely, so an agent can opt out with an empty entry.
+type PromptWraps struct {
+	Default PromptWrap
+	Agents  map[string]PromptWrap
+}
+
+// For returns the wrap that applies to agentID.
+func (p PromptWraps) For(agentID string) PromptWrap {
+	if w, ok := p.Agents[agentID]; ok {
+		return w
+	}
+	return p.Default
+}
+
+func (w PromptWrap) empty() bool {
+	return w.Prefix == "" && w.Suffix == ""
+}
+
+// isSlashCommand reports whether text is a slash command, which agents only
+// recognize at the very start of the prompt.
+func isSlashCommand(text string) bool {
+	return strings.HasPrefix(strings.TrimLeft(text, " \t\r\n"), "/")
+}
+
+// wrapText applies w to a plain-text prompt. Empty prompts and slash
+// commands pass through unchanged.
+func (w PromptWrap) wrapText(text string) string {
+	if w.empty() || strings.TrimSpace(text) == "" || isSlashCommand(text) {
+		return text
+	}
+	if w.Prefix != "" {
+		text = w.Prefix + "\n\n" + text
+	}
+	if w.Suffix != "" {
+		text = text + "\n\n" + w.Suffix
+	}
+	return text
+}
+
+// wrapBlocks applies w to prompt content blocks. Adapters concatenate text
+// blocks verbatim, so the prefix is merged into the first text block and the
+// suffix into the last one. Prompts whose first text block is a slash command
+// pass through unchanged. The input slice is not modified.
+func (w PromptWrap) wrapBlocks(blocks []acp.ContentBlock) []acp.ContentBlock {
+	if w.empty() {
+		return blocks
+	}
+	first, last := -1, -1
+	for i, b := range blocks {
+		if b.Text == nil {
+			continue
+		}
+		if first < 0 {
+			first = i
+		}
+		last = i
+	}
+	if first < 0 || isSlashCommand(blocks[first].Text.Text) {
+		return blocks
+	}
+
+	out := make([]acp.ContentBlock, len(blocks))
+	copy(out, blocks)
+	if w.Prefix != "" {
+		out[first] = withText(out[first], w.Prefix+"\n\n"+out[first].Text.Text)
+	}
+	if w.Suffix != "" {
+		out[last] = withText(out[last], out[last].Text.Text+"\n\n"+w.Suffix)
+	}
+	return out
+}
+
+// withText returns a copy of the text block b with its text replaced.
+func withText(b acp.ContentBlock, text string) acp.ContentBlock {
+	tb := *b.Text
+	tb.Text = text
+	b.Text = &tb
+	return b
+}
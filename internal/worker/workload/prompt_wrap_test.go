@@ -0,0 +1,68 @@
+package workload
+
+import (
+	"testing"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPromptWrap_WrapText(t *testing.T) {
+	w := PromptWrap{Prefix: "Follow the style guide.", Suffix: "Always run tests."}
+
+	tests := []struct {
+		name string
+		wrap PromptWrap
+		in   string
+		want string
+	}{
+		{"prefix and suffix", w, "Fix the login bug", "Follow the style guide.\n\nFix the login bug\n\nAlways run tests."},
+		{"prefix only", PromptWrap{Prefix: "P"}, "hi", "P\n\nhi"},
+		{"suffix only", PromptWrap{Suffix: "S"}, "hi", "hi\n\nS"},
+		{"no wrap", PromptWrap{}, "hi", "hi"},
+		{"slash command", w, "/review", "/review"},
+		{"slash command with leading space", w, "  /compact keep todos", "  /compact keep todos"},
+		{"empty prompt", w, "", ""},
+		{"slash later in text", w, "see a/b", "Follow the style guide.\n\nsee a/b\n\nAlways run tests."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.wrap.wrapText(tt.in))
+		})
+	}
+}
+
+func TestPromptWrap_WrapBlocks(t *testing.T) {
+	w := PromptWrap{Prefix: "P", Suffix: "S"}
+	image := acp.ImageBlock("aGk=", "image/png")
+
+	in := []acp.ContentBlock{image, acp.TextBlock("first"), acp.TextBlock("second")}
+	out := w.wrapBlocks(in)
+	assert.Equal(t, "P\n\nfirst", out[1].Text.Text)
+	assert.Equal(t, "second\n\nS", out[2].Text.Text)
+	assert.Equal(t, image, out[0])
+	assert.Equal(t, "first", in[1].Text.Text, "input is not modified")
+	assert.Equal(t, "second", in[2].Text.Text, "input is not modified")
+
+	single := w.wrapBlocks([]acp.ContentBlock{acp.TextBlock("only")})
+	assert.Equal(t, "P\n\nonly\n\nS", single[0].Text.Text)
+
+	slash := []acp.ContentBlock{acp.TextBlock("/init"), acp.TextBlock("extra")}
+	assert.Equal(t, slash, w.wrapBlocks(slash))
+
+	noText := []acp.ContentBlock{image}
+	assert.Equal(t, noText, w.wrapBlocks(noText))
+}
+
+func TestPromptWraps_For(t *testing.T) {
+	p := PromptWraps{
+		Default: PromptWrap{Suffix: "default"},
+		Agents: map[string]PromptWrap{
+			"codex":       {Prefix: "codex"},
+			"claude-code": {},
+		},
+	}
+	assert.Equal(t, PromptWrap{Suffix: "default"}, p.For("opencode"))
+	assert.Equal(t, PromptWrap{Prefix: "codex"}, p.For("codex"))
+	assert.Equal(t, PromptWrap{}, p.For("claude-code"), "empty override disables wrapping")
+}
@@ -2,18 +2,25 @@ package workload
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	acp "github.com/coder/acp-go-sdk"
+	"github.com/google/uuid"
+	"github.com/sebastianm/flowgentic/internal/logutil"
 	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
 	"github.com/sebastianm/flowgentic/internal/worker/driver"
 	v2 "github.com/sebastianm/flowgentic/internal/worker/driver/v2"
+	"github.com/sebastianm/flowgentic/internal/worker/metrics"
+	"github.com/sebastianm/flowgentic/internal/worker/procutil"
 )
 
 // StateEventType describes what kind of state change occurred.
@@ -32,6 +39,9 @@ type StateEvent struct {
 	SessionID string
 	// Snapshot is set for Update events, nil for Removed.
 	Snapshot *SessionSnapshot
+	// FinalStatus is set for Removed events: errored if the session failed,
+	// stopped otherwise.
+	FinalStatus v2.SessionStatus
 }
 
 // SessionEventUpdate carries a raw session event for subscribers.
@@ -42,42 +52,112 @@ type SessionEventUpdate struct {
 
 // SessionManager manages agent drivers and sessions.
 type SessionManager struct {
-	log         *slog.Logger
-	drivers     map[string]v2.Driver
-	ctlURL      string
-	ctlSecret   string
+	log       *slog.Logger
+	metrics   metrics.Metrics
+	drivers   map[string]v2.Driver
+	ctlURL    string
+	ctlSecret string
+	// promptWraps is applied to every user prompt; set once by Start.
+	promptWraps PromptWraps
+	// toolPolicies maps agent ID to its tool restrictions; set once by Start.
+	toolPolicies map[string]ToolPolicy
+	// resourceLimits maps agent ID to the limits its subprocess runs under
+	// when the launch sets none; set once by Start.
+	resourceLimits map[string]procutil.ResourceLimits
+	// mcpServers are added to every launch unless it names a server of the
+	// same name; set once by Start.
+	mcpServers []acp.McpServer
+	// maxRawOutput bounds the raw output of each tool call update when it
+	// becomes an event, so the broadcast and the event queue never hold
+	// more; zero uses defaultMaxRawOutput and a negative value disables the
+	// limit. Set once by Start.
+	maxRawOutput int
+	// agentctlEnv configures agentctl for every launch, such as its debug
+	// log; a launch's own env vars win. Set once by Start.
+	agentctlEnv map[string]string
+
+	// instanceID identifies this run of the worker to the control plane,
+	// which resets its event dedupe when it changes.
+	instanceID string
+
 	mu          sync.RWMutex
 	sessions    map[string]*sessionEntry
 	subscribers map[chan StateEvent]struct{}
 
 	eventQueue       *EventQueue
 	eventSubscribers map[chan SessionEventUpdate]struct{}
+	observers        map[*notificationObserver]struct{}
+
+	// closed is set by Shutdown; done is closed once all sessions are
+	// stopped so that background goroutines tracked by wg can exit.
+	closed bool
+	done   chan struct{}
+	wg     sync.WaitGroup
 }
 
+// maxParallelStops bounds how many sessions Shutdown stops concurrently.
+const maxParallelStops = 8
+
 type sessionEntry struct {
 	session v2.Session
 	driver  v2.Driver
 	topic   string
+	labels  map[string]string
 	nextSeq atomic.Int64
+
+	// modelAnnounced is set once the effective model has been emitted.
+	modelAnnounced atomic.Bool
+
+	// autoTopic is non-nil when the session opted into LaunchOpts.AutoTopic.
+	autoTopic *autoTopic
+
+	// ephemeral is LaunchOpts.Ephemeral; see publishEvent.
+	ephemeral bool
+
+	// messageText and thoughtText hold back multibyte sequences split
+	// across the agent's streamed chunks.
+	messageText utf8Stream
+	thoughtText utf8Stream
+
+	// cancelReason is the workerv1.CancelReason Cancel was last called
+	// with. The next TurnEnded event reports it if its turn was cancelled,
+	// and clears it either way.
+	cancelReason atomic.Int32
+
+	// softStopping is set by SoftStop; the session takes no more prompts.
+	softStopping atomic.Bool
 }
 
 // NewSessionManager creates a new SessionManager with the given drivers.
-func NewSessionManager(log *slog.Logger, ctlURL, ctlSecret string, drivers ...v2.Driver) *SessionManager {
+// A nil mtr disables metrics.
+func NewSessionManager(log *slog.Logger, ctlURL, ctlSecret string, mtr metrics.Metrics, drivers ...v2.Driver) *SessionManager {
 	dm := make(map[string]v2.Driver, len(drivers))
 	for _, d := range drivers {
 		dm[d.Agent()] = d
 	}
 
-	return &SessionManager{
-		log:              log,
+	m := &SessionManager{
+		log:              log.With("component", logutil.ComponentSessionManager),
+		metrics:          metrics.OrNop(mtr),
 		drivers:          dm,
 		ctlURL:           ctlURL,
 		ctlSecret:        ctlSecret,
 		sessions:         make(map[string]*sessionEntry),
 		subscribers:      make(map[chan StateEvent]struct{}),
-		eventQueue:       NewEventQueue(),
+		eventQueue:       NewEventQueue(log, EventRetention{}),
+		instanceID:       uuid.NewString(),
 		eventSubscribers: make(map[chan SessionEventUpdate]struct{}),
+		observers:        make(map[*notificationObserver]struct{}),
+		done:             make(chan struct{}),
 	}
+	m.eventQueue.metrics = m.metrics
+	return m
+}
+
+// InstanceID returns the ID of this run of the worker; see
+// workerv1.SessionStateSnapshot.InstanceId.
+func (m *SessionManager) InstanceID() string {
+	return m.instanceID
 }
 
 // Launch starts a new session with the specified agent driver.
@@ -87,61 +167,144 @@ func (m *SessionManager) Launch(_ context.Context, sessionID, agentID string, op
 	if !ok {
 		return nil, fmt.Errorf("unknown agent driver: %s", agentID)
 	}
+	if m.isClosed() {
+		return nil, fmt.Errorf("session manager is shut down")
+	}
 
 	caps := d.Capabilities()
-	if opts.ResumeSessionID != "" && !caps.Has(driver.CapSessionResume) {
+	if opts.ResumeSessionID != "" && !caps.Supports(driver.CapSessionResume) {
 		return nil, fmt.Errorf("agent %s does not support session resume", agentID)
 	}
-	if opts.Model != "" && !caps.Has(driver.CapCustomModel) {
+	if opts.Model != "" && !caps.Supports(driver.CapCustomModel) {
 		return nil, fmt.Errorf("agent %s does not support custom model selection", agentID)
 	}
-	if opts.SystemPrompt != "" && !caps.Has(driver.CapSystemPrompt) {
+	if (opts.SystemPrompt != "" || opts.SystemPromptTemplate != "") && !caps.Supports(driver.CapSystemPrompt) {
 		return nil, fmt.Errorf("agent %s does not support system prompts", agentID)
 	}
+	for _, c := range opts.RequiredCapabilities {
+		if !caps.Has(c) {
+			return nil, fmt.Errorf("agent %s does not support %s", agentID, c)
+		}
+	}
+	if opts.ReasoningEffort != "" {
+		if _, err := driver.ParseReasoningEffort(opts.ReasoningEffort); err != nil {
+			return nil, err
+		}
+		if !caps.Supports(driver.CapReasoningEffort) {
+			return nil, fmt.Errorf("agent %s does not support reasoning effort", agentID)
+		}
+	}
 	// Inject CTL env vars so agents can reach the private listener.
 	if opts.EnvVars == nil {
 		opts.EnvVars = make(map[string]string)
 	}
+	for k, v := range m.agentctlEnv {
+		if _, ok := opts.EnvVars[k]; !ok {
+			opts.EnvVars[k] = v
+		}
+	}
 	opts.EnvVars["AGENTCTL_WORKER_URL"] = m.ctlURL
 	opts.EnvVars["AGENTCTL_WORKER_SECRET"] = m.ctlSecret
 	opts.EnvVars["AGENTCTL_SESSION_ID"] = sessionID
 	opts.EnvVars["AGENTCTL_AGENT"] = agentID
+	m.toolPolicies[agentID].apply(&opts)
+	opts.MCPServers = v2.MergeMCPServers(opts.MCPServers, m.mcpServers)
+	if opts.ResourceLimits.IsZero() {
+		opts.ResourceLimits = m.resourceLimits[agentID]
+	}
+
+	// The driver is set up front so events emitted during Launch can be
+	// attributed to the agent.
+	entry := &sessionEntry{driver: d, labels: maps.Clone(opts.Labels), ephemeral: opts.Ephemeral}
+	// Relaunching a restored session continues its event sequence, which
+	// the control plane deduplicates on.
+	restored := m.restoredEntry(sessionID)
+	if restored != nil {
+		entry.nextSeq.Store(restored.nextSeq.Load())
+	}
+	if opts.AutoTopic {
+		entry.autoTopic = &autoTopic{}
+		entry.autoTopic.notePrompt(opts.Prompt)
+	}
 
-	entry := &sessionEntry{}
+	// The agent sees the wrapped prompt; events and the auto topic use the
+	// prompt as typed.
+	userPrompt := opts.Prompt
+	wrap := m.promptWraps.For(agentID)
+	opts.Prompt = wrap.wrapText(opts.Prompt)
+	if !wrap.empty() {
+		opts.AutoContinue.Wrap = wrap.wrapText
+	}
 
 	wrappedOnEvent := func(n acp.SessionNotification) {
 		logACPEvent(m.log, agentID, n)
 		m.emitSessionEvent(sessionID, entry, n)
+		m.notifyObservers(sessionID, n)
 		if onEvent != nil {
 			onEvent(n)
 		}
 	}
+	callerOnTurnEnded := opts.OnTurnEnded
+	opts.OnTurnEnded = func(te v2.TurnEnd) {
+		m.emitTurnEnded(sessionID, entry, te)
+		if callerOnTurnEnded != nil {
+			callerOnTurnEnded(te)
+		}
+	}
+	callerOnPermission := opts.OnPermission
+	opts.OnPermission = func(e v2.PermissionEvent) {
+		m.emitPermissionEvent(sessionID, entry, e)
+		if callerOnPermission != nil {
+			callerOnPermission(e)
+		}
+	}
 
 	// Wire up status channel so transitions (e.g. running→idle) are emitted
 	// as SessionEvent_StatusChange. This triggers the assembler to flush any
 	// buffered text/thought chunks that haven't been persisted yet.
+	// The forwarder waits for launched so it never sees the session before
+	// it is assigned and registered, and returns if the launch fails.
 	statusCh := make(chan v2.SessionStatus, 4)
 	opts.StatusCh = statusCh
-	go m.forwardStatusEvents(sessionID, entry, statusCh)
+	launched := make(chan struct{})
+	launchFailed := make(chan struct{})
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		select {
+		case <-launched:
+		case <-launchFailed:
+			return
+		case <-m.done:
+			return
+		}
+		m.forwardStatusEvents(sessionID, entry, statusCh)
+	}()
 
 	sess, err := d.Launch(ctx, opts, wrappedOnEvent)
 	if err != nil {
+		close(launchFailed)
+		m.metrics.Counter(metrics.Errors, 1, metrics.Labels{"agent": agentID, "op": "launch"})
 		return nil, fmt.Errorf("launch %s: %w", agentID, err)
 	}
 
 	entry.session = sess
-	entry.driver = d
 
 	m.mu.Lock()
 	m.sessions[sessionID] = entry
 	m.mu.Unlock()
+	close(launched)
+	m.metrics.Counter(metrics.SessionsLaunched, 1, metrics.Labels{"agent": agentID})
+	if restored == nil {
+		m.metrics.Gauge(metrics.SessionsActive, 1, metrics.Labels{"agent": agentID})
+	}
 
 	// Emit the initial prompt as a user_message event.
-	if opts.Prompt != "" {
-		m.emitUserMessage(sessionID, entry, opts.Prompt)
+	if userPrompt != "" {
+		m.emitUserMessage(sessionID, entry, userPrompt)
 	}
 
-	snap := SessionSnapshot{SessionID: sessionID, Info: sess.Info()}
+	snap := SessionSnapshot{SessionID: sessionID, Info: sess.Info(), Labels: entry.labels}
 	m.notifySubscribers(StateEvent{Type: StateEventUpdate, SessionID: sessionID, Snapshot: &snap})
 
 	m.log.Info("session launched", "agent", agentID, "session_id", sessionID, "agent_session_id", sess.Info().AgentSessionID)
@@ -163,24 +326,36 @@ func (m *SessionManager) GetSession(id string) (v2.Session, bool) {
 type SessionListEntry struct {
 	SessionID string
 	Info      v2.SessionInfo
+	Labels    map[string]string
+	// QueueDepth is the number of events queued for the session that the
+	// control plane has not acknowledged yet.
+	QueueDepth int
 }
 
-// ListSessions returns info for all active sessions, keyed by session ID.
-func (m *SessionManager) ListSessions() []SessionListEntry {
+// ListSessions returns info for all active sessions whose labels match sel.
+// A nil or empty selector returns every session.
+func (m *SessionManager) ListSessions(sel LabelSelector) []SessionListEntry {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 	entries := make([]SessionListEntry, 0, len(m.sessions))
 	for id, e := range m.sessions {
+		if !sel.Matches(e.labels) {
+			continue
+		}
 		entries = append(entries, SessionListEntry{
-			SessionID: id,
-			Info:      e.session.Info(),
+			SessionID:  id,
+			Info:       e.session.Info(),
+			Labels:     e.labels,
+			QueueDepth: m.eventQueue.Depth(id),
 		})
 	}
 	return entries
 }
 
-// StopSession stops the session with the given ID.
-func (m *SessionManager) StopSession(ctx context.Context, id string) error {
+// StopSession stops the session with the given ID. If wait is set, it also
+// blocks until the session has fully torn down or ctx is done; the session is
+// removed either way.
+func (m *SessionManager) StopSession(ctx context.Context, id string, wait bool) error {
 	m.mu.RLock()
 	e, ok := m.sessions[id]
 	m.mu.RUnlock()
@@ -190,7 +365,36 @@ func (m *SessionManager) StopSession(ctx context.Context, id string) error {
 	if err := e.session.Stop(ctx); err != nil {
 		return err
 	}
+	var err error
+	if wait {
+		err = e.session.Wait(ctx)
+	}
 	m.removeSession(id)
+	return err
+}
+
+// SoftStop stops the session with the given ID once its current turn has
+// ended: the tool call in flight completes, but the agent is denied further
+// tool calls and no new turns start. The session is removed when it has torn
+// down; SoftStop itself does not wait for that.
+func (m *SessionManager) SoftStop(ctx context.Context, id string) error {
+	m.mu.RLock()
+	e, ok := m.sessions[id]
+	m.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("session not found: %s", id)
+	}
+	if err := e.session.SoftStop(ctx); err != nil {
+		return err
+	}
+	e.softStopping.Store(true)
+	m.log.Info("session soft-stopping", "session_id", id)
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		_ = e.session.Wait(context.Background())
+		m.removeSession(id)
+	}()
 	return nil
 }
 
@@ -203,24 +407,195 @@ func (m *SessionManager) ListDrivers() []driver.Capabilities {
 	return caps
 }
 
+// Shutdown stops all active sessions (at most maxParallelStops at a time),
+// closes every state and event subscriber channel, and waits for background
+// goroutines to exit. It returns ctx.Err() if the deadline expires first.
+// After Shutdown, Launch fails and new subscriptions receive a closed channel.
+func (m *SessionManager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return nil
+	}
+	m.closed = true
+	ids := make([]string, 0, len(m.sessions))
+	for id := range m.sessions {
+		ids = append(ids, id)
+	}
+	m.mu.Unlock()
+
+	m.log.Info("shutting down session manager", "sessions", len(ids))
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		sem := make(chan struct{}, maxParallelStops)
+		var stopWG sync.WaitGroup
+		for _, id := range ids {
+			sem <- struct{}{}
+			stopWG.Add(1)
+			go func() {
+				defer stopWG.Done()
+				defer func() { <-sem }()
+				if err := m.StopSession(ctx, id, true); err != nil {
+					m.log.Warn("stop session during shutdown failed", "session_id", id, "error", err)
+				}
+			}()
+		}
+		stopWG.Wait()
+		// Sessions are stopped; release status forwarders whose driver never
+		// closes its status channel.
+		close(m.done)
+		m.wg.Wait()
+	}()
+
+	var err error
+	select {
+	case <-finished:
+	case <-ctx.Done():
+		err = ctx.Err()
+		m.log.Warn("session manager shutdown deadline exceeded", "error", err)
+	}
+
+	// Subscribers are closed last so in-flight stops can still publish
+	// their removal and final status events.
+	m.mu.Lock()
+	for ch := range m.subscribers {
+		close(ch)
+		delete(m.subscribers, ch)
+	}
+	for ch := range m.eventSubscribers {
+		close(ch)
+		delete(m.eventSubscribers, ch)
+	}
+	m.mu.Unlock()
+	return err
+}
+
+func (m *SessionManager) isClosed() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.closed
+}
+
 // forwardStatusEvents reads from the status channel and emits SessionEvent_StatusChange
 // events so the assembler flushes buffered text/thought on status transitions.
+// It returns when the driver closes ch or the manager shuts down.
 func (m *SessionManager) forwardStatusEvents(sessionID string, entry *sessionEntry, ch <-chan v2.SessionStatus) {
-	for status := range ch {
+	for {
+		var status v2.SessionStatus
+		select {
+		case s, ok := <-ch:
+			if !ok {
+				return
+			}
+			status = s
+		case <-m.done:
+			return
+		}
+		// The agent has finished session setup once it first reports
+		// running; announce the effective model before the status change.
+		if status == v2.SessionStatusRunning && !entry.modelAnnounced.Load() {
+			m.announceModel(sessionID, entry)
+		}
+		if status == v2.SessionStatusIdle && entry.autoTopic != nil {
+			m.maybeAutoTopic(sessionID, entry)
+		}
+		if status == v2.SessionStatusErrored {
+			m.emitSessionError(sessionID, entry)
+		}
 		seq := entry.nextSeq.Add(1)
 		event := &workerv1.SessionEvent{
 			SessionId: sessionID,
 			Sequence:  seq,
 			Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
 			Payload: &workerv1.SessionEvent_StatusChange{
-				StatusChange: &workerv1.StatusChange{
-					Status: sessionStatusToProto(status),
-				},
+				StatusChange: statusChangeToProto(status, entry.session.Info(), entry.softStopping.Load()),
 			},
 		}
-		m.eventQueue.Append(sessionID, event)
-		m.notifyEventSubscribers(SessionEventUpdate{SessionID: sessionID, Event: event})
+		m.publishEvent(entry, event)
+	}
+}
+
+// emitSessionError emits a SessionError event describing why the session
+// failed, so clients can offer e.g. re-authentication. It is a no-op when the
+// driver did not record a reason.
+func (m *SessionManager) emitSessionError(sessionID string, entry *sessionEntry) {
+	se := entry.session.Info().Error
+	if se == nil {
+		return
+	}
+	if se.Reason == v2.ErrorReasonAuth {
+		m.log.Warn("agent authentication failed", "session_id", sessionID, "agent", entry.driver.Agent(), "error", se.Message)
+	}
+	seq := entry.nextSeq.Add(1)
+	event := &workerv1.SessionEvent{
+		SessionId: sessionID,
+		Sequence:  seq,
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Payload: &workerv1.SessionEvent_SessionError{
+			SessionError: sessionErrorToProto(se),
+		},
+	}
+	m.publishEvent(entry, event)
+}
+
+// sessionErrorToProto maps a v2.SessionError to the proto message; nil stays
+// nil.
+func sessionErrorToProto(se *v2.SessionError) *workerv1.SessionError {
+	if se == nil {
+		return nil
+	}
+	reason := workerv1.SessionErrorReason_SESSION_ERROR_REASON_UNSPECIFIED
+	if se.Reason == v2.ErrorReasonAuth {
+		reason = workerv1.SessionErrorReason_SESSION_ERROR_REASON_AUTH
+	}
+	return &workerv1.SessionError{Reason: reason, Message: se.Message, StderrTail: se.StderrTail}
+}
+
+// announceModel emits a CurrentModelUpdate event and a snapshot update with
+// the session's effective model, which includes the agent default when the
+// caller didn't request one. It is a no-op until the model is known.
+func (m *SessionManager) announceModel(sessionID string, entry *sessionEntry) {
+	info := entry.session.Info()
+	if info.CurrentModel == "" || !entry.modelAnnounced.CompareAndSwap(false, true) {
+		return
+	}
+	m.emitModelUpdate(sessionID, entry, info)
+	m.log.Info("session model resolved", "session_id", sessionID, "model", info.CurrentModel)
+}
+
+// emitModelUpdate emits a CurrentModelUpdate event and a snapshot update
+// carrying info.CurrentModel.
+func (m *SessionManager) emitModelUpdate(sessionID string, entry *sessionEntry, info v2.SessionInfo) {
+	event := &workerv1.SessionEvent{
+		SessionId: sessionID,
+		Sequence:  entry.nextSeq.Add(1),
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Payload: &workerv1.SessionEvent_CurrentModelUpdate{
+			CurrentModelUpdate: &workerv1.CurrentModelUpdate{ModelId: info.CurrentModel},
+		},
+	}
+	m.publishEvent(entry, event)
+
+	m.mu.RLock()
+	snap := SessionSnapshot{SessionID: sessionID, Info: info, Topic: entry.topic, Labels: entry.labels}
+	m.mu.RUnlock()
+	m.notifySubscribers(StateEvent{Type: StateEventUpdate, SessionID: sessionID, Snapshot: &snap})
+}
+
+// statusChangeToProto builds the StatusChange for status, carrying the
+// session's error when it failed. Only idle sessions accept input, and not
+// once they are stopping.
+func statusChangeToProto(status v2.SessionStatus, info v2.SessionInfo, stopping bool) *workerv1.StatusChange {
+	sc := &workerv1.StatusChange{
+		Status:         sessionStatusToProto(status),
+		AcceptingInput: status == v2.SessionStatusIdle && !stopping,
 	}
+	if status == v2.SessionStatusErrored {
+		sc.Error = sessionErrorToProto(info.Error)
+	}
+	return sc
 }
 
 // sessionStatusToProto maps a v2.SessionStatus to the proto enum.
@@ -243,12 +618,19 @@ func sessionStatusToProto(s v2.SessionStatus) workerv1.SessionStatus {
 
 func (m *SessionManager) removeSession(id string) {
 	m.mu.Lock()
-	_, existed := m.sessions[id]
+	e, existed := m.sessions[id]
 	delete(m.sessions, id)
 	m.mu.Unlock()
 	if existed {
+		agent := metrics.Labels{"agent": e.driver.Agent()}
+		m.metrics.Gauge(metrics.SessionsActive, -1, agent)
+		m.metrics.Counter(metrics.SessionsStopped, 1, agent)
 		m.eventQueue.Remove(id)
-		m.notifySubscribers(StateEvent{Type: StateEventRemoved, SessionID: id})
+		final := v2.SessionStatusStopped
+		if e.session.Info().Status == v2.SessionStatusErrored {
+			final = v2.SessionStatusErrored
+		}
+		m.notifySubscribers(StateEvent{Type: StateEventRemoved, SessionID: id, FinalStatus: final})
 	}
 }
 
@@ -257,8 +639,12 @@ func (m *SessionManager) removeSession(id string) {
 func (m *SessionManager) Subscribe() chan StateEvent {
 	ch := make(chan StateEvent, 16)
 	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		close(ch)
+		return ch
+	}
 	m.subscribers[ch] = struct{}{}
-	m.mu.Unlock()
 	return ch
 }
 
@@ -292,8 +678,12 @@ func (m *SessionManager) notifySubscribers(event StateEvent) {
 func (m *SessionManager) SubscribeEvents() chan SessionEventUpdate {
 	ch := make(chan SessionEventUpdate, 64)
 	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		close(ch)
+		return ch
+	}
 	m.eventSubscribers[ch] = struct{}{}
-	m.mu.Unlock()
 	return ch
 }
 
@@ -304,6 +694,18 @@ func (m *SessionManager) UnsubscribeEvents(ch chan SessionEventUpdate) {
 	m.mu.Unlock()
 }
 
+// publishEvent queues event until the control plane acknowledges it and
+// hands it to event subscribers. Events of ephemeral sessions skip the queue
+// and are marked so the control plane does not persist them.
+func (m *SessionManager) publishEvent(entry *sessionEntry, event *workerv1.SessionEvent) {
+	if entry.ephemeral {
+		event.Ephemeral = true
+	} else {
+		m.eventQueue.Append(event.SessionId, event)
+	}
+	m.notifyEventSubscribers(SessionEventUpdate{SessionID: event.SessionId, Event: event})
+}
+
 func (m *SessionManager) notifyEventSubscribers(evt SessionEventUpdate) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
@@ -323,6 +725,84 @@ func (m *SessionManager) notifyEventSubscribers(evt SessionEventUpdate) {
 	}
 }
 
+// NotificationObserver is called with the ACP notifications of every
+// session the SessionManager runs.
+type NotificationObserver func(sessionID string, n acp.SessionNotification)
+
+type observedNotification struct {
+	sessionID string
+	n         acp.SessionNotification
+}
+
+type notificationObserver struct {
+	ch   chan observedNotification
+	stop chan struct{}
+}
+
+// Observe registers fn to be called for every notification of every
+// session, in addition to the per-session onEvent callback. fn runs on its
+// own goroutine so it never blocks the event path; an observer that falls
+// behind loses the oldest queued notifications. The returned func removes
+// the observer; observers also stop when the manager shuts down.
+func (m *SessionManager) Observe(fn NotificationObserver) (remove func()) {
+	o := &notificationObserver{
+		ch:   make(chan observedNotification, 64),
+		stop: make(chan struct{}),
+	}
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return func() {}
+	}
+	m.observers[o] = struct{}{}
+	m.wg.Add(1)
+	m.mu.Unlock()
+
+	go func() {
+		defer m.wg.Done()
+		for {
+			select {
+			case on := <-o.ch:
+				fn(on.sessionID, on.n)
+			case <-o.stop:
+				return
+			case <-m.done:
+				return
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			m.mu.Lock()
+			delete(m.observers, o)
+			m.mu.Unlock()
+			close(o.stop)
+		})
+	}
+}
+
+func (m *SessionManager) notifyObservers(sessionID string, n acp.SessionNotification) {
+	on := observedNotification{sessionID: sessionID, n: n}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for o := range m.observers {
+		select {
+		case o.ch <- on:
+		default:
+			select {
+			case <-o.ch:
+			default:
+			}
+			select {
+			case o.ch <- on:
+			default:
+			}
+		}
+	}
+}
+
 // PendingEvents returns all un-ACKed events for a session after the given sequence.
 func (m *SessionManager) PendingEvents(sessionID string, afterSeq int64) []*workerv1.SessionEvent {
 	return m.eventQueue.Pending(sessionID, afterSeq)
@@ -338,32 +818,76 @@ func (m *SessionManager) AckEvents(sessionID string, sequence int64) {
 	m.eventQueue.Ack(sessionID, sequence)
 }
 
+// QueueDepth returns the number of un-acknowledged events queued for a session.
+func (m *SessionManager) QueueDepth(sessionID string) int {
+	return m.eventQueue.Depth(sessionID)
+}
+
+// TotalQueueDepth returns the number of un-acknowledged events across all sessions.
+func (m *SessionManager) TotalQueueDepth() int {
+	return m.eventQueue.TotalDepth()
+}
+
 // emitSessionEvent converts an ACP notification to a proto SessionEvent and enqueues it.
 func (m *SessionManager) emitSessionEvent(sessionID string, entry *sessionEntry, n acp.SessionNotification) {
 	u := n.Update
-	seq := entry.nextSeq.Add(1)
 	now := time.Now().UTC().Format(time.RFC3339Nano)
 
 	event := &workerv1.SessionEvent{
 		SessionId: sessionID,
-		Sequence:  seq,
 		Timestamp: now,
 	}
 
 	switch {
 	case u.AgentMessageChunk != nil:
 		text := ""
-		if u.AgentMessageChunk.Content.Text != nil {
-			text = u.AgentMessageChunk.Content.Text.Text
+		if u.AgentMessageChunk.Content.Text != nil && u.AgentMessageChunk.Content.Text.Text != "" {
+			if text = entry.messageText.next(u.AgentMessageChunk.Content.Text.Text); text == "" {
+				return // held back until the rune is complete
+			}
 		}
 		event.Payload = &workerv1.SessionEvent_AgentMessageChunk{
 			AgentMessageChunk: &workerv1.AgentMessageChunk{Text: text},
 		}
+		if entry.autoTopic != nil {
+			entry.autoTopic.noteReply(text)
+		}
 	case u.AgentThoughtChunk != nil:
 		text := ""
 		if u.AgentThoughtChunk.Content.Text != nil {
 			text = u.AgentThoughtChunk.Content.Text.Text
 		}
+		// MCP startup progress and the session init are reported as
+		// thoughts for plain ACP clients; keep them out of the reasoning
+		// stream.
+		if s, ok := driver.ParseMCPStartup(u.AgentThoughtChunk.Meta); ok {
+			event.Payload = &workerv1.SessionEvent_McpServerStartup{
+				McpServerStartup: &workerv1.McpServerStartup{
+					Server:  s.Server,
+					Status:  s.Status,
+					Message: s.Message,
+					Ready:   s.Ready,
+					Text:    text,
+				},
+			}
+			break
+		}
+		if s, ok := driver.ParseSessionInit(u.AgentThoughtChunk.Meta); ok {
+			event.Payload = &workerv1.SessionEvent_SessionInit{
+				SessionInit: &workerv1.SessionInit{
+					Model:          s.Model,
+					PermissionMode: s.PermissionMode,
+					Tools:          s.Tools,
+					Text:           text,
+				},
+			}
+			break
+		}
+		if text != "" {
+			if text = entry.thoughtText.next(text); text == "" {
+				return // held back until the rune is complete
+			}
+		}
 		event.Payload = &workerv1.SessionEvent_AgentThoughtChunk{
 			AgentThoughtChunk: &workerv1.AgentThoughtChunk{Text: text},
 		}
@@ -371,20 +895,41 @@ func (m *SessionManager) emitSessionEvent(sessionID string, entry *sessionEntry,
 		event.Payload = &workerv1.SessionEvent_ToolCall{
 			ToolCall: acpToolCallToProto(u.ToolCall),
 		}
+		m.metrics.Counter(metrics.ToolCalls, 1, metrics.Labels{"agent": entry.driver.Agent(), "kind": string(u.ToolCall.Kind)})
 	case u.ToolCallUpdate != nil:
 		event.Payload = &workerv1.SessionEvent_ToolCallUpdate{
-			ToolCallUpdate: acpToolCallUpdateToProto(u.ToolCallUpdate),
+			ToolCallUpdate: acpToolCallUpdateToProto(u.ToolCallUpdate, m.maxRawOutput),
+		}
+	case u.UserMessageChunk != nil:
+		// Only prompts the driver sent by itself; the user's own are emitted
+		// by Prompt.
+		iteration, ok := v2.AutoContinueIteration(u.UserMessageChunk)
+		if !ok {
+			return
+		}
+		text := ""
+		if u.UserMessageChunk.Content.Text != nil {
+			text = u.UserMessageChunk.Content.Text.Text
+		}
+		event.Payload = &workerv1.SessionEvent_UserMessage{
+			UserMessage: &workerv1.UserMessage{Text: text, AutoContinueIteration: int32(iteration)},
 		}
 	case u.CurrentModeUpdate != nil:
 		event.Payload = &workerv1.SessionEvent_CurrentModeUpdate{
 			CurrentModeUpdate: &workerv1.CurrentModeUpdate{ModeId: string(u.CurrentModeUpdate.CurrentModeId)},
 		}
+	case u.Plan != nil:
+		// The driver has already merged the update into the session's
+		// current plan, so this carries the whole plan.
+		event.Payload = &workerv1.SessionEvent_AgentPlan{
+			AgentPlan: acpPlanToProto(u.Plan.Entries),
+		}
 	default:
 		return // skip events we don't handle
 	}
 
-	m.eventQueue.Append(sessionID, event)
-	m.notifyEventSubscribers(SessionEventUpdate{SessionID: sessionID, Event: event})
+	event.Sequence = entry.nextSeq.Add(1)
+	m.publishEvent(entry, event)
 }
 
 // SetSessionMode changes the permission mode of a running session.
@@ -398,6 +943,119 @@ func (m *SessionManager) SetSessionMode(ctx context.Context, sessionID string, m
 	return e.session.SetSessionMode(ctx, mode)
 }
 
+// maybeAutoTopic derives a topic from the opening exchange once the first
+// prompt has been answered, unless the agent already set one via set_topic.
+func (m *SessionManager) maybeAutoTopic(sessionID string, entry *sessionEntry) {
+	prompt, reply, ok := entry.autoTopic.take()
+	if !ok {
+		return
+	}
+	m.mu.RLock()
+	hasTopic := entry.topic != ""
+	m.mu.RUnlock()
+	if hasTopic {
+		return
+	}
+	topic := deriveAutoTopic(prompt, reply)
+	if topic == "" {
+		return
+	}
+	if err := m.HandleSetTopic(context.Background(), sessionID, topic); err != nil {
+		m.log.Warn("auto topic failed", "session_id", sessionID, "error", err)
+	}
+}
+
+// SetSessionModel switches a running session to model and announces the
+// change to event and state subscribers.
+func (m *SessionManager) SetSessionModel(ctx context.Context, sessionID, model string) error {
+	m.mu.RLock()
+	e, ok := m.sessions[sessionID]
+	m.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	if !e.driver.Capabilities().Supports(driver.CapCustomModel) {
+		return fmt.Errorf("agent %s does not support custom model selection", e.driver.Agent())
+	}
+	if err := e.session.SetModel(ctx, model); err != nil {
+		return fmt.Errorf("set model: %w", err)
+	}
+	e.modelAnnounced.Store(true)
+	m.emitModelUpdate(sessionID, e, e.session.Info())
+	m.log.Info("session model changed", "session_id", sessionID, "model", model)
+	return nil
+}
+
+// AddMCPServer starts server for a running session. Agents without
+// driver.CapAddMCPServer take MCP servers only at launch; for them the
+// returned error wraps v2.ErrAddMCPServerUnsupported. The agent refreshes its
+// available commands afterwards.
+func (m *SessionManager) AddMCPServer(ctx context.Context, sessionID string, server acp.McpServer) error {
+	m.mu.RLock()
+	e, ok := m.sessions[sessionID]
+	m.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	if !e.driver.Capabilities().Supports(driver.CapAddMCPServer) {
+		return fmt.Errorf("agent %s: %w", e.driver.Agent(), v2.ErrAddMCPServerUnsupported)
+	}
+	if err := e.session.AddMCPServer(ctx, server); err != nil {
+		return fmt.Errorf("add mcp server: %w", err)
+	}
+	m.log.Info("mcp server added", "session_id", sessionID, "server", driver.MCPServerName(server))
+	return nil
+}
+
+// SetAllowedTools replaces the tools a running session may use without
+// asking. Agents without driver.CapSetAllowedTools take allowed tools only at
+// launch; for them the returned error wraps v2.ErrSetAllowedToolsUnsupported.
+func (m *SessionManager) SetAllowedTools(ctx context.Context, sessionID string, tools []string) error {
+	m.mu.RLock()
+	e, ok := m.sessions[sessionID]
+	m.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	if !e.driver.Capabilities().Supports(driver.CapSetAllowedTools) {
+		return fmt.Errorf("agent %s: %w", e.driver.Agent(), v2.ErrSetAllowedToolsUnsupported)
+	}
+	if err := e.session.SetAllowedTools(ctx, tools); err != nil {
+		return fmt.Errorf("set allowed tools: %w", err)
+	}
+	m.log.Info("allowed tools updated", "session_id", sessionID, "tools", tools)
+	return nil
+}
+
+// SetHostCommands replaces the slash commands a running session offers in
+// addition to the agent's own; its driver announces them to the client as an
+// available-commands update.
+func (m *SessionManager) SetHostCommands(ctx context.Context, sessionID string, cmds []acp.AvailableCommand) error {
+	m.mu.RLock()
+	e, ok := m.sessions[sessionID]
+	m.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+	if err := e.session.SetHostCommands(ctx, cmds); err != nil {
+		return fmt.Errorf("set host commands: %w", err)
+	}
+	m.log.Info("host commands updated", "session_id", sessionID, "commands", len(cmds))
+	return nil
+}
+
+// GetCurrentPlan returns the latest plan the agent of a running session
+// reported, or nil if it has reported none.
+func (m *SessionManager) GetCurrentPlan(sessionID string) ([]acp.PlanEntry, error) {
+	m.mu.RLock()
+	e, ok := m.sessions[sessionID]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("session not found: %s", sessionID)
+	}
+	return e.session.Info().Plan, nil
+}
+
 // HandleSetTopic updates the topic for the given session and notifies subscribers.
 func (m *SessionManager) HandleSetTopic(_ context.Context, sessionID, topic string) error {
 	m.mu.Lock()
@@ -407,18 +1065,24 @@ func (m *SessionManager) HandleSetTopic(_ context.Context, sessionID, topic stri
 		return fmt.Errorf("session not found: %s", sessionID)
 	}
 	e.topic = topic
-	snap := SessionSnapshot{SessionID: sessionID, Info: e.session.Info(), Topic: topic}
+	snap := SessionSnapshot{SessionID: sessionID, Info: e.session.Info(), Topic: topic, Labels: e.labels}
 	m.mu.Unlock()
 	m.notifySubscribers(StateEvent{Type: StateEventUpdate, SessionID: sessionID, Snapshot: &snap})
 	m.log.Info("topic set", "session_id", sessionID, "topic", topic)
 	return nil
 }
 
-// SessionSnapshot is the state of a single session for state sync.
+// SessionSnapshot is the state of a single session for state sync. Its
+// JSON form is what ExportState writes.
 type SessionSnapshot struct {
-	SessionID string
-	Info      v2.SessionInfo
-	Topic     string
+	SessionID string            `json:"session_id"`
+	Info      v2.SessionInfo    `json:"info"`
+	Topic     string            `json:"topic,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+	// LastSequence is the sequence of the session's last event. ExportState
+	// sets it so a restored session continues the sequence, and
+	// GetStateSnapshot so the control plane notices a worker restart.
+	LastSequence int64 `json:"last_sequence,omitempty"`
 }
 
 // HandleHookEvent is a no-op stub for the agentctl EventHandler interface.
@@ -434,31 +1098,86 @@ func (m *SessionManager) HandleStatusReport(_ context.Context, sessionID, agent,
 	return nil
 }
 
-// HandlePlanSubmission is a no-op stub for the agentctl EventHandler interface.
+// HandlePlanSubmission parses a plan submitted via agentctl and emits it as a
+// PlanSubmitted event, which the control plane persists with the session.
 func (m *SessionManager) HandlePlanSubmission(_ context.Context, sessionID, agent string, plan []byte) error {
-	m.log.Info("plan submitted", "session_id", sessionID, "agent", agent, "plan_bytes", len(plan))
+	plans, err := driver.ParsePlans(plan)
+	if err != nil {
+		return err
+	}
+
+	m.mu.RLock()
+	e, ok := m.sessions[sessionID]
+	m.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	submitted := &workerv1.PlanSubmitted{}
+	for _, p := range plans {
+		submitted.Plans = append(submitted.Plans, planToProto(p))
+	}
+	event := &workerv1.SessionEvent{
+		SessionId: sessionID,
+		Sequence:  e.nextSeq.Add(1),
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Payload:   &workerv1.SessionEvent_PlanSubmitted{PlanSubmitted: submitted},
+	}
+	m.publishEvent(e, event)
+	m.log.Info("plan submitted", "session_id", sessionID, "agent", agent, "plans", len(plans))
+	return nil
+}
+
+// HandleProgressReport emits a Progress event for an intermediate progress
+// report the agent sent via agentctl.
+func (m *SessionManager) HandleProgressReport(_ context.Context, sessionID, message string, percent *int32) error {
+	m.mu.RLock()
+	e, ok := m.sessions[sessionID]
+	m.mu.RUnlock()
+	if !ok {
+		return fmt.Errorf("session not found: %s", sessionID)
+	}
+
+	event := &workerv1.SessionEvent{
+		SessionId: sessionID,
+		Sequence:  e.nextSeq.Add(1),
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Payload: &workerv1.SessionEvent_Progress{
+			Progress: &workerv1.Progress{Message: message, Percent: percent},
+		},
+	}
+	m.publishEvent(e, event)
 	return nil
 }
 
+func planToProto(p driver.Plan) *workerv1.Plan {
+	out := &workerv1.Plan{ThreadId: p.ThreadID, Title: p.Title, Body: p.Body}
+	for _, s := range p.Steps {
+		out.Steps = append(out.Steps, &workerv1.PlanStep{
+			Id:          s.ID,
+			File:        s.File,
+			Description: s.Description,
+			DependsOn:   s.DependsOn,
+			Agent:       s.Agent,
+			Subtasks:    s.Subtasks,
+		})
+	}
+	return out
+}
+
 func logACPEvent(log *slog.Logger, agentID string, n acp.SessionNotification) {
 	u := n.Update
 	switch {
 	case u.AgentMessageChunk != nil:
 		text := ""
 		if u.AgentMessageChunk.Content.Text != nil {
-			text = u.AgentMessageChunk.Content.Text.Text
-			if len(text) > 120 {
-				text = text[:120] + "..."
-			}
+			text = truncateText(u.AgentMessageChunk.Content.Text.Text, 120)
 		}
 		log.Info("acp: agent message", "agent", agentID, "session", n.SessionId, "text", text)
 	case u.AgentThoughtChunk != nil:
 		text := ""
 		if u.AgentThoughtChunk.Content.Text != nil {
-			text = u.AgentThoughtChunk.Content.Text.Text
-			if len(text) > 120 {
-				text = text[:120] + "..."
-			}
+			text = truncateText(u.AgentThoughtChunk.Content.Text.Text, 120)
 		}
 		log.Info("acp: agent thought", "agent", agentID, "session", n.SessionId, "text", text)
 	case u.ToolCall != nil:
@@ -511,26 +1230,26 @@ func logACPEvent(log *slog.Logger, agentID string, n acp.SessionNotification) {
 
 // formatRawField JSON-encodes an any value for logging, truncating to 200 chars.
 func formatRawField(v any) string {
+	s := encodeRawField(v)
+	if len(s) > 200 {
+		return s[:200] + "..."
+	}
+	return s
+}
+
+// encodeRawField JSON-encodes an any value; strings are returned as is.
+func encodeRawField(v any) string {
 	if v == nil {
 		return ""
 	}
-	switch val := v.(type) {
-	case string:
-		if len(val) > 200 {
-			return val[:200] + "..."
-		}
-		return val
-	default:
-		b, err := json.Marshal(val)
-		if err != nil {
-			return fmt.Sprintf("%v", val)
-		}
-		s := string(b)
-		if len(s) > 200 {
-			return s[:200] + "..."
-		}
+	if s, ok := v.(string); ok {
 		return s
 	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
 }
 
 // CheckSessionResumable checks if an agent driver supports session resume and
@@ -542,7 +1261,7 @@ func (m *SessionManager) CheckSessionResumable(agentID, agentSessionID, cwd stri
 	}
 
 	caps := d.Capabilities()
-	if !caps.Has(driver.CapSessionResume) {
+	if !caps.Supports(driver.CapSessionResume) {
 		return false, fmt.Sprintf("agent %s does not support session resume", agentID)
 	}
 
@@ -564,14 +1283,89 @@ func (m *SessionManager) Prompt(ctx context.Context, sessionID string, blocks []
 	if !ok {
 		return nil, fmt.Errorf("session not found: %s", sessionID)
 	}
+	if _, restored := e.session.(*restoredSession); restored {
+		return nil, ErrSessionRestored
+	}
 
 	// Extract text from content blocks and emit a user_message event.
 	text := extractTextFromBlocks(blocks)
 	if text != "" {
 		m.emitUserMessage(sessionID, e, text)
+		if e.autoTopic != nil {
+			e.autoTopic.notePrompt(text)
+		}
+	}
+
+	agent := metrics.Labels{"agent": e.driver.Agent()}
+	m.metrics.Counter(metrics.Prompts, 1, agent)
+	start := time.Now()
+	resp, err := e.session.Prompt(ctx, m.promptWraps.For(e.driver.Agent()).wrapBlocks(blocks))
+	m.metrics.Histogram(metrics.PromptDuration, time.Since(start).Seconds(), agent)
+	if err != nil {
+		m.metrics.Counter(metrics.Errors, 1, metrics.Labels{"agent": e.driver.Agent(), "op": "prompt"})
+	}
+	return resp, err
+}
+
+// emitTurnEnded enqueues a TurnEnded SessionEvent for a turn the driver
+// reported, with the reason the turn was cancelled if it was and the turn's
+// stats if the session has them.
+func (m *SessionManager) emitTurnEnded(sessionID string, entry *sessionEntry, te v2.TurnEnd) {
+	reason := workerv1.CancelReason(entry.cancelReason.Swap(int32(workerv1.CancelReason_CANCEL_REASON_UNSPECIFIED)))
+	ended := &workerv1.TurnEnded{StopReason: acpStopReasonToProto(te.StopReason)}
+	if te.StopReason == acp.StopReasonCancelled {
+		ended.CancelReason = reason
+		if te.TimedOut {
+			ended.CancelReason = workerv1.CancelReason_CANCEL_REASON_TIMEOUT
+		}
+	}
+	if stats := te.Stats; stats != nil {
+		ended.Stats = &workerv1.TurnStats{
+			DurationMs:         stats.Duration.Milliseconds(),
+			TimeToFirstTokenMs: stats.TimeToFirstToken.Milliseconds(),
+			ToolCalls:          int32(stats.ToolCalls),
+			InputTokens:        stats.Usage.InputTokens,
+			OutputTokens:       stats.Usage.OutputTokens,
+		}
+	}
+	event := &workerv1.SessionEvent{
+		SessionId: sessionID,
+		Sequence:  entry.nextSeq.Add(1),
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Payload:   &workerv1.SessionEvent_TurnEnded{TurnEnded: ended},
+	}
+	m.publishEvent(entry, event)
+}
+
+// acpStopReasonToProto maps an ACP stop reason to its proto enum. Reasons
+// newer than this worker map to STOP_REASON_UNSPECIFIED.
+func acpStopReasonToProto(r acp.StopReason) workerv1.StopReason {
+	switch r {
+	case acp.StopReasonEndTurn:
+		return workerv1.StopReason_STOP_REASON_END_TURN
+	case acp.StopReasonMaxTokens:
+		return workerv1.StopReason_STOP_REASON_MAX_TOKENS
+	case acp.StopReasonMaxTurnRequests:
+		return workerv1.StopReason_STOP_REASON_MAX_TURN_REQUESTS
+	case acp.StopReasonRefusal:
+		return workerv1.StopReason_STOP_REASON_REFUSAL
+	case acp.StopReasonCancelled:
+		return workerv1.StopReason_STOP_REASON_CANCELLED
+	default:
+		return workerv1.StopReason_STOP_REASON_UNSPECIFIED
 	}
+}
 
-	return e.session.Prompt(ctx, blocks)
+func acpPlanToProto(entries []acp.PlanEntry) *workerv1.AgentPlan {
+	p := &workerv1.AgentPlan{}
+	for _, e := range entries {
+		p.Entries = append(p.Entries, &workerv1.AgentPlanEntry{
+			Content:  e.Content,
+			Priority: string(e.Priority),
+			Status:   string(e.Status),
+		})
+	}
+	return p
 }
 
 // emitUserMessage creates and enqueues a user_message SessionEvent.
@@ -585,8 +1379,43 @@ func (m *SessionManager) emitUserMessage(sessionID string, entry *sessionEntry,
 			UserMessage: &workerv1.UserMessage{Text: text},
 		},
 	}
-	m.eventQueue.Append(sessionID, event)
-	m.notifyEventSubscribers(SessionEventUpdate{SessionID: sessionID, Event: event})
+	m.publishEvent(entry, event)
+}
+
+// emitPermissionEvent enqueues a PermissionRequest or PermissionResolved
+// SessionEvent so the transcript records the approval flow.
+func (m *SessionManager) emitPermissionEvent(sessionID string, entry *sessionEntry, e v2.PermissionEvent) {
+	event := &workerv1.SessionEvent{
+		SessionId: sessionID,
+		Sequence:  entry.nextSeq.Add(1),
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	if e.Resolved {
+		event.Payload = &workerv1.SessionEvent_PermissionResolved{
+			PermissionResolved: &workerv1.PermissionResolved{
+				RequestId: e.RequestID,
+				OptionId:  string(e.OptionID),
+				Outcome:   e.Outcome,
+			},
+		}
+	} else {
+		req := &workerv1.PermissionRequest{RequestId: e.RequestID}
+		if e.ToolCall.Title != nil {
+			req.Title = *e.ToolCall.Title
+		}
+		if e.ToolCall.Kind != nil {
+			req.Kind = acpToolKindToProto(*e.ToolCall.Kind)
+		}
+		for _, o := range e.Options {
+			req.Options = append(req.Options, &workerv1.PermissionOption{
+				OptionId: string(o.OptionId),
+				Name:     o.Name,
+				Kind:     string(o.Kind),
+			})
+		}
+		event.Payload = &workerv1.SessionEvent_PermissionRequest{PermissionRequest: req}
+	}
+	m.publishEvent(entry, event)
 }
 
 // extractTextFromBlocks concatenates text from ACP content blocks.
@@ -600,14 +1429,19 @@ func extractTextFromBlocks(blocks []acp.ContentBlock) string {
 	return strings.Join(parts, "\n")
 }
 
-// Cancel cancels the active prompt on a running session.
-func (m *SessionManager) Cancel(ctx context.Context, sessionID string) error {
+// Cancel cancels the active prompt on a running session. reason is reported
+// when the turn ends; unspecified counts as a user cancellation.
+func (m *SessionManager) Cancel(ctx context.Context, sessionID string, reason workerv1.CancelReason) error {
 	m.mu.RLock()
 	e, ok := m.sessions[sessionID]
 	m.mu.RUnlock()
 	if !ok {
 		return fmt.Errorf("session not found: %s", sessionID)
 	}
+	if reason == workerv1.CancelReason_CANCEL_REASON_UNSPECIFIED {
+		reason = workerv1.CancelReason_CANCEL_REASON_USER
+	}
+	e.cancelReason.Store(int32(reason))
 	return e.session.Cancel(ctx)
 }
 
@@ -618,9 +1452,11 @@ func (m *SessionManager) GetStateSnapshot() []SessionSnapshot {
 	entries := make([]SessionSnapshot, 0, len(m.sessions))
 	for id, e := range m.sessions {
 		entries = append(entries, SessionSnapshot{
-			SessionID: id,
-			Info:      e.session.Info(),
-			Topic:     e.topic,
+			SessionID:    id,
+			Info:         e.session.Info(),
+			Topic:        e.topic,
+			Labels:       e.labels,
+			LastSequence: e.nextSeq.Load(),
 		})
 	}
 	return entries
@@ -636,6 +1472,8 @@ func acpToolCallToProto(tc *acp.SessionUpdateToolCall) *workerv1.ToolCall {
 		RawInput:   formatRawField(tc.RawInput),
 		Status:     acpToolStatusToProto(tc.Status),
 		Content:    acpToolContentToProto(tc.Content),
+		Input:      toolInputToProto(tc.Meta, tc.RawInput),
+		Metadata:   driver.ToolMetadata(tc.Meta),
 	}
 	for _, loc := range tc.Locations {
 		pl := &workerv1.ToolCallLocation{Path: loc.Path}
@@ -647,11 +1485,31 @@ func acpToolCallToProto(tc *acp.SessionUpdateToolCall) *workerv1.ToolCall {
 	return p
 }
 
-func acpToolCallUpdateToProto(tc *acp.SessionToolCallUpdate) *workerv1.ToolCallUpdate {
+// defaultMaxRawOutput is the raw output kept per tool call update when the
+// worker config sets no limit.
+const defaultMaxRawOutput = 1 << 20
+
+// acpToolCallUpdateToProto cuts raw output to maxRawOutput bytes, recording
+// the full size and SHA-256; the control plane may store less.
+func acpToolCallUpdateToProto(tc *acp.SessionToolCallUpdate, maxRawOutput int) *workerv1.ToolCallUpdate {
 	p := &workerv1.ToolCallUpdate{
 		ToolCallId: string(tc.ToolCallId),
-		RawOutput:  formatRawField(tc.RawOutput),
+		RawOutput:  encodeRawField(tc.RawOutput),
 		Content:    acpToolContentToProto(tc.Content),
+		Input:      toolInputToProto(tc.Meta, tc.RawInput),
+		Metadata:   driver.ToolMetadata(tc.Meta),
+	}
+	if maxRawOutput == 0 {
+		maxRawOutput = defaultMaxRawOutput
+	}
+	if maxRawOutput > 0 && len(p.RawOutput) > maxRawOutput {
+		sum := sha256.Sum256([]byte(p.RawOutput))
+		p.RawOutputSize = int64(len(p.RawOutput))
+		p.RawOutputSha256 = hex.EncodeToString(sum[:])
+		p.RawOutput = cutText(p.RawOutput, maxRawOutput)
+	}
+	if out, ok := driver.ParseCommandOutput(tc.Meta); ok {
+		p.Content = append(p.Content, commandOutputToProto(out))
 	}
 	if tc.Title != nil {
 		p.Title = *tc.Title
@@ -705,6 +1563,79 @@ func acpToolStatusToProto(s acp.ToolCallStatus) workerv1.ToolCallStatus {
 	}
 }
 
+func commandOutputToProto(out driver.CommandOutput) *workerv1.ToolCallContentBlock {
+	co := &workerv1.ToolCallCommandOutput{
+		Stdout: out.Stdout,
+		Stderr: out.Stderr,
+	}
+	if out.ExitCode != nil {
+		code := int32(*out.ExitCode)
+		co.ExitCode = &code
+	}
+	return &workerv1.ToolCallContentBlock{
+		Block: &workerv1.ToolCallContentBlock_CommandOutput{CommandOutput: co},
+	}
+}
+
+// toolInputToProto parses the raw input of a well-known tool named in meta.
+// It returns nil for other tools, which only carry RawInput.
+func toolInputToProto(meta, rawInput any) *workerv1.ToolInput {
+	ti, ok := driver.ParseToolInput(driver.ToolNameFromMeta(meta), rawInput)
+	if !ok {
+		return nil
+	}
+	switch ti.Tool {
+	case "Read":
+		return &workerv1.ToolInput{Tool: &workerv1.ToolInput_Read{Read: &workerv1.ToolInputRead{
+			FilePath: ti.FilePath,
+			Offset:   optionalInt64(ti.Offset),
+			Limit:    optionalInt64(ti.Limit),
+		}}}
+	case "Write":
+		return &workerv1.ToolInput{Tool: &workerv1.ToolInput_Write{Write: &workerv1.ToolInputWrite{
+			FilePath: ti.FilePath,
+			Content:  ti.Content,
+		}}}
+	case "Edit":
+		return &workerv1.ToolInput{Tool: &workerv1.ToolInput_Edit{Edit: &workerv1.ToolInputEdit{
+			FilePath:   ti.FilePath,
+			OldString:  ti.OldString,
+			NewString:  ti.NewString,
+			ReplaceAll: ti.ReplaceAll,
+		}}}
+	case "Bash":
+		return &workerv1.ToolInput{Tool: &workerv1.ToolInput_Bash{Bash: &workerv1.ToolInputBash{
+			Command:         ti.Command,
+			Description:     ti.Description,
+			TimeoutMs:       optionalInt64(ti.TimeoutMs),
+			RunInBackground: ti.RunInBackground,
+		}}}
+	case "Grep":
+		return &workerv1.ToolInput{Tool: &workerv1.ToolInput_Grep{Grep: &workerv1.ToolInputGrep{
+			Pattern:         ti.Pattern,
+			Path:            ti.Path,
+			Glob:            ti.Glob,
+			Type:            ti.Type,
+			OutputMode:      ti.OutputMode,
+			CaseInsensitive: ti.CaseInsensitive,
+		}}}
+	case "Glob":
+		return &workerv1.ToolInput{Tool: &workerv1.ToolInput_Glob{Glob: &workerv1.ToolInputGlob{
+			Pattern: ti.Pattern,
+			Path:    ti.Path,
+		}}}
+	}
+	return nil
+}
+
+func optionalInt64(v *int) *int64 {
+	if v == nil {
+		return nil
+	}
+	n := int64(*v)
+	return &n
+}
+
 func acpToolContentToProto(content []acp.ToolCallContent) []*workerv1.ToolCallContentBlock {
 	if len(content) == 0 {
 		return nil
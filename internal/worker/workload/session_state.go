@@ -0,0 +1,175 @@
+package workload
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/sebastianm/flowgentic/internal/worker/driver"
+	v2 "github.com/sebastianm/flowgentic/internal/worker/driver/v2"
+	"github.com/sebastianm/flowgentic/internal/worker/metrics"
+)
+
+// exportedStateVersion is the version of the ExportState format.
+const exportedStateVersion = 1
+
+// exportedState is the JSON document written by ExportState.
+type exportedState struct {
+	Version  int               `json:"version"`
+	Sessions []SessionSnapshot `json:"sessions"`
+}
+
+// ErrSessionRestored is returned by the operations of a session restored by
+// ImportState, which has no agent running; relaunch it to use it again.
+var ErrSessionRestored = errors.New("session was restored without its agent; relaunch it")
+
+// ExportState serializes the session index: one SessionSnapshot per
+// session, ordered by ID. Ephemeral sessions are left out, and so are the
+// agents' stderr tails, which can hold credentials the agent printed and
+// must not end up on disk.
+func (m *SessionManager) ExportState() ([]byte, error) {
+	m.mu.RLock()
+	state := exportedState{Version: exportedStateVersion, Sessions: make([]SessionSnapshot, 0, len(m.sessions))}
+	for id, e := range m.sessions {
+		if e.ephemeral {
+			continue
+		}
+		state.Sessions = append(state.Sessions, SessionSnapshot{
+			SessionID:    id,
+			Info:         withoutStderr(e.session.Info()),
+			Topic:        e.topic,
+			Labels:       e.labels,
+			LastSequence: e.nextSeq.Load(),
+		})
+	}
+	m.mu.RUnlock()
+
+	slices.SortFunc(state.Sessions, func(a, b SessionSnapshot) int { return cmp.Compare(a.SessionID, b.SessionID) })
+	return json.Marshal(state)
+}
+
+// withoutStderr returns info with its stderr tails removed.
+func withoutStderr(info v2.SessionInfo) v2.SessionInfo {
+	info.StderrTail = nil
+	if info.Error != nil {
+		se := *info.Error
+		se.StderrTail = nil
+		info.Error = &se
+	}
+	return info
+}
+
+// ImportState restores the session index written by ExportState, e.g.
+// after a worker restart. Only the index comes back: restored sessions
+// have no agent running, report SessionStatusStopped unless they had
+// errored, and fail with ErrSessionRestored until they are relaunched
+// under the same ID. Sessions that already exist, and those of agents
+// without a driver, are skipped.
+func (m *SessionManager) ImportState(data []byte) error {
+	var state exportedState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return fmt.Errorf("decode session state: %w", err)
+	}
+	if state.Version != exportedStateVersion {
+		return fmt.Errorf("unsupported session state version %d", state.Version)
+	}
+
+	for _, snap := range state.Sessions {
+		d, ok := m.drivers[snap.Info.AgentID]
+		if !ok {
+			m.log.Warn("skipping restored session of unknown agent", "session_id", snap.SessionID, "agent", snap.Info.AgentID)
+			continue
+		}
+		info := snap.Info
+		if info.Status != v2.SessionStatusErrored {
+			info.Status = v2.SessionStatusStopped
+		}
+		entry := &sessionEntry{
+			session: &restoredSession{info: info},
+			driver:  d,
+			topic:   snap.Topic,
+			labels:  maps.Clone(snap.Labels),
+		}
+		entry.nextSeq.Store(snap.LastSequence)
+
+		m.mu.Lock()
+		_, exists := m.sessions[snap.SessionID]
+		if !exists {
+			m.sessions[snap.SessionID] = entry
+		}
+		m.mu.Unlock()
+		if exists {
+			m.log.Warn("skipping restored session that already exists", "session_id", snap.SessionID)
+			continue
+		}
+		m.metrics.Gauge(metrics.SessionsActive, 1, metrics.Labels{"agent": d.Agent()})
+
+		restored := SessionSnapshot{SessionID: snap.SessionID, Info: info, Topic: entry.topic, Labels: entry.labels}
+		m.notifySubscribers(StateEvent{Type: StateEventUpdate, SessionID: snap.SessionID, Snapshot: &restored})
+	}
+	return nil
+}
+
+// restoredEntry returns the entry of sessionID if ImportState restored it
+// and it has not been relaunched, and nil otherwise.
+func (m *SessionManager) restoredEntry(sessionID string) *sessionEntry {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, ok := m.sessions[sessionID]
+	if !ok {
+		return nil
+	}
+	if _, restored := e.session.(*restoredSession); !restored {
+		return nil
+	}
+	return e
+}
+
+// restoredSession stands in for the agent of a session restored by
+// ImportState. It only reports the exported info.
+type restoredSession struct {
+	info v2.SessionInfo
+}
+
+var _ v2.Session = (*restoredSession)(nil)
+
+func (s *restoredSession) Info() v2.SessionInfo { return s.info }
+
+func (s *restoredSession) Prompt(context.Context, []acp.ContentBlock) (*acp.PromptResponse, error) {
+	return nil, ErrSessionRestored
+}
+
+func (s *restoredSession) Cancel(context.Context) error { return ErrSessionRestored }
+
+func (s *restoredSession) SoftStop(context.Context) error { return ErrSessionRestored }
+
+// Stop and Wait succeed, so restored sessions can be removed.
+func (s *restoredSession) Stop(context.Context) error { return nil }
+func (s *restoredSession) Wait(context.Context) error { return nil }
+
+func (s *restoredSession) RespondToPermission(context.Context, string, bool, string) error {
+	return ErrSessionRestored
+}
+
+func (s *restoredSession) SetSessionMode(context.Context, driver.SessionMode) error {
+	return ErrSessionRestored
+}
+
+func (s *restoredSession) SetModel(context.Context, string) error { return ErrSessionRestored }
+
+func (s *restoredSession) AddMCPServer(context.Context, acp.McpServer) error {
+	return ErrSessionRestored
+}
+
+func (s *restoredSession) SetAllowedTools(context.Context, []string) error {
+	return ErrSessionRestored
+}
+
+func (s *restoredSession) SetHostCommands(context.Context, []acp.AvailableCommand) error {
+	return ErrSessionRestored
+}
@@ -0,0 +1,144 @@
+package workload
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	acp "github.com/coder/acp-go-sdk"
+	"github.com/sebastianm/flowgentic/internal/worker/driver"
+	v2 "github.com/sebastianm/flowgentic/internal/worker/driver/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSessionSnapshot_JSONRoundTrip(t *testing.T) {
+	snap := SessionSnapshot{
+		SessionID: "sess-1",
+		Info: v2.SessionInfo{
+			ID:             "sess-1",
+			AgentID:        "claude-code",
+			AgentSessionID: "agent-1",
+			Status:         v2.SessionStatusErrored,
+			Cwd:            "/work",
+			StartedAt:      time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+			Modes:          []v2.SessionModeInfo{{ID: "plan", Name: "Plan"}},
+			CurrentMode:    "plan",
+			Models:         []string{"opus", "sonnet"},
+			CurrentModel:   "opus",
+			Error:          &v2.SessionError{Reason: v2.ErrorReasonAuth, Message: "token expired", StderrTail: []string{"401"}},
+			LastStopReason: acp.StopReasonEndTurn,
+		},
+		Topic:        "Fix the login bug",
+		Labels:       map[string]string{"team": "web"},
+		LastSequence: 42,
+	}
+
+	data, err := json.Marshal(snap)
+	require.NoError(t, err)
+	var got SessionSnapshot
+	require.NoError(t, json.Unmarshal(data, &got))
+	assert.Equal(t, snap, got)
+}
+
+func TestSessionManager_ExportImportState(t *testing.T) {
+	ctx := context.Background()
+	d := newFakeDriver("test-agent")
+	sess := newFakeSession("sess-1", "test-agent")
+	sess.info.StartedAt = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	sess.info.CurrentModel = "test-model"
+	sess.info.CurrentMode = "plan"
+	sess.info.StderrTail = []string{"using token sk-secret"}
+	d.launchSess = sess
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	_, err := m.Launch(ctx, "sess-1", "test-agent", v2.LaunchOpts{Labels: map[string]string{"team": "web"}}, nil)
+	require.NoError(t, err)
+	require.NoError(t, m.HandleSetTopic(ctx, "sess-1", "Fix the login bug"))
+	_, err = m.Launch(ctx, "sess-eph", "test-agent", v2.LaunchOpts{Ephemeral: true}, nil)
+	require.NoError(t, err)
+
+	data, err := m.ExportState()
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "sk-secret", "stderr tails are not exported")
+
+	restoredDriver := newFakeDriver("test-agent", driver.CapSessionResume)
+	r := NewSessionManager(testLogger(), "", "", nil, restoredDriver)
+	stateCh := r.Subscribe()
+	defer r.Unsubscribe(stateCh)
+	require.NoError(t, r.ImportState(data))
+
+	select {
+	case ev := <-stateCh:
+		assert.Equal(t, StateEventUpdate, ev.Type)
+		assert.Equal(t, "sess-1", ev.SessionID)
+	case <-time.After(time.Second):
+		t.Fatal("no state event for the restored session")
+	}
+	entries := r.ListSessions(LabelSelector{})
+	require.Len(t, entries, 1, "ephemeral sessions are not exported")
+	assert.Equal(t, map[string]string{"team": "web"}, entries[0].Labels)
+	restored, ok := r.GetSession("sess-1")
+	require.True(t, ok)
+	info := restored.Info()
+	assert.Equal(t, v2.SessionStatusStopped, info.Status, "restored sessions have no running agent")
+	assert.Equal(t, "test-model", info.CurrentModel)
+	assert.Equal(t, "plan", info.CurrentMode)
+	_, err = r.Prompt(ctx, "sess-1", []acp.ContentBlock{acp.TextBlock("hi")})
+	assert.ErrorIs(t, err, ErrSessionRestored)
+
+	// Exporting the restored index gives back the original one, apart from
+	// the status.
+	again, err := r.ExportState()
+	require.NoError(t, err)
+	var before, after exportedState
+	require.NoError(t, json.Unmarshal(data, &before))
+	require.NoError(t, json.Unmarshal(again, &after))
+	require.Len(t, before.Sessions, 1)
+	require.Greater(t, before.Sessions[0].LastSequence, int64(0))
+	before.Sessions[0].Info.Status = v2.SessionStatusStopped
+	assert.Equal(t, before, after)
+
+	// Relaunching the session continues its event sequence.
+	eventCh := r.SubscribeEvents()
+	defer r.UnsubscribeEvents(eventCh)
+	_, err = r.Launch(ctx, "sess-1", "test-agent", v2.LaunchOpts{ResumeSessionID: "sess-1"}, nil)
+	require.NoError(t, err)
+	select {
+	case u := <-eventCh:
+		assert.Equal(t, before.Sessions[0].LastSequence+1, u.Event.GetSequence())
+	case <-time.After(time.Second):
+		t.Fatal("no event from the relaunched session")
+	}
+	relaunched, ok := r.GetSession("sess-1")
+	require.True(t, ok)
+	assert.Equal(t, v2.SessionStatusRunning, relaunched.Info().Status)
+}
+
+func TestSessionManager_ImportStateSkipsUnusableSessions(t *testing.T) {
+	m := NewSessionManager(testLogger(), "", "", nil, newFakeDriver("test-agent"))
+	_, err := m.Launch(context.Background(), "live", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+
+	data, err := json.Marshal(exportedState{Version: exportedStateVersion, Sessions: []SessionSnapshot{
+		{SessionID: "live", Info: v2.SessionInfo{AgentID: "test-agent", Status: v2.SessionStatusIdle}},
+		{SessionID: "gone", Info: v2.SessionInfo{AgentID: "removed-agent"}},
+		{SessionID: "failed", Info: v2.SessionInfo{AgentID: "test-agent", Status: v2.SessionStatusErrored, Error: &v2.SessionError{Message: "boom"}}},
+	}})
+	require.NoError(t, err)
+	require.NoError(t, m.ImportState(data))
+
+	live, ok := m.GetSession("live")
+	require.True(t, ok)
+	assert.NotErrorIs(t, live.SetModel(context.Background(), "x"), ErrSessionRestored, "live sessions are kept")
+	_, ok = m.GetSession("gone")
+	assert.False(t, ok)
+	failed, ok := m.GetSession("failed")
+	require.True(t, ok)
+	assert.Equal(t, v2.SessionStatusErrored, failed.Info().Status)
+	assert.Equal(t, "boom", failed.Info().Error.Message)
+	assert.NoError(t, m.StopSession(context.Background(), "failed", true), "restored sessions can be removed")
+
+	assert.ErrorContains(t, m.ImportState([]byte(`{"version":2}`)), "unsupported session state version 2")
+	assert.Error(t, m.ImportState([]byte(`not json`)))
+}
@@ -0,0 +1,41 @@
+package workload
+
+import (
+	"slices"
+
+	v2 "github.com/sebastianm/flowgentic/internal/worker/driver/v2"
+)
+
+// ToolPolicy holds operator-configured tool restrictions for an agent. They
+// are merged with the adapter's built-in lists and with any per-request
+// values, never replacing them.
+type ToolPolicy struct {
+	DisallowedTools      []string
+	PlanModeAllowedTools []string
+	KindPermissions      map[string]string // a kind the request sets keeps its value
+}
+
+// apply merges p into opts.
+func (p ToolPolicy) apply(opts *v2.LaunchOpts) {
+	opts.DisallowedTools = appendMissing(opts.DisallowedTools, p.DisallowedTools)
+	opts.PlanModeAllowedTools = appendMissing(opts.PlanModeAllowedTools, p.PlanModeAllowedTools)
+	for kind, perm := range p.KindPermissions {
+		if _, ok := opts.ToolKindPermissions[kind]; ok {
+			continue
+		}
+		if opts.ToolKindPermissions == nil {
+			opts.ToolKindPermissions = make(map[string]string, len(p.KindPermissions))
+		}
+		opts.ToolKindPermissions[kind] = perm
+	}
+}
+
+// appendMissing appends the entries of add that dst does not contain yet.
+func appendMissing(dst, add []string) []string {
+	for _, s := range add {
+		if !slices.Contains(dst, s) {
+			dst = append(dst, s)
+		}
+	}
+	return dst
+}
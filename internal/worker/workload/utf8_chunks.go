@@ -0,0 +1,65 @@
+package workload
+
+import (
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// utf8Stream joins the text chunks of one streamed message so that every
+// chunk passed on is valid UTF-8 by itself: an agent may split a multibyte
+// sequence across two deltas, and proto strings must be valid UTF-8. All
+// methods are safe for concurrent use.
+type utf8Stream struct {
+	mu      sync.Mutex
+	pending string // incomplete sequence held back from the last chunk
+}
+
+// next returns chunk prefixed with the bytes held back from the previous
+// one, holding back its own trailing incomplete sequence until the chunk
+// completing it arrives. Bytes that cannot form a rune are replaced with
+// U+FFFD. The result is empty if chunk only started a rune.
+func (s *utf8Stream) next(chunk string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	text := s.pending + chunk
+	cut := incompleteSuffix(text)
+	text, s.pending = text[:cut], text[cut:]
+	return strings.ToValidUTF8(text, string(utf8.RuneError))
+}
+
+// incompleteSuffix returns where the multibyte sequence text ends in
+// starts, if later bytes can still complete it, and len(text) otherwise.
+func incompleteSuffix(text string) int {
+	for i := len(text) - 1; i >= 0 && i > len(text)-utf8.UTFMax; i-- {
+		if !utf8.RuneStart(text[i]) {
+			continue
+		}
+		if utf8.FullRuneInString(text[i:]) {
+			break
+		}
+		return i
+	}
+	return len(text)
+}
+
+// truncateText shortens text to at most n bytes without splitting a rune,
+// appending "..." if it was cut.
+func truncateText(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	return cutText(text, n) + "..."
+}
+
+// cutText returns at most n bytes of text without splitting a rune.
+func cutText(text string, n int) string {
+	if len(text) <= n {
+		return text
+	}
+	for n > 0 && !utf8.RuneStart(text[n]) {
+		n--
+	}
+	return text[:n]
+}
@@ -0,0 +1,55 @@
+package workload
+
+import (
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUTF8Stream(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []string
+	}{
+		{
+			name:   "complete chunks pass through",
+			chunks: []string{"héllo ", "wörld"},
+			want:   []string{"héllo ", "wörld"},
+		},
+		{
+			name:   "rune split across two chunks",
+			chunks: []string{"price: \xe2\x82", "\xac5"},
+			want:   []string{"price: ", "€5"},
+		},
+		{
+			name:   "chunk holding only the start of a rune",
+			chunks: []string{"a", "\xf0\x9f", "\x98", "\x80b"},
+			want:   []string{"a", "", "", "😀b"},
+		},
+		{
+			name:   "sequence that is never completed",
+			chunks: []string{"x\xe2\x82", "y"},
+			want:   []string{"x", "�y"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var s utf8Stream
+			var got []string
+			for _, c := range tt.chunks {
+				out := s.next(c)
+				assert.True(t, utf8.ValidString(out), "chunk %q", out)
+				got = append(got, out)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestTruncateText(t *testing.T) {
+	assert.Equal(t, "short", truncateText("short", 10))
+	assert.Equal(t, "ab...", truncateText("ab€cd", 4), "the cut backs off to a rune boundary")
+	assert.Equal(t, "ab€...", truncateText("ab€cd", 5))
+}
@@ -2,11 +2,14 @@ package workload
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"log/slog"
 
 	"connectrpc.com/connect"
 	acp "github.com/coder/acp-go-sdk"
+	"github.com/sebastianm/flowgentic/internal/promptutil"
 	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
 	"github.com/sebastianm/flowgentic/internal/worker/driver"
 	v2 "github.com/sebastianm/flowgentic/internal/worker/driver/v2"
@@ -14,8 +17,10 @@ import (
 
 // workerServiceHandler implements workerv1connect.WorkerServiceHandler.
 type workerServiceHandler struct {
-	log *slog.Logger
-	svc *WorkloadService
+	log                *slog.Logger
+	svc                *WorkloadService
+	maxPromptBytes     int
+	maxAttachmentBytes int
 }
 
 var statusToProto = map[v2.SessionStatus]workerv1.SessionStatus{
@@ -28,9 +33,13 @@ var statusToProto = map[v2.SessionStatus]workerv1.SessionStatus{
 
 func (h *workerServiceHandler) ListSessions(
 	ctx context.Context,
-	_ *connect.Request[workerv1.ListSessionsRequest],
+	req *connect.Request[workerv1.ListSessionsRequest],
 ) (*connect.Response[workerv1.ListSessionsResponse], error) {
-	entries := h.svc.ListSessions(ctx)
+	sel, err := ParseLabelSelector(req.Msg.LabelSelector)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	entries := h.svc.ListSessions(ctx, sel)
 	sessions := make([]*workerv1.SessionInfo, 0, len(entries))
 	for _, e := range entries {
 		sessions = append(sessions, &workerv1.SessionInfo{
@@ -40,6 +49,9 @@ func (h *workerServiceHandler) ListSessions(
 			Mode:           workerv1.SessionMode_SESSION_MODE_HEADLESS,
 			AgentSessionId: e.Info.AgentSessionID,
 			Model:          e.Info.CurrentModel,
+			Labels:         e.Labels,
+			LastStopReason: acpStopReasonToProto(e.Info.LastStopReason),
+			QueuedEvents:   int64(e.QueueDepth),
 		})
 	}
 	return connect.NewResponse(&workerv1.ListSessionsResponse{
@@ -61,7 +73,7 @@ func (h *workerServiceHandler) StateSync(
 	}
 	if err := stream.Send(&workerv1.StateSyncResponse{
 		Update: &workerv1.StateSyncResponse_Snapshot{
-			Snapshot: &workerv1.SessionStateSnapshot{Sessions: states},
+			Snapshot: &workerv1.SessionStateSnapshot{Sessions: states, InstanceId: h.svc.InstanceID()},
 		},
 	}); err != nil {
 		h.log.Error("StateSync snapshot send failed", "error", err)
@@ -69,6 +81,14 @@ func (h *workerServiceHandler) StateSync(
 	}
 	h.log.Info("StateSync snapshot sent", "sessions", len(states))
 
+	// Subscribe before replaying the queue so no event falls between the
+	// two; the control plane drops the duplicates this can send.
+	stateCh := h.svc.Subscribe()
+	defer h.svc.Unsubscribe(stateCh)
+
+	eventCh := h.svc.SubscribeEvents()
+	defer h.svc.UnsubscribeEvents(eventCh)
+
 	// Send pending events from queue for all sessions.
 	allPending := h.svc.AllPendingEvents()
 	for _, events := range allPending {
@@ -83,13 +103,6 @@ func (h *workerServiceHandler) StateSync(
 		}
 	}
 
-	// Subscribe to state changes and events.
-	stateCh := h.svc.Subscribe()
-	defer h.svc.Unsubscribe(stateCh)
-
-	eventCh := h.svc.SubscribeEvents()
-	defer h.svc.UnsubscribeEvents(eventCh)
-
 	// Handle incoming ACKs from CP in a background goroutine.
 	ackDone := make(chan struct{})
 	go func() {
@@ -111,7 +124,10 @@ func (h *workerServiceHandler) StateSync(
 			return nil
 		case <-ackDone:
 			return nil
-		case event := <-stateCh:
+		case event, ok := <-stateCh:
+			if !ok {
+				return nil // worker shutting down
+			}
 			switch event.Type {
 			case StateEventUpdate:
 				if err := stream.Send(&workerv1.StateSyncResponse{
@@ -126,14 +142,17 @@ func (h *workerServiceHandler) StateSync(
 					Update: &workerv1.StateSyncResponse_SessionRemoved{
 						SessionRemoved: &workerv1.SessionRemoved{
 							SessionId:   event.SessionID,
-							FinalStatus: "completed",
+							FinalStatus: string(event.FinalStatus),
 						},
 					},
 				}); err != nil {
 					return err
 				}
 			}
-		case evt := <-eventCh:
+		case evt, ok := <-eventCh:
+			if !ok {
+				return nil // worker shutting down
+			}
 			if err := stream.Send(&workerv1.StateSyncResponse{
 				Update: &workerv1.StateSyncResponse_SessionEvent{
 					SessionEvent: evt.Event,
@@ -153,38 +172,113 @@ func sessionSnapshotToProto(s SessionSnapshot) *workerv1.SessionState {
 		Mode:           workerv1.SessionMode_SESSION_MODE_HEADLESS,
 		AgentSessionId: s.Info.AgentSessionID,
 		Topic:          s.Topic,
+		Model:          s.Info.CurrentModel,
+		Labels:         s.Labels,
+		Error:          sessionErrorToProto(s.Info.Error),
+		Modes:          agentModesToProto(s.Info.Modes),
+		CurrentMode:    s.Info.CurrentMode,
+		StderrTail:     s.Info.StderrTail,
+		LastSequence:   s.LastSequence,
+	}
+}
+
+func agentModesToProto(modes []v2.SessionModeInfo) []*workerv1.AgentMode {
+	if len(modes) == 0 {
+		return nil
+	}
+	out := make([]*workerv1.AgentMode, len(modes))
+	for i, m := range modes {
+		out[i] = &workerv1.AgentMode{Id: m.ID, Name: m.Name, Description: m.Description}
 	}
+	return out
 }
 
 func (h *workerServiceHandler) SetSessionMode(
 	ctx context.Context,
 	req *connect.Request[workerv1.SetSessionModeRequest],
 ) (*connect.Response[workerv1.SetSessionModeResponse], error) {
-	mode, err := driver.ParseSessionMode(req.Msg.ModeId)
-	if err != nil {
-		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	if err := h.svc.SetSessionMode(ctx, req.Msg.SessionId, driver.SessionMode(req.Msg.ModeId)); err != nil {
+		return nil, sessionModeError(err)
+	}
+
+	return connect.NewResponse(&workerv1.SetSessionModeResponse{}), nil
+}
+
+// sessionModeError reports modes the session does not offer as invalid
+// arguments.
+func sessionModeError(err error) error {
+	if errors.Is(err, v2.ErrUnknownSessionMode) {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	return connect.NewError(connect.CodeInternal, err)
+}
+
+// promptError reports prompts the agent rejected, such as content blocks it
+// does not support, as invalid arguments.
+func promptError(err error) error {
+	if msg, ok := driver.InvalidPromptMessage(err); ok {
+		return connect.NewError(connect.CodeInvalidArgument, errors.New(msg))
 	}
+	return connect.NewError(connect.CodeInternal, err)
+}
 
-	if err := h.svc.SetSessionMode(ctx, req.Msg.SessionId, mode); err != nil {
+func (h *workerServiceHandler) SetAllowedTools(
+	ctx context.Context,
+	req *connect.Request[workerv1.SetAllowedToolsRequest],
+) (*connect.Response[workerv1.SetAllowedToolsResponse], error) {
+	if err := h.svc.SetAllowedTools(ctx, req.Msg.SessionId, req.Msg.Tools); err != nil {
+		if errors.Is(err, v2.ErrSetAllowedToolsUnsupported) {
+			return nil, connect.NewError(connect.CodeUnimplemented, err)
+		}
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
+	return connect.NewResponse(&workerv1.SetAllowedToolsResponse{}), nil
+}
 
-	return connect.NewResponse(&workerv1.SetSessionModeResponse{}), nil
+func (h *workerServiceHandler) SetHostCommands(
+	ctx context.Context,
+	req *connect.Request[workerv1.SetHostCommandsRequest],
+) (*connect.Response[workerv1.SetHostCommandsResponse], error) {
+	cmds := make([]acp.AvailableCommand, 0, len(req.Msg.Commands))
+	for _, c := range req.Msg.Commands {
+		cmd := acp.AvailableCommand{Name: c.Name, Description: c.Description}
+		if c.InputHint != "" {
+			cmd.Input = &acp.AvailableCommandInput{
+				UnstructuredCommandInput: &acp.AvailableCommandUnstructuredCommandInput{Hint: c.InputHint},
+			}
+		}
+		cmds = append(cmds, cmd)
+	}
+	if err := h.svc.SetHostCommands(ctx, req.Msg.SessionId, cmds); err != nil {
+		return nil, connect.NewError(connect.CodeInternal, err)
+	}
+	return connect.NewResponse(&workerv1.SetHostCommandsResponse{}), nil
+}
+
+func (h *workerServiceHandler) GetPendingEvents(
+	_ context.Context,
+	req *connect.Request[workerv1.GetPendingEventsRequest],
+) (*connect.Response[workerv1.GetPendingEventsResponse], error) {
+	events := h.svc.PendingEvents(req.Msg.SessionId, req.Msg.AfterSequence)
+	return connect.NewResponse(&workerv1.GetPendingEventsResponse{Events: events}), nil
 }
 
 func (h *workerServiceHandler) SendUserMessage(
 	ctx context.Context,
 	req *connect.Request[workerv1.SendUserMessageRequest],
 ) (*connect.Response[workerv1.SendUserMessageResponse], error) {
-	// Convert proto ContentBlocks to ACP ContentBlocks.
-	var blocks []acp.ContentBlock
-	for _, b := range req.Msg.ContentBlocks {
-		blocks = append(blocks, acp.TextBlock(b.Text))
+	if err := h.validatePrompt(req.Msg.ContentBlocks); err != nil {
+		return nil, err
+	}
+
+	blocks, err := protoContentBlocksToACP(req.Msg.ContentBlocks)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
 	}
 
 	resp, err := h.svc.Prompt(ctx, req.Msg.SessionId, blocks)
 	if err != nil {
-		return nil, connect.NewError(connect.CodeInternal, err)
+		return nil, promptError(err)
 	}
 
 	return connect.NewResponse(&workerv1.SendUserMessageResponse{
@@ -192,11 +286,134 @@ func (h *workerServiceHandler) SendUserMessage(
 	}), nil
 }
 
+func (h *workerServiceHandler) Prompt(
+	ctx context.Context,
+	req *connect.Request[workerv1.PromptRequest],
+) (*connect.Response[workerv1.PromptResponse], error) {
+	msg := req.Msg
+	if err := h.validatePrompt(msg.ContentBlocks); err != nil {
+		return nil, err
+	}
+	blocks, err := protoContentBlocksToACP(msg.ContentBlocks)
+	if err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
+
+	// Per-prompt overrides apply to this turn only: the session's previous
+	// mode and model are restored once it ends, even if it fails.
+	prev, _ := h.svc.SessionInfo(msg.SessionId)
+	if msg.SessionMode != "" && msg.SessionMode != prev.CurrentMode {
+		if err := h.svc.SetSessionMode(ctx, msg.SessionId, driver.SessionMode(msg.SessionMode)); err != nil {
+			return nil, sessionModeError(err)
+		}
+		defer h.restoreSessionMode(ctx, msg.SessionId, prev.CurrentMode)
+	}
+	if msg.Model != "" && msg.Model != prev.CurrentModel {
+		if err := h.svc.SetSessionModel(ctx, msg.SessionId, msg.Model); err != nil {
+			return nil, connect.NewError(connect.CodeInternal, err)
+		}
+		defer h.restoreSessionModel(ctx, msg.SessionId, prev.CurrentModel)
+	}
+
+	resp, err := h.svc.Prompt(ctx, msg.SessionId, blocks)
+	if err != nil {
+		return nil, promptError(err)
+	}
+
+	return connect.NewResponse(&workerv1.PromptResponse{
+		StopReason: string(resp.StopReason),
+	}), nil
+}
+
+// restoreSessionMode switches a session back to mode after a per-prompt
+// override. It runs even if ctx has ended, since the turn it was made for
+// has. Sessions whose agent reports no mode carry their launch mode, so
+// mode is empty only for sessions that never had one.
+func (h *workerServiceHandler) restoreSessionMode(ctx context.Context, sessionID, mode string) {
+	if mode == "" {
+		return
+	}
+	if err := h.svc.SetSessionMode(context.WithoutCancel(ctx), sessionID, driver.SessionMode(mode)); err != nil {
+		h.log.Warn("restore session mode failed", "session_id", sessionID, "mode", mode, "error", err)
+	}
+}
+
+// restoreSessionModel is restoreSessionMode for the model override.
+func (h *workerServiceHandler) restoreSessionModel(ctx context.Context, sessionID, model string) {
+	if model == "" {
+		return
+	}
+	if err := h.svc.SetSessionModel(context.WithoutCancel(ctx), sessionID, model); err != nil {
+		h.log.Warn("restore session model failed", "session_id", sessionID, "model", model, "error", err)
+	}
+}
+
+// validatePrompt rejects prompts over the size limits or with text that
+// would break the agent's JSON-RPC framing. Attachment data has its own
+// limit.
+func (h *workerServiceHandler) validatePrompt(blocks []*workerv1.ContentBlock) error {
+	texts := make([]string, 0, len(blocks))
+	data := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		texts = append(texts, b.Text)
+		data = append(data, b.Data)
+	}
+	if err := promptutil.Validate(h.maxPromptBytes, texts...); err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	if err := promptutil.ValidateAttachments(h.maxAttachmentBytes, data...); err != nil {
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	}
+	return nil
+}
+
+// protoContentBlocksToACP converts prompt content blocks. An empty type is
+// treated as text. A "resource" block embeds its data as a binary resource
+// if set and its text otherwise.
+func protoContentBlocksToACP(blocks []*workerv1.ContentBlock) ([]acp.ContentBlock, error) {
+	out := make([]acp.ContentBlock, 0, len(blocks))
+	for i, b := range blocks {
+		switch b.Type {
+		case "", "text":
+			out = append(out, acp.TextBlock(b.Text))
+		case "image":
+			if b.Data == "" || b.MimeType == "" {
+				return nil, fmt.Errorf("content block %d: image needs data and mime_type", i)
+			}
+			if _, err := base64.StdEncoding.DecodeString(b.Data); err != nil {
+				return nil, fmt.Errorf("content block %d: image data is not base64: %w", i, err)
+			}
+			out = append(out, acp.ImageBlock(b.Data, b.MimeType))
+		case "resource":
+			if b.Uri == "" {
+				return nil, fmt.Errorf("content block %d: resource needs a uri", i)
+			}
+			var mimeType *string
+			if b.MimeType != "" {
+				mimeType = &b.MimeType
+			}
+			var res acp.EmbeddedResourceResource
+			if b.Data != "" {
+				if _, err := base64.StdEncoding.DecodeString(b.Data); err != nil {
+					return nil, fmt.Errorf("content block %d: resource data is not base64: %w", i, err)
+				}
+				res.BlobResourceContents = &acp.BlobResourceContents{Uri: b.Uri, Blob: b.Data, MimeType: mimeType}
+			} else {
+				res.TextResourceContents = &acp.TextResourceContents{Uri: b.Uri, Text: b.Text, MimeType: mimeType}
+			}
+			out = append(out, acp.ResourceBlock(res))
+		default:
+			return nil, fmt.Errorf("content block %d: unsupported type %q", i, b.Type)
+		}
+	}
+	return out, nil
+}
+
 func (h *workerServiceHandler) CancelSession(
 	ctx context.Context,
 	req *connect.Request[workerv1.CancelSessionRequest],
 ) (*connect.Response[workerv1.CancelSessionResponse], error) {
-	if err := h.svc.Cancel(ctx, req.Msg.SessionId); err != nil {
+	if err := h.svc.Cancel(ctx, req.Msg.SessionId, req.Msg.Reason); err != nil {
 		return nil, connect.NewError(connect.CodeInternal, err)
 	}
 	return connect.NewResponse(&workerv1.CancelSessionResponse{}), nil
@@ -235,15 +452,28 @@ func (h *workerServiceHandler) NewSession(
 	if msg.Mode != "" && msg.Mode != "headless" {
 		return nil, connect.NewError(connect.CodeInvalidArgument, fmt.Errorf("unsupported mode %q: only headless mode is supported", msg.Mode))
 	}
+	if err := promptutil.Validate(h.maxPromptBytes, msg.Prompt); err != nil {
+		return nil, connect.NewError(connect.CodeInvalidArgument, err)
+	}
 
 	opts := v2.LaunchOpts{
-		Prompt:          msg.Prompt,
-		SystemPrompt:    msg.SystemPrompt,
-		Model:           msg.Model,
-		Cwd:             msg.Cwd,
-		ResumeSessionID: msg.AgentSessionId,
-		SessionMode:     msg.SessionMode,
-		AllowedTools:    msg.AllowedTools,
+		Prompt:               msg.Prompt,
+		SystemPrompt:         msg.SystemPrompt,
+		Model:                msg.Model,
+		Cwd:                  msg.Cwd,
+		ResumeSessionID:      msg.AgentSessionId,
+		SessionMode:          msg.SessionMode,
+		ReasoningEffort:      msg.ReasoningEffort,
+		AllowedTools:         msg.AllowedTools,
+		RequiredCapabilities: msg.RequiredCapabilities,
+		Labels:               msg.Labels,
+		AutoTopic:            msg.AutoTopic,
+		Ephemeral:            msg.Ephemeral,
+		AutoContinue: v2.AutoContinue{
+			Prompt:        msg.GetAutoContinue().GetPrompt(),
+			MaxIterations: int(msg.GetAutoContinue().GetMaxIterations()),
+			StopPhrase:    msg.GetAutoContinue().GetStopPhrase(),
+		},
 	}
 
 	result, err := h.svc.Schedule(ctx, msg.SessionId, string(agentType), opts)
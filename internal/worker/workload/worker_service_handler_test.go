@@ -0,0 +1,188 @@
+package workload
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+	acp "github.com/coder/acp-go-sdk"
+	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
+	"github.com/sebastianm/flowgentic/internal/worker/driver"
+	v2 "github.com/sebastianm/flowgentic/internal/worker/driver/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkerServiceHandler_RejectsInvalidPrompts(t *testing.T) {
+	// Validation runs before the service is used.
+	h := &workerServiceHandler{log: testLogger(), maxPromptBytes: 16, maxAttachmentBytes: 32}
+	ctx := context.Background()
+	oversized := []*workerv1.ContentBlock{{Text: strings.Repeat("x", 10)}, {Text: strings.Repeat("y", 10)}}
+	nullByte := []*workerv1.ContentBlock{{Text: "a\x00b"}}
+
+	_, err := h.Prompt(ctx, connect.NewRequest(&workerv1.PromptRequest{SessionId: "s1", ContentBlocks: oversized}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	assert.ErrorContains(t, err, "over the limit of 16")
+
+	image := []*workerv1.ContentBlock{{Type: "image", MimeType: "image/png", Data: strings.Repeat("A", 33)}}
+	_, err = h.SendUserMessage(ctx, connect.NewRequest(&workerv1.SendUserMessageRequest{SessionId: "s1", ContentBlocks: image}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	assert.ErrorContains(t, err, "attachments are 33 bytes, over the limit of 32")
+	image[0].Data = strings.Repeat("A", 32)
+	assert.NoError(t, h.validatePrompt(image), "attachments do not count toward the prompt limit")
+
+	_, err = h.SendUserMessage(ctx, connect.NewRequest(&workerv1.SendUserMessageRequest{SessionId: "s1", ContentBlocks: nullByte}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	assert.ErrorContains(t, err, "control character")
+
+	_, err = h.NewSession(ctx, connect.NewRequest(&workerv1.NewSessionRequest{
+		SessionId: "s1",
+		Agent:     workerv1.Agent_AGENT_CLAUDE_CODE,
+		Prompt:    "bad\xff",
+	}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	assert.ErrorContains(t, err, "not valid UTF-8")
+}
+
+func TestWorkerServiceHandler_SendUserMessageContentBlocks(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	d.launchSess = newFakeSession("sess-blocks", "test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	_, err := m.Launch(context.Background(), "sess-blocks", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+	h := &workerServiceHandler{log: testLogger(), svc: &WorkloadService{mgr: m}}
+
+	_, err = h.SendUserMessage(context.Background(), connect.NewRequest(&workerv1.SendUserMessageRequest{
+		SessionId: "sess-blocks",
+		ContentBlocks: []*workerv1.ContentBlock{
+			{Type: "text", Text: "What is in these?"},
+			{Type: "image", MimeType: "image/png", Data: "iVBORw0K"},
+			{Type: "resource", Uri: "file:///repo/notes.md", MimeType: "text/markdown", Text: "# Notes"},
+			{Type: "resource", Uri: "file:///repo/logo.bin", Data: "AAEC"},
+		},
+	}))
+	require.NoError(t, err)
+
+	markdown := "text/markdown"
+	require.Len(t, d.launchSess.prompts, 1)
+	assert.Equal(t, []acp.ContentBlock{
+		acp.TextBlock("What is in these?"),
+		acp.ImageBlock("iVBORw0K", "image/png"),
+		acp.ResourceBlock(acp.EmbeddedResourceResource{TextResourceContents: &acp.TextResourceContents{Uri: "file:///repo/notes.md", MimeType: &markdown, Text: "# Notes"}}),
+		acp.ResourceBlock(acp.EmbeddedResourceResource{BlobResourceContents: &acp.BlobResourceContents{Uri: "file:///repo/logo.bin", Blob: "AAEC"}}),
+	}, d.launchSess.prompts[0])
+
+	for _, tc := range []struct {
+		block *workerv1.ContentBlock
+		err   string
+	}{
+		{&workerv1.ContentBlock{Type: "image", Data: "iVBORw0K"}, "image needs data and mime_type"},
+		{&workerv1.ContentBlock{Type: "image", MimeType: "image/png", Data: "not base64!"}, "not base64"},
+		{&workerv1.ContentBlock{Type: "resource", Text: "orphan"}, "resource needs a uri"},
+		{&workerv1.ContentBlock{Type: "audio"}, `unsupported type "audio"`},
+	} {
+		_, err := h.SendUserMessage(context.Background(), connect.NewRequest(&workerv1.SendUserMessageRequest{
+			SessionId:     "sess-blocks",
+			ContentBlocks: []*workerv1.ContentBlock{tc.block},
+		}))
+		assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err), tc.err)
+		assert.ErrorContains(t, err, tc.err)
+	}
+	assert.Len(t, d.launchSess.prompts, 1, "rejected messages do not reach the session")
+}
+
+func TestWorkerServiceHandler_PromptRejectedByAgent(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	d.launchSess = newFakeSession("sess-rejected", "test-agent")
+	d.launchSess.promptErr = driver.NewInvalidPromptError("content block 0: unsupported by codex")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	_, err := m.Launch(context.Background(), "sess-rejected", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+	h := &workerServiceHandler{log: testLogger(), svc: &WorkloadService{mgr: m}}
+
+	blocks := []*workerv1.ContentBlock{{Type: "image", MimeType: "image/png", Data: "iVBORw0K"}}
+	_, err = h.SendUserMessage(context.Background(), connect.NewRequest(&workerv1.SendUserMessageRequest{
+		SessionId:     "sess-rejected",
+		ContentBlocks: blocks,
+	}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+	assert.ErrorContains(t, err, "unsupported by codex")
+
+	_, err = h.Prompt(context.Background(), connect.NewRequest(&workerv1.PromptRequest{
+		SessionId:     "sess-rejected",
+		ContentBlocks: blocks,
+	}))
+	assert.Equal(t, connect.CodeInvalidArgument, connect.CodeOf(err))
+}
+
+func TestWorkerServiceHandler_PromptOverridesLastOneTurn(t *testing.T) {
+	d := newFakeDriver("test-agent", driver.CapCustomModel)
+	d.launchSess = newFakeSession("sess-override", "test-agent")
+	d.launchSess.info.CurrentModel = "sonnet"
+	d.launchSess.info.CurrentMode = "code"
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	_, err := m.Launch(context.Background(), "sess-override", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+	h := &workerServiceHandler{log: testLogger(), svc: &WorkloadService{mgr: m}}
+	blocks := []*workerv1.ContentBlock{{Text: "hi"}}
+
+	_, err = h.Prompt(context.Background(), connect.NewRequest(&workerv1.PromptRequest{
+		SessionId:     "sess-override",
+		ContentBlocks: blocks,
+		Model:         "opus",
+		SessionMode:   "ask",
+	}))
+	require.NoError(t, err)
+	_, err = h.Prompt(context.Background(), connect.NewRequest(&workerv1.PromptRequest{
+		SessionId:     "sess-override",
+		ContentBlocks: blocks,
+	}))
+	require.NoError(t, err)
+
+	// A failed turn restores them too.
+	d.launchSess.promptErr = errors.New("agent crashed")
+	_, err = h.Prompt(context.Background(), connect.NewRequest(&workerv1.PromptRequest{
+		SessionId:     "sess-override",
+		ContentBlocks: blocks,
+		Model:         "haiku",
+		SessionMode:   "architect",
+	}))
+	require.Error(t, err)
+
+	assert.Equal(t, []string{"opus", "sonnet", "haiku"}, d.launchSess.promptModels)
+	assert.Equal(t, []string{"ask", "code", "architect"}, d.launchSess.promptModes)
+	info := m.GetStateSnapshot()[0].Info
+	assert.Equal(t, "sonnet", info.CurrentModel)
+	assert.Equal(t, "code", info.CurrentMode)
+}
+
+func TestWorkerServiceHandler_SetHostCommands(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	d.launchSess = newFakeSession("sess-cmds", "test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	_, err := m.Launch(context.Background(), "sess-cmds", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+	h := &workerServiceHandler{log: testLogger(), svc: &WorkloadService{mgr: m}}
+
+	_, err = h.SetHostCommands(context.Background(), connect.NewRequest(&workerv1.SetHostCommandsRequest{
+		SessionId: "sess-cmds",
+		Commands: []*workerv1.HostCommand{
+			{Name: "deploy", Description: "deploy the branch", InputHint: "environment"},
+			{Name: "status"},
+		},
+	}))
+	require.NoError(t, err)
+	assert.Equal(t, []acp.AvailableCommand{
+		{
+			Name:        "deploy",
+			Description: "deploy the branch",
+			Input:       &acp.AvailableCommandInput{UnstructuredCommandInput: &acp.AvailableCommandUnstructuredCommandInput{Hint: "environment"}},
+		},
+		{Name: "status"},
+	}, d.launchSess.hostCommands)
+
+	_, err = h.SetHostCommands(context.Background(), connect.NewRequest(&workerv1.SetHostCommandsRequest{SessionId: "nope"}))
+	assert.Equal(t, connect.CodeInternal, connect.CodeOf(err))
+}
@@ -5,8 +5,11 @@ import (
 	"net/http"
 
 	"connectrpc.com/connect"
+	acp "github.com/coder/acp-go-sdk"
 	"github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1/workerv1connect"
 	v2 "github.com/sebastianm/flowgentic/internal/worker/driver/v2"
+	"github.com/sebastianm/flowgentic/internal/worker/metrics"
+	"github.com/sebastianm/flowgentic/internal/worker/procutil"
 )
 
 // StartDeps holds the dependencies needed by the workload feature.
@@ -17,15 +20,43 @@ type StartDeps struct {
 	Drivers      []v2.Driver
 	CtlURL       string
 	CtlSecret    string
+	Metrics      metrics.Metrics
+	PromptWraps  PromptWraps
+	ToolPolicies map[string]ToolPolicy
+	// ResourceLimits caps agent subprocesses per agent ID.
+	ResourceLimits map[string]procutil.ResourceLimits
+	// EventRetention bounds the un-acknowledged events kept per session.
+	EventRetention EventRetention
+	// MaxPromptBytes bounds prompt text; see promptutil.Validate.
+	MaxPromptBytes int
+	// MaxAttachment bounds prompt attachment data; see
+	// promptutil.ValidateAttachments.
+	MaxAttachment int
+	// MaxRawOutput bounds the raw output of tool call updates; see
+	// SessionManager.maxRawOutput.
+	MaxRawOutput int
+	// AgentctlEnv is added to every launch's env vars, for the agentctl MCP
+	// server; see SessionManager.agentctlEnv.
+	AgentctlEnv map[string]string
+	// MCPServers are added to every session, after the servers the launch
+	// names itself; see v2.LoadMCPServersFromEnv.
+	MCPServers []acp.McpServer
 }
 
 // Start registers the WorkerService RPC handler on the mux and creates
 // the SessionManager. It returns the manager so the caller can pass it
 // to agentctl as the EventHandler.
 func Start(d StartDeps) *SessionManager {
-	mgr := NewSessionManager(d.Log, d.CtlURL, d.CtlSecret, d.Drivers...)
+	mgr := NewSessionManager(d.Log, d.CtlURL, d.CtlSecret, d.Metrics, d.Drivers...)
+	mgr.promptWraps = d.PromptWraps
+	mgr.toolPolicies = d.ToolPolicies
+	mgr.resourceLimits = d.ResourceLimits
+	mgr.mcpServers = d.MCPServers
+	mgr.maxRawOutput = d.MaxRawOutput
+	mgr.agentctlEnv = d.AgentctlEnv
+	mgr.eventQueue.retention = d.EventRetention
 	svc := NewWorkloadService(mgr)
-	h := &workerServiceHandler{log: d.Log, svc: svc}
+	h := &workerServiceHandler{log: d.Log, svc: svc, maxPromptBytes: d.MaxPromptBytes, maxAttachmentBytes: d.MaxAttachment}
 	d.Mux.Handle(workerv1connect.NewWorkerServiceHandler(h, d.Interceptors))
 
 	return mgr
@@ -2,18 +2,30 @@ package workload
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
+	"unicode/utf8"
 
+	acp "github.com/coder/acp-go-sdk"
+	workerv1 "github.com/sebastianm/flowgentic/internal/proto/gen/worker/v1"
 	"github.com/sebastianm/flowgentic/internal/worker/driver"
 	v2 "github.com/sebastianm/flowgentic/internal/worker/driver/v2"
+	"github.com/sebastianm/flowgentic/internal/worker/metrics"
+	"github.com/sebastianm/flowgentic/internal/worker/procutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
 )
 
 func TestNewSessionManager(t *testing.T) {
 	d := newFakeDriver("test-agent")
-	m := NewSessionManager(testLogger(), "", "", d)
+	m := NewSessionManager(testLogger(), "", "", nil, d)
 
 	t.Run("lists drivers", func(t *testing.T) {
 		drivers := m.ListDrivers()
@@ -22,7 +34,7 @@ func TestNewSessionManager(t *testing.T) {
 	})
 
 	t.Run("no sessions initially", func(t *testing.T) {
-		sessions := m.ListSessions()
+		sessions := m.ListSessions(nil)
 		assert.Empty(t, sessions)
 	})
 }
@@ -30,7 +42,7 @@ func TestNewSessionManager(t *testing.T) {
 func TestSessionManager_Launch(t *testing.T) {
 	t.Run("launches session successfully", func(t *testing.T) {
 		d := newFakeDriver("test-agent")
-		m := NewSessionManager(testLogger(), "", "", d)
+		m := NewSessionManager(testLogger(), "", "", nil, d)
 
 		sess, err := m.Launch(context.Background(), "sess-1", "test-agent", v2.LaunchOpts{
 			Prompt: "hello",
@@ -40,15 +52,37 @@ func TestSessionManager_Launch(t *testing.T) {
 		assert.Equal(t, v2.SessionStatusRunning, sess.Info().Status)
 	})
 
+	t.Run("failed launch leaves no status forwarder behind", func(t *testing.T) {
+		d := newFakeDriver("test-agent")
+		d.launchErr = errors.New("agent binary not found")
+		m := NewSessionManager(testLogger(), "", "", nil, d)
+
+		_, err := m.Launch(context.Background(), "sess-1", "test-agent", v2.LaunchOpts{}, nil)
+		require.ErrorContains(t, err, "agent binary not found")
+
+		forwarders := make(chan struct{})
+		go func() {
+			m.wg.Wait()
+			close(forwarders)
+		}()
+		select {
+		case <-forwarders:
+		case <-time.After(time.Second):
+			t.Fatal("status forwarder still running after the launch failed")
+		}
+		_, ok := m.GetSession("sess-1")
+		assert.False(t, ok)
+	})
+
 	t.Run("unknown driver returns error", func(t *testing.T) {
-		m := NewSessionManager(testLogger(), "", "")
+		m := NewSessionManager(testLogger(), "", "", nil)
 		_, err := m.Launch(context.Background(), "sess-1", "nonexistent", v2.LaunchOpts{}, nil)
 		assert.ErrorContains(t, err, "unknown agent driver")
 	})
 
 	t.Run("rejects resume without capability", func(t *testing.T) {
 		d := newFakeDriver("test-agent")
-		m := NewSessionManager(testLogger(), "", "", d)
+		m := NewSessionManager(testLogger(), "", "", nil, d)
 		_, err := m.Launch(context.Background(), "sess-1", "test-agent", v2.LaunchOpts{
 			ResumeSessionID: "old-session",
 		}, nil)
@@ -57,7 +91,7 @@ func TestSessionManager_Launch(t *testing.T) {
 
 	t.Run("rejects model without capability", func(t *testing.T) {
 		d := newFakeDriver("test-agent")
-		m := NewSessionManager(testLogger(), "", "", d)
+		m := NewSessionManager(testLogger(), "", "", nil, d)
 		_, err := m.Launch(context.Background(), "sess-1", "test-agent", v2.LaunchOpts{
 			Model: "gpt-4",
 		}, nil)
@@ -66,21 +100,45 @@ func TestSessionManager_Launch(t *testing.T) {
 
 	t.Run("rejects system prompt without capability", func(t *testing.T) {
 		d := newFakeDriver("test-agent")
-		m := NewSessionManager(testLogger(), "", "", d)
+		m := NewSessionManager(testLogger(), "", "", nil, d)
 		_, err := m.Launch(context.Background(), "sess-1", "test-agent", v2.LaunchOpts{
 			SystemPrompt: "be helpful",
 		}, nil)
 		assert.ErrorContains(t, err, "does not support system prompts")
+
+		_, err = m.Launch(context.Background(), "sess-1", "test-agent", v2.LaunchOpts{
+			SystemPromptTemplate: "You work in {{.Cwd}}.",
+		}, nil)
+		assert.ErrorContains(t, err, "does not support system prompts", "templates are system prompts too")
+	})
+
+	t.Run("rejects reasoning effort without capability", func(t *testing.T) {
+		d := newFakeDriver("test-agent")
+		m := NewSessionManager(testLogger(), "", "", nil, d)
+		_, err := m.Launch(context.Background(), "sess-1", "test-agent", v2.LaunchOpts{
+			ReasoningEffort: "high",
+		}, nil)
+		assert.ErrorContains(t, err, "does not support reasoning effort")
+	})
+
+	t.Run("rejects unknown reasoning effort", func(t *testing.T) {
+		d := newFakeDriver("test-agent", driver.CapReasoningEffort)
+		m := NewSessionManager(testLogger(), "", "", nil, d)
+		_, err := m.Launch(context.Background(), "sess-1", "test-agent", v2.LaunchOpts{
+			ReasoningEffort: "extreme",
+		}, nil)
+		assert.ErrorContains(t, err, "unknown reasoning effort")
 	})
 
 	t.Run("accepts capabilities when supported", func(t *testing.T) {
-		d := newFakeDriver("test-agent", driver.CapCustomModel, driver.CapSystemPrompt, driver.CapSessionResume)
-		m := NewSessionManager(testLogger(), "", "", d)
+		d := newFakeDriver("test-agent", driver.CapCustomModel, driver.CapSystemPrompt, driver.CapSessionResume, driver.CapReasoningEffort)
+		m := NewSessionManager(testLogger(), "", "", nil, d)
 		sess, err := m.Launch(context.Background(), "sess-1", "test-agent", v2.LaunchOpts{
 			Model:           "gpt-4",
 			SystemPrompt:    "be helpful",
 			SessionMode:     "code",
 			ResumeSessionID: "resume-id",
+			ReasoningEffort: "medium",
 		}, nil)
 		require.NoError(t, err)
 		assert.NotNil(t, sess)
@@ -88,14 +146,14 @@ func TestSessionManager_Launch(t *testing.T) {
 
 	t.Run("driver launch error propagated", func(t *testing.T) {
 		d := &errDriver{id: "broken"}
-		m := NewSessionManager(testLogger(), "", "", d)
+		m := NewSessionManager(testLogger(), "", "", nil, d)
 		_, err := m.Launch(context.Background(), "sess-1", "broken", v2.LaunchOpts{}, nil)
 		assert.ErrorContains(t, err, "launch failed")
 	})
 
 	t.Run("agent session ID populated from session info", func(t *testing.T) {
 		d := newFakeDriver("test-agent")
-		m := NewSessionManager(testLogger(), "", "", d)
+		m := NewSessionManager(testLogger(), "", "", nil, d)
 		sess, err := m.Launch(context.Background(), "sess-sid", "test-agent", v2.LaunchOpts{}, nil)
 		require.NoError(t, err)
 		assert.Equal(t, "fake-agent-session-id", sess.Info().AgentSessionID)
@@ -103,7 +161,7 @@ func TestSessionManager_Launch(t *testing.T) {
 
 	t.Run("injects AGENTCTL env vars for MCP server", func(t *testing.T) {
 		d := newFakeDriver("codex")
-		m := NewSessionManager(testLogger(), "http://127.0.0.1:7777", "worker-secret", d)
+		m := NewSessionManager(testLogger(), "http://127.0.0.1:7777", "worker-secret", nil, d)
 		_, err := m.Launch(context.Background(), "sess-env", "codex", v2.LaunchOpts{}, nil)
 		require.NoError(t, err)
 
@@ -116,11 +174,28 @@ func TestSessionManager_Launch(t *testing.T) {
 		assert.Equal(t, "sess-env", launchOpts.EnvVars["AGENTCTL_SESSION_ID"])
 		assert.Equal(t, "codex", launchOpts.EnvVars["AGENTCTL_AGENT"])
 	})
+
+	t.Run("adds configured agentctl env unless the launch sets it", func(t *testing.T) {
+		d := newFakeDriver("codex")
+		m := NewSessionManager(testLogger(), "", "", nil, d)
+		m.agentctlEnv = map[string]string{"AGENTCTL_LOG_DIR": "/var/log/flowgentic", "AGENTCTL_LOG_MAX_FILES": "5"}
+		_, err := m.Launch(context.Background(), "sess-log", "codex", v2.LaunchOpts{
+			EnvVars: map[string]string{"AGENTCTL_LOG_MAX_FILES": "1"},
+		}, nil)
+		require.NoError(t, err)
+
+		d.mu.Lock()
+		launchOpts := d.lastOpts
+		d.mu.Unlock()
+
+		assert.Equal(t, "/var/log/flowgentic", launchOpts.EnvVars["AGENTCTL_LOG_DIR"])
+		assert.Equal(t, "1", launchOpts.EnvVars["AGENTCTL_LOG_MAX_FILES"])
+	})
 }
 
 func TestSessionManager_GetSession(t *testing.T) {
 	d := newFakeDriver("test-agent")
-	m := NewSessionManager(testLogger(), "", "", d)
+	m := NewSessionManager(testLogger(), "", "", nil, d)
 
 	sessionID := "sess-get"
 	_, err := m.Launch(context.Background(), sessionID, "test-agent", v2.LaunchOpts{}, nil)
@@ -140,41 +215,110 @@ func TestSessionManager_GetSession(t *testing.T) {
 
 func TestSessionManager_ListSessions(t *testing.T) {
 	d := newFakeDriver("test-agent")
-	m := NewSessionManager(testLogger(), "", "", d)
+	m := NewSessionManager(testLogger(), "", "", nil, d)
 
 	_, err := m.Launch(context.Background(), "sess-list-1", "test-agent", v2.LaunchOpts{}, nil)
 	require.NoError(t, err)
 	_, err = m.Launch(context.Background(), "sess-list-2", "test-agent", v2.LaunchOpts{}, nil)
 	require.NoError(t, err)
 
-	sessions := m.ListSessions()
+	launched := m.QueueDepth("sess-list-1")
+	appendEvents(m.eventQueue, "sess-list-1", 100, 101)
+
+	sessions := m.ListSessions(nil)
 	assert.Len(t, sessions, 2)
+	for _, e := range sessions {
+		assert.Equal(t, m.QueueDepth(e.SessionID), e.QueueDepth, e.SessionID)
+	}
+	assert.Equal(t, launched+2, m.QueueDepth("sess-list-1"))
 }
 
 func TestSessionManager_StopSession(t *testing.T) {
 	d := newFakeDriver("test-agent")
-	m := NewSessionManager(testLogger(), "", "", d)
+	m := NewSessionManager(testLogger(), "", "", nil, d)
 
 	sessionID := "sess-stop"
 	_, err := m.Launch(context.Background(), sessionID, "test-agent", v2.LaunchOpts{}, nil)
 	require.NoError(t, err)
 
-	err = m.StopSession(context.Background(), sessionID)
+	err = m.StopSession(context.Background(), sessionID, false)
 	require.NoError(t, err)
 
 	_, ok := m.GetSession(sessionID)
 	assert.False(t, ok)
 }
 
+func TestSessionManager_StopSession_Wait(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	sess := newFakeSession("sess-wait", "test-agent")
+	sess.teardown = make(chan struct{})
+	d.launchSess = sess
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	_, err := m.Launch(context.Background(), "sess-wait", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+
+	time.AfterFunc(20*time.Millisecond, func() { close(sess.teardown) })
+	require.NoError(t, m.StopSession(context.Background(), "sess-wait", true))
+
+	select {
+	case <-sess.done:
+	default:
+		t.Fatal("StopSession returned before the session was torn down")
+	}
+	assert.NoError(t, sess.Wait(context.Background()))
+	_, ok := m.GetSession("sess-wait")
+	assert.False(t, ok)
+}
+
+func TestSessionManager_StopSession_WaitHonorsContext(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	sess := newFakeSession("sess-stuck", "test-agent")
+	sess.teardown = make(chan struct{})
+	t.Cleanup(func() { close(sess.teardown) })
+	d.launchSess = sess
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	_, err := m.Launch(context.Background(), "sess-stuck", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = m.StopSession(ctx, "sess-stuck", true)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	_, ok := m.GetSession("sess-stuck")
+	assert.False(t, ok, "session is removed even if teardown outlives ctx")
+}
+
 func TestSessionManager_StopSession_NotFound(t *testing.T) {
-	m := NewSessionManager(testLogger(), "", "")
-	err := m.StopSession(context.Background(), "nonexistent")
+	m := NewSessionManager(testLogger(), "", "", nil)
+	err := m.StopSession(context.Background(), "nonexistent", false)
 	assert.ErrorContains(t, err, "session not found")
 }
 
+func TestSessionManager_SoftStop(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	sess := newFakeSession("sess-soft", "test-agent")
+	sess.teardown = make(chan struct{})
+	d.launchSess = sess
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	_, err := m.Launch(context.Background(), "sess-soft", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+
+	require.NoError(t, m.SoftStop(context.Background(), "sess-soft"))
+	_, ok := m.GetSession("sess-soft")
+	assert.True(t, ok, "the session is kept until its turn has ended")
+
+	close(sess.teardown)
+	assert.Eventually(t, func() bool {
+		_, ok := m.GetSession("sess-soft")
+		return !ok
+	}, time.Second, 5*time.Millisecond)
+
+	assert.ErrorContains(t, m.SoftStop(context.Background(), "sess-soft"), "session not found")
+}
+
 func TestSessionManager_Subscribe(t *testing.T) {
 	d := newFakeDriver("test-agent")
-	m := NewSessionManager(testLogger(), "", "", d)
+	m := NewSessionManager(testLogger(), "", "", nil, d)
 
 	t.Run("notifies on launch with update event", func(t *testing.T) {
 		ch := m.Subscribe()
@@ -203,7 +347,7 @@ func TestSessionManager_Subscribe(t *testing.T) {
 		default:
 		}
 
-		err := m.StopSession(context.Background(), "sess-sub-1")
+		err := m.StopSession(context.Background(), "sess-sub-1", false)
 		require.NoError(t, err)
 
 		select {
@@ -211,6 +355,7 @@ func TestSessionManager_Subscribe(t *testing.T) {
 			assert.Equal(t, StateEventRemoved, event.Type)
 			assert.Equal(t, "sess-sub-1", event.SessionID)
 			assert.Nil(t, event.Snapshot)
+			assert.Equal(t, v2.SessionStatusStopped, event.FinalStatus)
 		case <-time.After(time.Second):
 			t.Fatal("expected notification on remove")
 		}
@@ -234,7 +379,7 @@ func TestSessionManager_Subscribe(t *testing.T) {
 
 func TestSessionManager_HandleSetTopic(t *testing.T) {
 	d := newFakeDriver("test-agent")
-	m := NewSessionManager(testLogger(), "", "", d)
+	m := NewSessionManager(testLogger(), "", "", nil, d)
 
 	sessionID := "sess-topic"
 	_, err := m.Launch(context.Background(), sessionID, "test-agent", v2.LaunchOpts{}, nil)
@@ -278,7 +423,7 @@ func TestSessionManager_HandleSetTopic(t *testing.T) {
 
 func TestSessionManager_GetStateSnapshot(t *testing.T) {
 	d := newFakeDriver("test-agent")
-	m := NewSessionManager(testLogger(), "", "", d)
+	m := NewSessionManager(testLogger(), "", "", nil, d)
 
 	t.Run("empty when no sessions", func(t *testing.T) {
 		snap := m.GetStateSnapshot()
@@ -302,4 +447,1138 @@ func TestSessionManager_GetStateSnapshot(t *testing.T) {
 		assert.True(t, ids["sess-snap-1"])
 		assert.True(t, ids["sess-snap-2"])
 	})
+
+	t.Run("reports the last event sequence", func(t *testing.T) {
+		m.emitUserMessage("sess-snap-1", m.sessions["sess-snap-1"], "hello")
+		pending := m.PendingEvents("sess-snap-1", 0)
+		require.NotEmpty(t, pending)
+		for _, s := range m.GetStateSnapshot() {
+			if s.SessionID == "sess-snap-1" {
+				assert.Equal(t, pending[len(pending)-1].GetSequence(), s.LastSequence)
+			}
+		}
+	})
+}
+
+func TestSessionManager_Shutdown(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+
+	const n = 12 // more than maxParallelStops
+	sessions := make([]*fakeSession, 0, n)
+	for i := range n {
+		id := fmt.Sprintf("sess-shutdown-%d", i)
+		_, err := m.Launch(context.Background(), id, "test-agent", v2.LaunchOpts{}, nil)
+		require.NoError(t, err)
+		sess, ok := m.GetSession(id)
+		require.True(t, ok)
+		sessions = append(sessions, sess.(*fakeSession))
+	}
+
+	stateCh := m.Subscribe()
+	eventCh := m.SubscribeEvents()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	require.NoError(t, m.Shutdown(ctx))
+
+	for i, s := range sessions {
+		select {
+		case <-s.done:
+		default:
+			t.Fatalf("session %d was not stopped", i)
+		}
+	}
+	assert.Empty(t, m.ListSessions(nil))
+	assert.Empty(t, m.AllPendingEvents())
+
+	// Subscriber channels are closed after draining buffered events.
+	for range stateCh {
+	}
+	for range eventCh {
+	}
+
+	// The manager rejects new work and hands out closed channels.
+	_, err := m.Launch(context.Background(), "sess-late", "test-agent", v2.LaunchOpts{}, nil)
+	assert.ErrorContains(t, err, "shut down")
+	_, open := <-m.Subscribe()
+	assert.False(t, open)
+
+	// Shutdown is idempotent.
+	assert.NoError(t, m.Shutdown(ctx))
+}
+
+func TestSessionManager_AnnouncesEffectiveModel(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	d.launchSess = newFakeSession("sess-model", "test-agent")
+	d.launchSess.info.CurrentModel = "agent-default"
+	d.launchStatuses = []v2.SessionStatus{v2.SessionStatusRunning}
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+
+	stateCh := m.Subscribe()
+	defer m.Unsubscribe(stateCh)
+
+	_, err := m.Launch(context.Background(), "sess-model", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		for _, e := range m.PendingEvents("sess-model", 0) {
+			if e.GetStatusChange() != nil {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+
+	var modelEvt, statusEvt int64
+	for _, e := range m.PendingEvents("sess-model", 0) {
+		if u := e.GetCurrentModelUpdate(); u != nil {
+			assert.Equal(t, "agent-default", u.ModelId)
+			modelEvt = e.Sequence
+		}
+		if e.GetStatusChange() != nil {
+			statusEvt = e.Sequence
+		}
+	}
+	assert.Less(t, modelEvt, statusEvt, "model event precedes the running status change")
+
+	var models []string
+	for range 2 {
+		select {
+		case ev := <-stateCh:
+			models = append(models, ev.Snapshot.Info.CurrentModel)
+		case <-time.After(time.Second):
+			t.Fatal("expected snapshot updates")
+		}
+	}
+	assert.Contains(t, models, "agent-default")
+
+	snaps := m.GetStateSnapshot()
+	require.Len(t, snaps, 1)
+	assert.Equal(t, "agent-default", sessionSnapshotToProto(snaps[0]).Model)
+}
+
+func TestSessionSnapshotToProto_Modes(t *testing.T) {
+	state := sessionSnapshotToProto(SessionSnapshot{
+		SessionID: "sess-1",
+		Info: v2.SessionInfo{
+			AgentID: "opencode",
+			Modes: []v2.SessionModeInfo{
+				{ID: "build", Name: "Build"},
+				{ID: "plan", Name: "Plan", Description: "read-only planning"},
+			},
+			CurrentMode: "plan",
+		},
+	})
+
+	require.Len(t, state.Modes, 2)
+	assert.Equal(t, "build", state.Modes[0].Id)
+	assert.Equal(t, "Plan", state.Modes[1].Name)
+	assert.Equal(t, "read-only planning", state.Modes[1].Description)
+	assert.Equal(t, "plan", state.CurrentMode)
+}
+
+func TestAcpToolCallUpdateToProto_CommandOutput(t *testing.T) {
+	code := 3
+	upd := acp.UpdateToolCall("tc-1",
+		acp.WithUpdateStatus(acp.ToolCallStatusFailed),
+		driver.WithCommandOutput(driver.CommandOutput{Stdout: "out", Stderr: "err", ExitCode: &code}),
+	)
+
+	p := acpToolCallUpdateToProto(upd.ToolCallUpdate, 0)
+	require.Len(t, p.Content, 1)
+	co := p.Content[0].GetCommandOutput()
+	require.NotNil(t, co)
+	assert.Equal(t, "out", co.Stdout)
+	assert.Equal(t, "err", co.Stderr)
+	require.NotNil(t, co.ExitCode)
+	assert.Equal(t, int32(3), *co.ExitCode)
+}
+
+func TestAcpToolCallUpdateToProto_CutsRawOutput(t *testing.T) {
+	output := strings.Repeat("a", 9) + "é" + strings.Repeat("b", 10) // "é" straddles the limit
+	sum := sha256.Sum256([]byte(output))
+	upd := acp.UpdateToolCall("tc-1", acp.WithUpdateRawOutput(output))
+
+	p := acpToolCallUpdateToProto(upd.ToolCallUpdate, 10)
+	assert.Equal(t, strings.Repeat("a", 9), p.RawOutput, "cut before the split rune")
+	assert.Equal(t, int64(len(output)), p.RawOutputSize)
+	assert.Equal(t, hex.EncodeToString(sum[:]), p.RawOutputSha256)
+
+	p = acpToolCallUpdateToProto(upd.ToolCallUpdate, -1)
+	assert.Equal(t, output, p.RawOutput, "a negative limit keeps the output")
+	assert.Zero(t, p.RawOutputSize)
+
+	p = acpToolCallUpdateToProto(upd.ToolCallUpdate, 0)
+	assert.Equal(t, output, p.RawOutput, "below the default limit")
+	assert.Empty(t, p.RawOutputSha256)
+}
+
+func TestAcpToolCallToProto_ToolInput(t *testing.T) {
+	meta := map[string]any{"claudeCode": map[string]any{"toolName": "Bash"}}
+	tc := acp.StartToolCall("tc-1", "Run tests",
+		acp.WithStartKind(acp.ToolKindExecute),
+		acp.WithStartRawInput(map[string]any{"command": "go test ./...", "timeout": float64(60000)}),
+	)
+	tc.ToolCall.Meta = meta
+
+	p := acpToolCallToProto(tc.ToolCall)
+	bash := p.GetInput().GetBash()
+	require.NotNil(t, bash)
+	assert.Equal(t, "go test ./...", bash.Command)
+	require.NotNil(t, bash.TimeoutMs)
+	assert.Equal(t, int64(60000), *bash.TimeoutMs)
+	assert.NotEmpty(t, p.RawInput, "raw input is kept")
+
+	upd := acp.UpdateToolCall("tc-1", acp.WithUpdateRawInput(map[string]any{"file_path": "main.go"}))
+	upd.ToolCallUpdate.Meta = map[string]any{"claudeCode": map[string]any{"toolName": "Read"}}
+	assert.Equal(t, "main.go", acpToolCallUpdateToProto(upd.ToolCallUpdate, 0).GetInput().GetRead().GetFilePath())
+
+	// Unknown tools only carry raw input.
+	tc.ToolCall.Meta = map[string]any{"claudeCode": map[string]any{"toolName": "WebFetch"}}
+	assert.Nil(t, acpToolCallToProto(tc.ToolCall).GetInput())
+}
+
+func TestAcpToolCallToProto_Metadata(t *testing.T) {
+	tc := acp.StartToolCall("tc-1", "Run tests", acp.WithStartKind(acp.ToolKindExecute))
+	tc.ToolCall.Meta = map[string]any{"claudeCode": map[string]any{"toolName": "Bash"}, "background": true}
+	assert.Equal(t, map[string]string{"claudeCode.toolName": "Bash", "background": "true"}, acpToolCallToProto(tc.ToolCall).Metadata)
+
+	upd := acp.UpdateToolCall("tc-1", driver.WithCommandOutput(driver.CommandOutput{Stdout: "ok"}))
+	upd.ToolCallUpdate.Meta.(map[string]any)["claudeCode"] = map[string]any{"toolName": "Bash"}
+	assert.Equal(t, map[string]string{"claudeCode.toolName": "Bash"}, acpToolCallUpdateToProto(upd.ToolCallUpdate, 0).Metadata,
+		"command output is content, not metadata")
+
+	assert.Nil(t, acpToolCallToProto(acp.StartToolCall("tc-2", "Think").ToolCall).Metadata)
+}
+
+func TestSessionManager_Metrics(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	mtr := newFakeMetrics()
+	m := NewSessionManager(testLogger(), "", "", mtr, d)
+	agent := metrics.Labels{"agent": "test-agent"}
+
+	_, err := m.Launch(context.Background(), "sess-metrics", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, mtr.value(metrics.SessionsLaunched, agent))
+	assert.Equal(t, 1.0, mtr.value(metrics.SessionsActive, agent))
+
+	m.mu.RLock()
+	entry := m.sessions["sess-metrics"]
+	m.mu.RUnlock()
+	m.emitSessionEvent("sess-metrics", entry, acp.SessionNotification{
+		SessionId: "sess-metrics",
+		Update:    acp.StartToolCall("call-1", "Run ls", acp.WithStartKind(acp.ToolKindExecute)),
+	})
+	assert.Equal(t, 1.0, mtr.value(metrics.ToolCalls, metrics.Labels{"agent": "test-agent", "kind": "execute"}))
+
+	_, err = m.Prompt(context.Background(), "sess-metrics", []acp.ContentBlock{acp.TextBlock("hi")})
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, mtr.value(metrics.Prompts, agent))
+	assert.Equal(t, 1, mtr.observations(metrics.PromptDuration, agent))
+
+	require.NoError(t, m.StopSession(context.Background(), "sess-metrics", false))
+	assert.Equal(t, 0.0, mtr.value(metrics.SessionsActive, agent))
+	assert.Equal(t, 1.0, mtr.value(metrics.SessionsStopped, agent))
+}
+
+func TestSessionManager_TurnEndedCarriesCancelReason(t *testing.T) {
+	turnEnded := func(t *testing.T, m *SessionManager, sessionID string) *workerv1.TurnEnded {
+		t.Helper()
+		var ended []*workerv1.TurnEnded
+		for _, e := range m.PendingEvents(sessionID, 0) {
+			if te := e.GetTurnEnded(); te != nil {
+				ended = append(ended, te)
+			}
+		}
+		require.Len(t, ended, 1)
+		return ended[0]
+	}
+	launch := func(t *testing.T, sessionID string) (*SessionManager, *fakeSession) {
+		t.Helper()
+		d := newFakeDriver("test-agent")
+		d.launchSess = newFakeSession(sessionID, "test-agent")
+		d.launchSess.holdPrompt = make(chan struct{})
+		m := NewSessionManager(testLogger(), "", "", nil, d)
+		_, err := m.Launch(context.Background(), sessionID, "test-agent", v2.LaunchOpts{}, nil)
+		require.NoError(t, err)
+		return m, d.launchSess
+	}
+	cancelDuringPrompt := func(t *testing.T, m *SessionManager, sess *fakeSession, sessionID string, reason workerv1.CancelReason) {
+		t.Helper()
+		done := make(chan error, 1)
+		go func() {
+			_, err := m.Prompt(context.Background(), sessionID, []acp.ContentBlock{acp.TextBlock("hi")})
+			done <- err
+		}()
+		require.Eventually(t, func() bool {
+			sess.mu.Lock()
+			defer sess.mu.Unlock()
+			return len(sess.prompts) == 1
+		}, time.Second, time.Millisecond)
+		require.NoError(t, m.Cancel(context.Background(), sessionID, reason))
+		require.NoError(t, <-done)
+	}
+
+	t.Run("user", func(t *testing.T) {
+		m, sess := launch(t, "sess-user")
+		cancelDuringPrompt(t, m, sess, "sess-user", workerv1.CancelReason_CANCEL_REASON_UNSPECIFIED)
+		ended := turnEnded(t, m, "sess-user")
+		assert.Equal(t, workerv1.StopReason_STOP_REASON_CANCELLED, ended.StopReason)
+		assert.Equal(t, workerv1.CancelReason_CANCEL_REASON_USER, ended.CancelReason)
+	})
+
+	t.Run("limit", func(t *testing.T) {
+		m, sess := launch(t, "sess-limit")
+		cancelDuringPrompt(t, m, sess, "sess-limit", workerv1.CancelReason_CANCEL_REASON_LIMIT)
+		assert.Equal(t, workerv1.CancelReason_CANCEL_REASON_LIMIT, turnEnded(t, m, "sess-limit").CancelReason)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		m, sess := launch(t, "sess-timeout")
+		// The driver cancels a turn whose prompt deadline passed and says so.
+		sess.turnEnded(v2.TurnEnd{StopReason: acp.StopReasonCancelled, TimedOut: true})
+
+		ended := turnEnded(t, m, "sess-timeout")
+		assert.Equal(t, workerv1.StopReason_STOP_REASON_CANCELLED, ended.StopReason)
+		assert.Equal(t, workerv1.CancelReason_CANCEL_REASON_TIMEOUT, ended.CancelReason)
+	})
+
+	t.Run("reason applies to one turn", func(t *testing.T) {
+		m, sess := launch(t, "sess-once")
+		cancelDuringPrompt(t, m, sess, "sess-once", workerv1.CancelReason_CANCEL_REASON_LIMIT)
+		// A turn the agent cancelled by itself, e.g. an auto-continued one
+		// preempted by a user prompt, has no reason.
+		sess.turnEnded(v2.TurnEnd{StopReason: acp.StopReasonCancelled})
+
+		var reasons []workerv1.CancelReason
+		for _, e := range m.PendingEvents("sess-once", 0) {
+			if te := e.GetTurnEnded(); te != nil {
+				reasons = append(reasons, te.CancelReason)
+			}
+		}
+		assert.Equal(t, []workerv1.CancelReason{workerv1.CancelReason_CANCEL_REASON_LIMIT, workerv1.CancelReason_CANCEL_REASON_UNSPECIFIED}, reasons)
+	})
+
+	t.Run("turns the driver starts", func(t *testing.T) {
+		m, sess := launch(t, "sess-auto")
+		// The launch prompt and auto-continued turns end without a
+		// SessionManager.Prompt call.
+		sess.turnEnded(v2.TurnEnd{StopReason: acp.StopReasonEndTurn, Stats: &v2.TurnStats{ToolCalls: 2}})
+
+		ended := turnEnded(t, m, "sess-auto")
+		assert.Equal(t, workerv1.StopReason_STOP_REASON_END_TURN, ended.StopReason)
+		require.NotNil(t, ended.Stats)
+		assert.Equal(t, int32(2), ended.Stats.ToolCalls)
+	})
+
+	t.Run("finished turn", func(t *testing.T) {
+		d := newFakeDriver("test-agent")
+		m := NewSessionManager(testLogger(), "", "", nil, d)
+		_, err := m.Launch(context.Background(), "sess-done", "test-agent", v2.LaunchOpts{}, nil)
+		require.NoError(t, err)
+		_, err = m.Prompt(context.Background(), "sess-done", []acp.ContentBlock{acp.TextBlock("hi")})
+		require.NoError(t, err)
+		ended := turnEnded(t, m, "sess-done")
+		assert.Equal(t, workerv1.CancelReason_CANCEL_REASON_UNSPECIFIED, ended.CancelReason)
+		assert.Nil(t, ended.Stats, "the session reported no stats")
+	})
+
+	t.Run("turn stats", func(t *testing.T) {
+		d := newFakeDriver("test-agent")
+		d.launchSess = newFakeSession("sess-stats", "test-agent")
+		d.launchSess.turnStats = &v2.TurnStats{
+			Duration:         4200 * time.Millisecond,
+			TimeToFirstToken: 850 * time.Millisecond,
+			ToolCalls:        3,
+			Usage:            driver.TurnUsage{InputTokens: 1200, OutputTokens: 345},
+		}
+		m := NewSessionManager(testLogger(), "", "", nil, d)
+		_, err := m.Launch(context.Background(), "sess-stats", "test-agent", v2.LaunchOpts{}, nil)
+		require.NoError(t, err)
+		_, err = m.Prompt(context.Background(), "sess-stats", []acp.ContentBlock{acp.TextBlock("hi")})
+		require.NoError(t, err)
+
+		stats := turnEnded(t, m, "sess-stats").Stats
+		require.NotNil(t, stats)
+		assert.Equal(t, int64(4200), stats.DurationMs)
+		assert.Equal(t, int64(850), stats.TimeToFirstTokenMs)
+		assert.Equal(t, int32(3), stats.ToolCalls)
+		assert.Equal(t, int64(1200), stats.InputTokens)
+		assert.Equal(t, int64(345), stats.OutputTokens)
+	})
+
+	t.Run("stop reasons", func(t *testing.T) {
+		for reason, want := range map[acp.StopReason]workerv1.StopReason{
+			acp.StopReasonEndTurn:         workerv1.StopReason_STOP_REASON_END_TURN,
+			acp.StopReasonMaxTokens:       workerv1.StopReason_STOP_REASON_MAX_TOKENS,
+			acp.StopReasonMaxTurnRequests: workerv1.StopReason_STOP_REASON_MAX_TURN_REQUESTS,
+			acp.StopReasonRefusal:         workerv1.StopReason_STOP_REASON_REFUSAL,
+			"something_new":               workerv1.StopReason_STOP_REASON_UNSPECIFIED,
+		} {
+			d := newFakeDriver("test-agent")
+			d.launchSess = newFakeSession("sess-stop", "test-agent")
+			d.launchSess.stopReason = reason
+			m := NewSessionManager(testLogger(), "", "", nil, d)
+			_, err := m.Launch(context.Background(), "sess-stop", "test-agent", v2.LaunchOpts{}, nil)
+			require.NoError(t, err)
+			_, err = m.Prompt(context.Background(), "sess-stop", []acp.ContentBlock{acp.TextBlock("hi")})
+			require.NoError(t, err)
+
+			ended := turnEnded(t, m, "sess-stop")
+			assert.Equal(t, want, ended.StopReason, reason)
+			assert.Equal(t, workerv1.CancelReason_CANCEL_REASON_UNSPECIFIED, ended.CancelReason, reason)
+		}
+	})
+}
+
+func TestSessionManager_MCPStartupIsNotAThought(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	_, err := m.Launch(context.Background(), "sess-mcp", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+
+	m.mu.RLock()
+	entry := m.sessions["sess-mcp"]
+	m.mu.RUnlock()
+	m.emitSessionEvent("sess-mcp", entry, acp.SessionNotification{
+		SessionId: "sess-mcp",
+		Update:    driver.MCPStartupUpdate("[mcp startup] flowgentic - starting", driver.MCPStartup{Server: "flowgentic", Status: "starting"}),
+	})
+	m.emitSessionEvent("sess-mcp", entry, acp.SessionNotification{
+		SessionId: "sess-mcp",
+		Update:    acp.UpdateAgentThoughtText("thinking"),
+	})
+
+	var startups []*workerv1.McpServerStartup
+	var thoughts []string
+	for _, e := range m.PendingEvents("sess-mcp", 0) {
+		if ms := e.GetMcpServerStartup(); ms != nil {
+			startups = append(startups, ms)
+		}
+		if tc := e.GetAgentThoughtChunk(); tc != nil {
+			thoughts = append(thoughts, tc.Text)
+		}
+	}
+	require.Len(t, startups, 1)
+	assert.Equal(t, "flowgentic", startups[0].Server)
+	assert.Equal(t, "starting", startups[0].Status)
+	assert.Equal(t, "[mcp startup] flowgentic - starting", startups[0].Text)
+	assert.Equal(t, []string{"thinking"}, thoughts)
+}
+
+func TestSessionManager_SessionInitIsNotAThought(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	_, err := m.Launch(context.Background(), "sess-init", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+
+	m.mu.RLock()
+	entry := m.sessions["sess-init"]
+	m.mu.RUnlock()
+	afterSeq := entry.nextSeq.Load()
+	m.emitSessionEvent("sess-init", entry, acp.SessionNotification{
+		SessionId: "sess-init",
+		Update:    driver.SessionInitUpdate(driver.SessionInit{Model: "opus", PermissionMode: "plan", Tools: []string{"Read", "Grep"}}),
+	})
+
+	events := m.PendingEvents("sess-init", afterSeq)
+	require.Len(t, events, 1)
+	si := events[0].GetSessionInit()
+	require.NotNil(t, si, "reported as a session init, not a thought")
+	assert.Equal(t, "opus", si.Model)
+	assert.Equal(t, "plan", si.PermissionMode)
+	assert.Equal(t, []string{"Read", "Grep"}, si.Tools)
+	assert.Equal(t, "Session started: model opus, permission mode plan, 2 tools", si.Text)
+}
+
+func TestSessionManager_JoinsRunesSplitAcrossChunks(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	_, err := m.Launch(context.Background(), "sess-utf8", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+
+	m.mu.RLock()
+	entry := m.sessions["sess-utf8"]
+	m.mu.RUnlock()
+	afterSeq := entry.nextSeq.Load()
+	// "€" is "\xe2\x82\xac"; the agent splits it between two deltas.
+	for _, chunk := range []string{"costs 5\xe2", "\x82\xac", " in total"} {
+		m.emitSessionEvent("sess-utf8", entry, acp.SessionNotification{
+			SessionId: "sess-utf8",
+			Update:    acp.UpdateAgentMessageText(chunk),
+		})
+	}
+
+	var chunks []string
+	var text string
+	for _, e := range m.PendingEvents("sess-utf8", afterSeq) {
+		if mc := e.GetAgentMessageChunk(); mc != nil {
+			assert.True(t, utf8.ValidString(mc.Text), "chunk %q", mc.Text)
+			_, err := proto.Marshal(e)
+			assert.NoError(t, err)
+			chunks = append(chunks, mc.Text)
+			text += mc.Text
+		}
+	}
+	assert.Equal(t, []string{"costs 5", "€", " in total"}, chunks)
+	assert.Equal(t, "costs 5€ in total", text)
+}
+
+func TestSessionManager_AutoContinuePromptsAreUserMessages(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	_, err := m.Launch(context.Background(), "sess-ac", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+
+	m.mu.RLock()
+	entry := m.sessions["sess-ac"]
+	m.mu.RUnlock()
+	afterSeq := entry.nextSeq.Load()
+	continued := acp.UpdateUserMessageText("Continue.")
+	continued.UserMessageChunk.Meta = map[string]any{"autoContinue": 1}
+	for _, u := range []acp.SessionUpdate{acp.UpdateUserMessageText("replayed history"), continued} {
+		m.emitSessionEvent("sess-ac", entry, acp.SessionNotification{SessionId: "sess-ac", Update: u})
+	}
+
+	events := m.PendingEvents("sess-ac", afterSeq)
+	require.Len(t, events, 1, "only the driver's own prompts become events")
+	msg := events[0].GetUserMessage()
+	require.NotNil(t, msg)
+	assert.Equal(t, "Continue.", msg.Text)
+	assert.Equal(t, int32(1), msg.AutoContinueIteration)
+}
+
+func TestSessionManager_EphemeralSessionIsNotQueued(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	d.launchSess = newFakeSession("sess-eph", "test-agent")
+	d.launchStatuses = []v2.SessionStatus{v2.SessionStatusRunning, v2.SessionStatusIdle}
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	eventCh := m.SubscribeEvents()
+	defer m.UnsubscribeEvents(eventCh)
+
+	_, err := m.Launch(context.Background(), "sess-eph", "test-agent", v2.LaunchOpts{Ephemeral: true}, nil)
+	require.NoError(t, err)
+
+	var chunks, statuses int
+	for chunks == 0 || statuses < 2 {
+		select {
+		case u := <-eventCh:
+			assert.True(t, u.Event.GetEphemeral(), "live events are marked ephemeral")
+			if u.Event.GetAgentMessageChunk() != nil {
+				chunks++
+			}
+			if u.Event.GetStatusChange() != nil {
+				statuses++
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("got %d chunks and %d status changes, want live events of both", chunks, statuses)
+		}
+	}
+	assert.Empty(t, m.PendingEvents("sess-eph", 0), "nothing is queued for redelivery")
+	assert.Zero(t, m.TotalQueueDepth())
+
+	require.NoError(t, m.StopSession(context.Background(), "sess-eph", false))
+	_, ok := m.GetSession("sess-eph")
+	assert.False(t, ok)
+}
+
+func TestSessionManager_AgentPlan(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	d.launchSess = newFakeSession("sess-plan", "test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	_, err := m.Launch(context.Background(), "sess-plan", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+
+	plan, err := m.GetCurrentPlan("sess-plan")
+	require.NoError(t, err)
+	assert.Nil(t, plan)
+
+	m.mu.RLock()
+	entry := m.sessions["sess-plan"]
+	m.mu.RUnlock()
+	first := []acp.PlanEntry{{Content: "read", Priority: acp.PlanEntryPriorityHigh, Status: acp.PlanEntryStatusPending}}
+	second := []acp.PlanEntry{
+		{Content: "read", Priority: acp.PlanEntryPriorityHigh, Status: acp.PlanEntryStatusCompleted},
+		{Content: "write", Priority: acp.PlanEntryPriorityLow, Status: acp.PlanEntryStatusPending},
+	}
+	for _, entries := range [][]acp.PlanEntry{first, second} {
+		m.emitSessionEvent("sess-plan", entry, acp.SessionNotification{SessionId: "sess-plan", Update: acp.UpdatePlan(entries...)})
+	}
+	d.launchSess.info.Plan = second
+
+	var plans []*workerv1.AgentPlan
+	for _, e := range m.PendingEvents("sess-plan", 0) {
+		if p := e.GetAgentPlan(); p != nil {
+			plans = append(plans, p)
+		}
+	}
+	require.Len(t, plans, 2)
+	require.Len(t, plans[1].Entries, 2, "each plan event carries the whole plan")
+	assert.Equal(t, "completed", plans[1].Entries[0].Status)
+	assert.Equal(t, "write", plans[1].Entries[1].Content)
+	assert.Equal(t, "low", plans[1].Entries[1].Priority)
+
+	plan, err = m.GetCurrentPlan("sess-plan")
+	require.NoError(t, err)
+	assert.Equal(t, second, plan)
+
+	_, err = m.GetCurrentPlan("nope")
+	assert.ErrorContains(t, err, "session not found")
+}
+
+func TestSessionManager_ObserverSeesAllSessions(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+
+	var mu sync.Mutex
+	seen := make(map[string][]string)
+	remove := m.Observe(func(sessionID string, n acp.SessionNotification) {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[sessionID] = append(seen[sessionID], n.Update.AgentMessageChunk.Content.Text.Text)
+	})
+
+	var perSession []string
+	_, err := m.Launch(context.Background(), "sess-1", "test-agent", v2.LaunchOpts{}, func(n acp.SessionNotification) {
+		perSession = append(perSession, n.Update.AgentMessageChunk.Content.Text.Text)
+	})
+	require.NoError(t, err)
+	_, err = m.Launch(context.Background(), "sess-2", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+
+	want := map[string][]string{"sess-1": {"session started"}, "sess-2": {"session started"}}
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return assert.ObjectsAreEqual(want, seen)
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"session started"}, perSession, "onEvent still runs")
+
+	remove()
+	remove()
+	_, err = m.Launch(context.Background(), "sess-3", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+	mu.Lock()
+	assert.NotContains(t, seen, "sess-3")
+	mu.Unlock()
+}
+
+func TestSessionManager_AddMCPServer(t *testing.T) {
+	server := acp.McpServer{Stdio: &acp.McpServerStdio{Name: "db", Command: "db-mcp", Args: []string{}, Env: []acp.EnvVariable{}}}
+
+	t.Run("live", func(t *testing.T) {
+		d := newFakeDriver("test-agent", driver.CapAddMCPServer)
+		d.launchSess = newFakeSession("sess-mcp", "test-agent")
+		d.launchSess.liveMCP = true
+		m := NewSessionManager(testLogger(), "", "", nil, d)
+
+		commands := make(chan []acp.AvailableCommand, 1)
+		defer m.Observe(func(_ string, n acp.SessionNotification) {
+			if u := n.Update.AvailableCommandsUpdate; u != nil {
+				commands <- u.AvailableCommands
+			}
+		})()
+		_, err := m.Launch(context.Background(), "sess-mcp", "test-agent", v2.LaunchOpts{}, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, m.AddMCPServer(context.Background(), "sess-mcp", server))
+		assert.Equal(t, []acp.McpServer{server}, d.launchSess.mcpServers)
+		select {
+		case cmds := <-commands:
+			assert.Equal(t, "db:query", cmds[0].Name)
+		case <-time.After(time.Second):
+			t.Fatal("no available commands refresh after adding the server")
+		}
+	})
+
+	t.Run("unsupported agent", func(t *testing.T) {
+		d := newFakeDriver("test-agent")
+		d.launchSess = newFakeSession("sess-fixed", "test-agent")
+		d.launchSess.liveMCP = true
+		m := NewSessionManager(testLogger(), "", "", nil, d)
+		_, err := m.Launch(context.Background(), "sess-fixed", "test-agent", v2.LaunchOpts{}, nil)
+		require.NoError(t, err)
+
+		err = m.AddMCPServer(context.Background(), "sess-fixed", server)
+		assert.ErrorIs(t, err, v2.ErrAddMCPServerUnsupported)
+		assert.Empty(t, d.launchSess.mcpServers)
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		m := NewSessionManager(testLogger(), "", "", nil, newFakeDriver("test-agent"))
+		assert.ErrorContains(t, m.AddMCPServer(context.Background(), "nope", server), "session not found")
+	})
+}
+
+func TestSessionManager_SetAllowedTools(t *testing.T) {
+	t.Run("live", func(t *testing.T) {
+		d := newFakeDriver("test-agent", driver.CapSetAllowedTools)
+		d.launchSess = newFakeSession("sess-tools", "test-agent")
+		m := NewSessionManager(testLogger(), "", "", nil, d)
+		_, err := m.Launch(context.Background(), "sess-tools", "test-agent", v2.LaunchOpts{AllowedTools: []string{"Read"}}, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, m.SetAllowedTools(context.Background(), "sess-tools", []string{"Read", "Bash"}))
+		assert.Equal(t, []string{"Read", "Bash"}, d.launchSess.allowedTools)
+	})
+
+	t.Run("unsupported agent", func(t *testing.T) {
+		d := newFakeDriver("test-agent")
+		d.launchSess = newFakeSession("sess-fixed", "test-agent")
+		m := NewSessionManager(testLogger(), "", "", nil, d)
+		_, err := m.Launch(context.Background(), "sess-fixed", "test-agent", v2.LaunchOpts{}, nil)
+		require.NoError(t, err)
+
+		err = m.SetAllowedTools(context.Background(), "sess-fixed", []string{"Bash"})
+		assert.ErrorIs(t, err, v2.ErrSetAllowedToolsUnsupported)
+		assert.Nil(t, d.launchSess.allowedTools)
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		m := NewSessionManager(testLogger(), "", "", nil, newFakeDriver("test-agent"))
+		assert.ErrorContains(t, m.SetAllowedTools(context.Background(), "nope", nil), "session not found")
+	})
+}
+
+func TestSessionManager_SetHostCommands(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	d.launchSess = newFakeSession("sess-cmds", "test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	_, err := m.Launch(context.Background(), "sess-cmds", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+
+	cmds := []acp.AvailableCommand{{Name: "deploy", Description: "deploy the branch"}}
+	require.NoError(t, m.SetHostCommands(context.Background(), "sess-cmds", cmds))
+	assert.Equal(t, cmds, d.launchSess.hostCommands)
+
+	assert.ErrorContains(t, m.SetHostCommands(context.Background(), "nope", cmds), "session not found")
+}
+
+func TestSessionManager_LaunchChecksRequiredCapabilities(t *testing.T) {
+	d := newFakeDriver("painter", driver.CapStreaming)
+	d.caps.Extensions = []string{"image_generation"}
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+
+	_, err := m.Launch(context.Background(), "sess-web", "painter", v2.LaunchOpts{RequiredCapabilities: []string{"web_search"}}, nil)
+	assert.ErrorContains(t, err, "agent painter does not support web_search")
+
+	_, err = m.Launch(context.Background(), "sess-img", "painter", v2.LaunchOpts{RequiredCapabilities: []string{"streaming", "image_generation"}}, nil)
+	require.NoError(t, err)
+}
+
+func TestSessionManager_MetricsLaunchError(t *testing.T) {
+	d := &errDriver{id: "broken"}
+	mtr := newFakeMetrics()
+	m := NewSessionManager(testLogger(), "", "", mtr, d)
+
+	_, err := m.Launch(context.Background(), "sess-err", "broken", v2.LaunchOpts{}, nil)
+	require.Error(t, err)
+	assert.Equal(t, 1.0, mtr.value(metrics.Errors, metrics.Labels{"agent": "broken", "op": "launch"}))
+	assert.Equal(t, 0.0, mtr.value(metrics.SessionsLaunched, metrics.Labels{"agent": "broken"}))
+}
+
+func TestSessionManager_Labels(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+
+	labels := map[string]string{"project": "foo", "branch": "main"}
+	_, err := m.Launch(context.Background(), "sess-a", "test-agent", v2.LaunchOpts{Labels: labels}, nil)
+	require.NoError(t, err)
+	_, err = m.Launch(context.Background(), "sess-b", "test-agent", v2.LaunchOpts{
+		Labels: map[string]string{"project": "foo", "branch": "dev"},
+	}, nil)
+	require.NoError(t, err)
+	_, err = m.Launch(context.Background(), "sess-c", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+
+	// Labels are copied at launch; later caller mutations don't leak in.
+	labels["branch"] = "mutated"
+
+	ids := func(sel string) []string {
+		s, err := ParseLabelSelector(sel)
+		require.NoError(t, err)
+		var out []string
+		for _, e := range m.ListSessions(s) {
+			out = append(out, e.SessionID)
+		}
+		return out
+	}
+	assert.ElementsMatch(t, []string{"sess-a", "sess-b", "sess-c"}, ids(""))
+	assert.ElementsMatch(t, []string{"sess-a", "sess-b"}, ids("project=foo"))
+	assert.ElementsMatch(t, []string{"sess-a"}, ids("project=foo,branch=main"))
+	assert.Empty(t, ids("project=bar"))
+
+	var snap *SessionSnapshot
+	for _, s := range m.GetStateSnapshot() {
+		if s.SessionID == "sess-a" {
+			snap = &s
+		}
+	}
+	require.NotNil(t, snap)
+	assert.Equal(t, map[string]string{"project": "foo", "branch": "main"}, snap.Labels)
+	assert.Equal(t, snap.Labels, sessionSnapshotToProto(*snap).Labels)
+}
+
+func TestSessionManager_AutoTopic(t *testing.T) {
+	t.Run("derives topic after first turn", func(t *testing.T) {
+		d := newFakeDriver("test-agent")
+		d.launchSess = newFakeSession("sess-auto", "test-agent")
+		d.launchSess.promptReply = "Sure, I'll add pagination to the task list endpoint."
+		m := NewSessionManager(testLogger(), "", "", nil, d)
+
+		_, err := m.Launch(context.Background(), "sess-auto", "test-agent", v2.LaunchOpts{AutoTopic: true}, nil)
+		require.NoError(t, err)
+		_, err = m.Prompt(context.Background(), "sess-auto", []acp.ContentBlock{acp.TextBlock("hi")})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			snaps := m.GetStateSnapshot()
+			return len(snaps) == 1 && snaps[0].Topic == "Add pagination to the task list endpoint"
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("keeps topic set by the agent", func(t *testing.T) {
+		d := newFakeDriver("test-agent")
+		d.launchSess = newFakeSession("sess-agent-topic", "test-agent")
+		d.launchSess.promptReply = "Working on it."
+		m := NewSessionManager(testLogger(), "", "", nil, d)
+
+		_, err := m.Launch(context.Background(), "sess-agent-topic", "test-agent", v2.LaunchOpts{AutoTopic: true}, nil)
+		require.NoError(t, err)
+		require.NoError(t, m.HandleSetTopic(context.Background(), "sess-agent-topic", "Agent topic"))
+		_, err = m.Prompt(context.Background(), "sess-agent-topic", []acp.ContentBlock{acp.TextBlock("Rename the config package")})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			for _, e := range m.PendingEvents("sess-agent-topic", 0) {
+				if sc := e.GetStatusChange(); sc != nil && sc.Status == workerv1.SessionStatus_SESSION_STATUS_IDLE {
+					return true
+				}
+			}
+			return false
+		}, time.Second, 10*time.Millisecond)
+		assert.Equal(t, "Agent topic", m.GetStateSnapshot()[0].Topic)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		d := newFakeDriver("test-agent")
+		d.launchSess = newFakeSession("sess-no-auto", "test-agent")
+		d.launchSess.promptReply = "Done."
+		m := NewSessionManager(testLogger(), "", "", nil, d)
+
+		_, err := m.Launch(context.Background(), "sess-no-auto", "test-agent", v2.LaunchOpts{}, nil)
+		require.NoError(t, err)
+		_, err = m.Prompt(context.Background(), "sess-no-auto", []acp.ContentBlock{acp.TextBlock("Rename the config package")})
+		require.NoError(t, err)
+
+		require.Eventually(t, func() bool {
+			for _, e := range m.PendingEvents("sess-no-auto", 0) {
+				if e.GetStatusChange() != nil {
+					return true
+				}
+			}
+			return false
+		}, time.Second, 10*time.Millisecond)
+		assert.Empty(t, m.GetStateSnapshot()[0].Topic)
+	})
+}
+
+func TestSessionManager_StatusChangeAcceptingInput(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	d.launchSess = newFakeSession("sess-input", "test-agent")
+	d.launchSess.promptReply = "Done."
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+
+	_, err := m.Launch(context.Background(), "sess-input", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+	_, err = m.Prompt(context.Background(), "sess-input", []acp.ContentBlock{acp.TextBlock("hi")})
+	require.NoError(t, err)
+
+	type change struct {
+		status         workerv1.SessionStatus
+		acceptingInput bool
+	}
+	var changes []change
+	require.Eventually(t, func() bool {
+		changes = nil
+		for _, e := range m.PendingEvents("sess-input", 0) {
+			if sc := e.GetStatusChange(); sc != nil {
+				changes = append(changes, change{sc.GetStatus(), sc.GetAcceptingInput()})
+			}
+		}
+		return len(changes) == 2
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, []change{
+		{workerv1.SessionStatus_SESSION_STATUS_RUNNING, false},
+		{workerv1.SessionStatus_SESSION_STATUS_IDLE, true},
+	}, changes)
+
+	assert.False(t, statusChangeToProto(v2.SessionStatusIdle, v2.SessionInfo{}, true).GetAcceptingInput(), "a soft-stopping session takes no prompts")
+	assert.False(t, statusChangeToProto(v2.SessionStatusStopped, v2.SessionInfo{}, false).GetAcceptingInput())
+}
+
+func TestSessionManager_SetSessionModel(t *testing.T) {
+	t.Run("switches model and announces it", func(t *testing.T) {
+		d := newFakeDriver("test-agent", driver.CapCustomModel)
+		m := NewSessionManager(testLogger(), "", "", nil, d)
+		_, err := m.Launch(context.Background(), "sess-1", "test-agent", v2.LaunchOpts{}, nil)
+		require.NoError(t, err)
+
+		require.NoError(t, m.SetSessionModel(context.Background(), "sess-1", "opus"))
+
+		var got string
+		for _, e := range m.PendingEvents("sess-1", 0) {
+			if u := e.GetCurrentModelUpdate(); u != nil {
+				got = u.ModelId
+			}
+		}
+		assert.Equal(t, "opus", got)
+		assert.Equal(t, "opus", m.GetStateSnapshot()[0].Info.CurrentModel)
+	})
+
+	t.Run("rejects without capability", func(t *testing.T) {
+		d := newFakeDriver("test-agent")
+		m := NewSessionManager(testLogger(), "", "", nil, d)
+		_, err := m.Launch(context.Background(), "sess-1", "test-agent", v2.LaunchOpts{}, nil)
+		require.NoError(t, err)
+
+		assert.ErrorContains(t, m.SetSessionModel(context.Background(), "sess-1", "opus"), "does not support custom model")
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		m := NewSessionManager(testLogger(), "", "", nil)
+		assert.ErrorContains(t, m.SetSessionModel(context.Background(), "missing", "opus"), "session not found")
+	})
+}
+
+func TestSessionManager_PromptWrap(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	d.launchSess = newFakeSession("sess-wrap", "test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	m.promptWraps = PromptWraps{Default: PromptWrap{Prefix: "Follow the style guide.", Suffix: "Always run tests."}}
+
+	_, err := m.Launch(context.Background(), "sess-wrap", "test-agent", v2.LaunchOpts{
+		Prompt:       "Fix the login bug",
+		AutoContinue: v2.AutoContinue{MaxIterations: 3},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "Follow the style guide.\n\nFix the login bug\n\nAlways run tests.", d.lastOpts.Prompt)
+	require.NotNil(t, d.lastOpts.AutoContinue.Wrap, "auto-continue prompts are wrapped too")
+	assert.Equal(t, "Follow the style guide.\n\nContinue.\n\nAlways run tests.", d.lastOpts.AutoContinue.Wrap("Continue."))
+
+	_, err = m.Prompt(context.Background(), "sess-wrap", []acp.ContentBlock{acp.TextBlock("Now add a test")})
+	require.NoError(t, err)
+	_, err = m.Prompt(context.Background(), "sess-wrap", []acp.ContentBlock{acp.TextBlock("/compact")})
+	require.NoError(t, err)
+
+	prompts := d.launchSess.prompts
+	require.Len(t, prompts, 2)
+	assert.Equal(t, "Follow the style guide.\n\nNow add a test\n\nAlways run tests.", prompts[0][0].Text.Text)
+	assert.Equal(t, "/compact", prompts[1][0].Text.Text, "slash commands pass through")
+
+	// Clients only ever see the prompts as typed.
+	var userMessages []string
+	for _, e := range m.PendingEvents("sess-wrap", 0) {
+		if um := e.GetUserMessage(); um != nil {
+			userMessages = append(userMessages, um.Text)
+		}
+	}
+	assert.Equal(t, []string{"Fix the login bug", "Now add a test", "/compact"}, userMessages)
+}
+
+func TestSessionManager_PromptWrapAgentOverride(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	d.launchSess = newFakeSession("sess-override", "test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	m.promptWraps = PromptWraps{
+		Default: PromptWrap{Suffix: "default"},
+		Agents:  map[string]PromptWrap{"test-agent": {Prefix: "agent"}},
+	}
+
+	_, err := m.Launch(context.Background(), "sess-override", "test-agent", v2.LaunchOpts{Prompt: "hi"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "agent\n\nhi", d.lastOpts.Prompt)
+}
+
+func TestSessionManager_ToolPolicy(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	d.launchSess = newFakeSession("sess-tools", "test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	m.toolPolicies = map[string]ToolPolicy{
+		"test-agent":  {DisallowedTools: []string{"WebFetch", "Bash"}, PlanModeAllowedTools: []string{"WebSearch"}},
+		"other-agent": {DisallowedTools: []string{"Edit"}},
+	}
+
+	_, err := m.Launch(context.Background(), "sess-tools", "test-agent", v2.LaunchOpts{
+		DisallowedTools: []string{"Bash", "Task"},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Bash", "Task", "WebFetch"}, d.lastOpts.DisallowedTools, "config merges with request values")
+	assert.Equal(t, []string{"WebSearch"}, d.lastOpts.PlanModeAllowedTools)
+	assert.Nil(t, d.lastOpts.ToolKindPermissions)
+
+	m.toolPolicies["test-agent"] = ToolPolicy{KindPermissions: map[string]string{"read": "allow", "execute": "deny"}}
+	d.launchSess = newFakeSession("sess-tools-2", "test-agent")
+	_, err = m.Launch(context.Background(), "sess-tools-2", "test-agent", v2.LaunchOpts{
+		ToolKindPermissions: map[string]string{"execute": "ask"},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"read": "allow", "execute": "ask"}, d.lastOpts.ToolKindPermissions, "request values win")
+}
+
+func TestSessionManager_MCPServerCatalog(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	d.launchSess = newFakeSession("sess-mcp", "test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	catalogFS := acp.McpServer{Stdio: &acp.McpServerStdio{Name: "fs", Command: "mcp-fs"}}
+	docs := acp.McpServer{Sse: &acp.McpServerSse{Name: "docs", Type: "sse", Url: "https://docs.example.com/sse"}}
+	m.mcpServers = []acp.McpServer{catalogFS, docs}
+
+	ownFS := acp.McpServer{Stdio: &acp.McpServerStdio{Name: "fs", Command: "my-fs"}}
+	_, err := m.Launch(context.Background(), "sess-mcp", "test-agent", v2.LaunchOpts{MCPServers: []acp.McpServer{ownFS}}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []acp.McpServer{ownFS, docs}, d.lastOpts.MCPServers, "the launch's own servers win")
+}
+
+func TestSessionManager_ResourceLimits(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	d.launchSess = newFakeSession("sess-limits", "test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+	m.resourceLimits = map[string]procutil.ResourceLimits{
+		"test-agent": {MemoryBytes: 2 << 30, CPUQuota: 2},
+	}
+
+	_, err := m.Launch(context.Background(), "sess-limits", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, procutil.ResourceLimits{MemoryBytes: 2 << 30, CPUQuota: 2}, d.lastOpts.ResourceLimits, "configured limits apply")
+
+	d.launchSess = newFakeSession("sess-limits-2", "test-agent")
+	_, err = m.Launch(context.Background(), "sess-limits-2", "test-agent", v2.LaunchOpts{
+		ResourceLimits: procutil.ResourceLimits{MemoryBytes: 1 << 30},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, procutil.ResourceLimits{MemoryBytes: 1 << 30}, d.lastOpts.ResourceLimits, "launch limits win")
+}
+
+func TestSessionManager_AuthFailureEmitsSessionError(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	d.launchSess = newFakeSession("sess-auth", "test-agent")
+	d.launchSess.info.Error = &v2.SessionError{Reason: v2.ErrorReasonAuth, Message: "Invalid API key · Please run /login"}
+	d.launchStatuses = []v2.SessionStatus{v2.SessionStatusErrored}
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+
+	_, err := m.Launch(context.Background(), "sess-auth", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+
+	var events []*workerv1.SessionEvent
+	require.Eventually(t, func() bool {
+		events = m.PendingEvents("sess-auth", 0)
+		for _, e := range events {
+			if e.GetStatusChange().GetStatus() == workerv1.SessionStatus_SESSION_STATUS_ERRORED {
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+
+	var sessionError *workerv1.SessionError
+	for _, e := range events {
+		if se := e.GetSessionError(); se != nil {
+			sessionError = se
+			break
+		}
+		assert.Nil(t, e.GetStatusChange(), "error is emitted before the status change")
+	}
+	require.NotNil(t, sessionError)
+	assert.Equal(t, workerv1.SessionErrorReason_SESSION_ERROR_REASON_AUTH, sessionError.Reason)
+	assert.Equal(t, "Invalid API key · Please run /login", sessionError.Message)
+}
+
+func TestSessionManager_ErroredStatusCarriesError(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	d.launchSess = newFakeSession("sess-err", "test-agent")
+	d.launchSess.info.Error = &v2.SessionError{Message: "new session: model not found"}
+	d.launchStatuses = []v2.SessionStatus{v2.SessionStatusErrored}
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+
+	_, err := m.Launch(context.Background(), "sess-err", "test-agent", v2.LaunchOpts{}, nil)
+	require.NoError(t, err)
+
+	var statusChange *workerv1.StatusChange
+	require.Eventually(t, func() bool {
+		for _, e := range m.PendingEvents("sess-err", 0) {
+			if sc := e.GetStatusChange(); sc.GetStatus() == workerv1.SessionStatus_SESSION_STATUS_ERRORED {
+				statusChange = sc
+				return true
+			}
+		}
+		return false
+	}, time.Second, 10*time.Millisecond)
+	require.NotNil(t, statusChange.GetError())
+	assert.Equal(t, "new session: model not found", statusChange.GetError().GetMessage())
+	assert.Equal(t, workerv1.SessionErrorReason_SESSION_ERROR_REASON_UNSPECIFIED, statusChange.GetError().GetReason())
+
+	snaps := m.GetStateSnapshot()
+	require.Len(t, snaps, 1)
+	assert.Equal(t, "new session: model not found", sessionSnapshotToProto(snaps[0]).GetError().GetMessage())
+}
+
+func TestSessionManager_EmitsPermissionEvents(t *testing.T) {
+	d := newFakeDriver("test-agent")
+	d.launchSess = newFakeSession("sess-perm", "test-agent")
+	m := NewSessionManager(testLogger(), "", "", nil, d)
+
+	var forwarded []v2.PermissionEvent
+	_, err := m.Launch(context.Background(), "sess-perm", "test-agent", v2.LaunchOpts{
+		OnPermission: func(e v2.PermissionEvent) { forwarded = append(forwarded, e) },
+	}, nil)
+	require.NoError(t, err)
+	require.NotNil(t, d.lastOpts.OnPermission)
+
+	title := "rm -rf build"
+	kind := acp.ToolKindExecute
+	d.lastOpts.OnPermission(v2.PermissionEvent{
+		SessionID: "agent-sess",
+		RequestID: "call-1",
+		ToolCall:  acp.RequestPermissionToolCall{ToolCallId: "call-1", Title: &title, Kind: &kind},
+		Options: []acp.PermissionOption{
+			{OptionId: "allow", Name: "Allow", Kind: acp.PermissionOptionKindAllowOnce},
+			{OptionId: "reject", Name: "Reject", Kind: acp.PermissionOptionKindRejectOnce},
+		},
+	})
+	d.lastOpts.OnPermission(v2.PermissionEvent{
+		SessionID: "agent-sess",
+		RequestID: "call-1",
+		Resolved:  true,
+		OptionID:  "allow",
+		Outcome:   "allowed",
+	})
+
+	var requested *workerv1.PermissionRequest
+	var resolved *workerv1.PermissionResolved
+	for _, e := range m.PendingEvents("sess-perm", 0) {
+		if r := e.GetPermissionRequest(); r != nil {
+			requested = r
+		}
+		if r := e.GetPermissionResolved(); r != nil {
+			require.NotNil(t, requested, "resolved follows requested")
+			resolved = r
+		}
+	}
+	require.NotNil(t, requested)
+	assert.Equal(t, "call-1", requested.RequestId)
+	assert.Equal(t, "rm -rf build", requested.Title)
+	assert.Equal(t, workerv1.ToolCallKind_TOOL_CALL_KIND_EXECUTE, requested.Kind)
+	require.Len(t, requested.Options, 2)
+	assert.Equal(t, "allow", requested.Options[0].OptionId)
+	assert.Equal(t, "Allow", requested.Options[0].Name)
+	assert.Equal(t, "allow_once", requested.Options[0].Kind)
+
+	require.NotNil(t, resolved)
+	assert.Equal(t, "call-1", resolved.RequestId)
+	assert.Equal(t, "allow", resolved.OptionId)
+	assert.Equal(t, "allowed", resolved.Outcome)
+
+	assert.Len(t, forwarded, 2, "caller's callback still runs")
 }
@@ -25,24 +25,58 @@ func (s *WorkloadService) GetStateSnapshot() []SessionSnapshot {
 	return s.mgr.GetStateSnapshot()
 }
 
+// InstanceID returns the ID of this run of the worker.
+func (s *WorkloadService) InstanceID() string {
+	return s.mgr.InstanceID()
+}
+
 // HandleSetTopic updates the topic for the given session.
 func (s *WorkloadService) HandleSetTopic(ctx context.Context, sessionID, topic string) error {
 	return s.mgr.HandleSetTopic(ctx, sessionID, topic)
 }
 
+// SessionInfo returns the current info of a running session.
+func (s *WorkloadService) SessionInfo(sessionID string) (v2.SessionInfo, bool) {
+	sess, ok := s.mgr.GetSession(sessionID)
+	if !ok {
+		return v2.SessionInfo{}, false
+	}
+	return sess.Info(), true
+}
+
 // SetSessionMode changes the permission mode of a running session.
 func (s *WorkloadService) SetSessionMode(ctx context.Context, sessionID string, mode driver.SessionMode) error {
 	return s.mgr.SetSessionMode(ctx, sessionID, mode)
 }
 
+// SetSessionModel switches the model of a running session.
+func (s *WorkloadService) SetSessionModel(ctx context.Context, sessionID, model string) error {
+	return s.mgr.SetSessionModel(ctx, sessionID, model)
+}
+
+// SetAllowedTools replaces the allowed tools of a running session.
+func (s *WorkloadService) SetAllowedTools(ctx context.Context, sessionID string, tools []string) error {
+	return s.mgr.SetAllowedTools(ctx, sessionID, tools)
+}
+
+// SetHostCommands replaces the host-provided commands of a running session.
+func (s *WorkloadService) SetHostCommands(ctx context.Context, sessionID string, cmds []acp.AvailableCommand) error {
+	return s.mgr.SetHostCommands(ctx, sessionID, cmds)
+}
+
+// GetCurrentPlan returns the current plan of a running session's agent.
+func (s *WorkloadService) GetCurrentPlan(sessionID string) ([]acp.PlanEntry, error) {
+	return s.mgr.GetCurrentPlan(sessionID)
+}
+
 // Prompt sends a follow-up prompt to a running session.
 func (s *WorkloadService) Prompt(ctx context.Context, sessionID string, blocks []acp.ContentBlock) (*acp.PromptResponse, error) {
 	return s.mgr.Prompt(ctx, sessionID, blocks)
 }
 
 // Cancel cancels the active prompt on a running session.
-func (s *WorkloadService) Cancel(ctx context.Context, sessionID string) error {
-	return s.mgr.Cancel(ctx, sessionID)
+func (s *WorkloadService) Cancel(ctx context.Context, sessionID string, reason workerv1.CancelReason) error {
+	return s.mgr.Cancel(ctx, sessionID, reason)
 }
 
 // SubscribeEvents returns a channel that receives session events.
@@ -60,6 +94,12 @@ func (s *WorkloadService) AllPendingEvents() map[string][]*workerv1.SessionEvent
 	return s.mgr.AllPendingEvents()
 }
 
+// PendingEvents returns the un-acknowledged events of a session with a
+// sequence above afterSeq.
+func (s *WorkloadService) PendingEvents(sessionID string, afterSeq int64) []*workerv1.SessionEvent {
+	return s.mgr.PendingEvents(sessionID, afterSeq)
+}
+
 // AckEvents drops all events up to the given sequence for a session.
 func (s *WorkloadService) AckEvents(sessionID string, sequence int64) {
 	s.mgr.AckEvents(sessionID, sequence)
@@ -89,9 +129,9 @@ func NewWorkloadService(mgr *SessionManager) *WorkloadService {
 	return &WorkloadService{mgr: mgr}
 }
 
-// ListSessions returns info for all active sessions.
-func (s *WorkloadService) ListSessions(_ context.Context) []SessionListEntry {
-	return s.mgr.ListSessions()
+// ListSessions returns info for all active sessions matching sel.
+func (s *WorkloadService) ListSessions(_ context.Context, sel LabelSelector) []SessionListEntry {
+	return s.mgr.ListSessions(sel)
 }
 
 // Schedule launches a workload via the SessionManager.